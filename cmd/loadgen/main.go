@@ -0,0 +1,410 @@
+package main
+
+// ===========================================
+// DePIN BNB Load Generator
+// ===========================================
+// Simulates many local provers hammering a verification server at once so we
+// can see how the store and verifier hold up before launch.
+//
+// Each simulated prover gets a fresh wallet key, registers, and then loops:
+// request challenge -> answer it from a node -> sign -> submit.
+//
+// Answers come from a mock node. For challenges to pass, the server's
+// TRUSTED_RPC has to point at that same mock node:
+//
+//   go run cmd/loadgen/main.go --mock-only --mock-listen 127.0.0.1:18545
+//   TRUSTED_RPC=http://127.0.0.1:18545 go run cmd/server/main.go
+//   go run cmd/loadgen/main.go --node-rpc http://127.0.0.1:18545 --provers 50
+//
+// Or point --node-rpc at a real synced node to use real chain data.
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/mockchain"
+	"github.com/depinonbnb/depin/internal/normalize"
+	"github.com/depinonbnb/depin/internal/rpc"
+	"github.com/depinonbnb/depin/internal/signing"
+	"github.com/depinonbnb/depin/internal/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+type Config struct {
+	APIEndpoint string
+	NodeRPC     string
+	NodeType    types.NodeType
+	Provers     int
+	Rounds      int
+	Duration    time.Duration
+	Pause       time.Duration
+}
+
+// Latency samples for one kind of operation
+type opStats struct {
+	name    string
+	samples []time.Duration
+	errors  uint64
+	mu      sync.Mutex
+}
+
+func newOpStats(name string) *opStats {
+	return &opStats{name: name}
+}
+
+func (o *opStats) record(d time.Duration, err error) {
+	if err != nil {
+		atomic.AddUint64(&o.errors, 1)
+		return
+	}
+	o.mu.Lock()
+	o.samples = append(o.samples, d)
+	o.mu.Unlock()
+}
+
+func (o *opStats) percentile(p float64) time.Duration {
+	if len(o.samples) == 0 {
+		return 0
+	}
+	idx := int(float64(len(o.samples)-1) * p)
+	return o.samples[idx]
+}
+
+type LoadGen struct {
+	config   Config
+	client   *http.Client
+	nodeRPC  *rpc.Client
+	register *opStats
+	request  *opStats
+	answer   *opStats
+	submit   *opStats
+	passed   uint64
+	failed   uint64
+	reasons  map[string]int
+	mu       sync.Mutex
+}
+
+func NewLoadGen(config Config) *LoadGen {
+	return &LoadGen{
+		config:   config,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		nodeRPC:  rpc.NewClient(config.NodeRPC, "").WithChain(config.NodeType.Chain()),
+		register: newOpStats("register"),
+		request:  newOpStats("request"),
+		answer:   newOpStats("answer"),
+		submit:   newOpStats("submit"),
+		reasons:  make(map[string]int),
+	}
+}
+
+// One simulated prover
+type simProver struct {
+	lg         *LoadGen
+	privateKey *ecdsa.PrivateKey
+	address    string
+	nodeID     string
+}
+
+func (lg *LoadGen) Run() time.Duration {
+	var wg sync.WaitGroup
+	deadline := time.Time{}
+	if lg.config.Duration > 0 {
+		deadline = time.Now().Add(lg.config.Duration)
+	}
+
+	start := time.Now()
+	for i := 0; i < lg.config.Provers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			key, err := crypto.GenerateKey()
+			if err != nil {
+				log.Printf("failed to generate key: %v", err)
+				return
+			}
+			p := &simProver{
+				lg:         lg,
+				privateKey: key,
+				address:    crypto.PubkeyToAddress(key.PublicKey).Hex(),
+			}
+
+			t := time.Now()
+			err = p.registerNode()
+			lg.register.record(time.Since(t), err)
+			if err != nil {
+				return
+			}
+
+			for round := 0; ; round++ {
+				if deadline.IsZero() && round >= lg.config.Rounds {
+					return
+				}
+				if !deadline.IsZero() && time.Now().After(deadline) {
+					return
+				}
+				p.runRound()
+				if lg.config.Pause > 0 {
+					time.Sleep(lg.config.Pause)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return time.Since(start)
+}
+
+func (p *simProver) signMessage(message string) (string, error) {
+	prefixedMessage := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message)
+	hash := crypto.Keccak256Hash([]byte(prefixedMessage))
+
+	sig, err := crypto.Sign(hash.Bytes(), p.privateKey)
+	if err != nil {
+		return "", err
+	}
+	sig[64] += 27
+
+	return "0x" + hex.EncodeToString(sig), nil
+}
+
+func (p *simProver) post(path string, body interface{}, out interface{}) error {
+	jsonBody, _ := json.Marshal(body)
+	resp, err := p.lg.client.Post(p.lg.config.APIEndpoint+path, "application/json", bytes.NewReader(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("%s returned %d: %s", path, resp.StatusCode, string(respBody))
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+func (p *simProver) registerNode() error {
+	timestamp := time.Now().UnixMilli()
+	message := fmt.Sprintf("Register node\nWallet: %s\nType: %s\nTimestamp: %d", p.address, p.lg.config.NodeType, timestamp)
+	signature, err := p.signMessage(message)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		NodeID string `json:"node_id"`
+	}
+	err = p.post("/nodes/register", map[string]interface{}{
+		"wallet_address":      p.address,
+		"node_type":           p.lg.config.NodeType,
+		"verification_method": types.LocalProver,
+		"signature":           signature,
+		"timestamp":           timestamp,
+	}, &result)
+	if err != nil {
+		return err
+	}
+
+	p.nodeID = result.NodeID
+	return nil
+}
+
+func (p *simProver) runRound() {
+	lg := p.lg
+
+	// Step 1: request a challenge
+	t := time.Now()
+	timestamp := t.UnixMilli()
+	signature, err := p.signMessage(fmt.Sprintf("Request challenge\nNode: %s\nTimestamp: %d", p.nodeID, timestamp))
+	if err != nil {
+		lg.request.record(time.Since(t), err)
+		return
+	}
+	query := url.Values{}
+	query.Set("nodeId", p.nodeID)
+	query.Set("timestamp", fmt.Sprintf("%d", timestamp))
+	query.Set("signature", signature)
+
+	resp, err := lg.client.Get(lg.config.APIEndpoint + "/challenges/request?" + query.Encode())
+	if err != nil {
+		lg.request.record(time.Since(t), err)
+		return
+	}
+	var challengeResp struct {
+		Challenge struct {
+			ID            string                `json:"id"`
+			ChallengeType types.ChallengeType   `json:"challenge_type"`
+			Params        types.ChallengeParams `json:"params"`
+		} `json:"challenge"`
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		lg.request.record(time.Since(t), fmt.Errorf("status %d", resp.StatusCode))
+		return
+	}
+	if err := json.Unmarshal(body, &challengeResp); err != nil {
+		lg.request.record(time.Since(t), err)
+		return
+	}
+	lg.request.record(time.Since(t), nil)
+
+	// Step 2: answer it from the node
+	t = time.Now()
+	challenge := &types.Challenge{
+		ID:            challengeResp.Challenge.ID,
+		ChallengeType: challengeResp.Challenge.ChallengeType,
+		Params:        challengeResp.Challenge.Params,
+	}
+	nodeResponse := lg.nodeRPC.ExecuteChallenge(challenge)
+	queryTime := time.Since(t)
+	if !nodeResponse.Success {
+		lg.answer.record(queryTime, fmt.Errorf("%s", nodeResponse.Error))
+		return
+	}
+	lg.answer.record(queryTime, nil)
+	nodeResponse.Data = normalize.ChallengeAnswer(challenge, nodeResponse.Data)
+
+	// Step 3: sign and submit
+	timestamp = time.Now().UnixMilli()
+	message, _ := signing.AnswerMessage(signing.AnswerV2, challengeResp.Challenge.ID, p.nodeID, challengeResp.Challenge.ChallengeType, nodeResponse.Data, "", timestamp)
+	signature, err = p.signMessage(message)
+	if err != nil {
+		lg.submit.record(0, err)
+		return
+	}
+
+	var result struct {
+		Passed        bool   `json:"passed"`
+		FailureReason string `json:"failure_reason"`
+	}
+	t = time.Now()
+	err = p.post("/challenges/submit", map[string]interface{}{
+		"challenge_id":     challengeResp.Challenge.ID,
+		"node_id":          p.nodeID,
+		"answer":           nodeResponse.Data,
+		"signature":        signature,
+		"response_time_ms": queryTime.Milliseconds(),
+		"timestamp":        timestamp,
+		"version":          signing.AnswerV2,
+		"challenge_type":   challengeResp.Challenge.ChallengeType,
+	}, &result)
+	lg.submit.record(time.Since(t), err)
+	if err != nil {
+		return
+	}
+
+	if result.Passed {
+		atomic.AddUint64(&lg.passed, 1)
+	} else {
+		atomic.AddUint64(&lg.failed, 1)
+		lg.mu.Lock()
+		lg.reasons[result.FailureReason]++
+		lg.mu.Unlock()
+	}
+}
+
+func (lg *LoadGen) Report(elapsed time.Duration) {
+	fmt.Println("============================================================")
+	fmt.Println("Load Test Results")
+	fmt.Println("============================================================")
+	fmt.Printf("Provers: %d  Elapsed: %s\n", lg.config.Provers, elapsed.Round(time.Millisecond))
+	fmt.Println("")
+	fmt.Printf("%-10s %8s %8s %10s %10s %10s %10s %10s\n", "op", "ok", "errors", "req/s", "p50", "p90", "p99", "max")
+
+	for _, op := range []*opStats{lg.register, lg.request, lg.answer, lg.submit} {
+		op.mu.Lock()
+		sort.Slice(op.samples, func(i, j int) bool { return op.samples[i] < op.samples[j] })
+		count := len(op.samples)
+		throughput := float64(count) / elapsed.Seconds()
+		fmt.Printf("%-10s %8d %8d %10.1f %10s %10s %10s %10s\n",
+			op.name, count, atomic.LoadUint64(&op.errors), throughput,
+			op.percentile(0.50).Round(time.Microsecond),
+			op.percentile(0.90).Round(time.Microsecond),
+			op.percentile(0.99).Round(time.Microsecond),
+			op.percentile(1.0).Round(time.Microsecond))
+		op.mu.Unlock()
+	}
+
+	passed := atomic.LoadUint64(&lg.passed)
+	failed := atomic.LoadUint64(&lg.failed)
+	fmt.Println("")
+	fmt.Printf("Challenges passed: %d  failed: %d\n", passed, failed)
+	for reason, count := range lg.reasons {
+		fmt.Printf("  %-40s %d\n", reason, count)
+	}
+	fmt.Println("============================================================")
+}
+
+func main() {
+	apiEndpoint := flag.String("api", "http://localhost:3000/api", "DePIN API endpoint to load test")
+	nodeRPC := flag.String("node-rpc", "", "Node RPC used to answer challenges (default: built-in mock node)")
+	mockListen := flag.String("mock-listen", "127.0.0.1:18545", "Address for the built-in mock node")
+	nodeType := flag.String("node-type", "bsc-full", "Node type the simulated provers register as")
+	provers := flag.Int("provers", 20, "Number of concurrent simulated provers")
+	rounds := flag.Int("rounds", 10, "Challenges per prover (ignored if --duration is set)")
+	duration := flag.Duration("duration", 0, "Run for this long instead of a fixed number of rounds")
+	pause := flag.Duration("pause", 0, "Pause between rounds for each prover")
+	mockOnly := flag.Bool("mock-only", false, "Only serve the mock node (until Ctrl-C), don't generate load")
+	testnet := flag.Bool("testnet", false, "Make the mock node report testnet chain IDs, for servers run with NETWORK=testnet")
+
+	flag.Parse()
+
+	if *provers <= 0 {
+		fmt.Println("ERROR: --provers must be at least 1")
+		os.Exit(1)
+	}
+
+	// Start the mock node if we aren't using a real one
+	if *nodeRPC == "" || *mockOnly {
+		listener, err := net.Listen("tcp", *mockListen)
+		if err != nil {
+			log.Fatalf("failed to start mock node: %v", err)
+		}
+		chain := mockchain.New(46000000)
+		if *testnet {
+			chain.SetChainID(types.NodeType(*nodeType).Chain().ChainID(types.Testnet))
+		}
+		go http.Serve(listener, chain)
+		*nodeRPC = "http://" + listener.Addr().String()
+		fmt.Printf("Mock node listening on %s\n", *nodeRPC)
+		fmt.Println("(the server's TRUSTED_RPC must be this address for answers to match)")
+
+		if *mockOnly {
+			sigCh := make(chan os.Signal, 1)
+			signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+			<-sigCh
+			return
+		}
+	}
+
+	lg := NewLoadGen(Config{
+		APIEndpoint: *apiEndpoint,
+		NodeRPC:     *nodeRPC,
+		NodeType:    types.NodeType(*nodeType),
+		Provers:     *provers,
+		Rounds:      *rounds,
+		Duration:    *duration,
+		Pause:       *pause,
+	})
+
+	fmt.Printf("Running %d provers against %s...\n", *provers, *apiEndpoint)
+	elapsed := lg.Run()
+	lg.Report(elapsed)
+}
@@ -14,8 +14,10 @@ package main
 //   ./prover --private-key YOUR_KEY
 
 import (
+	"bufio"
 	"bytes"
 	"crypto/ecdsa"
+	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
 	"flag"
@@ -23,13 +25,22 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/depinonbnb/depin/internal/attestation"
+	"github.com/depinonbnb/depin/internal/i18n"
+	"github.com/depinonbnb/depin/internal/normalize"
+	"github.com/depinonbnb/depin/internal/proverbuild"
 	"github.com/depinonbnb/depin/internal/rpc"
+	"github.com/depinonbnb/depin/internal/signing"
+	"github.com/depinonbnb/depin/internal/tunnel"
 	"github.com/depinonbnb/depin/internal/types"
 	"github.com/ethereum/go-ethereum/crypto"
 )
@@ -40,6 +51,26 @@ type Config struct {
 	APIEndpoint string
 	NodeType    types.NodeType
 	IntervalMs  int
+
+	// Signed challenge log (for disputes)
+	ChallengeLog  string
+	ServerAddress string
+
+	// Signed result receipts, kept as our own record of how the node did
+	ReceiptLog string
+
+	// Attach a hardware attestation at registration (measures the disk
+	// holding this directory)
+	AttestDir string
+
+	// Console output: language (errors from the server come back in it
+	// too), and whether to leave out everything but failures and summaries
+	Lang  string
+	Quiet bool
+
+	// Register as exposed-rpc and let the server reach the node through a
+	// tunnel we hold open, instead of answering challenges ourselves
+	Tunnel bool
 }
 
 type Prover struct {
@@ -49,21 +80,69 @@ type Prover struct {
 	nodeRPC    *rpc.Client
 	nodeID     string
 	running    bool
+
+	serverKeys []string // Addresses the server signs challenges with (empty = unsigned)
+	build      string   // sha256 of this binary, signed with every answer
+
+	// Don't ask for challenges before this; set from the server's
+	// Retry-After and X-RateLimit headers
+	backoffMu    sync.Mutex
+	backoffUntil time.Time
+
+	http *http.Client // Sends our language with every request
+
+	tunnelReady     chan struct{} // Closed once the first tunnel is open
+	tunnelReadyOnce sync.Once
+
+	// Challenges answered since the last summary
+	statsMu    sync.Mutex
+	passed     int
+	failed     int
+	statsSince time.Time
+}
+
+// How often the prover prints how many challenges passed and failed
+const summaryInterval = time.Hour
+
+// Adds Accept-Language to every request, so failure reasons come back in
+// the operator's language
+type languageTransport struct {
+	lang string
+	next http.RoundTripper
+}
+
+func (t languageTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Language", t.lang)
+	return t.next.RoundTrip(req)
 }
 
 type ChallengeResponse struct {
-	Challenge struct {
-		ID            string                `json:"id"`
-		ChallengeType types.ChallengeType   `json:"challenge_type"`
-		Params        types.ChallengeParams `json:"params"`
-		ExpiresAt     int64                 `json:"expires_at"`
-	} `json:"challenge"`
-	ServerTime int64 `json:"server_time"`
+	Challenge  types.Challenge `json:"challenge"`
+	ServerTime int64           `json:"server_time"`
+}
+
+// One line in the challenge log. Keeps the challenge exactly as the server
+// signed it, plus what our node looked like when it arrived, so an operator
+// can later show a challenge was unfair (e.g. a block far behind the head).
+type ChallengeRecord struct {
+	Challenge      types.Challenge `json:"challenge"`
+	ServerAddress  string          `json:"server_address,omitempty"`
+	SignatureValid bool            `json:"signature_valid"`
+	ServerTime     int64           `json:"server_time"`
+	ReceivedAt     int64           `json:"received_at"`
+	LocalHead      uint64          `json:"local_head,omitempty"`
 }
 
 type SubmitResponse struct {
-	Passed        bool   `json:"passed"`
-	FailureReason string `json:"failure_reason"`
+	Passed        bool               `json:"passed"`
+	FailureReason string             `json:"failure_reason"`
+	Parts         []types.PartResult `json:"parts"`
+	Receipt       *signing.Receipt   `json:"receipt"`
+
+	// Set when the server is in maintenance and checks the answer later
+	Held    bool   `json:"held"`
+	Message string `json:"message"`
 }
 
 func NewProver(config Config) (*Prover, error) {
@@ -75,14 +154,87 @@ func NewProver(config Config) (*Prover, error) {
 
 	address := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
 
+	build, err := proverbuild.Self()
+	if err != nil {
+		return nil, fmt.Errorf("hashing the prover binary: %v", err)
+	}
+
+	client := &http.Client{}
+	if config.Lang != "" && config.Lang != i18n.English {
+		client.Transport = languageTransport{lang: config.Lang, next: http.DefaultTransport}
+	}
+
 	return &Prover{
-		config:     config,
-		privateKey: privateKey,
-		address:    address,
-		nodeRPC:    rpc.NewClient(config.NodeRPC, ""),
+		config:      config,
+		privateKey:  privateKey,
+		address:     address,
+		build:       build,
+		nodeRPC:     rpc.NewClient(config.NodeRPC, "").WithChain(config.NodeType.Chain()),
+		http:        client,
+		statsSince:  time.Now(),
+		tunnelReady: make(chan struct{}),
 	}, nil
 }
 
+// A console message in the operator's language. Leading and trailing
+// spaces and newlines are layout, not part of the message.
+func (p *Prover) tr(message string) string {
+	text := strings.Trim(message, " \n")
+	if text == "" {
+		return message
+	}
+	start := strings.Index(message, text)
+	return message[:start] + i18n.Translate(p.config.Lang, text) + message[start+len(text):]
+}
+
+// Progress, left out with --quiet
+func (p *Prover) info(format string, args ...interface{}) {
+	if !p.config.Quiet {
+		fmt.Println(p.tr(fmt.Sprintf(format, args...)))
+	}
+}
+
+// Failures and summaries, always printed
+func (p *Prover) notice(format string, args ...interface{}) {
+	fmt.Println(p.tr(fmt.Sprintf(format, args...)))
+}
+
+// Errors, to the log
+func (p *Prover) logf(format string, args ...interface{}) {
+	log.Print(p.tr(fmt.Sprintf(format, args...)))
+}
+
+// Count a challenge towards the next summary
+func (p *Prover) recordResult(passed bool) {
+	p.statsMu.Lock()
+	defer p.statsMu.Unlock()
+	if passed {
+		p.passed++
+	} else {
+		p.failed++
+	}
+}
+
+// Print how many challenges passed and failed since the last summary, and
+// start counting again
+func (p *Prover) printSummary() {
+	p.statsMu.Lock()
+	passed, failed, since := p.passed, p.failed, p.statsSince
+	p.passed, p.failed, p.statsSince = 0, 0, time.Now()
+	p.statsMu.Unlock()
+
+	p.notice("[%s] Summary since %s: %d passed, %d failed", time.Now().Format(time.RFC3339), since.Format(time.RFC3339), passed, failed)
+}
+
+func (p *Prover) printSummaries() {
+	for p.running {
+		time.Sleep(summaryInterval)
+		if p.running {
+			p.printSummary()
+		}
+	}
+}
+
 func (p *Prover) Start() error {
 	fmt.Println("============================================================")
 	fmt.Println("DePIN BNB Local Prover")
@@ -91,6 +243,16 @@ func (p *Prover) Start() error {
 	fmt.Printf("Node RPC: %s\n", p.config.NodeRPC)
 	fmt.Printf("API: %s\n", p.config.APIEndpoint)
 	fmt.Printf("Node Type: %s\n", p.config.NodeType)
+	fmt.Printf("Build: %s\n", p.build)
+	if p.config.Tunnel {
+		fmt.Println("Mode: tunnel (the server verifies the node through this prover)")
+	}
+	if p.config.ChallengeLog != "" {
+		fmt.Printf("Challenge Log: %s\n", p.config.ChallengeLog)
+	}
+	if p.config.ReceiptLog != "" {
+		fmt.Printf("Receipt Log: %s\n", p.config.ReceiptLog)
+	}
 	fmt.Println("============================================================")
 
 	// Check if we can connect to the local node
@@ -100,38 +262,77 @@ func (p *Prover) Start() error {
 	}
 
 	synced, _, _ := p.nodeRPC.GetSyncStatus()
-	fmt.Printf("Local node connected - Block #%d\n", blockNum)
-	fmt.Printf("Synced: %v\n", synced)
+	p.info("Local node connected - Block #%d", blockNum)
+	p.info("Synced: %v", synced)
 
 	if !synced {
 		return fmt.Errorf("node is not fully synced - please wait for sync to complete")
 	}
 
+	if err := p.checkNetwork(); err != nil {
+		return err
+	}
+	p.printAnnouncements()
+
 	// Register with the API
 	if err := p.register(); err != nil {
 		return fmt.Errorf("registration failed: %v", err)
 	}
 
+	p.loadServerKeys()
+
 	// Start the proof loop
 	p.running = true
-	fmt.Println("\nStarting proof loop...\n")
+	p.info("\nStarting proof loop...\n")
+
+	prove := p.submitProof
+	if p.config.Tunnel {
+		// The server does the challenging; we carry its calls and ask
+		// it to verify us on our schedule
+		prove = p.requestVerification
+		go p.holdTunnel()
+		select {
+		case <-p.tunnelReady:
+		case <-time.After(30 * time.Second):
+		}
+	} else {
+		go p.listenForSurprises()
+		if p.config.NodeType.Chain() != types.ChainGreenfield {
+			go p.sendHeartbeats()
+		}
+	}
+	go p.printSummaries()
 
 	for p.running {
-		if err := p.submitProof(); err != nil {
-			log.Printf("proof submission error: %v", err)
+		if err := prove(); err != nil {
+			p.logf("proof submission error: %v", err)
 		}
 		time.Sleep(time.Duration(p.config.IntervalMs) * time.Millisecond)
+		p.backoffMu.Lock()
+		until := p.backoffUntil
+		p.backoffMu.Unlock()
+		if wait := time.Until(until); wait > 0 {
+			p.info("Rate limited by the server - next request at %s", until.Format(time.RFC3339))
+			time.Sleep(wait)
+		}
 	}
 
 	return nil
 }
 
 func (p *Prover) Stop() {
-	fmt.Println("\nStopping prover...")
+	p.notice("\nStopping prover...")
 	p.running = false
+	p.printSummary()
 }
 
+// Sign a DePIN message with the wallet key. Anything that isn't one of
+// our own messages is refused, however it got here.
 func (p *Prover) signMessage(message string) (string, error) {
+	if err := signing.CheckProverMessage(message); err != nil {
+		return "", fmt.Errorf("refusing to sign: %v", err)
+	}
+
 	// Hash with Ethereum prefix
 	prefixedMessage := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message)
 	hash := crypto.Keccak256Hash([]byte(prefixedMessage))
@@ -149,7 +350,7 @@ func (p *Prover) signMessage(message string) (string, error) {
 }
 
 func (p *Prover) register() error {
-	fmt.Println("Registering node with API...")
+	p.info("Registering node with API...")
 
 	timestamp := time.Now().UnixMilli()
 	message := fmt.Sprintf("Register node\nWallet: %s\nType: %s\nTimestamp: %d", p.address, p.config.NodeType, timestamp)
@@ -165,9 +366,21 @@ func (p *Prover) register() error {
 		"signature":           signature,
 		"timestamp":           timestamp,
 	}
+	if p.config.Tunnel {
+		body["verification_method"] = "exposed-rpc"
+		body["rpc_endpoint"] = tunnel.Placeholder
+	}
+
+	if p.config.AttestDir != "" {
+		att, err := p.attest(timestamp)
+		if err != nil {
+			return err
+		}
+		body["attestation"] = att
+	}
 
 	jsonBody, _ := json.Marshal(body)
-	resp, err := http.Post(p.config.APIEndpoint+"/nodes/register", "application/json", bytes.NewReader(jsonBody))
+	resp, err := p.postIdempotent("/nodes/register", jsonBody)
 	if err != nil {
 		return err
 	}
@@ -185,7 +398,7 @@ func (p *Prover) register() error {
 	json.Unmarshal(respBody, &result)
 	p.nodeID = result.NodeID
 
-	fmt.Printf("Registered successfully - Node ID: %s\n", p.nodeID)
+	p.notice("Registered successfully - Node ID: %s", p.nodeID)
 	return nil
 }
 
@@ -193,13 +406,25 @@ func (p *Prover) submitProof() error {
 	startTime := time.Now()
 
 	// Step 1: Get a challenge from the server
-	fmt.Printf("[%s] Requesting challenge...\n", time.Now().Format(time.RFC3339))
+	p.info("[%s] Requesting challenge...", time.Now().Format(time.RFC3339))
 
-	resp, err := http.Get(fmt.Sprintf("%s/challenges/request?nodeId=%s", p.config.APIEndpoint, p.nodeID))
+	timestamp := time.Now().UnixMilli()
+	signature, err := p.signMessage(fmt.Sprintf("Request challenge\nNode: %s\nTimestamp: %d", p.nodeID, timestamp))
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("nodeId", p.nodeID)
+	query.Set("timestamp", fmt.Sprintf("%d", timestamp))
+	query.Set("signature", signature)
+
+	resp, err := p.http.Get(p.config.APIEndpoint + "/challenges/request?" + query.Encode())
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+	p.noteRateLimit(resp)
 
 	if resp.StatusCode != 200 {
 		body, _ := io.ReadAll(resp.Body)
@@ -208,33 +433,59 @@ func (p *Prover) submitProof() error {
 
 	var challengeResp ChallengeResponse
 	json.NewDecoder(resp.Body).Decode(&challengeResp)
+	if !p.checkChallenge(&challengeResp) {
+		return fmt.Errorf("not answering challenge %s: it didn't come from the server", challengeResp.Challenge.ID)
+	}
 
+	return p.answerChallenge(&challengeResp, startTime)
+}
+
+// Steps 2-4: run the challenge against our node and send back the answer.
+// Shared by scheduled and pushed (surprise) challenges.
+func (p *Prover) answerChallenge(challengeResp *ChallengeResponse, startTime time.Time) error {
 	blockNum := "N/A"
 	if challengeResp.Challenge.Params.BlockNumber != nil {
 		blockNum = fmt.Sprintf("%d", *challengeResp.Challenge.Params.BlockNumber)
 	}
-	fmt.Printf("  Challenge: %s (Block #%s)\n", challengeResp.Challenge.ChallengeType, blockNum)
+	p.info("  Challenge: %s (Block #%s)", challengeResp.Challenge.ChallengeType, blockNum)
 
 	// Step 2: Ask our local node for the answer
 	queryStart := time.Now()
-	challenge := &types.Challenge{
-		ID:            challengeResp.Challenge.ID,
-		ChallengeType: challengeResp.Challenge.ChallengeType,
-		Params:        challengeResp.Challenge.Params,
-	}
+	challenge := &challengeResp.Challenge
 	nodeResponse := p.nodeRPC.ExecuteChallenge(challenge)
 	queryTime := time.Since(queryStart).Milliseconds()
 
 	if !nodeResponse.Success {
-		fmt.Printf("  FAILED: %s\n", nodeResponse.Error)
+		p.notice("  FAILED: %s", nodeResponse.Error)
+		p.recordResult(false)
 		return nil
 	}
+	if nodeResponse.Error != "" {
+		// Some parts of a composite failed - send the rest for partial credit
+		p.notice("  PARTIAL: %s", nodeResponse.Error)
+	}
+
+	p.info("  Query time: %dms", queryTime)
+
+	// Send the answer in the canonical form the server compares in, so our
+	// client's formatting can't make it look wrong
+	nodeResponse.Data = normalize.ChallengeAnswer(challenge, nodeResponse.Data)
 
-	fmt.Printf("  Query time: %dms\n", queryTime)
+	// Commit-reveal challenges: lock in the answer first, the server times
+	// the commit
+	nonce := ""
+	if challenge.CommitBy != 0 {
+		var err error
+		if nonce, err = p.commitAnswer(challenge, nodeResponse.Data); err != nil {
+			p.notice("  FAILED: %v", err)
+			p.recordResult(false)
+			return nil
+		}
+	}
 
 	// Step 3: Sign the response
 	timestamp := time.Now().UnixMilli()
-	message := fmt.Sprintf("Challenge Response\nID: %s\nAnswer: %s\nTimestamp: %d", challenge.ID, nodeResponse.Data, timestamp)
+	message, _ := signing.AnswerMessage(signing.AnswerV3, challenge.ID, p.nodeID, challenge.ChallengeType, nodeResponse.Data, p.build, timestamp)
 	signature, err := p.signMessage(message)
 	if err != nil {
 		return err
@@ -248,35 +499,689 @@ func (p *Prover) submitProof() error {
 		"signature":        signature,
 		"response_time_ms": queryTime,
 		"timestamp":        timestamp,
+		"version":          signing.AnswerV3,
+		"challenge_type":   challenge.ChallengeType,
+		"build":            p.build,
+	}
+	if nonce != "" {
+		submitBody["nonce"] = nonce
+	}
+	// So the server can tell us when our client needs upgrading
+	if version, _, err := p.nodeRPC.GetClientVersion(); err == nil {
+		submitBody["client_version"] = version
 	}
 
 	jsonBody, _ := json.Marshal(submitBody)
-	submitResp, err := http.Post(p.config.APIEndpoint+"/challenges/submit", "application/json", bytes.NewReader(jsonBody))
+	submitResp, err := p.postIdempotent("/challenges/submit", jsonBody)
 	if err != nil {
 		return err
 	}
 	defer submitResp.Body.Close()
+	p.noteRateLimit(submitResp)
 
 	var result SubmitResponse
 	json.NewDecoder(submitResp.Body).Decode(&result)
+	if result.Held {
+		p.notice("  HELD: %s", result.Message)
+		return nil
+	}
 
 	totalTime := time.Since(startTime).Milliseconds()
 
+	p.recordResult(result.Passed)
+	if result.Passed {
+		p.info("  PASSED (Total: %dms)", totalTime)
+	} else {
+		p.notice("  FAILED: %s", result.FailureReason)
+	}
+	for i, part := range result.Parts {
+		if !part.Passed {
+			p.notice("    Part %d (%s): %s", i+1, part.ChallengeType, part.FailureReason)
+		}
+	}
+	p.keepReceipt(result.Receipt, challenge.ID)
+
+	return nil
+}
+
+// Append the server's receipt for an answer to the receipt log, once its
+// signature checks out. A receipt that doesn't check out is worthless as
+// evidence, so it's only warned about.
+func (p *Prover) keepReceipt(receipt *signing.Receipt, challengeID string) {
+	if p.config.ReceiptLog == "" || receipt == nil {
+		return
+	}
+	if receipt.ChallengeID != challengeID || receipt.NodeID != p.nodeID {
+		p.notice("  WARNING: receipt is for challenge %s on node %s, not ours", receipt.ChallengeID, receipt.NodeID)
+		return
+	}
+
+	if receipt.Signature != "" || len(p.serverKeys) > 0 {
+		ok := receipt.Verify(p.serverKeys)
+		if !ok && p.config.ServerAddress == "" && receipt.KeyID != "" {
+			p.loadServerKeys()
+			ok = receipt.Verify(p.serverKeys)
+		}
+		if !ok {
+			p.notice("  WARNING: receipt signature does not match any published server key")
+			return
+		}
+	}
+
+	if err := appendRecord(p.config.ReceiptLog, receipt); err != nil {
+		p.logf("failed to write receipt log: %v", err)
+	}
+}
+
+// Back off as the server asks: for Retry-After on a 429 or a 503 (the
+// server is in maintenance), or until the daily cap resets once
+// X-RateLimit-Remaining hits 0. Polling into a cap only burns requests
+// and looks like abuse.
+func (p *Prover) noteRateLimit(resp *http.Response) {
+	var until time.Time
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		if seconds, err := strconv.ParseInt(resp.Header.Get("Retry-After"), 10, 64); err == nil {
+			until = time.Now().Add(time.Duration(seconds) * time.Second)
+		}
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			until = time.Unix(reset, 0)
+		}
+	}
+	p.backoffMu.Lock()
+	if until.After(p.backoffUntil) {
+		p.backoffUntil = until
+	}
+	p.backoffMu.Unlock()
+}
+
+// POST JSON, retrying a couple of times if the connection fails. Every
+// attempt carries the same Idempotency-Key, so if an earlier one did get
+// through we get its response back instead of registering or submitting
+// twice.
+func (p *Prover) postIdempotent(path string, body []byte) (*http.Response, error) {
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return nil, err
+	}
+	key := hex.EncodeToString(keyBytes)
+
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+		req, _ := http.NewRequest("POST", p.config.APIEndpoint+path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", key)
+
+		var resp *http.Response
+		if resp, err = p.http.Do(req); err == nil {
+			return resp, nil
+		}
+		p.logf("POST %s attempt %d failed: %v", path, attempt+1, err)
+	}
+	return nil, err
+}
+
+// Send the server a commitment to our answer and return the nonce that
+// reveals it
+func (p *Prover) commitAnswer(challenge *types.Challenge, answer string) (string, error) {
+	nonceBytes := make([]byte, 32)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return "", err
+	}
+	nonce := "0x" + hex.EncodeToString(nonceBytes)
+	commitment := signing.Commitment(answer, nonce)
+
+	timestamp := time.Now().UnixMilli()
+	message := fmt.Sprintf("Challenge Commit\nID: %s\nCommitment: %s\nTimestamp: %d", challenge.ID, commitment, timestamp)
+	signature, err := p.signMessage(message)
+	if err != nil {
+		return "", err
+	}
+
+	jsonBody, _ := json.Marshal(map[string]interface{}{
+		"challenge_id": challenge.ID,
+		"node_id":      p.nodeID,
+		"commitment":   commitment,
+		"signature":    signature,
+		"timestamp":    timestamp,
+	})
+	resp, err := p.http.Post(p.config.APIEndpoint+"/challenges/commit", "application/json", bytes.NewReader(jsonBody))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("commit rejected: %s", string(body))
+	}
+	return nonce, nil
+}
+
+// Tell the server our node's newest block every few minutes, between proofs
+func (p *Prover) sendHeartbeats() {
+	for p.running {
+		if err := p.sendHeartbeat(); err != nil {
+			p.logf("heartbeat error: %v", err)
+		}
+		time.Sleep(5 * time.Minute)
+	}
+}
+
+// Sign the head block's number and hash; the server checks the hash
+// against the real chain
+func (p *Prover) sendHeartbeat() error {
+	blockNumber, _, err := p.nodeRPC.GetBlockNumber()
+	if err != nil {
+		return err
+	}
+	blockHash, _, err := p.nodeRPC.GetBlockHash(blockNumber)
+	if err != nil {
+		return err
+	}
+	blockHash = strings.ToLower(blockHash)
+
+	timestamp := time.Now().UnixMilli()
+	signature, err := p.signMessage(signing.HeartbeatMessage(p.nodeID, blockNumber, blockHash, timestamp))
+	if err != nil {
+		return err
+	}
+
+	jsonBody, _ := json.Marshal(map[string]interface{}{
+		"block_number": blockNumber,
+		"block_hash":   blockHash,
+		"timestamp":    timestamp,
+		"signature":    signature,
+	})
+	resp, err := p.http.Post(p.config.APIEndpoint+"/nodes/"+p.nodeID+"/heartbeat", "application/json", bytes.NewReader(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("heartbeat rejected: %s", string(body))
+	}
+	return nil
+}
+
+// Keep a stream open for surprise challenges the server pushes between
+// polls. They expire within seconds, so answer them straight away.
+func (p *Prover) listenForSurprises() {
+	for p.running {
+		if err := p.streamChallenges(); err != nil {
+			p.logf("challenge stream error: %v", err)
+		}
+		time.Sleep(10 * time.Second)
+	}
+}
+
+func (p *Prover) streamChallenges() error {
+	timestamp := time.Now().UnixMilli()
+	message := fmt.Sprintf("Subscribe challenges\nNode: %s\nTimestamp: %d", p.nodeID, timestamp)
+	signature, err := p.signMessage(message)
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("nodeId", p.nodeID)
+	query.Set("timestamp", fmt.Sprintf("%d", timestamp))
+	query.Set("signature", signature)
+
+	resp, err := p.http.Get(p.config.APIEndpoint + "/challenges/stream?" + query.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("stream refused: %s", string(body))
+	}
+
+	// Server-sent events: "event:" and "data:" lines, blank line ends one
+	var event, data string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() && p.running {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		case line == "":
+			if event == "challenge" {
+				p.handleSurprise(data)
+			}
+			event, data = "", ""
+		}
+	}
+	return scanner.Err()
+}
+
+func (p *Prover) handleSurprise(data string) {
+	startTime := time.Now()
+
+	var push signing.Push
+	if err := json.Unmarshal([]byte(data), &push); err != nil {
+		p.logf("bad pushed challenge: %v", err)
+		return
+	}
+	if err := p.checkPush(&push, "challenge"); err != nil {
+		p.logf("ignoring pushed challenge: %v", err)
+		return
+	}
+
+	var challengeResp ChallengeResponse
+	if err := json.Unmarshal([]byte(push.Payload), &challengeResp); err != nil {
+		p.logf("bad pushed challenge: %v", err)
+		return
+	}
+
+	p.info("[%s] Surprise challenge received", time.Now().Format(time.RFC3339))
+	if !p.checkChallenge(&challengeResp) {
+		p.logf("not answering surprise challenge %s: it didn't come from the server", challengeResp.Challenge.ID)
+		return
+	}
+	if err := p.answerChallenge(&challengeResp, startTime); err != nil {
+		p.logf("surprise challenge error: %v", err)
+	}
+}
+
+// JSON-RPC methods the server's verification calls, the only ones we
+// relay down the tunnel. Anything else - personal_*, admin_*, txpool_* -
+// is refused, so a compromised or spoofed server can't use the tunnel to
+// reach the rest of the node's API.
+var tunnelMethods = map[string]bool{
+	"eth_blockNumber":       true,
+	"eth_chainId":           true,
+	"eth_syncing":           true,
+	"eth_getBlockByNumber":  true,
+	"eth_getBalance":        true,
+	"debug_chaindbProperty": true,
+	"net_peerCount":         true,
+	"web3_clientVersion":    true,
+}
+
+// Keep a tunnel open so the server can reach our node, reopening it
+// whenever it drops
+func (p *Prover) holdTunnel() {
+	for p.running {
+		if err := p.streamTunnel(); err != nil {
+			p.logf("tunnel error: %v", err)
+		}
+		time.Sleep(10 * time.Second)
+	}
+}
+
+func (p *Prover) streamTunnel() error {
+	timestamp := time.Now().UnixMilli()
+	message := fmt.Sprintf("Open tunnel\nNode: %s\nTimestamp: %d", p.nodeID, timestamp)
+	signature, err := p.signMessage(message)
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("nodeId", p.nodeID)
+	query.Set("timestamp", fmt.Sprintf("%d", timestamp))
+	query.Set("signature", signature)
+
+	resp, err := p.http.Get(p.config.APIEndpoint + "/tunnel?" + query.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("tunnel refused: %s", string(body))
+	}
+	p.info("Tunnel open - the server reaches the node through this prover")
+	p.tunnelReadyOnce.Do(func() { close(p.tunnelReady) })
+
+	// Server-sent events, as for the challenge stream
+	var event, data string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() && p.running {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		case line == "":
+			if event == "request" {
+				var request tunnel.Request
+				if err := json.Unmarshal([]byte(data), &request); err != nil {
+					p.logf("bad tunnel request: %v", err)
+				} else {
+					go p.relay(&request)
+				}
+			}
+			event, data = "", ""
+		}
+	}
+	return scanner.Err()
+}
+
+// Make a call the server sent down the tunnel against our node and post
+// back what it said
+func (p *Prover) relay(request *tunnel.Request) {
+	reply := map[string]interface{}{"node_id": p.nodeID, "id": request.ID}
+
+	var call struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(request.Body, &call); err != nil || !tunnelMethods[call.Method] {
+		p.logf("not relaying %s: verification doesn't call it", call.Method)
+		reply["error"] = fmt.Sprintf("prover won't relay %q", call.Method)
+	} else if status, body, err := p.callNode(request.Body); err != nil {
+		reply["error"] = err.Error()
+	} else {
+		reply["status"], reply["body"] = status, body
+	}
+
+	jsonBody, _ := json.Marshal(reply)
+	resp, err := p.http.Post(p.config.APIEndpoint+"/tunnel/replies", "application/json", bytes.NewReader(jsonBody))
+	if err != nil {
+		p.logf("tunnel error: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	// 404 means the server gave up waiting; nothing to do about it
+	if resp.StatusCode != 200 && resp.StatusCode != 404 {
+		body, _ := io.ReadAll(resp.Body)
+		p.logf("tunnel reply rejected: %s", string(body))
+	}
+}
+
+// POST a JSON-RPC request to our node as it came, returning the raw answer
+func (p *Prover) callNode(body []byte) (int, []byte, error) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Post(p.config.NodeRPC, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	answer, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, answer, err
+}
+
+// Ask the server to verify our node now, through the tunnel
+func (p *Prover) requestVerification() error {
+	startTime := time.Now()
+	p.info("[%s] Requesting verification...", time.Now().Format(time.RFC3339))
+
+	resp, err := p.http.Post(p.config.APIEndpoint+"/verify/"+p.nodeID, "application/json", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	p.noteRateLimit(resp)
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("verification refused: %s", string(body))
+	}
+
+	var result SubmitResponse
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	p.recordResult(result.Passed)
 	if result.Passed {
-		fmt.Printf("  PASSED (Total: %dms)\n", totalTime)
+		p.info("  PASSED (Total: %dms)", time.Since(startTime).Milliseconds())
 	} else {
-		fmt.Printf("  FAILED: %s\n", result.FailureReason)
+		p.notice("  FAILED: %s", result.FailureReason)
+	}
+	for i, part := range result.Parts {
+		if !part.Passed {
+			p.notice("    Part %d (%s): %s", i+1, part.ChallengeType, part.FailureReason)
+		}
+	}
+	return nil
+}
+
+// Describe this machine and sign it, so the server can check the node
+// type we claim is something this hardware could actually run
+func (p *Prover) attest(timestamp int64) (*types.HardwareAttestation, error) {
+	report, err := attestation.Collect(p.config.AttestDir)
+	if err != nil {
+		return nil, err
+	}
+	p.info("Hardware: %d CPUs, disk %s, %s", report.CPUCount, report.DiskClass, report.OS)
+
+	signature, err := p.signMessage(attestation.Message(p.address, p.config.NodeType, timestamp, report))
+	if err != nil {
+		return nil, err
+	}
+	return &types.HardwareAttestation{Report: report, Signature: signature}, nil
+}
+
+// Figure out which keys the server signs challenges with.
+// A pinned --server-address wins over whatever the server advertises.
+// Make sure the local node is on the chain the server checks against,
+// e.g. not a mainnet node pointed at a testnet server. Skipped if the
+// server or node can't tell us.
+func (p *Prover) checkNetwork() error {
+	resp, err := p.http.Get(p.config.APIEndpoint + "/network")
+	if err != nil {
+		p.logf("could not fetch server network: %v", err)
+		return nil
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Network  types.Network          `json:"network"`
+		ChainIDs map[types.Chain]uint64 `json:"chain_ids"`
+	}
+	if resp.StatusCode != 200 || json.NewDecoder(resp.Body).Decode(&result) != nil {
+		p.logf("could not fetch server network: status %d", resp.StatusCode)
+		return nil
+	}
+
+	chainID, _, err := p.nodeRPC.GetChainID()
+	if err != nil {
+		p.logf("could not read chain ID from local node: %v", err)
+		return nil
+	}
+	p.info("Network: %s (chain %d)", result.Network, chainID)
+
+	if want := result.ChainIDs[p.config.NodeType.Chain()]; want != 0 && chainID != want {
+		return fmt.Errorf("local node is on chain %d, but the server runs %s (chain %d)", chainID, result.Network, want)
+	}
+	return nil
+}
+
+// Print what the server's operators want node operators to know: new
+// challenge types, prover releases to upgrade to, reward changes. Printed
+// even with --quiet; a server that can't be asked just has none.
+func (p *Prover) printAnnouncements() {
+	resp, err := p.http.Get(p.config.APIEndpoint + "/announcements")
+	if err != nil {
+		p.logf("could not fetch announcements: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Announcements []types.Announcement `json:"announcements"`
+	}
+	if resp.StatusCode != 200 || json.NewDecoder(resp.Body).Decode(&result) != nil {
+		p.logf("could not fetch announcements: status %d", resp.StatusCode)
+		return
+	}
+	if len(result.Announcements) == 0 {
+		return
+	}
+
+	p.notice("\nAnnouncements:")
+	for _, a := range result.Announcements {
+		fmt.Printf("  [%s] %s (%s)\n", time.UnixMilli(a.PostedAt).UTC().Format("2006-01-02"), a.Title, a.Kind)
+		for _, line := range strings.Split(strings.TrimSpace(a.Body), "\n") {
+			if line != "" {
+				fmt.Printf("    %s\n", line)
+			}
+		}
+	}
+	fmt.Println()
+}
+
+func (p *Prover) loadServerKeys() {
+	if p.config.ServerAddress != "" {
+		p.serverKeys = []string{p.config.ServerAddress}
+		p.info("Server key (pinned): %s", p.config.ServerAddress)
+		return
+	}
+
+	resp, err := p.http.Get(p.config.APIEndpoint + "/server-keys")
+	if err != nil {
+		p.logf("could not fetch server keys: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Keys []struct {
+			Address string `json:"address"`
+			Status  string `json:"status"`
+		} `json:"keys"`
+	}
+	if resp.StatusCode != 200 || json.NewDecoder(resp.Body).Decode(&result) != nil {
+		p.logf("could not fetch server keys: status %d", resp.StatusCode)
+		return
+	}
+
+	p.serverKeys = p.serverKeys[:0]
+	for _, key := range result.Keys {
+		p.serverKeys = append(p.serverKeys, key.Address)
+		p.info("Server key (%s): %s", key.Status, key.Address)
+	}
+
+	if len(p.serverKeys) == 0 {
+		p.info("Server does not sign challenges")
+	}
+}
+
+// Find the trusted key that signed this challenge
+func (p *Prover) verifyChallenge(ch *types.Challenge) (string, bool) {
+	message := signing.ChallengeMessage(ch)
+	for _, address := range p.serverKeys {
+		if ch.KeyID != "" && !strings.EqualFold(ch.KeyID, address) {
+			continue
+		}
+		if signing.Verify(message, ch.Signature, address) {
+			return address, true
+		}
 	}
+	return ch.KeyID, false
+}
 
+// Make sure something pushed to us was signed by the server, for us, and
+// recently. Without this, anyone who can get between us and the server
+// (or hijack its DNS) could feed us challenges to collect our signatures.
+func (p *Prover) checkPush(push *signing.Push, event string) error {
+	if push.Event != event || push.NodeID != p.nodeID {
+		return fmt.Errorf("%s push for node %s, expected %s", push.Event, push.NodeID, p.nodeID)
+	}
+	if age := time.Now().UnixMilli() - push.Timestamp; age > 5*60*1000 || age < -5*60*1000 {
+		return fmt.Errorf("push is %dms old", age)
+	}
+	if len(p.serverKeys) == 0 && push.Signature == "" {
+		return nil // Server doesn't sign
+	}
+
+	ok := push.Verify(p.serverKeys)
+	if !ok && p.config.ServerAddress == "" && push.KeyID != "" {
+		p.loadServerKeys()
+		ok = push.Verify(p.serverKeys)
+	}
+	if !ok {
+		return fmt.Errorf("signature does not match any published server key")
+	}
 	return nil
 }
 
+// Check the server's signature and append the challenge to the log.
+// Returns false for a challenge we shouldn't answer: one that isn't for
+// our node, or isn't signed by the server when the server signs.
+func (p *Prover) checkChallenge(resp *ChallengeResponse) bool {
+	record := ChallengeRecord{
+		Challenge:  resp.Challenge,
+		ServerTime: resp.ServerTime,
+		ReceivedAt: time.Now().UnixMilli(),
+	}
+
+	signed := len(p.serverKeys) > 0 || resp.Challenge.Signature != ""
+	if signed {
+		address, ok := p.verifyChallenge(&resp.Challenge)
+
+		// Signed with a key we haven't seen - the server may have rotated
+		if !ok && p.config.ServerAddress == "" && resp.Challenge.KeyID != "" {
+			p.loadServerKeys()
+			address, ok = p.verifyChallenge(&resp.Challenge)
+		}
+
+		record.ServerAddress = address
+		record.SignatureValid = ok
+		if !ok {
+			p.notice("  WARNING: challenge signature does not match any published server key")
+		}
+	}
+
+	trusted := (record.SignatureValid || !signed) && resp.Challenge.NodeID == p.nodeID
+	if p.config.ChallengeLog == "" {
+		return trusted
+	}
+
+	if head, _, err := p.nodeRPC.GetBlockNumber(); err == nil {
+		record.LocalHead = head
+	}
+
+	if err := appendRecord(p.config.ChallengeLog, record); err != nil {
+		p.logf("failed to write challenge log: %v", err)
+	}
+	return trusted
+}
+
+// Append one JSON line to the log file
+func appendRecord(path string, record interface{}) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
 func main() {
 	privateKey := flag.String("private-key", "", "Your wallet private key")
 	nodeRPC := flag.String("node-rpc", "http://localhost:8545", "Your node RPC endpoint")
 	apiEndpoint := flag.String("api", "http://localhost:3000/api", "DePIN API endpoint")
 	nodeType := flag.String("node-type", "bsc-full", "Node type: bsc-full, bsc-fast, opbnb-full, etc.")
 	intervalMs := flag.Int("interval", 300000, "Proof interval in milliseconds (default: 5 min)")
+	challengeLog := flag.String("challenge-log", "", "Append every received challenge to this file (JSON lines)")
+	serverAddress := flag.String("server-address", "", "Expected server signing address (default: ask the server)")
+	attestDir := flag.String("attest-dir", "", "Send a hardware attestation at registration, measuring the disk this directory (your node's data dir) is on")
+	lang := flag.String("lang", "", "Language for console messages and server errors: "+strings.Join(i18n.Languages(), ", ")+" (default: English)")
+	quiet := flag.Bool("quiet", false, "Only print failures and hourly summaries")
+	receiptLog := flag.String("receipt-log", "", "Append the server's signed receipt for every answer to this file (JSON lines)")
+	useTunnel := flag.Bool("tunnel", false, "Let the server verify your node through a tunnel this prover holds open, for nodes that can't accept inbound connections")
 
 	flag.Parse()
 
@@ -293,6 +1198,13 @@ func main() {
 	if os.Getenv("NODE_TYPE") != "" {
 		*nodeType = os.Getenv("NODE_TYPE")
 	}
+	if *lang == "" {
+		*lang = os.Getenv("PROVER_LANG")
+	}
+	language := i18n.Negotiate(*lang)
+	if *lang != "" && language == i18n.English && !strings.HasPrefix(strings.ToLower(*lang), "en") {
+		fmt.Printf("No translation for %q, using English\n", *lang)
+	}
 
 	if *privateKey == "" {
 		fmt.Println("ERROR: Private key required")
@@ -305,6 +1217,13 @@ func main() {
 		fmt.Println("  --api           DePIN API endpoint (default: http://localhost:3000/api)")
 		fmt.Println("  --node-type     Node type: bsc-full, bsc-fast, opbnb-full, etc.")
 		fmt.Println("  --interval      Proof interval in ms (default: 300000 = 5 min)")
+		fmt.Println("  --challenge-log     Append every received challenge to this file (JSON lines)")
+		fmt.Println("  --receipt-log       Append the server's signed receipt for every answer to this file (JSON lines)")
+		fmt.Println("  --server-address    Expected server signing address (default: ask the server)")
+		fmt.Println("  --attest-dir        Send a hardware attestation, measuring the disk this directory is on")
+		fmt.Println("  --lang              Language for messages: " + strings.Join(i18n.Languages(), ", ") + " (or set PROVER_LANG env)")
+		fmt.Println("  --quiet             Only print failures and hourly summaries")
+		fmt.Println("  --tunnel            Let the server verify your node through this prover (no inbound ports needed)")
 		os.Exit(1)
 	}
 
@@ -314,6 +1233,16 @@ func main() {
 		APIEndpoint: *apiEndpoint,
 		NodeType:    types.NodeType(*nodeType),
 		IntervalMs:  *intervalMs,
+
+		ChallengeLog:  *challengeLog,
+		ServerAddress: *serverAddress,
+		ReceiptLog:    *receiptLog,
+		AttestDir:     *attestDir,
+
+		Lang:  language,
+		Quiet: *quiet,
+
+		Tunnel: *useTunnel,
 	})
 	if err != nil {
 		log.Fatalf("failed to create prover: %v", err)
@@ -329,6 +1258,6 @@ func main() {
 	}()
 
 	if err := prover.Start(); err != nil {
-		log.Fatalf("prover error: %v", err)
+		log.Fatal(prover.tr(fmt.Sprintf("prover error: %v", err)))
 	}
 }
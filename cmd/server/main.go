@@ -1,14 +1,43 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/depinonbnb/depin/internal/anomaly"
 	"github.com/depinonbnb/depin/internal/api"
+	"github.com/depinonbnb/depin/internal/budget"
+	"github.com/depinonbnb/depin/internal/challenge"
+	"github.com/depinonbnb/depin/internal/clientcert"
+	"github.com/depinonbnb/depin/internal/clientversion"
+	"github.com/depinonbnb/depin/internal/config"
+	"github.com/depinonbnb/depin/internal/diagnostics"
+	"github.com/depinonbnb/depin/internal/hardfork"
+	"github.com/depinonbnb/depin/internal/headerchain"
+	"github.com/depinonbnb/depin/internal/metrics"
+	"github.com/depinonbnb/depin/internal/modlog"
+	"github.com/depinonbnb/depin/internal/names"
+	"github.com/depinonbnb/depin/internal/notify"
+	"github.com/depinonbnb/depin/internal/proverbuild"
+	"github.com/depinonbnb/depin/internal/ratelimit"
+	"github.com/depinonbnb/depin/internal/rates"
+	"github.com/depinonbnb/depin/internal/replica"
+	"github.com/depinonbnb/depin/internal/rpc"
+	"github.com/depinonbnb/depin/internal/sharedcache"
+	"github.com/depinonbnb/depin/internal/signing"
+	"github.com/depinonbnb/depin/internal/staking"
 	"github.com/depinonbnb/depin/internal/store"
+	"github.com/depinonbnb/depin/internal/types"
 	"github.com/depinonbnb/depin/internal/verification"
+	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
@@ -16,64 +45,620 @@ func main() {
 	// Load .env file if it exists
 	godotenv.Load()
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3000"
+	if len(os.Args) > 1 && (os.Args[1] == "--help" || os.Args[1] == "-h") {
+		fmt.Println("DePIN BNB Verification Server")
+		fmt.Println("")
+		fmt.Println("Configured with environment variables (or a .env file):")
+		fmt.Println("")
+		fmt.Print(config.Describe())
+		fmt.Println("")
+		fmt.Println("Commands:")
+		fmt.Println("  check-config   Validate the config, try the trusted RPCs, and exit nonzero on any problem")
+		return
 	}
 
-	trustedRPC := os.Getenv("TRUSTED_RPC")
-	if trustedRPC == "" {
-		trustedRPC = "https://bsc-dataseed1.binance.org"
+	// For deploy pipelines: fail before the server is swapped in
+	if len(os.Args) > 1 && os.Args[1] == "check-config" {
+		report := config.Check()
+		fmt.Print(report)
+		if report.Failed() {
+			os.Exit(1)
+		}
+		return
 	}
 
-	adminAPIKey := os.Getenv("ADMIN_API_KEY")
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
 
 	fmt.Println("============================================================")
 	fmt.Println("DePIN BNB Verification Server")
 	fmt.Println("============================================================")
-	fmt.Printf("Trusted RPC: %s\n", trustedRPC)
-	fmt.Printf("Port: %s\n", port)
-	if adminAPIKey != "" {
-		fmt.Println("Admin API Key: [configured]")
+	fmt.Printf("Network: %s\n", cfg.Network)
+	fmt.Printf("Trusted RPC: %s\n", cfg.TrustedRPC)
+	fmt.Printf("Trusted opBNB RPC: %s\n", cfg.TrustedOpbnbRPC)
+	fmt.Printf("Trusted Greenfield SP: %s (%d objects)\n", cfg.TrustedGreenfieldSP, len(cfg.GreenfieldObjects))
+	if len(cfg.HeaderChainRPCs) > 0 {
+		fmt.Printf("Header Chain: %d RPCs, quorum %d\n", len(cfg.HeaderChainRPCs), cfg.HeaderChainQuorum)
+	}
+	if cfg.BlockSampling != string(challenge.SamplingUniform) {
+		fmt.Printf("Block Sampling: %s\n", cfg.BlockSampling)
+	}
+	fmt.Printf("Port: %s\n", cfg.Port)
+	if cfg.NamesRegistry != "" {
+		fmt.Printf("Wallet Names: registry %s\n", cfg.NamesRegistry)
+	}
+	if cfg.StakingContract != "" {
+		fmt.Printf("Staking Bonus: contract %s (tiers %s)\n", cfg.StakingContract, cfg.StakingTiers)
+	}
+	if cfg.AnomalyScorer.URL != "" {
+		fmt.Printf("Anomaly Scorer: %s (timeout %dms, suspicious at %d%%)\n", cfg.AnomalyScorer.URL, cfg.AnomalyScorer.TimeoutMs, cfg.AnomalyScorer.ThresholdPercent)
+	}
+	if cfg.ReplicaOf != "" {
+		fmt.Printf("READ-ONLY REPLICA of %s (synced every %ds)\n", cfg.ReplicaOf, cfg.ReplicaSyncSeconds)
+	}
+	if cfg.RedisURL != "" {
+		fmt.Println("Shared Cache: Redis (pending challenges, leaderboard)")
+	}
+	switch cfg.StoreBackend {
+	case store.BackendPostgres:
+		fmt.Printf("Store: PostgreSQL (checkpoint every %ds)\n", cfg.CheckpointSeconds)
+	case store.BackendSQLite:
+		fmt.Printf("Store: SQLite %s (checkpoint every %ds)\n", cfg.SQLitePath, cfg.CheckpointSeconds)
+	default:
+		fmt.Println("Store: [memory - lost on restart]")
+	}
+	if len(cfg.TrustedProxies) > 0 {
+		fmt.Printf("Trusted Proxies: %s (client IP from %s)\n", strings.Join(cfg.TrustedProxies, ", "), strings.Join(cfg.ClientIPHeaders, ", "))
+	} else {
+		fmt.Println("Trusted Proxies: [none - client IP is the connection address]")
+	}
+	if keys := cfg.AdminAPIKeys(); len(keys) > 0 {
+		fmt.Printf("Admin API Keys: [%d configured]\n", len(keys))
 	} else {
 		fmt.Println("Admin API Key: [NOT SET - admin endpoints unprotected!]")
 	}
+	if cfg.Signing.Key != "" {
+		signer, _ := signing.NewSigner(cfg.Signing.Key) // Already validated
+		fmt.Printf("Challenge Signing: %s\n", signer.Address())
+	} else {
+		fmt.Println("Challenge Signing: [off]")
+	}
+	if cfg.ClientCertKey != "" {
+		fmt.Println("Node Client Certificates: [accepted]")
+	}
+	if cfg.WebhookURL != "" {
+		fmt.Printf("Notify Webhook: %s\n", cfg.WebhookURL)
+	}
+	if cfg.DiagnosticsAddr != "" {
+		fmt.Printf("Diagnostics: http://%s/debug/ (pprof, runtime)\n", cfg.DiagnosticsAddr)
+	}
+	if rpc.ChaosBuild {
+		fmt.Println("CHAOS BUILD: RPC fault injection enabled via CHAOS_* env vars")
+	}
 	fmt.Println("============================================================")
 
 	// Initialize components
 	nodeStore := store.NewStore()
-	verifier := verification.NewVerifier(trustedRPC)
+	durable, takenAt, err := openStore(cfg, nodeStore)
+	if err != nil {
+		log.Fatalf("opening the %s store: %v", cfg.StoreBackend, err)
+	}
+	if durable != nil {
+		if takenAt > 0 {
+			log.Printf("restored the store from the checkpoint taken %s", time.UnixMilli(takenAt).UTC().Format(time.RFC3339))
+		}
+		go func() {
+			ticker := time.NewTicker(time.Duration(cfg.CheckpointSeconds) * time.Second)
+			for range ticker.C {
+				if _, err := durable.Checkpoint(); err != nil {
+					log.Printf("store checkpoint failed, will retry: %v", err)
+				}
+			}
+		}()
+	}
+	nodeStore.SetNetwork(cfg.Network)
+	verifier := verification.NewVerifier(cfg.TrustedRPC)
+	verifier.SetNetwork(cfg.Network)
+	applyTrusted(nil, cfg, verifier)
+	verifier.SetGreenfieldObjects(cfg.GreenfieldObjects)
+	var shared sharedcache.Cache
+	if cfg.RedisURL != "" {
+		redis, err := sharedcache.NewRedis(cfg.RedisURL)
+		if err != nil {
+			log.Fatalf("connecting to REDIS_URL: %v", err)
+		}
+		shared = redis
+		verifier.SetSharedCache(redis)
+	}
+	sampling, _ := challenge.ParseSampling(cfg.BlockSampling) // Already validated
+	verifier.SetBlockSampling(sampling)
+	if cfg.ClientCertKey != "" {
+		sealer, _ := clientcert.NewSealer(cfg.ClientCertKey) // Already validated
+		verifier.SetClientCertSealer(sealer)
+	}
+	applyThresholds(cfg, nodeStore, verifier)
+	applySigning(cfg, verifier)
+	if scorer := cfg.AnomalyScorer; scorer.URL != "" {
+		verifier.SetAnomalyScorer(verification.NewHTTPScorer(scorer.URL, time.Duration(scorer.TimeoutMs)*time.Millisecond, float64(scorer.ThresholdPercent)/100))
+	}
+	verifier.SetPublicProviders(cfg.PublicProviders)
+	applyClientVersions(cfg, nodeStore)
+	applyChallengeCaps(cfg, nodeStore)
+	nodeStore.SetLeaderboardRules(cfg.Leaderboard)
+	nodeStore.SetDiversityBonus(cfg.DiversityBonusPercent)
+	nodeStore.SetUptimePenalty(cfg.UptimePenaltyPercent)
+	applyPointsRates(cfg, nodeStore)
+	applyStakingTiers(cfg, nodeStore)
+	verifier.SetChallengeBudget(nodeStore.ChargeChallenge)
+	var follower *replica.Follower
+	if cfg.ReplicaOf != "" {
+		follower = replica.New(cfg.ReplicaOf, cfg.AdminAPIKeys()[0], nodeStore)
+		go func() {
+			ticker := time.NewTicker(time.Duration(cfg.ReplicaSyncSeconds) * time.Second)
+			for {
+				if err := follower.Sync(time.Now().UnixMilli()); err != nil {
+					log.Printf("replica sync from %s failed, serving the last copy: %v", cfg.ReplicaOf, err)
+				}
+				<-ticker.C
+			}
+		}()
+	}
+	if cfg.PendingFile != "" && follower == nil {
+		restored, err := verifier.LoadPending(cfg.PendingFile)
+		if err != nil {
+			log.Printf("pending challenges not restored: %v", err)
+		} else if restored > 0 {
+			log.Printf("restored %d pending challenges from %s", restored, cfg.PendingFile)
+		}
+	}
+	if cfg.WebhookURL != "" {
+		nodeStore.SetNotifier(notify.NewWebhook(cfg.WebhookURL).SignWith(verifier.Keys()))
+	}
+	alerts := anomaly.NewMonitor()
+	alerts.SetLimits(cfg.Alerts)
+	var admins notify.Multi
+	if cfg.AdminWebhookURL != "" {
+		hook := notify.NewWebhook(cfg.AdminWebhookURL).SignWith(verifier.Keys())
+		nodeStore.ModerationLog().SetNotifier(hook)
+		admins = append(admins, hook)
+	}
+	if email := cfg.AlertEmail; len(email.To) > 0 {
+		admins = append(admins, notify.NewEmail(email.SMTPAddr, email.From, email.To, email.Username, email.Password))
+	}
+	alerts.SetNotifier(admins)
+	if err := verifier.Flags().Apply(cfg.FeatureFlags); err != nil {
+		log.Fatalf("invalid FEATURE_FLAGS: %v", err)
+	}
+	requests := metrics.NewRecorder()
+	requests.SetSlowThreshold(time.Duration(cfg.SlowRequestMs) * time.Millisecond)
+	verifier.Queue().SetSlots(int(cfg.WorkSlots))
+	cors := api.NewCORSPolicy(cfg.CORSOrigins...)
+	limiter := ratelimit.New(nil)
+	applyRatePlans(cfg, limiter)
+	var walletNames *names.Resolver
+	if cfg.NamesRegistry != "" {
+		namesRPC := cfg.NamesRPC
+		if namesRPC == "" {
+			namesRPC = cfg.TrustedRPC
+		}
+		walletNames = names.NewResolver(rpc.NewTrustedClient(namesRPC), cfg.NamesRegistry)
+	}
+	var stakes *staking.Reader
+	if cfg.StakingContract != "" {
+		stakingRPC := cfg.StakingRPC
+		if stakingRPC == "" {
+			stakingRPC = cfg.TrustedRPC
+		}
+		stakes = staking.NewReader(rpc.NewTrustedClient(stakingRPC), cfg.StakingContract)
+	}
+
+	// Reload the safe subset of settings, on SIGHUP or from the admin API.
+	// Challenges keep being issued throughout.
+	var reloadMu sync.Mutex
+	current := cfg
+	reload := func() ([]string, error) {
+		reloadMu.Lock()
+		defer reloadMu.Unlock()
 
-	// Start cleanup goroutine
+		godotenv.Overload()
+		next, skipped, err := current.Reload()
+		if err != nil {
+			log.Printf("config reload rejected, keeping current settings: %v", err)
+			return nil, err
+		}
+		if len(skipped) > 0 {
+			log.Printf("config reload: restart required to change %s", strings.Join(skipped, ", "))
+		}
+		prev := current
+		current = next
+		applyThresholds(current, nodeStore, verifier)
+		applySigning(current, verifier)
+		applyTrusted(prev, current, verifier)
+		verifier.SetPublicProviders(current.PublicProviders)
+		applyClientVersions(current, nodeStore)
+		applyChallengeCaps(current, nodeStore)
+		nodeStore.SetLeaderboardRules(current.Leaderboard)
+		nodeStore.SetDiversityBonus(current.DiversityBonusPercent)
+		nodeStore.SetUptimePenalty(current.UptimePenaltyPercent)
+		applyPointsRates(current, nodeStore)
+		applyStakingTiers(current, nodeStore)
+		requests.SetSlowThreshold(time.Duration(current.SlowRequestMs) * time.Millisecond)
+		verifier.Queue().SetSlots(int(current.WorkSlots))
+		cors.SetOrigins(current.CORSOrigins)
+		applyRatePlans(current, limiter)
+		alerts.SetLimits(current.Alerts)
+		log.Printf("config reloaded")
+		return skipped, nil
+	}
 	go func() {
-		ticker := time.NewTicker(1 * time.Minute)
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		for range hup {
+			reload()
+		}
+	}()
+
+	// Work that changes the store is the writer's alone; a replica's copy
+	// is replaced on every sync
+	if follower == nil {
+		// Start cleanup goroutine
+		go func() {
+			ticker := time.NewTicker(1 * time.Minute)
+			for range ticker.C {
+				cleaned := verifier.CleanupExpiredChallenges()
+				if cleaned > 0 {
+					log.Printf("cleaned up %d expired challenges", cleaned)
+				}
+
+				for _, id := range nodeStore.ExpireMaintenance(time.Now().UnixMilli()) {
+					log.Printf("node %s used up its maintenance allowance, resumed", id)
+				}
+
+				for _, id := range nodeStore.ApplyDueReclassifications(time.Now().UnixMilli()) {
+					log.Printf("node %s reclassified after its grace period", id)
+				}
+
+				for _, id := range nodeStore.InactivateSilentNodes(time.Now().UnixMilli()) {
+					log.Printf("node %s went silent, marked inactive", id)
+				}
+
+				for _, id := range nodeStore.EnforceUptime(time.Now().UnixMilli()) {
+					log.Printf("node %s fell below its 7-day uptime target", id)
+				}
+
+				// Trouble across the whole network: a failing trusted RPC, a Sybil wave
+				for _, alert := range alerts.Check(anomaly.Gather(nodeStore.NetworkActivity, time.Now().UnixMilli()), time.Now().UnixMilli()) {
+					log.Printf("ALERT %s: %s", alert.Rule, alert.Message)
+					if alert.Rule == anomaly.RuleTypeCollapse {
+						disableChallengeType(verifier, nodeStore, alert)
+					}
+				}
+
+				// Nodes shouldn't lose uptime points to our own downtime
+				for _, outage := range verifier.ServiceStatus().Settle(time.Now().UnixMilli()) {
+					credited := nodeStore.CompensateOutage(outage, time.Now().UnixMilli())
+					log.Printf("service outage (%s) of %d minutes, compensated %d nodes",
+						outage.Cause, (outage.End-outage.Start)/(60*1000), len(credited))
+				}
+
+				// Reweigh countries for the diversity bonus weekly, or as soon
+				// as the first ones are known
+				if weights := nodeStore.RegionWeights(); len(weights.Regions) == 0 || time.Since(time.UnixMilli(weights.UpdatedAt)) >= 7*24*time.Hour {
+					if weights = nodeStore.RecalculateRegionWeights(time.Now().UnixMilli()); len(weights.Regions) > 0 {
+						log.Printf("region weights recalculated for %d countries", len(weights.Regions))
+					}
+				}
+
+				// Surprise challenges: settle the last round, then maybe send
+				// more. None go out during maintenance.
+				nodeStore.ExpireSurprises(time.Now().UnixMilli())
+				if nodeStore.ServiceMaintenance() == nil {
+					for _, ch := range verifier.IssueSurprises(nodeStore.GetAllActiveNodes(), time.Now().UnixMilli()) {
+						nodeStore.RecordSurpriseIssued(ch)
+					}
+				}
+			}
+		}()
+	}
+
+	// Keep the header chain block-hash answers are checked against, if
+	// there is one. A reload can add, replace or remove it.
+	go func() {
+		ticker := time.NewTicker(10 * time.Second)
+		for {
+			if headers := verifier.HeaderChain(); headers != nil {
+				headers.Sync()
+			}
+			<-ticker.C
+		}
+	}()
+
+	if follower == nil {
+		// Ahead of hard forks, ask exposed nodes what they run so readiness
+		// (and early-upgrade bonuses) don't wait for the next heartbeat
+		go func() {
+			ticker := time.NewTicker(10 * time.Minute)
+			for range ticker.C {
+				upcoming := make(map[types.Chain]bool)
+				for _, fork := range nodeStore.HardForkReadiness(time.Now().UnixMilli()) {
+					if !fork.Activated {
+						upcoming[fork.Chain] = true
+					}
+				}
+				for _, node := range nodeStore.GetAllActiveNodes() {
+					if node.VerificationMethod != types.ExposedRPC || node.Paused || !upcoming[node.NodeType.Chain()] {
+						continue
+					}
+					nodeStore.RecordClientVersion(node.ID, verifier.CheckClientVersion(node))
+				}
+			}
+		}()
+	}
+
+	// Give back the capacity trimmed histories leave behind
+	go func() {
+		ticker := time.NewTicker(1 * time.Hour)
 		for range ticker.C {
-			cleaned := verifier.CleanupExpiredChallenges()
-			if cleaned > 0 {
-				log.Printf("cleaned up %d expired challenges", cleaned)
+			if freed := nodeStore.Compact(time.Now().UnixMilli()); freed.SlotsFreed > 0 {
+				log.Printf("store compaction: %d slices, ~%d bytes freed", freed.Slices, freed.BytesFreed)
+			}
+		}
+	}()
+
+	// Mark the service alive (or in maintenance) for its own status
+	// report, more than once a minute so none is missed. Maintenance
+	// counts as an outage, so nodes are made up for the uptime it cost.
+	go func() {
+		ticker := time.NewTicker(15 * time.Second)
+		for {
+			verifier.ServiceStatus().Alive(time.Now().UnixMilli())
+			if nodeStore.ServiceMaintenance() != nil {
+				verifier.ServiceStatus().Maintenance(time.Now().UnixMilli())
 			}
+			<-ticker.C
 		}
 	}()
 
+	// Look up names for wallets that are new or due a recheck. Lookups are
+	// cached, so most passes make no calls.
+	if walletNames != nil {
+		go func() {
+			ticker := time.NewTicker(10 * time.Minute)
+			for {
+				var wallets []string
+				for _, node := range nodeStore.GetAllActiveNodes() {
+					wallets = append(wallets, node.WalletAddress)
+				}
+				if changed, failed := walletNames.Refresh(wallets, time.Now().UnixMilli()); changed > 0 || failed > 0 {
+					log.Printf("wallet names: %d changed, %d lookups failed", changed, failed)
+				}
+				<-ticker.C
+			}
+		}()
+	}
+
+	// Read what operators have staked, for the stake bonus. Only the writer
+	// reads; replicas get the stakes with the rest of the snapshot.
+	if stakes != nil && follower == nil {
+		go func() {
+			ticker := time.NewTicker(staking.ReadInterval)
+			for {
+				var wallets []string
+				for _, node := range nodeStore.GetAllActiveNodes() {
+					wallets = append(wallets, node.WalletAddress)
+				}
+				read, failed := stakes.Read(wallets, time.Now().UnixMilli())
+				nodeStore.RecordStakes(read)
+				if failed > 0 {
+					log.Printf("staking: %d of %d stake reads failed", failed, len(read)+failed)
+				}
+				<-ticker.C
+			}
+		}()
+	}
+
+	// Measure public RPC latency so nodes proxying to them stand out
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		for range ticker.C {
+			verifier.ProbeProviders()
+		}
+	}()
+
+	// pprof and map sizes on their own listener, never on the public port.
+	// Off loopback it takes an admin key.
+	if cfg.DiagnosticsAddr != "" {
+		var keys []string
+		if !diagnostics.IsLoopback(cfg.DiagnosticsAddr) {
+			keys = cfg.AdminAPIKeys()
+		}
+		handler := diagnostics.Handler(map[string]diagnostics.Sizer{"store": nodeStore, "verifier": verifier}, keys)
+		go func() {
+			if err := http.ListenAndServe(cfg.DiagnosticsAddr, handler); err != nil {
+				log.Printf("diagnostics listener stopped: %v", err)
+			}
+		}()
+	}
+
 	// Setup router
-	router := api.SetupRouter(nodeStore, verifier, adminAPIKey)
+	gin.SetMode(cfg.GinMode)
+	router, err := api.NewRouter(nodeStore, verifier, api.Options{
+		AdminAPIKeys:    cfg.AdminAPIKeys(),
+		Metrics:         requests,
+		Alerts:          alerts,
+		Limiter:         limiter,
+		Names:           walletNames,
+		TrustedProxies:  cfg.TrustedProxies,
+		ClientIPHeaders: cfg.ClientIPHeaders,
+		CountryHeader:   cfg.CountryHeader,
+		CORS:            cors,
+		Reload:          reload,
+		Replica:         follower,
+		Cache:           shared,
+	})
+	if err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	fmt.Println("")
 	fmt.Println("Endpoints:")
 	fmt.Println("  POST /api/nodes/register     - Register a new node")
 	fmt.Println("  GET  /api/nodes/:id          - Get node details")
 	fmt.Println("  GET  /api/nodes/:id/stats    - Get node statistics")
-	fmt.Println("  GET  /api/challenges/request - Request a challenge")
+	fmt.Println("  GET  /api/challenges/request - Request a challenge (signed)")
 	fmt.Println("  POST /api/challenges/submit  - Submit challenge response")
+	fmt.Println("  POST /api/challenges/:id/validate - Dry-run an answer (admin or signed)")
 	fmt.Println("  POST /api/verify/:id         - Verify exposed-rpc node")
 	fmt.Println("  GET  /api/leaderboard        - Get top nodes")
+	fmt.Println("  GET  /api/search?q=          - Find nodes by ID, wallet or tag")
 	fmt.Println("  GET  /api/stats              - Get network stats")
+	fmt.Println("  GET  /api/service-status     - This service's own uptime and outages")
+	fmt.Println("  GET  /api/admin/metrics      - Per-route latency and slow requests")
+	fmt.Println("  POST /api/admin/config/reload - Reload config without a restart (also SIGHUP)")
 	fmt.Println("============================================================")
 	fmt.Println("Server ready!")
 	fmt.Println("")
 
-	// Start server
-	if err := router.Run(":" + port); err != nil {
+	// Start server. On SIGTERM or Ctrl-C, let requests in flight finish and
+	// save unanswered challenges so the next process can take them over.
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: router}
+	stopped := make(chan struct{})
+	go func() {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, syscall.SIGTERM, os.Interrupt)
+		<-stop
+		log.Printf("shutting down")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Printf("shutdown: %v", err)
+		}
+		if cfg.PendingFile != "" && follower == nil {
+			saved, err := verifier.SavePending(cfg.PendingFile)
+			if err != nil {
+				log.Printf("pending challenges not saved: %v", err)
+			} else {
+				log.Printf("saved %d pending challenges to %s", saved, cfg.PendingFile)
+			}
+		}
+		if durable != nil {
+			if rows, err := durable.Checkpoint(); err != nil {
+				log.Printf("final store checkpoint failed: %v", err)
+			} else {
+				log.Printf("saved the store to %s (%d rows written)", cfg.StoreBackend, rows)
+			}
+			durable.Close()
+		}
+		close(stopped)
+	}()
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatalf("failed to start server: %v", err)
 	}
+	<-stopped
+}
+
+// Stop issuing a challenge type that fails across the network, until an
+// admin turns its flag back on
+func disableChallengeType(verifier *verification.Verifier, nodeStore store.Store, alert anomaly.Alert) {
+	name := challenge.FlagName(alert.ChallengeType)
+	if flag, ok := verifier.Flags().Get(name); !ok || flag.Percent == 0 {
+		return
+	}
+	if err := verifier.Flags().Set(name, 0); err != nil {
+		log.Printf("failed to disable %s challenges: %v", alert.ChallengeType, err)
+		return
+	}
+	details := map[string]string{"percent": "0"}
+	nodeStore.ModerationLog().Append(modlog.ActionSetFlag, "auto", name, alert.Message, details, time.Now().UnixMilli())
+	log.Printf("stopped issuing %s challenges", alert.ChallengeType)
+}
+
+// Load the store from its database, if it's kept in one. Nil for memory.
+func openStore(cfg *config.Config, nodeStore *store.MemoryStore) (*store.DurableStore, int64, error) {
+	switch cfg.StoreBackend {
+	case store.BackendPostgres:
+		return store.OpenPostgres(cfg.DatabaseURL, nodeStore)
+	case store.BackendSQLite:
+		return store.OpenSQLite(cfg.SQLitePath, nodeStore)
+	}
+	return nil, 0, nil
+}
+
+func applyThresholds(cfg *config.Config, nodeStore store.Store, verifier *verification.Verifier) {
+	t := cfg.Thresholds
+	verifier.SetLatencyThresholds(t.LatencySuspiciousMs, t.LatencyMaxMs)
+	nodeStore.SetEscalationThresholds(t.WarningThreshold, t.FlagThreshold)
+	nodeStore.SetFingerprintWalletThreshold(int(t.FingerprintWalletThreshold))
+	nodeStore.SetTrustWeightedPoints(t.TrustWeightedPoints)
+	nodeStore.SetWalletBanCooldown(time.Duration(t.WalletBanCooldownDays) * 24 * time.Hour)
+	nodeStore.SetBanApprovalWindow(time.Duration(t.BanApprovalMinutes) * time.Minute)
+	nodeStore.SetSilentAfter(time.Duration(t.SilentNodeHours) * time.Hour)
+}
+
+// Load signing keys. A changed SERVER_SIGNING_KEY rotates: the old key is
+// retired but stays published for the grace period.
+func applySigning(cfg *config.Config, verifier *verification.Verifier) {
+	keys := verifier.Keys()
+	keys.SetGrace(time.Duration(cfg.Signing.GraceHours) * time.Hour)
+	keys.SetRetired(cfg.Signing.RetiredAddresses)
+
+	if cfg.Signing.Key == "" {
+		keys.Disable()
+		return
+	}
+
+	signer, _ := signing.NewSigner(cfg.Signing.Key) // Already validated
+	if active := keys.Active(); active == nil || active.ID != signer.Address() {
+		keys.Rotate(signer)
+		log.Printf("signing key is now %s", signer.Address())
+	}
+}
+
+// Point the verifier at the trusted endpoints. prev is the config they
+// were last set from, nil at startup; the header chain is only rebuilt
+// (and resynced) when its RPCs or quorum change.
+func applyTrusted(prev, cfg *config.Config, verifier *verification.Verifier) {
+	verifier.SetTrustedRPC(cfg.TrustedRPC)
+	verifier.SetTrustedOpbnbRPC(cfg.TrustedOpbnbRPC)
+	verifier.SetTrustedGreenfieldSP(cfg.TrustedGreenfieldSP)
+
+	if prev != nil && strings.Join(prev.HeaderChainRPCs, ",") == strings.Join(cfg.HeaderChainRPCs, ",") && prev.HeaderChainQuorum == cfg.HeaderChainQuorum {
+		return
+	}
+	if len(cfg.HeaderChainRPCs) == 0 {
+		verifier.SetHeaderChain(nil)
+		return
+	}
+	verifier.SetHeaderChain(headerchain.FromEndpoints(cfg.HeaderChainRPCs, int(cfg.HeaderChainQuorum)))
+}
+
+func applyPointsRates(cfg *config.Config, nodeStore store.Store) {
+	pointsRates, _ := rates.Parse(cfg.PointsPerHour) // Already validated
+	nodeStore.SetPointsRates(pointsRates)
+}
+
+func applyStakingTiers(cfg *config.Config, nodeStore store.Store) {
+	tiers, _ := staking.ParseTiers(cfg.StakingTiers) // Already validated
+	nodeStore.SetStakingTiers(tiers)
+}
+
+func applyClientVersions(cfg *config.Config, nodeStore store.Store) {
+	mins, _ := clientversion.ParseMinimums(cfg.MinClientVersions) // Already validated
+	nodeStore.SetMinClientVersions(mins)
+	forks, _ := hardfork.Parse(cfg.HardForks)
+	nodeStore.SetHardForks(forks)
+	builds, _ := proverbuild.ParseAllowlist(cfg.ProverBuilds) // Already validated
+	nodeStore.SetOfficialBuilds(builds)
+}
+
+func applyRatePlans(cfg *config.Config, limiter *ratelimit.Limiter) {
+	plans, _ := ratelimit.ParsePlans(cfg.APIRatePlans) // Already validated
+	limiter.SetPlans(plans)
+}
+
+func applyChallengeCaps(cfg *config.Config, nodeStore store.Store) {
+	caps, _ := budget.ParseCaps(cfg.ChallengeDailyCaps) // Already validated
+	nodeStore.SetChallengeCaps(caps)
 }
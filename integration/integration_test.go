@@ -0,0 +1,255 @@
+package integration
+
+// End-to-end tests across the prover/server boundary.
+//
+// Each test spins up a mock JSON-RPC chain, a real API server backed by it,
+// and the real prover binary built from cmd/prover. Skipped with -short since
+// building the prover takes a few seconds.
+//
+//   go test ./integration/...
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/api"
+	"github.com/depinonbnb/depin/internal/mockchain"
+	"github.com/depinonbnb/depin/internal/store"
+	"github.com/depinonbnb/depin/internal/types"
+	"github.com/depinonbnb/depin/internal/verification"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+type testEnv struct {
+	chain  *mockchain.Chain
+	store  *store.MemoryStore
+	server *httptest.Server
+	rpc    *httptest.Server
+}
+
+func setupEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	chain := mockchain.New(46000000)
+	rpcServer := httptest.NewServer(chain)
+	t.Cleanup(rpcServer.Close)
+
+	s := store.NewStore()
+	v := verification.NewVerifier(rpcServer.URL)
+	server := httptest.NewServer(api.SetupRouter(s, v, ""))
+	t.Cleanup(server.Close)
+
+	return &testEnv{
+		chain:  chain,
+		store:  s,
+		server: server,
+		rpc:    rpcServer,
+	}
+}
+
+// Build cmd/prover into a temp dir for this test
+func buildProver(t *testing.T) string {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	bin := filepath.Join(t.TempDir(), "prover")
+	if runtime.GOOS == "windows" {
+		bin += ".exe"
+	}
+
+	cmd := exec.Command("go", "build", "-o", bin, "../cmd/prover")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build prover: %v\n%s", err, out)
+	}
+	return bin
+}
+
+func newWallet(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return hex.EncodeToString(crypto.FromECDSA(key)), strings.ToLower(crypto.PubkeyToAddress(key.PublicKey).Hex())
+}
+
+func startProver(t *testing.T, ctx context.Context, bin string, env *testEnv, privateKey string, nodeType types.NodeType) *exec.Cmd {
+	t.Helper()
+
+	cmd := exec.CommandContext(ctx, bin,
+		"--private-key", privateKey,
+		"--node-rpc", env.rpc.URL,
+		"--api", env.server.URL+"/api",
+		"--node-type", string(nodeType),
+		"--interval", "100",
+	)
+	cmd.Env = os.Environ()
+	return cmd
+}
+
+// Wait until check returns true or the deadline passes
+func waitFor(timeout time.Duration, check func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if check() {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return false
+}
+
+func TestProverFullFlow(t *testing.T) {
+	bin := buildProver(t)
+	env := setupEnv(t)
+	privateKey, wallet := newWallet(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cmd := startProver(t, ctx, bin, env, privateKey, types.BscArchive)
+	var output strings.Builder
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start prover: %v", err)
+	}
+	defer cmd.Wait()
+
+	// Registration
+	registered := waitFor(10*time.Second, func() bool {
+		return len(env.store.GetNodesByWallet(wallet)) == 1
+	})
+	if !registered {
+		cancel()
+		cmd.Wait()
+		t.Fatalf("prover never registered\n%s", output.String())
+	}
+
+	node := env.store.GetNodesByWallet(wallet)[0]
+	if node.NodeType != types.BscArchive {
+		t.Errorf("expected node type bsc-archive, got %s", node.NodeType)
+	}
+	if node.VerificationMethod != types.LocalProver {
+		t.Errorf("expected local-prover method, got %s", node.VerificationMethod)
+	}
+
+	// Challenge -> submit, a few rounds
+	passed := waitFor(15*time.Second, func() bool {
+		n := env.store.GetNode(node.ID)
+		return n != nil && n.TotalChallengesPassed >= 3
+	})
+	cancel()
+	cmd.Wait()
+
+	if !passed {
+		t.Fatalf("prover did not pass 3 challenges\n%s", output.String())
+	}
+
+	// Points
+	n := env.store.GetNode(node.ID)
+	if n.TotalChallengesFailed != 0 {
+		t.Errorf("expected no failed challenges, got %d\n%s", n.TotalChallengesFailed, output.String())
+	}
+	if n.TotalPoints < types.BscArchive.RegistrationBonus() {
+		t.Errorf("expected at least %d points, got %d", types.BscArchive.RegistrationBonus(), n.TotalPoints)
+	}
+
+	stats := env.store.GetNodeStats(node.ID)
+	if stats.ChallengePassRate != 100 {
+		t.Errorf("expected 100%% pass rate, got %.1f", stats.ChallengePassRate)
+	}
+
+	walletStats := env.store.GetWalletStats(wallet)
+	if walletStats == nil || walletStats.TotalPoints != n.TotalPoints {
+		t.Errorf("wallet stats don't match node points: %+v", walletStats)
+	}
+}
+
+func TestProverRefusesUnsyncedNode(t *testing.T) {
+	bin := buildProver(t)
+	env := setupEnv(t)
+	env.chain.SetSyncing(true)
+	privateKey, wallet := newWallet(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cmd := startProver(t, ctx, bin, env, privateKey, types.BscFull)
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("prover should exit with an error for an unsynced node\n%s", out)
+	}
+	if !strings.Contains(string(out), "not fully synced") {
+		t.Errorf("expected sync error in output, got:\n%s", out)
+	}
+
+	if len(env.store.GetNodesByWallet(wallet)) != 0 {
+		t.Error("unsynced node should not be registered")
+	}
+}
+
+func TestProverTunnel(t *testing.T) {
+	bin := buildProver(t)
+	env := setupEnv(t)
+	privateKey, wallet := newWallet(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cmd := startProver(t, ctx, bin, env, privateKey, types.BscFull)
+	cmd.Args = append(cmd.Args, "--tunnel")
+	var output strings.Builder
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start prover: %v", err)
+	}
+	defer cmd.Wait()
+
+	registered := waitFor(10*time.Second, func() bool {
+		return len(env.store.GetNodesByWallet(wallet)) == 1
+	})
+	if !registered {
+		cancel()
+		cmd.Wait()
+		t.Fatalf("prover never registered\n%s", output.String())
+	}
+
+	node := env.store.GetNodesByWallet(wallet)[0]
+	if node.VerificationMethod != types.ExposedRPC || node.RPCEndpoint != "tunnel://"+node.ID {
+		t.Errorf("expected an exposed-rpc node on its own tunnel, got %s at %q", node.VerificationMethod, node.RPCEndpoint)
+	}
+
+	// The server verifies the node itself, through the prover
+	passed := waitFor(15*time.Second, func() bool {
+		n := env.store.GetNode(node.ID)
+		return n != nil && n.TotalChallengesPassed >= 3
+	})
+	cancel()
+	cmd.Wait()
+
+	if !passed {
+		t.Fatalf("node did not pass 3 verifications through the tunnel\n%s", output.String())
+	}
+	if n := env.store.GetNode(node.ID); n.TotalChallengesFailed != 0 {
+		t.Errorf("expected no failed verifications, got %d\n%s", n.TotalChallengesFailed, output.String())
+	}
+}
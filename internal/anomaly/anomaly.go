@@ -0,0 +1,242 @@
+// Package anomaly watches network-wide activity for trouble no single node
+// shows. A pass rate collapsing across the board usually means the trusted
+// RPC is failing, not every node at once; a burst of registrations usually
+// means a Sybil farm. A rule alerts admins once when it starts matching and
+// again when it stops.
+package anomaly
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/notify"
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// How far things have to move before the rules fire
+type Limits struct {
+	PassRateDropPercent     uint64 // Relative to the hour before, 0 = off
+	RegistrationSpike       uint64 // Multiple of the previous day's hourly average, 0 = off
+	TypePassRateDropPercent uint64 // One challenge type against its own previous day, 0 = off
+}
+
+// Network-wide activity the rules look at
+type Activity struct {
+	LastHour types.NetworkActivity
+	PrevHour types.NetworkActivity
+	PrevDay  types.NetworkActivity // The 24 hours before the last hour
+}
+
+// Fill in the windows the rules compare from a store's counts (see
+// store.NetworkActivity)
+func Gather(activity func(since, until int64) types.NetworkActivity, now int64) Activity {
+	hour := time.Hour.Milliseconds()
+	return Activity{
+		LastHour: activity(now-hour, now),
+		PrevHour: activity(now-2*hour, now-hour),
+		PrevDay:  activity(now-25*hour, now-hour),
+	}
+}
+
+// Below these, a swing is noise
+const (
+	minChecks        = 20 // In each of the two hours compared
+	minRegistrations = 10 // In the last hour
+)
+
+// A rule returns what's wrong, or false if nothing is
+type Rule struct {
+	Name  string
+	Check func(a Activity, limits Limits) (string, bool)
+}
+
+// Checked in order every time Check is called
+var Rules = []Rule{
+	{"pass-rate-drop", passRateDrop},
+	{"registration-spike", registrationSpike},
+}
+
+// A rule checked for each challenge type answered in the last day
+type TypeRule struct {
+	Name  string
+	Check func(a Activity, challengeType types.ChallengeType, limits Limits) (string, bool)
+}
+
+// Fires for a type whose pass rate collapsed on its own. Callers stop
+// issuing the type when it fires.
+const RuleTypeCollapse = "challenge-type-collapse"
+
+var TypeRules = []TypeRule{
+	{RuleTypeCollapse, typeCollapse},
+}
+
+func passRateDrop(a Activity, limits Limits) (string, bool) {
+	if limits.PassRateDropPercent == 0 || a.LastHour.Checks < minChecks || a.PrevHour.Checks < minChecks {
+		return "", false
+	}
+	now := a.LastHour.PassRate()
+	before := a.PrevHour.PassRate()
+	if before == 0 || (before-now)/before*100 < float64(limits.PassRateDropPercent) {
+		return "", false
+	}
+	return fmt.Sprintf("network pass rate fell from %.1f%% to %.1f%% in the last hour (%d checks); the trusted RPC may be failing",
+		before, now, a.LastHour.Checks), true
+}
+
+func registrationSpike(a Activity, limits Limits) (string, bool) {
+	if limits.RegistrationSpike == 0 || a.LastHour.Registrations < minRegistrations {
+		return "", false
+	}
+	hourly := float64(a.PrevDay.Registrations) / 24
+	if float64(a.LastHour.Registrations) < hourly*float64(limits.RegistrationSpike) {
+		return "", false
+	}
+	return fmt.Sprintf("%d registrations in the last hour against %.1f an hour the day before; possible Sybil attack",
+		a.LastHour.Registrations, hourly), true
+}
+
+// One type's pass rate fell by the limit against its baseline, the day
+// before, while the other types held up. When they all fell together the
+// cause is shared, and passRateDrop covers it.
+func typeCollapse(a Activity, challengeType types.ChallengeType, limits Limits) (string, bool) {
+	if limits.TypePassRateDropPercent == 0 {
+		return "", false
+	}
+	now, baseline := a.LastHour.ByType[challengeType], a.PrevDay.ByType[challengeType]
+	if now.Checks < minChecks || baseline.Checks < minChecks || !dropped(baseline.PassRate(), now.PassRate(), limits.TypePassRateDropPercent) {
+		return "", false
+	}
+
+	var othersNow, othersBefore types.ChallengeCounts
+	for t, counts := range a.LastHour.ByType {
+		if t != challengeType {
+			othersNow.Checks += counts.Checks
+			othersNow.Passed += counts.Passed
+		}
+	}
+	for t, counts := range a.PrevDay.ByType {
+		if t != challengeType {
+			othersBefore.Checks += counts.Checks
+			othersBefore.Passed += counts.Passed
+		}
+	}
+	if othersNow.Checks >= minChecks && dropped(othersBefore.PassRate(), othersNow.PassRate(), limits.TypePassRateDropPercent) {
+		return "", false
+	}
+
+	return fmt.Sprintf("%s pass rate fell to %.1f%% in the last hour (%d checks) from %.1f%% the day before, while other types held up; the challenge may be broken",
+		challengeType, now.PassRate(), now.Checks, baseline.PassRate()), true
+}
+
+// Whether now is at least percent below before, relatively
+func dropped(before, now float64, percent uint64) bool {
+	return before > 0 && (before-now)/before*100 >= float64(percent)
+}
+
+const historySize = 100 // Alerts kept for the admin API
+
+type Alert struct {
+	Rule          string              `json:"rule"`
+	ChallengeType types.ChallengeType `json:"challenge_type,omitempty"` // Type rules only
+	Message       string              `json:"message"`                  // As of when it fired
+	FiredAt       int64               `json:"fired_at"`
+	ResolvedAt    int64               `json:"resolved_at,omitempty"` // Unset while it's still matching
+}
+
+type Monitor struct {
+	mu       sync.Mutex
+	limits   Limits
+	notifier notify.Notifier
+	firing   map[string]*Alert // By rule, and type for type rules
+	history  []*Alert          // Oldest first
+}
+
+func NewMonitor() *Monitor {
+	return &Monitor{notifier: notify.Nop{}, firing: make(map[string]*Alert)}
+}
+
+func (m *Monitor) SetLimits(limits Limits) {
+	m.mu.Lock()
+	m.limits = limits
+	m.mu.Unlock()
+}
+
+// Where alerts and resolutions are sent
+func (m *Monitor) SetNotifier(n notify.Notifier) {
+	m.mu.Lock()
+	m.notifier = n
+	m.mu.Unlock()
+}
+
+// Run every rule against the latest activity, and every type rule for each
+// type answered in the last day or still alerting. Returns the alerts
+// that just fired.
+func (m *Monitor) Check(a Activity, now int64) []Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var fired []Alert
+	evaluate := func(key string, alert Alert, matching bool) {
+		firing := m.firing[key]
+		switch {
+		case matching && firing == nil:
+			alert.FiredAt = now
+			m.firing[key] = &alert
+			m.history = append(m.history, &alert)
+			if len(m.history) > historySize {
+				m.history = m.history[len(m.history)-historySize:]
+			}
+			fired = append(fired, alert)
+			m.notifier.Notify(notify.Event{Type: notify.EventAnomaly, Rule: alert.Rule, Message: alert.Message, Timestamp: now})
+		case !matching && firing != nil:
+			firing.ResolvedAt = now
+			delete(m.firing, key)
+			m.notifier.Notify(notify.Event{Type: notify.EventAnomalyResolved, Rule: firing.Rule, Message: firing.Message, Timestamp: now})
+		}
+	}
+
+	for _, rule := range Rules {
+		message, matching := rule.Check(a, m.limits)
+		evaluate(rule.Name, Alert{Rule: rule.Name, Message: message}, matching)
+	}
+
+	seen := make(map[types.ChallengeType]bool)
+	for t := range a.LastHour.ByType {
+		seen[t] = true
+	}
+	for t := range a.PrevDay.ByType {
+		seen[t] = true
+	}
+	for _, alert := range m.firing {
+		if alert.ChallengeType != "" {
+			seen[alert.ChallengeType] = true
+		}
+	}
+	challengeTypes := make([]types.ChallengeType, 0, len(seen))
+	for t := range seen {
+		challengeTypes = append(challengeTypes, t)
+	}
+	sort.Slice(challengeTypes, func(i, j int) bool { return challengeTypes[i] < challengeTypes[j] })
+
+	for _, rule := range TypeRules {
+		for _, t := range challengeTypes {
+			message, matching := rule.Check(a, t, m.limits)
+			evaluate(rule.Name+"/"+string(t), Alert{Rule: rule.Name, ChallengeType: t, Message: message}, matching)
+		}
+	}
+	return fired
+}
+
+// Recent alerts, newest first
+func (m *Monitor) Alerts() []Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	alerts := make([]Alert, len(m.history))
+	for i, alert := range m.history {
+		alerts[len(m.history)-1-i] = *alert
+	}
+	return alerts
+}
@@ -0,0 +1,162 @@
+package anomaly
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/depinonbnb/depin/internal/notify"
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+type recorder struct{ events []notify.Event }
+
+func (r *recorder) Notify(event notify.Event) { r.events = append(r.events, event) }
+
+var defaults = Limits{PassRateDropPercent: 20, RegistrationSpike: 10}
+
+func TestPassRateDrop(t *testing.T) {
+	tests := []struct {
+		name        string
+		before, now types.NetworkActivity
+		limits      Limits
+		want        bool
+	}{
+		{"steady", types.NetworkActivity{Checks: 100, Passed: 95}, types.NetworkActivity{Checks: 100, Passed: 90}, defaults, false},
+		{"collapse", types.NetworkActivity{Checks: 100, Passed: 95}, types.NetworkActivity{Checks: 100, Passed: 40}, defaults, true},
+		{"exactly the limit", types.NetworkActivity{Checks: 100, Passed: 100}, types.NetworkActivity{Checks: 100, Passed: 80}, defaults, true},
+		{"too few checks", types.NetworkActivity{Checks: 10, Passed: 10}, types.NetworkActivity{Checks: 10, Passed: 0}, defaults, false},
+		{"off", types.NetworkActivity{Checks: 100, Passed: 95}, types.NetworkActivity{Checks: 100, Passed: 0}, Limits{}, false},
+	}
+	for _, tt := range tests {
+		_, got := passRateDrop(Activity{PrevHour: tt.before, LastHour: tt.now}, tt.limits)
+		if got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRegistrationSpike(t *testing.T) {
+	tests := []struct {
+		name      string
+		day, hour uint64
+		want      bool
+	}{
+		{"quiet", 48, 3, false},
+		{"spike", 48, 20, true},
+		{"busy but not 10x", 240, 50, false},
+		{"from nothing, below the floor", 0, 5, false},
+		{"from nothing", 0, 12, true},
+	}
+	for _, tt := range tests {
+		a := Activity{PrevDay: types.NetworkActivity{Registrations: tt.day}, LastHour: types.NetworkActivity{Registrations: tt.hour}}
+		if _, got := registrationSpike(a, defaults); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTypeCollapse(t *testing.T) {
+	limits := Limits{TypePassRateDropPercent: 50}
+	counts := func(checks, passed uint64) types.ChallengeCounts {
+		return types.ChallengeCounts{Checks: checks, Passed: passed}
+	}
+	day := map[types.ChallengeType]types.ChallengeCounts{types.BlockHash: counts(500, 490), types.SyncStatus: counts(500, 495)}
+
+	tests := []struct {
+		name   string
+		hour   map[types.ChallengeType]types.ChallengeCounts
+		limits Limits
+		want   bool
+	}{
+		{"steady", map[types.ChallengeType]types.ChallengeCounts{types.BlockHash: counts(40, 38), types.SyncStatus: counts(40, 40)}, limits, false},
+		{"one type broken", map[types.ChallengeType]types.ChallengeCounts{types.BlockHash: counts(40, 4), types.SyncStatus: counts(40, 40)}, limits, true},
+		{"everything failing", map[types.ChallengeType]types.ChallengeCounts{types.BlockHash: counts(40, 4), types.SyncStatus: counts(40, 5)}, limits, false},
+		{"too few checks", map[types.ChallengeType]types.ChallengeCounts{types.BlockHash: counts(10, 0), types.SyncStatus: counts(40, 40)}, limits, false},
+		{"off", map[types.ChallengeType]types.ChallengeCounts{types.BlockHash: counts(40, 4), types.SyncStatus: counts(40, 40)}, Limits{}, false},
+	}
+	for _, tt := range tests {
+		a := Activity{LastHour: types.NetworkActivity{ByType: tt.hour}, PrevDay: types.NetworkActivity{ByType: day}}
+		if _, got := typeCollapse(a, types.BlockHash, tt.limits); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMonitorTypeRules(t *testing.T) {
+	m := NewMonitor()
+	m.SetLimits(Limits{TypePassRateDropPercent: 50})
+
+	day := types.NetworkActivity{ByType: map[types.ChallengeType]types.ChallengeCounts{
+		types.BlockHash:  {Checks: 500, Passed: 490},
+		types.SyncStatus: {Checks: 500, Passed: 495},
+	}}
+	broken := Activity{PrevDay: day, LastHour: types.NetworkActivity{ByType: map[types.ChallengeType]types.ChallengeCounts{
+		types.BlockHash:  {Checks: 40, Passed: 2},
+		types.SyncStatus: {Checks: 40, Passed: 40},
+	}}}
+
+	fired := m.Check(broken, 1000)
+	if len(fired) != 1 || fired[0].Rule != RuleTypeCollapse || fired[0].ChallengeType != types.BlockHash {
+		t.Fatalf("expected block-hash to collapse alone, got %+v", fired)
+	}
+	if fired := m.Check(broken, 2000); len(fired) != 0 {
+		t.Errorf("a type still collapsed shouldn't fire again, got %+v", fired)
+	}
+
+	// No longer issued, so it drops out of the last hour and resolves
+	m.Check(Activity{}, 3000)
+	if alerts := m.Alerts(); len(alerts) != 1 || alerts[0].ResolvedAt != 3000 {
+		t.Errorf("expected the type alert resolved, got %+v", alerts)
+	}
+}
+
+func TestMonitorFiresOnceAndResolves(t *testing.T) {
+	m := NewMonitor()
+	m.SetLimits(defaults)
+	sent := &recorder{}
+	m.SetNotifier(sent)
+
+	spike := Activity{LastHour: types.NetworkActivity{Registrations: 50}}
+	if fired := m.Check(spike, 1000); len(fired) != 1 || fired[0].Rule != "registration-spike" {
+		t.Fatalf("expected the spike to fire, got %+v", fired)
+	}
+	if fired := m.Check(spike, 2000); len(fired) != 0 {
+		t.Errorf("a rule still matching shouldn't fire again, got %+v", fired)
+	}
+	m.Check(Activity{}, 3000)
+
+	if len(sent.events) != 2 || sent.events[0].Type != notify.EventAnomaly || sent.events[1].Type != notify.EventAnomalyResolved {
+		t.Fatalf("expected an alert then a resolution, got %+v", sent.events)
+	}
+	if !strings.Contains(sent.events[0].Message, "Sybil") {
+		t.Errorf("unexpected message: %s", sent.events[0].Message)
+	}
+
+	alerts := m.Alerts()
+	if len(alerts) != 1 || alerts[0].FiredAt != 1000 || alerts[0].ResolvedAt != 3000 {
+		t.Errorf("unexpected history: %+v", alerts)
+	}
+
+	// Re-armed once resolved
+	if fired := m.Check(spike, 4000); len(fired) != 1 {
+		t.Errorf("expected the spike to fire again, got %+v", fired)
+	}
+	if alerts := m.Alerts(); len(alerts) != 2 || alerts[0].FiredAt != 4000 {
+		t.Errorf("expected newest first, got %+v", alerts)
+	}
+}
+
+func TestGather(t *testing.T) {
+	var windows [][2]int64
+	Gather(func(since, until int64) types.NetworkActivity {
+		windows = append(windows, [2]int64{since, until})
+		return types.NetworkActivity{}
+	}, 100*3600000)
+
+	want := [][2]int64{{99 * 3600000, 100 * 3600000}, {98 * 3600000, 99 * 3600000}, {75 * 3600000, 99 * 3600000}}
+	for i := range want {
+		if windows[i] != want[i] {
+			t.Errorf("window %d: got %v, want %v", i, windows[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// How long a computed leaderboard is served from the shared cache. Long
+// enough that a busy dashboard doesn't rebuild it on every server for every
+// request, short enough that points show up quickly.
+const leaderboardCacheTTL = 10 * time.Second
+
+const leaderboardCacheKey = "leaderboard:"
+
+// A response body another server (or this one) put in the shared cache,
+// nil if there's no cache, nothing there, or the cache failed
+func (h *Handlers) cachedResponse(key string) json.RawMessage {
+	if h.shared == nil {
+		return nil
+	}
+	body, ok, err := h.shared.Get(key)
+	if err != nil {
+		log.Printf("shared cache read of %s failed: %v", key, err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	return body
+}
+
+// Put a response body in the shared cache for ttl. Failures are only
+// logged; the next request computes it again.
+func (h *Handlers) cacheResponse(key string, payload interface{}, ttl time.Duration) {
+	if h.shared == nil {
+		return
+	}
+	body, err := json.Marshal(payload)
+	if err == nil {
+		err = h.shared.Set(key, body, ttl)
+	}
+	if err != nil {
+		log.Printf("shared cache write of %s failed: %v", key, err)
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Which origins browsers may call the API from. Changeable while serving,
+// so a dashboard can be added on a config reload.
+type CORSPolicy struct {
+	mu      sync.RWMutex
+	origins map[string]bool // Nil = any
+}
+
+// A policy allowing origins, or any origin if they include "*" or are
+// empty
+func NewCORSPolicy(origins ...string) *CORSPolicy {
+	p := &CORSPolicy{}
+	p.SetOrigins(origins)
+	return p
+}
+
+func (p *CORSPolicy) SetOrigins(origins []string) {
+	var allowed map[string]bool
+	for _, origin := range origins {
+		if origin == "*" {
+			allowed = nil
+			break
+		}
+		if allowed == nil {
+			allowed = make(map[string]bool)
+		}
+		allowed[origin] = true
+	}
+
+	p.mu.Lock()
+	p.origins = allowed
+	p.mu.Unlock()
+}
+
+// The Access-Control-Allow-Origin to send for a request from origin, ""
+// if it isn't allowed
+func (p *CORSPolicy) allow(origin string) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.origins == nil {
+		return "*"
+	}
+	if p.origins[origin] {
+		return origin
+	}
+	return ""
+}
+
+// CORSMiddleware answers preflights and tells browsers which origins may
+// read responses. A disallowed origin gets no CORS headers, so the
+// browser blocks it; the request itself still runs, as it would for any
+// non-browser client.
+func CORSMiddleware(policy *CORSPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed := policy.allow(c.GetHeader("Origin"))
+		if allowed != "*" {
+			c.Header("Vary", "Origin")
+		}
+		if allowed != "" {
+			c.Header("Access-Control-Allow-Origin", allowed)
+			c.Header("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, "+IdempotencyHeader)
+		}
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(204)
+			return
+		}
+		c.Next()
+	}
+}
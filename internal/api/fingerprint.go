@@ -0,0 +1,62 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/depinonbnb/depin/internal/types"
+	"github.com/gin-gonic/gin"
+)
+
+// Header our TLS terminator puts the client's JA3 hash in. Go's HTTP
+// server doesn't see the raw ClientHello, so we rely on the proxy in
+// front of us. It must strip any copy the client sent.
+const ja3Header = "X-JA3-Fingerprint"
+
+// Headers that differ per request or are added by proxies on the way in
+var volatileHeaders = map[string]bool{
+	"Content-Length":    true,
+	"X-Forwarded-For":   true,
+	"X-Forwarded-Proto": true,
+	"X-Real-Ip":         true,
+	ja3Header:           true,
+}
+
+// Fingerprint the connection a request came in on. Returns a short key
+// for clustering along with the parts it was built from.
+func connectionFingerprint(c *gin.Context) (string, types.ConnectionFingerprint) {
+	conn := types.ConnectionFingerprint{
+		JA3:             c.GetHeader(ja3Header),
+		ClientSignature: clientSignature(c.Request),
+		UserAgent:       c.GetHeader("User-Agent"),
+		RemoteAddr:      c.ClientIP(),
+	}
+
+	sum := sha256.Sum256([]byte(conn.JA3 + "|" + conn.ClientSignature + "|" + conn.RemoteAddr))
+	return hex.EncodeToString(sum[:8]), conn
+}
+
+// Which headers an HTTP client sends, and how, says a lot about the client
+// library. Go doesn't keep header order, so this uses the sorted names.
+func clientSignature(r *http.Request) string {
+	names := make([]string, 0, len(r.Header))
+	for name := range r.Header {
+		if !volatileHeaders[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	sig := strings.Join([]string{
+		r.Proto,
+		strings.Join(names, ","),
+		r.Header.Get("User-Agent"),
+		r.Header.Get("Accept-Encoding"),
+	}, "|")
+
+	sum := sha256.Sum256([]byte(sig))
+	return hex.EncodeToString(sum[:8])
+}
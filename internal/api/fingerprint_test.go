@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/depinonbnb/depin/internal/store"
+	"github.com/depinonbnb/depin/internal/verification"
+	"github.com/gin-gonic/gin"
+)
+
+func TestConnectionFingerprint(t *testing.T) {
+	fingerprint := func(remoteAddr string, headers map[string]string) string {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request, _ = http.NewRequest("POST", "/api/challenges/submit", nil)
+		c.Request.RemoteAddr = remoteAddr
+		for k, v := range headers {
+			c.Request.Header.Set(k, v)
+		}
+		fp, _ := connectionFingerprint(c)
+		return fp
+	}
+
+	prover := map[string]string{"User-Agent": "Go-http-client/1.1", "Content-Type": "application/json"}
+	base := fingerprint("203.0.113.7:5000", prover)
+
+	if fp := fingerprint("203.0.113.7:6000", prover); fp != base {
+		t.Error("source port should not change the fingerprint")
+	}
+	if fp := fingerprint("198.51.100.1:5000", prover); fp == base {
+		t.Error("different source address should change the fingerprint")
+	}
+	if fp := fingerprint("203.0.113.7:5000", map[string]string{"User-Agent": "curl/8.0", "Content-Type": "application/json"}); fp == base {
+		t.Error("different client should change the fingerprint")
+	}
+	if fp := fingerprint("203.0.113.7:5000", map[string]string{"User-Agent": "Go-http-client/1.1", "Content-Type": "application/json", ja3Header: "771,4865-4866"}); fp == base {
+		t.Error("JA3 from the TLS terminator should be part of the fingerprint")
+	}
+}
+
+func TestClientIPBehindProxy(t *testing.T) {
+	clientIP := func(opts Options, remoteAddr string) string {
+		router, err := NewRouter(store.NewStore(), verification.NewVerifier("https://bsc-dataseed1.binance.org"), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		router.GET("/ip", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+
+		req, _ := http.NewRequest("GET", "/ip", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	if ip := clientIP(Options{}, "198.51.100.1:5000"); ip != "198.51.100.1" {
+		t.Errorf("forwarded-for should be ignored without trusted proxies, got %s", ip)
+	}
+	trusted := Options{TrustedProxies: []string{"10.0.0.0/8"}}
+	if ip := clientIP(trusted, "10.0.0.1:5000"); ip != "203.0.113.7" {
+		t.Errorf("forwarded-for from a trusted proxy should be used, got %s", ip)
+	}
+	if ip := clientIP(trusted, "198.51.100.1:5000"); ip != "198.51.100.1" {
+		t.Errorf("forwarded-for from anyone else should be ignored, got %s", ip)
+	}
+	if ip := clientIP(Options{TrustedProxies: []string{"10.0.0.0/8"}, ClientIPHeaders: []string{"X-Real-Ip"}}, "10.0.0.1:5000"); ip != "10.0.0.1" {
+		t.Errorf("only the configured headers should be read, got %s", ip)
+	}
+
+	if _, err := NewRouter(store.NewStore(), nil, Options{TrustedProxies: []string{"lb.internal"}}); err == nil {
+		t.Error("expected an error for a malformed proxy")
+	}
+}
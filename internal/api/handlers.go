@@ -1,24 +1,57 @@
 package api
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/depinonbnb/depin/internal/anomaly"
+	"github.com/depinonbnb/depin/internal/attestation"
+	"github.com/depinonbnb/depin/internal/budget"
+	"github.com/depinonbnb/depin/internal/clientversion"
+	"github.com/depinonbnb/depin/internal/geo"
+	"github.com/depinonbnb/depin/internal/metrics"
+	"github.com/depinonbnb/depin/internal/modlog"
+	"github.com/depinonbnb/depin/internal/names"
+	"github.com/depinonbnb/depin/internal/proverbuild"
+	"github.com/depinonbnb/depin/internal/signing"
+	"github.com/depinonbnb/depin/internal/ratelimit"
+	"github.com/depinonbnb/depin/internal/sharedcache"
 	"github.com/depinonbnb/depin/internal/store"
+	"github.com/depinonbnb/depin/internal/totp"
+	"github.com/depinonbnb/depin/internal/tunnel"
 	"github.com/depinonbnb/depin/internal/types"
 	"github.com/depinonbnb/depin/internal/verification"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/gin-gonic/gin"
 )
 
 type Handlers struct {
-	store    *store.Store
+	store    store.Store
 	verifier *verification.Verifier
+	metrics  *metrics.Recorder
+	alerts   *anomaly.Monitor
+	limiter  *ratelimit.Limiter
+	names    *names.Resolver // Nil = addresses only
+
+	countryHeader string // Set by the proxy, empty = don't record countries
+
+	shared sharedcache.Cache // Nil = nothing is cached across servers
+
+	reload func() ([]string, error) // Nil = config can only be reloaded with SIGHUP
 }
 
-func NewHandlers(store *store.Store, verifier *verification.Verifier) *Handlers {
+func NewHandlers(store store.Store, verifier *verification.Verifier) *Handlers {
 	return &Handlers{
 		store:    store,
 		verifier: verifier,
@@ -34,12 +67,20 @@ type RegisterRequest struct {
 	AuthToken          string                   `json:"auth_token"`
 	Signature          string                   `json:"signature" binding:"required"`
 	Timestamp          int64                    `json:"timestamp" binding:"required"`
+
+	Attestation *types.HardwareAttestation `json:"attestation,omitempty"` // Optional, from the prover
+
+	// Optional PEM client cert and key, for an RPC that only accepts mTLS
+	ClientCert string `json:"client_cert"`
+	ClientKey  string `json:"client_key"`
 }
 
 type RegisterResponse struct {
 	Success bool   `json:"success"`
 	NodeID  string `json:"node_id"`
 	Message string `json:"message"`
+
+	EndpointCheck *verification.EndpointCheck `json:"endpoint_check,omitempty"` // exposed-rpc only
 }
 
 type ChallengeRequestResponse struct {
@@ -49,9 +90,14 @@ type ChallengeRequestResponse struct {
 
 type ChallengePublic struct {
 	ID            string                `json:"id"`
+	NodeID        string                `json:"node_id"`
 	ChallengeType types.ChallengeType   `json:"challenge_type"`
 	Params        types.ChallengeParams `json:"params"`
+	CreatedAt     int64                 `json:"created_at"`
 	ExpiresAt     int64                 `json:"expires_at"`
+	Signature     string                `json:"signature,omitempty"`
+	KeyID         string                `json:"key_id,omitempty"`
+	CommitBy      int64                 `json:"commit_by,omitempty"`
 }
 
 type SubmitChallengeRequest struct {
@@ -61,63 +107,92 @@ type SubmitChallengeRequest struct {
 	Signature      string `json:"signature" binding:"required"`
 	ResponseTimeMs uint64 `json:"response_time_ms"`
 	Timestamp      int64  `json:"timestamp" binding:"required"`
+	Nonce          string `json:"nonce"`          // Commit-reveal challenges only
+	ClientVersion  string `json:"client_version"` // web3_clientVersion of the prover's node
+
+	// Which signed message the signature is over (signing.AnswerMessage),
+	// 0 meaning v1. v2 also signs the challenge type, v3 the prover build.
+	Version       int                 `json:"version"`
+	ChallengeType types.ChallengeType `json:"challenge_type"`
+	Build         string              `json:"build"`
+}
+
+type CommitChallengeRequest struct {
+	ChallengeID string `json:"challenge_id" binding:"required"`
+	NodeID      string `json:"node_id" binding:"required"`
+	Commitment  string `json:"commitment" binding:"required"`
+	Signature   string `json:"signature" binding:"required"`
+	Timestamp   int64  `json:"timestamp" binding:"required"`
+}
+
+// Admins just send the answer; operators also sign for their node
+type ValidateAnswerRequest struct {
+	Answer    string `json:"answer" binding:"required"`
+	NodeID    string `json:"node_id"`
+	Signature string `json:"signature"`
+	Timestamp int64  `json:"timestamp"`
 }
 
 type VerifyResponse struct {
-	Passed         bool   `json:"passed"`
-	FailureReason  string `json:"failure_reason,omitempty"`
-	ResponseTimeMs uint64 `json:"response_time_ms"`
+	Passed         bool               `json:"passed"`
+	FailureReason  string             `json:"failure_reason,omitempty"`
+	ResponseTimeMs uint64             `json:"response_time_ms"`
+	Parts          []types.PartResult `json:"parts,omitempty"` // Composite challenges, so provers can see which query failed
+	Receipt        *signing.Receipt   `json:"receipt,omitempty"` // Submits only, for the prover to keep
 }
 
-// Verify wallet signature
-func (h *Handlers) verifySignature(message, signature, expectedAddress string) bool {
-	// Remove 0x prefix if present
-	sig := strings.TrimPrefix(signature, "0x")
+// Points/stats response with a server signature, for ?signed=true
+type SignedResponse struct {
+	Payload   string `json:"payload"` // JSON body exactly as signed
+	Timestamp int64  `json:"timestamp"`
+	Signer    string `json:"signer"`
+	KeyID     string `json:"key_id"`
+	Signature string `json:"signature"`
+}
 
-	sigBytes := make([]byte, 65)
-	for i := 0; i < 65; i++ {
-		var b byte
-		_, err := hexToByte(sig[i*2:i*2+2], &b)
-		if err != nil {
-			return false
-		}
-		sigBytes[i] = b
+// Send stats as-is, or wrapped in a SignedResponse if the caller asked for
+// one. Lets wallets show third parties proof of their points without
+// anyone having to trust the middleman.
+func (h *Handlers) respondStats(c *gin.Context, payload interface{}) {
+	if c.Query("signed") != "true" {
+		c.JSON(http.StatusOK, payload)
+		return
 	}
 
-	// Ethereum signatures have v = 27 or 28, but we need 0 or 1
-	if sigBytes[64] >= 27 {
-		sigBytes[64] -= 27
+	key := h.verifier.Keys().Active()
+	if key == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "response signing is not enabled on this server")})
+		return
 	}
 
-	// Hash the message with Ethereum prefix
-	prefixedMessage := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message)
-	hash := crypto.Keccak256Hash([]byte(prefixedMessage))
+	body, err := json.Marshal(payload)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "failed to encode response")})
+		return
+	}
 
-	// Recover public key
-	pubKey, err := crypto.SigToPub(hash.Bytes(), sigBytes)
+	timestamp := time.Now().UnixMilli()
+	done := track(c, "sign")
+	signature, err := key.Signer.Sign(signing.ResponseMessage(string(body), timestamp))
+	done()
 	if err != nil {
-		return false
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "failed to sign response")})
+		return
 	}
 
-	recoveredAddress := crypto.PubkeyToAddress(*pubKey).Hex()
-	return strings.EqualFold(recoveredAddress, expectedAddress)
+	c.JSON(http.StatusOK, SignedResponse{
+		Payload:   string(body),
+		Timestamp: timestamp,
+		Signer:    key.Signer.Address(),
+		KeyID:     key.ID,
+		Signature: signature,
+	})
 }
 
-func hexToByte(s string, b *byte) (int, error) {
-	n := 0
-	for _, c := range s {
-		n *= 16
-		switch {
-		case '0' <= c && c <= '9':
-			n += int(c - '0')
-		case 'a' <= c && c <= 'f':
-			n += int(c - 'a' + 10)
-		case 'A' <= c && c <= 'F':
-			n += int(c - 'A' + 10)
-		}
-	}
-	*b = byte(n)
-	return 2, nil
+// Verify wallet signature. signature can come straight from a query
+// string, so anything that isn't 65 bytes of hex is just a bad signature.
+func (h *Handlers) verifySignature(message, signature, expectedAddress string) bool {
+	return signing.Verify(message, signature, expectedAddress)
 }
 
 // ==================
@@ -128,30 +203,78 @@ func hexToByte(s string, b *byte) (int, error) {
 func (h *Handlers) RegisterNode(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing required fields"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "missing required fields")})
 		return
 	}
 
 	// If exposed-rpc, need endpoint
 	if req.VerificationMethod == types.ExposedRPC && req.RPCEndpoint == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "rpc endpoint required for exposed-rpc method"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "rpc endpoint required for exposed-rpc method")})
+		return
+	}
+
+	// The prover only speaks JSON-RPC, and an SP's API is public anyway
+	if req.NodeType == types.GreenfieldSP && req.VerificationMethod != types.ExposedRPC {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "greenfield storage providers must use exposed-rpc with their SP endpoint")})
+		return
+	}
+
+	// tunnel:// asks for a tunnel. The node's own tunnel endpoint is filled
+	// in once it has an ID, so nobody can register someone else's.
+	if problem := tunnelEndpointProblem(req.NodeType, req.RPCEndpoint); problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, problem)})
 		return
 	}
 
 	// Check timestamp is recent (within 5 minutes)
 	now := time.Now().UnixMilli()
 	if abs(now-req.Timestamp) > 5*60*1000 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "timestamp too old"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "timestamp too old")})
 		return
 	}
 
 	// Verify signature
 	message := "Register node\nWallet: " + req.WalletAddress + "\nType: " + string(req.NodeType) + "\nTimestamp: " + fmt.Sprintf("%d", req.Timestamp)
 	if !h.verifySignature(message, req.Signature, req.WalletAddress) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "invalid signature")})
 		return
 	}
 
+	// Banned wallets sit out their cooldown before coming back
+	if ban := h.store.GetWalletBan(strings.ToLower(req.WalletAddress), now); ban != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": tr(c, "wallet is banned"), "reason": ban.Reason, "banned_until": ban.ExpiresAt})
+		return
+	}
+
+	// Hardware that can't run the claimed node type means the claim is wrong
+	if att := req.Attestation; att != nil {
+		message := attestation.Message(req.WalletAddress, req.NodeType, req.Timestamp, att.Report)
+		if !h.verifySignature(message, att.Signature, req.WalletAddress) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "invalid attestation signature")})
+			return
+		}
+		if problems := attestation.Check(req.NodeType, att.Report); len(problems) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "hardware can't run this node type"), "problems": trAll(c, problems)})
+			return
+		}
+	}
+
+	cert, problem := h.clientCert(req.NodeType, req.VerificationMethod, req.RPCEndpoint, req.ClientCert, req.ClientKey)
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, problem)})
+		return
+	}
+
+	// An endpoint that can't answer would fail every scheduled verification
+	var endpoint *verification.EndpointCheck
+	if req.VerificationMethod == types.ExposedRPC {
+		endpoint = endpointCheckResponse(c, h.verifier.CheckEndpoint(req.NodeType, req.RPCEndpoint, req.AuthToken, cert))
+		if endpoint.Problem != "" {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": tr(c, endpoint.Problem), "endpoint_check": endpoint})
+			return
+		}
+	}
+
 	// Register the node
 	node := h.store.RegisterNode(
 		strings.ToLower(req.WalletAddress),
@@ -160,28 +283,100 @@ func (h *Handlers) RegisterNode(c *gin.Context) {
 		req.RPCEndpoint,
 		req.AuthToken,
 	)
+	if req.Attestation != nil {
+		report := req.Attestation.Report
+		h.store.UpdateNode(node.ID, func(n *types.NodeRegistration) {
+			n.Hardware = &report
+		})
+	}
+	if req.RPCEndpoint == tunnel.Placeholder {
+		h.store.UpdateNode(node.ID, func(n *types.NodeRegistration) {
+			n.RPCEndpoint = tunnel.EndpointFor(n.ID)
+		})
+	}
+	if cert != nil {
+		h.store.UpdateNode(node.ID, func(n *types.NodeRegistration) {
+			n.ClientCert = cert
+		})
+	}
 
 	c.JSON(http.StatusOK, RegisterResponse{
-		Success: true,
-		NodeID:  node.ID,
-		Message: "node registered successfully",
+		Success:       true,
+		NodeID:        node.ID,
+		Message:       "node registered successfully",
+		EndpointCheck: endpoint,
 	})
 }
 
+// Why an rpc_endpoint can't have the tunnel it asks for, "" if it can or
+// isn't asking for one
+func tunnelEndpointProblem(nodeType types.NodeType, endpoint string) string {
+	switch {
+	case !tunnel.IsEndpoint(endpoint):
+		return ""
+	case endpoint != tunnel.Placeholder:
+		return "to use a tunnel, register tunnel:// with nothing after it"
+	case nodeType == types.GreenfieldSP:
+		return "storage providers can't use a tunnel"
+	}
+	return ""
+}
+
+// Check and seal the client certificate a request carries. Nil with no
+// problem if it doesn't carry one.
+func (h *Handlers) clientCert(nodeType types.NodeType, method types.VerificationMethod, endpoint, certPEM, keyPEM string) (*types.ClientCert, string) {
+	switch {
+	case certPEM == "" && keyPEM == "":
+		return nil, ""
+	case certPEM == "" || keyPEM == "":
+		return nil, "client_cert and client_key go together"
+	case method != types.ExposedRPC || nodeType == types.GreenfieldSP || tunnel.IsEndpoint(endpoint):
+		return nil, "client certificates are only for exposed-rpc JSON-RPC endpoints"
+	}
+	cert, err := h.verifier.SealClientCert(certPEM, keyPEM)
+	if err != nil {
+		return nil, err.Error()
+	}
+	return cert, ""
+}
+
 // GET /nodes/:nodeId
 func (h *Handlers) GetNode(c *gin.Context) {
 	nodeID := c.Param("nodeId")
 	node := h.store.GetNode(nodeID)
 
 	if node == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "node not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
 		return
 	}
 
-	// Don't expose auth token
-	safeCopy := *node
-	safeCopy.AuthToken = ""
-	c.JSON(http.StatusOK, safeCopy)
+	c.JSON(http.StatusOK, h.publicNode(node, time.Now().UnixMilli()))
+}
+
+// A node as shown publicly: no auth token, plus its liveness so
+// dashboards don't need another call for a status badge
+type NodeResponse struct {
+	types.NodeRegistration
+	Liveness types.Liveness   `json:"liveness"`
+	Health   types.NodeHealth `json:"health"`
+}
+
+func (h *Handlers) publicNode(node *types.NodeRegistration, now int64) NodeResponse {
+	resp := NodeResponse{NodeRegistration: *node, Liveness: node.Liveness(now)}
+	resp.Health, _ = h.store.GetHealth(node.ID)
+	resp.AuthToken = ""
+	resp.ClientCert = withoutSecret(node.ClientCert)
+	return resp
+}
+
+// A client certificate's details without the sealed cert and key
+func withoutSecret(cert *types.ClientCert) *types.ClientCert {
+	if cert == nil {
+		return nil
+	}
+	public := *cert
+	public.Sealed = ""
+	return &public
 }
 
 // GET /nodes/wallet/:walletAddress
@@ -189,318 +384,2868 @@ func (h *Handlers) GetNodesByWallet(c *gin.Context) {
 	wallet := strings.ToLower(c.Param("walletAddress"))
 	nodes := h.store.GetNodesByWallet(wallet)
 
-	// Don't expose auth tokens
-	safeNodes := make([]types.NodeRegistration, len(nodes))
+	now := time.Now().UnixMilli()
+	safeNodes := make([]NodeResponse, len(nodes))
 	for i, node := range nodes {
-		safeNodes[i] = *node
-		safeNodes[i].AuthToken = ""
+		safeNodes[i] = h.publicNode(node, now)
 	}
 
 	c.JSON(http.StatusOK, safeNodes)
 }
 
-// GET /wallet/:walletAddress/stats
-func (h *Handlers) GetWalletStats(c *gin.Context) {
-	wallet := strings.ToLower(c.Param("walletAddress"))
-	stats := h.store.GetWalletStats(wallet)
+// Longest query and most results the search box gets
+const (
+	maxSearchQuery   = 200
+	maxSearchResults = 100
+)
 
-	if stats == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "wallet not found"})
+// GET /search?q= - Nodes by ID, wallet (or a prefix of at least 4
+// characters of either) and tags like bsc-archive, erigon or a country
+// code, best match first. ?limit= defaults to 20.
+func (h *Handlers) SearchNodes(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" || len(query) > maxSearchQuery {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "q required")})
 		return
 	}
+	limit := 20
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxSearchResults {
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, fmt.Sprintf("limit must be 1-%d", maxSearchResults))})
+			return
+		}
+		limit = n
+	}
 
-	c.JSON(http.StatusOK, stats)
+	done := track(c, "store")
+	results := h.store.SearchNodes(query, limit)
+	done()
+	if results == nil {
+		results = []types.SearchResult{}
+	}
+	c.JSON(http.StatusOK, gin.H{"query": query, "results": results})
 }
 
-// GET /nodes/:nodeId/stats
-func (h *Handlers) GetNodeStats(c *gin.Context) {
-	nodeID := c.Param("nodeId")
-	stats := h.store.GetNodeStats(nodeID)
+// GET /wallet/:walletAddress/stats
+func (h *Handlers) GetWalletStats(c *gin.Context) {
+	wallet := strings.ToLower(c.Param("walletAddress"))
+	done := track(c, "store")
+	stats := h.store.GetWalletStats(wallet)
+	done()
 
 	if stats == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "node not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "wallet not found")})
 		return
 	}
 
-	c.JSON(http.StatusOK, stats)
+	stats.Name = h.walletName(wallet)
+	h.respondStats(c, stats)
 }
 
-// ==================
-// CHALLENGES
-// ==================
-
-// GET /challenges/request
-func (h *Handlers) RequestChallenge(c *gin.Context) {
-	nodeID := c.Query("nodeId")
-	if nodeID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "nodeId required"})
-		return
-	}
-
-	node := h.store.GetNode(nodeID)
-	if node == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "node not found"})
-		return
-	}
+// GET /wallet/:walletAddress/claims - What the wallet can mint from each
+// closed reward epoch, with the merkle proof to mint it
+func (h *Handlers) GetRewardClaims(c *gin.Context) {
+	wallet := strings.ToLower(c.Param("walletAddress"))
+	done := track(c, "store")
+	claims := h.store.GetRewardClaims(wallet)
+	done()
 
-	if !node.IsActive {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "node is not active"})
-		return
-	}
+	c.JSON(http.StatusOK, gin.H{"wallet_address": wallet, "claims": claims})
+}
 
-	challenge, err := h.verifier.CreateChallenge(node)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create challenge"})
-		return
+// A wallet's name, "" without one or with names off
+func (h *Handlers) walletName(address string) string {
+	if h.names == nil {
+		return ""
 	}
+	return h.names.Name(address)
+}
 
-	c.JSON(http.StatusOK, ChallengeRequestResponse{
-		Challenge: ChallengePublic{
-			ID:            challenge.ID,
-			ChallengeType: challenge.ChallengeType,
-			Params:        challenge.Params,
-			ExpiresAt:     challenge.ExpiresAt,
-		},
-		ServerTime: time.Now().UnixMilli(),
-	})
+// POST /wallets/stats - Stats for up to 100 wallets in one call
+type BulkWalletStatsRequest struct {
+	Addresses []string `json:"addresses" binding:"required,min=1"`
 }
 
-// POST /challenges/submit
-func (h *Handlers) SubmitChallenge(c *gin.Context) {
-	var req SubmitChallengeRequest
+const maxBulkWallets = 100
+
+func (h *Handlers) GetBulkWalletStats(c *gin.Context) {
+	var req BulkWalletStatsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing required fields"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "addresses required")})
 		return
 	}
 
-	node := h.store.GetNode(req.NodeID)
-	if node == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "node not found"})
-		return
+	// Normalise and drop duplicates, keeping the caller's order
+	seen := make(map[string]bool, len(req.Addresses))
+	wallets := make([]string, 0, len(req.Addresses))
+	for _, addr := range req.Addresses {
+		wallet := strings.ToLower(strings.TrimSpace(addr))
+		if wallet == "" || seen[wallet] {
+			continue
+		}
+		seen[wallet] = true
+		wallets = append(wallets, wallet)
 	}
 
-	// Verify signature
-	message := "Challenge Response\nID: " + req.ChallengeID + "\nAnswer: " + req.Answer + "\nTimestamp: " + fmt.Sprintf("%d", req.Timestamp)
-	if !h.verifySignature(message, req.Signature, node.WalletAddress) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+	if len(wallets) > maxBulkWallets {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, fmt.Sprintf("at most %d addresses per request", maxBulkWallets))})
 		return
 	}
 
-	// Verify the response
-	result := h.verifier.VerifyResponse(&types.ChallengeResponse{
-		ChallengeID:    req.ChallengeID,
-		NodeID:         req.NodeID,
-		Answer:         req.Answer,
-		Signature:      req.Signature,
-		ResponseTimeMs: req.ResponseTimeMs,
-		Timestamp:      req.Timestamp,
-	})
-
-	h.store.RecordVerificationResult(result)
+	done := track(c, "store")
+	found := h.store.GetWalletStatsBatch(wallets)
+	done()
+
+	stats := make([]*types.WalletStats, 0, len(found))
+	notFound := make([]string, 0)
+	for _, wallet := range wallets {
+		if s, ok := found[wallet]; ok {
+			s.Name = h.walletName(wallet)
+			stats = append(stats, s)
+		} else {
+			notFound = append(notFound, wallet)
+		}
+	}
 
-	c.JSON(http.StatusOK, VerifyResponse{
-		Passed:         result.Passed,
-		FailureReason:  result.FailureReason,
-		ResponseTimeMs: result.ResponseTimeMs,
+	h.respondStats(c, gin.H{
+		"wallets":   stats,
+		"not_found": notFound,
 	})
 }
 
-// ==================
-// DIRECT VERIFICATION
-// ==================
-
-// POST /verify/:nodeId
-func (h *Handlers) VerifyNode(c *gin.Context) {
+// GET /nodes/:nodeId/stats
+func (h *Handlers) GetNodeStats(c *gin.Context) {
 	nodeID := c.Param("nodeId")
-	node := h.store.GetNode(nodeID)
+	done := track(c, "store")
+	stats := h.store.GetNodeStats(nodeID)
+	done()
 
-	if node == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "node not found"})
+	if stats == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
 		return
 	}
 
-	if node.VerificationMethod != types.ExposedRPC {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "node is not using exposed-rpc method"})
+	h.respondStats(c, stats)
+}
+
+// GET /nodes/:nodeId/projection - Expected points per day and week at current rates, with the inputs used
+func (h *Handlers) GetPointsProjection(c *gin.Context) {
+	done := track(c, "store")
+	projection := h.store.ProjectPoints(c.Param("nodeId"), time.Now().UnixMilli())
+	done()
+
+	if projection == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
 		return
 	}
 
-	result := h.verifier.VerifyExposedRPC(node)
-	h.store.RecordVerificationResult(result)
+	h.respondStats(c, projection)
+}
 
-	c.JSON(http.StatusOK, VerifyResponse{
-		Passed:         result.Passed,
-		FailureReason:  result.FailureReason,
-		ResponseTimeMs: result.ResponseTimeMs,
-	})
+// GET /nodes/:nodeId/points?format=csv (or /points-history) - Every change
+// to a node's points, with the running balance
+type LedgerEntry struct {
+	types.PointsEntry
+	Balance uint64 `json:"balance"`
 }
 
-// GET /verify/:nodeId/heartbeat
-func (h *Handlers) CheckHeartbeat(c *gin.Context) {
+func (h *Handlers) GetPointsLedger(c *gin.Context) {
 	nodeID := c.Param("nodeId")
+	done := track(c, "store")
 	node := h.store.GetNode(nodeID)
+	ledger := h.store.PointsLedger(nodeID)
+	done()
 
 	if node == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "node not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
 		return
 	}
 
-	if node.VerificationMethod != types.ExposedRPC {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "node is not using exposed-rpc method"})
-		return
+	entries := make([]LedgerEntry, 0, len(ledger))
+	var balance int64
+	for _, entry := range ledger {
+		balance += entry.Amount
+		entries = append(entries, LedgerEntry{PointsEntry: entry, Balance: uint64(balance)})
 	}
 
-	heartbeat := h.verifier.CheckHeartbeat(node)
-	if heartbeat == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "node unreachable"})
+	if c.Query("format") == "csv" {
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="points-%s.csv"`, nodeID))
+		w := csv.NewWriter(c.Writer)
+		w.Write([]string{"id", "timestamp", "reason", "amount", "balance", "reference", "note"})
+		for _, entry := range entries {
+			w.Write([]string{
+				strconv.FormatUint(entry.ID, 10),
+				time.UnixMilli(entry.Timestamp).UTC().Format(time.RFC3339),
+				string(entry.Reason),
+				strconv.FormatInt(entry.Amount, 10),
+				strconv.FormatUint(entry.Balance, 10),
+				entry.Reference,
+				entry.Note,
+			})
+		}
+		w.Flush()
 		return
 	}
 
-	h.store.RecordHeartbeat(heartbeat)
-	c.JSON(http.StatusOK, heartbeat)
+	c.JSON(http.StatusOK, gin.H{
+		"node_id":      nodeID,
+		"total_points": uint64(balance),
+		"entries":      entries,
+	})
 }
 
-// ==================
-// PUBLIC DATA
-// ==================
-
-// GET /leaderboard
-func (h *Handlers) GetLeaderboard(c *gin.Context) {
-	nodes := h.store.GetAllActiveNodes()
-
-	type LeaderboardEntry struct {
-		Rank               int              `json:"rank"`
-		NodeID             string           `json:"node_id"`
-		WalletAddress      string           `json:"wallet_address"`
-		NodeType           types.NodeType   `json:"node_type"`
-		TotalPoints        uint64           `json:"total_points"`
-		TotalUptimeHours   float64          `json:"total_uptime_hours"`
-		ChallengePassRate  float64          `json:"challenge_pass_rate"`
-		RegisteredAt       int64            `json:"registered_at"`
-	}
-
-	entries := make([]LeaderboardEntry, 0, len(nodes))
-	for _, node := range nodes {
-		// Don't show banned nodes on leaderboard
-		if node.CheatStatus == types.StatusBanned {
-			continue
-		}
-
-		stats := h.store.GetNodeStats(node.ID)
-		entry := LeaderboardEntry{
-			NodeID:             node.ID,
-			WalletAddress:      node.WalletAddress,
-			NodeType:           node.NodeType,
-			TotalPoints:        node.TotalPoints,
-			TotalUptimeHours:   float64(node.TotalUptimeMinutes) / 60.0,
-			RegisteredAt:       node.RegisteredAt,
-		}
-		if stats != nil {
-			entry.ChallengePassRate = stats.ChallengePassRate
-		}
-		entries = append(entries, entry)
+// GET /nodes/compare?ids=a,b,c - Side-by-side stats for spotting the weak machine
+const maxCompareNodes = 20
+
+func (h *Handlers) CompareNodes(c *gin.Context) {
+	type NodeComparison struct {
+		NodeID            string                              `json:"node_id"`
+		NodeType          types.NodeType                      `json:"node_type"`
+		ChallengePassRate float64                             `json:"challenge_pass_rate"`
+		Latency           map[string]types.LatencyPercentiles `json:"latency_percentiles"`
+		Uptime7dPercent   float64                             `json:"uptime_7d_percent"`
+		TotalUptimeHours  float64                             `json:"total_uptime_hours"`
+		TotalPoints       uint64                              `json:"total_points"`
+		PointsPerDay      float64                             `json:"points_per_day"`
+		CheatStatus       types.CheatStatus                   `json:"cheat_status"`
 	}
 
-	// Sort by total points (highest first)
-	for i := 0; i < len(entries); i++ {
-		for j := i + 1; j < len(entries); j++ {
-			if entries[j].TotalPoints > entries[i].TotalPoints {
-				entries[i], entries[j] = entries[j], entries[i]
-			}
+	ids := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, id := range strings.Split(c.Query("ids"), ",") {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
 		}
 	}
 
-	// Add ranks and limit to 100
-	if len(entries) > 100 {
-		entries = entries[:100]
+	if len(ids) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "ids required (comma separated)")})
+		return
 	}
-	for i := range entries {
-		entries[i].Rank = i + 1
+	if len(ids) > maxCompareNodes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, fmt.Sprintf("at most %d nodes per comparison", maxCompareNodes))})
+		return
 	}
 
-	c.JSON(http.StatusOK, entries)
-}
-
-// GET /stats
-func (h *Handlers) GetNetworkStats(c *gin.Context) {
-	nodes := h.store.GetAllActiveNodes()
+	now := time.Now().UnixMilli()
+	nodes := make([]NodeComparison, 0, len(ids))
+	notFound := make([]string, 0)
+
+	for _, id := range ids {
+		node := h.store.GetNode(id)
+		stats := h.store.GetNodeStats(id)
+		if node == nil || stats == nil {
+			notFound = append(notFound, id)
+			continue
+		}
 
-	byType := make(map[string]int)
-	byMethod := make(map[string]int)
+		// Points per day since registration
+		var perDay float64
+		if days := float64(now-node.RegisteredAt) / float64(24*60*60*1000); days > 0 {
+			perDay = float64(stats.TotalPoints) / days
+		}
 
-	for _, node := range nodes {
-		byType[string(node.NodeType)]++
-		byMethod[string(node.VerificationMethod)]++
+		nodes = append(nodes, NodeComparison{
+			NodeID:            id,
+			NodeType:          node.NodeType,
+			ChallengePassRate: stats.ChallengePassRate,
+			Latency:           stats.LatencyPercentiles,
+			Uptime7dPercent:   h.store.GetRecentUptimePercent(id, 7),
+			TotalUptimeHours:  stats.TotalUptimeHours,
+			TotalPoints:       stats.TotalPoints,
+			PointsPerDay:      perDay,
+			CheatStatus:       stats.CheatStatus,
+		})
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"total_nodes": len(nodes),
-		"by_type":     byType,
-		"by_method":   byMethod,
+		"nodes":     nodes,
+		"not_found": notFound,
 	})
 }
 
-// ==================
-// ADMIN ENDPOINTS
-// ==================
-
-// GET /admin/flagged - Get all nodes that need review
-func (h *Handlers) GetFlaggedNodes(c *gin.Context) {
-	flagged := h.store.GetFlaggedNodes()
+// GET /nodes/:nodeId/uptime/calendar?month=2024-01 - Per-day uptime for a month (UTC)
+func (h *Handlers) GetUptimeCalendar(c *gin.Context) {
+	nodeID := c.Param("nodeId")
+	if h.store.GetNode(nodeID) == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
+		return
+	}
 
-	// Don't expose auth tokens
-	safeNodes := make([]types.NodeRegistration, len(flagged))
-	for i, node := range flagged {
-		safeNodes[i] = *node
-		safeNodes[i].AuthToken = ""
+	month := time.Now().UTC()
+	if raw := c.Query("month"); raw != "" {
+		parsed, err := time.Parse("2006-01", raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "month must be YYYY-MM")})
+			return
+		}
+		month = parsed
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"count": len(safeNodes),
-		"nodes": safeNodes,
+		"node_id": nodeID,
+		"month":   month.Format("2006-01"),
+		"days":    h.store.GetUptimeCalendar(nodeID, month.Year(), month.Month()),
 	})
 }
 
-// POST /admin/review/:nodeId - Admin reviews a flagged node
-type ReviewRequest struct {
-	Action string `json:"action" binding:"required"` // "clear", "warn", "ban"
-	Reason string `json:"reason"`
+// POST /nodes/:nodeId/pause and /resume - Operator maintenance, signed by the node's wallet
+type MaintenanceRequest struct {
+	Signature string `json:"signature" binding:"required"`
+	Timestamp int64  `json:"timestamp" binding:"required"`
 }
 
-func (h *Handlers) ReviewNode(c *gin.Context) {
-	nodeID := c.Param("nodeId")
+func (h *Handlers) PauseNode(c *gin.Context) {
+	h.setPaused(c, "Pause node")
+}
 
-	var req ReviewRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "action required (clear, warn, or ban)"})
+func (h *Handlers) ResumeNode(c *gin.Context) {
+	h.setPaused(c, "Resume node")
+}
+
+func (h *Handlers) setPaused(c *gin.Context, action string) {
+	var req MaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "missing required fields")})
+		return
+	}
+
+	nodeID := c.Param("nodeId")
+	node := h.store.GetNode(nodeID)
+	if node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
+		return
+	}
+
+	// Check timestamp is recent (within 5 minutes)
+	now := time.Now().UnixMilli()
+	if abs(now-req.Timestamp) > 5*60*1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "timestamp too old")})
+		return
+	}
+
+	// Only the wallet that owns the node can pause it
+	message := action + "\nNode: " + nodeID + "\nTimestamp: " + fmt.Sprintf("%d", req.Timestamp)
+	if !h.verifySignature(message, req.Signature, node.WalletAddress) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "invalid signature")})
+		return
+	}
+
+	var err error
+	if action == "Pause node" {
+		node, err = h.store.PauseNode(nodeID, now)
+	} else {
+		node, err = h.store.ResumeNode(nodeID, now)
+	}
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"node_id":                       nodeID,
+		"paused":                        node.Paused,
+		"maintenance_used_minutes":      node.MaintenanceUsedMinutes,
+		"maintenance_remaining_minutes": store.RemainingMaintenanceMinutes(node),
+	})
+}
+
+// POST /nodes/:nodeId/verification-method - Switch between exposed-rpc and
+// local-prover without re-registering, signed by the node's wallet
+type ChangeMethodRequest struct {
+	VerificationMethod types.VerificationMethod `json:"verification_method" binding:"required"`
+	RPCEndpoint        string                   `json:"rpc_endpoint"`
+	AuthToken          string                   `json:"auth_token"`
+	ClientCert         string                   `json:"client_cert"`
+	ClientKey          string                   `json:"client_key"`
+	TOTPCode           string                   `json:"totp_code"` // If the wallet has two-factor on
+	Signature          string                   `json:"signature" binding:"required"`
+	Timestamp          int64                    `json:"timestamp" binding:"required"`
+}
+
+// The node after the change, and what its endpoint looked like
+type ChangeMethodResponse struct {
+	NodeResponse
+	EndpointCheck *verification.EndpointCheck `json:"endpoint_check,omitempty"`
+}
+
+func (h *Handlers) ChangeVerificationMethod(c *gin.Context) {
+	var req ChangeMethodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "missing required fields")})
+		return
+	}
+	if req.VerificationMethod != types.ExposedRPC && req.VerificationMethod != types.LocalProver {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "verification_method must be exposed-rpc or local-prover")})
+		return
+	}
+	if req.VerificationMethod == types.ExposedRPC && req.RPCEndpoint == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "rpc endpoint required for exposed-rpc method")})
+		return
+	}
+
+	nodeID := c.Param("nodeId")
+	node := h.store.GetNode(nodeID)
+	if node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
+		return
+	}
+	if node.NodeType == types.GreenfieldSP {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "greenfield storage providers must use exposed-rpc with their SP endpoint")})
+		return
+	}
+	if problem := tunnelEndpointProblem(node.NodeType, req.RPCEndpoint); problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, problem)})
+		return
+	}
+	cert, problem := h.clientCert(node.NodeType, req.VerificationMethod, req.RPCEndpoint, req.ClientCert, req.ClientKey)
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, problem)})
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	if abs(now-req.Timestamp) > 5*60*1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "timestamp too old")})
+		return
+	}
+
+	// The endpoint is signed too, so nobody can point the node somewhere
+	// else, and so is a client certificate if there is one
+	message := "Change verification method\nNode: " + nodeID + "\nMethod: " + string(req.VerificationMethod) + "\nEndpoint: " + req.RPCEndpoint
+	if cert != nil {
+		message += "\nFingerprint: " + cert.Fingerprint
+	}
+	message += "\nTimestamp: " + fmt.Sprintf("%d", req.Timestamp)
+	if !h.verifySignature(message, req.Signature, node.WalletAddress) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "invalid signature")})
+		return
+	}
+	if !h.checkTOTP(c, node.WalletAddress, req.TOTPCode) {
+		return
+	}
+
+	rpcEndpoint := req.RPCEndpoint
+	if rpcEndpoint == tunnel.Placeholder {
+		rpcEndpoint = tunnel.EndpointFor(nodeID)
+	}
+
+	// Same check as at registration
+	var endpoint *verification.EndpointCheck
+	if req.VerificationMethod == types.ExposedRPC {
+		endpoint = endpointCheckResponse(c, h.verifier.CheckEndpoint(node.NodeType, rpcEndpoint, req.AuthToken, cert))
+		if endpoint.Problem != "" {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": tr(c, endpoint.Problem), "endpoint_check": endpoint})
+			return
+		}
+	}
+
+	node, err := h.store.ChangeVerificationMethod(nodeID, req.VerificationMethod, rpcEndpoint, req.AuthToken, now)
+	if err == nil && cert != nil {
+		node = h.store.UpdateNode(nodeID, func(n *types.NodeRegistration) {
+			n.ClientCert = cert
+		})
+	}
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, ChangeMethodResponse{NodeResponse: h.publicNode(node, now), EndpointCheck: endpoint})
+	case store.ErrNodeNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, err.Error())})
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, err.Error())})
+	}
+}
+
+// POST /nodes/:nodeId/client-cert - Put a new client certificate on file
+// for an exposed-rpc node (e.g. before the old one expires), or remove it
+// by sending neither cert nor key. Signed by the node's wallet.
+type SetClientCertRequest struct {
+	ClientCert string `json:"client_cert"`
+	ClientKey  string `json:"client_key"`
+	TOTPCode   string `json:"totp_code"` // If the wallet has two-factor on
+	Signature  string `json:"signature" binding:"required"`
+	Timestamp  int64  `json:"timestamp" binding:"required"`
+}
+
+func (h *Handlers) SetClientCert(c *gin.Context) {
+	var req SetClientCertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "missing required fields")})
+		return
+	}
+
+	nodeID := c.Param("nodeId")
+	node := h.store.GetNode(nodeID)
+	if node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
+		return
+	}
+	if node.VerificationMethod != types.ExposedRPC || node.NodeType == types.GreenfieldSP || tunnel.IsEndpoint(node.RPCEndpoint) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "client certificates are only for exposed-rpc JSON-RPC endpoints")})
+		return
+	}
+	cert, problem := h.clientCert(node.NodeType, node.VerificationMethod, node.RPCEndpoint, req.ClientCert, req.ClientKey)
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, problem)})
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	if abs(now-req.Timestamp) > 5*60*1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "timestamp too old")})
+		return
+	}
+
+	fingerprint := "none"
+	if cert != nil {
+		fingerprint = cert.Fingerprint
+	}
+	message := "Set client certificate\nNode: " + nodeID + "\nFingerprint: " + fingerprint + "\nTimestamp: " + fmt.Sprintf("%d", req.Timestamp)
+	if !h.verifySignature(message, req.Signature, node.WalletAddress) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "invalid signature")})
+		return
+	}
+	if !h.checkTOTP(c, node.WalletAddress, req.TOTPCode) {
+		return
+	}
+
+	// The endpoint has to take the new cert (or do without one) before it
+	// replaces the old
+	endpoint := endpointCheckResponse(c, h.verifier.CheckEndpoint(node.NodeType, node.RPCEndpoint, node.AuthToken, cert))
+	if endpoint.Problem != "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": tr(c, endpoint.Problem), "endpoint_check": endpoint})
+		return
+	}
+
+	node = h.store.UpdateNode(nodeID, func(n *types.NodeRegistration) {
+		n.ClientCert = cert
+	})
+	c.JSON(http.StatusOK, ChangeMethodResponse{NodeResponse: h.publicNode(node, now), EndpointCheck: endpoint})
+}
+
+// GET /nodes/:nodeId/reclassification - The latest type change proposed for a node
+func (h *Handlers) GetReclassification(c *gin.Context) {
+	reclassification := h.store.GetReclassification(c.Param("nodeId"))
+	if reclassification == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, store.ErrNoReclassification.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, reclassification)
+}
+
+// POST /nodes/:nodeId/reclassification/accept - Operator agrees to the
+// proposed type instead of waiting out the grace period
+type AcceptReclassificationRequest struct {
+	NodeType  types.NodeType `json:"node_type" binding:"required"`
+	TOTPCode  string         `json:"totp_code"` // If the wallet has two-factor on
+	Signature string         `json:"signature" binding:"required"`
+	Timestamp int64          `json:"timestamp" binding:"required"`
+}
+
+func (h *Handlers) AcceptReclassification(c *gin.Context) {
+	var req AcceptReclassificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "missing required fields")})
+		return
+	}
+
+	nodeID := c.Param("nodeId")
+	node := h.store.GetNode(nodeID)
+	if node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	if abs(now-req.Timestamp) > 5*60*1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "timestamp too old")})
+		return
+	}
+
+	message := "Accept reclassification\nNode: " + nodeID + "\nType: " + string(req.NodeType) + "\nTimestamp: " + fmt.Sprintf("%d", req.Timestamp)
+	if !h.verifySignature(message, req.Signature, node.WalletAddress) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "invalid signature")})
+		return
+	}
+	if !h.checkTOTP(c, node.WalletAddress, req.TOTPCode) {
+		return
+	}
+
+	reclassification, err := h.store.ResolveReclassification(nodeID, true, req.NodeType, "operator", now)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, reclassification)
+	case store.ErrNoReclassification:
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, err.Error())})
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, err.Error())})
+	}
+}
+
+// ==================
+// CHALLENGES
+// ==================
+
+// GET /challenges/request?nodeId=&timestamp=&signature= - Signed by the
+// node's wallet, so nobody else can use up its challenges or see them
+func (h *Handlers) RequestChallenge(c *gin.Context) {
+	nodeID := c.Query("nodeId")
+	if nodeID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "nodeId required")})
+		return
+	}
+
+	node := h.store.GetNode(nodeID)
+	if node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
+		return
+	}
+
+	timestamp, err := strconv.ParseInt(c.Query("timestamp"), 10, 64)
+	if err != nil || abs(time.Now().UnixMilli()-timestamp) > 5*60*1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "timestamp missing or too old")})
+		return
+	}
+
+	message := "Request challenge\nNode: " + nodeID + "\nTimestamp: " + fmt.Sprintf("%d", timestamp)
+	if !h.verifySignature(message, c.Query("signature"), node.WalletAddress) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "invalid signature")})
+		return
+	}
+
+	// Each signed request is good for one challenge
+	if !h.store.ClaimChallengeRequest(nodeID, timestamp) {
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, "request already used")})
+		return
+	}
+
+	// A node that only went quiet gets challenges, so a passed proof can
+	// bring it back
+	if !node.IsActive && !node.Silent {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "node is not active")})
+		return
+	}
+
+	if node.Paused {
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, "node is paused for maintenance")})
+		return
+	}
+
+	// No point asking the trusted RPC for a challenge the node can't have
+	now := time.Now().UnixMilli()
+	if !h.store.HasChallengeBudget(nodeID, now) {
+		reset := h.setBudgetHeaders(c, nodeID, now)
+		c.Header("Retry-After", strconv.FormatInt((reset-now+999)/1000, 10))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": tr(c, verification.ErrBudgetExhausted.Error())})
+		return
+	}
+
+	h.store.RecordPoll(nodeID, now)
+
+	release, ok := h.waitTurn(c, node)
+	if !ok {
+		return
+	}
+	done := track(c, "verifier")
+	challenge, err := h.verifier.CreateChallengeFor(node, node.WalletAddress)
+	done()
+	release()
+	switch err {
+	case nil:
+	case verification.ErrBudgetExhausted:
+		// Only this type is used up; the next request may get another
+		h.setBudgetHeaders(c, nodeID, now)
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": tr(c, "daily budget for this challenge type used up, ask again")})
+		return
+	case verification.ErrNoChallengeTypes:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": tr(c, err.Error())})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "failed to create challenge")})
+		return
+	}
+
+	h.setBudgetHeaders(c, nodeID, now)
+	c.JSON(http.StatusOK, challengeResponse(challenge))
+}
+
+// How long challenge work waits for a slot before the client is told to
+// come back
+const queueTimeout = 10 * time.Second
+
+// Wait for the node's turn at the verifier's work queue. Returns false,
+// having already responded, if the wait took too long.
+func (h *Handlers) waitTurn(c *gin.Context, node *types.NodeRegistration) (func(), bool) {
+	done := track(c, "queue")
+	defer done()
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), queueTimeout)
+	defer cancel()
+	class, score := verification.WorkPriority(node, time.Now().UnixMilli())
+	release, err := h.verifier.Queue().Wait(ctx, class, score)
+	if err != nil {
+		c.Header("Retry-After", "5")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": tr(c, "server busy, try again shortly")})
+		return nil, false
+	}
+	return release, true
+}
+
+// Tell a prover how many challenges it has left today and when the count
+// resets (unix seconds), so it can slow down before it runs into the cap.
+// Nothing is set without a cap on all types together. Returns the reset
+// time in ms.
+func (h *Handlers) setBudgetHeaders(c *gin.Context, nodeID string, now int64) int64 {
+	today := h.store.ChallengeBudget(nodeID, now)
+	reset := budget.NextDay(now)
+	caps := budget.Caps{PerType: today.Caps, Total: today.TotalCap}
+	if remaining, capped := caps.Remaining(today.Issued); capped {
+		c.Header("X-RateLimit-Limit", strconv.FormatUint(today.TotalCap, 10))
+		c.Header("X-RateLimit-Remaining", strconv.FormatUint(remaining, 10))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(reset/1000, 10))
+	}
+	return reset
+}
+
+// What the prover gets to see (no expected answer)
+func challengeResponse(challenge *types.Challenge) ChallengeRequestResponse {
+	return ChallengeRequestResponse{
+		Challenge: ChallengePublic{
+			ID:            challenge.ID,
+			NodeID:        challenge.NodeID,
+			ChallengeType: challenge.ChallengeType,
+			Params:        challenge.Params,
+			CreatedAt:     challenge.CreatedAt,
+			ExpiresAt:     challenge.ExpiresAt,
+			Signature:     challenge.Signature,
+			KeyID:         challenge.KeyID,
+			CommitBy:      challenge.CommitBy,
+		},
+		ServerTime: time.Now().UnixMilli(),
+	}
+}
+
+// GET /challenges/stream?nodeId=&timestamp=&signature= - Server-sent events
+// stream the server pushes surprise challenges on. Signed by the node's
+// wallet so nobody else can listen in. Each challenge comes wrapped in a
+// signing.Push, so the prover can tell it really came from us.
+func (h *Handlers) StreamChallenges(c *gin.Context) {
+	nodeID := c.Query("nodeId")
+	node := h.store.GetNode(nodeID)
+	if node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
+		return
+	}
+
+	timestamp, err := strconv.ParseInt(c.Query("timestamp"), 10, 64)
+	if err != nil || abs(time.Now().UnixMilli()-timestamp) > 5*60*1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "timestamp missing or too old")})
+		return
+	}
+
+	message := "Subscribe challenges\nNode: " + nodeID + "\nTimestamp: " + fmt.Sprintf("%d", timestamp)
+	if !h.verifySignature(message, c.Query("signature"), node.WalletAddress) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "invalid signature")})
+		return
+	}
+
+	challenges, unsubscribe := h.verifier.Push().Subscribe(nodeID)
+	defer unsubscribe()
+
+	// Keeps proxies from closing an idle stream
+	ping := time.NewTicker(30 * time.Second)
+	defer ping.Stop()
+
+	c.SSEvent("ping", time.Now().UnixMilli())
+	c.Writer.Flush() // Lets the prover know it's subscribed
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case challenge := <-challenges:
+			push, err := h.signPush("challenge", nodeID, challengeResponse(challenge))
+			if err != nil {
+				log.Printf("failed to sign pushed challenge %s: %v", challenge.ID, err)
+				return true
+			}
+			c.SSEvent("challenge", push)
+			return true
+		case <-ping.C:
+			c.SSEvent("ping", time.Now().UnixMilli())
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// GET /tunnel - Hold a tunnel open for an exposed-rpc node that can't take
+// inbound connections. Calls verification makes to the node come down as
+// "request" events; the prover answers each at POST /tunnel/replies.
+func (h *Handlers) OpenTunnel(c *gin.Context) {
+	nodeID := c.Query("nodeId")
+	node := h.store.GetNode(nodeID)
+	if node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
+		return
+	}
+
+	timestamp, err := strconv.ParseInt(c.Query("timestamp"), 10, 64)
+	if err != nil || abs(time.Now().UnixMilli()-timestamp) > 5*60*1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "timestamp missing or too old")})
+		return
+	}
+
+	message := "Open tunnel\nNode: " + nodeID + "\nTimestamp: " + fmt.Sprintf("%d", timestamp)
+	if !h.verifySignature(message, c.Query("signature"), node.WalletAddress) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "invalid signature")})
+		return
+	}
+
+	if node.RPCEndpoint != tunnel.EndpointFor(nodeID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "node isn't registered for a tunnel")})
+		return
+	}
+
+	tun, err := h.verifier.Tunnels().Open(nodeID, timestamp)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+	defer h.verifier.Tunnels().Close(tun)
+
+	// Keeps proxies from closing an idle stream
+	ping := time.NewTicker(30 * time.Second)
+	defer ping.Stop()
+
+	c.SSEvent("ping", time.Now().UnixMilli())
+	c.Writer.Flush() // Lets the prover know the tunnel is open
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case request := <-tun.Requests():
+			c.SSEvent("request", request)
+			return true
+		case <-ping.C:
+			c.SSEvent("ping", time.Now().UnixMilli())
+			return true
+		case <-tun.Done():
+			return false // The prover opened a newer one
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// POST /tunnel/replies - The node's answer to a call sent down its tunnel.
+// Not signed: the call's ID only ever went down the node's own tunnel.
+type TunnelReplyRequest struct {
+	NodeID string `json:"node_id" binding:"required"`
+	ID     string `json:"id" binding:"required"`
+	Status int    `json:"status"`
+	Body   []byte `json:"body"`
+	Error  string `json:"error"` // Why the prover couldn't get an answer
+}
+
+func (h *Handlers) TunnelReply(c *gin.Context) {
+	var req TunnelReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "missing required fields")})
+		return
+	}
+
+	reply := &tunnel.Reply{ID: req.ID, Status: req.Status, Body: req.Body, Error: req.Error}
+	if err := h.verifier.Tunnels().Deliver(req.NodeID, reply); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// Wrap something we push to a node, signed with the active key
+func (h *Handlers) signPush(event, nodeID string, payload interface{}) (signing.Push, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return signing.Push{}, err
+	}
+	return h.verifier.Keys().SignPush(event, nodeID, body, time.Now().UnixMilli())
+}
+
+// POST /challenges/commit - Commit to H(answer‖nonce) before revealing
+// the answer, for challenges that carry a commit_by deadline
+func (h *Handlers) CommitChallenge(c *gin.Context) {
+	var req CommitChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "missing required fields")})
+		return
+	}
+
+	node := h.store.GetNode(req.NodeID)
+	if node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
+		return
+	}
+
+	message := "Challenge Commit\nID: " + req.ChallengeID + "\nCommitment: " + req.Commitment + "\nTimestamp: " + fmt.Sprintf("%d", req.Timestamp)
+	if !h.verifySignature(message, req.Signature, node.WalletAddress) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "invalid signature")})
+		return
+	}
+
+	err := h.verifier.CommitAnswer(req.ChallengeID, req.NodeID, req.Commitment)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"committed": true})
+	case verification.ErrChallengeNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, err.Error())})
+	case verification.ErrCommitTooLate:
+		c.JSON(http.StatusGone, gin.H{"error": tr(c, err.Error())})
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, err.Error())})
+	}
+}
+
+// POST /challenges/:challengeId/validate - Dry run an answer and get a
+// field-by-field diff, without using the challenge up. Admins (by API key)
+// can validate any challenge; operators sign with the node's wallet and
+// get one try per challenge, without the expected values.
+func (h *Handlers) ValidateAnswer(c *gin.Context) {
+	var req ValidateAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "missing required fields")})
+		return
+	}
+	challengeID := c.Param("challengeId")
+
+	nodeID := ""
+	if !isAdmin(c) {
+		if req.NodeID == "" || req.Signature == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "admin key or node signature required")})
+			return
+		}
+		node := h.store.GetNode(req.NodeID)
+		if node == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
+			return
+		}
+		if abs(time.Now().UnixMilli()-req.Timestamp) > 5*60*1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "timestamp too old")})
+			return
+		}
+		message := "Validate answer\nID: " + challengeID + "\nNode: " + node.ID + "\nTimestamp: " + fmt.Sprintf("%d", req.Timestamp)
+		if !h.verifySignature(message, req.Signature, node.WalletAddress) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "invalid signature")})
+			return
+		}
+		nodeID = node.ID
+	}
+
+	diff, err := h.verifier.ValidateAnswer(challengeID, nodeID, req.Answer)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, diff)
+	case verification.ErrChallengeNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, err.Error())})
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, err.Error())})
+	}
+}
+
+// POST /challenges/submit
+func (h *Handlers) SubmitChallenge(c *gin.Context) {
+	var req SubmitChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "missing required fields")})
+		return
+	}
+
+	node := h.store.GetNode(req.NodeID)
+	if node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
+		return
+	}
+
+	// Verify signature. v1 doesn't cover the node or challenge type, and
+	// nodes in the rollout have to use v2.
+	version := req.Version
+	if version == 0 {
+		version = signing.AnswerV1
+	}
+	if version < signing.AnswerV2 && h.verifier.Flags().EnabledFor(verification.FlagAnswerV2, node.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "sign answers with message version 2")})
+		return
+	}
+	if version >= signing.AnswerV2 && req.ChallengeType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "challenge_type required")})
+		return
+	}
+	if version >= signing.AnswerV3 && !proverbuild.Valid(req.Build) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "build must be 0x and a sha256 hash")})
+		return
+	}
+	if version < signing.AnswerV2 {
+		req.ChallengeType = "" // Not signed, so not trusted
+	}
+	if version < signing.AnswerV3 {
+		req.Build = ""
+	}
+	message, ok := signing.AnswerMessage(version, req.ChallengeID, req.NodeID, req.ChallengeType, req.Answer, req.Build, req.Timestamp)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "unknown message version")})
+		return
+	}
+	if !h.verifySignature(message, req.Signature, node.WalletAddress) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "invalid signature")})
+		return
+	}
+
+	// Many wallets submitting from one machine looks like a Sybil farm
+	fingerprint, conn := connectionFingerprint(c)
+	h.store.RecordSubmissionFingerprint(node.ID, fingerprint, conn, time.Now().UnixMilli())
+	if h.countryHeader != "" {
+		h.store.RecordNodeCountry(node.ID, geo.NormalizeCountry(c.GetHeader(h.countryHeader)))
+	}
+	h.store.RecordClientVersion(node.ID, req.ClientVersion)
+	h.store.RecordProverBuild(node.ID, req.Build)
+
+	answer := &types.ChallengeResponse{
+		ChallengeID:    req.ChallengeID,
+		NodeID:         req.NodeID,
+		Answer:         req.Answer,
+		Signature:      req.Signature,
+		ResponseTimeMs: req.ResponseTimeMs,
+		Timestamp:      req.Timestamp,
+		Nonce:          req.Nonce,
+		Wallet:         node.WalletAddress,
+		ChallengeType:  req.ChallengeType,
+	}
+
+	// Every submission is kept against its challenge, not just the first
+	attempt := submissionAttempt(answer, time.Now().UnixMilli())
+
+	// During maintenance the answer is checked once it's over, as of now
+	if maintenance := h.store.ServiceMaintenance(); maintenance != nil && h.verifier.HoldAnswer(answer, attempt.ReceivedAt) {
+		attempt.Held = true
+		h.store.RecordSubmission(answer.ChallengeID, attempt)
+		c.JSON(http.StatusAccepted, gin.H{
+			"held":        true,
+			"message":     tr(c, "in maintenance, your answer will be checked when it's back"),
+			"maintenance": maintenance,
+		})
+		return
+	}
+
+	// Verify the response
+	release, ok := h.waitTurn(c, node)
+	if !ok {
+		return
+	}
+	done := track(c, "verifier")
+	result := h.verifier.VerifyResponse(answer)
+	done()
+	release()
+
+	// A retried submit gets the first verdict back but mustn't count twice
+	pointsBefore := h.totalPoints(node.ID)
+	attempt.Passed, attempt.FailureKind, attempt.Repeat = result.Passed, result.FailureKind, result.Retry
+	done = track(c, "store")
+	h.store.RecordSubmission(answer.ChallengeID, attempt)
+	if !result.Retry {
+		h.store.RecordVerificationResult(result)
+	}
+	done()
+
+	response := verifyResponse(c, result)
+	receipt, err := h.receipt(c, result, pointsBefore)
+	if err != nil {
+		log.Printf("failed to sign receipt for challenge %s: %v", result.ChallengeID, err)
+	}
+	response.Receipt = receipt
+
+	h.setBudgetHeaders(c, node.ID, time.Now().UnixMilli())
+	c.JSON(http.StatusOK, response)
+}
+
+// A submission to keep against its challenge, before anything is known
+// of its outcome
+func submissionAttempt(answer *types.ChallengeResponse, now int64) types.SubmissionAttempt {
+	sum := sha256.Sum256([]byte(answer.Answer))
+	return types.SubmissionAttempt{
+		NodeID:     answer.NodeID,
+		AnswerHash: hex.EncodeToString(sum[:]),
+		ReceivedAt: now,
+	}
+}
+
+// A receipt for a verified answer, signed if signing is on, so the
+// operator has their own record of the result and what it did to their
+// points
+func (h *Handlers) receipt(c *gin.Context, result *types.VerificationResult, pointsBefore uint64) (*signing.Receipt, error) {
+	total := h.totalPoints(result.NodeID)
+	done := track(c, "sign")
+	defer done()
+	receipt, err := h.verifier.Keys().SignReceipt(signing.Receipt{
+		ChallengeID:   result.ChallengeID,
+		NodeID:        result.NodeID,
+		ChallengeType: result.ChallengeType,
+		Passed:        result.Passed,
+		FailureKind:   result.FailureKind,
+		PointsDelta:   int64(total) - int64(pointsBefore),
+		TotalPoints:   total,
+		Timestamp:     time.Now().UnixMilli(),
+	})
+	return &receipt, err
+}
+
+func (h *Handlers) totalPoints(nodeID string) uint64 {
+	if stats := h.store.GetNodeStats(nodeID); stats != nil {
+		return stats.TotalPoints
+	}
+	return 0
+}
+
+// GET /server-key - Address the server currently signs with
+func (h *Handlers) GetServerKey(c *gin.Context) {
+	key := h.verifier.Keys().Active()
+	if key == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled": true,
+		"address": key.Signer.Address(),
+		"key_id":  key.ID,
+		"scheme":  "personal_sign",
+	})
+}
+
+// GET /server-keys - Every key whose signatures are still valid (JWKS style)
+func (h *Handlers) GetServerKeys(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"keys": h.verifier.Keys().JWKS(),
+	})
+}
+
+// ==================
+// DIRECT VERIFICATION
+// ==================
+
+// POST /verify/:nodeId
+func (h *Handlers) VerifyNode(c *gin.Context) {
+	nodeID := c.Param("nodeId")
+	node := h.store.GetNode(nodeID)
+
+	if node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
+		return
+	}
+
+	if node.VerificationMethod != types.ExposedRPC {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "node is not using exposed-rpc method")})
+		return
+	}
+
+	if node.Paused {
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, "node is paused for maintenance")})
+		return
+	}
+
+	release, ok := h.waitTurn(c, node)
+	if !ok {
+		return
+	}
+	done := track(c, "verifier")
+	result := h.verifier.VerifyExposedRPC(node)
+	done()
+	release()
+	done = track(c, "store")
+	h.store.RecordVerificationResult(result)
+	done()
+
+	c.JSON(http.StatusOK, verifyResponse(c, result))
+}
+
+// GET /verify/:nodeId/heartbeat
+func (h *Handlers) CheckHeartbeat(c *gin.Context) {
+	nodeID := c.Param("nodeId")
+	node := h.store.GetNode(nodeID)
+
+	if node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
+		return
+	}
+
+	if node.VerificationMethod != types.ExposedRPC {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "node is not using exposed-rpc method")})
+		return
+	}
+
+	if node.Paused {
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, "node is paused for maintenance")})
+		return
+	}
+
+	heartbeat := h.verifier.CheckHeartbeat(node)
+	if heartbeat == nil {
+		h.store.RecordMissedHeartbeat(nodeID, time.Now().UnixMilli())
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": tr(c, "node unreachable")})
+		return
+	}
+
+	h.store.RecordHeartbeat(heartbeat)
+	c.JSON(http.StatusOK, heartbeat)
+}
+
+// POST /nodes/:nodeId/heartbeat - A local prover's signed report of the
+// newest block its node has, checked against the trusted chain
+type ProverHeartbeatRequest struct {
+	BlockNumber uint64 `json:"block_number" binding:"required"`
+	BlockHash   string `json:"block_hash" binding:"required"`
+	Timestamp   int64  `json:"timestamp" binding:"required"`
+	Signature   string `json:"signature" binding:"required"`
+}
+
+func (h *Handlers) ProverHeartbeat(c *gin.Context) {
+	var req ProverHeartbeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "missing required fields")})
+		return
+	}
+
+	nodeID := c.Param("nodeId")
+	node := h.store.GetNode(nodeID)
+	if node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
+		return
+	}
+
+	if node.VerificationMethod != types.LocalProver {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "node is not using local-prover method")})
+		return
+	}
+
+	// Greenfield storage providers have no blocks of their own to report
+	if node.NodeType.Chain() == types.ChainGreenfield {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "heartbeats are not supported for this node type")})
+		return
+	}
+
+	if node.Paused {
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, "node is paused for maintenance")})
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	if abs(now-req.Timestamp) > 5*60*1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "timestamp too old")})
+		return
+	}
+
+	message := signing.HeartbeatMessage(nodeID, req.BlockNumber, req.BlockHash, req.Timestamp)
+	if !h.verifySignature(message, req.Signature, node.WalletAddress) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "invalid signature")})
+		return
+	}
+
+	if !h.store.ClaimHeartbeat(nodeID, req.Timestamp) {
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, "heartbeat already received")})
+		return
+	}
+
+	done := track(c, "verifier")
+	check, err := h.verifier.CheckProverHeartbeat(node, req.BlockNumber, req.BlockHash)
+	done()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": tr(c, "trusted RPC unavailable")})
+		return
+	}
+
+	// A made-up block is evidence of a node that isn't really running
+	if check.Suspicious != "" {
+		h.store.AddSuspiciousEvent(nodeID, check.Suspicious)
+	}
+
+	heartbeat := &types.HeartbeatRecord{
+		NodeID:      nodeID,
+		Timestamp:   now,
+		BlockNumber: req.BlockNumber,
+		BlockHash:   req.BlockHash,
+		IsSynced:    check.Synced,
+	}
+	h.store.RecordHeartbeat(heartbeat)
+
+	c.JSON(http.StatusOK, gin.H{
+		"heartbeat":    heartbeat,
+		"trusted_head": check.TrustedHead,
+		"suspicious":   check.Suspicious != "",
+	})
+}
+
+// POST /verify/:nodeId/storage - Check an exposed-rpc node stores as much data as its type needs
+func (h *Handlers) CheckStorage(c *gin.Context) {
+	nodeID := c.Param("nodeId")
+	node := h.store.GetNode(nodeID)
+
+	if node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
+		return
+	}
+
+	if node.VerificationMethod != types.ExposedRPC {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "node is not using exposed-rpc method")})
+		return
+	}
+
+	if node.Paused {
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, "node is paused for maintenance")})
+		return
+	}
+
+	report := h.verifier.CheckStorage(node)
+	h.store.UpdateNode(nodeID, func(n *types.NodeRegistration) {
+		n.Storage = report
+	})
+
+	// A claimed archive node without archive data is lying about its type
+	if report.Verdict == types.StorageTooSmall || report.Verdict == types.StorageNoDeepState {
+		h.store.AddSuspiciousEvent(nodeID, report.Note)
+	}
+
+	// Either way, propose the type it really is
+	if to, reason, ok := verification.ProposeNodeType(node.NodeType, report); ok {
+		h.store.ProposeReclassification(nodeID, to, reason, time.Now().UnixMilli())
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ==================
+// PUBLIC DATA
+// ==================
+
+// GET /leaderboard
+func (h *Handlers) GetLeaderboard(c *gin.Context) {
+	done := track(c, "store")
+	network := h.store.Network()
+	done()
+
+	cacheKey := leaderboardCacheKey + string(network)
+	done = track(c, "cache")
+	cached := h.cachedResponse(cacheKey)
+	done()
+	if cached != nil {
+		h.respondStats(c, cached)
+		return
+	}
+
+	done = track(c, "store")
+	nodes := h.store.GetAllActiveNodes()
+	rules := h.store.LeaderboardRules()
+	done()
+
+	type LeaderboardEntry struct {
+		Rank               int              `json:"rank"`
+		NodeID             string           `json:"node_id"`
+		WalletAddress      string           `json:"wallet_address"`
+		WalletName         string           `json:"wallet_name,omitempty"`
+		NodeType           types.NodeType   `json:"node_type"`
+		TotalPoints        uint64           `json:"total_points"`
+		TotalUptimeHours   float64          `json:"total_uptime_hours"`
+		ChallengePassRate  float64          `json:"challenge_pass_rate"`
+		RegisteredAt       int64            `json:"registered_at"`
+		LastVerifiedAt     int64            `json:"last_verified_at"`
+		LastHeartbeatAt    int64            `json:"last_heartbeat_at"`
+		Liveness           types.Liveness   `json:"liveness"`
+		HealthGrade        types.HealthGrade `json:"health_grade"`
+	}
+
+	now := time.Now().UnixMilli()
+	entries := make([]LeaderboardEntry, 0, len(nodes))
+	for _, node := range nodes {
+		// Each network has its own leaderboard
+		if node.Network != network {
+			continue
+		}
+
+		done := track(c, "store")
+		stats := h.store.GetNodeStats(node.ID)
+		done()
+
+		var passRate float64
+		if stats != nil {
+			passRate = stats.ChallengePassRate
+		}
+		if !rules.Eligible(node, passRate) {
+			continue
+		}
+		done = track(c, "store")
+		health, _ := h.store.GetHealth(node.ID)
+		done()
+
+		entry := LeaderboardEntry{
+			NodeID:             node.ID,
+			WalletAddress:      node.WalletAddress,
+			WalletName:         h.walletName(node.WalletAddress),
+			NodeType:           node.NodeType,
+			TotalPoints:        node.TotalPoints,
+			TotalUptimeHours:   float64(node.TotalUptimeMinutes) / 60.0,
+			RegisteredAt:       node.RegisteredAt,
+			LastVerifiedAt:     node.LastVerifiedAt,
+			LastHeartbeatAt:    node.LastHeartbeatAt,
+			Liveness:           node.Liveness(now),
+			ChallengePassRate:  passRate,
+			HealthGrade:        health.Grade,
+		}
+		entries = append(entries, entry)
+	}
+
+	// Sort by total points (highest first)
+	done = track(c, "sort")
+	for i := 0; i < len(entries); i++ {
+		for j := i + 1; j < len(entries); j++ {
+			if entries[j].TotalPoints > entries[i].TotalPoints {
+				entries[i], entries[j] = entries[j], entries[i]
+			}
+		}
+	}
+	done()
+
+	// Add ranks and limit to 100
+	if len(entries) > 100 {
+		entries = entries[:100]
+	}
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+
+	h.cacheResponse(cacheKey, entries, leaderboardCacheTTL)
+	h.respondStats(c, entries)
+}
+
+// GET /network - Which network this server runs and the chain IDs nodes need to be on
+type NetworkResponse struct {
+	Network          types.Network          `json:"network"`
+	ChainIDs         map[types.Chain]uint64 `json:"chain_ids"`
+	PointsMultiplier uint64                 `json:"points_multiplier"`
+}
+
+func (h *Handlers) GetNetwork(c *gin.Context) {
+	network := h.store.Network()
+	chainIDs := make(map[types.Chain]uint64)
+	for _, chain := range []types.Chain{types.ChainBSC, types.ChainOpBNB, types.ChainGreenfield} {
+		chainIDs[chain] = chain.ChainID(network)
+	}
+
+	c.JSON(http.StatusOK, NetworkResponse{
+		Network:          network,
+		ChainIDs:         chainIDs,
+		PointsMultiplier: network.PointsMultiplier(),
+	})
+}
+
+// GET /node-types - What each node type needs and earns. Requirements come
+// straight from the types package; the hourly rate is the one in force.
+type NodeTypeInfo struct {
+	NodeType                  types.NodeType        `json:"node_type"`
+	Chain                     types.Chain           `json:"chain"`
+	RegistrationBonus         uint64                `json:"registration_bonus"`
+	PointsPerHour             uint64                `json:"points_per_hour"`
+	MinUptimePercent          uint8                 `json:"min_uptime_percent"`
+	MinDiskGB                 uint64                `json:"min_disk_gb"`
+	MinCPUs                   int                   `json:"min_cpus"`
+	ChallengeFrequencyMinutes uint64                `json:"challenge_frequency_minutes"`
+	ChallengeTypes            []types.ChallengeType `json:"challenge_types"`
+}
+
+func (h *Handlers) GetNodeTypes(c *gin.Context) {
+	infos := make([]NodeTypeInfo, 0, len(types.NodeTypes))
+	for _, t := range types.NodeTypes {
+		infos = append(infos, NodeTypeInfo{
+			NodeType:                  t,
+			Chain:                     t.Chain(),
+			RegistrationBonus:         t.RegistrationBonus(),
+			PointsPerHour:             h.store.PointsPerHour(t),
+			MinUptimePercent:          t.MinUptimePercent(),
+			MinDiskGB:                 t.MinDiskGB(),
+			MinCPUs:                   t.MinCPUs(),
+			ChallengeFrequencyMinutes: t.ChallengeFrequencyMinutes(),
+			ChallengeTypes:            t.ChallengeTypes(),
+		})
+	}
+
+	// Points are before the network's multiplier, which is sent alongside
+	c.JSON(http.StatusOK, gin.H{
+		"node_types":        infos,
+		"points_multiplier": h.store.Network().PointsMultiplier(),
+	})
+}
+
+// GET /regions - How underrepresented each country is, and the uptime bonus its nodes get
+func (h *Handlers) GetRegionWeights(c *gin.Context) {
+	c.JSON(http.StatusOK, h.store.RegionWeights())
+}
+
+// GET /stats
+func (h *Handlers) GetNetworkStats(c *gin.Context) {
+	done := track(c, "store")
+	nodes := h.store.GetAllActiveNodes()
+	failures := h.store.GetNetworkFailureCounts()
+	done()
+
+	byType := make(map[string]int)
+	byMethod := make(map[string]int)
+	byClient := make(map[string]int)
+	outdated := 0
+
+	for _, node := range nodes {
+		byType[string(node.NodeType)]++
+		byMethod[string(node.VerificationMethod)]++
+		if node.ClientVersion == "" {
+			continue
+		}
+		if v, ok := clientversion.Parse(node.ClientVersion); ok {
+			byClient[v.String()]++
+		} else {
+			byClient["unknown"]++
+		}
+		if node.ClientOutdated {
+			outdated++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total_nodes":       len(nodes),
+		"by_type":           byType,
+		"by_method":         byMethod,
+		"by_client_version": byClient,
+		"outdated_clients":  outdated,
+		"failures_by_kind":  failures,
+	})
+}
+
+// GET /hardforks - How ready the network is for each scheduled hard fork
+func (h *Handlers) GetHardForks(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"forks": h.store.HardForkReadiness(time.Now().UnixMilli()),
+	})
+}
+
+// GET /announcements - News for node operators, newest first. The prover
+// prints these at startup.
+func (h *Handlers) GetAnnouncements(c *gin.Context) {
+	done := track(c, "store")
+	announcements := h.store.GetAnnouncements(time.Now().UnixMilli())
+	done()
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":         len(announcements),
+		"announcements": announcements,
+	})
+}
+
+// GET /transparency - Aggregate numbers anyone can use to audit how challenges are run
+func (h *Handlers) GetTransparency(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"challenges_issued":      h.verifier.IssuedStats(),
+		"pass_rate_by_node_type": h.store.GetPassRatesByNodeType(),
+		"nodes_by_cheat_status":  h.store.CountByCheatStatus(),
+		"leaderboard_rules":      h.store.LeaderboardRules(),
+		"generated_at":           time.Now().UnixMilli(),
+	})
+}
+
+// GET /service-status - The verification service's own uptime and how
+// reliably it issued challenges, so a node's failures can be told apart
+// from the service's
+func (h *Handlers) GetServiceStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.verifier.ServiceStatus().Report(time.Now().UnixMilli()))
+}
+
+// ==================
+// API KEYS
+// ==================
+
+// POST /keys - A wallet signs up for an API key, for reading the public
+// endpoints on a plan's rate limit rather than the anonymous one
+type CreateAPIKeyRequest struct {
+	WalletAddress string `json:"wallet_address" binding:"required"`
+	Label         string `json:"label"` // e.g. the site it's for
+	Signature     string `json:"signature" binding:"required"`
+	Timestamp     int64  `json:"timestamp" binding:"required"`
+}
+
+const maxAPIKeyLabelLength = 64
+
+func (h *Handlers) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "missing required fields")})
+		return
+	}
+	if len(req.Label) > maxAPIKeyLabelLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "label too long")})
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	if abs(now-req.Timestamp) > 5*60*1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "timestamp too old")})
+		return
+	}
+	message := "Create API key\nWallet: " + req.WalletAddress + "\nLabel: " + req.Label + "\nTimestamp: " + fmt.Sprintf("%d", req.Timestamp)
+	if !h.verifySignature(message, req.Signature, req.WalletAddress) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "invalid signature")})
+		return
+	}
+
+	secret, key, err := h.store.CreateAPIKey(strings.ToLower(req.WalletAddress), req.Label, ratelimit.DefaultPlan, now)
+	if err == store.ErrTooManyAPIKeys {
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+
+	// The only time the key itself is shown
+	c.JSON(http.StatusCreated, gin.H{"api_key": secret, "key": key})
+}
+
+// A key with what it's been used for since the server started
+type APIKeyUsage struct {
+	types.APIKey
+	Usage *ratelimit.Usage `json:"usage,omitempty"` // Nil = not used yet
+}
+
+// GET /keys/:walletAddress - A wallet's keys, their plans and usage
+func (h *Handlers) GetAPIKeys(c *gin.Context) {
+	usage := make(map[string]ratelimit.Usage)
+	for _, u := range h.limiter.Top(0) {
+		usage[u.Client] = u
+	}
+
+	keys := []APIKeyUsage{}
+	for _, key := range h.store.GetAPIKeys(strings.ToLower(c.Param("walletAddress"))) {
+		entry := APIKeyUsage{APIKey: key}
+		if u, ok := usage[key.ID]; ok {
+			entry.Usage = &u
+		}
+		keys = append(keys, entry)
+	}
+	c.JSON(http.StatusOK, gin.H{"keys": keys})
+}
+
+// ==================
+// TWO-FACTOR
+// ==================
+
+// Issuer shown next to the wallet in authenticator apps
+const totpIssuer = "DePIN BNB"
+
+// POST /wallets/totp - Start turning on two-factor for a wallet. Once it's
+// confirmed, actions signed by the wallet that a stolen hot key shouldn't
+// be enough for (changing a node's endpoint or client certificate,
+// accepting a new node type) need a code from the authenticator as well.
+type TOTPRequest struct {
+	WalletAddress string `json:"wallet_address" binding:"required"`
+	Code          string `json:"code"` // From the authenticator, to confirm or turn off
+	Signature     string `json:"signature" binding:"required"`
+	Timestamp     int64  `json:"timestamp" binding:"required"`
+}
+
+// Bind a two-factor request and check the wallet signed action for it.
+// Returns the lowercased wallet, or "" after writing the error.
+func (h *Handlers) bindTOTPRequest(c *gin.Context, action string, req *TOTPRequest) string {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "missing required fields")})
+		return ""
+	}
+
+	now := time.Now().UnixMilli()
+	if abs(now-req.Timestamp) > 5*60*1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "timestamp too old")})
+		return ""
+	}
+	message := action + "\nWallet: " + req.WalletAddress + "\nTimestamp: " + fmt.Sprintf("%d", req.Timestamp)
+	if !h.verifySignature(message, req.Signature, req.WalletAddress) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "invalid signature")})
+		return ""
+	}
+	return strings.ToLower(req.WalletAddress)
+}
+
+func (h *Handlers) EnrollTOTP(c *gin.Context) {
+	var req TOTPRequest
+	wallet := h.bindTOTPRequest(c, "Enable two-factor", &req)
+	if wallet == "" {
+		return
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+	if err := h.store.EnrollTOTP(wallet, secret, time.Now().UnixMilli()); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+
+	// The only time the secret is shown
+	c.JSON(http.StatusCreated, gin.H{
+		"secret": secret,
+		"uri":    totp.URI(totpIssuer, wallet, secret),
+	})
+}
+
+// POST /wallets/totp/confirm - Turn two-factor on with the first code
+func (h *Handlers) ConfirmTOTP(c *gin.Context) {
+	var req TOTPRequest
+	wallet := h.bindTOTPRequest(c, "Confirm two-factor", &req)
+	if wallet == "" {
+		return
+	}
+
+	switch err := h.store.ConfirmTOTP(wallet, req.Code, time.Now().UnixMilli()); err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"success": true, "wallet_address": wallet})
+	case store.ErrTOTPNotEnrolled:
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, err.Error())})
+	case store.ErrTOTPLocked:
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": tr(c, err.Error())})
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": tr(c, err.Error())})
+	}
+}
+
+// POST /wallets/totp/disable - Turn two-factor off, with a current code.
+// Lost the authenticator? An admin can reset it.
+func (h *Handlers) DisableTOTP(c *gin.Context) {
+	var req TOTPRequest
+	wallet := h.bindTOTPRequest(c, "Disable two-factor", &req)
+	if wallet == "" {
+		return
+	}
+
+	switch err := h.store.DisableTOTP(wallet, req.Code, time.Now().UnixMilli()); err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"success": true, "wallet_address": wallet})
+	case store.ErrTOTPNotEnabled:
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, err.Error())})
+	case store.ErrTOTPLocked:
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": tr(c, err.Error())})
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": tr(c, err.Error())})
+	}
+}
+
+// GET /wallet/:walletAddress/totp - Whether a wallet's signed actions need a code
+func (h *Handlers) GetTOTPStatus(c *gin.Context) {
+	wallet := strings.ToLower(c.Param("walletAddress"))
+	enabled, since := h.store.TOTPStatus(wallet)
+	c.JSON(http.StatusOK, gin.H{"wallet_address": wallet, "enabled": enabled, "enabled_at": since})
+}
+
+// The second factor for an action wallet signed, if it has two-factor on.
+// Call after the signature checks out. Writes the error and returns false
+// if the code is missing or wrong, or too many wrong ones locked it.
+func (h *Handlers) checkTOTP(c *gin.Context, wallet, code string) bool {
+	switch err := h.store.CheckTOTP(strings.ToLower(wallet), code, time.Now().UnixMilli()); err {
+	case nil:
+		return true
+	case store.ErrTOTPRequired:
+		c.JSON(http.StatusForbidden, gin.H{"error": tr(c, err.Error()), "totp_required": true})
+	case store.ErrTOTPLocked:
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": tr(c, err.Error())})
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": tr(c, err.Error())})
+	}
+	return false
+}
+
+// ==================
+// COMMUNITY REPORTS
+// ==================
+
+// POST /reports - Any wallet can report a node it thinks is cheating
+type ReportRequest struct {
+	ReporterWallet string `json:"reporter_wallet" binding:"required"`
+	NodeID         string `json:"node_id" binding:"required"`
+	Evidence       string `json:"evidence" binding:"required"`
+	Signature      string `json:"signature" binding:"required"`
+	Timestamp      int64  `json:"timestamp" binding:"required"`
+}
+
+const maxEvidenceLength = 2000
+
+func (h *Handlers) FileReport(c *gin.Context) {
+	var req ReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "missing required fields")})
+		return
+	}
+
+	if len(req.Evidence) > maxEvidenceLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, fmt.Sprintf("evidence too long (max %d characters)", maxEvidenceLength))})
+		return
+	}
+
+	// Check timestamp is recent (within 5 minutes)
+	now := time.Now().UnixMilli()
+	if abs(now-req.Timestamp) > 5*60*1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "timestamp too old")})
+		return
+	}
+
+	// Evidence is part of the message so it can't be swapped after signing
+	message := "Report node\nNode: " + req.NodeID + "\nTimestamp: " + fmt.Sprintf("%d", req.Timestamp) + "\nEvidence: " + req.Evidence
+	if !h.verifySignature(message, req.Signature, req.ReporterWallet) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "invalid signature")})
+		return
+	}
+
+	reporter := strings.ToLower(req.ReporterWallet)
+	report, err := h.store.FileReport(reporter, req.NodeID, req.Evidence, now)
+	switch err {
+	case nil:
+	case store.ErrNodeNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
+		return
+	case store.ErrReporterBlocked:
+		c.JSON(http.StatusForbidden, gin.H{"error": tr(c, err.Error())})
+		return
+	case store.ErrTooManyOpenReports:
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": tr(c, err.Error())})
+		return
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"report":     report,
+		"reputation": h.store.GetReporterReputation(reporter),
+	})
+}
+
+// ==================
+// ADMIN ENDPOINTS
+// ==================
+
+// GET /admin/flagged - Get all nodes that need review
+type FlaggedNode struct {
+	types.NodeRegistration
+	Latency  types.LatencyPercentiles `json:"latency_24h"`
+	Trust    types.TrustScore         `json:"trust"`
+	Reports  []FlaggedReport          `json:"reports,omitempty"`  // Open community reports
+	Siblings []types.SiblingNode      `json:"siblings,omitempty"` // Nodes sharing its wallet, address or endpoint host
+}
+
+// A community report with how reliable its reporter has been
+type FlaggedReport struct {
+	types.CheatReport
+	ReporterScore float64 `json:"reporter_score"`
+}
+
+func (h *Handlers) GetFlaggedNodes(c *gin.Context) {
+	flagged := h.store.GetFlaggedNodes()
+
+	// Don't expose auth tokens
+	safeNodes := make([]FlaggedNode, len(flagged))
+	for i, node := range flagged {
+		safeNodes[i].NodeRegistration = *node
+		safeNodes[i].AuthToken = ""
+		safeNodes[i].ClientCert = withoutSecret(node.ClientCert)
+		safeNodes[i].Latency = h.store.GetLatencyPercentiles(node.ID)
+		safeNodes[i].Trust = node.Trust
+		safeNodes[i].Siblings = h.store.GetSiblings(node.ID)
+
+		for _, report := range h.store.GetOpenReports(node.ID) {
+			safeNodes[i].Reports = append(safeNodes[i].Reports, FlaggedReport{
+				CheatReport:   report,
+				ReporterScore: h.store.GetReporterReputation(report.ReporterWallet).Score,
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(safeNodes),
+		"nodes": safeNodes,
+	})
+}
+
+// GET /admin/verifications/:challengeId - What exactly went wrong with a failed challenge
+func (h *Handlers) GetVerificationReplay(c *gin.Context) {
+	replay := h.store.GetChallengeReplay(c.Param("challengeId"))
+	if replay == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "no failed verification for this challenge")})
+		return
+	}
+
+	c.JSON(http.StatusOK, replay)
+}
+
+// GET /admin/verifications/:challengeId/attempts - Every submission to a
+// challenge, repeats and late ones included, oldest first
+func (h *Handlers) GetSubmissionAttempts(c *gin.Context) {
+	attempts := h.store.SubmissionAttempts(c.Param("challengeId"))
+	if len(attempts) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "no submissions kept for this challenge")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"challenge_id": c.Param("challengeId"), "attempts": attempts})
+}
+
+// GET /admin/metrics - Latency and errors per route, and the latest slow requests
+func (h *Handlers) GetMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"routes":            h.metrics.Routes(),
+		"queue":             h.verifier.Queue().Stats(),
+		"slow_threshold_ms": h.metrics.SlowThreshold().Milliseconds(),
+		"slow_requests":     h.metrics.SlowRequests(),
+	})
+}
+
+// GET /admin/alerts - Recent network anomaly alerts, newest first
+func (h *Handlers) GetAlerts(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"alerts": h.alerts.Alerts()})
+}
+
+// GET /admin/store/usage - Entry counts and estimated memory held by the store
+func (h *Handlers) GetStoreUsage(c *gin.Context) {
+	c.JSON(http.StatusOK, h.store.Usage())
+}
+
+// GET /admin/store/snapshot - The state read-only replicas serve from
+// (gob, for replicas rather than people)
+func (h *Handlers) GetStoreSnapshot(c *gin.Context) {
+	c.Header("Content-Type", "application/octet-stream")
+	c.Status(http.StatusOK)
+	if err := h.store.WriteSnapshot(c.Writer); err != nil {
+		c.Error(err)
+	}
+}
+
+// GET /admin/snapshot - Download a backup of the whole store: nodes,
+// points, history and the moderation log. Unlike /admin/store/snapshot,
+// which is what replicas follow, this can be restored.
+func (h *Handlers) ExportStore(c *gin.Context) {
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="depin-%s.backup"`, time.Now().UTC().Format("20060102-150405")))
+	c.Status(http.StatusOK)
+	if err := h.store.Export(c.Writer); err != nil {
+		c.Error(err)
+	}
+}
+
+// POST /admin/restore - Replace the whole store with a backup from
+// /admin/snapshot, sent as the request body. Everything since the backup
+// was taken is lost.
+func (h *Handlers) RestoreStore(c *gin.Context) {
+	takenAt, err := h.store.Import(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "restore failed: "+err.Error())})
+		return
+	}
+
+	// Logged after the restore, so it's on the end of the restored log
+	now := time.Now().UnixMilli()
+	details := map[string]string{"taken_at": strconv.FormatInt(takenAt, 10)}
+	h.store.ModerationLog().Append(modlog.ActionRestoreStore, adminID(c), "store", "", details, now)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"taken_at": takenAt,
+		"nodes":    h.store.Sizes()["nodes"],
+	})
+}
+
+// POST /admin/store/compact - Release spare slice capacity now instead of waiting for the hourly pass
+func (h *Handlers) CompactStore(c *gin.Context) {
+	c.JSON(http.StatusOK, h.store.Compact(time.Now().UnixMilli()))
+}
+
+// Nodes recomputed per store lock, so a recompute doesn't stall requests
+const recomputeBatch = 100
+
+// POST /admin/recompute - Recompute every node's trust score under the current
+// rules, tallying health grades and leaderboard eligibility as it goes.
+// Runs in the background; follow it with GET /admin/recompute.
+func (h *Handlers) StartRecompute(c *gin.Context) {
+	now := time.Now().UnixMilli()
+	job, started := h.store.StartRecompute(now)
+	if !started {
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, "a recompute is already running"), "recompute": job})
+		return
+	}
+	h.store.ModerationLog().Append(modlog.ActionRecompute, adminID(c), "stats", "", map[string]string{"nodes": strconv.Itoa(job.Total)}, now)
+
+	go func() {
+		for job.Done < job.Total {
+			job = h.store.RecomputeStep(recomputeBatch, time.Now().UnixMilli())
+		}
+		log.Printf("recompute finished: %d nodes, %d trust scores changed", job.Done, job.TrustChanged)
+	}()
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GET /admin/recompute - Progress of the running recompute, or the last one's result
+func (h *Handlers) GetRecompute(c *gin.Context) {
+	job, ok := h.store.RecomputeStatus()
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "no recompute has run")})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// POST /admin/config/reload - Re-read the config like SIGHUP does, without shell access to the server
+func (h *Handlers) ReloadConfig(c *gin.Context) {
+	if h.reload == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": tr(c, "config reload not available")})
+		return
+	}
+
+	skipped, err := h.reload()
+	if err != nil {
+		// Nothing was applied; the running config stays as it was
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+
+	details := map[string]string{}
+	if len(skipped) > 0 {
+		details["restart_required"] = strings.Join(skipped, ",")
+	}
+	h.store.ModerationLog().Append(modlog.ActionReloadConfig, adminID(c), "config", "", details, time.Now().UnixMilli())
+
+	if skipped == nil {
+		skipped = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":          true,
+		"restart_required": skipped,
+	})
+}
+
+// GET /admin/fingerprints - Connection fingerprints shared by more than one wallet
+func (h *Handlers) GetFingerprintClusters(c *gin.Context) {
+	clusters := h.store.GetFingerprintClusters()
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":    len(clusters),
+		"clusters": clusters,
+	})
+}
+
+// GET /admin/prover-builds - Which prover builds active nodes answer
+// with, and the nodes on builds that aren't official releases
+func (h *Handlers) GetProverBuilds(c *gin.Context) {
+	done := track(c, "store")
+	nodes := h.store.GetAllActiveNodes()
+	done()
+
+	byBuild := make(map[string]int)
+	unofficial := []string{}
+	for _, node := range nodes {
+		if node.ProverBuild == "" {
+			continue
+		}
+		byBuild[node.ProverBuild]++
+		if node.UnofficialBuild {
+			unofficial = append(unofficial, node.ID)
+		}
+	}
+	sort.Strings(unofficial)
+
+	c.JSON(http.StatusOK, gin.H{
+		"by_build":         byBuild,
+		"unofficial_nodes": unofficial,
+	})
+}
+
+// GET /admin/features?since=&format=csv - Anonymized anti-cheat features of
+// every verification, for training an anomaly model offline. Each export
+// gets a fresh salt, so pseudonyms can't be joined across exports or back
+// to nodes. CSV only; for Parquet, convert the CSV.
+func (h *Handlers) GetVerificationFeatures(c *gin.Context) {
+	var since int64
+	if raw := c.Query("since"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "since must be a unix timestamp in milliseconds")})
+			return
+		}
+		since = n
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "failed to salt the export")})
+		return
+	}
+	done := track(c, "store")
+	rows := h.store.VerificationFeatures(since, hex.EncodeToString(salt))
+	done()
+
+	if c.Query("format") == "csv" {
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", `attachment; filename="verification-features.csv"`)
+		w := csv.NewWriter(c.Writer)
+		w.Write([]string{"node", "challenge_type", "response_time_ms", "hour_of_day", "day", "block_age", "cluster", "cluster_wallets", "surprise", "suspicious", "passed", "failure_kind"})
+		for _, row := range rows {
+			blockAge := ""
+			if row.BlockAge != nil {
+				blockAge = strconv.FormatUint(*row.BlockAge, 10)
+			}
+			w.Write([]string{
+				row.Node,
+				string(row.ChallengeType),
+				strconv.FormatUint(row.ResponseTimeMs, 10),
+				strconv.Itoa(row.HourOfDay),
+				row.Day,
+				blockAge,
+				row.Cluster,
+				strconv.Itoa(row.ClusterWallets),
+				strconv.FormatBool(row.Surprise),
+				strconv.FormatBool(row.Suspicious),
+				strconv.FormatBool(row.Passed),
+				string(row.FailureKind),
+			})
+		}
+		w.Flush()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(rows),
+		"rows":  rows,
+	})
+}
+
+// GET /admin/trust/:nodeId - A node's trust score and the factors behind it
+func (h *Handlers) GetTrustScore(c *gin.Context) {
+	trust, ok := h.store.GetTrustScore(c.Param("nodeId"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
+		return
+	}
+
+	c.JSON(http.StatusOK, trust)
+}
+
+// POST /admin/review/:nodeId - Admin reviews a flagged node
+type ReviewRequest struct {
+	Action string `json:"action" binding:"required"` // "clear", "warn", "ban"
+	Reason string `json:"reason"`
+}
+
+func (h *Handlers) ReviewNode(c *gin.Context) {
+	nodeID := c.Param("nodeId")
+
+	var req ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "action required (clear, warn, or ban)")})
+		return
+	}
+
+	var status types.CheatStatus
+	switch req.Action {
+	case "clear":
+		status = types.StatusClean
+	case "warn":
+		status = types.StatusWarning
+	case "ban":
+		status = types.StatusBanned
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "invalid action - use clear, warn, or ban")})
+		return
+	}
+
+	if status == types.StatusBanned {
+		h.requestNodeBan(c, nodeID, req.Reason)
+		return
+	}
+
+	if !h.store.SetNodeCheatStatus(nodeID, status, req.Reason) {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
+		return
+	}
+
+	action := modlog.ActionClear
+	if status == types.StatusWarning {
+		action = modlog.ActionWarn
+	}
+	h.store.ModerationLog().Append(action, adminID(c), nodeID, req.Reason, nil, time.Now().UnixMilli())
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"node_id": nodeID,
+		"status":  status,
+		"message": fmt.Sprintf("Node status set to %s", status),
+	})
+}
+
+// With ban approval on, the first admin's ban waits for a second one
+func (h *Handlers) requestNodeBan(c *gin.Context, nodeID, reason string) {
+	now := time.Now().UnixMilli()
+	pending, err := h.store.RequestNodeBan(nodeID, reason, adminID(c), now)
+	switch err {
+	case nil:
+	case store.ErrNodeNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "node not found")})
+		return
+	case store.ErrSameAdmin:
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, err.Error())})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+
+	if pending != nil {
+		h.store.ModerationLog().Append(modlog.ActionBanRequested, adminID(c), pending.ID, reason, nil, now)
+		c.JSON(http.StatusAccepted, gin.H{
+			"success":     true,
+			"node_id":     nodeID,
+			"pending_ban": pending,
+			"message":     "Ban recorded - a second admin must confirm it before it takes effect",
+		})
+		return
+	}
+
+	// Banning flags nodes that share its wallet, address or endpoint host
+	siblings := h.store.GetSiblings(nodeID)
+	details := map[string]string{"siblings": fmt.Sprintf("%d", len(siblings))}
+	h.store.ModerationLog().Append(modlog.ActionBanNode, adminID(c), nodeID, reason, details, now)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"node_id":  nodeID,
+		"status":   types.StatusBanned,
+		"message":  fmt.Sprintf("Node status set to %s", types.StatusBanned),
+		"siblings": siblings,
+	})
+}
+
+// GET /admin/pending-bans - Bans waiting for a second admin to confirm
+func (h *Handlers) GetPendingBans(c *gin.Context) {
+	pending := h.store.GetPendingBans(time.Now().UnixMilli())
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":        len(pending),
+		"pending_bans": pending,
+	})
+}
+
+// GET /admin/reclassifications - Proposed type changes waiting to be applied
+func (h *Handlers) GetReclassifications(c *gin.Context) {
+	open := h.store.GetOpenReclassifications()
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":             len(open),
+		"reclassifications": open,
+	})
+}
+
+// POST /admin/reclassifications/:nodeId - Apply a proposed type change now,
+// or dismiss it (e.g. the operator showed the probe was wrong)
+type ResolveReclassificationRequest struct {
+	Action string `json:"action" binding:"required"` // "apply", "dismiss"
+	Reason string `json:"reason"`
+}
+
+func (h *Handlers) ResolveReclassification(c *gin.Context) {
+	nodeID := c.Param("nodeId")
+
+	var req ResolveReclassificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Action != "apply" && req.Action != "dismiss") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "action required (apply or dismiss)")})
 		return
 	}
 
-	var status types.CheatStatus
-	switch req.Action {
-	case "clear":
-		status = types.StatusClean
-	case "warn":
-		status = types.StatusWarning
-	case "ban":
-		status = types.StatusBanned
+	now := time.Now().UnixMilli()
+	apply := req.Action == "apply"
+	reclassification, err := h.store.ResolveReclassification(nodeID, apply, "", adminID(c), now)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+
+	action := modlog.ActionKeepNodeType
+	if apply {
+		action = modlog.ActionReclassify
+	}
+	details := map[string]string{"from": string(reclassification.From), "to": string(reclassification.To)}
+	h.store.ModerationLog().Append(action, adminID(c), nodeID, req.Reason, details, now)
+
+	c.JSON(http.StatusOK, reclassification)
+}
+
+// POST /admin/points/:nodeId/reverse/:entryId - Undo a points ledger entry
+type ReversePointsRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+func (h *Handlers) ReversePoints(c *gin.Context) {
+	nodeID := c.Param("nodeId")
+	entryID, err := strconv.ParseUint(c.Param("entryId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "entryId must be a ledger entry number")})
+		return
+	}
+
+	var req ReversePointsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "reason required")})
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	reversal, err := h.store.ReversePoints(nodeID, entryID, req.Reason, now)
+	switch err {
+	case nil:
+	case store.ErrNodeNotFound, store.ErrEntryNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, err.Error())})
+		return
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid action - use clear, warn, or ban"})
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, err.Error())})
 		return
 	}
 
-	if !h.store.SetNodeCheatStatus(nodeID, status, req.Reason) {
-		c.JSON(http.StatusNotFound, gin.H{"error": "node not found"})
+	details := map[string]string{"entry": c.Param("entryId"), "amount": strconv.FormatInt(reversal.Amount, 10)}
+	h.store.ModerationLog().Append(modlog.ActionReversePoints, adminID(c), nodeID, req.Reason, details, now)
+
+	c.JSON(http.StatusOK, gin.H{
+		"reversal":     reversal,
+		"total_points": h.store.GetNode(nodeID).TotalPoints,
+	})
+}
+
+// POST /admin/points/:nodeId/adjust - Add points to a node, or take them
+// away with a negative amount, as a new ledger entry
+type AdjustPointsRequest struct {
+	Amount int64  `json:"amount" binding:"required"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+func (h *Handlers) AdjustPoints(c *gin.Context) {
+	nodeID := c.Param("nodeId")
+
+	var req AdjustPointsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "non-zero amount and reason required")})
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	adjustment, err := h.store.AdjustPoints(nodeID, req.Amount, req.Reason, now)
+	switch err {
+	case nil:
+	case store.ErrNodeNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, err.Error())})
+		return
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+
+	details := map[string]string{"entry": strconv.FormatUint(adjustment.ID, 10), "amount": strconv.FormatInt(adjustment.Amount, 10)}
+	h.store.ModerationLog().Append(modlog.ActionAdjustPoints, adminID(c), nodeID, req.Reason, details, now)
+
+	c.JSON(http.StatusOK, gin.H{
+		"adjustment":   adjustment,
+		"total_points": h.store.GetNode(nodeID).TotalPoints,
+	})
+}
+
+// POST /admin/epochs - Close the next reward epoch: finalize every
+// wallet's unpaid points up to until (default now) for the minting script
+type CloseEpochRequest struct {
+	Until int64 `json:"until"` // Unix ms
+}
+
+func (h *Handlers) CloseEpoch(c *gin.Context) {
+	var req CloseEpochRequest
+	c.ShouldBindJSON(&req) // Until is optional
+
+	now := time.Now().UnixMilli()
+	if req.Until == 0 {
+		req.Until = now
+	}
+	epoch, err := h.store.CloseEpoch(req.Until, now)
+	switch err {
+	case nil:
+	case store.ErrEpochEmpty:
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, err.Error())})
+		return
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+
+	details := map[string]string{
+		"until":       strconv.FormatInt(epoch.Until, 10),
+		"merkle_root": epoch.MerkleRoot,
+		"total":       strconv.FormatUint(epoch.Total, 10),
+	}
+	h.store.ModerationLog().Append(modlog.ActionCloseEpoch, adminID(c), strconv.FormatUint(epoch.Epoch, 10), "", details, now)
+
+	wallets := len(epoch.Claims)
+	epoch.Claims = nil
+	c.JSON(http.StatusCreated, gin.H{"epoch": epoch, "wallets": wallets})
+}
+
+// GET /admin/epochs - Every closed epoch, oldest first
+func (h *Handlers) GetEpochs(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"epochs": h.store.GetEpochs()})
+}
+
+// GET /admin/epochs/:epoch/export?format=csv - An epoch's claims with their
+// proofs, for the minting script. The CSV has one claim per row, its proof
+// hashes joined by ";".
+func (h *Handlers) ExportEpoch(c *gin.Context) {
+	number, err := strconv.ParseUint(c.Param("epoch"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "epoch must be a number")})
+		return
+	}
+	epoch := h.store.GetEpoch(number)
+	if epoch == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "epoch not found")})
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="epoch-%d.csv"`, epoch.Epoch))
+		w := csv.NewWriter(c.Writer)
+		w.Write([]string{"address", "amount", "epoch", "proof"})
+		for _, claim := range epoch.Claims {
+			w.Write([]string{
+				claim.WalletAddress,
+				strconv.FormatUint(claim.Amount, 10),
+				strconv.FormatUint(claim.Epoch, 10),
+				strings.Join(claim.Proof, ";"),
+			})
+		}
+		w.Flush()
+		return
+	}
+
+	c.JSON(http.StatusOK, epoch)
+}
+
+// GET /admin/wallet-bans - Every wallet ban, newest first
+func (h *Handlers) GetWalletBans(c *gin.Context) {
+	bans := h.store.GetWalletBans()
+	now := time.Now().UnixMilli()
+
+	active := 0
+	for _, ban := range bans {
+		if ban.Active(now) {
+			active++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":  len(bans),
+		"active": active,
+		"bans":   bans,
+	})
+}
+
+// POST /admin/wallet-bans/:walletAddress - Ban a wallet directly
+type WalletBanRequest struct {
+	Reason string  `json:"reason" binding:"required"`
+	Days   *uint64 `json:"days"` // Unset = configured cooldown, 0 = permanent
+}
+
+func (h *Handlers) BanWallet(c *gin.Context) {
+	wallet := strings.ToLower(c.Param("walletAddress"))
+
+	var req WalletBanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "reason required")})
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	pending, ban, err := h.store.RequestWalletBan(wallet, req.Reason, req.Days, adminID(c), now)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+	if pending != nil {
+		h.store.ModerationLog().Append(modlog.ActionBanRequested, adminID(c), pending.ID, req.Reason, nil, now)
+		c.JSON(http.StatusAccepted, gin.H{
+			"success":     true,
+			"pending_ban": pending,
+			"message":     "Ban recorded - a second admin must confirm it before it takes effect",
+		})
+		return
+	}
+
+	details := map[string]string{"expires_at": fmt.Sprintf("%d", ban.ExpiresAt)}
+	h.store.ModerationLog().Append(modlog.ActionBanWallet, adminID(c), wallet, req.Reason, details, now)
+	c.JSON(http.StatusOK, ban)
+}
+
+// POST /admin/wallet-bans/:walletAddress/lift - End a wallet ban early
+func (h *Handlers) LiftWalletBan(c *gin.Context) {
+	wallet := strings.ToLower(c.Param("walletAddress"))
+
+	now := time.Now().UnixMilli()
+	if err := h.store.LiftWalletBan(wallet, now); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+	h.store.ModerationLog().Append(modlog.ActionLiftWalletBan, adminID(c), wallet, "", nil, now)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "wallet_address": wallet})
+}
+
+// POST /admin/wallets/:walletAddress/totp/reset - Turn off a wallet's
+// two-factor without a code, for an operator who lost their authenticator.
+// With ban approval on, a second admin has to confirm it like a ban.
+type ResetTOTPRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *Handlers) ResetTOTP(c *gin.Context) {
+	var req ResetTOTPRequest
+	c.ShouldBindJSON(&req) // Reason is optional
+
+	wallet := strings.ToLower(c.Param("walletAddress"))
+	now := time.Now().UnixMilli()
+	pending, err := h.store.RequestTOTPReset(wallet, req.Reason, adminID(c), now)
+	switch err {
+	case nil:
+	case store.ErrTOTPNotEnabled:
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "wallet has no two-factor")})
+		return
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+
+	if pending != nil {
+		h.store.ModerationLog().Append(modlog.ActionBanRequested, adminID(c), pending.ID, req.Reason, nil, now)
+		c.JSON(http.StatusAccepted, gin.H{
+			"success":     true,
+			"pending_ban": pending,
+			"message":     "Reset recorded - a second admin must confirm it before it takes effect",
+		})
+		return
+	}
+	h.store.ModerationLog().Append(modlog.ActionResetTOTP, adminID(c), wallet, req.Reason, nil, now)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "wallet_address": wallet})
+}
+
+// GET /admin/api-keys/usage - The heaviest readers of the public API since
+// the server started, with their keys. ?limit= (default 20, 0 = all).
+// Requests without a key are counted together as "anonymous".
+type APIConsumer struct {
+	ratelimit.Usage
+	Key *types.APIKey `json:"key,omitempty"` // Nil for anonymous traffic
+}
+
+func (h *Handlers) GetAPIUsage(c *gin.Context) {
+	limit := 20
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "limit must be a whole number")})
+			return
+		}
+		limit = n
+	}
+
+	consumers := []APIConsumer{}
+	for _, u := range h.limiter.Top(limit) {
+		consumer := APIConsumer{Usage: u}
+		if key, ok := h.store.GetAPIKey(u.Client); ok {
+			consumer.Key = &key
+		}
+		consumers = append(consumers, consumer)
+	}
+	c.JSON(http.StatusOK, gin.H{"consumers": consumers})
+}
+
+// POST /admin/announcements - Post news for node operators
+const (
+	maxAnnouncementTitle = 200
+	maxAnnouncementBody  = 4000
+)
+
+type PostAnnouncementRequest struct {
+	Kind      types.AnnouncementKind `json:"kind"` // Default general
+	Title     string                 `json:"title" binding:"required"`
+	Body      string                 `json:"body"`
+	ExpiresAt int64                  `json:"expires_at"` // Unix ms, 0 = until retracted
+}
+
+func (h *Handlers) PostAnnouncement(c *gin.Context) {
+	var req PostAnnouncementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "title required")})
+		return
+	}
+	if req.Kind == "" {
+		req.Kind = types.AnnounceGeneral
+	}
+	now := time.Now().UnixMilli()
+	switch {
+	case !req.Kind.Valid():
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, fmt.Sprintf("unknown kind %q", req.Kind))})
+		return
+	case len(req.Title) > maxAnnouncementTitle || len(req.Body) > maxAnnouncementBody:
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, fmt.Sprintf("title is limited to %d bytes and body to %d", maxAnnouncementTitle, maxAnnouncementBody))})
+		return
+	case req.ExpiresAt != 0 && req.ExpiresAt <= now:
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "expires_at is in the past")})
+		return
+	}
+
+	announcement := h.store.PostAnnouncement(req.Kind, req.Title, req.Body, req.ExpiresAt, now)
+	details := map[string]string{"kind": string(announcement.Kind), "title": announcement.Title}
+	h.store.ModerationLog().Append(modlog.ActionAnnounce, adminID(c), strconv.FormatUint(announcement.ID, 10), "", details, now)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "announcement": announcement})
+}
+
+// POST /admin/announcements/:id/retract - Take an announcement down
+func (h *Handlers) RetractAnnouncement(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err == nil {
+		err = h.store.RetractAnnouncement(id)
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, store.ErrAnnouncementNotFound.Error())})
+		return
+	}
+	h.store.ModerationLog().Append(modlog.ActionUnannounce, adminID(c), c.Param("id"), "", nil, time.Now().UnixMilli())
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// GET /admin/maintenance - Whether the service is in maintenance, and how
+// many answers are waiting for it to end
+func (h *Handlers) GetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"maintenance":  h.store.ServiceMaintenance(),
+		"held_answers": h.verifier.HeldAnswers(),
+	})
+}
+
+// POST /admin/maintenance - Put the whole service in maintenance, or
+// change the reason or expected end of the one under way
+type StartMaintenanceRequest struct {
+	Reason string `json:"reason"`
+	Until  int64  `json:"until"` // Expected end, unix ms; 0 = not given
+}
+
+func (h *Handlers) StartMaintenance(c *gin.Context) {
+	var req StartMaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "invalid request")})
+		return
+	}
+	now := time.Now().UnixMilli()
+	if req.Until != 0 && req.Until <= now {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "until is in the past")})
+		return
+	}
+
+	maintenance := h.store.StartServiceMaintenance(req.Reason, req.Until, now)
+	details := map[string]string{"until": strconv.FormatInt(req.Until, 10)}
+	h.store.ModerationLog().Append(modlog.ActionPauseAPI, adminID(c), "service", req.Reason, details, now)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "maintenance": maintenance})
+}
+
+// POST /admin/maintenance/end - End maintenance and check the answers held
+// during it
+func (h *Handlers) EndMaintenance(c *gin.Context) {
+	now := time.Now().UnixMilli()
+	ended, err := h.store.EndServiceMaintenance(now)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+
+	passed := 0
+	results := h.verifier.VerifyHeld()
+	for _, result := range results {
+		if !result.Retry {
+			h.store.RecordVerificationResult(result)
+		}
+		if result.Passed {
+			passed++
+		}
+	}
+	details := map[string]string{
+		"minutes":        strconv.FormatInt((now-ended.StartedAt)/(60*1000), 10),
+		"answers_held":   strconv.Itoa(len(results)),
+		"answers_passed": strconv.Itoa(passed),
+	}
+	h.store.ModerationLog().Append(modlog.ActionResumeAPI, adminID(c), "service", "", details, now)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "maintenance": ended, "answers_held": len(results), "answers_passed": passed})
+}
+
+// POST /admin/api-keys/:keyId/plan - Move a key to another rate plan
+type SetAPIKeyPlanRequest struct {
+	Plan   string `json:"plan" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+func (h *Handlers) SetAPIKeyPlan(c *gin.Context) {
+	var req SetAPIKeyPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "plan required")})
+		return
+	}
+	plan := strings.ToLower(req.Plan)
+	if plan == ratelimit.Anonymous || !h.limiter.HasPlan(plan) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, fmt.Sprintf("unknown plan %q", req.Plan))})
+		return
+	}
+
+	key, err := h.store.SetAPIKeyPlan(c.Param("keyId"), plan)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+	h.store.ModerationLog().Append(modlog.ActionSetAPIKeyPlan, adminID(c), key.ID, req.Reason, map[string]string{"plan": plan}, time.Now().UnixMilli())
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "key": key})
+}
+
+// POST /admin/api-keys/:keyId/revoke - Stop a key working, e.g. one that
+// leaked or is being abused
+type RevokeAPIKeyRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *Handlers) RevokeAPIKey(c *gin.Context) {
+	var req RevokeAPIKeyRequest
+	c.ShouldBindJSON(&req) // Reason is optional
+
+	now := time.Now().UnixMilli()
+	key, err := h.store.RevokeAPIKey(c.Param("keyId"), now)
+	switch err {
+	case nil:
+	case store.ErrAPIKeyRevoked:
+		c.JSON(http.StatusConflict, gin.H{"error": tr(c, err.Error())})
+		return
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+	h.store.ModerationLog().Append(modlog.ActionRevokeAPIKey, adminID(c), key.ID, req.Reason, nil, now)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "key": key})
+}
+
+// GET /admin/flags - List feature flags and their rollout
+func (h *Handlers) GetFlags(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"flags": h.verifier.Flags().All(),
+	})
+}
+
+// POST /admin/flags/:name - Change a flag's rollout percentage
+type SetFlagRequest struct {
+	Percent *uint8 `json:"percent" binding:"required"`
+}
+
+func (h *Handlers) SetFlag(c *gin.Context) {
+	name := c.Param("name")
+
+	var req SetFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil || *req.Percent > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "percent required (0-100)")})
+		return
+	}
+
+	if err := h.verifier.Flags().Set(name, *req.Percent); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, err.Error())})
 		return
 	}
 
+	details := map[string]string{"percent": fmt.Sprintf("%d", *req.Percent)}
+	h.store.ModerationLog().Append(modlog.ActionSetFlag, adminID(c), name, "", details, time.Now().UnixMilli())
+
+	flag, _ := h.verifier.Flags().Get(name)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"node_id": nodeID,
-		"status":  status,
-		"message": fmt.Sprintf("Node status set to %s", status),
+		"flag":    flag,
 	})
 }
 
+// GET /admin/moderation-log?since=<seq> - Every admin action, hash-chained.
+// The head hash is signed with the server key (if signing is on), so a
+// published export can be checked against it.
+type ModerationLogResponse struct {
+	Entries   []modlog.Entry `json:"entries"`
+	PrevHash  string         `json:"prev_hash"` // Hash before the first entry returned
+	Count     uint64         `json:"count"`     // Entries in the whole log
+	Head      string         `json:"head"`
+	Signature string         `json:"signature,omitempty"` // Over modlog.ExportMessage(count, head)
+	KeyID     string         `json:"key_id,omitempty"`
+}
+
+func (h *Handlers) GetModerationLog(c *gin.Context) {
+	var since uint64
+	if raw := c.Query("since"); raw != "" {
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "since must be an entry number")})
+			return
+		}
+		since = n
+	}
+
+	// One snapshot, so the entries, count and head agree
+	all := h.store.ModerationLog().Since(0)
+	count := uint64(len(all))
+	if since > count {
+		since = count
+	}
+
+	resp := ModerationLogResponse{
+		Entries:  all[since:],
+		PrevHash: modlog.GenesisHash,
+		Count:    count,
+		Head:     modlog.GenesisHash,
+	}
+	if count > 0 {
+		resp.Head = all[count-1].Hash
+	}
+	if since > 0 {
+		resp.PrevHash = all[since-1].Hash
+	}
+
+	if key := h.verifier.Keys().Active(); key != nil {
+		sig, err := key.Signer.Sign(modlog.ExportMessage(resp.Count, resp.Head))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "failed to sign log")})
+			return
+		}
+		resp.Signature = sig
+		resp.KeyID = key.ID
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
 func abs(x int64) int64 {
 	if x < 0 {
 		return -x
@@ -523,7 +3268,7 @@ type TestCreateNodeRequest struct {
 func (h *Handlers) TestCreateNode(c *gin.Context) {
 	var req TestCreateNodeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing required fields: wallet_address, node_type, verification_method"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "missing required fields: wallet_address, node_type, verification_method")})
 		return
 	}
 
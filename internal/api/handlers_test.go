@@ -1,15 +1,43 @@
 package api
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
-
+	"time"
+
+	"github.com/depinonbnb/depin/internal/attestation"
+	"github.com/depinonbnb/depin/internal/budget"
+	"github.com/depinonbnb/depin/internal/clientcert"
+	"github.com/depinonbnb/depin/internal/i18n"
+	"github.com/depinonbnb/depin/internal/metrics"
+	"github.com/depinonbnb/depin/internal/mockchain"
+	"github.com/depinonbnb/depin/internal/modlog"
+	"github.com/depinonbnb/depin/internal/proverbuild"
+	"github.com/depinonbnb/depin/internal/ratelimit"
+	"github.com/depinonbnb/depin/internal/replica"
+	"github.com/depinonbnb/depin/internal/rewards"
+	"github.com/depinonbnb/depin/internal/sharedcache"
+	"github.com/depinonbnb/depin/internal/signing"
 	"github.com/depinonbnb/depin/internal/store"
+	"github.com/depinonbnb/depin/internal/store/storemock"
+	"github.com/depinonbnb/depin/internal/totp"
 	"github.com/depinonbnb/depin/internal/types"
 	"github.com/depinonbnb/depin/internal/verification"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,7 +45,7 @@ func init() {
 	gin.SetMode(gin.TestMode)
 }
 
-func setupTestRouter(adminKey string) (*gin.Engine, *store.Store) {
+func setupTestRouter(adminKey string) (*gin.Engine, *store.MemoryStore) {
 	s := store.NewStore()
 	v := verification.NewVerifier("https://bsc-dataseed1.binance.org")
 	router := SetupRouter(s, v, adminKey)
@@ -81,342 +109,3104 @@ func TestGetNodeStats(t *testing.T) {
 	}
 }
 
-func TestGetLeaderboard(t *testing.T) {
+func TestGetUptimeCalendar(t *testing.T) {
 	router, s := setupTestRouter("")
 
-	// Create nodes with different points
-	s.RegisterNode("0x1", types.BscArchive, types.LocalProver, "", "") // 100 pts
-	s.RegisterNode("0x2", types.BscFull, types.LocalProver, "", "")    // 50 pts
-	s.RegisterNode("0x3", types.OpbnbFast, types.LocalProver, "", "")  // 30 pts
+	node := s.RegisterNode("0x1", types.BscFull, types.ExposedRPC, "http://test", "")
+	s.RecordHeartbeat(&types.HeartbeatRecord{
+		NodeID:    node.ID,
+		Timestamp: time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC).UnixMilli(),
+		IsSynced:  true,
+	})
 
-	req, _ := http.NewRequest("GET", "/api/leaderboard", nil)
+	req, _ := http.NewRequest("GET", "/api/nodes/"+node.ID+"/uptime/calendar?month=2024-03", nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
 	if w.Code != http.StatusOK {
-		t.Errorf("expected status 200, got %d", w.Code)
+		t.Fatalf("expected status 200, got %d", w.Code)
 	}
 
-	var entries []map[string]interface{}
-	json.Unmarshal(w.Body.Bytes(), &entries)
-
-	if len(entries) != 3 {
-		t.Errorf("expected 3 entries, got %d", len(entries))
+	var response struct {
+		Month string            `json:"month"`
+		Days  []types.UptimeDay `json:"days"`
 	}
+	json.Unmarshal(w.Body.Bytes(), &response)
 
-	// Check ranking order (highest points first)
-	if entries[0]["total_points"].(float64) != 100 {
-		t.Error("first entry should have 100 points (BSC Archive)")
-	}
-	if entries[1]["total_points"].(float64) != 50 {
-		t.Error("second entry should have 50 points (BSC Full)")
+	if response.Month != "2024-03" || len(response.Days) != 31 {
+		t.Fatalf("expected 31 days for 2024-03, got %d for %s", len(response.Days), response.Month)
 	}
-	if entries[2]["total_points"].(float64) != 30 {
-		t.Error("third entry should have 30 points (opBNB Fast)")
-	}
-
-	// Check ranks
-	if entries[0]["rank"].(float64) != 1 {
-		t.Error("first entry should have rank 1")
+	if response.Days[9].UptimePercent != 100 {
+		t.Errorf("expected 100%% uptime on Mar 10, got %f", response.Days[9].UptimePercent)
 	}
-}
-
-func TestGetLeaderboardExcludesBanned(t *testing.T) {
-	router, s := setupTestRouter("")
-
-	node1 := s.RegisterNode("0x1", types.BscArchive, types.LocalProver, "", "")
-	s.RegisterNode("0x2", types.BscFull, types.LocalProver, "", "")
-
-	// Ban first node
-	s.SetNodeCheatStatus(node1.ID, types.StatusBanned, "cheating")
 
-	req, _ := http.NewRequest("GET", "/api/leaderboard", nil)
-	w := httptest.NewRecorder()
+	// Bad month
+	req, _ = http.NewRequest("GET", "/api/nodes/"+node.ID+"/uptime/calendar?month=March", nil)
+	w = httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	var entries []map[string]interface{}
-	json.Unmarshal(w.Body.Bytes(), &entries)
-
-	if len(entries) != 1 {
-		t.Errorf("expected 1 entry (banned excluded), got %d", len(entries))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for bad month, got %d", w.Code)
 	}
 }
 
-func TestGetNetworkStats(t *testing.T) {
+func TestCompareNodes(t *testing.T) {
 	router, s := setupTestRouter("")
 
-	s.RegisterNode("0x1", types.BscFull, types.LocalProver, "", "")
-	s.RegisterNode("0x2", types.BscArchive, types.ExposedRPC, "http://test", "")
-	s.RegisterNode("0x3", types.OpbnbFull, types.LocalProver, "", "")
+	good := s.RegisterNode("0x1", types.BscFull, types.LocalProver, "", "")
+	bad := s.RegisterNode("0x1", types.BscFull, types.LocalProver, "", "")
 
-	req, _ := http.NewRequest("GET", "/api/stats", nil)
+	now := time.Now().UnixMilli()
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: good.ID, Passed: true, ResponseTimeMs: 30, Timestamp: now})
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: bad.ID, Passed: false, ResponseTimeMs: 800, Timestamp: now})
+
+	req, _ := http.NewRequest("GET", "/api/nodes/compare?ids="+good.ID+","+bad.ID+",missing", nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
 	if w.Code != http.StatusOK {
-		t.Errorf("expected status 200, got %d", w.Code)
+		t.Fatalf("expected status 200, got %d", w.Code)
 	}
 
-	var stats map[string]interface{}
-	json.Unmarshal(w.Body.Bytes(), &stats)
-
-	if stats["total_nodes"].(float64) != 3 {
-		t.Errorf("expected 3 total nodes, got %v", stats["total_nodes"])
+	var response struct {
+		Nodes []struct {
+			NodeID            string                              `json:"node_id"`
+			ChallengePassRate float64                             `json:"challenge_pass_rate"`
+			Latency           map[string]types.LatencyPercentiles `json:"latency_percentiles"`
+			Uptime7dPercent   float64                             `json:"uptime_7d_percent"`
+		} `json:"nodes"`
+		NotFound []string `json:"not_found"`
 	}
+	json.Unmarshal(w.Body.Bytes(), &response)
 
-	byType := stats["by_type"].(map[string]interface{})
-	if byType["bsc-full"].(float64) != 1 {
-		t.Error("expected 1 bsc-full node")
+	if len(response.Nodes) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(response.Nodes))
+	}
+	if response.Nodes[0].NodeID != good.ID || response.Nodes[0].ChallengePassRate != 100 || response.Nodes[0].Uptime7dPercent != 100 {
+		t.Errorf("unexpected stats for good node: %+v", response.Nodes[0])
+	}
+	if response.Nodes[1].ChallengePassRate != 0 || response.Nodes[1].Latency["1h"].P50 != 800 {
+		t.Errorf("unexpected stats for bad node: %+v", response.Nodes[1])
+	}
+	if len(response.NotFound) != 1 || response.NotFound[0] != "missing" {
+		t.Errorf("expected missing in not_found, got %v", response.NotFound)
 	}
 
-	byMethod := stats["by_method"].(map[string]interface{})
-	if byMethod["local-prover"].(float64) != 2 {
-		t.Error("expected 2 local-prover nodes")
+	// No ids
+	req, _ = http.NewRequest("GET", "/api/nodes/compare", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 without ids, got %d", w.Code)
 	}
 }
 
-func TestGetWalletStats(t *testing.T) {
-	router, s := setupTestRouter("")
+// GET /challenges/request signed by signer
+func requestChallenge(router *gin.Engine, nodeID string, signer *signing.Signer, timestamp int64) *httptest.ResponseRecorder {
+	sig, _ := signer.Sign(fmt.Sprintf("Request challenge\nNode: %s\nTimestamp: %d", nodeID, timestamp))
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/challenges/request?nodeId=%s&timestamp=%d&signature=%s", nodeID, timestamp, sig), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
 
-	wallet := "0xmywallet"
-	s.RegisterNode(wallet, types.BscArchive, types.LocalProver, "", "") // 100 pts
-	s.RegisterNode(wallet, types.BscFull, types.LocalProver, "", "")    // 50 pts
+func TestRequestChallengeSigned(t *testing.T) {
+	server := httptest.NewServer(mockchain.New(46000000))
+	defer server.Close()
 
-	req, _ := http.NewRequest("GET", "/api/wallet/"+wallet+"/stats", nil)
+	s := store.NewStore()
+	router := SetupRouter(s, verification.NewVerifier(server.URL))
+
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	node := s.RegisterNode(strings.ToLower(wallet.Address()), types.BscFull, types.LocalProver, "", "")
+
+	// Just a node ID isn't enough any more
+	req, _ := http.NewRequest("GET", "/api/challenges/request?nodeId="+node.ID, nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unsigned request, got %d", w.Code)
+	}
 
-	if w.Code != http.StatusOK {
-		t.Errorf("expected status 200, got %d", w.Code)
+	// Truncated, empty and non-hex signatures are refused, not a panic
+	for _, sig := range []string{"", "0x", "0xabcd", strings.Repeat("z", 130)} {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/api/challenges/request?nodeId=%s&timestamp=%d&signature=%s", node.ID, time.Now().UnixMilli(), sig), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401 for signature %q, got %d", sig, w.Code)
+		}
 	}
 
-	var stats types.WalletStats
-	json.Unmarshal(w.Body.Bytes(), &stats)
+	otherKey, _ := crypto.GenerateKey()
+	other, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(otherKey)))
+	if w := requestChallenge(router, node.ID, other, time.Now().UnixMilli()); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for another wallet, got %d", w.Code)
+	}
 
-	if stats.TotalPoints != 150 {
-		t.Errorf("expected 150 total points, got %d", stats.TotalPoints)
+	timestamp := time.Now().UnixMilli()
+	if w := requestChallenge(router, node.ID, wallet, timestamp); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
 	}
 
-	if stats.TotalNodes != 2 {
-		t.Errorf("expected 2 nodes, got %d", stats.TotalNodes)
+	// A signed request only buys one challenge
+	if w := requestChallenge(router, node.ID, wallet, timestamp); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a replayed request, got %d", w.Code)
+	}
+	if w := requestChallenge(router, node.ID, wallet, timestamp+1); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for a fresh request, got %d", w.Code)
 	}
 }
 
-func TestGetWalletStatsNotFound(t *testing.T) {
-	router, _ := setupTestRouter("")
+func TestRequestChallengeBudget(t *testing.T) {
+	server := httptest.NewServer(mockchain.New(46000000))
+	defer server.Close()
 
-	req, _ := http.NewRequest("GET", "/api/wallet/0xnonexistent/stats", nil)
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	s := store.NewStore()
+	v := verification.NewVerifier(server.URL)
+	v.SetChallengeBudget(s.ChargeChallenge)
+	caps, _ := budget.ParseCaps("*=1")
+	s.SetChallengeCaps(caps)
+	router := SetupRouter(s, v)
+
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	node := s.RegisterNode(strings.ToLower(wallet.Address()), types.BscFull, types.LocalProver, "", "")
+
+	timestamp := time.Now().UnixMilli()
+	w := requestChallenge(router, node.ID, wallet, timestamp)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("X-RateLimit-Limit") != "1" || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("expected the budget in the headers, got %v", w.Header())
+	}
+	if reset, _ := strconv.ParseInt(w.Header().Get("X-RateLimit-Reset"), 10, 64); reset*1000 != budget.NextDay(timestamp) {
+		t.Errorf("expected the reset at the next UTC midnight, got %d", reset)
+	}
 
-	if w.Code != http.StatusNotFound {
-		t.Errorf("expected status 404, got %d", w.Code)
+	w = requestChallenge(router, node.ID, wallet, timestamp+1)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 once the day's budget is used up, got %d", w.Code)
+	}
+	if retry, _ := strconv.ParseInt(w.Header().Get("Retry-After"), 10, 64); retry <= 0 || retry > 24*60*60 {
+		t.Errorf("expected Retry-After until midnight, got %q", w.Header().Get("Retry-After"))
+	}
+
+	if b := s.GetNodeStats(node.ID).ChallengeBudget; len(b.Issued) != 1 || b.TotalCap != 1 {
+		t.Errorf("node stats should show the budget, got %+v", b)
 	}
 }
 
-func TestGetNodesByWallet(t *testing.T) {
-	router, s := setupTestRouter("")
+func TestRequestChallengeSilentNode(t *testing.T) {
+	server := httptest.NewServer(mockchain.New(46000000))
+	defer server.Close()
 
-	wallet := "0xmywallet"
-	s.RegisterNode(wallet, types.BscFull, types.LocalProver, "", "")
-	s.RegisterNode(wallet, types.BscArchive, types.LocalProver, "", "")
+	s := store.NewStore()
+	router := SetupRouter(s, verification.NewVerifier(server.URL))
 
-	req, _ := http.NewRequest("GET", "/api/nodes/wallet/"+wallet, nil)
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	node := s.RegisterNode(strings.ToLower(wallet.Address()), types.BscFull, types.LocalProver, "", "")
 
-	if w.Code != http.StatusOK {
-		t.Errorf("expected status 200, got %d", w.Code)
+	// Went quiet: still gets challenges, so it can prove itself back
+	s.UpdateNode(node.ID, func(n *types.NodeRegistration) { n.IsActive, n.Silent = false, true })
+	timestamp := time.Now().UnixMilli()
+	if w := requestChallenge(router, node.ID, wallet, timestamp); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for a silent node, got %d: %s", w.Code, w.Body.String())
 	}
 
-	var nodes []types.NodeRegistration
-	json.Unmarshal(w.Body.Bytes(), &nodes)
+	s.UpdateNode(node.ID, func(n *types.NodeRegistration) { n.Silent = false })
+	if w := requestChallenge(router, node.ID, wallet, timestamp+1); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an inactive node, got %d", w.Code)
+	}
+}
 
-	if len(nodes) != 2 {
-		t.Errorf("expected 2 nodes, got %d", len(nodes))
+func TestProverHeartbeat(t *testing.T) {
+	server := httptest.NewServer(mockchain.New(46000000))
+	defer server.Close()
+
+	s := store.NewStore()
+	router := SetupRouter(s, verification.NewVerifier(server.URL))
+
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	node := s.RegisterNode(strings.ToLower(wallet.Address()), types.BscFull, types.LocalProver, "", "")
+
+	timestamp := time.Now().UnixMilli()
+	send := func(block uint64, hash string, timestamp int64) *httptest.ResponseRecorder {
+		signature, _ := wallet.Sign(signing.HeartbeatMessage(node.ID, block, hash, timestamp))
+		body, _ := json.Marshal(map[string]interface{}{
+			"block_number": block,
+			"block_hash":   hash,
+			"timestamp":    timestamp,
+			"signature":    signature,
+		})
+		req, _ := http.NewRequest("POST", "/api/nodes/"+node.ID+"/heartbeat", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	synced := func() bool {
+		heartbeats := s.GetHeartbeats(node.ID, 0)
+		return heartbeats[len(heartbeats)-1].IsSynced
 	}
 
-	// Auth token should not be exposed
-	for _, node := range nodes {
-		if node.AuthToken != "" {
-			t.Error("auth token should be hidden")
-		}
+	if w := send(45999999, mockchain.BlockHash(45999999), timestamp); w.Code != http.StatusOK || !synced() {
+		t.Fatalf("expected a synced heartbeat for a real block, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send(45999999, mockchain.BlockHash(45999999), timestamp); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a replayed heartbeat, got %d", w.Code)
 	}
-}
 
-// Admin endpoint tests
-func TestAdminEndpointRequiresAuth(t *testing.T) {
-	router, _ := setupTestRouter("secretkey")
+	// Behind, but the block is real: not synced, nothing suspicious
+	if w := send(45990000, mockchain.BlockHash(45990000), timestamp+1); w.Code != http.StatusOK || synced() {
+		t.Errorf("expected an unsynced heartbeat for a lagging node, got %d: %s", w.Code, w.Body.String())
+	}
+	// A block the trusted RPC hasn't seen yet can't be checked by hash
+	if w := send(46000002, mockchain.BlockHash(46000002), timestamp+2); w.Code != http.StatusOK || !synced() {
+		t.Errorf("expected a synced heartbeat just past our head, got %d: %s", w.Code, w.Body.String())
+	}
+	if events := s.GetNode(node.ID).SuspiciousEvents; len(events) != 0 {
+		t.Fatalf("real blocks should not be suspicious, got %v", events)
+	}
 
-	req, _ := http.NewRequest("GET", "/api/admin/flagged", nil)
+	if w := send(45999999, mockchain.BlockHash(1), timestamp+3); w.Code != http.StatusOK || synced() {
+		t.Errorf("expected an unsynced heartbeat for a made-up hash, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send(47000000, mockchain.BlockHash(47000000), timestamp+4); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for a block past the head, got %d", w.Code)
+	}
+	if events := s.GetNode(node.ID).SuspiciousEvents; len(events) != 2 {
+		t.Errorf("a wrong hash and a future block should both be suspicious, got %v", events)
+	}
+
+	exposed := s.RegisterNode(strings.ToLower(wallet.Address()), types.BscFull, types.ExposedRPC, server.URL, "")
+	req, _ := http.NewRequest("POST", "/api/nodes/"+exposed.ID+"/heartbeat", strings.NewReader(`{"block_number":1,"block_hash":"0x","timestamp":1,"signature":"0x"}`))
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
-
-	if w.Code != http.StatusUnauthorized {
-		t.Errorf("expected status 401 without auth, got %d", w.Code)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an exposed-rpc node, got %d", w.Code)
 	}
 }
 
-func TestAdminEndpointWithWrongKey(t *testing.T) {
-	router, _ := setupTestRouter("secretkey")
+func TestSubmitChallengeMessageVersions(t *testing.T) {
+	s := store.NewStore()
+	v := verification.NewVerifier("https://bsc-dataseed1.binance.org")
+	router := SetupRouter(s, v)
+
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	node := s.RegisterNode(strings.ToLower(wallet.Address()), types.BscFull, types.LocalProver, "", "")
+
+	// The challenge doesn't exist, so a 200 just means the signature was accepted
+	submit := func(version int, signedType, sentType types.ChallengeType) int {
+		timestamp := time.Now().UnixMilli()
+		message, _ := signing.AnswerMessage(version, "c1", node.ID, signedType, "0xabc", "", timestamp)
+		sig, _ := wallet.Sign(message)
+		body, _ := json.Marshal(map[string]interface{}{
+			"challenge_id":   "c1",
+			"node_id":        node.ID,
+			"answer":         "0xabc",
+			"signature":      sig,
+			"timestamp":      timestamp,
+			"version":        version,
+			"challenge_type": sentType,
+		})
+		req, _ := http.NewRequest("POST", "/api/challenges/submit", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
 
-	req, _ := http.NewRequest("GET", "/api/admin/flagged", nil)
-	req.Header.Set("Authorization", "Bearer wrongkey")
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	tests := []struct {
+		name       string
+		version    int
+		signedType types.ChallengeType
+		sentType   types.ChallengeType
+		want       int
+	}{
+		{"v1", signing.AnswerV1, "", "", http.StatusOK},
+		{"v2", signing.AnswerV2, types.BlockHash, types.BlockHash, http.StatusOK},
+		{"v2 without a type", signing.AnswerV2, "", "", http.StatusBadRequest},
+		{"v2 signed for another type", signing.AnswerV2, types.BlockHash, types.StateBalance, http.StatusUnauthorized},
+		{"unknown version", 4, types.BlockHash, types.BlockHash, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if code := submit(tt.version, tt.signedType, tt.sentType); code != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, code)
+		}
+	}
 
-	if w.Code != http.StatusUnauthorized {
-		t.Errorf("expected status 401 with wrong key, got %d", w.Code)
+	// Once a node is in the v2 rollout, v1 is refused
+	v.Flags().Set(verification.FlagAnswerV2, 100)
+	if code := submit(signing.AnswerV1, "", ""); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for v1 in the rollout, got %d", code)
+	}
+	if code := submit(signing.AnswerV2, types.BlockHash, types.BlockHash); code != http.StatusOK {
+		t.Errorf("expected v2 to still be accepted, got %d", code)
 	}
 }
 
-func TestAdminEndpointWithCorrectKey(t *testing.T) {
-	router, _ := setupTestRouter("secretkey")
-
-	req, _ := http.NewRequest("GET", "/api/admin/flagged", nil)
-	req.Header.Set("Authorization", "Bearer secretkey")
+func TestSubmitReturnsReceipt(t *testing.T) {
+	s := store.NewStore()
+	v := verification.NewVerifier("https://bsc-dataseed1.binance.org")
+	serverKey, _ := crypto.GenerateKey()
+	server, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(serverKey)))
+	v.Keys().Rotate(server)
+	router := SetupRouter(s, v)
+
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	node := s.RegisterNode(strings.ToLower(wallet.Address()), types.BscFull, types.LocalProver, "", "")
+
+	timestamp := time.Now().UnixMilli()
+	message, _ := signing.AnswerMessage(signing.AnswerV2, "c1", node.ID, types.BlockHash, "0xabc", "", timestamp)
+	sig, _ := wallet.Sign(message)
+	body, _ := json.Marshal(map[string]interface{}{
+		"challenge_id":   "c1",
+		"node_id":        node.ID,
+		"answer":         "0xabc",
+		"signature":      sig,
+		"timestamp":      timestamp,
+		"version":        signing.AnswerV2,
+		"challenge_type": types.BlockHash,
+	})
+	req, _ := http.NewRequest("POST", "/api/challenges/submit", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	if w.Code != http.StatusOK {
-		t.Errorf("expected status 200 with correct key, got %d", w.Code)
+	var response VerifyResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	receipt := response.Receipt
+	if receipt == nil {
+		t.Fatalf("expected a receipt, got %s", w.Body.String())
+	}
+	// The challenge doesn't exist, so it failed
+	if receipt.ChallengeID != "c1" || receipt.NodeID != node.ID || receipt.Passed || receipt.FailureKind == "" {
+		t.Errorf("unexpected receipt: %+v", receipt)
+	}
+	if receipt.TotalPoints != s.GetNode(node.ID).TotalPoints || receipt.PointsDelta != 0 {
+		t.Errorf("expected the node's points unchanged, got %+v", receipt)
+	}
+	if !receipt.Verify([]string{server.Address()}) {
+		t.Error("receipt should be signed with the server key")
 	}
 }
 
-func TestAdminGetFlaggedNodes(t *testing.T) {
-	router, s := setupTestRouter("key")
+func TestSubmitRecordsCountry(t *testing.T) {
+	s := store.NewStore()
+	router, _ := NewRouter(s, verification.NewVerifier("https://bsc-dataseed1.binance.org"), Options{CountryHeader: "CF-IPCountry"})
+
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	node := s.RegisterNode(strings.ToLower(wallet.Address()), types.BscFull, types.LocalProver, "", "")
+
+	submit := func(country string) {
+		timestamp := time.Now().UnixMilli()
+		message, _ := signing.AnswerMessage(signing.AnswerV2, "c1", node.ID, types.BlockHash, "0xabc", "", timestamp)
+		sig, _ := wallet.Sign(message)
+		body, _ := json.Marshal(map[string]interface{}{
+			"challenge_id":   "c1",
+			"node_id":        node.ID,
+			"answer":         "0xabc",
+			"signature":      sig,
+			"timestamp":      timestamp,
+			"version":        signing.AnswerV2,
+			"challenge_type": types.BlockHash,
+		})
+		req, _ := http.NewRequest("POST", "/api/challenges/submit", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("CF-IPCountry", country)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
 
-	s.RegisterNode("0x1", types.BscFull, types.LocalProver, "", "")
-	node2 := s.RegisterNode("0x2", types.BscFull, types.LocalProver, "", "")
-	s.SetNodeCheatStatus(node2.ID, types.StatusWarning, "suspicious")
+	submit("ng")
+	if country := s.GetNode(node.ID).Country; country != "NG" {
+		t.Errorf("expected the node placed in NG, got %q", country)
+	}
+	submit("XX")
+	if country := s.GetNode(node.ID).Country; country != "NG" {
+		t.Errorf("an unknown country shouldn't erase the last one, got %q", country)
+	}
 
-	req, _ := http.NewRequest("GET", "/api/admin/flagged", nil)
-	req.Header.Set("Authorization", "Bearer key")
+	s.SetDiversityBonus(20)
+	s.RecalculateRegionWeights(time.Now().UnixMilli())
+	req, _ := http.NewRequest("GET", "/api/regions", nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	var response map[string]interface{}
-	json.Unmarshal(w.Body.Bytes(), &response)
-
-	if response["count"].(float64) != 1 {
-		t.Errorf("expected 1 flagged node, got %v", response["count"])
+	var weights types.RegionWeights
+	json.Unmarshal(w.Body.Bytes(), &weights)
+	if weights.MaxBonusPercent != 20 || weights.Regions["NG"].Nodes != 1 {
+		t.Errorf("unexpected region weights: %+v", weights)
 	}
 }
 
-func TestAdminReviewNode(t *testing.T) {
-	router, s := setupTestRouter("key")
+func TestValidateAnswer(t *testing.T) {
+	server := httptest.NewServer(mockchain.New(46000000))
+	defer server.Close()
 
-	node := s.RegisterNode("0x1", types.BscFull, types.LocalProver, "", "")
-	s.SetNodeCheatStatus(node.ID, types.StatusWarning, "suspicious")
+	s := store.NewStore()
+	router := SetupRouter(s, verification.NewVerifier(server.URL), "admin-key")
 
-	// Clear the node
-	body := []byte(`{"action": "clear", "reason": "false positive"}`)
-	req, _ := http.NewRequest("POST", "/api/admin/review/"+node.ID, bytes.NewBuffer(body))
-	req.Header.Set("Authorization", "Bearer key")
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	node := s.RegisterNode(strings.ToLower(wallet.Address()), types.BscFull, types.LocalProver, "", "")
 
-	if w.Code != http.StatusOK {
-		t.Errorf("expected status 200, got %d", w.Code)
+	w := requestChallenge(router, node.ID, wallet, time.Now().UnixMilli())
+	var response ChallengeRequestResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	challenge := response.Challenge
+	if challenge.ID == "" {
+		t.Fatalf("no challenge: %s", w.Body.String())
 	}
 
-	// Verify node is cleared
-	updated := s.GetNode(node.ID)
-	if updated.CheatStatus != types.StatusClean {
-		t.Errorf("expected clean status, got %s", updated.CheatStatus)
+	validate := func(auth string, body map[string]interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", "/api/challenges/"+challenge.ID+"/validate", bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		if auth != "" {
+			req.Header.Set("Authorization", "Bearer "+auth)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	signed := func(signer *signing.Signer) map[string]interface{} {
+		timestamp := time.Now().UnixMilli()
+		sig, _ := signer.Sign(fmt.Sprintf("Validate answer\nID: %s\nNode: %s\nTimestamp: %d", challenge.ID, node.ID, timestamp))
+		return map[string]interface{}{"answer": "0x0", "node_id": node.ID, "signature": sig, "timestamp": timestamp}
 	}
-}
-
-func TestAdminReviewNodeBan(t *testing.T) {
-	router, s := setupTestRouter("key")
 
-	node := s.RegisterNode("0x1", types.BscFull, types.LocalProver, "", "")
+	if w := validate("", map[string]interface{}{"answer": "0x0"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a key or signature, got %d", w.Code)
+	}
+	if w := validate("wrong-key", map[string]interface{}{"answer": "0x0"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 with a bad key, got %d", w.Code)
+	}
 
-	body := []byte(`{"action": "ban", "reason": "confirmed cheating"}`)
-	req, _ := http.NewRequest("POST", "/api/admin/review/"+node.ID, bytes.NewBuffer(body))
-	req.Header.Set("Authorization", "Bearer key")
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	otherKey, _ := crypto.GenerateKey()
+	other, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(otherKey)))
+	if w := validate("", signed(other)); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for another wallet, got %d", w.Code)
+	}
 
-	if w.Code != http.StatusOK {
-		t.Errorf("expected status 200, got %d", w.Code)
+	// Admins can validate as often as they like
+	for i := 0; i < 2; i++ {
+		w := validate("admin-key", map[string]interface{}{"answer": "0x0"})
+		var diff verification.AnswerDiff
+		json.Unmarshal(w.Body.Bytes(), &diff)
+		if w.Code != http.StatusOK || diff.Passed || len(diff.Fields) == 0 {
+			t.Fatalf("expected a failing diff for the admin, got %d: %s", w.Code, w.Body.String())
+		}
 	}
 
-	updated := s.GetNode(node.ID)
-	if updated.CheatStatus != types.StatusBanned {
-		t.Errorf("expected banned status, got %s", updated.CheatStatus)
+	// The operator gets one go
+	if w := validate("", signed(wallet)); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for the operator, got %d: %s", w.Code, w.Body.String())
 	}
-	if updated.IsActive {
-		t.Error("banned node should be deactivated")
+	if w := validate("", signed(wallet)); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a second operator try, got %d", w.Code)
 	}
 }
 
-func TestAdminReviewInvalidAction(t *testing.T) {
-	router, s := setupTestRouter("key")
+func TestPauseAndResumeNode(t *testing.T) {
+	router, s := setupTestRouter("")
 
-	node := s.RegisterNode("0x1", types.BscFull, types.LocalProver, "", "")
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	node := s.RegisterNode(strings.ToLower(wallet.Address()), types.BscFull, types.LocalProver, "", "")
+
+	send := func(action, path string, signer *signing.Signer) *httptest.ResponseRecorder {
+		timestamp := time.Now().UnixMilli()
+		sig, _ := signer.Sign(fmt.Sprintf("%s\nNode: %s\nTimestamp: %d", action, node.ID, timestamp))
+		body, _ := json.Marshal(map[string]interface{}{"signature": sig, "timestamp": timestamp})
+		req, _ := http.NewRequest("POST", "/api/nodes/"+node.ID+path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
 
-	body := []byte(`{"action": "invalid"}`)
-	req, _ := http.NewRequest("POST", "/api/admin/review/"+node.ID, bytes.NewBuffer(body))
-	req.Header.Set("Authorization", "Bearer key")
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	// Someone else's wallet
+	otherKey, _ := crypto.GenerateKey()
+	other, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(otherKey)))
+	if w := send("Pause node", "/pause", other); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for another wallet, got %d", w.Code)
+	}
 
-	if w.Code != http.StatusBadRequest {
-		t.Errorf("expected status 400 for invalid action, got %d", w.Code)
+	if w := send("Pause node", "/pause", wallet); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 on pause, got %d: %s", w.Code, w.Body.String())
 	}
-}
 
-func TestTestCreateNode(t *testing.T) {
+	// No challenges while paused
+	w := requestChallenge(router, node.ID, wallet, time.Now().UnixMilli())
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 when requesting a challenge while paused, got %d", w.Code)
+	}
+
+	if w := send("Pause node", "/pause", wallet); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 when already paused, got %d", w.Code)
+	}
+
+	w = send("Resume node", "/resume", wallet)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 on resume, got %d", w.Code)
+	}
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response["paused"] != false || response["maintenance_used_minutes"].(float64) > 1 {
+		t.Errorf("unexpected resume response: %v", response)
+	}
+}
+
+func TestChangeVerificationMethod(t *testing.T) {
+	trusted := httptest.NewServer(mockchain.New(46000000))
+	defer trusted.Close()
+	nodeServer := httptest.NewServer(mockchain.New(46000000))
+	defer nodeServer.Close()
+
+	s := store.NewStore()
+	router := SetupRouter(s, verification.NewVerifier(trusted.URL))
+
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	node := s.RegisterNode(strings.ToLower(wallet.Address()), types.BscFull, types.ExposedRPC, "http://10.0.0.5:8545", "secret")
+	pointsBefore := node.TotalPoints
+
+	send := func(method types.VerificationMethod, signedEndpoint, endpoint string) *httptest.ResponseRecorder {
+		timestamp := time.Now().UnixMilli()
+		sig, _ := wallet.Sign(fmt.Sprintf("Change verification method\nNode: %s\nMethod: %s\nEndpoint: %s\nTimestamp: %d", node.ID, method, signedEndpoint, timestamp))
+		body, _ := json.Marshal(map[string]interface{}{
+			"verification_method": method,
+			"rpc_endpoint":        endpoint,
+			"auth_token":          "new-secret",
+			"signature":           sig,
+			"timestamp":           timestamp,
+		})
+		req, _ := http.NewRequest("POST", "/api/nodes/"+node.ID+"/verification-method", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send(types.LocalProver, "", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 switching to local-prover, got %d: %s", w.Code, w.Body.String())
+	}
+	updated := s.GetNode(node.ID)
+	if updated.VerificationMethod != types.LocalProver || updated.RPCEndpoint != "" || updated.AuthToken != "" {
+		t.Errorf("expected local-prover with the endpoint cleared, got %+v", updated)
+	}
+	if updated.TotalPoints != pointsBefore {
+		t.Errorf("points should survive the switch, got %d, had %d", updated.TotalPoints, pointsBefore)
+	}
+
+	if w := send(types.LocalProver, "", ""); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 switching to the method already in use, got %d", w.Code)
+	}
+	// The endpoint is part of what's signed
+	if w := send(types.ExposedRPC, nodeServer.URL, "http://attacker:8545"); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for an endpoint that wasn't signed, got %d", w.Code)
+	}
+	if w := send(types.ExposedRPC, "", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for exposed-rpc without an endpoint, got %d", w.Code)
+	}
+
+	down := httptest.NewServer(mockchain.New(1))
+	down.Close()
+	if w := send(types.ExposedRPC, down.URL, down.URL); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for an endpoint that doesn't answer, got %d", w.Code)
+	}
+
+	w := send(types.ExposedRPC, nodeServer.URL, nodeServer.URL)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 switching back, got %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "new-secret") {
+		t.Error("the auth token should not be echoed back")
+	}
+	if updated := s.GetNode(node.ID); updated.RPCEndpoint != nodeServer.URL || updated.AuthToken != "new-secret" {
+		t.Errorf("expected the new endpoint and token, got %s and %q", updated.RPCEndpoint, updated.AuthToken)
+	}
+}
+
+func TestAcceptReclassification(t *testing.T) {
+	router, s := setupTestRouter("")
+
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	node := s.RegisterNode(strings.ToLower(wallet.Address()), types.BscFast, types.ExposedRPC, "http://node", "")
+
+	accept := func(nodeType types.NodeType) *httptest.ResponseRecorder {
+		timestamp := time.Now().UnixMilli()
+		sig, _ := wallet.Sign(fmt.Sprintf("Accept reclassification\nNode: %s\nType: %s\nTimestamp: %d", node.ID, nodeType, timestamp))
+		body, _ := json.Marshal(map[string]interface{}{"node_type": nodeType, "signature": sig, "timestamp": timestamp})
+		req, _ := http.NewRequest("POST", "/api/nodes/"+node.ID+"/reclassification/accept", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := accept(types.BscFull); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 with nothing proposed, got %d", w.Code)
+	}
+
+	s.ProposeReclassification(node.ID, types.BscFull, "database is 1500GB", time.Now().UnixMilli())
+	if w := accept(types.BscArchive); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 accepting a type that wasn't proposed, got %d", w.Code)
+	}
+	if w := accept(types.BscFull); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if s.GetNode(node.ID).NodeType != types.BscFull {
+		t.Error("accepting should change the node type right away")
+	}
+	if r := s.GetReclassification(node.ID); r.ResolvedBy != "operator" {
+		t.Errorf("expected the operator to have resolved it, got %+v", r)
+	}
+}
+
+func TestWalletTOTP(t *testing.T) {
+	router, s := setupTestRouter("")
+
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	node := s.RegisterNode(strings.ToLower(wallet.Address()), types.BscFast, types.ExposedRPC, "http://node", "")
+	s.ProposeReclassification(node.ID, types.BscFull, "database is 1500GB", time.Now().UnixMilli())
+
+	post := func(path string, body map[string]interface{}) *httptest.ResponseRecorder {
+		encoded, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", path, bytes.NewReader(encoded))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	signed := func(action, code string) map[string]interface{} {
+		timestamp := time.Now().UnixMilli()
+		sig, _ := wallet.Sign(fmt.Sprintf("%s\nWallet: %s\nTimestamp: %d", action, wallet.Address(), timestamp))
+		return map[string]interface{}{"wallet_address": wallet.Address(), "code": code, "signature": sig, "timestamp": timestamp}
+	}
+	accept := func(code string) *httptest.ResponseRecorder {
+		timestamp := time.Now().UnixMilli()
+		sig, _ := wallet.Sign(fmt.Sprintf("Accept reclassification\nNode: %s\nType: %s\nTimestamp: %d", node.ID, types.BscFull, timestamp))
+		return post("/api/nodes/"+node.ID+"/reclassification/accept", map[string]interface{}{"node_type": types.BscFull, "totp_code": code, "signature": sig, "timestamp": timestamp})
+	}
+
+	w := post("/api/wallets/totp", signed("Enable two-factor", ""))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var enrolled struct {
+		Secret string `json:"secret"`
+		URI    string `json:"uri"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &enrolled)
+	if !strings.HasPrefix(enrolled.URI, "otpauth://totp/") || !strings.Contains(enrolled.URI, enrolled.Secret) {
+		t.Errorf("expected an otpauth URI with the secret, got %q", enrolled.URI)
+	}
+	if w := post("/api/wallets/totp/confirm", signed("Enable two-factor", "")); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 with the enroll signature reused to confirm, got %d", w.Code)
+	}
+
+	step := totp.StepAt(time.Now())
+	code, _ := totp.Code(enrolled.Secret, step)
+	if w := post("/api/wallets/totp/confirm", signed("Confirm two-factor", code)); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 confirming, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The wallet's signature alone isn't enough any more
+	w = accept("")
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), `"totp_required":true`) {
+		t.Errorf("expected 403 asking for a code, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := accept(code); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a code already used, got %d", w.Code)
+	}
+	next, _ := totp.Code(enrolled.Secret, step+1)
+	if w := accept(next); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 with a fresh code, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Guessing locks the wallet out, right code or not
+	wrong, _ := totp.Code(enrolled.Secret, step+100)
+	for i := 0; i < 5; i++ {
+		accept(wrong)
+	}
+	later, _ := totp.Code(enrolled.Secret, step+2)
+	if w := accept(later); w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 once locked out, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// An admin can take it off for someone who lost their authenticator
+	if w := post("/api/admin/wallets/"+wallet.Address()+"/totp/reset", map[string]interface{}{"reason": "lost phone"}); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 resetting, got %d: %s", w.Code, w.Body.String())
+	}
+	if enabled, _ := s.TOTPStatus(strings.ToLower(wallet.Address())); enabled {
+		t.Error("expected two-factor off after the reset")
+	}
+	if entries := s.ModerationLog().Since(0); len(entries) != 1 || entries[0].Action != modlog.ActionResetTOTP {
+		t.Errorf("expected the reset logged, got %+v", entries)
+	}
+}
+
+func TestRewardEpochExport(t *testing.T) {
+	router, s := setupTestRouter("")
+	wallet := "0x00000000000000000000000000000000000000a1"
+	s.RegisterNode(wallet, types.BscFull, types.LocalProver, "", "")
+	s.RegisterNode("0x00000000000000000000000000000000000000b2", types.BscFast, types.LocalProver, "", "")
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do("POST", "/api/admin/epochs"); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do("POST", "/api/admin/epochs"); w.Code == http.StatusCreated {
+		t.Error("expected a second epoch with nothing new refused")
+	}
+	if entries := s.ModerationLog().Since(0); len(entries) != 1 || entries[0].Action != modlog.ActionCloseEpoch {
+		t.Errorf("expected closing the epoch logged, got %+v", entries)
+	}
+
+	w := do("GET", "/api/admin/epochs/1/export")
+	var epoch types.RewardEpoch
+	json.Unmarshal(w.Body.Bytes(), &epoch)
+	if w.Code != http.StatusOK || len(epoch.Claims) != 2 {
+		t.Fatalf("expected both wallets in the export, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do("GET", "/api/admin/epochs/1/export?format=csv"); !strings.HasPrefix(w.Body.String(), "address,amount,epoch,proof\n") || strings.Count(w.Body.String(), "\n") != 3 {
+		t.Errorf("expected a header and a row per claim, got %q", w.Body.String())
+	}
+	if w := do("GET", "/api/admin/epochs/2/export"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an epoch not closed yet, got %d", w.Code)
+	}
+
+	// What the wallet fetches checks out against the exported root
+	w = do("GET", "/api/wallet/0x"+strings.ToUpper(wallet[2:])+"/claims")
+	var claims struct {
+		Claims []types.RewardClaim `json:"claims"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &claims)
+	if len(claims.Claims) != 1 {
+		t.Fatalf("expected one claim for the wallet, got %s", w.Body.String())
+	}
+	claim := claims.Claims[0]
+	if !rewards.Verify(epoch.MerkleRoot, claim.Proof, claim.WalletAddress, claim.Amount, claim.Epoch) {
+		t.Errorf("expected the wallet's proof to verify against the epoch root, got %+v", claim)
+	}
+}
+
+func TestRegisterWithAttestation(t *testing.T) {
+	router, s := setupTestRouter("")
+
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+
+	register := func(report types.HardwareReport) *httptest.ResponseRecorder {
+		timestamp := time.Now().UnixMilli()
+		sig, _ := wallet.Sign(fmt.Sprintf("Register node\nWallet: %s\nType: %s\nTimestamp: %d", wallet.Address(), types.BscArchive, timestamp))
+		attSig, _ := wallet.Sign(attestation.Message(wallet.Address(), types.BscArchive, timestamp, report))
+		body, _ := json.Marshal(map[string]interface{}{
+			"wallet_address":      wallet.Address(),
+			"node_type":           types.BscArchive,
+			"verification_method": types.LocalProver,
+			"signature":           sig,
+			"timestamp":           timestamp,
+			"attestation":         types.HardwareAttestation{Report: report, Signature: attSig},
+		})
+		req, _ := http.NewRequest("POST", "/api/nodes/register", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// An archive node on a 200GB disk can't be real
+	if w := register(types.HardwareReport{CPUCount: 16, DiskClass: "under-500gb", OS: "linux/amd64"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for impossible hardware, got %d", w.Code)
+	}
+
+	w := register(types.HardwareReport{CPUCount: 16, DiskClass: "8tb-plus", OS: "linux/amd64"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response RegisterResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if node := s.GetNode(response.NodeID); node == nil || node.Hardware == nil || node.Hardware.DiskClass != "8tb-plus" {
+		t.Errorf("expected hardware report stored on the node, got %+v", node)
+	}
+}
+
+func TestRegisterBannedWallet(t *testing.T) {
+	router, s := setupTestRouter("")
+
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+
+	register := func() *httptest.ResponseRecorder {
+		timestamp := time.Now().UnixMilli()
+		sig, _ := wallet.Sign(fmt.Sprintf("Register node\nWallet: %s\nType: %s\nTimestamp: %d", wallet.Address(), types.BscFull, timestamp))
+		body, _ := json.Marshal(map[string]interface{}{
+			"wallet_address":      wallet.Address(),
+			"node_type":           types.BscFull,
+			"verification_method": types.LocalProver,
+			"signature":           sig,
+			"timestamp":           timestamp,
+		})
+		req, _ := http.NewRequest("POST", "/api/nodes/register", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := register()
+	var response RegisterResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	s.SetNodeCheatStatus(response.NodeID, types.StatusBanned, "proxying")
+
+	if w := register(); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a banned wallet, got %d: %s", w.Code, w.Body.String())
+	}
+
+	s.LiftWalletBan(strings.ToLower(wallet.Address()), time.Now().UnixMilli())
+	if w := register(); w.Code != http.StatusOK {
+		t.Errorf("expected 200 once the ban is lifted, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRegisterIdempotent(t *testing.T) {
+	router, s := setupTestRouter("")
+
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	timestamp := time.Now().UnixMilli()
+	sig, _ := wallet.Sign(fmt.Sprintf("Register node\nWallet: %s\nType: %s\nTimestamp: %d", wallet.Address(), types.BscFull, timestamp))
+	body, _ := json.Marshal(map[string]interface{}{
+		"wallet_address":      wallet.Address(),
+		"node_type":           types.BscFull,
+		"verification_method": types.LocalProver,
+		"signature":           sig,
+		"timestamp":           timestamp,
+	})
+
+	var nodeIDs []string
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("POST", "/api/nodes/register", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(IdempotencyHeader, "register-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response RegisterResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		nodeIDs = append(nodeIDs, response.NodeID)
+	}
+
+	if nodeIDs[0] != nodeIDs[1] || len(s.GetNodesByWallet(strings.ToLower(wallet.Address()))) != 1 {
+		t.Errorf("a retried registration should not create a second node, got %v", nodeIDs)
+	}
+}
+
+func TestRegisterChecksEndpoint(t *testing.T) {
+	trusted := httptest.NewServer(mockchain.New(46000000))
+	defer trusted.Close()
+	router := SetupRouter(store.NewStore(), verification.NewVerifier(trusted.URL))
+
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	register := func(endpoint string) (*httptest.ResponseRecorder, *verification.EndpointCheck) {
+		timestamp := time.Now().UnixMilli()
+		sig, _ := wallet.Sign(fmt.Sprintf("Register node\nWallet: %s\nType: %s\nTimestamp: %d", wallet.Address(), types.BscFull, timestamp))
+		body, _ := json.Marshal(map[string]interface{}{
+			"wallet_address":      wallet.Address(),
+			"node_type":           types.BscFull,
+			"verification_method": types.ExposedRPC,
+			"rpc_endpoint":        endpoint,
+			"signature":           sig,
+			"timestamp":           timestamp,
+		})
+		req, _ := http.NewRequest("POST", "/api/nodes/register", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			EndpointCheck *verification.EndpointCheck `json:"endpoint_check"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response.EndpointCheck
+	}
+
+	down := httptest.NewServer(mockchain.New(1))
+	down.Close()
+	if w, check := register(down.URL); w.Code != http.StatusUnprocessableEntity || check == nil || check.Reachable {
+		t.Errorf("expected 422 for an endpoint that doesn't answer, got %d: %s", w.Code, w.Body.String())
+	}
+
+	testnetChain := mockchain.New(46000000)
+	testnetChain.SetChainID(97)
+	testnet := httptest.NewServer(testnetChain)
+	defer testnet.Close()
+	if w, _ := register(testnet.URL); w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "is on chain 97") {
+		t.Errorf("expected 422 for a testnet node on mainnet, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Syncing nodes are let in, with a warning
+	syncingChain := mockchain.New(45000000)
+	syncingChain.SetSyncing(true)
+	syncing := httptest.NewServer(syncingChain)
+	defer syncing.Close()
+	w, check := register(syncing.URL)
+	if w.Code != http.StatusOK || check == nil || check.Synced || len(check.Warnings) != 2 {
+		t.Errorf("expected 200 with syncing and lag warnings, got %d: %s", w.Code, w.Body.String())
+	}
+
+	healthy := httptest.NewServer(mockchain.New(46000000))
+	defer healthy.Close()
+	w, check = register(healthy.URL)
+	if w.Code != http.StatusOK || check == nil || !check.Synced || check.ChainID != 56 || check.Head != 46000000 || len(check.Warnings) != 0 {
+		t.Errorf("expected a clean check for a healthy node, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRegisterStorageProviderNeedsExposedRPC(t *testing.T) {
+	router, _ := setupTestRouter("")
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"wallet_address":      "0x1234567890123456789012345678901234567890",
+		"node_type":           types.GreenfieldSP,
+		"verification_method": types.LocalProver,
+		"signature":           "0x00",
+		"timestamp":           time.Now().UnixMilli(),
+	})
+	req, _ := http.NewRequest("POST", "/api/nodes/register", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "exposed-rpc") {
+		t.Errorf("expected 400 asking for exposed-rpc, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRegisterErrorLocalized(t *testing.T) {
+	router, _ := setupTestRouter("")
+
+	register := func(acceptLanguage string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{
+			"wallet_address":      "0x1234567890123456789012345678901234567890",
+			"node_type":           types.BscFull,
+			"verification_method": types.LocalProver,
+			"signature":           "0x00",
+			"timestamp":           time.Now().Add(-time.Hour).UnixMilli(),
+		})
+		req, _ := http.NewRequest("POST", "/api/nodes/register", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguage)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := register("vi-VN,vi;q=0.9,en;q=0.8")
+	var resp map[string]string
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp["error"] != "timestamp quá cũ" || w.Header().Get("Content-Language") != "vi" {
+		t.Errorf("expected a Vietnamese error, got %q (Content-Language %q)", resp["error"], w.Header().Get("Content-Language"))
+	}
+
+	w = register("fr-FR")
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp["error"] != "timestamp too old" || w.Header().Get("Content-Language") != "" {
+		t.Errorf("expected English for an unsupported language, got %q (Content-Language %q)", resp["error"], w.Header().Get("Content-Language"))
+	}
+}
+
+// Every error message the handlers pass through tr has a translation, so
+// none of them reach operators in English by mistake
+func TestErrorsTranslated(t *testing.T) {
+	verb := regexp.MustCompile(`%[dqsv]`)
+	for _, file := range []string{"handlers.go", "middleware.go", "idempotency.go"} {
+		parsed, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(parsed, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) != 2 {
+				return true
+			}
+			if fn, ok := call.Fun.(*ast.Ident); !ok || fn.Name != "tr" {
+				return true
+			}
+			message := call.Args[1]
+			if sprintf, ok := message.(*ast.CallExpr); ok && len(sprintf.Args) > 0 {
+				message = sprintf.Args[0]
+			}
+			literal, ok := message.(*ast.BasicLit)
+			if !ok {
+				return true // An error's text, or built some other way
+			}
+			english, _ := strconv.Unquote(literal.Value)
+			english = verb.ReplaceAllString(english, "7")
+			for _, lang := range []string{"zh", "vi", "ru"} {
+				if i18n.Translate(lang, english) == english {
+					t.Errorf("%s: no %s translation for %q", file, lang, english)
+				}
+			}
+			return true
+		})
+	}
+}
+
+func TestFileReport(t *testing.T) {
+	router, s := setupTestRouter("")
+	node := s.RegisterNode("0xoperator", types.BscFull, types.LocalProver, "", "")
+
+	key, _ := crypto.GenerateKey()
+	reporter, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+
+	send := func(nodeID, evidence, signedEvidence string) *httptest.ResponseRecorder {
+		timestamp := time.Now().UnixMilli()
+		sig, _ := reporter.Sign(fmt.Sprintf("Report node\nNode: %s\nTimestamp: %d\nEvidence: %s", nodeID, timestamp, signedEvidence))
+		body, _ := json.Marshal(map[string]interface{}{
+			"reporter_wallet": reporter.Address(),
+			"node_id":         nodeID,
+			"evidence":        evidence,
+			"signature":       sig,
+			"timestamp":       timestamp,
+		})
+		req, _ := http.NewRequest("POST", "/api/reports", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Evidence swapped after signing
+	if w := send(node.ID, "something else", "always 400ms"); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for tampered evidence, got %d", w.Code)
+	}
+	if w := send("missing", "always 400ms", "always 400ms"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown node, got %d", w.Code)
+	}
+
+	if w := send(node.ID, "always 400ms", "always 400ms"); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send(node.ID, "always 400ms", "always 400ms"); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for duplicate report, got %d", w.Code)
+	}
+
+	// Shows up in the admin queue with the reporter's score
+	req, _ := http.NewRequest("GET", "/api/admin/flagged", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response struct {
+		Nodes []FlaggedNode `json:"nodes"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if len(response.Nodes) != 1 || len(response.Nodes[0].Reports) != 1 {
+		t.Fatalf("expected reported node in admin queue, got %s", w.Body.String())
+	}
+	if r := response.Nodes[0].Reports[0]; r.ReporterWallet != strings.ToLower(reporter.Address()) || r.ReporterScore != 0.5 {
+		t.Errorf("unexpected report in queue: %+v", r)
+	}
+}
+
+func TestCheckStorageFlagsFakeArchive(t *testing.T) {
+	chain := mockchain.New(46000000)
+	server := httptest.NewServer(chain)
+	defer server.Close()
+
+	s := store.NewStore()
+	router := SetupRouter(s, verification.NewVerifier(server.URL), "")
+
+	// Claims archive, but its database is nowhere near archive size
+	node := s.RegisterNode("0x1", types.BscArchive, types.ExposedRPC, server.URL, "")
+	chain.SetDatabaseSize(500e9)
+
+	req, _ := http.NewRequest("POST", "/api/verify/"+node.ID+"/storage", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var report types.StorageReport
+	json.Unmarshal(w.Body.Bytes(), &report)
+	if report.Verdict != types.StorageTooSmall {
+		t.Errorf("expected too-small verdict, got %s", report.Verdict)
+	}
+
+	node = s.GetNode(node.ID)
+	if node.WarningCount != 1 || node.Storage == nil {
+		t.Errorf("expected a suspicious event and a stored report, got warnings=%d storage=%v", node.WarningCount, node.Storage)
+	}
+}
+
+func TestGetLeaderboard(t *testing.T) {
+	router, s := setupTestRouter("")
+
+	// Create nodes with different points
+	s.RegisterNode("0x1", types.BscArchive, types.LocalProver, "", "") // 100 pts
+	s.RegisterNode("0x2", types.BscFull, types.LocalProver, "", "")    // 50 pts
+	s.RegisterNode("0x3", types.OpbnbFast, types.LocalProver, "", "")  // 30 pts
+
+	req, _ := http.NewRequest("GET", "/api/leaderboard", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+
+	var entries []map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &entries)
+
+	if len(entries) != 3 {
+		t.Errorf("expected 3 entries, got %d", len(entries))
+	}
+
+	// Check ranking order (highest points first)
+	if entries[0]["total_points"].(float64) != 100 {
+		t.Error("first entry should have 100 points (BSC Archive)")
+	}
+	if entries[1]["total_points"].(float64) != 50 {
+		t.Error("second entry should have 50 points (BSC Full)")
+	}
+	if entries[2]["total_points"].(float64) != 30 {
+		t.Error("third entry should have 30 points (opBNB Fast)")
+	}
+
+	// Check ranks
+	if entries[0]["rank"].(float64) != 1 {
+		t.Error("first entry should have rank 1")
+	}
+}
+
+func TestGetLeaderboardExcludesBanned(t *testing.T) {
+	router, s := setupTestRouter("")
+
+	node1 := s.RegisterNode("0x1", types.BscArchive, types.LocalProver, "", "")
+	s.RegisterNode("0x2", types.BscFull, types.LocalProver, "", "")
+
+	// Ban first node
+	s.SetNodeCheatStatus(node1.ID, types.StatusBanned, "cheating")
+
+	req, _ := http.NewRequest("GET", "/api/leaderboard", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var entries []map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &entries)
+
+	if len(entries) != 1 {
+		t.Errorf("expected 1 entry (banned excluded), got %d", len(entries))
+	}
+}
+
+func TestGetLeaderboardEligibility(t *testing.T) {
+	router, s := setupTestRouter("")
+
+	veteran := s.RegisterNode("0x1", types.BscArchive, types.LocalProver, "", "")
+	warned := s.RegisterNode("0x2", types.BscFull, types.LocalProver, "", "")
+	s.RegisterNode("0x3", types.BscFull, types.LocalProver, "", "") // No uptime yet
+	for _, node := range []*types.NodeRegistration{veteran, warned} {
+		s.AwardUptimePoints(node.ID, 48*60)
+		s.RecordVerificationResult(&types.VerificationResult{NodeID: node.ID, Passed: true, Timestamp: time.Now().UnixMilli()})
+	}
+	s.SetNodeCheatStatus(warned.ID, types.StatusWarning, "slow answers")
+	s.SetLeaderboardRules(types.LeaderboardRules{MinUptimeHours: 24, MinPassRate: 50, CleanOnly: true})
+
+	req, _ := http.NewRequest("GET", "/api/leaderboard", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var entries []map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &entries)
+	if len(entries) != 1 || entries[0]["node_id"] != veteran.ID {
+		t.Errorf("expected only the clean node with uptime on the leaderboard, got %v", entries)
+	}
+
+	// The rules are published with the transparency report
+	req, _ = http.NewRequest("GET", "/api/transparency", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var report struct {
+		Rules types.LeaderboardRules `json:"leaderboard_rules"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &report)
+	if report.Rules.MinUptimeHours != 24 || report.Rules.MinPassRate != 50 || !report.Rules.CleanOnly {
+		t.Errorf("unexpected published rules: %+v", report.Rules)
+	}
+}
+
+func TestTestnetLeaderboard(t *testing.T) {
+	router, s := setupTestRouter("")
+
+	s.RegisterNode("0x1", types.BscArchive, types.LocalProver, "", "")
+	s.SetNetwork(types.Testnet)
+	s.RegisterNode("0x2", types.BscFull, types.LocalProver, "", "")
+
+	req, _ := http.NewRequest("GET", "/api/leaderboard", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var entries []map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &entries)
+	if len(entries) != 1 || entries[0]["wallet_address"] != "0x2" {
+		t.Errorf("expected only the testnet node ranked, got %v", entries)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/network", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var network NetworkResponse
+	json.Unmarshal(w.Body.Bytes(), &network)
+	if network.Network != types.Testnet || network.ChainIDs[types.ChainBSC] != 97 || network.PointsMultiplier != 10 {
+		t.Errorf("unexpected network response: %+v", network)
+	}
+}
+
+func TestGetNodeTypes(t *testing.T) {
+	router, _ := setupTestRouter("")
+
+	req, _ := http.NewRequest("GET", "/api/node-types", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var response struct {
+		NodeTypes        []NodeTypeInfo `json:"node_types"`
+		PointsMultiplier uint64         `json:"points_multiplier"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if len(response.NodeTypes) != len(types.NodeTypes) || response.PointsMultiplier != 1 {
+		t.Fatalf("unexpected response: %+v", response)
+	}
+
+	archive := response.NodeTypes[0]
+	if archive.NodeType != types.BscArchive || archive.RegistrationBonus != 100 || archive.PointsPerHour != 10 ||
+		archive.ChallengeFrequencyMinutes != 30 || len(archive.ChallengeTypes) != 4 {
+		t.Errorf("unexpected bsc-archive entry: %+v", archive)
+	}
+}
+
+func TestGetNetworkStats(t *testing.T) {
+	router, s := setupTestRouter("")
+
+	s.RegisterNode("0x1", types.BscFull, types.LocalProver, "", "")
+	s.RegisterNode("0x2", types.BscArchive, types.ExposedRPC, "http://test", "")
+	s.RegisterNode("0x3", types.OpbnbFull, types.LocalProver, "", "")
+
+	req, _ := http.NewRequest("GET", "/api/stats", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+
+	var stats map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &stats)
+
+	if stats["total_nodes"].(float64) != 3 {
+		t.Errorf("expected 3 total nodes, got %v", stats["total_nodes"])
+	}
+
+	byType := stats["by_type"].(map[string]interface{})
+	if byType["bsc-full"].(float64) != 1 {
+		t.Error("expected 1 bsc-full node")
+	}
+
+	byMethod := stats["by_method"].(map[string]interface{})
+	if byMethod["local-prover"].(float64) != 2 {
+		t.Error("expected 2 local-prover nodes")
+	}
+
+	if _, ok := stats["failures_by_kind"]; !ok {
+		t.Error("expected failures_by_kind in network stats")
+	}
+}
+
+func TestGetTransparency(t *testing.T) {
+	router, s := setupTestRouter("")
+
+	node := s.RegisterNode("0x1", types.BscFull, types.LocalProver, "", "")
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: node.ID, Passed: true})
+
+	req, _ := http.NewRequest("GET", "/api/transparency", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var report map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &report)
+
+	for _, key := range []string{"challenges_issued", "pass_rate_by_node_type", "nodes_by_cheat_status"} {
+		if _, ok := report[key]; !ok {
+			t.Errorf("missing %s in report", key)
+		}
+	}
+
+	rates := report["pass_rate_by_node_type"].(map[string]interface{})
+	full := rates["bsc-full"].(map[string]interface{})
+	if full["passed"].(float64) != 1 {
+		t.Errorf("expected 1 passed bsc-full challenge, got %v", full["passed"])
+	}
+}
+
+func TestGetWalletStats(t *testing.T) {
+	router, s := setupTestRouter("")
+
+	wallet := "0xmywallet"
+	s.RegisterNode(wallet, types.BscArchive, types.LocalProver, "", "") // 100 pts
+	s.RegisterNode(wallet, types.BscFull, types.LocalProver, "", "")    // 50 pts
+
+	req, _ := http.NewRequest("GET", "/api/wallet/"+wallet+"/stats", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+
+	var stats types.WalletStats
+	json.Unmarshal(w.Body.Bytes(), &stats)
+
+	if stats.TotalPoints != 150 {
+		t.Errorf("expected 150 total points, got %d", stats.TotalPoints)
+	}
+
+	if stats.TotalNodes != 2 {
+		t.Errorf("expected 2 nodes, got %d", stats.TotalNodes)
+	}
+}
+
+func TestSignedWalletStats(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+
+	s := store.NewStore()
+	v := verification.NewVerifier("https://bsc-dataseed1.binance.org")
+	v.Keys().Rotate(signer)
+	router := SetupRouter(s, v, "")
+
+	s.RegisterNode("0xabc", types.BscArchive, types.LocalProver, "", "")
+
+	req, _ := http.NewRequest("GET", "/api/wallet/0xabc/stats?signed=true", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var signed SignedResponse
+	json.Unmarshal(w.Body.Bytes(), &signed)
+
+	if signed.Signer != signer.Address() {
+		t.Errorf("expected signer %s, got %s", signer.Address(), signed.Signer)
+	}
+	if !signing.Verify(signing.ResponseMessage(signed.Payload, signed.Timestamp), signed.Signature, signed.Signer) {
+		t.Error("signature should verify over payload and timestamp")
+	}
+
+	var stats types.WalletStats
+	json.Unmarshal([]byte(signed.Payload), &stats)
+	if stats.TotalPoints != 100 {
+		t.Errorf("expected 100 points in signed payload, got %d", stats.TotalPoints)
+	}
+}
+
+func TestServerKeysAfterRotation(t *testing.T) {
+	s := store.NewStore()
+	v := verification.NewVerifier("https://bsc-dataseed1.binance.org")
+	router := SetupRouter(s, v, "")
+
+	var signers []*signing.Signer
+	for i := 0; i < 2; i++ {
+		key, _ := crypto.GenerateKey()
+		signer, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+		v.Keys().Rotate(signer)
+		signers = append(signers, signer)
+	}
+
+	req, _ := http.NewRequest("GET", "/api/server-keys", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response struct {
+		Keys []signing.JWK `json:"keys"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if len(response.Keys) != 2 {
+		t.Fatalf("expected 2 published keys, got %d", len(response.Keys))
+	}
+	if response.Keys[0].Kid != signers[0].Address() || response.Keys[0].Status != "retired" {
+		t.Errorf("expected first key retired, got %+v", response.Keys[0])
+	}
+	if response.Keys[1].Kid != signers[1].Address() || response.Keys[1].Status != "active" {
+		t.Errorf("expected second key active, got %+v", response.Keys[1])
+	}
+}
+
+func TestSignedStatsWithoutKey(t *testing.T) {
+	router, s := setupTestRouter("")
+	s.RegisterNode("0xabc", types.BscArchive, types.LocalProver, "", "")
+
+	req, _ := http.NewRequest("GET", "/api/wallet/0xabc/stats?signed=true", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 when signing is off, got %d", w.Code)
+	}
+}
+
+func TestGetWalletStatsNotFound(t *testing.T) {
+	router, _ := setupTestRouter("")
+
+	req, _ := http.NewRequest("GET", "/api/wallet/0xnonexistent/stats", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+func TestGetBulkWalletStats(t *testing.T) {
+	router, s := setupTestRouter("")
+
+	s.RegisterNode("0xaaa", types.BscFull, types.LocalProver, "", "")
+	s.RegisterNode("0xbbb", types.BscArchive, types.LocalProver, "", "")
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"addresses": []string{"0xBBB", "0xaaa", "0xaaa", "0xccc"},
+	})
+	req, _ := http.NewRequest("POST", "/api/wallets/stats", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Wallets  []types.WalletStats `json:"wallets"`
+		NotFound []string            `json:"not_found"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if len(response.Wallets) != 2 {
+		t.Fatalf("expected 2 wallets, got %d", len(response.Wallets))
+	}
+	if response.Wallets[0].WalletAddress != "0xbbb" || response.Wallets[1].WalletAddress != "0xaaa" {
+		t.Errorf("wallets should come back in request order, got %s, %s",
+			response.Wallets[0].WalletAddress, response.Wallets[1].WalletAddress)
+	}
+	if len(response.NotFound) != 1 || response.NotFound[0] != "0xccc" {
+		t.Errorf("expected 0xccc not found, got %v", response.NotFound)
+	}
+}
+
+func TestGetBulkWalletStatsLimits(t *testing.T) {
+	router, _ := setupTestRouter("")
+
+	tooMany := make([]string, 101)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("0x%d", i)
+	}
+
+	tests := []struct {
+		name      string
+		addresses []string
+	}{
+		{"empty", []string{}},
+		{"too many", tooMany},
+	}
+
+	for _, tt := range tests {
+		body, _ := json.Marshal(map[string]interface{}{"addresses": tt.addresses})
+		req, _ := http.NewRequest("POST", "/api/wallets/stats", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", tt.name, w.Code)
+		}
+	}
+}
+
+func TestGetPointsProjection(t *testing.T) {
+	router, s := setupTestRouter("")
+	node := s.RegisterNode("0xmywallet", types.BscFull, types.LocalProver, "", "")
+
+	req, _ := http.NewRequest("GET", "/api/nodes/"+node.ID+"/projection", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var projection types.PointsProjection
+	json.Unmarshal(w.Body.Bytes(), &projection)
+	if projection.Daily == 0 || projection.Inputs.IntervalsPerDay != 288 || projection.Inputs.NodeType != types.BscFull {
+		t.Errorf("unexpected projection: %+v", projection)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/nodes/missing/projection", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestGetNodesByWallet(t *testing.T) {
+	router, s := setupTestRouter("")
+
+	wallet := "0xmywallet"
+	s.RegisterNode(wallet, types.BscFull, types.LocalProver, "", "")
+	s.RegisterNode(wallet, types.BscArchive, types.LocalProver, "", "")
+
+	req, _ := http.NewRequest("GET", "/api/nodes/wallet/"+wallet, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+
+	var nodes []types.NodeRegistration
+	json.Unmarshal(w.Body.Bytes(), &nodes)
+
+	if len(nodes) != 2 {
+		t.Errorf("expected 2 nodes, got %d", len(nodes))
+	}
+
+	// Auth token should not be exposed
+	for _, node := range nodes {
+		if node.AuthToken != "" {
+			t.Error("auth token should be hidden")
+		}
+	}
+}
+
+func TestLivenessInResponses(t *testing.T) {
+	router, s := setupTestRouter("")
+
+	wallet := "0xmywallet"
+	up := s.RegisterNode(wallet, types.BscFull, types.LocalProver, "", "")
+	down := s.RegisterNode(wallet, types.BscArchive, types.LocalProver, "", "")
+	s.RecordHeartbeat(&types.HeartbeatRecord{NodeID: up.ID, Timestamp: time.Now().UnixMilli(), IsSynced: true})
+
+	get := func(path string, out interface{}) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", path, w.Code)
+		}
+		json.Unmarshal(w.Body.Bytes(), out)
+	}
+
+	var node map[string]interface{}
+	get("/api/nodes/"+up.ID, &node)
+	if node["liveness"] != "online" || node["last_heartbeat_at"].(float64) == 0 {
+		t.Errorf("node should be online with a heartbeat time, got %v / %v", node["liveness"], node["last_heartbeat_at"])
+	}
+	if _, ok := node["auth_token"]; ok {
+		t.Error("auth token should be hidden")
+	}
+
+	var nodes []map[string]interface{}
+	get("/api/nodes/wallet/"+wallet, &nodes)
+	byID := map[string]interface{}{}
+	for _, n := range nodes {
+		byID[n["id"].(string)] = n["liveness"]
+	}
+	if byID[up.ID] != "online" || byID[down.ID] != "offline" {
+		t.Errorf("unexpected liveness by node: %v", byID)
+	}
+
+	var stats types.WalletStats
+	get("/api/wallet/"+wallet+"/stats", &stats)
+	if len(stats.Nodes) != 2 {
+		t.Fatalf("expected liveness for 2 nodes, got %+v", stats.Nodes)
+	}
+	for _, n := range stats.Nodes {
+		want := types.Offline
+		if n.NodeID == up.ID {
+			want = types.Online
+		}
+		if n.Liveness != want {
+			t.Errorf("%s: expected %s, got %s", n.NodeID, want, n.Liveness)
+		}
+	}
+
+	var entries []map[string]interface{}
+	get("/api/leaderboard", &entries)
+	for _, e := range entries {
+		if e["node_id"] == up.ID && e["liveness"] != "online" {
+			t.Errorf("leaderboard should show the node online, got %v", e["liveness"])
+		}
+		if _, ok := e["last_verified_at"]; !ok {
+			t.Error("leaderboard entries should carry last_verified_at")
+		}
+	}
+}
+
+// Admin endpoint tests
+func TestAdminEndpointRequiresAuth(t *testing.T) {
+	router, _ := setupTestRouter("secretkey")
+
+	req, _ := http.NewRequest("GET", "/api/admin/flagged", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 without auth, got %d", w.Code)
+	}
+}
+
+func TestAdminEndpointWithWrongKey(t *testing.T) {
+	router, _ := setupTestRouter("secretkey")
+
+	req, _ := http.NewRequest("GET", "/api/admin/flagged", nil)
+	req.Header.Set("Authorization", "Bearer wrongkey")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 with wrong key, got %d", w.Code)
+	}
+}
+
+func TestAdminEndpointWithCorrectKey(t *testing.T) {
+	router, _ := setupTestRouter("secretkey")
+
+	req, _ := http.NewRequest("GET", "/api/admin/flagged", nil)
+	req.Header.Set("Authorization", "Bearer secretkey")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200 with correct key, got %d", w.Code)
+	}
+}
+
+func TestAdminGetFlaggedNodes(t *testing.T) {
+	router, s := setupTestRouter("key")
+
+	s.RegisterNode("0x1", types.BscFull, types.LocalProver, "", "")
+	node2 := s.RegisterNode("0x2", types.BscFull, types.LocalProver, "", "")
+	s.SetNodeCheatStatus(node2.ID, types.StatusWarning, "suspicious")
+
+	req, _ := http.NewRequest("GET", "/api/admin/flagged", nil)
+	req.Header.Set("Authorization", "Bearer key")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if response["count"].(float64) != 1 {
+		t.Errorf("expected 1 flagged node, got %v", response["count"])
+	}
+
+	nodes := response["nodes"].([]interface{})
+	flagged := nodes[0].(map[string]interface{})
+	if flagged["id"] != node2.ID {
+		t.Errorf("expected node fields at the top level, got id %v", flagged["id"])
+	}
+	if _, ok := flagged["latency_24h"]; !ok {
+		t.Error("expected latency_24h on flagged nodes")
+	}
+}
+
+func TestAdminVerificationReplay(t *testing.T) {
+	router, s := setupTestRouter("key")
+
+	node := s.RegisterNode("0x1", types.BscFull, types.LocalProver, "", "")
+	s.RecordVerificationResult(&types.VerificationResult{
+		ChallengeID:   "c1",
+		NodeID:        node.ID,
+		FailureReason: "incorrect answer",
+		FailureKind:   types.FailureWrongAnswer,
+		Replay: &types.ChallengeReplay{
+			Challenge:       types.Challenge{ID: "c1", NodeID: node.ID},
+			ExpectedAnswer:  "0xa",
+			SubmittedAnswer: "0xb",
+		},
+	})
+
+	req, _ := http.NewRequest("GET", "/api/admin/verifications/c1", nil)
+	req.Header.Set("Authorization", "Bearer key")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var replay types.ChallengeReplay
+	json.Unmarshal(w.Body.Bytes(), &replay)
+	if replay.ExpectedAnswer != "0xa" || replay.SubmittedAnswer != "0xb" {
+		t.Errorf("unexpected replay: %+v", replay)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/admin/verifications/unknown", nil)
+	req.Header.Set("Authorization", "Bearer key")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+func TestAdminReviewNode(t *testing.T) {
+	router, s := setupTestRouter("key")
+
+	node := s.RegisterNode("0x1", types.BscFull, types.LocalProver, "", "")
+	s.SetNodeCheatStatus(node.ID, types.StatusWarning, "suspicious")
+
+	// Clear the node
+	body := []byte(`{"action": "clear", "reason": "false positive"}`)
+	req, _ := http.NewRequest("POST", "/api/admin/review/"+node.ID, bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer key")
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+
+	// Verify node is cleared
+	updated := s.GetNode(node.ID)
+	if updated.CheatStatus != types.StatusClean {
+		t.Errorf("expected clean status, got %s", updated.CheatStatus)
+	}
+}
+
+func TestAdminReviewNodeBan(t *testing.T) {
+	router, s := setupTestRouter("key")
+
+	node := s.RegisterNode("0x1", types.BscFull, types.LocalProver, "", "")
+
+	body := []byte(`{"action": "ban", "reason": "confirmed cheating"}`)
+	req, _ := http.NewRequest("POST", "/api/admin/review/"+node.ID, bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer key")
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+
+	updated := s.GetNode(node.ID)
+	if updated.CheatStatus != types.StatusBanned {
+		t.Errorf("expected banned status, got %s", updated.CheatStatus)
+	}
+	if updated.IsActive {
+		t.Error("banned node should be deactivated")
+	}
+}
+
+func TestAdminReviewBanFlagsSiblings(t *testing.T) {
+	router, s := setupTestRouter("key")
+
+	banned := s.RegisterNode("0x1", types.BscFull, types.ExposedRPC, "http://203.0.113.5:8545", "")
+	sameHost := s.RegisterNode("0x2", types.BscFull, types.ExposedRPC, "https://203.0.113.5:443", "")
+	s.RegisterNode("0x3", types.BscFull, types.ExposedRPC, "http://198.51.100.9:8545", "")
+
+	body := []byte(`{"action": "ban", "reason": "proxying"}`)
+	req, _ := http.NewRequest("POST", "/api/admin/review/"+banned.ID, bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer key")
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp struct {
+		Siblings []types.SiblingNode `json:"siblings"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Siblings) != 1 || resp.Siblings[0].NodeID != sameHost.ID || resp.Siblings[0].CheatStatus != types.StatusFlagged {
+		t.Errorf("expected the same-host node flagged as a sibling, got %+v", resp.Siblings)
+	}
+}
+
+func TestBanNeedsSecondAdmin(t *testing.T) {
+	s := store.NewStore()
+	s.SetBanApprovalWindow(time.Hour)
+	router := SetupRouter(s, verification.NewVerifier("https://bsc-dataseed1.binance.org"), "alice-key", "bob-key")
+	node := s.RegisterNode("0x1", types.BscFull, types.LocalProver, "", "")
+
+	ban := func(key string) *httptest.ResponseRecorder {
+		body := []byte(`{"action": "ban", "reason": "proxying"}`)
+		req, _ := http.NewRequest("POST", "/api/admin/review/"+node.ID, bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+key)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := ban("alice-key"); w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202 for the first admin, got %d: %s", w.Code, w.Body.String())
+	}
+	if s.GetNode(node.ID).CheatStatus == types.StatusBanned {
+		t.Fatal("ban took effect with only one admin")
+	}
+	if pending := s.GetPendingBans(time.Now().UnixMilli()); len(pending) != 1 || pending[0].RequestedBy != AdminID("alice-key") {
+		t.Errorf("expected one pending ban from alice, got %+v", pending)
+	}
+
+	if w := ban("alice-key"); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 when the same admin confirms, got %d", w.Code)
+	}
+
+	if w := ban("bob-key"); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 once a second admin confirms, got %d: %s", w.Code, w.Body.String())
+	}
+	if s.GetNode(node.ID).CheatStatus != types.StatusBanned {
+		t.Error("expected the node banned after two admins")
+	}
+}
+
+func TestModerationLogExport(t *testing.T) {
+	s := store.NewStore()
+	v := verification.NewVerifier("https://bsc-dataseed1.binance.org")
+	key, _ := crypto.GenerateKey()
+	signer, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	v.Keys().Rotate(signer)
+	router := SetupRouter(s, v, "key")
+
+	node := s.RegisterNode("0x1", types.BscFull, types.LocalProver, "", "")
+	for _, action := range []string{"warn", "ban"} {
+		body := []byte(`{"action": "` + action + `", "reason": "test"}`)
+		req, _ := http.NewRequest("POST", "/api/admin/review/"+node.ID, bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer key")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req, _ := http.NewRequest("GET", "/api/admin/moderation-log?since=1", nil)
+	req.Header.Set("Authorization", "Bearer key")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp ModerationLogResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Count != 2 || len(resp.Entries) != 1 || resp.Entries[0].Action != modlog.ActionBanNode {
+		t.Fatalf("expected the ban as the only entry after seq 1, got %+v", resp)
+	}
+	if resp.Entries[0].Admin != AdminID("key") {
+		t.Errorf("expected the entry to name the admin key, got %s", resp.Entries[0].Admin)
+	}
+	if err := modlog.Verify(resp.Entries, resp.PrevHash); err != nil {
+		t.Errorf("export should verify: %v", err)
+	}
+	if !signing.Verify(modlog.ExportMessage(resp.Count, resp.Head), resp.Signature, signer.Address()) {
+		t.Error("export signature should match the server key")
+	}
+}
+
+func TestAdminReviewInvalidAction(t *testing.T) {
+	router, s := setupTestRouter("key")
+
+	node := s.RegisterNode("0x1", types.BscFull, types.LocalProver, "", "")
+
+	body := []byte(`{"action": "invalid"}`)
+	req, _ := http.NewRequest("POST", "/api/admin/review/"+node.ID, bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer key")
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for invalid action, got %d", w.Code)
+	}
+}
+
+func TestTestCreateNode(t *testing.T) {
+	router, _ := setupTestRouter("key")
+
+	body := []byte(`{
+		"wallet_address": "0xtest123",
+		"node_type": "bsc-full",
+		"verification_method": "local-prover"
+	}`)
+
+	req, _ := http.NewRequest("POST", "/api/admin/test/create-node", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer key")
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if response["success"] != true {
+		t.Error("expected success: true")
+	}
+
+	if response["node_id"] == "" {
+		t.Error("expected node_id to be set")
+	}
+
+	if response["total_points"].(float64) != 50 {
+		t.Errorf("expected 50 points for bsc-full, got %v", response["total_points"])
+	}
+}
+
+func TestCORSHeaders(t *testing.T) {
+	router, _ := setupTestRouter("")
+
+	req, _ := http.NewRequest("OPTIONS", "/api/stats", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected status 204 for OPTIONS, got %d", w.Code)
+	}
+
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Error("CORS header not set correctly")
+	}
+}
+
+func TestCORSOrigins(t *testing.T) {
+	cors := NewCORSPolicy("https://dashboard.example.com")
+	router, _ := NewRouter(store.NewStore(), verification.NewVerifier("https://bsc-dataseed1.binance.org"), Options{CORS: cors})
+
+	allowOrigin := func(origin string) string {
+		req, _ := http.NewRequest("OPTIONS", "/api/stats", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		allowed := w.Header().Get("Access-Control-Allow-Origin")
+		if allowed != "*" && w.Header().Get("Vary") != "Origin" {
+			t.Errorf("%s: expected Vary: Origin, got %q", origin, w.Header().Get("Vary"))
+		}
+		return allowed
+	}
+
+	if got := allowOrigin("https://dashboard.example.com"); got != "https://dashboard.example.com" {
+		t.Errorf("expected the listed origin to be echoed, got %q", got)
+	}
+	if got := allowOrigin("https://evil.example.com"); got != "" {
+		t.Errorf("expected no CORS header for an unlisted origin, got %q", got)
+	}
+
+	// As a reload would
+	cors.SetOrigins([]string{"https://evil.example.com"})
+	if got := allowOrigin("https://dashboard.example.com"); got != "" {
+		t.Errorf("expected a removed origin to be refused, got %q", got)
+	}
+	cors.SetOrigins([]string{"*"})
+	if got := allowOrigin("https://dashboard.example.com"); got != "*" {
+		t.Errorf("expected any origin after reloading *, got %q", got)
+	}
+}
+
+func TestAdminReloadConfig(t *testing.T) {
+	router, _ := setupTestRouter("")
+	req, _ := http.NewRequest("POST", "/api/admin/config/reload", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("expected 501 without a reload func, got %d", w.Code)
+	}
+
+	reloadErr := errors.New("invalid configuration:\n  - LATENCY_MAX_MS: must be greater than LATENCY_SUSPICIOUS_MS (150), got 10")
+	s := store.NewStore()
+	router, _ = NewRouter(s, verification.NewVerifier("https://bsc-dataseed1.binance.org"), Options{
+		Reload: func() ([]string, error) {
+			if reloadErr != nil {
+				return nil, reloadErr
+			}
+			return []string{"PORT"}, nil
+		},
+	})
+
+	req, _ = http.NewRequest("POST", "/api/admin/config/reload", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "LATENCY_MAX_MS") {
+		t.Errorf("expected 422 naming the bad setting, got %d: %s", w.Code, w.Body.String())
+	}
+	if entries := s.ModerationLog().Since(0); len(entries) != 0 {
+		t.Errorf("a rejected reload shouldn't be logged, got %v", entries)
+	}
+
+	reloadErr = nil
+	req, _ = http.NewRequest("POST", "/api/admin/config/reload", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var resp struct {
+		Success         bool     `json:"success"`
+		RestartRequired []string `json:"restart_required"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || !resp.Success || len(resp.RestartRequired) != 1 || resp.RestartRequired[0] != "PORT" {
+		t.Errorf("expected success with PORT needing a restart, got %d: %s", w.Code, w.Body.String())
+	}
+	entries := s.ModerationLog().Since(0)
+	if len(entries) != 1 || entries[0].Action != modlog.ActionReloadConfig || entries[0].Details["restart_required"] != "PORT" {
+		t.Errorf("expected the reload in the moderation log, got %v", entries)
+	}
+}
+
+func TestAdminFlags(t *testing.T) {
 	router, _ := setupTestRouter("key")
 
-	body := []byte(`{
-		"wallet_address": "0xtest123",
-		"node_type": "bsc-full",
-		"verification_method": "local-prover"
-	}`)
+	req, _ := http.NewRequest("GET", "/api/admin/flags", nil)
+	req.Header.Set("Authorization", "Bearer key")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Flags []map[string]interface{} `json:"flags"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if len(response.Flags) == 0 {
+		t.Error("expected default flags to be listed")
+	}
+
+	// Roll back a challenge type
+	body := []byte(`{"percent": 0}`)
+	req, _ = http.NewRequest("POST", "/api/admin/flags/challenge.state-balance", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer key")
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+
+	// Unknown flag
+	req, _ = http.NewRequest("POST", "/api/admin/flags/nope", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer key")
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for unknown flag, got %d", w.Code)
+	}
+}
+
+func TestAdminMetrics(t *testing.T) {
+	router, s := setupTestRouter("")
+	s.RegisterNode("0x1234567890123456789012345678901234567890", types.BscFull, types.LocalProver, "", "")
+
+	for _, path := range []string{"/api/leaderboard", "/api/leaderboard", "/api/nodes/missing"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req, _ := http.NewRequest("GET", "/api/admin/metrics", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Routes []metrics.RouteStats `json:"routes"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	byRoute := make(map[string]metrics.RouteStats)
+	for _, route := range response.Routes {
+		byRoute[route.Route] = route
+	}
+	if byRoute["GET /api/leaderboard"].Requests != 2 {
+		t.Errorf("expected 2 leaderboard requests, got %+v", byRoute["GET /api/leaderboard"])
+	}
+	// Counted by route pattern, not by path
+	if node := byRoute["GET /api/nodes/:nodeId"]; node.Requests != 1 || node.ClientErrors != 1 {
+		t.Errorf("expected one 404 on the node route, got %+v", node)
+	}
+}
+
+func TestAdminStoreUsage(t *testing.T) {
+	router, s := setupTestRouter("")
+	node := s.RegisterNode("0x1234567890123456789012345678901234567890", types.BscFull, types.LocalProver, "", "")
+	for i := 0; i < 1001; i++ {
+		s.RecordVerificationResult(&types.VerificationResult{NodeID: node.ID, Passed: true, Timestamp: time.Now().UnixMilli()})
+	}
+
+	req, _ := http.NewRequest("GET", "/api/admin/store/usage", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var usage types.StoreUsage
+	json.Unmarshal(w.Body.Bytes(), &usage)
+	if usage.Nodes != 1 || usage.Entries["verification_records"] != 1000 || usage.TotalBytes == 0 {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+
+	req, _ = http.NewRequest("POST", "/api/admin/store/compact", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var compaction types.Compaction
+	json.Unmarshal(w.Body.Bytes(), &compaction)
+	if w.Code != http.StatusOK || compaction.SlotsFreed == 0 {
+		t.Errorf("expected the trimmed history to be compacted, got %d %+v", w.Code, compaction)
+	}
+}
+
+func TestAdminSnapshotRestore(t *testing.T) {
+	router, s := setupTestRouter("")
+	node := s.RegisterNode("0x1234567890123456789012345678901234567890", types.BscFull, types.LocalProver, "", "")
+
+	req, _ := http.NewRequest("GET", "/api/admin/snapshot", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Header().Get("Content-Disposition"), ".backup") {
+		t.Fatalf("expected a backup download, got %d %v", w.Code, w.Header())
+	}
+	backup := w.Body.Bytes()
+
+	// Into a new server, as after a migration
+	other, restored := setupTestRouter("")
+	req, _ = http.NewRequest("POST", "/api/admin/restore", strings.NewReader("garbage"))
+	w = httptest.NewRecorder()
+	other.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 restoring something that isn't a backup, got %d", w.Code)
+	}
+
+	req, _ = http.NewRequest("POST", "/api/admin/restore", bytes.NewReader(backup))
+	w = httptest.NewRecorder()
+	other.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := restored.GetNode(node.ID); got == nil || got.TotalPoints != node.TotalPoints {
+		t.Errorf("expected the node and its points restored, got %+v", got)
+	}
+	if entries := restored.ModerationLog().Since(0); len(entries) != 1 || entries[0].Action != modlog.ActionRestoreStore {
+		t.Errorf("expected the restore logged, got %+v", entries)
+	}
+}
+
+func TestPointsLedgerEndpoints(t *testing.T) {
+	router, s := setupTestRouter("")
+	node := s.RegisterNode("0x1234567890123456789012345678901234567890", types.BscFull, types.ExposedRPC, "http://localhost:8545", "")
+	s.AwardUptimePoints(node.ID, 5)
+
+	reverse := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/admin/points/"+node.ID+"/reverse/2", strings.NewReader(`{"reason":"awarded while down"}`))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	if w := reverse(); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := reverse(); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 reversing twice, got %d", w.Code)
+	}
+	if entries := s.ModerationLog().Since(0); len(entries) != 1 || entries[0].Action != modlog.ActionReversePoints {
+		t.Errorf("reversal should be in the moderation log, got %+v", entries)
+	}
+
+	req, _ := http.NewRequest("GET", "/api/nodes/"+node.ID+"/points", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var resp struct {
+		TotalPoints uint64        `json:"total_points"`
+		Entries     []LedgerEntry `json:"entries"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Entries) != 3 || resp.TotalPoints != s.GetNode(node.ID).TotalPoints || resp.Entries[2].Balance != resp.TotalPoints {
+		t.Errorf("unexpected ledger: %s", w.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/api/nodes/"+node.ID+"/points?format=csv", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if w.Header().Get("Content-Type") != "text/csv" || len(lines) != 4 || !strings.HasPrefix(lines[0], "id,timestamp,reason") {
+		t.Errorf("unexpected csv: %s", w.Body.String())
+	}
+	if !strings.Contains(lines[3], "reversal") || !strings.Contains(lines[3], "awarded while down") {
+		t.Errorf("csv should show the reversal and why, got %q", lines[3])
+	}
 
-	req, _ := http.NewRequest("POST", "/api/admin/test/create-node", bytes.NewBuffer(body))
-	req.Header.Set("Authorization", "Bearer key")
-	req.Header.Set("Content-Type", "application/json")
+	adjust := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/admin/points/"+node.ID+"/adjust", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	if w := adjust(`{"amount":0,"reason":"nothing"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a zero adjustment, got %d", w.Code)
+	}
+	if w := adjust(fmt.Sprintf(`{"amount":%d,"reason":"too much"}`, -int64(s.GetNode(node.ID).TotalPoints)-1)); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 taking the node below zero, got %d", w.Code)
+	}
+	if w := adjust(`{"amount":25,"reason":"missed payout"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The same ledger under its other name
+	req, _ = http.NewRequest("GET", "/api/nodes/"+node.ID+"/points-history", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Entries) != 4 || resp.Entries[3].Reason != types.PointsAdjustment || resp.Entries[3].Note != "missed payout" {
+		t.Errorf("expected the adjustment at the end of the history, got %s", w.Body.String())
+	}
+	if entries := s.ModerationLog().Since(0); len(entries) != 2 || entries[1].Action != modlog.ActionAdjustPoints {
+		t.Errorf("adjustment should be in the moderation log, got %+v", entries)
+	}
+}
+
+func TestGetServerKeyDisabled(t *testing.T) {
+	router, _ := setupTestRouter("")
+
+	req, _ := http.NewRequest("GET", "/api/server-key", nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
 	if w.Code != http.StatusOK {
-		t.Errorf("expected status 200, got %d", w.Code)
+		t.Fatalf("expected status 200, got %d", w.Code)
 	}
 
 	var response map[string]interface{}
 	json.Unmarshal(w.Body.Bytes(), &response)
 
-	if response["success"] != true {
-		t.Error("expected success: true")
+	if response["enabled"] != false {
+		t.Errorf("expected signing to be disabled, got %v", response["enabled"])
 	}
+}
 
-	if response["node_id"] == "" {
-		t.Error("expected node_id to be set")
+func TestGetNodeWithMockStore(t *testing.T) {
+	s := &storemock.Store{
+		GetNodeFunc: func(nodeID string) *types.NodeRegistration {
+			if nodeID != "node-1" {
+				return nil
+			}
+			return &types.NodeRegistration{ID: nodeID, AuthToken: "secret"}
+		},
 	}
+	router := SetupRouter(s, verification.NewVerifier("https://bsc-dataseed1.binance.org"))
 
-	if response["total_points"].(float64) != 50 {
-		t.Errorf("expected 50 points for bsc-full, got %v", response["total_points"])
+	req, _ := http.NewRequest("GET", "/api/nodes/node-1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var node types.NodeRegistration
+	json.Unmarshal(w.Body.Bytes(), &node)
+	if node.ID != "node-1" || node.AuthToken != "" {
+		t.Errorf("expected node-1 without its auth token, got %+v", node)
+	}
+	if s.Calls("GetNode") != 1 {
+		t.Errorf("expected one GetNode call, got %d", s.Calls("GetNode"))
 	}
 }
 
-func TestCORSHeaders(t *testing.T) {
-	router, _ := setupTestRouter("")
+func TestResolveReclassificationWithMockStore(t *testing.T) {
+	log := modlog.New()
+	s := &storemock.Store{
+		ResolveReclassificationFunc: func(nodeID string, apply bool, want types.NodeType, by string, now int64) (*types.Reclassification, error) {
+			if nodeID != "node-1" || !apply {
+				return nil, store.ErrNoReclassification
+			}
+			return &types.Reclassification{NodeID: nodeID, From: types.BscFast, To: types.BscArchive, Status: types.ReclassificationApplied}, nil
+		},
+		ModerationLogFunc: func() *modlog.Log { return log },
+	}
+	router := SetupRouter(s, verification.NewVerifier("https://bsc-dataseed1.binance.org"), "key")
+
+	post := func(nodeID, action string) int {
+		body := strings.NewReader(`{"action":"` + action + `","reason":"serves deep state"}`)
+		req, _ := http.NewRequest("POST", "/api/admin/reclassifications/"+nodeID, body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer key")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
 
-	req, _ := http.NewRequest("OPTIONS", "/api/stats", nil)
+	if code := post("node-2", "apply"); code != http.StatusNotFound {
+		t.Errorf("expected 404 without a proposal, got %d", code)
+	}
+	if code := post("node-1", "apply"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	entries := log.Since(0)
+	if len(entries) != 1 || entries[0].Action != modlog.ActionReclassify || entries[0].Details["to"] != string(types.BscArchive) {
+		t.Errorf("expected one reclassify entry, got %+v", entries)
+	}
+}
+
+func TestStreamChallengesSigned(t *testing.T) {
+	s := store.NewStore()
+	v := verification.NewVerifier("https://bsc-dataseed1.binance.org")
+	serverKey, _ := crypto.GenerateKey()
+	serverSigner, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(serverKey)))
+	v.Keys().Rotate(serverSigner)
+
+	server := httptest.NewServer(SetupRouter(s, v))
+	defer server.Close()
+
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	node := s.RegisterNode(strings.ToLower(wallet.Address()), types.BscFull, types.LocalProver, "", "")
+
+	timestamp := time.Now().UnixMilli()
+	sig, _ := wallet.Sign(fmt.Sprintf("Subscribe challenges\nNode: %s\nTimestamp: %d", node.ID, timestamp))
+	resp, err := http.Get(fmt.Sprintf("%s/api/challenges/stream?nodeId=%s&timestamp=%d&signature=%s", server.URL, node.ID, timestamp, sig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// Wait for the opening ping so we know the stream is subscribed
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() && !strings.HasPrefix(scanner.Text(), "event:ping") {
+	}
+	v.Push().Publish(&types.Challenge{ID: "c1", NodeID: node.ID, ChallengeType: types.BlockHash})
+
+	var event, data string
+	for data == "" && scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "event:") {
+			event = strings.TrimPrefix(line, "event:")
+		} else if event == "challenge" && strings.HasPrefix(line, "data:") {
+			data = strings.TrimPrefix(line, "data:")
+		}
+	}
+
+	var push signing.Push
+	if err := json.Unmarshal([]byte(data), &push); err != nil {
+		t.Fatalf("expected a push envelope, got %q", data)
+	}
+	if push.Event != "challenge" || push.NodeID != node.ID || !strings.Contains(push.Payload, `"id":"c1"`) {
+		t.Errorf("unexpected push: %+v", push)
+	}
+	if !push.Verify([]string{serverSigner.Address()}) {
+		t.Error("pushed challenge should be signed with the server key")
+	}
+}
+
+func TestTunnel(t *testing.T) {
+	chain := httptest.NewServer(mockchain.New(46000000))
+	defer chain.Close()
+	s := store.NewStore()
+	server := httptest.NewServer(SetupRouter(s, verification.NewVerifier(chain.URL)))
+	defer server.Close()
+
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	register := func(endpoint string) *http.Response {
+		timestamp := time.Now().UnixMilli()
+		sig, _ := wallet.Sign(fmt.Sprintf("Register node\nWallet: %s\nType: %s\nTimestamp: %d", wallet.Address(), types.BscFull, timestamp))
+		body, _ := json.Marshal(map[string]interface{}{
+			"wallet_address":      wallet.Address(),
+			"node_type":           types.BscFull,
+			"verification_method": types.ExposedRPC,
+			"rpc_endpoint":        endpoint,
+			"signature":           sig,
+			"timestamp":           timestamp,
+		})
+		resp, err := http.Post(server.URL+"/api/nodes/register", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// Nobody gets to name a node's tunnel, so nobody can borrow another's
+	if resp := register("tunnel://some-other-node"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for a named tunnel endpoint, got %d", resp.StatusCode)
+	}
+
+	resp := register("tunnel://")
+	var registered RegisterResponse
+	json.NewDecoder(resp.Body).Decode(&registered)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || registered.EndpointCheck == nil || len(registered.EndpointCheck.Warnings) != 1 {
+		t.Fatalf("expected 200 with a tunnel-not-open warning, got %d: %+v", resp.StatusCode, registered)
+	}
+	nodeID := registered.NodeID
+	if endpoint := s.GetNode(nodeID).RPCEndpoint; endpoint != "tunnel://"+nodeID {
+		t.Fatalf("expected the node's own tunnel endpoint, got %q", endpoint)
+	}
+
+	// Verifying before the tunnel is open fails as unreachable
+	verify := func() VerifyResponse {
+		resp, err := http.Post(server.URL+"/api/verify/"+nodeID, "application/json", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var result VerifyResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		return result
+	}
+	if result := verify(); result.Passed {
+		t.Error("verification shouldn't pass without a tunnel")
+	}
+
+	timestamp := time.Now().UnixMilli()
+	sig, _ := wallet.Sign(fmt.Sprintf("Open tunnel\nNode: %s\nTimestamp: %d", nodeID, timestamp))
+	stream, err := http.Get(fmt.Sprintf("%s/api/tunnel?nodeId=%s&timestamp=%d&signature=%s", server.URL, nodeID, timestamp, sig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Body.Close()
+	if stream.StatusCode != http.StatusOK {
+		t.Fatalf("expected the tunnel to open, got %d", stream.StatusCode)
+	}
+
+	// Relay each call to the chain, like the prover does
+	go func() {
+		scanner := bufio.NewScanner(stream.Body)
+		var event string
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.HasPrefix(line, "event:") {
+				event = strings.TrimPrefix(line, "event:")
+				continue
+			}
+			if event != "request" || !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			var request struct {
+				ID   string `json:"id"`
+				Body []byte `json:"body"`
+			}
+			json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &request)
+			answer, err := http.Post(chain.URL, "application/json", bytes.NewReader(request.Body))
+			if err != nil {
+				return
+			}
+			var buf bytes.Buffer
+			buf.ReadFrom(answer.Body)
+			answer.Body.Close()
+			reply, _ := json.Marshal(map[string]interface{}{"node_id": nodeID, "id": request.ID, "status": answer.StatusCode, "body": buf.Bytes()})
+			resp, err := http.Post(server.URL+"/api/tunnel/replies", "application/json", bytes.NewReader(reply))
+			if err == nil {
+				resp.Body.Close()
+			}
+		}
+	}()
+
+	if result := verify(); !result.Passed {
+		t.Errorf("expected verification through the tunnel to pass, got %+v", result)
+	}
+
+	// A replayed open can't take the tunnel over
+	replay, _ := http.Get(fmt.Sprintf("%s/api/tunnel?nodeId=%s&timestamp=%d&signature=%s", server.URL, nodeID, timestamp, sig))
+	replay.Body.Close()
+	if replay.StatusCode != http.StatusConflict {
+		t.Errorf("expected 409 for a replayed open, got %d", replay.StatusCode)
+	}
+
+	reply, _ := json.Marshal(map[string]interface{}{"node_id": nodeID, "id": "made-up", "status": 200})
+	if resp, _ := http.Post(server.URL+"/api/tunnel/replies", "application/json", bytes.NewReader(reply)); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for a reply nothing is waiting for, got %d", resp.StatusCode)
+	}
+}
+
+func TestRegisterWithClientCert(t *testing.T) {
+	chain := httptest.NewServer(mockchain.New(46000000))
+	defer chain.Close()
+	s := store.NewStore()
+	v := verification.NewVerifier(chain.URL)
+	router := SetupRouter(s, v)
+
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	register := func(certPEM, keyPEM string) *httptest.ResponseRecorder {
+		timestamp := time.Now().UnixMilli()
+		sig, _ := wallet.Sign(fmt.Sprintf("Register node\nWallet: %s\nType: %s\nTimestamp: %d", wallet.Address(), types.BscFull, timestamp))
+		body, _ := json.Marshal(map[string]interface{}{
+			"wallet_address":      wallet.Address(),
+			"node_type":           types.BscFull,
+			"verification_method": types.ExposedRPC,
+			"rpc_endpoint":        chain.URL,
+			"client_cert":         certPEM,
+			"client_key":          keyPEM,
+			"signature":           sig,
+			"timestamp":           timestamp,
+		})
+		req, _ := http.NewRequest("POST", "/api/nodes/register", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	certPEM, keyPEM := mockchain.Certificate("node-a", time.Now().Add(time.Hour), x509.ExtKeyUsageClientAuth)
+	if w := register(certPEM, keyPEM); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 from a server without CLIENT_CERT_KEY, got %d: %s", w.Code, w.Body.String())
+	}
+
+	sealer, _ := clientcert.NewSealer(strings.Repeat("ab", 32))
+	v.SetClientCertSealer(sealer)
+	if w := register(certPEM, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a cert without its key, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := register(certPEM, keyPEM)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var registered RegisterResponse
+	json.Unmarshal(w.Body.Bytes(), &registered)
+	if stored := s.GetNode(registered.NodeID); stored.ClientCert == nil || stored.ClientCert.Sealed == "" {
+		t.Fatalf("expected the sealed cert to be stored, got %+v", stored.ClientCert)
+	}
+
+	req, _ := http.NewRequest("GET", "/api/nodes/"+registered.NodeID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if strings.Contains(w.Body.String(), `"sealed"`) || !strings.Contains(w.Body.String(), `"subject":"CN=node-a"`) {
+		t.Errorf("node response should show the cert's details but not the cert, got %s", w.Body.String())
+	}
+}
+
+func TestReadOnlyReplica(t *testing.T) {
+	writerRouter, writerStore := setupTestRouter("admin-key")
+	writer := httptest.NewServer(writerRouter)
+	defer writer.Close()
+	node := writerStore.RegisterNode("0xreplicated", types.BscFull, types.LocalProver, "", "")
+	writerStore.RecordVerificationResult(&types.VerificationResult{NodeID: node.ID, Passed: true, Timestamp: time.Now().UnixMilli()})
+
+	replicaStore := store.NewStore()
+	follower := replica.New(writer.URL, "admin-key", replicaStore)
+	if err := follower.Sync(time.Now().UnixMilli()); err != nil {
+		t.Fatal(err)
+	}
+	router, _ := NewRouter(replicaStore, verification.NewVerifier("https://bsc-dataseed1.binance.org"), Options{
+		AdminAPIKeys: []string{"admin-key"},
+		Replica:      follower,
+	})
+
+	// Reads are served from the copy
+	req, _ := http.NewRequest("GET", "/api/nodes/"+node.ID+"/stats", nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
+	var stats types.NodeStats
+	json.Unmarshal(w.Body.Bytes(), &stats)
+	if w.Code != http.StatusOK || stats.ChallengePassRate != 100 {
+		t.Fatalf("expected the writer's node and result, got %d: %s", w.Code, w.Body.String())
+	}
 
-	if w.Code != http.StatusNoContent {
-		t.Errorf("expected status 204 for OPTIONS, got %d", w.Code)
+	// Writes go to the writer, method and body intact
+	req, _ = http.NewRequest("POST", "/api/nodes/register?lang=en", strings.NewReader(`{}`))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusTemporaryRedirect || w.Header().Get("Location") != writer.URL+"/api/nodes/register?lang=en" {
+		t.Errorf("expected a redirect to the writer, got %d %q", w.Code, w.Header().Get("Location"))
 	}
 
-	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
-		t.Error("CORS header not set correctly")
+	req, _ = http.NewRequest("GET", "/health", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `"writer":"`+writer.URL+`"`) {
+		t.Errorf("expected health to show the replica's writer, got %s", w.Body.String())
+	}
+}
+
+func TestAPIKeyRateLimits(t *testing.T) {
+	s := store.NewStore()
+	limiter := ratelimit.New(ratelimit.Plans{ratelimit.Anonymous: 1, ratelimit.DefaultPlan: 2, "partner": 0})
+	router, _ := NewRouter(s, verification.NewVerifier("https://bsc-dataseed1.binance.org"), Options{Limiter: limiter})
+
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	timestamp := time.Now().UnixMilli()
+	sig, _ := wallet.Sign(fmt.Sprintf("Create API key\nWallet: %s\nLabel: explorer\nTimestamp: %d", wallet.Address(), timestamp))
+	body, _ := json.Marshal(map[string]interface{}{
+		"wallet_address": wallet.Address(),
+		"label":          "explorer",
+		"signature":      sig,
+		"timestamp":      timestamp,
+	})
+	req, _ := http.NewRequest("POST", "/api/keys", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var created struct {
+		APIKey string       `json:"api_key"`
+		Key    types.APIKey `json:"key"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if w.Code != http.StatusCreated || created.APIKey == "" || created.Key.Plan != ratelimit.DefaultPlan {
+		t.Fatalf("expected a new key on the default plan, got %d: %s", w.Code, w.Body.String())
+	}
+
+	read := func(apiKey string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/leaderboard", nil)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Without a key, the anonymous allowance
+	if w := read(""); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "1" {
+		t.Fatalf("expected the anonymous plan's limit, got %d %v", w.Code, w.Header())
+	}
+	if w := read(""); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected the second anonymous read limited, got %d", w.Code)
+	}
+
+	// With one, the key's plan
+	for i := 0; i < 2; i++ {
+		if w := read(created.APIKey); w.Code != http.StatusOK {
+			t.Fatalf("keyed read %d: got %d", i, w.Code)
+		}
+	}
+	if w := read(created.APIKey); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the key limited past its plan, got %d", w.Code)
+	}
+	if w := read("dpk_wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected an unknown key refused, got %d", w.Code)
+	}
+
+	// Admins see who reads most and can move them to another plan
+	req, _ = http.NewRequest("GET", "/api/admin/api-keys/usage", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var usage struct {
+		Consumers []APIConsumer `json:"consumers"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &usage)
+	if len(usage.Consumers) != 2 || usage.Consumers[0].Key == nil || usage.Consumers[0].Key.Label != "explorer" ||
+		usage.Consumers[0].Requests != 2 || usage.Consumers[0].Limited != 1 || usage.Consumers[1].Client != ratelimit.Anonymous {
+		t.Fatalf("unexpected usage: %s", w.Body.String())
+	}
+
+	plan := func(name string) int {
+		req, _ := http.NewRequest("POST", "/api/admin/api-keys/"+created.Key.ID+"/plan", strings.NewReader(`{"plan":"`+name+`"}`))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := plan("platinum"); code != http.StatusBadRequest {
+		t.Errorf("expected an unknown plan rejected, got %d", code)
+	}
+	if code := plan("partner"); code != http.StatusOK {
+		t.Fatalf("expected the plan change, got %d", code)
+	}
+	if w := read(created.APIKey); w.Code != http.StatusOK {
+		t.Errorf("expected the unlimited plan to let the key in, got %d", w.Code)
+	}
+
+	req, _ = http.NewRequest("POST", "/api/admin/api-keys/"+created.Key.ID+"/revoke", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected the key revoked, got %d", w.Code)
+	}
+	if w := read(created.APIKey); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected a revoked key refused, got %d", w.Code)
+	}
+	if entries := s.ModerationLog().Since(0); len(entries) != 2 || entries[1].Action != modlog.ActionRevokeAPIKey {
+		t.Errorf("expected the plan change and revocation logged, got %+v", entries)
+	}
+}
+
+func TestSearchNodes(t *testing.T) {
+	router, s := setupTestRouter("")
+	node := s.RegisterNode("0xsearchable", types.BscFull, types.LocalProver, "", "")
+
+	search := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/search?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := search("q=0xsearch")
+	var response struct {
+		Results []types.SearchResult `json:"results"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusOK || len(response.Results) != 1 || response.Results[0].NodeID != node.ID {
+		t.Fatalf("expected the node by wallet prefix, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := search("q=greenfield"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"results":[]`) {
+		t.Errorf("expected an empty list, got %s", w.Body.String())
+	}
+	if w := search("q="); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a query, got %d", w.Code)
+	}
+	if w := search("q=bsc&limit=500"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for too big a limit, got %d", w.Code)
+	}
+}
+
+func TestAnnouncements(t *testing.T) {
+	router, s := setupTestRouter("")
+
+	post := func(body map[string]interface{}) *httptest.ResponseRecorder {
+		raw, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", "/api/admin/announcements", bytes.NewBuffer(raw))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	list := func() []types.Announcement {
+		req, _ := http.NewRequest("GET", "/api/announcements", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response struct {
+			Announcements []types.Announcement `json:"announcements"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.Announcements
+	}
+
+	if w := post(map[string]interface{}{"title": "Prover 1.4 required", "body": "Upgrade before March 1", "kind": "prover-version"}); w.Code != http.StatusOK {
+		t.Fatalf("expected the announcement posted, got %d: %s", w.Code, w.Body.String())
+	}
+	post(map[string]interface{}{"title": "Welcome"})
+	for _, bad := range []map[string]interface{}{
+		{"body": "no title"},
+		{"title": "x", "kind": "gossip"},
+		{"title": "x", "expires_at": 1},
+	} {
+		if w := post(bad); w.Code != http.StatusBadRequest {
+			t.Errorf("%v: expected 400, got %d", bad, w.Code)
+		}
+	}
+
+	got := list()
+	if len(got) != 2 || got[0].Title != "Welcome" || got[0].Kind != types.AnnounceGeneral || got[1].Kind != types.AnnounceProverVersion {
+		t.Fatalf("expected both announcements newest first, got %+v", got)
+	}
+
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/api/admin/announcements/%d/retract", got[1].ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected the retraction to work, got %d", w.Code)
+	}
+	if got := list(); len(got) != 1 || got[0].Title != "Welcome" {
+		t.Errorf("expected the retracted announcement gone, got %+v", got)
+	}
+	req, _ = http.NewRequest("POST", "/api/admin/announcements/999/retract", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown announcement, got %d", w.Code)
+	}
+
+	entries := s.ModerationLog().Since(0)
+	if len(entries) != 3 || entries[0].Action != modlog.ActionAnnounce || entries[2].Action != modlog.ActionUnannounce {
+		t.Errorf("expected two posts and a retraction logged, got %+v", entries)
+	}
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	server := httptest.NewServer(mockchain.New(46000000))
+	defer server.Close()
+
+	s := store.NewStore()
+	v := verification.NewVerifier(server.URL)
+	router := SetupRouter(s, v)
+
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	node := s.RegisterNode(strings.ToLower(wallet.Address()), types.BscFull, types.LocalProver, "", "")
+
+	// A challenge goes out before maintenance starts
+	w := requestChallenge(router, node.ID, wallet, time.Now().UnixMilli())
+	var issued ChallengeRequestResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &issued); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("expected a challenge, got %d: %s", w.Code, w.Body.String())
+	}
+
+	admin := func(path string, body map[string]interface{}) *httptest.ResponseRecorder {
+		raw, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", path, bytes.NewBuffer(raw))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	if w := admin("/api/admin/maintenance", map[string]interface{}{"until": 1}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an end in the past, got %d", w.Code)
+	}
+	until := time.Now().Add(10 * time.Minute).UnixMilli()
+	if w := admin("/api/admin/maintenance", map[string]interface{}{"reason": "database upgrade", "until": until}); w.Code != http.StatusOK {
+		t.Fatalf("expected maintenance started, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// No new challenges, with a hint when to come back
+	w = requestChallenge(router, node.ID, wallet, time.Now().UnixMilli())
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 during maintenance, got %d", w.Code)
+	}
+	if retry, _ := strconv.Atoi(w.Header().Get("Retry-After")); retry < 590 || retry > 600 {
+		t.Errorf("expected Retry-After of about 10 minutes, got %q", w.Header().Get("Retry-After"))
+	}
+	var unavailable struct {
+		Maintenance types.ServiceMaintenance `json:"maintenance"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &unavailable)
+	if unavailable.Maintenance.Reason != "database upgrade" || unavailable.Maintenance.Until != until {
+		t.Errorf("expected the maintenance in the payload, got %s", w.Body.String())
+	}
+	for _, path := range []string{"/api/leaderboard", "/api/announcements", "/health"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if want := path != "/api/leaderboard"; (w.Code == http.StatusOK) != want {
+			t.Errorf("%s: got %d during maintenance", path, w.Code)
+		}
+	}
+
+	// The answer to the challenge already out is held, not failed
+	ch := issued.Challenge
+	timestamp := time.Now().UnixMilli()
+	message, _ := signing.AnswerMessage(signing.AnswerV2, ch.ID, node.ID, ch.ChallengeType, "0xabc", "", timestamp)
+	sig, _ := wallet.Sign(message)
+	body, _ := json.Marshal(map[string]interface{}{
+		"challenge_id":   ch.ID,
+		"node_id":        node.ID,
+		"answer":         "0xabc",
+		"signature":      sig,
+		"timestamp":      timestamp,
+		"version":        signing.AnswerV2,
+		"challenge_type": ch.ChallengeType,
+	})
+	req, _ := http.NewRequest("POST", "/api/challenges/submit", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted || v.HeldAnswers() != 1 {
+		t.Fatalf("expected the answer held, got %d: %s", w.Code, w.Body.String())
+	}
+	if s.GetNode(node.ID).TotalChallengesFailed != 0 {
+		t.Error("a held answer shouldn't be checked yet")
+	}
+
+	// Ending maintenance checks it
+	w = admin("/api/admin/maintenance/end", nil)
+	var ended struct {
+		AnswersHeld int `json:"answers_held"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &ended)
+	if w.Code != http.StatusOK || ended.AnswersHeld != 1 || v.HeldAnswers() != 0 {
+		t.Fatalf("expected the held answer checked, got %d: %s", w.Code, w.Body.String())
+	}
+	if stats := s.GetNode(node.ID); stats.TotalChallengesPassed+stats.TotalChallengesFailed != 1 {
+		t.Errorf("expected the held answer recorded, got %+v", stats)
+	}
+	if w := admin("/api/admin/maintenance/end", nil); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 ending maintenance twice, got %d", w.Code)
+	}
+	// A millisecond on, so it can't reuse the timestamp refused above
+	if w := requestChallenge(router, node.ID, wallet, time.Now().UnixMilli()+1); w.Code != http.StatusOK {
+		t.Errorf("expected challenges again after maintenance, got %d", w.Code)
+	}
+
+	entries := s.ModerationLog().Since(0)
+	if len(entries) != 2 || entries[0].Action != modlog.ActionPauseAPI || entries[1].Action != modlog.ActionResumeAPI {
+		t.Errorf("expected start and end logged, got %+v", entries)
+	}
+}
+
+func TestSubmissionAttempts(t *testing.T) {
+	router, s := setupTestRouter("")
+
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	node := s.RegisterNode(strings.ToLower(wallet.Address()), types.BscFull, types.LocalProver, "", "")
+
+	// Late answers to a challenge that's gone, the second one changed
+	for i, answer := range []string{"0xabc", "0xdef"} {
+		timestamp := time.Now().UnixMilli() + int64(i)
+		message, _ := signing.AnswerMessage(signing.AnswerV1, "c1", node.ID, "", answer, "", timestamp)
+		sig, _ := wallet.Sign(message)
+		body, _ := json.Marshal(map[string]interface{}{
+			"challenge_id": "c1",
+			"node_id":      node.ID,
+			"answer":       answer,
+			"signature":    sig,
+			"timestamp":    timestamp,
+		})
+		req, _ := http.NewRequest("POST", "/api/challenges/submit", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req, _ := http.NewRequest("GET", "/api/admin/verifications/c1/attempts", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var response struct {
+		Attempts []types.SubmissionAttempt `json:"attempts"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusOK || len(response.Attempts) != 2 {
+		t.Fatalf("expected both submissions kept, got %d: %s", w.Code, w.Body.String())
+	}
+	if first := response.Attempts[0]; first.Conflicting || first.Passed || first.FailureKind != types.FailureExpired {
+		t.Errorf("expected the first submission failed as expired, got %+v", first)
+	}
+	if !response.Attempts[1].Conflicting || len(s.GetNode(node.ID).SuspiciousEvents) != 1 {
+		t.Errorf("expected the changed answer to conflict and count against the node, got %+v", response.Attempts[1])
+	}
+
+	req, _ = http.NewRequest("GET", "/api/admin/verifications/c2/attempts", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a challenge with no submissions, got %d", w.Code)
+	}
+}
+
+func TestProverBuilds(t *testing.T) {
+	router, s := setupTestRouter("")
+	official := "0x" + strings.Repeat("ab", 32)
+	modified := "0x" + strings.Repeat("cd", 32)
+	builds, _ := proverbuild.ParseAllowlist(official)
+	s.SetOfficialBuilds(builds)
+
+	key, _ := crypto.GenerateKey()
+	wallet, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	node := s.RegisterNode(strings.ToLower(wallet.Address()), types.BscFull, types.LocalProver, "", "")
+
+	submit := func(signedBuild, sentBuild string) int {
+		timestamp := time.Now().UnixMilli()
+		message, _ := signing.AnswerMessage(signing.AnswerV3, "c1", node.ID, types.BlockHash, "0xabc", signedBuild, timestamp)
+		sig, _ := wallet.Sign(message)
+		body, _ := json.Marshal(map[string]interface{}{
+			"challenge_id":   "c1",
+			"node_id":        node.ID,
+			"answer":         "0xabc",
+			"signature":      sig,
+			"timestamp":      timestamp,
+			"version":        signing.AnswerV3,
+			"challenge_type": types.BlockHash,
+			"build":          sentBuild,
+		})
+		req, _ := http.NewRequest("POST", "/api/challenges/submit", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := submit("", ""); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for v3 without a build, got %d", code)
+	}
+	if code := submit(official, modified); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a build the signature doesn't cover, got %d", code)
+	}
+	if code := submit(official, official); code != http.StatusOK || s.GetNode(node.ID).UnofficialBuild {
+		t.Errorf("expected an official build accepted and unmarked, got %d", code)
+	}
+
+	// A modified build is marked, not refused
+	if code := submit(modified, modified); code != http.StatusOK {
+		t.Errorf("expected a modified build accepted, got %d", code)
+	}
+	req, _ := http.NewRequest("GET", "/api/admin/prover-builds", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var response struct {
+		ByBuild         map[string]int `json:"by_build"`
+		UnofficialNodes []string       `json:"unofficial_nodes"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.ByBuild[modified] != 1 || len(response.UnofficialNodes) != 1 || response.UnofficialNodes[0] != node.ID {
+		t.Errorf("expected the node listed on an unofficial build, got %s", w.Body.String())
+	}
+
+	// Adding the build to the allowlist clears the mark
+	builds, _ = proverbuild.ParseAllowlist(official + "," + modified)
+	s.SetOfficialBuilds(builds)
+	if s.GetNode(node.ID).UnofficialBuild {
+		t.Error("expected the node unmarked once its build is official")
+	}
+}
+
+func TestLeaderboardSharedCache(t *testing.T) {
+	s := store.NewStore()
+	cache := sharedcache.NewMemory()
+	router, _ := NewRouter(s, verification.NewVerifier("https://bsc-dataseed1.binance.org"), Options{Cache: cache})
+
+	get := func() string {
+		req, _ := http.NewRequest("GET", "/api/leaderboard", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		return w.Body.String()
+	}
+
+	get()
+	if _, ok, _ := cache.Get(leaderboardCacheKey + string(s.Network())); !ok {
+		t.Fatal("expected the leaderboard put in the shared cache")
+	}
+
+	// What another server computed is served as is
+	cache.Set(leaderboardCacheKey+string(s.Network()), []byte(`[{"rank":1,"node_id":"elsewhere"}]`), time.Minute)
+	if body := get(); !strings.Contains(body, "elsewhere") {
+		t.Errorf("expected the cached leaderboard, got %s", body)
 	}
 }
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Header a client sets to make a POST safe to retry. A repeat with the
+// same key and body gets the first response back instead of running again.
+const IdempotencyHeader = "Idempotency-Key"
+
+// Set on responses replayed from the cache
+const idempotentReplayHeader = "Idempotent-Replayed"
+
+// Signed requests are only accepted for 5 minutes, so a retry after that
+// fails anyway; keep responses a while longer than that
+const IdempotencyTTL = 10 * time.Minute
+
+const (
+	maxIdempotencyKeyLen = 255
+	maxIdempotencyKeys   = 100000 // Past this, requests go through uncached
+)
+
+// What the first request with a key got
+type idempotentResponse struct {
+	bodyHash    [32]byte
+	done        bool // False while the first request is still running
+	status      int
+	contentType string
+	body        []byte
+	expiresAt   time.Time
+}
+
+type idempotencyCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*idempotentResponse
+}
+
+func newIdempotencyCache(ttl time.Duration) *idempotencyCache {
+	return &idempotencyCache{ttl: ttl, entries: make(map[string]*idempotentResponse)}
+}
+
+// Tees everything the handler writes so it can be replayed
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware replays the response to a request carrying an
+// Idempotency-Key the route has seen recently, so a prover retrying after
+// a dropped connection doesn't register a second node or submit twice.
+// Requests without the header run as usual. Server errors and rate limits
+// aren't kept, so those can be retried for real. Use one instance for
+// every route that takes the header.
+func IdempotencyMiddleware(ttl time.Duration) gin.HandlerFunc {
+	cache := newIdempotencyCache(ttl)
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": tr(c, "Idempotency-Key too long")})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": tr(c, "could not read request body")})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		bodyHash := sha256.Sum256(body)
+
+		// Keys are per route, so one key can't mix a registration and a submit
+		key = c.Request.Method + " " + c.Request.URL.Path + " " + key
+		now := time.Now()
+
+		cache.mu.Lock()
+		entry, seen := cache.entries[key]
+		if seen && now.After(entry.expiresAt) {
+			delete(cache.entries, key)
+			seen = false
+		}
+		switch {
+		case seen && entry.bodyHash != bodyHash:
+			cache.mu.Unlock()
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": tr(c, "Idempotency-Key was already used with a different request")})
+			return
+		case seen && !entry.done:
+			cache.mu.Unlock()
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": tr(c, "a request with this Idempotency-Key is still in progress")})
+			return
+		case seen:
+			status, contentType, replay := entry.status, entry.contentType, entry.body
+			cache.mu.Unlock()
+			c.Header(idempotentReplayHeader, "true")
+			c.Data(status, contentType, replay)
+			c.Abort()
+			return
+		}
+
+		if len(cache.entries) >= maxIdempotencyKeys {
+			cache.sweep(now)
+		}
+		if len(cache.entries) >= maxIdempotencyKeys {
+			cache.mu.Unlock()
+			c.Next()
+			return
+		}
+		entry = &idempotentResponse{bodyHash: bodyHash, expiresAt: now.Add(cache.ttl)}
+		cache.entries[key] = entry
+		cache.mu.Unlock()
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		status := writer.Status()
+		if status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+			delete(cache.entries, key)
+			return
+		}
+		entry.done = true
+		entry.status = status
+		entry.contentType = writer.Header().Get("Content-Type")
+		entry.body = writer.body.Bytes()
+	}
+}
+
+// Drop expired responses. Requests still running are kept.
+// Caller must hold cache.mu
+func (cache *idempotencyCache) sweep(now time.Time) {
+	for key, entry := range cache.entries {
+		if entry.done && now.After(entry.expiresAt) {
+			delete(cache.entries, key)
+		}
+	}
+}
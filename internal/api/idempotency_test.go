@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	var runs, failures atomic.Int32
+	router := gin.New()
+	idempotent := IdempotencyMiddleware(time.Minute)
+	router.POST("/create", idempotent, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"run": runs.Add(1)})
+	})
+	router.POST("/flaky", idempotent, func(c *gin.Context) {
+		if failures.Add(1) == 1 {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "try again"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	post := func(path, key, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	run := func(w *httptest.ResponseRecorder) int32 {
+		var resp struct{ Run int32 }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Run
+	}
+
+	first := post("/create", "k1", `{"a":1}`)
+	retry := post("/create", "k1", `{"a":1}`)
+	if run(first) != 1 || run(retry) != 1 || retry.Header().Get(idempotentReplayHeader) != "true" {
+		t.Errorf("retry should replay the first response, got %s then %s", first.Body.String(), retry.Body.String())
+	}
+	if retry.Header().Get("Content-Type") != first.Header().Get("Content-Type") {
+		t.Errorf("replay should keep the content type, got %q", retry.Header().Get("Content-Type"))
+	}
+
+	if w := post("/create", "k1", `{"a":2}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 reusing a key for another body, got %d", w.Code)
+	}
+	if w := post("/create", "k2", `{"a":1}`); run(w) != 2 {
+		t.Errorf("a new key should run again, got %s", w.Body.String())
+	}
+	if w := post("/create", "", `{"a":1}`); run(w) != 3 {
+		t.Errorf("no key should run as usual, got %s", w.Body.String())
+	}
+	if w := post("/create", strings.Repeat("k", 256), `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an overlong key, got %d", w.Code)
+	}
+
+	// Server errors aren't kept, so the retry runs for real
+	if w := post("/flaky", "k1", `{}`); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected the first attempt to fail, got %d", w.Code)
+	}
+	if w := post("/flaky", "k1", `{}`); w.Code != http.StatusOK {
+		t.Errorf("retry after a server error should run again, got %d", w.Code)
+	}
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"github.com/depinonbnb/depin/internal/i18n"
+	"github.com/depinonbnb/depin/internal/types"
+	"github.com/depinonbnb/depin/internal/verification"
+	"github.com/gin-gonic/gin"
+)
+
+// A message for the node operator, in the language their client asked for
+// with Accept-Language. English, and no Content-Language, if we don't have
+// that language.
+func tr(c *gin.Context, message string) string {
+	lang := i18n.Negotiate(c.GetHeader("Accept-Language"))
+	if lang == i18n.English {
+		return message
+	}
+	c.Header("Content-Language", lang)
+	return i18n.Translate(lang, message)
+}
+
+// Every message in a list, for operator-facing detail such as
+// attestation problems
+func trAll(c *gin.Context, messages []string) []string {
+	out := make([]string, len(messages))
+	for i, message := range messages {
+		out[i] = tr(c, message)
+	}
+	return out
+}
+
+// An endpoint check as shown to the operator who gave us the endpoint
+func endpointCheckResponse(c *gin.Context, check *verification.EndpointCheck) *verification.EndpointCheck {
+	localized := *check
+	localized.Problem = tr(c, check.Problem)
+	if len(check.Warnings) > 0 {
+		localized.Warnings = trAll(c, check.Warnings)
+	}
+	return &localized
+}
+
+// What a prover gets back for a verdict. The result itself keeps the
+// English reasons, since that's what's stored and shown to admins.
+func verifyResponse(c *gin.Context, result *types.VerificationResult) VerifyResponse {
+	parts := make([]types.PartResult, len(result.Parts))
+	for i, part := range result.Parts {
+		part.FailureReason = tr(c, part.FailureReason)
+		parts[i] = part
+	}
+	if len(parts) == 0 {
+		parts = nil
+	}
+	return VerifyResponse{
+		Passed:         result.Passed,
+		FailureReason:  tr(c, result.FailureReason),
+		ResponseTimeMs: result.ResponseTimeMs,
+		Parts:          parts,
+	}
+}
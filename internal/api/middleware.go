@@ -1,21 +1,62 @@
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/depinonbnb/depin/internal/metrics"
+	"github.com/depinonbnb/depin/internal/ratelimit"
+	"github.com/depinonbnb/depin/internal/store"
 	"github.com/gin-gonic/gin"
 )
 
-// AdminAuthMiddleware checks for valid admin API key
-func AdminAuthMiddleware(apiKey string) gin.HandlerFunc {
+// Context key holding which admin key made the request
+const adminContextKey = "admin"
+
+// Context key holding the request's *metrics.Breakdown
+const breakdownContextKey = "breakdown"
+
+// MetricsMiddleware counts every request against its route pattern (so
+// /nodes/:nodeId is one route) and hands handlers a breakdown to time
+// their slow parts in.
+func MetricsMiddleware(recorder *metrics.Recorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		breakdown := &metrics.Breakdown{}
+		c.Set(breakdownContextKey, breakdown)
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "(unmatched)"
+		}
+		recorder.Observe(c.Request.Method+" "+route, c.Writer.Status(), time.Since(start), breakdown)
+	}
+}
+
+// Time part of a request for the slow-request log:
+//
+//	defer track(c, "store")()
+func track(c *gin.Context, phase string) func() {
+	breakdown, _ := c.Value(breakdownContextKey).(*metrics.Breakdown)
+	return breakdown.Track(phase)
+}
+
+// AdminAuthMiddleware checks for valid admin API key. Each admin can have
+// their own key, so actions can tell admins apart.
+func AdminAuthMiddleware(apiKeys ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get the Authorization header
 		authHeader := c.GetHeader("Authorization")
 
 		// Check for Bearer token format
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "missing authorization header")})
 			c.Abort()
 			return
 		}
@@ -27,12 +68,145 @@ func AdminAuthMiddleware(apiKey string) gin.HandlerFunc {
 		}
 
 		// Validate the API key
-		if token != apiKey {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+		if !containsKey(apiKeys, token) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "invalid api key")})
 			c.Abort()
 			return
 		}
 
+		c.Set(adminContextKey, AdminID(token))
 		c.Next()
 	}
 }
+
+// For endpoints open to both admins and operators. A request with a valid
+// admin key is marked as an admin's; anything else goes through for the
+// handler to authenticate some other way. With no keys configured every
+// request counts as an admin's, as on the admin endpoints.
+func OptionalAdminMiddleware(apiKeys ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(apiKeys) == 0 {
+			c.Set(adminContextKey, "")
+			c.Next()
+			return
+		}
+
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token != "" && containsKey(apiKeys, token) {
+			c.Set(adminContextKey, AdminID(token))
+		}
+		c.Next()
+	}
+}
+
+func containsKey(keys []string, token string) bool {
+	for _, key := range keys {
+		if token == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Name for an admin key that's safe to log and show: a short hash of it
+func AdminID(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return "admin-" + hex.EncodeToString(sum[:4])
+}
+
+// Which admin made this request ("" when admin endpoints are unprotected)
+func adminID(c *gin.Context) string {
+	return c.GetString(adminContextKey)
+}
+
+// Whether OptionalAdminMiddleware let this request in as an admin
+func isAdmin(c *gin.Context) bool {
+	_, ok := c.Get(adminContextKey)
+	return ok
+}
+
+// On a read-only replica (writer set), send the request to the writer
+// instead. 307 keeps the method and body, so signed requests still verify
+// there. With no writer, requests are served here.
+func WriterRedirectMiddleware(writer string) gin.HandlerFunc {
+	writer = strings.TrimSuffix(writer, "/")
+	return func(c *gin.Context) {
+		if writer == "" {
+			c.Next()
+			return
+		}
+		c.Redirect(http.StatusTemporaryRedirect, writer+c.Request.URL.RequestURI())
+		c.Abort()
+	}
+}
+
+// Meter and rate limit reads of the public API. A request with an
+// X-API-Key header counts against its key's plan, anything else against
+// the anonymous plan for its client IP. An unknown or revoked key is
+// refused rather than treated as anonymous, so a typo doesn't go unnoticed.
+func RateLimitMiddleware(limiter *ratelimit.Limiter, keys store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client, plan := c.ClientIP(), ratelimit.Anonymous
+		if secret := c.GetHeader("X-API-Key"); secret != "" {
+			key, ok := keys.LookupAPIKey(secret)
+			if !ok {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "invalid or revoked api key")})
+				c.Abort()
+				return
+			}
+			client, plan = key.ID, key.Plan
+		}
+
+		decision := limiter.Allow(client, plan, time.Now().UnixMilli())
+		if decision.Limit > 0 {
+			c.Header("X-RateLimit-Limit", strconv.FormatUint(decision.Limit, 10))
+			c.Header("X-RateLimit-Remaining", strconv.FormatUint(decision.Remaining, 10))
+			c.Header("X-RateLimit-Reset", strconv.FormatInt(decision.Reset/1000, 10))
+		}
+		if !decision.Allowed {
+			c.Header("Retry-After", strconv.FormatInt((decision.Reset-time.Now().UnixMilli()+999)/1000, 10))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": tr(c, "rate limit reached, try again next minute or use an api key")})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// How long to tell clients to wait during maintenance with no expected
+// end, or once it has overrun
+const maintenanceRetrySeconds = 60
+
+// While the service is in maintenance, answer 503 with a Retry-After of
+// the time left to its expected end, instead of serving the request.
+// Routes whose path starts with one of exempt are served as usual.
+func MaintenanceMiddleware(state store.Store, exempt ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		maintenance := state.ServiceMaintenance()
+		if maintenance == nil || hasAnyPrefix(c.FullPath(), exempt) {
+			c.Next()
+			return
+		}
+
+		retry := int64(maintenanceRetrySeconds)
+		if left := (maintenance.Until - time.Now().UnixMilli() + 999) / 1000; left > 0 {
+			retry = left
+		}
+		c.Header("Retry-After", strconv.FormatInt(retry, 10))
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":               tr(c, "the service is down for maintenance, try again later"),
+			"maintenance":         maintenance,
+			"retry_after_seconds": retry,
+		})
+		c.Abort()
+	}
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,67 +1,267 @@
 package api
 
 import (
+	"github.com/depinonbnb/depin/internal/anomaly"
+	"github.com/depinonbnb/depin/internal/metrics"
+	"github.com/depinonbnb/depin/internal/names"
+	"github.com/depinonbnb/depin/internal/ratelimit"
+	"github.com/depinonbnb/depin/internal/replica"
+	"github.com/depinonbnb/depin/internal/sharedcache"
 	"github.com/depinonbnb/depin/internal/store"
 	"github.com/depinonbnb/depin/internal/verification"
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter(store *store.Store, verifier *verification.Verifier, adminAPIKey string) *gin.Engine {
+// Router settings beyond the store and verifier
+type Options struct {
+	AdminAPIKeys []string
+	Metrics      *metrics.Recorder  // Nil = a fresh one nobody reads
+	Alerts       *anomaly.Monitor   // Nil = a fresh one nobody feeds
+	Limiter      *ratelimit.Limiter // Nil = reads are metered but not limited
+	Names        *names.Resolver    // Nil = wallets are shown by address only
+
+	// Proxies (IPs or CIDRs) whose ClientIPHeaders are believed. With
+	// none, the client IP is always the connection's address, since a
+	// forwarded-for header from anyone else could be made up.
+	TrustedProxies  []string
+	ClientIPHeaders []string // Nil = X-Forwarded-For, X-Real-IP
+
+	// Header the proxy puts the client's country code in, e.g.
+	// CF-IPCountry. Empty = nodes aren't placed.
+	CountryHeader string
+
+	CORS *CORSPolicy // Nil = any origin
+
+	// Set = this server is a read-only replica serving the follower's
+	// copy of the writer's store. Requests that change state are
+	// redirected to the writer.
+	Replica *replica.Follower
+
+	// Shared by every server process behind the load balancer (REDIS_URL).
+	// Hot reads like the leaderboard are cached in it. Nil = each server
+	// computes its own.
+	Cache sharedcache.Cache
+
+	// Re-reads the config and applies what can change while serving,
+	// returning the settings that need a restart. Nil = the admin reload
+	// endpoint isn't available.
+	Reload func() (skipped []string, err error)
+}
+
+func SetupRouter(store store.Store, verifier *verification.Verifier, adminAPIKeys ...string) *gin.Engine {
+	router, _ := NewRouter(store, verifier, Options{AdminAPIKeys: adminAPIKeys}) // No proxies to get wrong
+	return router
+}
+
+// Like SetupRouter, with every setting. Fails on a malformed proxy.
+func NewRouter(store store.Store, verifier *verification.Verifier, opts Options) (*gin.Engine, error) {
 	router := gin.Default()
+	if err := router.SetTrustedProxies(opts.TrustedProxies); err != nil {
+		return nil, err
+	}
+	if opts.ClientIPHeaders != nil {
+		router.RemoteIPHeaders = opts.ClientIPHeaders
+	}
 
-	// Enable CORS
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
-		c.Next()
-	})
+	recorder := opts.Metrics
+	if recorder == nil {
+		recorder = metrics.NewRecorder()
+	}
+	router.Use(MetricsMiddleware(recorder))
+
+	cors := opts.CORS
+	if cors == nil {
+		cors = NewCORSPolicy("*")
+	}
+	router.Use(CORSMiddleware(cors))
 
 	handlers := NewHandlers(store, verifier)
+	handlers.metrics = recorder
+	handlers.alerts = opts.Alerts
+	if handlers.alerts == nil {
+		handlers.alerts = anomaly.NewMonitor()
+	}
+	handlers.names = opts.Names
+	handlers.shared = opts.Cache
+	handlers.limiter = opts.Limiter
+	if handlers.limiter == nil {
+		handlers.limiter = ratelimit.New(ratelimit.Plans{})
+	}
+	handlers.countryHeader = opts.CountryHeader
+	handlers.reload = opts.Reload
 
-	// Health check
+	// Health check. Maintenance doesn't make the server unhealthy; it's
+	// reported so a load balancer's dashboard shows it.
 	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
+		health := gin.H{"status": "ok"}
+		if opts.Replica != nil {
+			health["replica"] = opts.Replica.Status()
+		}
+		if maintenance := store.ServiceMaintenance(); maintenance != nil {
+			health["maintenance"] = maintenance
+		}
+		c.JSON(200, health)
 	})
 
-	api := router.Group("/api")
+	// On a read-only replica, anything that changes state (and everything
+	// admin) is sent to the writer
+	var writer string
+	if opts.Replica != nil {
+		writer = opts.Replica.Writer()
+	}
+	writes := WriterRedirectMiddleware(writer)
+
+	// Public reads, limited per API key plan or per IP without one
+	reads := RateLimitMiddleware(handlers.limiter, store)
+
+	// Safe retries for the calls that create something
+	idempotent := IdempotencyMiddleware(IdempotencyTTL)
+
+	// During maintenance, everything but admin, answers to challenges
+	// already out (held until it's over) and what tells operators what's
+	// going on answers 503
+	api := router.Group("/api", MaintenanceMiddleware(store,
+		"/api/admin/",
+		"/api/challenges/commit",
+		"/api/challenges/submit",
+		"/api/service-status",
+		"/api/announcements",
+		"/api/server-key",
+	))
 	{
 		// Node registration
-		api.POST("/nodes/register", handlers.RegisterNode)
-		api.GET("/nodes/:nodeId", handlers.GetNode)
-		api.GET("/nodes/wallet/:walletAddress", handlers.GetNodesByWallet)
-		api.GET("/nodes/:nodeId/stats", handlers.GetNodeStats)
+		api.POST("/nodes/register", writes, idempotent, handlers.RegisterNode)
+		api.GET("/nodes/compare", reads, handlers.CompareNodes)
+		api.GET("/nodes/:nodeId", reads, handlers.GetNode)
+		api.GET("/nodes/wallet/:walletAddress", reads, handlers.GetNodesByWallet)
+		api.GET("/nodes/:nodeId/stats", reads, handlers.GetNodeStats)
+		api.GET("/nodes/:nodeId/projection", reads, handlers.GetPointsProjection)
+		api.GET("/nodes/:nodeId/points", reads, handlers.GetPointsLedger)
+		api.GET("/nodes/:nodeId/points-history", reads, handlers.GetPointsLedger)
+		api.GET("/nodes/:nodeId/uptime/calendar", reads, handlers.GetUptimeCalendar)
+
+		// Operator maintenance (signed by the node's wallet)
+		api.POST("/nodes/:nodeId/pause", writes, handlers.PauseNode)
+		api.POST("/nodes/:nodeId/resume", writes, handlers.ResumeNode)
+		api.POST("/nodes/:nodeId/verification-method", writes, handlers.ChangeVerificationMethod)
+		api.POST("/nodes/:nodeId/client-cert", writes, handlers.SetClientCert)
+
+		// Node type corrections from probing (accept is signed by the node's wallet)
+		api.GET("/nodes/:nodeId/reclassification", reads, handlers.GetReclassification)
+		api.POST("/nodes/:nodeId/reclassification/accept", writes, handlers.AcceptReclassification)
 
 		// Wallet stats (total points across all nodes)
-		api.GET("/wallet/:walletAddress/stats", handlers.GetWalletStats)
+		api.GET("/wallet/:walletAddress/stats", reads, handlers.GetWalletStats)
+		api.POST("/wallets/stats", reads, handlers.GetBulkWalletStats)
+		api.GET("/wallet/:walletAddress/claims", reads, handlers.GetRewardClaims)
 
 		// Challenges (for local-prover)
-		api.GET("/challenges/request", handlers.RequestChallenge)
-		api.POST("/challenges/submit", handlers.SubmitChallenge)
+		api.GET("/challenges/request", writes, handlers.RequestChallenge)
+		api.POST("/challenges/commit", writes, handlers.CommitChallenge)
+		api.POST("/challenges/submit", writes, idempotent, handlers.SubmitChallenge)
+		api.POST("/nodes/:nodeId/heartbeat", writes, handlers.ProverHeartbeat)
+		api.POST("/challenges/:challengeId/validate", writes, OptionalAdminMiddleware(nonEmpty(opts.AdminAPIKeys)...), handlers.ValidateAnswer)
+		api.GET("/challenges/stream", writes, handlers.StreamChallenges)
+		api.GET("/server-key", handlers.GetServerKey)
+		api.GET("/server-keys", handlers.GetServerKeys)
 
 		// Direct verification (for exposed-rpc)
-		api.POST("/verify/:nodeId", handlers.VerifyNode)
-		api.GET("/verify/:nodeId/heartbeat", handlers.CheckHeartbeat)
+		api.POST("/verify/:nodeId", writes, handlers.VerifyNode)
+		api.GET("/verify/:nodeId/heartbeat", writes, handlers.CheckHeartbeat)
+		api.POST("/verify/:nodeId/storage", writes, handlers.CheckStorage)
+
+		// Tunnels, for exposed-rpc nodes without inbound ports (opened with
+		// the node's wallet signature)
+		api.GET("/tunnel", writes, handlers.OpenTunnel)
+		api.POST("/tunnel/replies", writes, handlers.TunnelReply)
 
 		// Public data
-		api.GET("/leaderboard", handlers.GetLeaderboard)
-		api.GET("/stats", handlers.GetNetworkStats)
+		api.GET("/leaderboard", reads, handlers.GetLeaderboard)
+		api.GET("/search", reads, handlers.SearchNodes)
+		api.GET("/network", reads, handlers.GetNetwork)
+		api.GET("/node-types", reads, handlers.GetNodeTypes)
+		api.GET("/regions", reads, handlers.GetRegionWeights)
+		api.GET("/stats", reads, handlers.GetNetworkStats)
+		api.GET("/hardforks", reads, handlers.GetHardForks)
+		api.GET("/transparency", reads, handlers.GetTransparency)
+		api.GET("/service-status", reads, handlers.GetServiceStatus)
+		api.GET("/announcements", reads, handlers.GetAnnouncements)
+
+		// API keys for heavy readers (created with the wallet's signature)
+		api.POST("/keys", writes, handlers.CreateAPIKey)
+		api.GET("/keys/:walletAddress", reads, handlers.GetAPIKeys)
+
+		// Two-factor for a wallet's signed actions (enrolling is signed by the wallet)
+		api.POST("/wallets/totp", writes, handlers.EnrollTOTP)
+		api.POST("/wallets/totp/confirm", writes, handlers.ConfirmTOTP)
+		api.POST("/wallets/totp/disable", writes, handlers.DisableTOTP)
+		api.GET("/wallet/:walletAddress/totp", reads, handlers.GetTOTPStatus)
+
+		// Community cheat reports (signed by the reporter's wallet)
+		api.POST("/reports", writes, handlers.FileReport)
 
 		// Admin endpoints (protected by API key)
-		admin := api.Group("/admin")
-		if adminAPIKey != "" {
-			admin.Use(AdminAuthMiddleware(adminAPIKey))
+		admin := api.Group("/admin", writes)
+		if keys := nonEmpty(opts.AdminAPIKeys); len(keys) > 0 {
+			admin.Use(AdminAuthMiddleware(keys...))
 		}
 		{
 			admin.GET("/flagged", handlers.GetFlaggedNodes)
 			admin.POST("/review/:nodeId", handlers.ReviewNode)
+			admin.GET("/pending-bans", handlers.GetPendingBans)
+			admin.GET("/moderation-log", handlers.GetModerationLog)
+			admin.GET("/verifications/:challengeId", handlers.GetVerificationReplay)
+			admin.GET("/verifications/:challengeId/attempts", handlers.GetSubmissionAttempts)
+			admin.GET("/fingerprints", handlers.GetFingerprintClusters)
+			admin.GET("/prover-builds", handlers.GetProverBuilds)
+			admin.GET("/features", handlers.GetVerificationFeatures)
+			admin.GET("/trust/:nodeId", handlers.GetTrustScore)
+			admin.GET("/metrics", handlers.GetMetrics)
+			admin.GET("/alerts", handlers.GetAlerts)
+			admin.GET("/store/usage", handlers.GetStoreUsage)
+			admin.GET("/store/snapshot", handlers.GetStoreSnapshot)
+			admin.GET("/snapshot", handlers.ExportStore)
+			admin.POST("/restore", handlers.RestoreStore)
+			admin.POST("/store/compact", handlers.CompactStore)
+			admin.POST("/config/reload", handlers.ReloadConfig)
+			admin.GET("/recompute", handlers.GetRecompute)
+			admin.POST("/recompute", handlers.StartRecompute)
+			admin.GET("/api-keys/usage", handlers.GetAPIUsage)
+			admin.POST("/api-keys/:keyId/plan", handlers.SetAPIKeyPlan)
+			admin.POST("/api-keys/:keyId/revoke", handlers.RevokeAPIKey)
+			admin.POST("/announcements", handlers.PostAnnouncement)
+			admin.POST("/announcements/:id/retract", handlers.RetractAnnouncement)
+			admin.GET("/maintenance", handlers.GetMaintenance)
+			admin.POST("/maintenance", handlers.StartMaintenance)
+			admin.POST("/maintenance/end", handlers.EndMaintenance)
+			admin.GET("/wallet-bans", handlers.GetWalletBans)
+			admin.POST("/wallet-bans/:walletAddress", handlers.BanWallet)
+			admin.POST("/wallet-bans/:walletAddress/lift", handlers.LiftWalletBan)
+			admin.POST("/wallets/:walletAddress/totp/reset", handlers.ResetTOTP)
+			admin.GET("/reclassifications", handlers.GetReclassifications)
+			admin.POST("/reclassifications/:nodeId", handlers.ResolveReclassification)
+			admin.POST("/points/:nodeId/reverse/:entryId", handlers.ReversePoints)
+			admin.POST("/points/:nodeId/adjust", handlers.AdjustPoints)
+			admin.GET("/epochs", handlers.GetEpochs)
+			admin.POST("/epochs", handlers.CloseEpoch)
+			admin.GET("/epochs/:epoch/export", handlers.ExportEpoch)
 			admin.POST("/test/create-node", handlers.TestCreateNode)
+
+			// Feature flags
+			admin.GET("/flags", handlers.GetFlags)
+			admin.POST("/flags/:name", handlers.SetFlag)
 		}
 	}
 
-	return router
+	return router, nil
+}
+
+func nonEmpty(items []string) []string {
+	var kept []string
+	for _, item := range items {
+		if item != "" {
+			kept = append(kept, item)
+		}
+	}
+	return kept
 }
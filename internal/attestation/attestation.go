@@ -0,0 +1,81 @@
+package attestation
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// Disk size buckets. Only the bucket leaves the machine.
+var diskClasses = []struct {
+	label string
+	maxGB uint64 // Exclusive
+}{
+	{"under-500gb", 500},
+	{"500gb-1tb", 1000},
+	{"1tb-2tb", 2000},
+	{"2tb-4tb", 4000},
+	{"4tb-8tb", 8000},
+	{"8tb-plus", ^uint64(0)},
+}
+
+// Which bucket a disk of this many bytes falls in
+func DiskClass(bytes uint64) string {
+	gb := bytes / 1e9
+	for _, c := range diskClasses {
+		if gb < c.maxGB {
+			return c.label
+		}
+	}
+	return diskClasses[len(diskClasses)-1].label
+}
+
+// Describe this machine. dataDir is the node's data directory, so we
+// measure the disk the chain actually lives on.
+func Collect(dataDir string) (types.HardwareReport, error) {
+	size, err := diskSize(dataDir)
+	if err != nil {
+		return types.HardwareReport{}, fmt.Errorf("measuring disk for %s: %v", dataDir, err)
+	}
+
+	return types.HardwareReport{
+		CPUCount:  runtime.NumCPU(),
+		DiskClass: DiskClass(size),
+		OS:        runtime.GOOS + "/" + runtime.GOARCH,
+	}, nil
+}
+
+// What the wallet signs. Tied to the registration so it can't be replayed
+// onto another wallet or node type.
+func Message(wallet string, nodeType types.NodeType, timestamp int64, r types.HardwareReport) string {
+	return fmt.Sprintf("Hardware attestation\nWallet: %s\nType: %s\nCPUs: %d\nDisk: %s\nOS: %s\nTimestamp: %d",
+		wallet, nodeType, r.CPUCount, r.DiskClass, r.OS, timestamp)
+}
+
+// Reasons the reported machine can't run this node type (empty if it can)
+func Check(nodeType types.NodeType, r types.HardwareReport) []string {
+	var problems []string
+
+	if r.CPUCount < nodeType.MinCPUs() {
+		problems = append(problems, fmt.Sprintf("%s needs at least %d CPUs, machine has %d", nodeType, nodeType.MinCPUs(), r.CPUCount))
+	}
+
+	maxGB, ok := diskClassMax(r.DiskClass)
+	if !ok {
+		problems = append(problems, fmt.Sprintf("unknown disk class %q", r.DiskClass))
+	} else if maxGB <= nodeType.MinDiskGB() {
+		problems = append(problems, fmt.Sprintf("%s needs a disk over %dGB, machine has %s", nodeType, nodeType.MinDiskGB(), r.DiskClass))
+	}
+
+	return problems
+}
+
+func diskClassMax(label string) (uint64, bool) {
+	for _, c := range diskClasses {
+		if c.label == label {
+			return c.maxGB, true
+		}
+	}
+	return 0, false
+}
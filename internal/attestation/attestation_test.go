@@ -0,0 +1,62 @@
+package attestation
+
+import (
+	"testing"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+func TestDiskClass(t *testing.T) {
+	tests := []struct {
+		bytes uint64
+		want  string
+	}{
+		{200e9, "under-500gb"},
+		{500e9, "500gb-1tb"},
+		{1.9e12, "1tb-2tb"},
+		{3.8e12, "2tb-4tb"},
+		{7.68e12, "4tb-8tb"},
+		{16e12, "8tb-plus"},
+	}
+
+	for _, tt := range tests {
+		if got := DiskClass(tt.bytes); got != tt.want {
+			t.Errorf("DiskClass(%d) = %s, want %s", tt.bytes, got, tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		nodeType types.NodeType
+		report   types.HardwareReport
+		problems int
+	}{
+		{"archive on a big disk", types.BscArchive, types.HardwareReport{CPUCount: 16, DiskClass: "4tb-8tb"}, 0},
+		{"archive on 200GB", types.BscArchive, types.HardwareReport{CPUCount: 16, DiskClass: "under-500gb"}, 1},
+		{"archive on a laptop", types.BscArchive, types.HardwareReport{CPUCount: 2, DiskClass: "under-500gb"}, 2},
+		{"full node just under 1TB", types.BscFull, types.HardwareReport{CPUCount: 8, DiskClass: "500gb-1tb"}, 1},
+		{"opbnb fast on a small box", types.OpbnbFast, types.HardwareReport{CPUCount: 4, DiskClass: "under-500gb"}, 0},
+		{"made up disk class", types.BscFast, types.HardwareReport{CPUCount: 4, DiskClass: "huge"}, 1},
+	}
+
+	for _, tt := range tests {
+		if problems := Check(tt.nodeType, tt.report); len(problems) != tt.problems {
+			t.Errorf("%s: expected %d problems, got %v", tt.name, tt.problems, problems)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	report, err := Collect(t.TempDir())
+	if err != nil {
+		t.Skipf("disk measurement unavailable: %v", err)
+	}
+	if report.CPUCount < 1 || report.OS == "" {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if _, ok := diskClassMax(report.DiskClass); !ok {
+		t.Errorf("collected unknown disk class %q", report.DiskClass)
+	}
+}
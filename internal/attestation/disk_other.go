@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package attestation
+
+import "errors"
+
+// Disk measurement is only implemented for Linux and macOS
+func diskSize(path string) (uint64, error) {
+	return 0, errors.New("not supported on this OS")
+}
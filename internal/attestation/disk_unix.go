@@ -0,0 +1,14 @@
+//go:build linux || darwin
+
+package attestation
+
+import "syscall"
+
+// Total size of the filesystem holding path
+func diskSize(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Blocks) * uint64(st.Bsize), nil
+}
@@ -0,0 +1,104 @@
+// Package budget caps how many challenges a node can use up per UTC day,
+// by type and in total, so polling harder can't be turned into more
+// passed challenges (and, once there are pass bonuses, more points).
+package budget
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// Spec key for the cap on all types together
+const All = "*"
+
+// Challenge types a cap can be set for
+var capped = map[types.ChallengeType]bool{
+	types.BlockHash:      true,
+	types.BlockData:      true,
+	types.StateBalance:   true,
+	types.TxReceipt:      true,
+	types.SyncStatus:     true,
+	types.ObjectExists:   true,
+	types.ObjectChecksum: true,
+	types.Composite:      true,
+}
+
+// Daily challenge caps. A type that isn't listed has no cap of its own.
+type Caps struct {
+	PerType map[types.ChallengeType]uint64
+	Total   uint64 // All types together, 0 = no cap
+}
+
+// Parse "block-hash=200,state-balance=100,*=500". Empty means no caps.
+func ParseCaps(spec string) (Caps, error) {
+	caps := Caps{PerType: make(map[types.ChallengeType]uint64)}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(entry, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		limit, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if !ok || err != nil || limit == 0 {
+			return Caps{}, fmt.Errorf("want type=count with a count above 0, got %q", entry)
+		}
+
+		switch {
+		case key == All:
+			caps.Total = limit
+		case capped[types.ChallengeType(key)]:
+			caps.PerType[types.ChallengeType(key)] = limit
+		default:
+			return Caps{}, fmt.Errorf("unknown challenge type %q in %q", key, entry)
+		}
+	}
+	return caps, nil
+}
+
+// Whether a node that has already been issued these challenges today may
+// be issued one more of this type
+func (c Caps) Allows(issued map[types.ChallengeType]uint64, challengeType types.ChallengeType) bool {
+	if limit, ok := c.PerType[challengeType]; ok && issued[challengeType] >= limit {
+		return false
+	}
+	return c.TotalLeft(issued)
+}
+
+// Whether the cap on all types together still has room
+func (c Caps) TotalLeft(issued map[types.ChallengeType]uint64) bool {
+	remaining, capped := c.Remaining(issued)
+	return !capped || remaining > 0
+}
+
+// How many more challenges the cap on all types together allows, and
+// whether there is one
+func (c Caps) Remaining(issued map[types.ChallengeType]uint64) (uint64, bool) {
+	if c.Total == 0 {
+		return 0, false
+	}
+	var total uint64
+	for _, n := range issued {
+		total += n
+	}
+	if total >= c.Total {
+		return 0, true
+	}
+	return c.Total - total, true
+}
+
+// The UTC day a unix ms time falls on, as YYYY-MM-DD
+func Day(timestamp int64) string {
+	return time.UnixMilli(timestamp).UTC().Format("2006-01-02")
+}
+
+// When the caps reset after a unix ms time: the next UTC midnight, in ms
+func NextDay(timestamp int64) int64 {
+	t := time.UnixMilli(timestamp).UTC()
+	return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC).UnixMilli()
+}
@@ -0,0 +1,70 @@
+package budget
+
+import (
+	"testing"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+func TestParseCaps(t *testing.T) {
+	caps, err := ParseCaps(" block-hash=200, State-Balance=100,*=500 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caps.PerType[types.BlockHash] != 200 || caps.PerType[types.StateBalance] != 100 || caps.Total != 500 {
+		t.Errorf("unexpected caps: %+v", caps)
+	}
+
+	if caps, err := ParseCaps(""); err != nil || len(caps.PerType) != 0 || caps.Total != 0 {
+		t.Errorf("empty spec should mean no caps, got %+v, %v", caps, err)
+	}
+
+	for _, spec := range []string{"block-hash", "block-hash=0", "block-hash=-1", "logs=10", "*=lots"} {
+		if _, err := ParseCaps(spec); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
+
+func TestAllows(t *testing.T) {
+	caps, _ := ParseCaps("block-hash=2,*=3")
+	issued := map[types.ChallengeType]uint64{types.BlockHash: 2}
+
+	if caps.Allows(issued, types.BlockHash) {
+		t.Error("block-hash cap is used up")
+	}
+	if !caps.Allows(issued, types.StateBalance) {
+		t.Error("state-balance has no cap of its own and the total has room")
+	}
+
+	issued[types.StateBalance] = 1
+	if caps.Allows(issued, types.SyncStatus) || caps.TotalLeft(issued) {
+		t.Error("total cap is used up")
+	}
+
+	if !(Caps{}).Allows(issued, types.BlockHash) {
+		t.Error("no caps should allow everything")
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	caps, _ := ParseCaps("*=3")
+	if left, capped := caps.Remaining(map[types.ChallengeType]uint64{types.BlockHash: 1}); left != 2 || !capped {
+		t.Errorf("expected 2 left, got %d %v", left, capped)
+	}
+	if left, _ := caps.Remaining(map[types.ChallengeType]uint64{types.BlockHash: 5}); left != 0 {
+		t.Errorf("going over the cap should leave 0, got %d", left)
+	}
+	if _, capped := (Caps{}).Remaining(nil); capped {
+		t.Error("no total cap should say so")
+	}
+}
+
+func TestDay(t *testing.T) {
+	if day := Day(1700000000000); day != "2023-11-14" {
+		t.Errorf("expected the UTC date, got %s", day)
+	}
+	if next := NextDay(1700000000000); next != 1700006400000 {
+		t.Errorf("expected the next UTC midnight, got %d", next)
+	}
+}
@@ -1,9 +1,13 @@
 package challenge
 
 import (
+	"log"
 	"math/rand"
+	"strings"
 	"time"
 
+	"github.com/depinonbnb/depin/internal/clock"
+	"github.com/depinonbnb/depin/internal/flags"
 	"github.com/depinonbnb/depin/internal/types"
 	"github.com/google/uuid"
 )
@@ -19,17 +23,42 @@ var knownAddresses = []string{
 	"0x7130d2A12B9BCbFAe4f2634d864A1Ee1Ce3Ead9c", // BTCB
 }
 
+// Same idea on BSC testnet
+var testnetAddresses = []string{
+	"0xae13d989daC2f0dEbFf460aC112a837C89BAa7cd", // WBNB
+	"0x337610d27c682E347C9cD60BD4b3b107C9d34dDd", // USDT
+	"0xeD24FC36d5Ee211Ea25A80239Fb8C4Cfd80f12Ee", // BUSD
+}
+
+// A public Greenfield object storage providers can be asked about
+type Object struct {
+	Bucket string
+	Name   string
+}
+
+// Roughly one object-exists challenge in this many asks about an object
+// that isn't there, so an SP can't just answer "yes" to everything
+const missingObjectOneIn = 3
+
 // Block ranges we can safely query
 type blockRange struct {
 	min          uint64
 	safeMax      uint64
 	recentWindow uint64
+
+	// Block 0's time and a steady block time, for telling when a block was
+	// produced. 0 = not steady enough to tell.
+	genesisTime  int64
+	blockSeconds uint64
 }
 
+// BSC made a block every 3 seconds from genesis until well past safeMax
 var bscBlockRanges = blockRange{
 	min:          1000000,
 	safeMax:      45000000,
 	recentWindow: 100,
+	genesisTime:  1598671449,
+	blockSeconds: 3,
 }
 
 var opbnbBlockRanges = blockRange{
@@ -38,62 +67,146 @@ var opbnbBlockRanges = blockRange{
 	recentWindow: 100,
 }
 
+// Testnets are reset and pruned more freely, so stay well inside them
+var bscTestnetBlockRanges = blockRange{
+	min:          100000,
+	safeMax:      40000000,
+	recentWindow: 100,
+}
+
+var opbnbTestnetBlockRanges = blockRange{
+	min:          1000,
+	safeMax:      40000000,
+	recentWindow: 100,
+}
+
 type Generator struct {
-	rng *rand.Rand
+	rng      *rand.Rand
+	flags    *flags.Flags
+	objects  []Object
+	network  types.Network
+	sampling Sampling
+	clock    clock.Clock
 }
 
 func NewGenerator() *Generator {
 	return &Generator{
-		rng: rand.New(rand.NewSource(time.Now().UnixNano())),
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		network:  types.Mainnet,
+		sampling: SamplingUniform,
+		clock:    clock.System{},
+	}
+}
+
+// Where challenge creation and expiry times come from
+func (g *Generator) SetClock(c clock.Clock) {
+	g.clock = c
+}
+
+// Gate challenge types behind feature flags ("challenge.<type>")
+func (g *Generator) SetFlags(f *flags.Flags) {
+	g.flags = f
+}
+
+// Pick block ranges and addresses for this network
+func (g *Generator) SetNetwork(network types.Network) {
+	g.network = network
+}
+
+// How blocks are picked for block and state challenges
+func (g *Generator) SetSampling(sampling Sampling) {
+	g.sampling = sampling
+}
+
+// Greenfield objects to challenge storage providers with, as
+// "bucket/object". Entries without a slash are skipped.
+func (g *Generator) SetObjects(objects []string) {
+	g.objects = g.objects[:0]
+	for _, o := range objects {
+		if bucket, name, ok := strings.Cut(o, "/"); ok && bucket != "" && name != "" {
+			g.objects = append(g.objects, Object{Bucket: bucket, Name: name})
+		}
 	}
 }
 
+// Flag name that controls a challenge type
+func FlagName(challengeType types.ChallengeType) string {
+	return "challenge." + string(challengeType)
+}
+
 func (g *Generator) getBlockRanges(nodeType types.NodeType) blockRange {
+	testnet := g.network == types.Testnet
 	switch nodeType {
 	case types.OpbnbFull, types.OpbnbFast:
+		if testnet {
+			return opbnbTestnetBlockRanges
+		}
 		return opbnbBlockRanges
 	default:
+		if testnet {
+			return bscTestnetBlockRanges
+		}
 		return bscBlockRanges
 	}
 }
 
-// Different node types can handle different challenges
-func (g *Generator) getAvailableChallengeTypes(nodeType types.NodeType) []types.ChallengeType {
-	switch nodeType {
-	case types.BscArchive:
-		// Archive nodes keep all historical state
-		return []types.ChallengeType{
-			types.BlockHash,
-			types.BlockData,
-			types.StateBalance,
-			types.SyncStatus,
-		}
-	case types.BscFull, types.OpbnbFull:
-		// Full nodes have block data but limited historical state
-		return []types.ChallengeType{
-			types.BlockHash,
-			types.BlockData,
-			types.SyncStatus,
-		}
-	default:
-		// Fast nodes only keep recent stuff
-		return []types.ChallengeType{
-			types.BlockHash,
-			types.SyncStatus,
-		}
+func (g *Generator) randomAddress() string {
+	addresses := knownAddresses
+	if g.network == types.Testnet {
+		addresses = testnetAddresses
 	}
+	return addresses[g.rng.Intn(len(addresses))]
 }
 
 func (g *Generator) randomBlockNumber(min, max uint64) uint64 {
 	return min + uint64(g.rng.Int63n(int64(max-min+1)))
 }
 
-// Generate a random challenge for a node
+// Drop challenge types whose flag is off for this node. Empty if every
+// one of them is off.
+func (g *Generator) filterByFlags(nodeID string, challengeTypes []types.ChallengeType) []types.ChallengeType {
+	if g.flags == nil {
+		return challengeTypes
+	}
+
+	enabled := make([]types.ChallengeType, 0, len(challengeTypes))
+	for _, ct := range challengeTypes {
+		if g.flags.EnabledFor(FlagName(ct), nodeID) {
+			enabled = append(enabled, ct)
+		}
+	}
+	return enabled
+}
+
+// When composite challenges are on for a node, roughly one challenge in
+// this many is a composite of compositeParts queries
+const (
+	compositeOneIn = 4
+	compositeParts = 3
+)
+
+// Generate a random challenge for a node. Nil if every challenge type the
+// node could get is flagged off for it: an admin's rollback is kept, not
+// worked around.
 func (g *Generator) GenerateChallenge(nodeID string, nodeType types.NodeType) *types.Challenge {
-	challengeTypes := g.getAvailableChallengeTypes(nodeType)
+	challengeTypes := g.filterByFlags(nodeID, nodeType.ChallengeTypes())
+	if len(challengeTypes) == 0 {
+		log.Printf("challenge: every challenge type for %s is flagged off for node %s, issuing nothing", nodeType, nodeID)
+		return nil
+	}
+
 	challengeType := challengeTypes[g.rng.Intn(len(challengeTypes))]
+	params := g.generateParams(challengeType, nodeType)
+	if g.compositeEnabled(nodeID, nodeType) && g.rng.Intn(compositeOneIn) == 0 {
+		challengeType = types.Composite
+		params = types.ChallengeParams{Parts: make([]types.ChallengePart, compositeParts)}
+		for i := range params.Parts {
+			partType := challengeTypes[g.rng.Intn(len(challengeTypes))]
+			params.Parts[i] = types.ChallengePart{ChallengeType: partType, Params: g.generateParams(partType, nodeType)}
+		}
+	}
 
-	now := time.Now().UnixMilli()
+	now := g.clock.Now().UnixMilli()
 	expiresIn := int64(60000) // 1 minute to answer
 
 	challenge := &types.Challenge{
@@ -102,18 +215,25 @@ func (g *Generator) GenerateChallenge(nodeID string, nodeType types.NodeType) *t
 		ChallengeType: challengeType,
 		CreatedAt:     now,
 		ExpiresAt:     now + expiresIn,
-		Params:        g.generateParams(challengeType, nodeType),
+		Params:        params,
 	}
 
 	return challenge
 }
 
+// Composites are off unless their flag is on for the node. Storage
+// providers don't get them.
+func (g *Generator) compositeEnabled(nodeID string, nodeType types.NodeType) bool {
+	return g.flags != nil && nodeType.Chain() != types.ChainGreenfield &&
+		g.flags.EnabledFor(FlagName(types.Composite), nodeID)
+}
+
 func (g *Generator) generateParams(challengeType types.ChallengeType, nodeType types.NodeType) types.ChallengeParams {
 	ranges := g.getBlockRanges(nodeType)
 
 	switch challengeType {
 	case types.BlockHash, types.BlockData:
-		blockNum := g.randomBlockNumber(ranges.min, ranges.safeMax)
+		blockNum := g.sampleBlock(ranges, nodeType, ranges.min, ranges.safeMax)
 		return types.ChallengeParams{
 			BlockNumber: &blockNum,
 		}
@@ -126,8 +246,8 @@ func (g *Generator) generateParams(challengeType types.ChallengeType, nodeType t
 		} else {
 			minBlock = ranges.safeMax - 10000
 		}
-		blockNum := g.randomBlockNumber(minBlock, ranges.safeMax)
-		address := knownAddresses[g.rng.Intn(len(knownAddresses))]
+		blockNum := g.sampleBlock(ranges, nodeType, minBlock, ranges.safeMax)
+		address := g.randomAddress()
 		return types.ChallengeParams{
 			BlockNumber: &blockNum,
 			Address:     address,
@@ -136,16 +256,60 @@ func (g *Generator) generateParams(challengeType types.ChallengeType, nodeType t
 	case types.SyncStatus:
 		return types.ChallengeParams{}
 
+	case types.ObjectExists, types.ObjectChecksum:
+		// No objects configured - the verifier refuses to send these
+		if len(g.objects) == 0 {
+			return types.ChallengeParams{}
+		}
+		object := g.objects[g.rng.Intn(len(g.objects))]
+		name := object.Name
+		if challengeType == types.ObjectExists && g.rng.Intn(missingObjectOneIn) == 0 {
+			name += ".missing-" + uuid.New().String()[:8]
+		}
+		return types.ChallengeParams{
+			Bucket: object.Bucket,
+			Object: name,
+		}
+
 	default:
 		return types.ChallengeParams{}
 	}
 }
 
-// Generate multiple challenges at once
+// How far past the start of the range honeypot blocks are picked from.
+// State this old is only kept by archive nodes, and most public RPCs
+// prune it too.
+const honeypotDepth = 1000000
+
+// A state query at a very old block. Only a real archive node can answer
+// it, so an answer from any other node type means it's proxying to
+// someone else's archive node.
+func (g *Generator) GenerateHoneypot(nodeID string, nodeType types.NodeType) *types.Challenge {
+	ranges := g.getBlockRanges(nodeType)
+	blockNum := g.randomBlockNumber(ranges.min, ranges.min+honeypotDepth)
+
+	now := g.clock.Now().UnixMilli()
+	return &types.Challenge{
+		ID:            uuid.New().String(),
+		NodeID:        nodeID,
+		ChallengeType: types.StateBalance,
+		CreatedAt:     now,
+		ExpiresAt:     now + 60000,
+		Params: types.ChallengeParams{
+			BlockNumber: &blockNum,
+			Address:     g.randomAddress(),
+		},
+	}
+}
+
+// Generate multiple challenges at once. Nil if the node's challenge types
+// are all flagged off.
 func (g *Generator) GenerateBatch(nodeID string, nodeType types.NodeType, count int) []*types.Challenge {
 	challenges := make([]*types.Challenge, count)
 	for i := 0; i < count; i++ {
-		challenges[i] = g.GenerateChallenge(nodeID, nodeType)
+		if challenges[i] = g.GenerateChallenge(nodeID, nodeType); challenges[i] == nil {
+			return nil
+		}
 	}
 	return challenges
 }
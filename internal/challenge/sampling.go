@@ -0,0 +1,77 @@
+package challenge
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// How challenge blocks are picked from their range
+type Sampling string
+
+const (
+	// Every block in the range equally likely
+	SamplingUniform Sampling = "uniform"
+	// Weighted toward blocks a public RPC is unlikely to have cached, so a
+	// node proxying to one has to wait on a cold lookup: no round heights,
+	// quiet hours twice as likely, and archives asked about the oldest
+	// quarter of history half the time
+	SamplingHard Sampling = "hard"
+)
+
+func ParseSampling(s string) (Sampling, error) {
+	switch Sampling(s) {
+	case SamplingUniform, SamplingHard:
+		return Sampling(s), nil
+	}
+	return "", fmt.Errorf("want %s or %s, got %q", SamplingUniform, SamplingHard, s)
+}
+
+// When BSC is quietest (UTC, start inclusive): night in Asia, where most
+// of its traffic comes from. Blocks from then are the least queried.
+const (
+	quietFromHour = 17
+	quietToHour   = 23
+)
+
+// Heights divisible by this are what explorers, dashboards and tutorials
+// ask for, so they're warm in every cache
+const roundHeight = 10
+
+// Draws before settling for whatever came up, so a tiny range can't spin
+const hardDraws = 8
+
+// A block between min and max (inclusive) per the generator's sampling
+func (g *Generator) sampleBlock(ranges blockRange, nodeType types.NodeType, min, max uint64) uint64 {
+	if g.sampling != SamplingHard {
+		return g.randomBlockNumber(min, max)
+	}
+
+	if nodeType == types.BscArchive && g.rng.Intn(2) == 0 {
+		max = min + (max-min)/4
+	}
+	var block uint64
+	for i := 0; i < hardDraws; i++ {
+		block = g.randomBlockNumber(min, max)
+		if block%roundHeight == 0 {
+			continue
+		}
+		if !ranges.quiet(block) && g.rng.Intn(2) == 0 {
+			continue
+		}
+		break
+	}
+	return block
+}
+
+// Whether block was produced in the quiet hours. Only known where the
+// range has a steady block time; elsewhere every block counts as quiet.
+func (r blockRange) quiet(block uint64) bool {
+	if r.blockSeconds == 0 {
+		return true
+	}
+	produced := time.Unix(r.genesisTime+int64(block*r.blockSeconds), 0).UTC()
+	hour := produced.Hour()
+	return hour >= quietFromHour && hour < quietToHour
+}
@@ -0,0 +1,54 @@
+package challenge
+
+import (
+	"testing"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+func TestHardSampling(t *testing.T) {
+	g := NewGenerator()
+	g.SetSampling(SamplingHard)
+	ranges := g.getBlockRanges(types.BscFull)
+
+	const draws = 4000
+	var round, quiet int
+	for i := 0; i < draws; i++ {
+		block := g.sampleBlock(ranges, types.BscFull, ranges.min, ranges.safeMax)
+		if block < ranges.min || block > ranges.safeMax {
+			t.Fatalf("block %d outside the range", block)
+		}
+		if block%roundHeight == 0 {
+			round++
+		}
+		if ranges.quiet(block) {
+			quiet++
+		}
+	}
+	// Quiet hours are a quarter of the day, so about 2/5 once doubled
+	if round > draws/100 || quiet < draws*3/10 {
+		t.Errorf("expected few round heights and more quiet blocks, got %d round and %d quiet of %d", round, quiet, draws)
+	}
+
+	// Archives go deep half the time
+	deep := 0
+	quarter := ranges.min + (ranges.safeMax-ranges.min)/4
+	for i := 0; i < draws; i++ {
+		if g.sampleBlock(ranges, types.BscArchive, ranges.min, ranges.safeMax) <= quarter {
+			deep++
+		}
+	}
+	if deep < draws/2 {
+		t.Errorf("expected most archive blocks from the oldest quarter, got %d of %d", deep, draws)
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	// Block 0 was produced at 03:24 UTC; 14 hours of 3-second blocks later it's 17:24
+	if bscBlockRanges.quiet(0) || !bscBlockRanges.quiet(14*1200) {
+		t.Error("expected block 0 outside the quiet hours and 14 hours on inside them")
+	}
+	if !opbnbBlockRanges.quiet(12345) {
+		t.Error("blocks of ranges without a steady block time all count as quiet")
+	}
+}
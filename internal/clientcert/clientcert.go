@@ -0,0 +1,121 @@
+// Package clientcert keeps the client certificates exposed-rpc nodes give
+// us, for operators whose RPC only accepts mTLS. The cert and key are
+// sealed with a server key (AES-256-GCM) before they're stored, and only
+// opened to make a connection.
+package clientcert
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+var (
+	ErrNoKey      = errors.New("this server doesn't take client certificates")
+	ErrInvalid    = errors.New("client certificate and key don't parse or don't match")
+	ErrExpired    = errors.New("client certificate has expired")
+	ErrNotClient  = errors.New("certificate isn't for client authentication")
+	ErrUnreadable = errors.New("stored client certificate can't be opened with this server's key")
+)
+
+// Seals and opens client certificates with one server key
+type Sealer struct {
+	aead cipher.AEAD
+}
+
+// A sealer for a 32-byte hex key (CLIENT_CERT_KEY)
+func NewSealer(hexKey string) (*Sealer, error) {
+	key, err := hex.DecodeString(strings.TrimPrefix(hexKey, "0x"))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("want 32 bytes of hex, e.g. from openssl rand -hex 32")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Sealer{aead: aead}, nil
+}
+
+// Check a PEM cert and key belong together, are in date and are meant for
+// client auth, then seal them. A nil Sealer refuses every cert.
+func (s *Sealer) Seal(certPEM, keyPEM string, now time.Time) (*types.ClientCert, error) {
+	if s == nil {
+		return nil, ErrNoKey
+	}
+
+	pair, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	if now.After(leaf.NotAfter) {
+		return nil, ErrExpired
+	}
+	if !forClientAuth(leaf) {
+		return nil, ErrNotClient
+	}
+
+	// X509KeyPair picks the blocks it needs out of either, so both go in one
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := s.aead.Seal(nonce, nonce, []byte(strings.TrimSpace(certPEM)+"\n"+strings.TrimSpace(keyPEM)+"\n"), nil)
+
+	fingerprint := sha256.Sum256(leaf.Raw)
+	return &types.ClientCert{
+		Sealed:      base64.StdEncoding.EncodeToString(sealed),
+		Subject:     leaf.Subject.String(),
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+		NotAfter:    leaf.NotAfter.UnixMilli(),
+	}, nil
+}
+
+// The cert and key, ready to present
+func (s *Sealer) Open(cert *types.ClientCert) (tls.Certificate, error) {
+	if s == nil {
+		return tls.Certificate{}, ErrNoKey
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(cert.Sealed)
+	if err != nil || len(sealed) < s.aead.NonceSize() {
+		return tls.Certificate{}, ErrUnreadable
+	}
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	pem, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return tls.Certificate{}, ErrUnreadable
+	}
+	return tls.X509KeyPair(pem, pem)
+}
+
+// A cert without extended key usages is good for anything
+func forClientAuth(cert *x509.Certificate) bool {
+	if len(cert.ExtKeyUsage) == 0 {
+		return true
+	}
+	for _, usage := range cert.ExtKeyUsage {
+		if usage == x509.ExtKeyUsageClientAuth || usage == x509.ExtKeyUsageAny {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package clientcert
+
+import (
+	"crypto/x509"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/mockchain"
+)
+
+const testKey = "6b9c3c2f1e0d4a5b8c7d6e5f4a3b2c1d0e9f8a7b6c5d4e3f2a1b0c9d8e7f6a5b"
+
+func TestSealOpen(t *testing.T) {
+	sealer, err := NewSealer(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notAfter := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	certPEM, keyPEM := mockchain.Certificate("node-a", notAfter, x509.ExtKeyUsageClientAuth)
+
+	cert, err := sealer.Seal(certPEM, keyPEM, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.Subject != "CN=node-a" || cert.NotAfter != notAfter.UnixMilli() || len(cert.Fingerprint) != 64 {
+		t.Errorf("unexpected cert details: %+v", cert)
+	}
+	if strings.Contains(cert.Sealed, "PRIVATE KEY") {
+		t.Error("the key should be stored sealed")
+	}
+
+	pair, err := sealer.Open(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair.PrivateKey == nil || len(pair.Certificate) != 1 {
+		t.Error("opened pair should have the cert and key")
+	}
+
+	// Another key can't open it
+	other, _ := NewSealer(strings.Repeat("ab", 32))
+	if _, err := other.Open(cert); !errors.Is(err, ErrUnreadable) {
+		t.Errorf("expected ErrUnreadable with the wrong key, got %v", err)
+	}
+}
+
+func TestSealRefuses(t *testing.T) {
+	sealer, _ := NewSealer(testKey)
+	now := time.Now()
+	certPEM, keyPEM := mockchain.Certificate("node-a", now.Add(time.Hour))
+	_, otherKey := mockchain.Certificate("node-b", now.Add(time.Hour))
+	expiredCert, expiredKey := mockchain.Certificate("node-a", now.Add(time.Hour))
+	serverCert, serverKey := mockchain.Certificate("node-a", now.Add(time.Hour), x509.ExtKeyUsageServerAuth)
+
+	tests := []struct {
+		name         string
+		certPEM, key string
+		now          time.Time
+		want         error
+	}{
+		{"key from another cert", certPEM, otherKey, now, ErrInvalid},
+		{"not PEM", "hello", "world", now, ErrInvalid},
+		{"expired", expiredCert, expiredKey, now.Add(2 * time.Hour), ErrExpired},
+		{"server only", serverCert, serverKey, now, ErrNotClient},
+	}
+	for _, tt := range tests {
+		if _, err := sealer.Seal(tt.certPEM, tt.key, tt.now); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	var off *Sealer
+	if _, err := off.Seal(certPEM, keyPEM, now); !errors.Is(err, ErrNoKey) {
+		t.Errorf("a nil sealer should refuse certs, got %v", err)
+	}
+}
+
+func TestNewSealerKeyLength(t *testing.T) {
+	for _, key := range []string{"", "abcd", strings.Repeat("zz", 32)} {
+		if _, err := NewSealer(key); err == nil {
+			t.Errorf("expected an error for key %q", key)
+		}
+	}
+	if _, err := NewSealer("0x" + testKey); err != nil {
+		t.Errorf("0x prefix should be allowed: %v", err)
+	}
+}
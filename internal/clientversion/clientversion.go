@@ -0,0 +1,126 @@
+package clientversion
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// A node client and release, parsed from web3_clientVersion. BSC and
+// opBNB both report as Geth ("Geth/v1.4.15-5e6b9b7c/linux-amd64/go1.21.12"),
+// Erigon builds as "erigon/2.60.1/linux-amd64/go1.22.3".
+type Version struct {
+	Client string // Lowercase, e.g. "geth"
+	Major  int
+	Minor  int
+	Patch  int
+}
+
+// Parse a web3_clientVersion string. ok is false for anything that doesn't
+// look like client/version.
+func Parse(raw string) (Version, bool) {
+	parts := strings.Split(strings.TrimSpace(raw), "/")
+	if len(parts) < 2 || parts[0] == "" {
+		return Version{}, false
+	}
+
+	release, ok := parseRelease(parts[1])
+	if !ok {
+		return Version{}, false
+	}
+	release.Client = strings.ToLower(parts[0])
+	return release, true
+}
+
+// "v1.4.15-5e6b9b7c" -> 1.4.15. Missing minor/patch count as 0.
+func parseRelease(raw string) (Version, bool) {
+	raw = strings.TrimPrefix(strings.ToLower(raw), "v")
+	if i := strings.IndexAny(raw, "-+"); i >= 0 {
+		raw = raw[:i]
+	}
+
+	var v Version
+	fields := strings.Split(raw, ".")
+	if len(fields) > 3 {
+		return v, false
+	}
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		*nums[i] = n
+	}
+	return v, true
+}
+
+// "geth/1.4.15"
+func (v Version) String() string {
+	return fmt.Sprintf("%s/%d.%d.%d", v.Client, v.Major, v.Minor, v.Patch)
+}
+
+// Older release than other (clients aren't compared)
+func (v Version) Less(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor < other.Minor
+	}
+	return v.Patch < other.Patch
+}
+
+// Lowest release each client may run on each chain, keyed "chain/client"
+type Minimums map[string]Version
+
+// Parse "bsc/geth=1.4.15,opbnb/geth=0.5.3". Empty means no minimums.
+func ParseMinimums(spec string) (Minimums, error) {
+	mins := make(Minimums)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, release, ok := strings.Cut(entry, "=")
+		chain, client, hasClient := strings.Cut(strings.ToLower(strings.TrimSpace(key)), "/")
+		if !ok || !hasClient || chain == "" || client == "" {
+			return nil, fmt.Errorf("want chain/client=version, got %q", entry)
+		}
+		if chain != string(types.ChainBSC) && chain != string(types.ChainOpBNB) {
+			return nil, fmt.Errorf("unknown chain %q in %q", chain, entry)
+		}
+
+		min, ok := parseRelease(strings.TrimSpace(release))
+		if !ok {
+			return nil, fmt.Errorf("bad version in %q", entry)
+		}
+		min.Client = client
+		mins[chain+"/"+client] = min
+	}
+	return mins, nil
+}
+
+// The minimum a node on this chain running this client has to meet, if
+// there is one
+func (m Minimums) For(chain types.Chain, v Version) (Version, bool) {
+	min, ok := m[string(chain)+"/"+v.Client]
+	return min, ok
+}
+
+// Whether a raw client version is below the minimum for its chain. Clients
+// we can't parse or have no minimum for are never outdated.
+func (m Minimums) Outdated(chain types.Chain, raw string) (Version, bool) {
+	v, ok := Parse(raw)
+	if !ok {
+		return Version{}, false
+	}
+	min, ok := m.For(chain, v)
+	if !ok {
+		return Version{}, false
+	}
+	return min, v.Less(min)
+}
@@ -0,0 +1,74 @@
+package clientversion
+
+import (
+	"testing"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{"Geth/v1.4.15-5e6b9b7c/linux-amd64/go1.21.12", "geth/1.4.15", true},
+		{"Geth/v0.5.3-stable-f3a1c2/linux-amd64/go1.21", "geth/0.5.3", true},
+		{"erigon/2.60.1/linux-amd64/go1.22.3", "erigon/2.60.1", true},
+		{"reth/v1.0", "reth/1.0.0", true},
+		{"Geth/vNext/linux", "", false},
+		{"Geth", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		v, ok := Parse(tt.raw)
+		if ok != tt.ok {
+			t.Errorf("%q: ok = %v, want %v", tt.raw, ok, tt.ok)
+			continue
+		}
+		if ok && v.String() != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.raw, v, tt.want)
+		}
+	}
+}
+
+func TestOutdated(t *testing.T) {
+	mins, err := ParseMinimums("bsc/geth=1.4.15, opbnb/geth=0.5.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		chain    types.Chain
+		raw      string
+		outdated bool
+	}{
+		{types.ChainBSC, "Geth/v1.4.14-abc/linux-amd64/go1.21", true},
+		{types.ChainBSC, "Geth/v1.4.15-abc/linux-amd64/go1.21", false},
+		{types.ChainBSC, "Geth/v1.5.0/linux-amd64/go1.21", false},
+		{types.ChainBSC, "Geth/v1.3.99/linux-amd64/go1.21", true},
+		{types.ChainOpBNB, "Geth/v0.5.2/linux-amd64/go1.21", true},
+		{types.ChainBSC, "erigon/1.0.0/linux-amd64/go1.22", false}, // No minimum for erigon
+		{types.ChainBSC, "garbage", false},
+	}
+
+	for _, tt := range tests {
+		if _, outdated := mins.Outdated(tt.chain, tt.raw); outdated != tt.outdated {
+			t.Errorf("%s %q: outdated = %v, want %v", tt.chain, tt.raw, outdated, tt.outdated)
+		}
+	}
+}
+
+func TestParseMinimumsErrors(t *testing.T) {
+	for _, spec := range []string{"geth=1.4.15", "bsc/geth", "bsc/geth=one", "greenfield/sp=1.0.0"} {
+		if _, err := ParseMinimums(spec); err == nil {
+			t.Errorf("%q should be rejected", spec)
+		}
+	}
+
+	mins, err := ParseMinimums("")
+	if err != nil || len(mins) != 0 {
+		t.Errorf("empty spec should mean no minimums, got %v, %v", mins, err)
+	}
+}
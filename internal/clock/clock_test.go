@@ -0,0 +1,34 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := NewFake(start)
+	if !c.Now().Equal(start) {
+		t.Fatalf("expected %v, got %v", start, c.Now())
+	}
+	if !c.Now().Equal(start) {
+		t.Error("a fake clock shouldn't move on its own")
+	}
+
+	c.Advance(90 * time.Second)
+	if got := c.Now().Sub(start); got != 90*time.Second {
+		t.Errorf("expected to be 90s on, got %v", got)
+	}
+
+	c.Set(start)
+	if !c.Now().Equal(start) {
+		t.Errorf("expected the clock set back, got %v", c.Now())
+	}
+}
+
+func TestSystem(t *testing.T) {
+	before := time.Now()
+	if now := (System{}).Now(); now.Before(before) || now.Sub(before) > time.Second {
+		t.Errorf("system clock off: %v vs %v", now, before)
+	}
+}
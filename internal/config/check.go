@@ -0,0 +1,198 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/depinonbnb/depin/internal/rpc"
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// How one check went. Only failures make check-config exit nonzero;
+// warnings are for settings that work but probably aren't what production
+// wants.
+type CheckStatus string
+
+const (
+	CheckOK   CheckStatus = "ok"
+	CheckWarn CheckStatus = "warn"
+	CheckFail CheckStatus = "fail"
+)
+
+type CheckResult struct {
+	Setting string
+	Status  CheckStatus
+	Detail  string
+}
+
+// What check-config found, in the order it looked
+type CheckReport struct {
+	Results []CheckResult
+}
+
+func (r *CheckReport) add(setting string, status CheckStatus, format string, args ...interface{}) {
+	r.Results = append(r.Results, CheckResult{Setting: setting, Status: status, Detail: fmt.Sprintf(format, args...)})
+}
+
+// Whether anything would stop the server from working
+func (r *CheckReport) Failed() bool {
+	for _, result := range r.Results {
+		if result.Status == CheckFail {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *CheckReport) String() string {
+	var b strings.Builder
+	failed, warned := 0, 0
+	for _, result := range r.Results {
+		fmt.Fprintf(&b, "  %-4s  %s: %s\n", strings.ToUpper(string(result.Status)), result.Setting, result.Detail)
+		switch result.Status {
+		case CheckFail:
+			failed++
+		case CheckWarn:
+			warned++
+		}
+	}
+	if failed > 0 {
+		fmt.Fprintf(&b, "\nconfig check failed: %d problem(s), %d warning(s)\n", failed, warned)
+	} else {
+		fmt.Fprintf(&b, "\nconfig OK (%d warning(s))\n", warned)
+	}
+	return b.String()
+}
+
+// Check loads the config from the environment, like the server would, and
+// then tries it: the trusted RPCs have to answer and be on the right
+// chain, admin endpoints have to be protected, and so on. For deploy
+// pipelines, so a bad config fails the deploy rather than the server.
+func Check() *CheckReport {
+	return check(os.Getenv)
+}
+
+func check(getenv func(string) string) *CheckReport {
+	report := &CheckReport{}
+
+	cfg, err := load(getenv)
+	if err != nil {
+		var invalid *ValidationError
+		if !errors.As(err, &invalid) {
+			report.add("config", CheckFail, "%v", err)
+			return report
+		}
+		for _, problem := range invalid.Problems {
+			env, detail, _ := strings.Cut(problem, ": ")
+			report.add(env, CheckFail, "%s", detail)
+		}
+		// The rest needs a config that loads
+		return report
+	}
+	report.add("settings", CheckOK, "all %d parse and are consistent", len(Settings))
+
+	t := cfg.Thresholds
+	report.add("thresholds", CheckOK, "suspicious over %dms, failed over %dms, warning after %d events, flagged after %d",
+		t.LatencySuspiciousMs, t.LatencyMaxMs, t.WarningThreshold, t.FlagThreshold)
+
+	// Only one backend so far; nothing to connect to
+	report.add("store", CheckOK, "in-memory, nothing to connect to (data doesn't survive a restart)")
+
+	cfg.checkAdminKeys(report)
+	cfg.checkTrusted(report)
+
+	if cfg.Signing.Key == "" {
+		report.add("SERVER_SIGNING_KEY", CheckWarn, "unset, so challenges aren't signed and provers can't tell them from forged ones")
+	}
+	if cfg.GinMode == "debug" {
+		report.add("GIN_MODE", CheckWarn, "debug logs every route at startup; use release in production")
+	}
+	return report
+}
+
+// Short keys are guessable; these guard bans and point reversals
+const minAdminKeyLen = 16
+
+func (c *Config) checkAdminKeys(report *CheckReport) {
+	keys := c.AdminAPIKeys()
+	if len(keys) == 0 {
+		report.add("ADMIN_API_KEY", CheckFail, "unset, so admin endpoints are unprotected")
+		return
+	}
+
+	short := 0
+	for _, key := range keys {
+		if len(key) < minAdminKeyLen {
+			short++
+		}
+	}
+	if short > 0 {
+		report.add("ADMIN_API_KEY", CheckWarn, "%d of %d keys shorter than %d characters", short, len(keys), minAdminKeyLen)
+		return
+	}
+	report.add("ADMIN_API_KEY", CheckOK, "%d key(s)", len(keys))
+}
+
+// Every RPC and SP expected answers come from has to be up and on the
+// chain this network means
+func (c *Config) checkTrusted(report *CheckReport) {
+	c.checkRPC(report, "TRUSTED_RPC", c.TrustedRPC, types.ChainBSC, CheckFail)
+	c.checkRPC(report, "TRUSTED_OPBNB_RPC", c.TrustedOpbnbRPC, types.ChainOpBNB, CheckFail)
+
+	sp := rpc.NewGreenfieldClient(c.TrustedGreenfieldSP, "")
+	if _, err := sp.GetStatus(); err != nil {
+		report.add("TRUSTED_GREENFIELD_SP", CheckFail, "%s unreachable: %v", c.TrustedGreenfieldSP, err)
+	} else {
+		report.add("TRUSTED_GREENFIELD_SP", CheckOK, "%s is up", c.TrustedGreenfieldSP)
+		for _, object := range c.GreenfieldObjects {
+			bucket, name, _ := strings.Cut(object, "/")
+			if _, _, err := sp.GetObjectMeta(bucket, name); err != nil {
+				report.add("GREENFIELD_OBJECTS", CheckFail, "%s: %v", object, err)
+			}
+		}
+	}
+	if len(c.GreenfieldObjects) == 0 {
+		report.add("GREENFIELD_OBJECTS", CheckWarn, "unset, so greenfield-sp nodes get no challenges")
+	}
+
+	if len(c.HeaderChainRPCs) > 0 {
+		reachable := 0
+		for _, endpoint := range c.HeaderChainRPCs {
+			if c.checkRPC(report, "HEADER_CHAIN_RPCS", endpoint, types.ChainBSC, CheckWarn) {
+				reachable++
+			}
+		}
+		if uint64(reachable) < c.HeaderChainQuorum {
+			report.add("HEADER_CHAIN_QUORUM", CheckFail, "only %d of %d HEADER_CHAIN_RPCS work, quorum needs %d", reachable, len(c.HeaderChainRPCs), c.HeaderChainQuorum)
+		}
+	}
+
+	// Latency probes only; one being down doesn't stop anything
+	for _, provider := range c.PublicProviders {
+		c.checkRPC(report, "PUBLIC_RPC_PROVIDERS", provider, types.ChainBSC, CheckWarn)
+	}
+}
+
+// Ask an RPC for its chain ID and head. A problem is reported with the
+// given status; returns whether there was none.
+func (c *Config) checkRPC(report *CheckReport, env, endpoint string, chain types.Chain, problem CheckStatus) bool {
+	client := rpc.NewTrustedClient(endpoint).WithChain(chain)
+	chainID, _, err := client.GetChainID()
+	if err != nil {
+		report.add(env, problem, "%s unreachable: %v", endpoint, err)
+		return false
+	}
+	if want := chain.ChainID(c.Network); chainID != want {
+		report.add(env, problem, "%s is on chain %d, %s %s is chain %d", endpoint, chainID, c.Network, chain, want)
+		return false
+	}
+	head, _, err := client.GetBlockNumber()
+	if err != nil {
+		report.add(env, problem, "%s unreachable: %v", endpoint, err)
+		return false
+	}
+	report.add(env, CheckOK, "%s on chain %d, head #%d", endpoint, chainID, head)
+	return true
+}
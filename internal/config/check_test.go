@@ -0,0 +1,138 @@
+package config
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/depinonbnb/depin/internal/mockchain"
+)
+
+// An environment pointing every trusted endpoint at mocks on the right
+// chains, with nothing to warn about
+func checkEnv(t *testing.T) map[string]string {
+	bsc := httptest.NewServer(mockchain.New(46000000))
+	t.Cleanup(bsc.Close)
+	opbnbChain := mockchain.New(50000000)
+	opbnbChain.SetChainID(204)
+	opbnb := httptest.NewServer(opbnbChain)
+	t.Cleanup(opbnb.Close)
+	spMock := mockchain.NewSP()
+	spMock.AddObject("bucket", "file.bin")
+	sp := httptest.NewServer(spMock)
+	t.Cleanup(sp.Close)
+
+	return map[string]string{
+		"TRUSTED_RPC":           bsc.URL,
+		"TRUSTED_OPBNB_RPC":     opbnb.URL,
+		"TRUSTED_GREENFIELD_SP": sp.URL,
+		"GREENFIELD_OBJECTS":    "bucket/file.bin",
+		"PUBLIC_RPC_PROVIDERS":  bsc.URL,
+		"ADMIN_API_KEY":         "a-long-enough-admin-key",
+		"SERVER_SIGNING_KEY":    "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318",
+		"GIN_MODE":              "release",
+	}
+}
+
+// The results for one setting, joined
+func resultsFor(report *CheckReport, setting string) (CheckStatus, string) {
+	status, details := CheckOK, []string{}
+	for _, result := range report.Results {
+		if result.Setting != setting {
+			continue
+		}
+		if result.Status == CheckFail || (result.Status == CheckWarn && status == CheckOK) {
+			status = result.Status
+		}
+		details = append(details, result.Detail)
+	}
+	return status, strings.Join(details, "; ")
+}
+
+func TestCheckPasses(t *testing.T) {
+	report := check(envFrom(checkEnv(t)))
+
+	if report.Failed() {
+		t.Fatalf("expected a clean report, got:\n%s", report)
+	}
+	for _, result := range report.Results {
+		if result.Status != CheckOK {
+			t.Errorf("unexpected %s for %s: %s", result.Status, result.Setting, result.Detail)
+		}
+	}
+	if _, detail := resultsFor(report, "TRUSTED_RPC"); !strings.Contains(detail, "head #46000000") {
+		t.Errorf("expected the trusted RPC's head in the report, got %q", detail)
+	}
+}
+
+func TestCheckFindsProblems(t *testing.T) {
+	env := checkEnv(t)
+	wrongChain := mockchain.New(46000000)
+	wrongChain.SetChainID(97)
+	testnet := httptest.NewServer(wrongChain)
+	defer testnet.Close()
+	down := httptest.NewServer(mockchain.New(1))
+	down.Close()
+
+	env["TRUSTED_RPC"] = testnet.URL
+	env["PUBLIC_RPC_PROVIDERS"] = down.URL
+	env["GREENFIELD_OBJECTS"] = "bucket/missing.bin"
+	env["ADMIN_API_KEY"] = ""
+	env["GIN_MODE"] = "debug"
+	report := check(envFrom(env))
+
+	if !report.Failed() {
+		t.Fatalf("expected the check to fail, got:\n%s", report)
+	}
+	tests := []struct {
+		setting string
+		status  CheckStatus
+		detail  string
+	}{
+		{"TRUSTED_RPC", CheckFail, "is on chain 97, mainnet bsc is chain 56"},
+		{"GREENFIELD_OBJECTS", CheckFail, "bucket/missing.bin"},
+		{"ADMIN_API_KEY", CheckFail, "unprotected"},
+		{"PUBLIC_RPC_PROVIDERS", CheckWarn, "unreachable"},
+		{"GIN_MODE", CheckWarn, "release"},
+		{"TRUSTED_OPBNB_RPC", CheckOK, "chain 204"},
+	}
+	for _, tt := range tests {
+		status, detail := resultsFor(report, tt.setting)
+		if status != tt.status || !strings.Contains(detail, tt.detail) {
+			t.Errorf("%s: expected %s mentioning %q, got %s: %s", tt.setting, tt.status, tt.detail, status, detail)
+		}
+	}
+	if !strings.Contains(report.String(), "config check failed: 3 problem(s)") {
+		t.Errorf("expected a failure summary, got:\n%s", report)
+	}
+}
+
+func TestCheckHeaderChainQuorum(t *testing.T) {
+	env := checkEnv(t)
+	down := httptest.NewServer(mockchain.New(1))
+	down.Close()
+	env["HEADER_CHAIN_RPCS"] = env["TRUSTED_RPC"] + "," + down.URL
+	env["HEADER_CHAIN_QUORUM"] = "2"
+
+	status, detail := resultsFor(check(envFrom(env)), "HEADER_CHAIN_QUORUM")
+	if status != CheckFail || !strings.Contains(detail, "only 1 of 2") {
+		t.Errorf("expected quorum to fail with one RPC down, got %s: %s", status, detail)
+	}
+}
+
+func TestCheckInvalidConfig(t *testing.T) {
+	report := check(envFrom(map[string]string{"PORT": "0", "LATENCY_SUSPICIOUS_MS": "6000"}))
+
+	if !report.Failed() {
+		t.Fatal("an invalid config should fail the check")
+	}
+	for _, setting := range []string{"PORT", "LATENCY_MAX_MS"} {
+		if status, _ := resultsFor(report, setting); status != CheckFail {
+			t.Errorf("expected %s to fail, got %s", setting, status)
+		}
+	}
+	// Nothing is contacted with a config the server wouldn't start with
+	if status, detail := resultsFor(report, "TRUSTED_RPC"); detail != "" {
+		t.Errorf("expected no connection checks, got %s: %s", status, detail)
+	}
+}
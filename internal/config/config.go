@@ -0,0 +1,821 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/depinonbnb/depin/internal/anomaly"
+	"github.com/depinonbnb/depin/internal/budget"
+	"github.com/depinonbnb/depin/internal/challenge"
+	"github.com/depinonbnb/depin/internal/clientcert"
+	"github.com/depinonbnb/depin/internal/clientversion"
+	"github.com/depinonbnb/depin/internal/diagnostics"
+	"github.com/depinonbnb/depin/internal/flags"
+	"github.com/depinonbnb/depin/internal/hardfork"
+	"github.com/depinonbnb/depin/internal/proverbuild"
+	"github.com/depinonbnb/depin/internal/ratelimit"
+	"github.com/depinonbnb/depin/internal/rates"
+	"github.com/depinonbnb/depin/internal/signing"
+	"github.com/depinonbnb/depin/internal/staking"
+	"github.com/depinonbnb/depin/internal/store"
+	"github.com/depinonbnb/depin/internal/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Defaults that differ when NETWORK=testnet
+var testnetDefaults = map[string]string{
+	"TRUSTED_RPC":           "https://data-seed-prebsc-1-s1.bnbchain.org:8545",
+	"TRUSTED_OPBNB_RPC":     "https://opbnb-testnet-rpc.bnbchain.org",
+	"TRUSTED_GREENFIELD_SP": "https://gnfd-testnet-sp1.bnbchain.org",
+	"PUBLIC_RPC_PROVIDERS":  "https://data-seed-prebsc-1-s1.bnbchain.org:8545,https://data-seed-prebsc-2-s1.bnbchain.org:8545,https://bsc-testnet-rpc.publicnode.com",
+}
+
+// Every server setting lives here, with its default and what it does.
+// Settings marked Reloadable can be changed on a running server (SIGHUP);
+// everything else needs a restart.
+type Setting struct {
+	Env         string
+	Default     string
+	Description string
+	Reloadable  bool
+}
+
+var Settings = []Setting{
+	{"PORT", "3000", "HTTP port the API listens on", false},
+	{"NETWORK", "mainnet", "mainnet or testnet. Testnet checks nodes against BSC/opBNB testnet, keeps its own leaderboard, and awards 10x points", false},
+	{"TRUSTED_RPC", "https://bsc-dataseed1.binance.org", "Trusted BSC RPC used to compute expected answers", true},
+	{"TRUSTED_OPBNB_RPC", "https://opbnb-mainnet-rpc.bnbchain.org", "Trusted opBNB RPC used to compute expected answers for opbnb-* nodes", true},
+	{"TRUSTED_GREENFIELD_SP", "https://greenfield-sp.bnbchain.org", "Trusted Greenfield storage provider used to compute expected answers for greenfield-sp nodes", true},
+	{"HEADER_CHAIN_RPCS", "", "Comma separated BSC RPCs a header chain is synced from; block-hash answers are checked against it instead of TRUSTED_RPC (unset = off)", true},
+	{"HEADER_CHAIN_QUORUM", "2", "How many HEADER_CHAIN_RPCS have to agree on a block hash before it's used", true},
+	{"PENDING_CHALLENGES_FILE", "pending-challenges.json", "File unanswered challenges are saved to on shutdown (SIGTERM or Ctrl-C) and restored from on startup, so proofs in flight survive a deploy. It holds expected answers, so keep it private (off = they're lost on restart)", false},
+	{"GREENFIELD_OBJECTS", "", "Comma separated public Greenfield objects (bucket/object) storage providers are challenged with (unset = greenfield-sp nodes get no challenges)", false},
+	{"BLOCK_SAMPLING", "uniform", "How challenge blocks are picked: uniform, or hard to favour blocks public RPCs are unlikely to have cached (no round heights, BSC's quiet hours, deep history for archives), so proxies show up in response times", false},
+	{"GIN_MODE", "debug", "debug logs every route at startup; use release in production", false},
+	{"TRUSTED_PROXIES", "", "Comma separated IPs or CIDRs of the load balancers/proxies in front of the server. Only their CLIENT_IP_HEADERS are believed (unset = client IP is the connection's address)", false},
+	{"CLIENT_IP_HEADERS", "X-Forwarded-For,X-Real-IP", "Headers a trusted proxy puts the client's IP in, checked in order", false},
+	{"GEO_COUNTRY_HEADER", "", "Header the proxy in front of the server puts the client's country code in, e.g. CF-IPCountry; it must strip any client-sent copy (unset = nodes aren't placed and get no diversity bonus)", false},
+	{"ADMIN_API_KEY", "", "API key for /api/admin endpoints, comma separated to give each admin their own (unset = admin endpoints unprotected)", false},
+	{"LATENCY_SUSPICIOUS_MS", "150", "Responses slower than this pass but are marked suspicious", true},
+	{"LATENCY_MAX_MS", "5000", "Responses slower than this fail", true},
+	{"WARNING_THRESHOLD", "2", "Suspicious events before a node goes to warning status", true},
+	{"FLAG_THRESHOLD", "5", "Suspicious events before a node is flagged for admin review", true},
+	{"FINGERPRINT_WALLET_THRESHOLD", "5", "Wallets submitting from one connection fingerprint before their nodes get a suspicious event", true},
+	{"WALLET_BAN_COOLDOWN_DAYS", "30", "Days a wallet can't register nodes after one of its nodes is banned, multiplied by its number of offences (0 = permanent)", true},
+	{"BAN_APPROVAL_MINUTES", "0", "If set, a ban only takes effect once a second admin key confirms it within this many minutes (needs 2+ admin keys)", true},
+	{"TRUST_WEIGHTED_POINTS", "false", "Scale uptime points by each node's trust score (0-100)", true},
+	{"UPTIME_PENALTY_PERCENT", "50", "Percent taken off uptime points while a node's 7-day uptime is below the minimum for its type (0 = warn only)", true},
+	{"SILENT_NODE_HOURS", "24", "Hours without a passed proof or heartbeat before a node is made inactive; its next passed proof reactivates it (0 = never)", true},
+	{"SERVER_SIGNING_KEY", "", "Hex private key used to sign issued challenges and ?signed=true stats responses (unset = no signing). Changing it rotates the key", true},
+	{"SERVER_RETIRED_SIGNING_ADDRESSES", "", "Comma separated addresses of old signing keys to keep publishing (e.g. keys rotated out before a restart)", true},
+	{"SIGNING_KEY_GRACE_HOURS", "168", "How long a rotated-out signing key stays published", true},
+	{"CLIENT_CERT_KEY", "", "32-byte hex key (openssl rand -hex 32) that client certificates from exposed-rpc nodes are encrypted with, for nodes whose RPC only accepts mTLS. Changing it makes stored certificates unreadable (unset = nodes can't give one)", false},
+	{"PUBLIC_RPC_PROVIDERS", "https://bsc-dataseed1.binance.org,https://bsc-rpc.publicnode.com,https://rpc.ankr.com/bsc,https://bsc.drpc.org", "Comma separated public BSC RPCs probed for latency, to spot nodes proxying to them (anticheat.provider-latency flag)", true},
+	{"MIN_CLIENT_VERSIONS", "", "Lowest client release per chain, e.g. bsc/geth=1.4.15,opbnb/geth=0.5.3; operators of older nodes are notified (unset = no minimum)", true},
+	{"PROVER_BUILD_HASHES", "", "Comma separated sha256 hashes (0x...) of the official prover release binaries. Nodes answering with any other build are marked unofficial for admins to review, not refused (unset = no allowlist)", true},
+	{"HARD_FORKS", "", "Scheduled hard forks and the first ready release of each client, e.g. bsc/pascal@1742436600:geth=1.5.7:erigon=1.3.0; nodes ready early get bonus points", true},
+	{"CHALLENGE_DAILY_CAPS", "", "Challenges each node can ask for per UTC day, per type and * for all types together, e.g. block-hash=200,*=500 (unset = no caps)", true},
+	{"LEADERBOARD_MIN_UPTIME_HOURS", "0", "Uptime hours a node needs before it shows up on the leaderboard", true},
+	{"LEADERBOARD_MIN_PASS_RATE", "0", "Challenge pass rate (percent, last 24 hours) a node needs to show up on the leaderboard", true},
+	{"LEADERBOARD_CLEAN_ONLY", "false", "Leave nodes in warning or flagged status off the leaderboard (banned nodes never show)", true},
+	{"DIVERSITY_BONUS_PERCENT", "0", "Extra uptime points, in percent, for nodes in the most underrepresented countries; less for more common ones, none at or above a fair share. Weights are recalculated weekly (0 = off)", true},
+	{"STAKING_TIERS", "1=5,10=10,100=20", "Extra uptime points, in percent, by whole tokens a node's wallet has staked on STAKING_CONTRACT, e.g. 1=5,10=10,100=20. Only the highest tier reached counts", true},
+	{"SLOW_REQUEST_MS", "1000", "Requests slower than this are logged with a store/verifier timing breakdown and listed at /api/admin/metrics (0 = off)", true},
+	{"CHALLENGE_WORK_SLOTS", "64", "Challenge requests, answer checks and exposed-rpc verifications run at once; beyond that they queue, long-standing trusted nodes first and new or flagged nodes last (0 = no limit)", true},
+	{"POINTS_PER_HOUR", "", "Uptime points per hour by node type, overriding the built-in rates, e.g. bsc-archive=12,opbnb-fast=2 (unset = built-in rates)", true},
+	{"API_RATE_PLANS", "anonymous=120,basic=1200", "Requests per minute to the public read endpoints by plan: anonymous per client IP, the rest per API key. New keys start on basic; admins can move keys to any other plan listed (0 = unlimited)", true},
+	{"CORS_ORIGINS", "*", "Comma separated origins browsers may call the API from, e.g. https://dashboard.example.com (* = any)", true},
+	{"ALERT_PASS_RATE_DROP_PERCENT", "20", "Alert admins when the network-wide challenge pass rate over the last hour is this many percent below the hour before (0 = off)", true},
+	{"ALERT_REGISTRATION_SPIKE", "10", "Alert admins when registrations in the last hour reach this many times the previous day's hourly average (0 = off)", true},
+	{"ALERT_TYPE_PASS_RATE_DROP_PERCENT", "50", "Stop issuing a challenge type and alert admins when its pass rate over the last hour is this many percent below its own the day before while other types hold up; turn its challenge.* flag back on once fixed (0 = off)", true},
+	{"ALERT_EMAIL_TO", "", "Comma separated addresses network anomaly alerts are mailed to, as well as going to ADMIN_WEBHOOK_URL (unset = no email)", false},
+	{"ALERT_SMTP_ADDR", "", "SMTP server (host:port) alert emails are sent through", false},
+	{"ALERT_SMTP_FROM", "", "Sender address of alert emails", false},
+	{"ALERT_SMTP_USERNAME", "", "SMTP login for alert emails (unset = no login)", false},
+	{"ALERT_SMTP_PASSWORD", "", "SMTP password for ALERT_SMTP_USERNAME", false},
+	{"NOTIFY_WEBHOOK_URL", "", "URL that operator events (e.g. a node about to be flagged) are POSTed to as JSON (unset = off)", false},
+	{"ADMIN_WEBHOOK_URL", "", "URL every admin action (reviews, bans, flag changes) is POSTed to as JSON (unset = off)", false},
+	{"DIAGNOSTICS_ADDR", "", "Address pprof and runtime stats (/debug/pprof/, /debug/runtime) are served on, e.g. 127.0.0.1:6060. Anything but loopback needs ADMIN_API_KEY (unset = off)", false},
+	{"NAMES_REGISTRY", "", "ENS-style registry wallet names are reverse-resolved from, e.g. the SpaceID .bnb registry on BSC, 0x08CEd32a7f3eeC915Ba84415e9C07a7286977956. Names show next to addresses on the leaderboard and wallet stats (unset = off)", false},
+	{"NAMES_RPC", "", "RPC the names registry is read through (unset = TRUSTED_RPC)", false},
+	{"STAKING_CONTRACT", "", "Contract whose balanceOf(wallet) is what the wallet has staked (18 decimals), e.g. a liquid staking token. Read hourly for every wallet with an active node; stakers earn STAKING_TIERS more uptime points (unset = off)", false},
+	{"STAKING_RPC", "", "RPC the staking contract is read through (unset = TRUSTED_RPC)", false},
+	{"ANOMALY_SCORER_URL", "", "External service answers are POSTed to for an anomaly score, e.g. a model trained on /api/admin/features. Roll it out with the anticheat.external-scorer flag; if it fails, the built-in rules score the answer (unset = rules only)", false},
+	{"ANOMALY_SCORER_TIMEOUT_MS", "200", "How long an answer waits for the anomaly scorer before the rules score it instead", false},
+	{"ANOMALY_SCORER_THRESHOLD_PERCENT", "90", "Anomaly score (percent of 1) at or above which an answer is marked suspicious", false},
+	{"REPLICA_OF", "", "Base URL of the writer to follow, e.g. http://writer:3000. Set = this server is a read-only replica: it serves reads from a copy of the writer's store, pulled with the first ADMIN_API_KEY (which the writer must accept), and redirects everything else to the writer (unset = this is the writer)", false},
+	{"REPLICA_SYNC_SECONDS", "10", "How often a replica pulls the writer's store", false},
+	{"STORE_BACKEND", "memory", "Where the store is kept: memory, postgres (DATABASE_URL) or sqlite (SQLITE_PATH). With a database the store is loaded from it at startup and what changed is written back at each checkpoint, so a restart loses nothing before the last one. Replicas always use memory", false},
+	{"DATABASE_URL", "", "PostgreSQL connection URL for STORE_BACKEND=postgres, e.g. postgres://depin:secret@db:5432/depin?sslmode=require", false},
+	{"SQLITE_PATH", "depin.db", "SQLite file for STORE_BACKEND=sqlite, created with its table on first start", false},
+	{"STORE_CHECKPOINT_SECONDS", "10", "How often changes to the store are written to the database (they're also written at shutdown)", false},
+	{"REDIS_URL", "", "Redis shared by every server process (redis://[:password@]host:6379/0, rediss:// for TLS, Redis 6.2+). Pending challenges are kept there so any server process can verify an answer, and the leaderboard is cached there for a few seconds (unset = each process keeps its own)", false},
+	{"FEATURE_FLAGS", "", "Feature flag rollouts at startup, e.g. challenge.state-balance=50,anticheat.latency-suspicious=0", false},
+}
+
+type Config struct {
+	Port         string
+	Network      types.Network
+	TrustedRPC   string
+	AdminAPIKey  string
+	FeatureFlags string
+	WebhookURL   string
+
+	DiagnosticsAddr string
+	ClientCertKey   string
+	PendingFile     string
+
+	NamesRegistry string
+	NamesRPC      string
+
+	StakingContract string
+	StakingRPC      string
+
+	AnomalyScorer AnomalyScorer
+
+	ReplicaOf          string
+	ReplicaSyncSeconds uint64
+
+	StoreBackend      string
+	DatabaseURL       string
+	SQLitePath        string
+	CheckpointSeconds uint64
+
+	RedisURL string
+
+	GinMode         string
+	TrustedProxies  []string
+	ClientIPHeaders []string
+	CountryHeader   string
+
+	TrustedOpbnbRPC string
+	AdminWebhookURL string
+	AlertEmail      AlertEmail
+
+	TrustedGreenfieldSP string
+	GreenfieldObjects   []string
+
+	BlockSampling string
+
+	HeaderChainRPCs   []string
+	HeaderChainQuorum uint64
+
+	// Safe to change at runtime
+	Thresholds        Thresholds
+	Signing           Signing
+	PublicProviders   []string
+	MinClientVersions string
+	ProverBuilds      string
+	HardForks         string
+	SlowRequestMs     uint64
+	WorkSlots         uint64
+
+	ChallengeDailyCaps string
+	Leaderboard        types.LeaderboardRules
+
+	DiversityBonusPercent uint64
+	UptimePenaltyPercent  uint64
+	StakingTiers          string
+
+	PointsPerHour string
+	APIRatePlans  string
+	CORSOrigins   []string
+
+	Alerts anomaly.Limits
+}
+
+// Where network anomaly alerts are mailed
+type AlertEmail struct {
+	To       []string
+	SMTPAddr string
+	From     string
+	Username string
+	Password string
+}
+
+// External anomaly scoring service
+type AnomalyScorer struct {
+	URL              string
+	TimeoutMs        uint64
+	ThresholdPercent uint64
+}
+
+// Server signing keys
+type Signing struct {
+	Key              string
+	RetiredAddresses []string
+	GraceHours       uint64
+}
+
+// Anti-cheat thresholds
+type Thresholds struct {
+	LatencySuspiciousMs uint64
+	LatencyMaxMs        uint64
+	WarningThreshold    uint8
+	FlagThreshold       uint8
+
+	FingerprintWalletThreshold uint64
+	WalletBanCooldownDays      uint64
+	BanApprovalMinutes         uint64
+	TrustWeightedPoints        bool
+	SilentNodeHours            uint64
+}
+
+// Collects every problem so the operator can fix them all in one go
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+func (e *ValidationError) add(env, format string, args ...interface{}) {
+	e.Problems = append(e.Problems, env+": "+fmt.Sprintf(format, args...))
+}
+
+// Load reads config from the environment and validates it
+func Load() (*Config, error) {
+	return load(os.Getenv)
+}
+
+func load(getenv func(string) string) (*Config, error) {
+	errs := &ValidationError{}
+
+	testnet := strings.TrimSpace(getenv("NETWORK")) == string(types.Testnet)
+	get := func(env string) string {
+		if v := strings.TrimSpace(getenv(env)); v != "" {
+			return v
+		}
+		if def, ok := testnetDefaults[env]; ok && testnet {
+			return def
+		}
+		return defaultFor(env)
+	}
+
+	getUint := func(env string, bits int) uint64 {
+		raw := get(env)
+		n, err := strconv.ParseUint(raw, 10, bits)
+		if err != nil {
+			errs.add(env, "must be a whole number, got %q", raw)
+		}
+		return n
+	}
+
+	getBool := func(env string) bool {
+		raw := get(env)
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			errs.add(env, "must be true or false, got %q", raw)
+		}
+		return b
+	}
+
+	cfg := &Config{
+		Port:         get("PORT"),
+		Network:      types.Network(get("NETWORK")),
+		TrustedRPC:   get("TRUSTED_RPC"),
+		AdminAPIKey:  getenv("ADMIN_API_KEY"),
+		FeatureFlags: get("FEATURE_FLAGS"),
+		WebhookURL:   getenv("NOTIFY_WEBHOOK_URL"),
+
+		DiagnosticsAddr: get("DIAGNOSTICS_ADDR"),
+		ClientCertKey:   getenv("CLIENT_CERT_KEY"),
+		PendingFile:     get("PENDING_CHALLENGES_FILE"),
+
+		NamesRegistry: get("NAMES_REGISTRY"),
+		NamesRPC:      get("NAMES_RPC"),
+
+		StakingContract: get("STAKING_CONTRACT"),
+		StakingRPC:      get("STAKING_RPC"),
+
+		AnomalyScorer: AnomalyScorer{
+			URL:              get("ANOMALY_SCORER_URL"),
+			TimeoutMs:        getUint("ANOMALY_SCORER_TIMEOUT_MS", 32),
+			ThresholdPercent: getUint("ANOMALY_SCORER_THRESHOLD_PERCENT", 8),
+		},
+
+		ReplicaOf:          get("REPLICA_OF"),
+		ReplicaSyncSeconds: getUint("REPLICA_SYNC_SECONDS", 32),
+
+		StoreBackend:      get("STORE_BACKEND"),
+		DatabaseURL:       getenv("DATABASE_URL"),
+		SQLitePath:        get("SQLITE_PATH"),
+		CheckpointSeconds: getUint("STORE_CHECKPOINT_SECONDS", 32),
+
+		RedisURL: getenv("REDIS_URL"),
+
+		GinMode:         get("GIN_MODE"),
+		TrustedProxies:  splitList(get("TRUSTED_PROXIES")),
+		ClientIPHeaders: splitList(get("CLIENT_IP_HEADERS")),
+		CountryHeader:   get("GEO_COUNTRY_HEADER"),
+
+		TrustedOpbnbRPC: get("TRUSTED_OPBNB_RPC"),
+		AdminWebhookURL: getenv("ADMIN_WEBHOOK_URL"),
+		AlertEmail: AlertEmail{
+			To:       splitList(get("ALERT_EMAIL_TO")),
+			SMTPAddr: get("ALERT_SMTP_ADDR"),
+			From:     get("ALERT_SMTP_FROM"),
+			Username: getenv("ALERT_SMTP_USERNAME"),
+			Password: getenv("ALERT_SMTP_PASSWORD"),
+		},
+
+		TrustedGreenfieldSP: get("TRUSTED_GREENFIELD_SP"),
+		GreenfieldObjects:   splitList(get("GREENFIELD_OBJECTS")),
+
+		BlockSampling: get("BLOCK_SAMPLING"),
+
+		HeaderChainRPCs:   splitList(get("HEADER_CHAIN_RPCS")),
+		HeaderChainQuorum: getUint("HEADER_CHAIN_QUORUM", 8),
+		Thresholds: Thresholds{
+			LatencySuspiciousMs: getUint("LATENCY_SUSPICIOUS_MS", 64),
+			LatencyMaxMs:        getUint("LATENCY_MAX_MS", 64),
+			WarningThreshold:    uint8(getUint("WARNING_THRESHOLD", 8)),
+			FlagThreshold:       uint8(getUint("FLAG_THRESHOLD", 8)),
+
+			FingerprintWalletThreshold: getUint("FINGERPRINT_WALLET_THRESHOLD", 16),
+			WalletBanCooldownDays:      getUint("WALLET_BAN_COOLDOWN_DAYS", 16),
+			BanApprovalMinutes:         getUint("BAN_APPROVAL_MINUTES", 16),
+			TrustWeightedPoints:        getBool("TRUST_WEIGHTED_POINTS"),
+			SilentNodeHours:            getUint("SILENT_NODE_HOURS", 32),
+		},
+		Signing: Signing{
+			Key:              getenv("SERVER_SIGNING_KEY"),
+			RetiredAddresses: splitList(get("SERVER_RETIRED_SIGNING_ADDRESSES")),
+			GraceHours:       getUint("SIGNING_KEY_GRACE_HOURS", 64),
+		},
+		PublicProviders:   splitList(get("PUBLIC_RPC_PROVIDERS")),
+		MinClientVersions: get("MIN_CLIENT_VERSIONS"),
+		ProverBuilds:      get("PROVER_BUILD_HASHES"),
+		HardForks:         get("HARD_FORKS"),
+		SlowRequestMs:     getUint("SLOW_REQUEST_MS", 64),
+		WorkSlots:         getUint("CHALLENGE_WORK_SLOTS", 16),
+
+		ChallengeDailyCaps: get("CHALLENGE_DAILY_CAPS"),
+		Leaderboard: types.LeaderboardRules{
+			MinUptimeHours: getUint("LEADERBOARD_MIN_UPTIME_HOURS", 64),
+			MinPassRate:    uint8(getUint("LEADERBOARD_MIN_PASS_RATE", 8)),
+			CleanOnly:      getBool("LEADERBOARD_CLEAN_ONLY"),
+		},
+
+		DiversityBonusPercent: getUint("DIVERSITY_BONUS_PERCENT", 64),
+		UptimePenaltyPercent:  getUint("UPTIME_PENALTY_PERCENT", 64),
+		StakingTiers:          get("STAKING_TIERS"),
+
+		PointsPerHour: get("POINTS_PER_HOUR"),
+		APIRatePlans:  get("API_RATE_PLANS"),
+		CORSOrigins:   splitList(get("CORS_ORIGINS")),
+
+		Alerts: anomaly.Limits{
+			PassRateDropPercent:     getUint("ALERT_PASS_RATE_DROP_PERCENT", 64),
+			RegistrationSpike:       getUint("ALERT_REGISTRATION_SPIKE", 64),
+			TypePassRateDropPercent: getUint("ALERT_TYPE_PASS_RATE_DROP_PERCENT", 64),
+		},
+	}
+	// Unset takes the default, so turning it off needs a word of its own
+	if cfg.PendingFile == "off" {
+		cfg.PendingFile = ""
+	}
+
+	if len(errs.Problems) > 0 {
+		return nil, errs
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) Validate() error {
+	errs := &ValidationError{}
+
+	port, err := strconv.Atoi(c.Port)
+	if err != nil || port < 1 || port > 65535 {
+		errs.add("PORT", "must be a port number between 1 and 65535, got %q", c.Port)
+	}
+
+	if c.Network != types.Mainnet && c.Network != types.Testnet {
+		errs.add("NETWORK", "must be mainnet or testnet, got %q", c.Network)
+	}
+
+	if err := validateURL(c.TrustedRPC); err != nil {
+		errs.add("TRUSTED_RPC", "%v", err)
+	}
+	if err := validateURL(c.TrustedOpbnbRPC); err != nil {
+		errs.add("TRUSTED_OPBNB_RPC", "%v", err)
+	}
+	if err := validateURL(c.TrustedGreenfieldSP); err != nil {
+		errs.add("TRUSTED_GREENFIELD_SP", "%v", err)
+	}
+	for _, endpoint := range c.HeaderChainRPCs {
+		if err := validateURL(endpoint); err != nil {
+			errs.add("HEADER_CHAIN_RPCS", "%v", err)
+		}
+	}
+	if len(c.HeaderChainRPCs) > 0 && (c.HeaderChainQuorum < 1 || c.HeaderChainQuorum > uint64(len(c.HeaderChainRPCs))) {
+		errs.add("HEADER_CHAIN_QUORUM", "must be between 1 and the %d HEADER_CHAIN_RPCS, got %d", len(c.HeaderChainRPCs), c.HeaderChainQuorum)
+	}
+	if _, err := challenge.ParseSampling(c.BlockSampling); err != nil {
+		errs.add("BLOCK_SAMPLING", "%v", err)
+	}
+	for _, object := range c.GreenfieldObjects {
+		if bucket, name, ok := strings.Cut(object, "/"); !ok || bucket == "" || name == "" {
+			errs.add("GREENFIELD_OBJECTS", "want bucket/object, got %q", object)
+		}
+	}
+
+	if c.AdminWebhookURL != "" {
+		if err := validateURL(c.AdminWebhookURL); err != nil {
+			errs.add("ADMIN_WEBHOOK_URL", "%v", err)
+		}
+	}
+
+	if len(c.AlertEmail.To) > 0 {
+		if _, _, err := net.SplitHostPort(c.AlertEmail.SMTPAddr); err != nil {
+			errs.add("ALERT_SMTP_ADDR", "needed for ALERT_EMAIL_TO, want host:port, got %q", c.AlertEmail.SMTPAddr)
+		}
+		if c.AlertEmail.From == "" {
+			errs.add("ALERT_SMTP_FROM", "needed for ALERT_EMAIL_TO")
+		}
+	}
+	if c.Alerts.PassRateDropPercent > 100 {
+		errs.add("ALERT_PASS_RATE_DROP_PERCENT", "must be at most 100, got %d", c.Alerts.PassRateDropPercent)
+	}
+	if c.Alerts.TypePassRateDropPercent > 100 {
+		errs.add("ALERT_TYPE_PASS_RATE_DROP_PERCENT", "must be at most 100, got %d", c.Alerts.TypePassRateDropPercent)
+	}
+
+	if c.Thresholds.BanApprovalMinutes > 0 && len(c.AdminAPIKeys()) < 2 {
+		errs.add("BAN_APPROVAL_MINUTES", "needs at least 2 keys in ADMIN_API_KEY, got %d", len(c.AdminAPIKeys()))
+	}
+
+	if c.WebhookURL != "" {
+		if err := validateURL(c.WebhookURL); err != nil {
+			errs.add("NOTIFY_WEBHOOK_URL", "%v", err)
+		}
+	}
+
+	if c.GinMode != "debug" && c.GinMode != "release" && c.GinMode != "test" {
+		errs.add("GIN_MODE", "must be debug, release or test, got %q", c.GinMode)
+	}
+	for _, proxy := range c.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				errs.add("TRUSTED_PROXIES", "not an IP or CIDR: %q", proxy)
+			}
+		}
+	}
+	if len(c.ClientIPHeaders) == 0 {
+		errs.add("CLIENT_IP_HEADERS", "must list at least one header")
+	}
+
+	if c.DiagnosticsAddr != "" {
+		if _, _, err := net.SplitHostPort(c.DiagnosticsAddr); err != nil {
+			errs.add("DIAGNOSTICS_ADDR", "want host:port, got %q", c.DiagnosticsAddr)
+		} else if !diagnostics.IsLoopback(c.DiagnosticsAddr) && len(c.AdminAPIKeys()) == 0 {
+			errs.add("DIAGNOSTICS_ADDR", "%q isn't loopback, so ADMIN_API_KEY must be set to guard it", c.DiagnosticsAddr)
+		}
+	}
+
+	if c.NamesRegistry != "" && !common.IsHexAddress(c.NamesRegistry) {
+		errs.add("NAMES_REGISTRY", "not an address: %q", c.NamesRegistry)
+	}
+	if c.NamesRPC != "" {
+		if err := validateURL(c.NamesRPC); err != nil {
+			errs.add("NAMES_RPC", "%v", err)
+		}
+	}
+
+	if c.StakingContract != "" && !common.IsHexAddress(c.StakingContract) {
+		errs.add("STAKING_CONTRACT", "not an address: %q", c.StakingContract)
+	}
+	if c.StakingRPC != "" {
+		if err := validateURL(c.StakingRPC); err != nil {
+			errs.add("STAKING_RPC", "%v", err)
+		}
+	}
+
+	if c.AnomalyScorer.URL != "" {
+		if err := validateURL(c.AnomalyScorer.URL); err != nil {
+			errs.add("ANOMALY_SCORER_URL", "%v", err)
+		}
+		if c.AnomalyScorer.TimeoutMs == 0 {
+			errs.add("ANOMALY_SCORER_TIMEOUT_MS", "must be at least 1")
+		}
+		if c.AnomalyScorer.ThresholdPercent == 0 || c.AnomalyScorer.ThresholdPercent > 100 {
+			errs.add("ANOMALY_SCORER_THRESHOLD_PERCENT", "must be 1-100, got %d", c.AnomalyScorer.ThresholdPercent)
+		}
+	}
+
+	if c.ReplicaOf != "" {
+		if err := validateURL(c.ReplicaOf); err != nil {
+			errs.add("REPLICA_OF", "%v", err)
+		} else if len(c.AdminAPIKeys()) == 0 {
+			errs.add("REPLICA_OF", "needs ADMIN_API_KEY to pull the writer's store with")
+		}
+		if c.ReplicaSyncSeconds == 0 {
+			errs.add("REPLICA_SYNC_SECONDS", "must be at least 1")
+		}
+	}
+
+	switch {
+	case !store.ValidBackend(c.StoreBackend):
+		errs.add("STORE_BACKEND", "want %s, %s or %s, got %q", store.BackendMemory, store.BackendPostgres, store.BackendSQLite, c.StoreBackend)
+	case c.StoreBackend == store.BackendPostgres && c.DatabaseURL == "":
+		errs.add("DATABASE_URL", "needed for STORE_BACKEND=%s", store.BackendPostgres)
+	case c.StoreBackend != store.BackendMemory && c.ReplicaOf != "":
+		errs.add("STORE_BACKEND", "a replica's store comes from the writer; leave it at %s", store.BackendMemory)
+	}
+	if c.CheckpointSeconds == 0 {
+		errs.add("STORE_CHECKPOINT_SECONDS", "must be at least 1")
+	}
+	if c.RedisURL != "" {
+		if u, err := url.Parse(c.RedisURL); err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") || u.Host == "" {
+			errs.add("REDIS_URL", "want a redis:// or rediss:// URL with a host")
+		}
+	}
+
+	if c.Signing.Key != "" {
+		if _, err := signing.NewSigner(c.Signing.Key); err != nil {
+			errs.add("SERVER_SIGNING_KEY", "%v", err)
+		}
+	}
+	for _, addr := range c.Signing.RetiredAddresses {
+		if !common.IsHexAddress(addr) {
+			errs.add("SERVER_RETIRED_SIGNING_ADDRESSES", "not an address: %q", addr)
+		}
+	}
+
+	if c.ClientCertKey != "" {
+		if _, err := clientcert.NewSealer(c.ClientCertKey); err != nil {
+			errs.add("CLIENT_CERT_KEY", "%v", err)
+		}
+	}
+
+	for _, provider := range c.PublicProviders {
+		if err := validateURL(provider); err != nil {
+			errs.add("PUBLIC_RPC_PROVIDERS", "%v", err)
+		}
+	}
+
+	if _, err := clientversion.ParseMinimums(c.MinClientVersions); err != nil {
+		errs.add("MIN_CLIENT_VERSIONS", "%v", err)
+	}
+	if _, err := proverbuild.ParseAllowlist(c.ProverBuilds); err != nil {
+		errs.add("PROVER_BUILD_HASHES", "%v", err)
+	}
+	if _, err := hardfork.Parse(c.HardForks); err != nil {
+		errs.add("HARD_FORKS", "%v", err)
+	}
+	if _, err := budget.ParseCaps(c.ChallengeDailyCaps); err != nil {
+		errs.add("CHALLENGE_DAILY_CAPS", "%v", err)
+	}
+
+	if c.Leaderboard.MinPassRate > 100 {
+		errs.add("LEADERBOARD_MIN_PASS_RATE", "must be a percent from 0 to 100, got %d", c.Leaderboard.MinPassRate)
+	}
+
+	if c.DiversityBonusPercent > 100 {
+		errs.add("DIVERSITY_BONUS_PERCENT", "must be at most 100, got %d", c.DiversityBonusPercent)
+	}
+	if c.DiversityBonusPercent > 0 && c.CountryHeader == "" {
+		errs.add("DIVERSITY_BONUS_PERCENT", "needs GEO_COUNTRY_HEADER to place nodes")
+	}
+
+	if c.UptimePenaltyPercent > 100 {
+		errs.add("UPTIME_PENALTY_PERCENT", "must be at most 100, got %d", c.UptimePenaltyPercent)
+	}
+	if _, err := staking.ParseTiers(c.StakingTiers); err != nil {
+		errs.add("STAKING_TIERS", "%v", err)
+	}
+
+	if _, err := rates.Parse(c.PointsPerHour); err != nil {
+		errs.add("POINTS_PER_HOUR", "%v", err)
+	}
+	if _, err := ratelimit.ParsePlans(c.APIRatePlans); err != nil {
+		errs.add("API_RATE_PLANS", "%v", err)
+	}
+
+	if len(c.CORSOrigins) == 0 {
+		errs.add("CORS_ORIGINS", "must list at least one origin, or *")
+	}
+	for _, origin := range c.CORSOrigins {
+		if err := validateOrigin(origin); err != nil {
+			errs.add("CORS_ORIGINS", "%v", err)
+		}
+	}
+
+	if _, err := flags.ParseSpec(c.FeatureFlags); err != nil {
+		errs.add("FEATURE_FLAGS", "%v", err)
+	}
+
+	c.Thresholds.validate(errs)
+
+	if len(errs.Problems) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// One key per admin
+func (c *Config) AdminAPIKeys() []string {
+	return splitList(c.AdminAPIKey)
+}
+
+func (t Thresholds) validate(errs *ValidationError) {
+	if t.LatencySuspiciousMs == 0 {
+		errs.add("LATENCY_SUSPICIOUS_MS", "must be greater than 0")
+	}
+	if t.LatencySuspiciousMs >= t.LatencyMaxMs {
+		errs.add("LATENCY_MAX_MS", "must be greater than LATENCY_SUSPICIOUS_MS (%d), got %d", t.LatencySuspiciousMs, t.LatencyMaxMs)
+	}
+	if t.WarningThreshold == 0 {
+		errs.add("WARNING_THRESHOLD", "must be greater than 0")
+	}
+	if t.WarningThreshold >= t.FlagThreshold {
+		errs.add("FLAG_THRESHOLD", "must be greater than WARNING_THRESHOLD (%d), got %d", t.WarningThreshold, t.FlagThreshold)
+	}
+	if t.FingerprintWalletThreshold < 2 {
+		errs.add("FINGERPRINT_WALLET_THRESHOLD", "must be at least 2 (one wallet always shares its own fingerprint)")
+	}
+}
+
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("not a valid URL: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("must be an http or https URL, got %q", raw)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("missing host in %q", raw)
+	}
+	return nil
+}
+
+// Browsers send Origin as scheme://host[:port], nothing more
+func validateOrigin(origin string) error {
+	if origin == "*" {
+		return nil
+	}
+	if err := validateURL(origin); err != nil {
+		return err
+	}
+	if u, _ := url.Parse(origin); u.Path != "" || u.RawQuery != "" || u.User != nil {
+		return fmt.Errorf("an origin is just scheme://host[:port], got %q", origin)
+	}
+	return nil
+}
+
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func defaultFor(env string) string {
+	for _, s := range Settings {
+		if s.Env == env {
+			return s.Default
+		}
+	}
+	return ""
+}
+
+// Reload re-reads the environment and returns a copy of the current config
+// with only the reloadable settings updated. Changes to restart-only
+// settings are reported in skipped and otherwise ignored.
+func (c *Config) Reload() (next *Config, skipped []string, err error) {
+	return c.reload(os.Getenv)
+}
+
+func (c *Config) reload(getenv func(string) string) (*Config, []string, error) {
+	fresh, err := load(getenv)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	next := *c
+	next.Thresholds = fresh.Thresholds
+	next.Signing = fresh.Signing
+	next.PublicProviders = fresh.PublicProviders
+	next.MinClientVersions = fresh.MinClientVersions
+	next.ProverBuilds = fresh.ProverBuilds
+	next.HardForks = fresh.HardForks
+	next.SlowRequestMs = fresh.SlowRequestMs
+	next.WorkSlots = fresh.WorkSlots
+	next.ChallengeDailyCaps = fresh.ChallengeDailyCaps
+	next.Leaderboard = fresh.Leaderboard
+	next.DiversityBonusPercent = fresh.DiversityBonusPercent
+	next.UptimePenaltyPercent = fresh.UptimePenaltyPercent
+	next.StakingTiers = fresh.StakingTiers
+	next.PointsPerHour = fresh.PointsPerHour
+	next.APIRatePlans = fresh.APIRatePlans
+	next.CORSOrigins = fresh.CORSOrigins
+	next.Alerts = fresh.Alerts
+
+	// Challenges already issued keep the answers the old endpoints gave
+	next.TrustedRPC = fresh.TrustedRPC
+	next.TrustedOpbnbRPC = fresh.TrustedOpbnbRPC
+	next.TrustedGreenfieldSP = fresh.TrustedGreenfieldSP
+	next.HeaderChainRPCs = fresh.HeaderChainRPCs
+	next.HeaderChainQuorum = fresh.HeaderChainQuorum
+
+	var skipped []string
+	if fresh.Port != c.Port {
+		skipped = append(skipped, "PORT")
+	}
+	if fresh.Network != c.Network {
+		skipped = append(skipped, "NETWORK")
+	}
+	if fresh.BlockSampling != c.BlockSampling {
+		skipped = append(skipped, "BLOCK_SAMPLING")
+	}
+	if strings.Join(fresh.GreenfieldObjects, ",") != strings.Join(c.GreenfieldObjects, ",") {
+		skipped = append(skipped, "GREENFIELD_OBJECTS")
+	}
+	if fresh.AdminAPIKey != c.AdminAPIKey {
+		skipped = append(skipped, "ADMIN_API_KEY")
+	}
+	if fresh.WebhookURL != c.WebhookURL {
+		skipped = append(skipped, "NOTIFY_WEBHOOK_URL")
+	}
+	if fresh.AdminWebhookURL != c.AdminWebhookURL {
+		skipped = append(skipped, "ADMIN_WEBHOOK_URL")
+	}
+	if fmt.Sprint(fresh.AlertEmail) != fmt.Sprint(c.AlertEmail) {
+		skipped = append(skipped, "ALERT_EMAIL_TO/ALERT_SMTP_*")
+	}
+	if fresh.DiagnosticsAddr != c.DiagnosticsAddr {
+		skipped = append(skipped, "DIAGNOSTICS_ADDR")
+	}
+	if fresh.NamesRegistry != c.NamesRegistry || fresh.NamesRPC != c.NamesRPC {
+		skipped = append(skipped, "NAMES_REGISTRY/NAMES_RPC")
+	}
+	if fresh.StakingContract != c.StakingContract || fresh.StakingRPC != c.StakingRPC {
+		skipped = append(skipped, "STAKING_CONTRACT/STAKING_RPC")
+	}
+	if fresh.AnomalyScorer != c.AnomalyScorer {
+		skipped = append(skipped, "ANOMALY_SCORER_*")
+	}
+	if fresh.ReplicaOf != c.ReplicaOf || fresh.ReplicaSyncSeconds != c.ReplicaSyncSeconds {
+		skipped = append(skipped, "REPLICA_OF/REPLICA_SYNC_SECONDS")
+	}
+	if fresh.PendingFile != c.PendingFile {
+		skipped = append(skipped, "PENDING_CHALLENGES_FILE")
+	}
+	if fresh.StoreBackend != c.StoreBackend || fresh.DatabaseURL != c.DatabaseURL || fresh.SQLitePath != c.SQLitePath || fresh.CheckpointSeconds != c.CheckpointSeconds {
+		skipped = append(skipped, "STORE_BACKEND/DATABASE_URL/SQLITE_PATH/STORE_CHECKPOINT_SECONDS")
+	}
+	if fresh.RedisURL != c.RedisURL {
+		skipped = append(skipped, "REDIS_URL")
+	}
+	if fresh.ClientCertKey != c.ClientCertKey {
+		skipped = append(skipped, "CLIENT_CERT_KEY")
+	}
+	if fresh.GinMode != c.GinMode {
+		skipped = append(skipped, "GIN_MODE")
+	}
+	if strings.Join(fresh.TrustedProxies, ",") != strings.Join(c.TrustedProxies, ",") || strings.Join(fresh.ClientIPHeaders, ",") != strings.Join(c.ClientIPHeaders, ",") {
+		skipped = append(skipped, "TRUSTED_PROXIES/CLIENT_IP_HEADERS")
+	}
+	if fresh.CountryHeader != c.CountryHeader {
+		skipped = append(skipped, "GEO_COUNTRY_HEADER")
+	}
+	if fresh.FeatureFlags != c.FeatureFlags {
+		// Runtime flag changes go through the admin API
+		skipped = append(skipped, "FEATURE_FLAGS")
+	}
+
+	return &next, skipped, nil
+}
+
+// Print every setting with its default, for --help style output
+func Describe() string {
+	var b strings.Builder
+	for _, s := range Settings {
+		reload := ""
+		if s.Reloadable {
+			reload = " (reloadable)"
+		}
+		def := s.Default
+		if def == "" {
+			def = "unset"
+		}
+		if testnetDef, ok := testnetDefaults[s.Env]; ok {
+			def += ", testnet: " + testnetDef
+		}
+		fmt.Fprintf(&b, "  %-22s %s%s\n  %-22s default: %s\n", s.Env, s.Description, reload, "", def)
+	}
+	return b.String()
+}
@@ -0,0 +1,260 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func envFrom(values map[string]string) func(string) string {
+	return func(key string) string {
+		return values[key]
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := load(envFrom(nil))
+	if err != nil {
+		t.Fatalf("defaults should be valid: %v", err)
+	}
+
+	if cfg.Port != "3000" {
+		t.Errorf("expected default port 3000, got %s", cfg.Port)
+	}
+	if cfg.TrustedRPC != "https://bsc-dataseed1.binance.org" {
+		t.Errorf("unexpected default trusted RPC: %s", cfg.TrustedRPC)
+	}
+	if cfg.Thresholds.LatencySuspiciousMs != 150 || cfg.Thresholds.LatencyMaxMs != 5000 {
+		t.Errorf("unexpected default latency thresholds: %+v", cfg.Thresholds)
+	}
+	if cfg.Thresholds.WarningThreshold != 2 || cfg.Thresholds.FlagThreshold != 5 {
+		t.Errorf("unexpected default escalation thresholds: %+v", cfg.Thresholds)
+	}
+}
+
+func TestLoadPendingFile(t *testing.T) {
+	for value, want := range map[string]string{
+		"":                            "pending-challenges.json",
+		"/var/lib/depin/pending.json": "/var/lib/depin/pending.json",
+		"off":                         "",
+	} {
+		cfg, err := load(envFrom(map[string]string{"PENDING_CHALLENGES_FILE": value}))
+		if err != nil {
+			t.Fatalf("%q: %v", value, err)
+		}
+		if cfg.PendingFile != want {
+			t.Errorf("PENDING_CHALLENGES_FILE=%q: expected %q, got %q", value, want, cfg.PendingFile)
+		}
+	}
+}
+
+func TestLoadTestnetDefaults(t *testing.T) {
+	cfg, err := load(envFrom(map[string]string{"NETWORK": "testnet"}))
+	if err != nil {
+		t.Fatalf("testnet defaults should be valid: %v", err)
+	}
+
+	if cfg.TrustedRPC != testnetDefaults["TRUSTED_RPC"] || cfg.TrustedOpbnbRPC != testnetDefaults["TRUSTED_OPBNB_RPC"] {
+		t.Errorf("expected testnet trusted RPCs, got %s and %s", cfg.TrustedRPC, cfg.TrustedOpbnbRPC)
+	}
+
+	// Explicit settings still win
+	cfg, err = load(envFrom(map[string]string{"NETWORK": "testnet", "TRUSTED_RPC": "http://127.0.0.1:8545"}))
+	if err != nil || cfg.TrustedRPC != "http://127.0.0.1:8545" {
+		t.Errorf("explicit TRUSTED_RPC should override the testnet default, got %v (%v)", cfg, err)
+	}
+}
+
+func TestLoadInvalidValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		problem string
+	}{
+		{"bad port", map[string]string{"PORT": "http"}, "PORT"},
+		{"port out of range", map[string]string{"PORT": "70000"}, "PORT"},
+		{"bad rpc scheme", map[string]string{"TRUSTED_RPC": "ftp://node"}, "TRUSTED_RPC"},
+		{"rpc missing host", map[string]string{"TRUSTED_RPC": "https://"}, "TRUSTED_RPC"},
+		{"latency not a number", map[string]string{"LATENCY_MAX_MS": "fast"}, "LATENCY_MAX_MS"},
+		{"latency order", map[string]string{"LATENCY_SUSPICIOUS_MS": "6000"}, "LATENCY_MAX_MS"},
+		{"threshold order", map[string]string{"WARNING_THRESHOLD": "5"}, "FLAG_THRESHOLD"},
+		{"threshold overflow", map[string]string{"FLAG_THRESHOLD": "300"}, "FLAG_THRESHOLD"},
+		{"bad signing key", map[string]string{"SERVER_SIGNING_KEY": "0x1234"}, "SERVER_SIGNING_KEY"},
+		{"bad retired address", map[string]string{"SERVER_RETIRED_SIGNING_ADDRESSES": "0x1234"}, "SERVER_RETIRED_SIGNING_ADDRESSES"},
+		{"ban approval with one admin", map[string]string{"BAN_APPROVAL_MINUTES": "30", "ADMIN_API_KEY": "only-one"}, "BAN_APPROVAL_MINUTES"},
+		{"trust points not a bool", map[string]string{"TRUST_WEIGHTED_POINTS": "sometimes"}, "TRUST_WEIGHTED_POINTS"},
+		{"unknown network", map[string]string{"NETWORK": "devnet"}, "NETWORK"},
+		{"object without bucket", map[string]string{"GREENFIELD_OBJECTS": "file.bin"}, "GREENFIELD_OBJECTS"},
+		{"client minimum without chain", map[string]string{"MIN_CLIENT_VERSIONS": "geth=1.4.15"}, "MIN_CLIENT_VERSIONS"},
+		{"hard fork without releases", map[string]string{"HARD_FORKS": "bsc/pascal@1742436600"}, "HARD_FORKS"},
+		{"challenge cap for unknown type", map[string]string{"CHALLENGE_DAILY_CAPS": "block-hash=200,logs=10"}, "CHALLENGE_DAILY_CAPS"},
+		{"leaderboard pass rate over 100", map[string]string{"LEADERBOARD_MIN_PASS_RATE": "120"}, "LEADERBOARD_MIN_PASS_RATE"},
+		{"diversity bonus over 100", map[string]string{"DIVERSITY_BONUS_PERCENT": "150", "GEO_COUNTRY_HEADER": "CF-IPCountry"}, "DIVERSITY_BONUS_PERCENT"},
+		{"diversity bonus without countries", map[string]string{"DIVERSITY_BONUS_PERCENT": "20"}, "DIVERSITY_BONUS_PERCENT"},
+		{"bad gin mode", map[string]string{"GIN_MODE": "prod"}, "GIN_MODE"},
+		{"bad trusted proxy", map[string]string{"TRUSTED_PROXIES": "10.0.0.0/8,lb.internal"}, "TRUSTED_PROXIES"},
+		{"diagnostics without port", map[string]string{"DIAGNOSTICS_ADDR": "127.0.0.1"}, "DIAGNOSTICS_ADDR"},
+		{"public diagnostics without admin key", map[string]string{"DIAGNOSTICS_ADDR": ":6060"}, "DIAGNOSTICS_ADDR"},
+		{"unknown block sampling", map[string]string{"BLOCK_SAMPLING": "random"}, "BLOCK_SAMPLING"},
+		{"names registry not an address", map[string]string{"NAMES_REGISTRY": "space.id"}, "NAMES_REGISTRY"},
+		{"staking contract not an address", map[string]string{"STAKING_CONTRACT": "slisbnb"}, "STAKING_CONTRACT"},
+		{"staking tier over 100 percent", map[string]string{"STAKING_TIERS": "1=150"}, "STAKING_TIERS"},
+		{"anomaly scorer not a url", map[string]string{"ANOMALY_SCORER_URL": "scorer:8080"}, "ANOMALY_SCORER_URL"},
+		{"anomaly threshold over 100", map[string]string{"ANOMALY_SCORER_URL": "http://scorer:8080", "ANOMALY_SCORER_THRESHOLD_PERCENT": "150"}, "ANOMALY_SCORER_THRESHOLD_PERCENT"},
+		{"replica of a non-url", map[string]string{"REPLICA_OF": "writer:3000", "ADMIN_API_KEY": "k"}, "REPLICA_OF"},
+		{"replica without admin key", map[string]string{"REPLICA_OF": "http://writer:3000"}, "REPLICA_OF"},
+		{"prover build not a hash", map[string]string{"PROVER_BUILD_HASHES": "v1.4.0"}, "PROVER_BUILD_HASHES"},
+		{"unknown store backend", map[string]string{"STORE_BACKEND": "mysql"}, "STORE_BACKEND"},
+		{"postgres without database url", map[string]string{"STORE_BACKEND": "postgres"}, "DATABASE_URL"},
+		{"sqlite on a replica", map[string]string{"STORE_BACKEND": "sqlite", "REPLICA_OF": "http://writer:3000", "ADMIN_API_KEY": "k"}, "STORE_BACKEND"},
+		{"redis not a redis url", map[string]string{"REDIS_URL": "http://cache:6379"}, "REDIS_URL"},
+		{"checkpoint every 0 seconds", map[string]string{"STORE_CHECKPOINT_SECONDS": "0"}, "STORE_CHECKPOINT_SECONDS"},
+		{"short client cert key", map[string]string{"CLIENT_CERT_KEY": "abcd"}, "CLIENT_CERT_KEY"},
+		{"alert email without smtp server", map[string]string{"ALERT_EMAIL_TO": "ops@example.com", "ALERT_SMTP_FROM": "depin@example.com"}, "ALERT_SMTP_ADDR"},
+		{"alert email without sender", map[string]string{"ALERT_EMAIL_TO": "ops@example.com", "ALERT_SMTP_ADDR": "smtp.example.com:587"}, "ALERT_SMTP_FROM"},
+		{"type pass rate drop over 100", map[string]string{"ALERT_TYPE_PASS_RATE_DROP_PERCENT": "101"}, "ALERT_TYPE_PASS_RATE_DROP_PERCENT"},
+		{"pass rate drop over 100", map[string]string{"ALERT_PASS_RATE_DROP_PERCENT": "150"}, "ALERT_PASS_RATE_DROP_PERCENT"},
+		{"rate plans without anonymous", map[string]string{"API_RATE_PLANS": "basic=600,pro=6000"}, "API_RATE_PLANS"},
+		{"points for unknown type", map[string]string{"POINTS_PER_HOUR": "bsc-light=3"}, "POINTS_PER_HOUR"},
+		{"cors origin with path", map[string]string{"CORS_ORIGINS": "https://dashboard.example.com/app"}, "CORS_ORIGINS"},
+		{"cors origin without scheme", map[string]string{"CORS_ORIGINS": "dashboard.example.com"}, "CORS_ORIGINS"},
+	}
+
+	for _, tt := range tests {
+		_, err := load(envFrom(tt.env))
+		if err == nil {
+			t.Errorf("%s: expected error", tt.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.problem) {
+			t.Errorf("%s: expected error to mention %s, got: %v", tt.name, tt.problem, err)
+		}
+	}
+}
+
+func TestLoadReportsAllProblems(t *testing.T) {
+	_, err := load(envFrom(map[string]string{
+		"PORT":        "0",
+		"TRUSTED_RPC": "not a url",
+	}))
+
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if len(verr.Problems) != 2 {
+		t.Errorf("expected 2 problems, got %d: %v", len(verr.Problems), verr.Problems)
+	}
+}
+
+func TestReloadOnlyChangesSafeSettings(t *testing.T) {
+	cfg, _ := load(envFrom(nil))
+
+	next, skipped, err := cfg.reload(envFrom(map[string]string{
+		"PORT":                 "4000",
+		"WARNING_THRESHOLD":    "3",
+		"FLAG_THRESHOLD":       "10",
+		"SLOW_REQUEST_MS":      "250",
+		"CHALLENGE_WORK_SLOTS": "8",
+
+		"CHALLENGE_DAILY_CAPS":   "*=500",
+		"LEADERBOARD_CLEAN_ONLY": "true",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if next.Port != "3000" {
+		t.Errorf("port should not change on reload, got %s", next.Port)
+	}
+	if next.Thresholds.FlagThreshold != 10 || next.Thresholds.WarningThreshold != 3 {
+		t.Errorf("thresholds should be reloaded, got %+v", next.Thresholds)
+	}
+	if next.SlowRequestMs != 250 {
+		t.Errorf("slow request threshold should be reloaded, got %d", next.SlowRequestMs)
+	}
+	if next.WorkSlots != 8 {
+		t.Errorf("work slots should be reloaded, got %d", next.WorkSlots)
+	}
+	if next.ChallengeDailyCaps != "*=500" {
+		t.Errorf("challenge caps should be reloaded, got %q", next.ChallengeDailyCaps)
+	}
+	if !next.Leaderboard.CleanOnly {
+		t.Error("leaderboard rules should be reloaded")
+	}
+	if len(skipped) != 1 || skipped[0] != "PORT" {
+		t.Errorf("expected PORT to be reported as skipped, got %v", skipped)
+	}
+}
+
+func TestReloadTrustedEndpoints(t *testing.T) {
+	cfg, _ := load(envFrom(nil))
+
+	next, skipped, err := cfg.reload(envFrom(map[string]string{
+		"TRUSTED_RPC":         "http://10.0.0.5:8545",
+		"HEADER_CHAIN_RPCS":   "http://10.0.0.5:8545,http://10.0.0.6:8545",
+		"HEADER_CHAIN_QUORUM": "2",
+		"POINTS_PER_HOUR":     "bsc-archive=12",
+		"CORS_ORIGINS":        "https://dashboard.example.com",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if next.TrustedRPC != "http://10.0.0.5:8545" || len(next.HeaderChainRPCs) != 2 {
+		t.Errorf("trusted RPCs should be reloaded, got %s and %v", next.TrustedRPC, next.HeaderChainRPCs)
+	}
+	if next.PointsPerHour != "bsc-archive=12" {
+		t.Errorf("point rates should be reloaded, got %q", next.PointsPerHour)
+	}
+	if len(next.CORSOrigins) != 1 || next.CORSOrigins[0] != "https://dashboard.example.com" {
+		t.Errorf("CORS origins should be reloaded, got %v", next.CORSOrigins)
+	}
+	if cfg.TrustedRPC == next.TrustedRPC {
+		t.Error("reload should not change the config it was called on")
+	}
+	if len(skipped) != 0 {
+		t.Errorf("nothing should be skipped, got %v", skipped)
+	}
+}
+
+func TestReloadRotatesSigningKey(t *testing.T) {
+	cfg, _ := load(envFrom(nil))
+
+	key := "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	next, skipped, err := cfg.reload(envFrom(map[string]string{
+		"SERVER_SIGNING_KEY":               key,
+		"SERVER_RETIRED_SIGNING_ADDRESSES": "0x1111111111111111111111111111111111111111, 0x2222222222222222222222222222222222222222",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if next.Signing.Key != key {
+		t.Error("signing key should be reloadable")
+	}
+	if len(next.Signing.RetiredAddresses) != 2 {
+		t.Errorf("expected 2 retired addresses, got %v", next.Signing.RetiredAddresses)
+	}
+	if next.Signing.GraceHours != 168 {
+		t.Errorf("expected default grace of 168h, got %d", next.Signing.GraceHours)
+	}
+	if len(skipped) != 0 {
+		t.Errorf("nothing should be skipped, got %v", skipped)
+	}
+}
+
+func TestReloadRejectsInvalid(t *testing.T) {
+	cfg, _ := load(envFrom(nil))
+
+	if _, _, err := cfg.reload(envFrom(map[string]string{"LATENCY_MAX_MS": "10"})); err == nil {
+		t.Error("invalid reload should be rejected")
+	}
+}
+
+func TestEverySettingDocumented(t *testing.T) {
+	for _, s := range Settings {
+		if s.Description == "" {
+			t.Errorf("%s has no description", s.Env)
+		}
+	}
+}
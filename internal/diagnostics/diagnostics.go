@@ -0,0 +1,97 @@
+// Package diagnostics serves pprof and runtime stats on their own listener,
+// away from the public API, so memory growth (e.g. of pending challenges
+// or store maps) can be looked into on a production server.
+package diagnostics
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Anything with in-memory maps worth watching
+type Sizer interface {
+	Sizes() map[string]int
+}
+
+type Runtime struct {
+	Goroutines     int                       `json:"goroutines"`
+	HeapAllocBytes uint64                    `json:"heap_alloc_bytes"`
+	HeapInuseBytes uint64                    `json:"heap_inuse_bytes"`
+	HeapObjects    uint64                    `json:"heap_objects"`
+	SysBytes       uint64                    `json:"sys_bytes"`
+	NumGC          uint32                    `json:"num_gc"`
+	UptimeSeconds  int64                     `json:"uptime_seconds"`
+	Sizes          map[string]map[string]int `json:"sizes"` // e.g. "verifier" -> "pending_challenges" -> 12
+}
+
+// Whether addr only listens on this machine. An empty host binds every
+// interface, so it doesn't count.
+func IsLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// pprof under /debug/pprof/ and runtime stats at /debug/runtime. With
+// adminKeys, every request needs one as a bearer token; leave them out
+// only when listening on loopback.
+func Handler(sizers map[string]Sizer, adminKeys []string) http.Handler {
+	started := time.Now()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/runtime", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(snapshot(sizers, started))
+	})
+
+	if len(adminKeys) == 0 {
+		return mux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		for _, key := range adminKeys {
+			if token != "" && token == key {
+				mux.ServeHTTP(w, r)
+				return
+			}
+		}
+		http.Error(w, "admin key required", http.StatusUnauthorized)
+	})
+}
+
+func snapshot(sizers map[string]Sizer, started time.Time) Runtime {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	sizes := make(map[string]map[string]int, len(sizers))
+	for name, sizer := range sizers {
+		sizes[name] = sizer.Sizes()
+	}
+
+	return Runtime{
+		Goroutines:     runtime.NumGoroutine(),
+		HeapAllocBytes: mem.HeapAlloc,
+		HeapInuseBytes: mem.HeapInuse,
+		HeapObjects:    mem.HeapObjects,
+		SysBytes:       mem.Sys,
+		NumGC:          mem.NumGC,
+		UptimeSeconds:  int64(time.Since(started).Seconds()),
+		Sizes:          sizes,
+	}
+}
@@ -0,0 +1,82 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fixedSizes map[string]int
+
+func (f fixedSizes) Sizes() map[string]int { return f }
+
+func TestIsLoopback(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1:6060", true},
+		{"localhost:6060", true},
+		{"[::1]:6060", true},
+		{":6060", false},
+		{"0.0.0.0:6060", false},
+		{"10.0.0.5:6060", false},
+		{"6060", false},
+	}
+	for _, tt := range tests {
+		if got := IsLoopback(tt.addr); got != tt.want {
+			t.Errorf("%q: got %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestRuntime(t *testing.T) {
+	h := Handler(map[string]Sizer{"verifier": fixedSizes{"pending_challenges": 3}}, nil)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/debug/runtime", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var stats Runtime
+	json.Unmarshal(w.Body.Bytes(), &stats)
+	if stats.Goroutines == 0 || stats.HeapAllocBytes == 0 {
+		t.Errorf("runtime stats missing: %+v", stats)
+	}
+	if stats.Sizes["verifier"]["pending_challenges"] != 3 {
+		t.Errorf("expected the verifier's sizes, got %v", stats.Sizes)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/debug/pprof/", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected the pprof index, got %d", w.Code)
+	}
+}
+
+func TestHandlerNeedsAdminKey(t *testing.T) {
+	h := Handler(nil, []string{"secret"})
+
+	tests := []struct {
+		auth string
+		want int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"Bearer secret", http.StatusOK},
+		{"secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/debug/runtime", nil)
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%q: expected %d, got %d", tt.auth, tt.want, w.Code)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package flags
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Feature flags for rolling out new challenge types and anti-cheat rules.
+// Each flag has a rollout percentage: 0 = off, 100 = on for everyone, and
+// anything in between turns it on for a stable slice of nodes (the same
+// node always lands in the same bucket, so rolling 10% -> 50% only adds
+// nodes, never shuffles them).
+type Flag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Percent     uint8  `json:"percent"`
+}
+
+type Flags struct {
+	flags map[string]*Flag
+	mu    sync.RWMutex
+}
+
+func New() *Flags {
+	return &Flags{
+		flags: make(map[string]*Flag),
+	}
+}
+
+// Register a flag with its default rollout. Calling it again for the same
+// name only updates the description.
+func (f *Flags) Define(name, description string, percent uint8) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if existing, ok := f.flags[name]; ok {
+		existing.Description = description
+		return
+	}
+	f.flags[name] = &Flag{Name: name, Description: description, Percent: clamp(percent)}
+}
+
+// Change a flag's rollout percentage
+func (f *Flags) Set(name string, percent uint8) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	flag, ok := f.flags[name]
+	if !ok {
+		return fmt.Errorf("unknown flag %q", name)
+	}
+	flag.Percent = clamp(percent)
+	return nil
+}
+
+// Is the flag on for this node?
+func (f *Flags) EnabledFor(name, nodeID string) bool {
+	f.mu.RLock()
+	flag, ok := f.flags[name]
+	f.mu.RUnlock()
+
+	if !ok {
+		return false
+	}
+
+	switch {
+	case flag.Percent == 0:
+		return false
+	case flag.Percent >= 100:
+		return true
+	default:
+		return bucket(name, nodeID) < uint32(flag.Percent)
+	}
+}
+
+func (f *Flags) Get(name string) (Flag, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	flag, ok := f.flags[name]
+	if !ok {
+		return Flag{}, false
+	}
+	return *flag, true
+}
+
+// All flags sorted by name
+func (f *Flags) All() []Flag {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	all := make([]Flag, 0, len(f.flags))
+	for _, flag := range f.flags {
+		all = append(all, *flag)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// Apply a spec like "challenge.state-balance=50,anticheat.latency=0"
+func (f *Flags) Apply(spec string) error {
+	values, err := ParseSpec(spec)
+	if err != nil {
+		return err
+	}
+	for name, percent := range values {
+		if err := f.Set(name, percent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Parse a flag spec without applying it (used for config validation)
+func ParseSpec(spec string) (map[string]uint8, error) {
+	values := make(map[string]uint8)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, raw, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("flag %q needs a percentage, e.g. %s=50", part, part)
+		}
+
+		percent, err := strconv.ParseUint(strings.TrimSpace(raw), 10, 8)
+		if err != nil || percent > 100 {
+			return nil, fmt.Errorf("flag %q: percentage must be 0-100, got %q", name, raw)
+		}
+		values[strings.TrimSpace(name)] = uint8(percent)
+	}
+	return values, nil
+}
+
+// Stable 0-99 bucket for a node, separate per flag so the same nodes
+// aren't always the guinea pigs
+func bucket(name, nodeID string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(nodeID))
+	return h.Sum32() % 100
+}
+
+func clamp(percent uint8) uint8 {
+	if percent > 100 {
+		return 100
+	}
+	return percent
+}
@@ -0,0 +1,96 @@
+package flags
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestUnknownFlagIsOff(t *testing.T) {
+	f := New()
+	if f.EnabledFor("missing", "node-1") {
+		t.Error("unknown flags should be off")
+	}
+	if err := f.Set("missing", 50); err == nil {
+		t.Error("setting an unknown flag should fail")
+	}
+}
+
+func TestFlagOnAndOff(t *testing.T) {
+	f := New()
+	f.Define("on", "always on", 100)
+	f.Define("off", "always off", 0)
+
+	for i := 0; i < 50; i++ {
+		id := fmt.Sprintf("node-%d", i)
+		if !f.EnabledFor("on", id) {
+			t.Errorf("100%% flag should be on for %s", id)
+		}
+		if f.EnabledFor("off", id) {
+			t.Errorf("0%% flag should be off for %s", id)
+		}
+	}
+}
+
+func TestPercentageRollout(t *testing.T) {
+	f := New()
+	f.Define("rollout", "partial", 30)
+
+	enabled := 0
+	for i := 0; i < 2000; i++ {
+		if f.EnabledFor("rollout", fmt.Sprintf("node-%d", i)) {
+			enabled++
+		}
+	}
+
+	// Should be roughly 30% (allow some slack)
+	if enabled < 500 || enabled > 700 {
+		t.Errorf("expected roughly 600 of 2000 nodes enabled, got %d", enabled)
+	}
+}
+
+func TestRolloutIsStableAndGrows(t *testing.T) {
+	f := New()
+	f.Define("rollout", "partial", 10)
+
+	before := make(map[string]bool)
+	for i := 0; i < 500; i++ {
+		id := fmt.Sprintf("node-%d", i)
+		before[id] = f.EnabledFor("rollout", id)
+	}
+
+	f.Set("rollout", 50)
+
+	for id, wasEnabled := range before {
+		if wasEnabled && !f.EnabledFor("rollout", id) {
+			t.Errorf("%s was in the 10%% rollout but dropped out at 50%%", id)
+		}
+	}
+}
+
+func TestApplySpec(t *testing.T) {
+	f := New()
+	f.Define("a", "", 100)
+	f.Define("b", "", 100)
+
+	if err := f.Apply("a=0, b=25"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	a, _ := f.Get("a")
+	b, _ := f.Get("b")
+	if a.Percent != 0 || b.Percent != 25 {
+		t.Errorf("spec not applied: a=%d b=%d", a.Percent, b.Percent)
+	}
+
+	if err := f.Apply("c=10"); err == nil {
+		t.Error("unknown flag in spec should fail")
+	}
+}
+
+func TestParseSpecInvalid(t *testing.T) {
+	for _, spec := range []string{"a", "a=101", "a=half"} {
+		if _, err := ParseSpec(spec); err == nil {
+			t.Errorf("expected error for spec %q", spec)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Package geo works out where nodes are and which countries are
+// underrepresented, so nodes there can earn a diversity bonus. We don't
+// ship a GeoIP database: the country comes from the proxy or CDN in front
+// of the server (e.g. Cloudflare's CF-IPCountry header).
+package geo
+
+import (
+	"strings"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// Codes CDNs send when they don't know the country, or it isn't one
+var unknownCountries = map[string]bool{
+	"XX": true, // Unknown
+	"T1": true, // Tor exit
+	"A1": true, // Anonymous proxy
+	"A2": true, // Satellite provider
+	"EU": true, // Only known to be somewhere in Europe
+	"AP": true, // Only known to be somewhere in Asia/Pacific
+}
+
+// An ISO 3166-1 alpha-2 code in upper case, "" if the value isn't a
+// country we can place a node in
+func NormalizeCountry(raw string) string {
+	code := strings.ToUpper(strings.TrimSpace(raw))
+	if len(code) != 2 || unknownCountries[code] {
+		return ""
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return ""
+		}
+	}
+	return code
+}
+
+// How underrepresented each country is, from how many nodes it has. A
+// country with its fair share (total / countries) or more weighs 0; one
+// with no nodes at all would weigh 1. With a single country nobody is
+// underrepresented.
+func Weights(nodesByCountry map[string]int) map[string]types.RegionWeight {
+	total := 0
+	for _, n := range nodesByCountry {
+		total += n
+	}
+
+	weights := make(map[string]types.RegionWeight, len(nodesByCountry))
+	for country, n := range nodesByCountry {
+		weight := 1 - float64(n*len(nodesByCountry))/float64(total)
+		if weight < 0 {
+			weight = 0
+		}
+		weights[country] = types.RegionWeight{
+			Nodes:        n,
+			SharePercent: float64(n) / float64(total) * 100,
+			Weight:       weight,
+		}
+	}
+	return weights
+}
@@ -0,0 +1,49 @@
+package geo
+
+import "testing"
+
+func TestNormalizeCountry(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"DE", "DE"},
+		{" ng ", "NG"},
+		{"XX", ""},
+		{"T1", ""},
+		{"EU", ""},
+		{"USA", ""},
+		{"1A", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeCountry(tt.raw); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestWeights(t *testing.T) {
+	// 10 nodes over 3 countries: a fair share is 3.33
+	weights := Weights(map[string]int{"US": 7, "DE": 2, "NG": 1})
+
+	if weights["US"].Weight != 0 {
+		t.Errorf("an overrepresented country should weigh 0, got %v", weights["US"].Weight)
+	}
+	if w := weights["NG"].Weight; w < 0.69 || w > 0.71 {
+		t.Errorf("expected NG to weigh 0.7, got %v", w)
+	}
+	if weights["DE"].Weight >= weights["NG"].Weight {
+		t.Errorf("rarer countries should weigh more: DE %v, NG %v", weights["DE"].Weight, weights["NG"].Weight)
+	}
+	if weights["US"].Nodes != 7 || weights["US"].SharePercent != 70 {
+		t.Errorf("unexpected US entry: %+v", weights["US"])
+	}
+
+	if w := Weights(map[string]int{"US": 5}); w["US"].Weight != 0 {
+		t.Errorf("a single country isn't underrepresented, got %v", w["US"].Weight)
+	}
+	if w := Weights(nil); len(w) != 0 {
+		t.Errorf("expected no weights without nodes, got %v", w)
+	}
+}
@@ -0,0 +1,114 @@
+package hardfork
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/clientversion"
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// A scheduled network upgrade and the first release of each client that
+// supports it. A node is ready once it runs one of those releases or newer.
+type Fork struct {
+	Name        string
+	Chain       types.Chain
+	ActivatesAt int64                            // Unix ms
+	Releases    map[string]clientversion.Version // Client -> first ready release
+}
+
+// Early upgraders get a one-off bonus: a base amount for being ready
+// before activation plus a bit for each full day early, capped
+const (
+	earlyBonusBase   = 100
+	earlyBonusPerDay = 50
+	earlyBonusMax    = 1000
+)
+
+// Parse forks from "chain/name@unix-seconds:client=version[:client=version]",
+// comma separated, e.g. "bsc/pascal@1742436600:geth=1.5.7:erigon=1.3.0".
+// Empty means no forks scheduled.
+func Parse(spec string) ([]Fork, error) {
+	var forks []Fork
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		fields := strings.Split(entry, ":")
+		head, releases := fields[0], fields[1:]
+		key, at, ok := strings.Cut(head, "@")
+		chain, name, hasName := strings.Cut(strings.ToLower(key), "/")
+		if !ok || !hasName || name == "" || len(releases) == 0 {
+			return nil, fmt.Errorf("want chain/name@unix-seconds:client=version, got %q", entry)
+		}
+		if chain != string(types.ChainBSC) && chain != string(types.ChainOpBNB) {
+			return nil, fmt.Errorf("unknown chain %q in %q", chain, entry)
+		}
+		seconds, err := strconv.ParseInt(at, 10, 64)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("bad activation time in %q", entry)
+		}
+
+		fork := Fork{
+			Name:        name,
+			Chain:       types.Chain(chain),
+			ActivatesAt: seconds * 1000,
+			Releases:    make(map[string]clientversion.Version),
+		}
+		for _, release := range releases {
+			min, err := clientversion.ParseMinimums(chain + "/" + release)
+			if err != nil {
+				return nil, fmt.Errorf("fork %s: %v", name, err)
+			}
+			for _, v := range min {
+				fork.Releases[v.Client] = v
+			}
+		}
+		forks = append(forks, fork)
+	}
+
+	sort.Slice(forks, func(i, j int) bool { return forks[i].ActivatesAt < forks[j].ActivatesAt })
+	return forks, nil
+}
+
+// "bsc/pascal"
+func (f Fork) ID() string {
+	return string(f.Chain) + "/" + f.Name
+}
+
+// Whether a node running this client version is ready. known is false when
+// the version can't be parsed or the fork lists no release for the client.
+func (f Fork) Ready(raw string) (ready, known bool) {
+	v, ok := clientversion.Parse(raw)
+	if !ok {
+		return false, false
+	}
+	min, ok := f.Releases[v.Client]
+	if !ok {
+		return false, false
+	}
+	return !v.Less(min), true
+}
+
+// Still ahead of us, so readiness counts
+func (f Fork) Upcoming(now int64) bool {
+	return now < f.ActivatesAt
+}
+
+// Bonus for a node first seen ready at readyAt (0 if it was late)
+func (f Fork) EarlyBonus(readyAt int64) uint64 {
+	if readyAt >= f.ActivatesAt {
+		return 0
+	}
+	days := uint64((f.ActivatesAt - readyAt) / (24 * time.Hour).Milliseconds())
+	bonus := earlyBonusBase + days*earlyBonusPerDay
+	if bonus > earlyBonusMax {
+		bonus = earlyBonusMax
+	}
+	return bonus
+}
@@ -0,0 +1,85 @@
+package hardfork
+
+import (
+	"testing"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+func TestParse(t *testing.T) {
+	forks, err := Parse("opbnb/fermat@1800000000:geth=0.5.3, bsc/pascal@1742436600:geth=1.5.7:erigon=1.3.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(forks) != 2 {
+		t.Fatalf("expected 2 forks, got %d", len(forks))
+	}
+
+	pascal := forks[0] // Sorted by activation
+	if pascal.ID() != "bsc/pascal" || pascal.Chain != types.ChainBSC || pascal.ActivatesAt != 1742436600000 {
+		t.Errorf("unexpected fork: %+v", pascal)
+	}
+	if len(pascal.Releases) != 2 || pascal.Releases["erigon"].Minor != 3 {
+		t.Errorf("unexpected releases: %+v", pascal.Releases)
+	}
+
+	for _, spec := range []string{
+		"pascal@1742436600:geth=1.5.7",
+		"bsc/pascal:geth=1.5.7",
+		"bsc/pascal@soon:geth=1.5.7",
+		"bsc/pascal@1742436600",
+		"bsc/pascal@1742436600:geth",
+		"greenfield/pampas@1742436600:sp=1.0.0",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("%q should be rejected", spec)
+		}
+	}
+}
+
+func TestReady(t *testing.T) {
+	forks, _ := Parse("bsc/pascal@1742436600:geth=1.5.7")
+	fork := forks[0]
+
+	tests := []struct {
+		raw   string
+		ready bool
+		known bool
+	}{
+		{"Geth/v1.5.7-abc/linux-amd64/go1.23", true, true},
+		{"Geth/v1.6.0/linux-amd64/go1.23", true, true},
+		{"Geth/v1.5.6-abc/linux-amd64/go1.23", false, true},
+		{"erigon/1.3.0/linux-amd64/go1.23", false, false},
+		{"", false, false},
+	}
+
+	for _, tt := range tests {
+		ready, known := fork.Ready(tt.raw)
+		if ready != tt.ready || known != tt.known {
+			t.Errorf("%q: ready=%v known=%v, want ready=%v known=%v", tt.raw, ready, known, tt.ready, tt.known)
+		}
+	}
+}
+
+func TestEarlyBonus(t *testing.T) {
+	day := (24 * time.Hour).Milliseconds()
+	fork := Fork{ActivatesAt: 100 * day}
+
+	tests := []struct {
+		readyAt int64
+		want    uint64
+	}{
+		{100 * day, 0},
+		{101 * day, 0},
+		{100*day - 1, earlyBonusBase},
+		{97 * day, earlyBonusBase + 3*earlyBonusPerDay},
+		{0, earlyBonusMax},
+	}
+
+	for _, tt := range tests {
+		if got := fork.EarlyBonus(tt.readyAt); got != tt.want {
+			t.Errorf("ready %d days before: got %d, want %d", (fork.ActivatesAt-tt.readyAt)/day, got, tt.want)
+		}
+	}
+}
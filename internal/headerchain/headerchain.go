@@ -0,0 +1,184 @@
+package headerchain
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/depinonbnb/depin/internal/rpc"
+)
+
+// How many recent headers we keep
+const DefaultWindow = 1024
+
+// Most headers fetched in one Sync, so catching up never blocks for long
+const maxPerSync = 200
+
+// A window of recent block hashes, built from several untrusted RPCs
+// instead of one trusted one. A header is only kept when a quorum of
+// sources agree on its hash and its parent hash matches the header
+// before it, so one bad or compromised endpoint can't change the answer.
+//
+// This is not a full Parlia light client: validator seals aren't checked.
+// Trust comes from independent sources agreeing and the chain staying
+// linked.
+type Chain struct {
+	sources []*rpc.Client
+	quorum  int
+	window  uint64
+	hashes  map[uint64]string
+	head    uint64 // Highest stored header, 0 = none yet
+	tail    uint64 // Lowest stored header
+	mu      sync.RWMutex
+}
+
+func New(sources []*rpc.Client, quorum int) *Chain {
+	if quorum < 1 {
+		quorum = 1
+	}
+	return &Chain{
+		sources: sources,
+		quorum:  quorum,
+		window:  DefaultWindow,
+		hashes:  make(map[uint64]string),
+	}
+}
+
+// Build a header chain from RPC endpoints
+func FromEndpoints(endpoints []string, quorum int) *Chain {
+	sources := make([]*rpc.Client, len(endpoints))
+	for i, endpoint := range endpoints {
+		sources[i] = rpc.NewTrustedClient(endpoint)
+	}
+	return New(sources, quorum)
+}
+
+// Highest block at least quorum sources have reached
+func (c *Chain) agreedHead() (uint64, bool) {
+	heads := make([]uint64, 0, len(c.sources))
+	for _, source := range c.sources {
+		if head, _, err := source.GetBlockNumber(); err == nil {
+			heads = append(heads, head)
+		}
+	}
+	if len(heads) < c.quorum {
+		return 0, false
+	}
+	sort.Slice(heads, func(i, j int) bool { return heads[i] > heads[j] })
+	return heads[c.quorum-1], true
+}
+
+// The hash and parent hash a quorum of sources agree on for a block
+func (c *Chain) agreedHeader(number uint64) (hash, parent string, ok bool) {
+	type header struct{ hash, parent string }
+	votes := make(map[header]int)
+	for _, source := range c.sources {
+		block, _, err := source.GetBlockByNumber(number)
+		if err != nil || block.Hash == "" {
+			continue
+		}
+		h := header{strings.ToLower(block.Hash), strings.ToLower(block.ParentHash)}
+		votes[h]++
+		if votes[h] >= c.quorum {
+			return h.hash, h.parent, true
+		}
+	}
+	return "", "", false
+}
+
+// Fetch new headers up to the agreed head. Call periodically, from one
+// goroutine. Returns how many headers were added.
+func (c *Chain) Sync() int {
+	target, ok := c.agreedHead()
+	if !ok {
+		return 0
+	}
+
+	c.mu.RLock()
+	next := c.head + 1
+	empty := c.head == 0
+	c.mu.RUnlock()
+
+	// First sync, or too far behind to link up - start a fresh window
+	if empty || target >= next+c.window {
+		next = 1
+		if target > c.window {
+			next = target - c.window + 1
+		}
+		c.reset()
+		empty = true
+	}
+
+	added := 0
+	for number := next; number <= target && added < maxPerSync; number++ {
+		hash, parent, ok := c.agreedHeader(number)
+		if !ok {
+			break
+		}
+
+		c.mu.Lock()
+		if prev, known := c.hashes[number-1]; known && prev != parent {
+			// Reorg under us: drop our tip and relink from there next time
+			delete(c.hashes, number-1)
+			c.head = number - 2
+			if c.head < c.tail {
+				c.head, c.tail = 0, 0
+			}
+			c.mu.Unlock()
+			break
+		}
+		c.hashes[number] = hash
+		c.head = number
+		if empty {
+			c.tail = number
+			empty = false
+		}
+		c.prune()
+		c.mu.Unlock()
+		added++
+	}
+	return added
+}
+
+func (c *Chain) reset() {
+	c.mu.Lock()
+	c.hashes = make(map[uint64]string)
+	c.head, c.tail = 0, 0
+	c.mu.Unlock()
+}
+
+// Caller must hold c.mu
+func (c *Chain) prune() {
+	for c.head-c.tail >= c.window {
+		delete(c.hashes, c.tail)
+		c.tail++
+	}
+}
+
+// Hash of a block in the window
+func (c *Chain) Hash(number uint64) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	hash, ok := c.hashes[number]
+	return hash, ok
+}
+
+// Lowest and highest block in the window, ok is false until the first sync
+func (c *Chain) Range() (from, to uint64, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tail, c.head, c.head != 0
+}
+
+// A random block from the window, for a block-hash challenge
+func (c *Chain) Random() (uint64, string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.head == 0 {
+		return 0, "", false
+	}
+	number := c.tail + uint64(rand.Int63n(int64(c.head-c.tail+1)))
+	hash, ok := c.hashes[number]
+	return number, hash, ok
+}
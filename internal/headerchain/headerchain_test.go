@@ -0,0 +1,91 @@
+package headerchain
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/depinonbnb/depin/internal/mockchain"
+	"github.com/depinonbnb/depin/internal/rpc"
+)
+
+func newSources(t *testing.T, chains ...*mockchain.Chain) []*rpc.Client {
+	sources := make([]*rpc.Client, len(chains))
+	for i, chain := range chains {
+		server := httptest.NewServer(chain)
+		t.Cleanup(server.Close)
+		sources[i] = rpc.NewClient(server.URL, "")
+	}
+	return sources
+}
+
+func syncAll(c *Chain) {
+	for c.Sync() > 0 {
+	}
+}
+
+func TestSyncWindow(t *testing.T) {
+	chains := []*mockchain.Chain{mockchain.New(5000), mockchain.New(5000), mockchain.New(5000)}
+	c := New(newSources(t, chains...), 2)
+
+	if _, _, ok := c.Range(); ok {
+		t.Fatal("expected no range before the first sync")
+	}
+
+	if added := c.Sync(); added != maxPerSync {
+		t.Errorf("first sync should stop at %d headers, added %d", maxPerSync, added)
+	}
+	syncAll(c)
+
+	from, to, _ := c.Range()
+	if to != 5000 || to-from+1 != DefaultWindow {
+		t.Errorf("expected a %d block window ending at 5000, got %d-%d", DefaultWindow, from, to)
+	}
+	if hash, ok := c.Hash(4500); !ok || hash != strings.ToLower(mockchain.BlockHash(4500)) {
+		t.Errorf("hash for 4500 = %s, want %s", hash, mockchain.BlockHash(4500))
+	}
+
+	// Follow the head, dropping old headers
+	for _, chain := range chains {
+		chain.SetHead(5100)
+	}
+	syncAll(c)
+	from, to, _ = c.Range()
+	if to != 5100 || to-from+1 != DefaultWindow {
+		t.Errorf("expected the window to move to end at 5100, got %d-%d", from, to)
+	}
+	if _, ok := c.Hash(from - 1); ok {
+		t.Error("headers below the window should be pruned")
+	}
+
+	number, hash, ok := c.Random()
+	if !ok || number < from || number > to || hash != strings.ToLower(mockchain.BlockHash(number)) {
+		t.Errorf("Random() = %d %s %v, want a block in %d-%d with its real hash", number, hash, ok, from, to)
+	}
+}
+
+func TestBadSourceOutvoted(t *testing.T) {
+	sources := newSources(t, mockchain.New(300), mockchain.New(300), mockchain.New(300))
+	sources[0].SetFaults(rpc.FaultConfig{CorruptPercent: 100})
+
+	c := New(sources, 2)
+	syncAll(c)
+
+	if hash, ok := c.Hash(250); !ok || hash != strings.ToLower(mockchain.BlockHash(250)) {
+		t.Errorf("hash for 250 = %s, want the honest sources' %s", hash, mockchain.BlockHash(250))
+	}
+}
+
+func TestNoQuorum(t *testing.T) {
+	sources := newSources(t, mockchain.New(300), mockchain.New(300), mockchain.New(300))
+	sources[0].SetFaults(rpc.FaultConfig{DropPercent: 100})
+	sources[1].SetFaults(rpc.FaultConfig{DropPercent: 100})
+
+	c := New(sources, 2)
+	if added := c.Sync(); added != 0 {
+		t.Errorf("one source isn't a quorum of 2, but %d headers were added", added)
+	}
+	if _, _, ok := c.Random(); ok {
+		t.Error("Random() should fail with no headers")
+	}
+}
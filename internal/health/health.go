@@ -0,0 +1,99 @@
+// Package health grades how well a node is doing, A to F, for delegators
+// and observers who want a quick quality signal without reading every
+// stat. It only does the arithmetic; the store gathers the inputs.
+package health
+
+import (
+	"math"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// How much each factor counts towards the score
+const (
+	weightPassRate = 0.35
+	weightLatency  = 0.20
+	weightSync     = 0.15
+	weightUptime   = 0.30
+)
+
+// Lowest score for each grade; anything below D is F
+var gradeFloors = []struct {
+	grade types.HealthGrade
+	floor float64
+}{
+	{types.GradeA, 90},
+	{types.GradeB, 80},
+	{types.GradeC, 70},
+	{types.GradeD, 60},
+}
+
+// What a grade is made from. Counts say whether there's anything behind a
+// factor; a factor with nothing behind it is left out and the others
+// weighed up to make up for it.
+type Inputs struct {
+	PassRate   float64 // Percent of challenges passed in the last 24 hours
+	Challenges int
+
+	LatencyP90Ms   uint64 // Last 24 hours
+	LatencySamples int
+
+	SyncedPercent float64 // Percent of the last 24 hours' heartbeats that found the node synced
+	Heartbeats    int
+
+	UptimePercent float64 // Last 7 days
+	UptimeChecked bool
+}
+
+// Grade a node. One with nothing to go on yet is ungraded.
+func Grade(in Inputs) types.NodeHealth {
+	h := types.NodeHealth{
+		Grade:           types.GradeNone,
+		PassRate24h:     round(in.PassRate),
+		LatencyP90Ms:    in.LatencyP90Ms,
+		SyncedPercent:   round(in.SyncedPercent),
+		Uptime7dPercent: round(in.UptimePercent),
+	}
+
+	var total, weights float64
+	add := func(has bool, weight, score float64) {
+		if has {
+			total += weight * score
+			weights += weight
+		}
+	}
+	add(in.Challenges > 0, weightPassRate, in.PassRate)
+	add(in.LatencySamples > 0, weightLatency, latencyScore(in.LatencyP90Ms))
+	add(in.Heartbeats > 0, weightSync, in.SyncedPercent)
+	add(in.UptimeChecked, weightUptime, in.UptimePercent)
+	if weights == 0 {
+		return h
+	}
+
+	h.Score = round(total / weights)
+	h.Grade = types.GradeF
+	for _, g := range gradeFloors {
+		if h.Score >= g.floor {
+			h.Grade = g.grade
+			break
+		}
+	}
+	return h
+}
+
+// Full marks up to the suspicious latency, none at the timeout, and a
+// straight line in between
+func latencyScore(p90 uint64) float64 {
+	switch {
+	case p90 <= types.LatencySuspiciousMin:
+		return 100
+	case p90 >= types.LatencyMaxAllowed:
+		return 0
+	}
+	span := float64(types.LatencyMaxAllowed - types.LatencySuspiciousMin)
+	return 100 * (1 - float64(p90-types.LatencySuspiciousMin)/span)
+}
+
+func round(percent float64) float64 {
+	return math.Round(percent*10) / 10
+}
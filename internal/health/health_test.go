@@ -0,0 +1,38 @@
+package health
+
+import (
+	"testing"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+func TestGrade(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Inputs
+		want types.HealthGrade
+	}{
+		{"nothing yet", Inputs{}, types.GradeNone},
+		{"perfect", Inputs{PassRate: 100, Challenges: 50, LatencyP90Ms: 40, LatencySamples: 50, SyncedPercent: 100, Heartbeats: 20, UptimePercent: 100, UptimeChecked: true}, types.GradeA},
+		{"slow but right", Inputs{PassRate: 100, Challenges: 50, LatencyP90Ms: 3000, LatencySamples: 50, SyncedPercent: 100, Heartbeats: 20, UptimePercent: 100, UptimeChecked: true}, types.GradeB},
+		{"often down", Inputs{PassRate: 90, Challenges: 50, LatencyP90Ms: 100, LatencySamples: 50, SyncedPercent: 80, Heartbeats: 20, UptimePercent: 20, UptimeChecked: true}, types.GradeD},
+		{"failing", Inputs{PassRate: 20, Challenges: 50, LatencyP90Ms: 5000, LatencySamples: 50, UptimePercent: 50, UptimeChecked: true}, types.GradeF},
+		// No heartbeats (e.g. a local prover) doesn't count against it
+		{"no heartbeats", Inputs{PassRate: 100, Challenges: 50, LatencyP90Ms: 40, LatencySamples: 50, UptimePercent: 95, UptimeChecked: true}, types.GradeA},
+	}
+	for _, tt := range tests {
+		if got := Grade(tt.in); got.Grade != tt.want {
+			t.Errorf("%s: expected %s, got %s (score %.1f)", tt.name, tt.want, got.Grade, got.Score)
+		}
+	}
+}
+
+func TestLatencyScore(t *testing.T) {
+	if latencyScore(types.LatencySuspiciousMin) != 100 || latencyScore(types.LatencyMaxAllowed) != 0 {
+		t.Error("latency should score 100 at the suspicious threshold and 0 at the timeout")
+	}
+	mid := (types.LatencySuspiciousMin + types.LatencyMaxAllowed) / 2
+	if score := latencyScore(mid); score < 49 || score > 51 {
+		t.Errorf("expected about 50 halfway, got %.1f", score)
+	}
+}
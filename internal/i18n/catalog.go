@@ -0,0 +1,669 @@
+package i18n
+
+// Translations by language, keyed by the English message. When a message
+// changes in the code its entry here has to change too, or operators get
+// the English again.
+var catalog = map[string]map[string]string{
+	"zh": {
+		// Registration and requests
+		"missing required fields":                      "缺少必填字段",
+		"invalid signature":                            "签名无效",
+		"node not found":                               "未找到节点",
+		"timestamp too old":                            "时间戳过旧",
+		"timestamp missing or too old":                 "时间戳缺失或过旧",
+		"rpc endpoint required for exposed-rpc method": "exposed-rpc 方式需要提供 RPC 端点",
+		"greenfield storage providers must use exposed-rpc with their SP endpoint": "Greenfield 存储提供商必须使用 exposed-rpc 方式并提供其 SP 端点",
+		"wallet is banned":                                                   "钱包已被封禁",
+		"invalid attestation signature":                                      "硬件证明签名无效",
+		"hardware can't run this node type":                                  "该硬件无法运行此节点类型",
+		"nodeId required":                                                    "缺少 nodeId",
+		"q required":                                                         "缺少搜索词 q",
+		"request already used":                                               "该请求已被使用",
+		"node is not active":                                                 "节点未处于活跃状态",
+		"node is paused for maintenance":                                     "节点正在暂停维护",
+		"daily challenge budget used up":                                     "今日挑战额度已用完",
+		"daily budget for this challenge type used up, ask again":            "今日该类型挑战额度已用完，请重新请求",
+		"failed to create challenge":                                         "创建挑战失败",
+		"no challenge types are enabled for this node":                       "此节点当前没有启用任何挑战类型",
+		"sign answers with message version 2":                                "请使用第 2 版消息格式对答案签名",
+		"challenge_type required":                                            "缺少 challenge_type",
+		"build must be 0x and a sha256 hash":                                 "build 必须是 0x 开头的 sha256 哈希",
+		"unknown message version":                                            "未知的消息版本",
+		"node is not using local-prover method":                              "节点未使用 local-prover 方式",
+		"node is not using exposed-rpc method":                               "节点未使用 exposed-rpc 方式",
+		"heartbeats are not supported for this node type":                    "此节点类型不支持心跳",
+		"heartbeat already received":                                         "该心跳已接收过",
+		"trusted RPC unavailable":                                            "可信 RPC 不可用",
+		"server busy, try again shortly":                                     "服务器繁忙，请稍后重试",
+		"invalid or revoked api key":                                         "API 密钥无效或已被撤销",
+		"rate limit reached, try again next minute or use an api key":        "已达到请求频率上限，请下一分钟再试或使用 API 密钥",
+		"in maintenance, your answer will be checked when it's back":         "服务正在维护，恢复后将检查你的答案",
+		"the service is down for maintenance, try again later":               "服务正在维护，请稍后再试",
+		"wallet already has the most API keys allowed - revoke one first":    "该钱包的 API 密钥数量已达上限 - 请先撤销一个",
+		"label too long":                                                     "标签过长",
+		"node is already paused":                                             "节点已处于暂停状态",
+		"node is not paused":                                                 "节点未暂停",
+		"maintenance allowance used up for this month":                       "本月维护时长已用完",
+		"verification_method must be exposed-rpc or local-prover":            "verification_method 必须是 exposed-rpc 或 local-prover",
+		"node already uses this verification method":                         "节点已在使用该验证方式",
+		"node is banned":                                                     "节点已被封禁",
+		"endpoint unreachable: {}":                                           "无法连接端点：{1}",
+		"couldn't read the chain ID":                                         "无法读取链 ID",
+		"node is still syncing, so challenges will fail until it catches up": "节点仍在同步，追上之前挑战都会失败",
+		"node's head #{} is {} blocks behind the chain":                      "节点最新区块 #{1} 落后链上 {2} 个区块",
+		"challenge not found or expired":                                     "挑战不存在或已过期",
+		"challenge doesn't take a commitment":                                "该挑战不需要承诺",
+		"answer already committed":                                           "答案已提交承诺",
+		"commit deadline passed":                                             "承诺截止时间已过",
+		"to use a tunnel, register tunnel:// with nothing after it":          "要使用隧道，请将 RPC 端点设为 tunnel://，后面不要加任何内容",
+		"storage providers can't use a tunnel":                               "存储提供商不能使用隧道",
+		"node isn't registered for a tunnel":                                 "节点未注册使用隧道",
+		"tunnel open is older than the current one":                          "隧道打开请求早于当前隧道",
+		"no call waiting with that ID":                                       "没有等待该 ID 的调用",
+		"tunnel isn't open yet, start the prover with --tunnel":              "隧道尚未打开，请使用 --tunnel 启动证明程序",
+
+		// Two-factor
+		"two-factor is already on for this wallet - turn it off first": "该钱包已开启双重验证，请先关闭",
+		"no two-factor enrollment to confirm":                          "没有待确认的双重验证",
+		"two-factor isn't on for this wallet":                          "该钱包未开启双重验证",
+		"this wallet has two-factor on - send totp_code":               "该钱包已开启双重验证，请提供 totp_code",
+		"wrong or already used two-factor code":                        "双重验证码错误或已被使用",
+		"too many wrong two-factor codes - try again later":            "双重验证码错误次数过多，请稍后再试",
+
+		// Hardware attestation
+		"{} needs at least {} CPUs, machine has {}": "{1} 至少需要 {2} 个 CPU，本机只有 {3} 个",
+		"unknown disk class {}":                     "未知的磁盘类别 {1}",
+		"{} needs a disk over {}GB, machine has {}": "{1} 需要大于 {2}GB 的磁盘，本机为 {3}",
+
+		// Failure reasons
+		"challenge expired":                            "挑战已过期",
+		"incorrect answer":                             "答案错误",
+		"response too slow":                            "响应太慢",
+		"answer wasn't committed before the deadline":  "未在截止时间前提交答案承诺",
+		"answer doesn't match commitment":              "答案与承诺不符",
+		"not answered":                                 "未作答",
+		"no RPC endpoint configured":                   "未配置 RPC 端点",
+		"answer was signed for a {} challenge, not {}": "答案签名对应的是 {1} 类型挑战，而不是 {2}",
+		"{} of {} parts incorrect":                     "{2} 个部分中有 {1} 个错误",
+		"answer isn't a list of {} answers":            "答案不是包含 {1} 个答案的列表",
+		"node is on chain {}, {} {} is chain {}":       "节点位于链 {1} 上，而 {2} {3} 的链 ID 是 {4}",
+		"trusted node error: {}":                       "可信节点错误：{1}",
+
+		// Lookups, reports and API keys
+		"response signing is not enabled on this server":                  "此服务器未启用响应签名",
+		"failed to encode response":                                       "响应编码失败",
+		"failed to sign response":                                         "响应签名失败",
+		"wallet not found":                                                "未找到钱包",
+		"addresses required":                                              "缺少 addresses",
+		"at most {} addresses per request":                                "每次请求最多 {1} 个地址",
+		"ids required (comma separated)":                                  "缺少 ids（以逗号分隔）",
+		"at most {} nodes per comparison":                                 "每次最多比较 {1} 个节点",
+		"limit must be 1-{}":                                              "limit 必须在 1-{1} 之间",
+		"month must be YYYY-MM":                                           "month 必须是 YYYY-MM 格式",
+		"client certificates are only for exposed-rpc JSON-RPC endpoints": "客户端证书仅适用于 exposed-rpc 的 JSON-RPC 端点",
+		"admin key or node signature required":                            "需要管理员密钥或节点签名",
+		"challenge already validated":                                     "挑战已验证过",
+		"node unreachable":                                                "无法连接节点",
+		"no failed verification for this challenge":                       "该挑战没有失败的验证记录",
+		"no submissions kept for this challenge":                          "该挑战没有保留的提交记录",
+		"no reclassification proposed for this node":                      "没有为该节点提议重新分类",
+		"proposed type has changed":                                       "提议的类型已变更",
+		"evidence too long (max {} characters)":                           "证据过长（最多 {1} 个字符）",
+		"you already have an open report against this node":               "你对该节点已有未处理的举报",
+		"too many open reports - wait for some to be reviewed":            "未处理的举报过多 - 请等待部分举报审核完成",
+		"too many of your reports have been dismissed":                    "你的举报被驳回次数过多",
+		"api key not found":                                               "未找到 API 密钥",
+		"api key was already revoked":                                     "API 密钥已被撤销",
+		"missing authorization header":                                    "缺少 Authorization 请求头",
+		"invalid api key":                                                 "API 密钥无效",
+		"Idempotency-Key too long":                                        "Idempotency-Key 过长",
+		"could not read request body":                                     "无法读取请求体",
+		"Idempotency-Key was already used with a different request":       "该 Idempotency-Key 已用于其他请求",
+		"a request with this Idempotency-Key is still in progress":        "使用该 Idempotency-Key 的请求仍在处理中",
+
+		// Admin
+		"restore failed: {}":                                 "恢复失败：{1}",
+		"not a store backup":                                 "不是存储备份文件",
+		"a recompute is already running":                     "已有重新计算正在运行",
+		"no recompute has run":                               "尚未运行过重新计算",
+		"config reload not available":                        "无法重新加载配置",
+		"since must be a unix timestamp in milliseconds":     "since 必须是以毫秒为单位的 Unix 时间戳",
+		"failed to salt the export":                          "导出加盐失败",
+		"action required (clear, warn, or ban)":              "缺少 action（clear、warn 或 ban）",
+		"invalid action - use clear, warn, or ban":           "action 无效 - 请使用 clear、warn 或 ban",
+		"action required (apply or dismiss)":                 "缺少 action（apply 或 dismiss）",
+		"entryId must be a ledger entry number":              "entryId 必须是账本条目编号",
+		"reason required":                                    "缺少 reason",
+		"non-zero amount and reason required":                "需要非零的 amount 和 reason",
+		"ledger entry not found":                             "未找到账本条目",
+		"a reversal can't be reversed":                       "冲正条目不能再被冲正",
+		"ledger entry was already reversed":                  "该账本条目已被冲正",
+		"that would take the node below zero points":         "这会使节点积分低于零",
+		"epoch must be a number":                             "epoch 必须是数字",
+		"epoch not found":                                    "未找到该周期",
+		"an epoch has to end after the last one":             "周期必须在上一个周期之后结束",
+		"an epoch can't end in the future":                   "周期不能在未来结束",
+		"no new points to pay out":                           "没有新的积分可发放",
+		"wallet is not banned":                               "钱包未被封禁",
+		"this needs a second, different admin to confirm it": "需要另一位管理员确认",
+		"wallet has no two-factor":                           "该钱包未开启双重验证",
+		"limit must be a whole number":                       "limit 必须是整数",
+		"title required":                                     "缺少 title",
+		"unknown kind {}":                                    "未知的 kind {1}",
+		"title is limited to {} bytes and body to {}":        "title 最多 {1} 字节，body 最多 {2} 字节",
+		"expires_at is in the past":                          "expires_at 已是过去的时间",
+		"announcement not found":                             "未找到公告",
+		"invalid request":                                    "请求无效",
+		"until is in the past":                               "until 已是过去的时间",
+		"service is not in maintenance":                      "服务未处于维护状态",
+		"plan required":                                      "缺少 plan",
+		"unknown plan {}":                                    "未知的套餐 {1}",
+		"percent required (0-100)":                           "缺少 percent（0-100）",
+		"since must be an entry number":                      "since 必须是条目编号",
+		"failed to sign log":                                 "日志签名失败",
+		"missing required fields: wallet_address, node_type, verification_method": "缺少必填字段：wallet_address、node_type、verification_method",
+
+		// Prover console
+		"Local node connected - Block #{}":                "已连接本地节点 - 区块 #{1}",
+		"Synced: {}":                                      "已同步：{1}",
+		"Starting proof loop...":                          "开始证明循环...",
+		"Stopping prover...":                              "正在停止证明程序...",
+		"Rate limited by the server - next request at {}": "被服务器限流 - 下次请求时间 {1}",
+		"Registering node with API...":                    "正在向 API 注册节点...",
+		"Registered successfully - Node ID: {}":           "注册成功 - 节点 ID：{1}",
+		"[{}] Requesting challenge...":                    "[{1}] 正在请求挑战...",
+		"Challenge: {} (Block #{})":                       "挑战：{1}（区块 #{2}）",
+		"FAILED: {}":                                      "失败：{1}",
+		"HELD: {}":                                        "暂缓：{1}",
+		"PARTIAL: {}":                                     "部分失败：{1}",
+		"Query time: {}ms":                                "查询耗时：{1}ms",
+		"PASSED (Total: {}ms)":                            "通过（总耗时：{1}ms）",
+		"Part {} ({}): {}":                                "第 {1} 部分（{2}）：{3}",
+		"[{}] Surprise challenge received":                "[{1}] 收到突击挑战",
+		"Hardware: {} CPUs, disk {}, {}":                  "硬件：{1} 个 CPU，磁盘 {2}，{3}",
+		"Network: {} (chain {})":                          "网络：{1}（链 {2}）",
+		"Server key (pinned): {}":                         "服务器密钥（固定）：{1}",
+		"Server key ({}): {}":                             "服务器密钥（{1}）：{2}",
+		"Server does not sign challenges":                 "服务器不对挑战签名",
+		"WARNING: challenge signature does not match any published server key": "警告：挑战签名与任何已公布的服务器密钥都不匹配",
+		"[{}] Summary since {}: {} passed, {} failed":                          "[{1}] 自 {2} 以来：通过 {3} 个，失败 {4} 个",
+		"proof submission error: {}":                                           "提交证明出错：{1}",
+		"POST {} attempt {} failed: {}":                                        "POST {1} 第 {2} 次尝试失败：{3}",
+		"heartbeat error: {}":                                                  "心跳出错：{1}",
+		"challenge stream error: {}":                                           "挑战推送流出错：{1}",
+		"bad pushed challenge: {}":                                             "推送的挑战无效：{1}",
+		"ignoring pushed challenge: {}":                                        "忽略推送的挑战：{1}",
+		"not answering surprise challenge {}: it didn't come from the server":  "不回答突击挑战 {1}：它不是来自服务器",
+		"surprise challenge error: {}":                                         "突击挑战出错：{1}",
+		"could not fetch server network: status {}":                            "无法获取服务器网络：状态码 {1}",
+		"could not fetch server network: {}":                                   "无法获取服务器网络：{1}",
+		"could not read chain ID from local node: {}":                          "无法从本地节点读取链 ID：{1}",
+		"could not fetch server keys: status {}":                               "无法获取服务器密钥：状态码 {1}",
+		"could not fetch server keys: {}":                                      "无法获取服务器密钥：{1}",
+		"could not fetch announcements: status {}":                             "无法获取公告：状态码 {1}",
+		"could not fetch announcements: {}":                                    "无法获取公告：{1}",
+		"Announcements:":                                                       "公告：",
+		"failed to write challenge log: {}":                                    "写入挑战日志失败：{1}",
+		"prover error: {}":                                                     "证明程序出错：{1}",
+		"cannot connect to local node: {}":                                     "无法连接本地节点：{1}",
+		"node is not fully synced - please wait for sync to complete":          "节点尚未完全同步 - 请等待同步完成",
+		"registration failed: {}":                                              "注册失败：{1}",
+		"failed to get challenge: {}":                                          "获取挑战失败：{1}",
+		"not answering challenge {}: it didn't come from the server":           "不回答挑战 {1}：它不是来自服务器",
+		"commit rejected: {}":                                                  "承诺被拒绝：{1}",
+		"heartbeat rejected: {}":                                               "心跳被拒绝：{1}",
+		"stream refused: {}":                                                   "推送流被拒绝：{1}",
+		"refusing to sign: {}":                                                 "拒绝签名：{1}",
+		"local node is on chain {}, but the server runs {} (chain {})":         "本地节点位于链 {1}，但服务器运行的是 {2}（链 {3}）",
+		"Tunnel open - the server reaches the node through this prover":        "隧道已打开 - 服务器通过本证明程序访问节点",
+		"[{}] Requesting verification...":                                      "[{1}] 正在请求验证...",
+		"tunnel error: {}":                                                     "隧道出错：{1}",
+		"bad tunnel request: {}":                                               "隧道请求无效：{1}",
+		"not relaying {}: verification doesn't call it":                        "不转发 {1}：验证不会调用它",
+		"tunnel reply rejected: {}":                                            "隧道回复被拒绝：{1}",
+		"tunnel refused: {}":                                                   "隧道被拒绝：{1}",
+		"verification refused: {}":                                             "验证被拒绝：{1}",
+	},
+
+	"vi": {
+		// Registration and requests
+		"missing required fields":                      "thiếu các trường bắt buộc",
+		"invalid signature":                            "chữ ký không hợp lệ",
+		"node not found":                               "không tìm thấy node",
+		"timestamp too old":                            "timestamp quá cũ",
+		"timestamp missing or too old":                 "thiếu timestamp hoặc timestamp quá cũ",
+		"rpc endpoint required for exposed-rpc method": "phương thức exposed-rpc cần có RPC endpoint",
+		"greenfield storage providers must use exposed-rpc with their SP endpoint": "nhà cung cấp lưu trữ Greenfield phải dùng exposed-rpc với SP endpoint của mình",
+		"wallet is banned":                                                   "ví đã bị cấm",
+		"invalid attestation signature":                                      "chữ ký xác thực phần cứng không hợp lệ",
+		"hardware can't run this node type":                                  "phần cứng không thể chạy loại node này",
+		"nodeId required":                                                    "thiếu nodeId",
+		"q required":                                                         "thiếu từ khóa q",
+		"request already used":                                               "yêu cầu đã được sử dụng",
+		"node is not active":                                                 "node không hoạt động",
+		"node is paused for maintenance":                                     "node đang tạm dừng để bảo trì",
+		"daily challenge budget used up":                                     "đã dùng hết hạn mức thử thách trong ngày",
+		"daily budget for this challenge type used up, ask again":            "đã dùng hết hạn mức trong ngày cho loại thử thách này, hãy yêu cầu lại",
+		"failed to create challenge":                                         "không tạo được thử thách",
+		"no challenge types are enabled for this node":                       "không có loại thử thách nào được bật cho node này",
+		"sign answers with message version 2":                                "hãy ký câu trả lời bằng định dạng tin nhắn phiên bản 2",
+		"challenge_type required":                                            "thiếu challenge_type",
+		"build must be 0x and a sha256 hash":                                 "build phải là 0x và một mã băm sha256",
+		"unknown message version":                                            "phiên bản tin nhắn không xác định",
+		"node is not using local-prover method":                              "node không dùng phương thức local-prover",
+		"node is not using exposed-rpc method":                               "node không dùng phương thức exposed-rpc",
+		"heartbeats are not supported for this node type":                    "loại node này không hỗ trợ heartbeat",
+		"heartbeat already received":                                         "heartbeat này đã được nhận",
+		"trusted RPC unavailable":                                            "RPC tin cậy không khả dụng",
+		"server busy, try again shortly":                                     "máy chủ đang bận, vui lòng thử lại sau",
+		"invalid or revoked api key":                                         "API key không hợp lệ hoặc đã bị thu hồi",
+		"rate limit reached, try again next minute or use an api key":        "đã đạt giới hạn tần suất, hãy thử lại sau một phút hoặc dùng API key",
+		"in maintenance, your answer will be checked when it's back":         "dịch vụ đang bảo trì, câu trả lời của bạn sẽ được kiểm tra khi dịch vụ hoạt động lại",
+		"the service is down for maintenance, try again later":               "dịch vụ đang bảo trì, hãy thử lại sau",
+		"wallet already has the most API keys allowed - revoke one first":    "ví đã có số API key tối đa - hãy thu hồi một key trước",
+		"label too long":                                                     "nhãn quá dài",
+		"node is already paused":                                             "node đã tạm dừng",
+		"node is not paused":                                                 "node không ở trạng thái tạm dừng",
+		"maintenance allowance used up for this month":                       "đã dùng hết thời gian bảo trì của tháng này",
+		"verification_method must be exposed-rpc or local-prover":            "verification_method phải là exposed-rpc hoặc local-prover",
+		"node already uses this verification method":                         "node đã dùng phương thức xác minh này",
+		"node is banned":                                                     "node đã bị cấm",
+		"endpoint unreachable: {}":                                           "không kết nối được endpoint: {1}",
+		"couldn't read the chain ID":                                         "không đọc được chain ID",
+		"node is still syncing, so challenges will fail until it catches up": "node vẫn đang đồng bộ, các thử thách sẽ thất bại cho đến khi node bắt kịp",
+		"node's head #{} is {} blocks behind the chain":                      "block mới nhất #{1} của node chậm hơn chuỗi {2} block",
+		"challenge not found or expired":                                     "không tìm thấy thử thách hoặc thử thách đã hết hạn",
+		"challenge doesn't take a commitment":                                "thử thách này không cần cam kết",
+		"answer already committed":                                           "câu trả lời đã được cam kết",
+		"commit deadline passed":                                             "đã quá hạn cam kết",
+		"to use a tunnel, register tunnel:// with nothing after it":          "để dùng tunnel, hãy đặt rpc endpoint là tunnel:// và không thêm gì phía sau",
+		"storage providers can't use a tunnel":                               "nhà cung cấp lưu trữ không thể dùng tunnel",
+		"node isn't registered for a tunnel":                                 "node không đăng ký dùng tunnel",
+		"tunnel open is older than the current one":                          "yêu cầu mở tunnel cũ hơn tunnel hiện tại",
+		"no call waiting with that ID":                                       "không có lời gọi nào đang chờ với ID đó",
+		"tunnel isn't open yet, start the prover with --tunnel":              "tunnel chưa được mở, hãy chạy prover với --tunnel",
+
+		// Two-factor
+		"two-factor is already on for this wallet - turn it off first": "ví này đã bật xác thực hai lớp - hãy tắt trước",
+		"no two-factor enrollment to confirm":                          "không có đăng ký xác thực hai lớp nào để xác nhận",
+		"two-factor isn't on for this wallet":                          "ví này chưa bật xác thực hai lớp",
+		"this wallet has two-factor on - send totp_code":               "ví này đã bật xác thực hai lớp - hãy gửi totp_code",
+		"wrong or already used two-factor code":                        "mã xác thực hai lớp sai hoặc đã được dùng",
+		"too many wrong two-factor codes - try again later":            "nhập sai mã xác thực hai lớp quá nhiều lần - hãy thử lại sau",
+
+		// Hardware attestation
+		"{} needs at least {} CPUs, machine has {}": "{1} cần ít nhất {2} CPU, máy có {3}",
+		"unknown disk class {}":                     "loại ổ đĩa không xác định {1}",
+		"{} needs a disk over {}GB, machine has {}": "{1} cần ổ đĩa trên {2}GB, máy có {3}",
+
+		// Failure reasons
+		"challenge expired":                            "thử thách đã hết hạn",
+		"incorrect answer":                             "câu trả lời không đúng",
+		"response too slow":                            "phản hồi quá chậm",
+		"answer wasn't committed before the deadline":  "câu trả lời không được cam kết trước hạn",
+		"answer doesn't match commitment":              "câu trả lời không khớp với cam kết",
+		"not answered":                                 "chưa trả lời",
+		"no RPC endpoint configured":                   "chưa cấu hình RPC endpoint",
+		"answer was signed for a {} challenge, not {}": "câu trả lời được ký cho thử thách loại {1}, không phải {2}",
+		"{} of {} parts incorrect":                     "{1} trên {2} phần không đúng",
+		"answer isn't a list of {} answers":            "câu trả lời không phải là danh sách gồm {1} câu trả lời",
+		"node is on chain {}, {} {} is chain {}":       "node đang ở chain {1}, còn {2} {3} là chain {4}",
+		"trusted node error: {}":                       "lỗi node tin cậy: {1}",
+
+		// Lookups, reports and API keys
+		"response signing is not enabled on this server":                  "máy chủ này chưa bật ký phản hồi",
+		"failed to encode response":                                       "không mã hóa được phản hồi",
+		"failed to sign response":                                         "không ký được phản hồi",
+		"wallet not found":                                                "không tìm thấy ví",
+		"addresses required":                                              "thiếu addresses",
+		"at most {} addresses per request":                                "tối đa {1} địa chỉ mỗi yêu cầu",
+		"ids required (comma separated)":                                  "thiếu ids (phân tách bằng dấu phẩy)",
+		"at most {} nodes per comparison":                                 "tối đa {1} node mỗi lần so sánh",
+		"limit must be 1-{}":                                              "limit phải từ 1 đến {1}",
+		"month must be YYYY-MM":                                           "month phải có dạng YYYY-MM",
+		"client certificates are only for exposed-rpc JSON-RPC endpoints": "chứng chỉ client chỉ dùng cho JSON-RPC endpoint của exposed-rpc",
+		"admin key or node signature required":                            "cần khóa admin hoặc chữ ký của node",
+		"challenge already validated":                                     "thử thách đã được kiểm tra",
+		"node unreachable":                                                "không kết nối được node",
+		"no failed verification for this challenge":                       "không có lần xác minh thất bại nào cho thử thách này",
+		"no submissions kept for this challenge":                          "không có lần gửi nào được lưu cho thử thách này",
+		"no reclassification proposed for this node":                      "không có đề xuất phân loại lại nào cho node này",
+		"proposed type has changed":                                       "loại được đề xuất đã thay đổi",
+		"evidence too long (max {} characters)":                           "bằng chứng quá dài (tối đa {1} ký tự)",
+		"you already have an open report against this node":               "bạn đã có một báo cáo đang mở về node này",
+		"too many open reports - wait for some to be reviewed":            "quá nhiều báo cáo đang mở - hãy chờ một số được xem xét",
+		"too many of your reports have been dismissed":                    "quá nhiều báo cáo của bạn đã bị bác bỏ",
+		"api key not found":                                               "không tìm thấy khóa API",
+		"api key was already revoked":                                     "khóa API đã bị thu hồi",
+		"missing authorization header":                                    "thiếu header Authorization",
+		"invalid api key":                                                 "khóa API không hợp lệ",
+		"Idempotency-Key too long":                                        "Idempotency-Key quá dài",
+		"could not read request body":                                     "không đọc được nội dung yêu cầu",
+		"Idempotency-Key was already used with a different request":       "Idempotency-Key đã được dùng cho một yêu cầu khác",
+		"a request with this Idempotency-Key is still in progress":        "một yêu cầu với Idempotency-Key này vẫn đang được xử lý",
+
+		// Admin
+		"restore failed: {}":                                 "khôi phục thất bại: {1}",
+		"not a store backup":                                 "không phải bản sao lưu của store",
+		"a recompute is already running":                     "đang có một lần tính lại chạy",
+		"no recompute has run":                               "chưa có lần tính lại nào",
+		"config reload not available":                        "không thể tải lại cấu hình",
+		"since must be a unix timestamp in milliseconds":     "since phải là unix timestamp tính bằng mili giây",
+		"failed to salt the export":                          "không thêm salt được cho bản xuất",
+		"action required (clear, warn, or ban)":              "thiếu action (clear, warn hoặc ban)",
+		"invalid action - use clear, warn, or ban":           "action không hợp lệ - hãy dùng clear, warn hoặc ban",
+		"action required (apply or dismiss)":                 "thiếu action (apply hoặc dismiss)",
+		"entryId must be a ledger entry number":              "entryId phải là số của một mục sổ cái",
+		"reason required":                                    "thiếu reason",
+		"non-zero amount and reason required":                "cần amount khác 0 và reason",
+		"ledger entry not found":                             "không tìm thấy mục sổ cái",
+		"a reversal can't be reversed":                       "không thể đảo ngược một mục đảo ngược",
+		"ledger entry was already reversed":                  "mục sổ cái đã được đảo ngược",
+		"that would take the node below zero points":         "điều đó sẽ khiến điểm của node xuống dưới 0",
+		"epoch must be a number":                             "epoch phải là một số",
+		"epoch not found":                                    "không tìm thấy epoch",
+		"an epoch has to end after the last one":             "epoch phải kết thúc sau epoch trước",
+		"an epoch can't end in the future":                   "epoch không thể kết thúc trong tương lai",
+		"no new points to pay out":                           "không có điểm mới để chi trả",
+		"wallet is not banned":                               "ví không bị cấm",
+		"this needs a second, different admin to confirm it": "cần một admin khác xác nhận",
+		"wallet has no two-factor":                           "ví này chưa bật xác thực hai lớp",
+		"limit must be a whole number":                       "limit phải là số nguyên",
+		"title required":                                     "thiếu title",
+		"unknown kind {}":                                    "kind không xác định {1}",
+		"title is limited to {} bytes and body to {}":        "title tối đa {1} byte và body tối đa {2} byte",
+		"expires_at is in the past":                          "expires_at nằm trong quá khứ",
+		"announcement not found":                             "không tìm thấy thông báo",
+		"invalid request":                                    "yêu cầu không hợp lệ",
+		"until is in the past":                               "until nằm trong quá khứ",
+		"service is not in maintenance":                      "dịch vụ không ở chế độ bảo trì",
+		"plan required":                                      "thiếu plan",
+		"unknown plan {}":                                    "gói không xác định {1}",
+		"percent required (0-100)":                           "thiếu percent (0-100)",
+		"since must be an entry number":                      "since phải là số thứ tự của một mục",
+		"failed to sign log":                                 "không ký được nhật ký",
+		"missing required fields: wallet_address, node_type, verification_method": "thiếu các trường bắt buộc: wallet_address, node_type, verification_method",
+
+		// Prover console
+		"Local node connected - Block #{}":                "Đã kết nối node cục bộ - Block #{1}",
+		"Synced: {}":                                      "Đã đồng bộ: {1}",
+		"Starting proof loop...":                          "Bắt đầu vòng lặp chứng minh...",
+		"Stopping prover...":                              "Đang dừng prover...",
+		"Rate limited by the server - next request at {}": "Bị server giới hạn tần suất - yêu cầu tiếp theo lúc {1}",
+		"Registering node with API...":                    "Đang đăng ký node với API...",
+		"Registered successfully - Node ID: {}":           "Đăng ký thành công - Node ID: {1}",
+		"[{}] Requesting challenge...":                    "[{1}] Đang yêu cầu thử thách...",
+		"Challenge: {} (Block #{})":                       "Thử thách: {1} (Block #{2})",
+		"FAILED: {}":                                      "THẤT BẠI: {1}",
+		"HELD: {}":                                        "TẠM GIỮ: {1}",
+		"PARTIAL: {}":                                     "MỘT PHẦN: {1}",
+		"Query time: {}ms":                                "Thời gian truy vấn: {1}ms",
+		"PASSED (Total: {}ms)":                            "ĐẠT (Tổng: {1}ms)",
+		"Part {} ({}): {}":                                "Phần {1} ({2}): {3}",
+		"[{}] Surprise challenge received":                "[{1}] Nhận được thử thách đột xuất",
+		"Hardware: {} CPUs, disk {}, {}":                  "Phần cứng: {1} CPU, ổ đĩa {2}, {3}",
+		"Network: {} (chain {})":                          "Mạng: {1} (chain {2})",
+		"Server key (pinned): {}":                         "Khóa server (cố định): {1}",
+		"Server key ({}): {}":                             "Khóa server ({1}): {2}",
+		"Server does not sign challenges":                 "Server không ký thử thách",
+		"WARNING: challenge signature does not match any published server key": "CẢNH BÁO: chữ ký thử thách không khớp với khóa server nào đã công bố",
+		"[{}] Summary since {}: {} passed, {} failed":                          "[{1}] Tổng kết từ {2}: {3} đạt, {4} thất bại",
+		"proof submission error: {}":                                           "lỗi gửi bằng chứng: {1}",
+		"POST {} attempt {} failed: {}":                                        "POST {1} lần thử {2} thất bại: {3}",
+		"heartbeat error: {}":                                                  "lỗi heartbeat: {1}",
+		"challenge stream error: {}":                                           "lỗi luồng thử thách: {1}",
+		"bad pushed challenge: {}":                                             "thử thách được đẩy không hợp lệ: {1}",
+		"ignoring pushed challenge: {}":                                        "bỏ qua thử thách được đẩy: {1}",
+		"not answering surprise challenge {}: it didn't come from the server":  "không trả lời thử thách đột xuất {1}: nó không đến từ server",
+		"surprise challenge error: {}":                                         "lỗi thử thách đột xuất: {1}",
+		"could not fetch server network: status {}":                            "không lấy được mạng của server: mã trạng thái {1}",
+		"could not fetch server network: {}":                                   "không lấy được mạng của server: {1}",
+		"could not read chain ID from local node: {}":                          "không đọc được chain ID từ node cục bộ: {1}",
+		"could not fetch server keys: status {}":                               "không lấy được khóa server: mã trạng thái {1}",
+		"could not fetch server keys: {}":                                      "không lấy được khóa server: {1}",
+		"could not fetch announcements: status {}":                             "không lấy được thông báo: mã trạng thái {1}",
+		"could not fetch announcements: {}":                                    "không lấy được thông báo: {1}",
+		"Announcements:":                                                       "Thông báo:",
+		"failed to write challenge log: {}":                                    "không ghi được nhật ký thử thách: {1}",
+		"prover error: {}":                                                     "lỗi prover: {1}",
+		"cannot connect to local node: {}":                                     "không kết nối được node cục bộ: {1}",
+		"node is not fully synced - please wait for sync to complete":          "node chưa đồng bộ xong - vui lòng đợi đồng bộ hoàn tất",
+		"registration failed: {}":                                              "đăng ký thất bại: {1}",
+		"failed to get challenge: {}":                                          "không lấy được thử thách: {1}",
+		"not answering challenge {}: it didn't come from the server":           "không trả lời thử thách {1}: nó không đến từ server",
+		"commit rejected: {}":                                                  "cam kết bị từ chối: {1}",
+		"heartbeat rejected: {}":                                               "heartbeat bị từ chối: {1}",
+		"stream refused: {}":                                                   "luồng bị từ chối: {1}",
+		"refusing to sign: {}":                                                 "từ chối ký: {1}",
+		"local node is on chain {}, but the server runs {} (chain {})":         "node cục bộ ở chain {1}, nhưng server chạy {2} (chain {3})",
+		"Tunnel open - the server reaches the node through this prover":        "Tunnel đã mở - server truy cập node qua prover này",
+		"[{}] Requesting verification...":                                      "[{1}] Đang yêu cầu xác minh...",
+		"tunnel error: {}":                                                     "lỗi tunnel: {1}",
+		"bad tunnel request: {}":                                               "yêu cầu tunnel không hợp lệ: {1}",
+		"not relaying {}: verification doesn't call it":                        "không chuyển tiếp {1}: việc xác minh không gọi phương thức này",
+		"tunnel reply rejected: {}":                                            "phản hồi tunnel bị từ chối: {1}",
+		"tunnel refused: {}":                                                   "tunnel bị từ chối: {1}",
+		"verification refused: {}":                                             "xác minh bị từ chối: {1}",
+	},
+
+	"ru": {
+		// Registration and requests
+		"missing required fields":                      "отсутствуют обязательные поля",
+		"invalid signature":                            "неверная подпись",
+		"node not found":                               "нода не найдена",
+		"timestamp too old":                            "слишком старая метка времени",
+		"timestamp missing or too old":                 "метка времени отсутствует или слишком старая",
+		"rpc endpoint required for exposed-rpc method": "для метода exposed-rpc требуется RPC-эндпоинт",
+		"greenfield storage providers must use exposed-rpc with their SP endpoint": "провайдеры хранения Greenfield должны использовать exposed-rpc со своим SP-эндпоинтом",
+		"wallet is banned":                                                   "кошелёк заблокирован",
+		"invalid attestation signature":                                      "неверная подпись аттестации оборудования",
+		"hardware can't run this node type":                                  "это оборудование не может запускать ноду этого типа",
+		"nodeId required":                                                    "требуется nodeId",
+		"q required":                                                         "требуется параметр q",
+		"request already used":                                               "запрос уже использован",
+		"node is not active":                                                 "нода неактивна",
+		"node is paused for maintenance":                                     "нода приостановлена на обслуживание",
+		"daily challenge budget used up":                                     "дневной лимит заданий исчерпан",
+		"daily budget for this challenge type used up, ask again":            "дневной лимит заданий этого типа исчерпан, запросите снова",
+		"failed to create challenge":                                         "не удалось создать задание",
+		"no challenge types are enabled for this node":                       "для этой ноды не включён ни один тип заданий",
+		"sign answers with message version 2":                                "подписывайте ответы сообщением версии 2",
+		"challenge_type required":                                            "требуется challenge_type",
+		"build must be 0x and a sha256 hash":                                 "build должен быть 0x и хешем sha256",
+		"unknown message version":                                            "неизвестная версия сообщения",
+		"node is not using local-prover method":                              "нода не использует метод local-prover",
+		"node is not using exposed-rpc method":                               "нода не использует метод exposed-rpc",
+		"heartbeats are not supported for this node type":                    "heartbeat не поддерживается для этого типа нод",
+		"heartbeat already received":                                         "этот heartbeat уже получен",
+		"trusted RPC unavailable":                                            "доверенный RPC недоступен",
+		"server busy, try again shortly":                                     "сервер занят, повторите попытку позже",
+		"invalid or revoked api key":                                         "API-ключ недействителен или отозван",
+		"rate limit reached, try again next minute or use an api key":        "достигнут лимит запросов, повторите через минуту или используйте API-ключ",
+		"in maintenance, your answer will be checked when it's back":         "сервис на обслуживании, ваш ответ будет проверен после его возвращения",
+		"the service is down for maintenance, try again later":               "сервис на обслуживании, повторите позже",
+		"wallet already has the most API keys allowed - revoke one first":    "у кошелька уже максимум API-ключей - сначала отзовите один",
+		"label too long":                                                     "слишком длинная метка",
+		"node is already paused":                                             "нода уже приостановлена",
+		"node is not paused":                                                 "нода не приостановлена",
+		"maintenance allowance used up for this month":                       "лимит обслуживания на этот месяц исчерпан",
+		"verification_method must be exposed-rpc or local-prover":            "verification_method должен быть exposed-rpc или local-prover",
+		"node already uses this verification method":                         "нода уже использует этот метод проверки",
+		"node is banned":                                                     "нода заблокирована",
+		"endpoint unreachable: {}":                                           "эндпоинт недоступен: {1}",
+		"couldn't read the chain ID":                                         "не удалось прочитать chain ID",
+		"node is still syncing, so challenges will fail until it catches up": "нода ещё синхронизируется, задания будут проваливаться, пока она не догонит сеть",
+		"node's head #{} is {} blocks behind the chain":                      "последний блок ноды #{1} отстаёт от сети на {2} блоков",
+		"challenge not found or expired":                                     "задание не найдено или истекло",
+		"challenge doesn't take a commitment":                                "заданию не требуется коммит",
+		"answer already committed":                                           "ответ уже закоммичен",
+		"commit deadline passed":                                             "срок коммита истёк",
+		"to use a tunnel, register tunnel:// with nothing after it":          "чтобы использовать туннель, укажите RPC-эндпоинт tunnel:// и ничего после него",
+		"storage providers can't use a tunnel":                               "провайдеры хранилища не могут использовать туннель",
+		"node isn't registered for a tunnel":                                 "нода не зарегистрирована для работы через туннель",
+		"tunnel open is older than the current one":                          "запрос на открытие туннеля старше текущего туннеля",
+		"no call waiting with that ID":                                       "нет ожидающего вызова с таким ID",
+		"tunnel isn't open yet, start the prover with --tunnel":              "туннель ещё не открыт, запустите прувер с --tunnel",
+
+		// Two-factor
+		"two-factor is already on for this wallet - turn it off first": "двухфакторная защита для этого кошелька уже включена - сначала выключите её",
+		"no two-factor enrollment to confirm":                          "нет подключения двухфакторной защиты для подтверждения",
+		"two-factor isn't on for this wallet":                          "двухфакторная защита для этого кошелька не включена",
+		"this wallet has two-factor on - send totp_code":               "для этого кошелька включена двухфакторная защита - передайте totp_code",
+		"wrong or already used two-factor code":                        "неверный или уже использованный код двухфакторной защиты",
+		"too many wrong two-factor codes - try again later":            "слишком много неверных кодов двухфакторной защиты - попробуйте позже",
+
+		// Hardware attestation
+		"{} needs at least {} CPUs, machine has {}": "{1} требует не менее {2} CPU, на машине {3}",
+		"unknown disk class {}":                     "неизвестный класс диска {1}",
+		"{} needs a disk over {}GB, machine has {}": "{1} требует диск более {2} ГБ, на машине {3}",
+
+		// Failure reasons
+		"challenge expired":                            "задание истекло",
+		"incorrect answer":                             "неверный ответ",
+		"response too slow":                            "слишком медленный ответ",
+		"answer wasn't committed before the deadline":  "ответ не был закоммичен до дедлайна",
+		"answer doesn't match commitment":              "ответ не совпадает с коммитом",
+		"not answered":                                 "нет ответа",
+		"no RPC endpoint configured":                   "RPC-эндпоинт не настроен",
+		"answer was signed for a {} challenge, not {}": "ответ подписан для задания типа {1}, а не {2}",
+		"{} of {} parts incorrect":                     "неверных частей: {1} из {2}",
+		"answer isn't a list of {} answers":            "ответ не является списком из {1} ответов",
+		"node is on chain {}, {} {} is chain {}":       "нода в сети с chain ID {1}, а {2} {3} — это chain ID {4}",
+		"trusted node error: {}":                       "ошибка доверенной ноды: {1}",
+
+		// Lookups, reports and API keys
+		"response signing is not enabled on this server":                  "подпись ответов на этом сервере не включена",
+		"failed to encode response":                                       "не удалось закодировать ответ",
+		"failed to sign response":                                         "не удалось подписать ответ",
+		"wallet not found":                                                "кошелёк не найден",
+		"addresses required":                                              "требуется addresses",
+		"at most {} addresses per request":                                "не более {1} адресов за запрос",
+		"ids required (comma separated)":                                  "требуется ids (через запятую)",
+		"at most {} nodes per comparison":                                 "не более {1} нод в одном сравнении",
+		"limit must be 1-{}":                                              "limit должен быть от 1 до {1}",
+		"month must be YYYY-MM":                                           "month должен быть в формате YYYY-MM",
+		"client certificates are only for exposed-rpc JSON-RPC endpoints": "клиентские сертификаты только для JSON-RPC-эндпоинтов exposed-rpc",
+		"admin key or node signature required":                            "требуется ключ администратора или подпись ноды",
+		"challenge already validated":                                     "задание уже проверено",
+		"node unreachable":                                                "нода недоступна",
+		"no failed verification for this challenge":                       "для этого задания нет неудачной проверки",
+		"no submissions kept for this challenge":                          "для этого задания не сохранено отправок",
+		"no reclassification proposed for this node":                      "для этой ноды не предложена переклассификация",
+		"proposed type has changed":                                       "предложенный тип изменился",
+		"evidence too long (max {} characters)":                           "доказательства слишком длинные (не более {1} символов)",
+		"you already have an open report against this node":               "у вас уже есть открытая жалоба на эту ноду",
+		"too many open reports - wait for some to be reviewed":            "слишком много открытых жалоб - дождитесь рассмотрения некоторых",
+		"too many of your reports have been dismissed":                    "слишком много ваших жалоб было отклонено",
+		"api key not found":                                               "API-ключ не найден",
+		"api key was already revoked":                                     "API-ключ уже отозван",
+		"missing authorization header":                                    "отсутствует заголовок Authorization",
+		"invalid api key":                                                 "неверный API-ключ",
+		"Idempotency-Key too long":                                        "Idempotency-Key слишком длинный",
+		"could not read request body":                                     "не удалось прочитать тело запроса",
+		"Idempotency-Key was already used with a different request":       "Idempotency-Key уже использован с другим запросом",
+		"a request with this Idempotency-Key is still in progress":        "запрос с этим Idempotency-Key ещё выполняется",
+
+		// Admin
+		"restore failed: {}":                                 "не удалось восстановить: {1}",
+		"not a store backup":                                 "это не резервная копия хранилища",
+		"a recompute is already running":                     "пересчёт уже выполняется",
+		"no recompute has run":                               "пересчёт ещё не запускался",
+		"config reload not available":                        "перезагрузка конфигурации недоступна",
+		"since must be a unix timestamp in milliseconds":     "since должен быть unix-временем в миллисекундах",
+		"failed to salt the export":                          "не удалось добавить соль к выгрузке",
+		"action required (clear, warn, or ban)":              "требуется action (clear, warn или ban)",
+		"invalid action - use clear, warn, or ban":           "неверный action - используйте clear, warn или ban",
+		"action required (apply or dismiss)":                 "требуется action (apply или dismiss)",
+		"entryId must be a ledger entry number":              "entryId должен быть номером записи журнала",
+		"reason required":                                    "требуется reason",
+		"non-zero amount and reason required":                "требуются ненулевой amount и reason",
+		"ledger entry not found":                             "запись журнала не найдена",
+		"a reversal can't be reversed":                       "сторно нельзя сторнировать",
+		"ledger entry was already reversed":                  "запись журнала уже сторнирована",
+		"that would take the node below zero points":         "это опустит баллы ноды ниже нуля",
+		"epoch must be a number":                             "epoch должен быть числом",
+		"epoch not found":                                    "эпоха не найдена",
+		"an epoch has to end after the last one":             "эпоха должна заканчиваться после предыдущей",
+		"an epoch can't end in the future":                   "эпоха не может заканчиваться в будущем",
+		"no new points to pay out":                           "нет новых баллов для выплаты",
+		"wallet is not banned":                               "кошелёк не заблокирован",
+		"this needs a second, different admin to confirm it": "это должен подтвердить второй, другой администратор",
+		"wallet has no two-factor":                           "у кошелька нет двухфакторной защиты",
+		"limit must be a whole number":                       "limit должен быть целым числом",
+		"title required":                                     "требуется title",
+		"unknown kind {}":                                    "неизвестный kind {1}",
+		"title is limited to {} bytes and body to {}":        "title ограничен {1} байтами, а body - {2}",
+		"expires_at is in the past":                          "expires_at в прошлом",
+		"announcement not found":                             "объявление не найдено",
+		"invalid request":                                    "неверный запрос",
+		"until is in the past":                               "until в прошлом",
+		"service is not in maintenance":                      "сервис не на обслуживании",
+		"plan required":                                      "требуется plan",
+		"unknown plan {}":                                    "неизвестный тариф {1}",
+		"percent required (0-100)":                           "требуется percent (0-100)",
+		"since must be an entry number":                      "since должен быть номером записи",
+		"failed to sign log":                                 "не удалось подписать журнал",
+		"missing required fields: wallet_address, node_type, verification_method": "отсутствуют обязательные поля: wallet_address, node_type, verification_method",
+
+		// Prover console
+		"Local node connected - Block #{}":                "Локальная нода подключена - блок #{1}",
+		"Synced: {}":                                      "Синхронизирована: {1}",
+		"Starting proof loop...":                          "Запуск цикла доказательств...",
+		"Stopping prover...":                              "Остановка прувера...",
+		"Rate limited by the server - next request at {}": "Сервер ограничил частоту запросов - следующий запрос в {1}",
+		"Registering node with API...":                    "Регистрация ноды в API...",
+		"Registered successfully - Node ID: {}":           "Успешная регистрация - ID ноды: {1}",
+		"[{}] Requesting challenge...":                    "[{1}] Запрос задания...",
+		"Challenge: {} (Block #{})":                       "Задание: {1} (блок #{2})",
+		"FAILED: {}":                                      "ПРОВАЛ: {1}",
+		"HELD: {}":                                        "ОТЛОЖЕНО: {1}",
+		"PARTIAL: {}":                                     "ЧАСТИЧНО: {1}",
+		"Query time: {}ms":                                "Время запроса: {1} мс",
+		"PASSED (Total: {}ms)":                            "ПРОЙДЕНО (всего: {1} мс)",
+		"Part {} ({}): {}":                                "Часть {1} ({2}): {3}",
+		"[{}] Surprise challenge received":                "[{1}] Получено внезапное задание",
+		"Hardware: {} CPUs, disk {}, {}":                  "Оборудование: CPU {1}, диск {2}, {3}",
+		"Network: {} (chain {})":                          "Сеть: {1} (chain {2})",
+		"Server key (pinned): {}":                         "Ключ сервера (закреплён): {1}",
+		"Server key ({}): {}":                             "Ключ сервера ({1}): {2}",
+		"Server does not sign challenges":                 "Сервер не подписывает задания",
+		"WARNING: challenge signature does not match any published server key": "ВНИМАНИЕ: подпись задания не совпадает ни с одним опубликованным ключом сервера",
+		"[{}] Summary since {}: {} passed, {} failed":                          "[{1}] Итог с {2}: пройдено {3}, провалено {4}",
+		"proof submission error: {}":                                           "ошибка отправки доказательства: {1}",
+		"POST {} attempt {} failed: {}":                                        "POST {1}, попытка {2} не удалась: {3}",
+		"heartbeat error: {}":                                                  "ошибка heartbeat: {1}",
+		"challenge stream error: {}":                                           "ошибка потока заданий: {1}",
+		"bad pushed challenge: {}":                                             "некорректное присланное задание: {1}",
+		"ignoring pushed challenge: {}":                                        "присланное задание проигнорировано: {1}",
+		"not answering surprise challenge {}: it didn't come from the server":  "не отвечаем на внезапное задание {1}: оно пришло не от сервера",
+		"surprise challenge error: {}":                                         "ошибка внезапного задания: {1}",
+		"could not fetch server network: status {}":                            "не удалось получить сеть сервера: статус {1}",
+		"could not fetch server network: {}":                                   "не удалось получить сеть сервера: {1}",
+		"could not read chain ID from local node: {}":                          "не удалось прочитать chain ID с локальной ноды: {1}",
+		"could not fetch server keys: status {}":                               "не удалось получить ключи сервера: статус {1}",
+		"could not fetch server keys: {}":                                      "не удалось получить ключи сервера: {1}",
+		"could not fetch announcements: status {}":                             "не удалось получить объявления: статус {1}",
+		"could not fetch announcements: {}":                                    "не удалось получить объявления: {1}",
+		"Announcements:":                                                       "Объявления:",
+		"failed to write challenge log: {}":                                    "не удалось записать журнал заданий: {1}",
+		"prover error: {}":                                                     "ошибка прувера: {1}",
+		"cannot connect to local node: {}":                                     "не удалось подключиться к локальной ноде: {1}",
+		"node is not fully synced - please wait for sync to complete":          "нода не полностью синхронизирована - дождитесь окончания синхронизации",
+		"registration failed: {}":                                              "регистрация не удалась: {1}",
+		"failed to get challenge: {}":                                          "не удалось получить задание: {1}",
+		"not answering challenge {}: it didn't come from the server":           "не отвечаем на задание {1}: оно пришло не от сервера",
+		"commit rejected: {}":                                                  "коммит отклонён: {1}",
+		"heartbeat rejected: {}":                                               "heartbeat отклонён: {1}",
+		"stream refused: {}":                                                   "поток отклонён: {1}",
+		"refusing to sign: {}":                                                 "отказ в подписи: {1}",
+		"local node is on chain {}, but the server runs {} (chain {})":         "локальная нода в сети chain {1}, а сервер работает в {2} (chain {3})",
+		"Tunnel open - the server reaches the node through this prover":        "Туннель открыт - сервер обращается к ноде через этот прувер",
+		"[{}] Requesting verification...":                                      "[{1}] Запрос проверки...",
+		"tunnel error: {}":                                                     "ошибка туннеля: {1}",
+		"bad tunnel request: {}":                                               "некорректный запрос через туннель: {1}",
+		"not relaying {}: verification doesn't call it":                        "{1} не передаётся: проверка его не вызывает",
+		"tunnel reply rejected: {}":                                            "ответ через туннель отклонён: {1}",
+		"tunnel refused: {}":                                                   "туннель отклонён: {1}",
+		"verification refused: {}":                                             "проверка отклонена: {1}",
+	},
+}
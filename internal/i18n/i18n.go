@@ -0,0 +1,145 @@
+// Package i18n translates what we tell node operators (API errors,
+// challenge failure reasons and the prover's console output) into the
+// language they ask for: Accept-Language on the server, --lang on the
+// prover. Code and server logs stay in English: the English message is the
+// catalog key, with {} standing in for the parts that vary, such as a node
+// type or a block number.
+package i18n
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// What untranslated messages are in
+const English = "en"
+
+// One translated message. Placeholders in the translation are numbered,
+// {1} for the first {} in the English, since word order differs.
+type entry struct {
+	pattern     *regexp.Regexp
+	literal     int // Characters outside placeholders
+	translation string
+}
+
+// Per language, exact messages and ones with placeholders
+type compiled struct {
+	exact    map[string]string
+	patterns []entry
+}
+
+var languages = compile(catalog)
+
+func compile(catalog map[string]map[string]string) map[string]compiled {
+	out := make(map[string]compiled, len(catalog))
+	for lang, messages := range catalog {
+		c := compiled{exact: make(map[string]string)}
+		for message, translation := range messages {
+			if !strings.Contains(message, "{}") {
+				c.exact[message] = translation
+				continue
+			}
+			parts := strings.Split(message, "{}")
+			for i, part := range parts {
+				parts[i] = regexp.QuoteMeta(part)
+			}
+			c.patterns = append(c.patterns, entry{
+				pattern:     regexp.MustCompile("^" + strings.Join(parts, "(.+?)") + "$"),
+				literal:     len(message) - 2*(len(parts)-1),
+				translation: translation,
+			})
+		}
+		// Most fixed text first, so "Server key (pinned): {}" wins over
+		// "Server key ({}): {}"
+		sort.Slice(c.patterns, func(i, j int) bool {
+			return c.patterns[i].literal > c.patterns[j].literal
+		})
+		out[lang] = c
+	}
+	return out
+}
+
+// Languages with a catalog, besides English
+func Languages() []string {
+	out := make([]string, 0, len(languages))
+	for lang := range languages {
+		out = append(out, lang)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// The best language we have for an Accept-Language header, e.g.
+// "vi-VN,vi;q=0.9,en;q=0.8". English when nothing listed is supported.
+func Negotiate(header string) string {
+	type choice struct {
+		lang string
+		q    float64
+	}
+	var choices []choice
+	for _, item := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if lang := supported(tag); lang != "" && q > 0 {
+			choices = append(choices, choice{lang, q})
+		}
+	}
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+	if len(choices) == 0 {
+		return English
+	}
+	return choices[0].lang
+}
+
+// Our language for a language tag, "" if we don't have one
+func supported(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	subtags := strings.Split(strings.ReplaceAll(tag, "_", "-"), "-")
+	primary := subtags[0]
+	if primary == English {
+		return English
+	}
+	if primary == "zh" && len(subtags) > 1 {
+		// The catalog is Simplified Chinese; Traditional readers get English
+		switch subtags[1] {
+		case "hant", "tw", "hk", "mo":
+			return ""
+		}
+	}
+	if _, ok := languages[primary]; ok {
+		return primary
+	}
+	return ""
+}
+
+// A message in a language, unchanged when there's no translation for it
+func Translate(lang, message string) string {
+	c, ok := languages[lang]
+	if !ok || message == "" {
+		return message
+	}
+	if translation, ok := c.exact[message]; ok {
+		return translation
+	}
+	for _, e := range c.patterns {
+		values := e.pattern.FindStringSubmatch(message)
+		if values == nil {
+			continue
+		}
+		translation := e.translation
+		for i, value := range values[1:] {
+			translation = strings.ReplaceAll(translation, "{"+strconv.Itoa(i+1)+"}", Translate(lang, value))
+		}
+		return translation
+	}
+	return message
+}
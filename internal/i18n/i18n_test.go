@@ -0,0 +1,84 @@
+package i18n
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"vi", "vi"},
+		{"vi-VN,vi;q=0.9,en;q=0.8", "vi"},
+		{"en-US,en;q=0.9,zh-CN;q=0.8", "en"},
+		{"fr-FR,ru;q=0.5", "ru"},
+		{"de;q=0.9, zh_CN;q=0.7", "zh"},
+		{"zh-TW", "en"},
+		{"zh-Hant-HK,ru;q=0.3", "ru"},
+		{"ru;q=0", "en"},
+		{"ru;q=abc,vi;q=0.2", "vi"},
+		{"*", "en"},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.header); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		lang, message, want string
+	}{
+		{"en", "invalid signature", "invalid signature"},
+		{"vi", "invalid signature", "chữ ký không hợp lệ"},
+		{"zh", "3 of 5 parts incorrect", "5 个部分中有 3 个错误"},
+		{"ru", "answer was signed for a block-hash challenge, not state-balance", "ответ подписан для задания типа block-hash, а не state-balance"},
+		{"zh", "trusted node error: node not found", "可信节点错误：未找到节点"},
+		{"vi", "something we never translated", "something we never translated"},
+		{"fr", "invalid signature", "invalid signature"},
+	}
+	for _, tt := range tests {
+		if got := Translate(tt.lang, tt.message); got != tt.want {
+			t.Errorf("%s %q: got %q, want %q", tt.lang, tt.message, got, tt.want)
+		}
+	}
+}
+
+// Every language should cover the same messages, and use each of a
+// message's placeholders exactly once
+func TestCatalogComplete(t *testing.T) {
+	for lang, messages := range catalog {
+		for other, otherMessages := range catalog {
+			for message := range otherMessages {
+				if _, ok := messages[message]; !ok {
+					t.Errorf("%s has %q but %s doesn't", other, message, lang)
+				}
+			}
+		}
+
+		for message, translation := range messages {
+			for i := 1; i <= strings.Count(message, "{}"); i++ {
+				if n := strings.Count(translation, "{"+strconv.Itoa(i)+"}"); n != 1 {
+					t.Errorf("%s %q: placeholder {%d} used %d times", lang, message, i, n)
+				}
+			}
+		}
+	}
+}
+
+func TestTranslatePrefersSpecificPattern(t *testing.T) {
+	if got := Translate("vi", "Server key (pinned): 0xabc"); got != "Khóa server (cố định): 0xabc" {
+		t.Errorf("pinned key: got %q", got)
+	}
+	if got := Translate("vi", "Server key (active): 0xabc"); got != "Khóa server (active): 0xabc" {
+		t.Errorf("advertised key: got %q", got)
+	}
+	if got := Translate("ru", "could not fetch server keys: status 502"); got != "не удалось получить ключи сервера: статус 502" {
+		t.Errorf("status error: got %q", got)
+	}
+}
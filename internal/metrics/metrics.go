@@ -0,0 +1,222 @@
+// Package metrics keeps per-route request latency and error counts, and a
+// log of slow requests with where each one spent its time, so production
+// bottlenecks show up without attaching a profiler.
+package metrics
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sampleWindow = 1000 // Latency samples kept per route
+	slowLogSize  = 100  // Slow requests kept for the admin API
+)
+
+// Counters and latency for one route, e.g. "GET /api/leaderboard"
+type RouteStats struct {
+	Route        string  `json:"route"`
+	Requests     uint64  `json:"requests"`
+	ClientErrors uint64  `json:"client_errors"` // 4xx
+	ServerErrors uint64  `json:"server_errors"` // 5xx
+	ErrorRate    float64 `json:"error_rate"`    // Share of requests that were 5xx
+	P50Ms        float64 `json:"p50_ms"`        // Over the last 1000 requests
+	P90Ms        float64 `json:"p90_ms"`
+	P99Ms        float64 `json:"p99_ms"`
+	MaxMs        float64 `json:"max_ms"`
+}
+
+// Time a request spent on one thing, e.g. "store" or "sort"
+type Phase struct {
+	Name string  `json:"name"`
+	Ms   float64 `json:"ms"`
+}
+
+type SlowRequest struct {
+	Route     string  `json:"route"`
+	Status    int     `json:"status"`
+	Timestamp int64   `json:"timestamp"`
+	Ms        float64 `json:"ms"`
+	Phases    []Phase `json:"phases,omitempty"` // Whatever the handler tracked; the rest is untracked
+}
+
+// Where one request spent its time. Handlers track the parts worth
+// knowing about; tracking the same name twice adds up.
+type Breakdown struct {
+	mu     sync.Mutex
+	phases []Phase
+}
+
+// Start timing a phase; call the result when it's done. Safe on a nil
+// Breakdown, so handlers don't need to care whether metrics are on.
+func (b *Breakdown) Track(name string) func() {
+	if b == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		took := ms(time.Since(start))
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i := range b.phases {
+			if b.phases[i].Name == name {
+				b.phases[i].Ms += took
+				return
+			}
+		}
+		b.phases = append(b.phases, Phase{Name: name, Ms: took})
+	}
+}
+
+func (b *Breakdown) Phases() []Phase {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Phase(nil), b.phases...)
+}
+
+type route struct {
+	requests     uint64
+	clientErrors uint64
+	serverErrors uint64
+	samples      []time.Duration // Ring of the last sampleWindow latencies
+	next         int
+}
+
+type Recorder struct {
+	mu            sync.Mutex
+	routes        map[string]*route
+	slowThreshold time.Duration
+	slow          []SlowRequest // Oldest first
+}
+
+// Slow-request logging starts off; see SetSlowThreshold
+func NewRecorder() *Recorder {
+	return &Recorder{routes: make(map[string]*route)}
+}
+
+// Requests taking longer than this are logged with their breakdown (0 = off)
+func (r *Recorder) SetSlowThreshold(threshold time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.slowThreshold = threshold
+}
+
+func (r *Recorder) SlowThreshold() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.slowThreshold
+}
+
+// Count a finished request
+func (r *Recorder) Observe(name string, status int, took time.Duration, breakdown *Breakdown) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rt := r.routes[name]
+	if rt == nil {
+		rt = &route{}
+		r.routes[name] = rt
+	}
+	rt.requests++
+	switch {
+	case status >= 500:
+		rt.serverErrors++
+	case status >= 400:
+		rt.clientErrors++
+	}
+	if len(rt.samples) < sampleWindow {
+		rt.samples = append(rt.samples, took)
+	} else {
+		rt.samples[rt.next] = took
+		rt.next = (rt.next + 1) % sampleWindow
+	}
+
+	if r.slowThreshold == 0 || took < r.slowThreshold {
+		return
+	}
+	slow := SlowRequest{
+		Route:     name,
+		Status:    status,
+		Timestamp: time.Now().UnixMilli(),
+		Ms:        ms(took),
+		Phases:    breakdown.Phases(),
+	}
+	if len(r.slow) == slowLogSize {
+		r.slow = r.slow[1:]
+	}
+	r.slow = append(r.slow, slow)
+	log.Printf("slow request: %s -> %d in %.0fms%s", name, status, slow.Ms, describe(slow.Phases))
+}
+
+// Every route seen so far, by name
+func (r *Recorder) Routes() []RouteStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := make([]RouteStats, 0, len(r.routes))
+	for name, rt := range r.routes {
+		samples := append([]time.Duration(nil), rt.samples...)
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		stats = append(stats, RouteStats{
+			Route:        name,
+			Requests:     rt.requests,
+			ClientErrors: rt.clientErrors,
+			ServerErrors: rt.serverErrors,
+			ErrorRate:    float64(rt.serverErrors) / float64(rt.requests),
+			P50Ms:        ms(rank(samples, 50)),
+			P90Ms:        ms(rank(samples, 90)),
+			P99Ms:        ms(rank(samples, 99)),
+			MaxMs:        ms(rank(samples, 100)),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Route < stats[j].Route })
+	return stats
+}
+
+// The most recent slow requests, newest first
+func (r *Recorder) SlowRequests() []SlowRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	slow := make([]SlowRequest, len(r.slow))
+	for i, req := range r.slow {
+		slow[len(r.slow)-1-i] = req
+	}
+	return slow
+}
+
+// Nearest-rank percentile of sorted samples
+func rank(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := (len(sorted)*p + 99) / 100 // ceil(n * p / 100)
+	if idx < 1 {
+		idx = 1
+	}
+	return sorted[idx-1]
+}
+
+// Milliseconds to two decimal places
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()/10) / 100
+}
+
+// " (store 12ms, sort 780ms)"
+func describe(phases []Phase) string {
+	if len(phases) == 0 {
+		return ""
+	}
+	parts := make([]string, len(phases))
+	for i, p := range phases {
+		parts[i] = fmt.Sprintf("%s %.0fms", p.Name, p.Ms)
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRoutes(t *testing.T) {
+	r := NewRecorder()
+	for i := 1; i <= 100; i++ {
+		r.Observe("GET /api/leaderboard", 200, time.Duration(i)*time.Millisecond, nil)
+	}
+	r.Observe("GET /api/leaderboard", 500, time.Millisecond, nil)
+	r.Observe("GET /api/leaderboard", 404, time.Millisecond, nil)
+	r.Observe("POST /api/challenges/submit", 200, time.Millisecond, nil)
+
+	routes := r.Routes()
+	if len(routes) != 2 || routes[0].Route != "GET /api/leaderboard" {
+		t.Fatalf("expected two routes by name, got %+v", routes)
+	}
+	lb := routes[0]
+	if lb.Requests != 102 || lb.ServerErrors != 1 || lb.ClientErrors != 1 {
+		t.Errorf("unexpected counts: %+v", lb)
+	}
+	if lb.ErrorRate != 1.0/102 {
+		t.Errorf("error rate should only count 5xx, got %f", lb.ErrorRate)
+	}
+	if lb.P50Ms != 49 || lb.P99Ms != 99 || lb.MaxMs != 100 {
+		t.Errorf("unexpected percentiles: %+v", lb)
+	}
+}
+
+func TestSampleWindow(t *testing.T) {
+	r := NewRecorder()
+	for i := 0; i < sampleWindow; i++ {
+		r.Observe("GET /", 200, time.Second, nil)
+	}
+	for i := 0; i < sampleWindow; i++ {
+		r.Observe("GET /", 200, time.Millisecond, nil)
+	}
+	if max := r.Routes()[0].MaxMs; max != 1 {
+		t.Errorf("old samples should have rolled out, max is %vms", max)
+	}
+}
+
+func TestSlowRequests(t *testing.T) {
+	r := NewRecorder()
+	r.Observe("GET /api/leaderboard", 200, time.Hour, nil)
+	if len(r.SlowRequests()) != 0 {
+		t.Error("slow log should be off until a threshold is set")
+	}
+
+	r.SetSlowThreshold(100 * time.Millisecond)
+	b := &Breakdown{}
+	b.phases = []Phase{{Name: "store", Ms: 10}, {Name: "sort", Ms: 180}}
+	r.Observe("GET /api/leaderboard", 200, 200*time.Millisecond, b)
+	r.Observe("GET /api/stats", 200, 50*time.Millisecond, nil)
+	r.Observe("GET /api/stats", 200, 150*time.Millisecond, nil)
+
+	slow := r.SlowRequests()
+	if len(slow) != 2 || slow[0].Route != "GET /api/stats" || slow[1].Route != "GET /api/leaderboard" {
+		t.Fatalf("expected both slow requests, newest first, got %+v", slow)
+	}
+	if len(slow[1].Phases) != 2 || slow[1].Phases[1].Name != "sort" {
+		t.Errorf("breakdown should be kept, got %+v", slow[1].Phases)
+	}
+
+	for i := 0; i < slowLogSize+10; i++ {
+		r.Observe("GET /", 200, time.Second, nil)
+	}
+	if len(r.SlowRequests()) != slowLogSize {
+		t.Errorf("slow log should be capped at %d", slowLogSize)
+	}
+}
+
+func TestBreakdown(t *testing.T) {
+	b := &Breakdown{}
+	for i := 0; i < 3; i++ {
+		b.Track("store")()
+	}
+	b.Track("sort")()
+	if phases := b.Phases(); len(phases) != 2 || phases[0].Name != "store" {
+		t.Errorf("repeated phases should add up, got %+v", phases)
+	}
+
+	var none *Breakdown
+	none.Track("store")()
+	if none.Phases() != nil {
+		t.Error("nil breakdown should track nothing")
+	}
+}
@@ -0,0 +1,104 @@
+package mockchain
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// A fake Greenfield storage provider that answers object-meta lookups.
+// Checksums are derived from the bucket and object name, so two mock SPs
+// holding the same object always agree.
+type SP struct {
+	objects map[string]bool // "bucket/object"
+	mu      sync.RWMutex
+}
+
+type spObjectInfo struct {
+	BucketName  string   `xml:"BucketName"`
+	ObjectName  string   `xml:"ObjectName"`
+	PayloadSize uint64   `xml:"PayloadSize"`
+	Checksums   []string `xml:"Checksums"`
+}
+
+type spObjectMeta struct {
+	XMLName xml.Name `xml:"GfSpGetObjectMetaResponse"`
+	Object  struct {
+		ObjectInfo spObjectInfo `xml:"ObjectInfo"`
+		Removed    bool         `xml:"Removed"`
+	} `xml:"Object"`
+}
+
+func NewSP() *SP {
+	return &SP{objects: make(map[string]bool)}
+}
+
+// Start storing an object
+func (s *SP) AddObject(bucket, object string) {
+	s.mu.Lock()
+	s.objects[bucket+"/"+object] = true
+	s.mu.Unlock()
+}
+
+// Lose an object, like an SP that stopped keeping the data it's paid for
+func (s *SP) RemoveObject(bucket, object string) {
+	s.mu.Lock()
+	delete(s.objects, bucket+"/"+object)
+	s.mu.Unlock()
+}
+
+// Deterministic checksums for an object, primary SP's first
+func ObjectChecksums(bucket, object string) [][]byte {
+	checksums := make([][]byte, 7) // Primary plus 6 erasure-coded segments
+	for i := range checksums {
+		checksums[i] = crypto.Keccak256([]byte(fmt.Sprintf("mockchain-object-%s/%s-%d", bucket, object, i)))
+	}
+	return checksums
+}
+
+func (s *SP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.URL.Path == "/status" {
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprint(w, "<GfSpStatus><Version>mock</Version></GfSpStatus>")
+		return
+	}
+
+	if _, ok := r.URL.Query()["object-meta"]; !ok {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	bucket, object, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	s.mu.RLock()
+	stored := s.objects[bucket+"/"+object]
+	s.mu.RUnlock()
+	if !ok || !stored {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "<Error><Code>NoSuchObject</Code></Error>")
+		return
+	}
+
+	var resp spObjectMeta
+	resp.Object.ObjectInfo = spObjectInfo{
+		BucketName:  bucket,
+		ObjectName:  object,
+		PayloadSize: 1 << 20,
+	}
+	for _, checksum := range ObjectChecksums(bucket, object) {
+		resp.Object.ObjectInfo.Checksums = append(resp.Object.ObjectInfo.Checksums, base64.StdEncoding.EncodeToString(checksum))
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,305 @@
+package mockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// A fake BSC node that speaks just enough JSON-RPC to answer our challenges.
+// Every answer is derived from the block number, so two mock chains (or the
+// same one used as both the trusted RPC and the "user" node) always agree.
+type Chain struct {
+	head     uint64
+	chainID  uint64
+	syncing  bool
+	peers    uint64
+	latency  time.Duration
+	history  uint64    // Blocks of state kept behind the head, 0 = everything
+	dbSize   uint64    // Reported by debug_chaindbProperty, 0 = debug namespace off
+	headTime time.Time // Timestamp of the head block, zero = fixed timestamps
+	client   string    // web3_clientVersion
+	mu       sync.RWMutex
+}
+
+type rpcRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+func New(head uint64) *Chain {
+	return &Chain{
+		head:    head,
+		chainID: 56,
+		peers:   25,
+		client:  DefaultClientVersion,
+	}
+}
+
+// Move the chain head forward
+func (c *Chain) SetHead(head uint64) {
+	c.mu.Lock()
+	c.head = head
+	c.mu.Unlock()
+}
+
+func (c *Chain) Head() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.head
+}
+
+// Report a different chain ID (56, BSC mainnet, by default)
+func (c *Chain) SetChainID(chainID uint64) {
+	c.mu.Lock()
+	c.chainID = chainID
+	c.mu.Unlock()
+}
+
+// Make the node report that it's still syncing
+func (c *Chain) SetSyncing(syncing bool) {
+	c.mu.Lock()
+	c.syncing = syncing
+	c.mu.Unlock()
+}
+
+// Add artificial delay to every call (useful for latency checks)
+func (c *Chain) SetLatency(d time.Duration) {
+	c.mu.Lock()
+	c.latency = d
+	c.mu.Unlock()
+}
+
+// Only keep state for the last n blocks, like a pruned full node.
+// 0 keeps everything (archive).
+func (c *Chain) SetStateHistory(n uint64) {
+	c.mu.Lock()
+	c.history = n
+	c.mu.Unlock()
+}
+
+// Expose debug_chaindbProperty, reporting a LevelDB of this many bytes.
+// 0 turns the debug namespace off again.
+func (c *Chain) SetDatabaseSize(bytes uint64) {
+	c.mu.Lock()
+	c.dbSize = bytes
+	c.mu.Unlock()
+}
+
+// Stamp the head block with this time and earlier blocks one second
+// apart, like a live opBNB node. A head that stops moving in wall-clock
+// time looks stalled. Zero goes back to fixed timestamps.
+func (c *Chain) SetHeadTime(t time.Time) {
+	c.mu.Lock()
+	c.headTime = t
+	c.mu.Unlock()
+}
+
+// What web3_clientVersion reports unless changed
+const DefaultClientVersion = "Geth/v1.4.5-mock/linux-amd64/go1.21"
+
+// Report a different web3_clientVersion
+func (c *Chain) SetClientVersion(version string) {
+	c.mu.Lock()
+	c.client = version
+	c.mu.Unlock()
+}
+
+// Deterministic block hash for a block number
+func BlockHash(number uint64) string {
+	return crypto.Keccak256Hash([]byte(fmt.Sprintf("mockchain-block-%d", number))).Hex()
+}
+
+// Deterministic state root for a block number
+func StateRoot(number uint64) string {
+	return crypto.Keccak256Hash([]byte(fmt.Sprintf("mockchain-state-%d", number))).Hex()
+}
+
+// Deterministic hash of a block's index-th transaction
+func TxHash(number uint64, index int) string {
+	return crypto.Keccak256Hash([]byte(fmt.Sprintf("mockchain-tx-%d-%d", number, index))).Hex()
+}
+
+// Deterministic balance for an address at a block
+func Balance(address string, number uint64) string {
+	h := crypto.Keccak256([]byte(fmt.Sprintf("%s-%d", strings.ToLower(address), number)))
+	return "0x" + strconv.FormatUint(uint64(h[0])<<16|uint64(h[1])<<8|uint64(h[2]), 16)
+}
+
+func (c *Chain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	var req rpcRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	c.mu.RLock()
+	latency := c.latency
+	c.mu.RUnlock()
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+
+	resp := rpcResponse{Jsonrpc: "2.0", ID: req.ID}
+	result, rerr := c.handle(req.Method, req.Params)
+	if rerr != nil {
+		resp.Error = rerr
+	} else {
+		resp.Result = result
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Chain) handle(method string, params []json.RawMessage) (interface{}, *rpcError) {
+	c.mu.RLock()
+	head := c.head
+	chainID := c.chainID
+	syncing := c.syncing
+	peers := c.peers
+	history := c.history
+	dbSize := c.dbSize
+	headTime := c.headTime
+	client := c.client
+	c.mu.RUnlock()
+
+	switch method {
+	case "eth_blockNumber":
+		return fmt.Sprintf("0x%x", head), nil
+
+	case "eth_chainId":
+		return fmt.Sprintf("0x%x", chainID), nil
+
+	case "eth_syncing":
+		if syncing {
+			return map[string]string{
+				"currentBlock": fmt.Sprintf("0x%x", head),
+				"highestBlock": fmt.Sprintf("0x%x", head+1000),
+			}, nil
+		}
+		return false, nil
+
+	case "net_peerCount":
+		return fmt.Sprintf("0x%x", peers), nil
+
+	case "web3_clientVersion":
+		return client, nil
+
+	case "eth_getBlockByNumber":
+		number, err := blockParam(params, 0, head)
+		if err != nil {
+			return nil, err
+		}
+		if number > head {
+			return nil, nil
+		}
+		parentHash := "0x0000000000000000000000000000000000000000000000000000000000000000"
+		if number > 0 {
+			parentHash = BlockHash(number - 1)
+		}
+		timestamp := 1598671449 + number*3
+		if !headTime.IsZero() {
+			timestamp = uint64(headTime.Unix()) - (head - number)
+		}
+		return map[string]interface{}{
+			"hash":             BlockHash(number),
+			"number":           fmt.Sprintf("0x%x", number),
+			"timestamp":        fmt.Sprintf("0x%x", timestamp),
+			"parentHash":       parentHash,
+			"stateRoot":        StateRoot(number),
+			"transactionsRoot": crypto.Keccak256Hash([]byte(fmt.Sprintf("mockchain-tx-%d", number))).Hex(),
+			"receiptsRoot":     crypto.Keccak256Hash([]byte(fmt.Sprintf("mockchain-receipts-%d", number))).Hex(),
+			"miner":            "0x0000000000000000000000000000000000000000",
+			"gasUsed":          "0x0",
+			"gasLimit":         "0x8f0d180",
+			"transactions":     []string{TxHash(number, 0), TxHash(number, 1)},
+		}, nil
+
+	case "eth_getBalance":
+		if len(params) < 1 {
+			return nil, &rpcError{Code: -32602, Message: "missing address"}
+		}
+		var address string
+		if err := json.Unmarshal(params[0], &address); err != nil {
+			return nil, &rpcError{Code: -32602, Message: "invalid address"}
+		}
+		number, err := blockParam(params, 1, head)
+		if err != nil {
+			return nil, err
+		}
+		if history > 0 && number+history < head {
+			return nil, &rpcError{Code: -32000, Message: "missing trie node"}
+		}
+		return Balance(address, number), nil
+
+	case "debug_chaindbProperty":
+		if dbSize == 0 {
+			break
+		}
+		// Same layout as LevelDB's compaction stats, all data on level 1
+		return fmt.Sprintf("Compactions\n"+
+			" Level |   Tables   |    Size(MB)   |    Time(sec)  |    Read(MB)   |   Write(MB)\n"+
+			"-------+------------+---------------+---------------+---------------+---------------\n"+
+			"   0   |          0 |       0.00000 |       0.00000 |       0.00000 |       0.00000\n"+
+			"   1   |       1000 | %13.5f |       0.00000 |       0.00000 |       0.00000\n", float64(dbSize)/1e6), nil
+	}
+
+	return nil, &rpcError{Code: -32601, Message: "the method " + method + " does not exist/is not available"}
+}
+
+// Parse a block tag ("latest" or hex number) from the params list
+func blockParam(params []json.RawMessage, index int, head uint64) (uint64, *rpcError) {
+	if len(params) <= index {
+		return head, nil
+	}
+
+	var tag string
+	if err := json.Unmarshal(params[index], &tag); err != nil {
+		return 0, &rpcError{Code: -32602, Message: "invalid block tag"}
+	}
+
+	switch tag {
+	case "latest", "pending", "safe", "finalized", "":
+		return head, nil
+	case "earliest":
+		return 0, nil
+	}
+
+	number, err := strconv.ParseUint(strings.TrimPrefix(tag, "0x"), 16, 64)
+	if err != nil {
+		return 0, &rpcError{Code: -32602, Message: "invalid block number"}
+	}
+	return number, nil
+}
@@ -0,0 +1,179 @@
+package modlog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/depinonbnb/depin/internal/notify"
+)
+
+// Admin actions
+const (
+	ActionClear         = "review.clear"
+	ActionWarn          = "review.warn"
+	ActionBanNode       = "ban.node"
+	ActionBanWallet     = "ban.wallet"
+	ActionBanRequested  = "ban.requested" // Waiting for a second admin
+	ActionLiftWalletBan = "ban.lifted"
+	ActionSetFlag       = "flag.set"
+	ActionReclassify    = "reclassify.apply"
+	ActionKeepNodeType  = "reclassify.dismiss"
+	ActionReversePoints = "points.reverse"
+	ActionAdjustPoints  = "points.adjust"
+	ActionReloadConfig  = "config.reload"
+	ActionRecompute     = "stats.recompute"
+	ActionSetAPIKeyPlan = "apikey.plan"
+	ActionRevokeAPIKey  = "apikey.revoke"
+	ActionAnnounce      = "announcement.post"
+	ActionUnannounce    = "announcement.retract"
+	ActionPauseAPI      = "maintenance.start"
+	ActionResumeAPI     = "maintenance.end"
+	ActionResetTOTP     = "totp.reset"
+	ActionCloseEpoch    = "rewards.epoch"
+	ActionRestoreStore  = "store.restore"
+)
+
+// Hash the first entry points back to
+var GenesisHash = strings.Repeat("0", 64)
+
+// One admin action. Each entry includes the hash of the one before it, so
+// changing or dropping an old entry breaks every hash after it.
+type Entry struct {
+	Seq       uint64            `json:"seq"`
+	Action    string            `json:"action"`
+	Admin     string            `json:"admin"`
+	Target    string            `json:"target"` // Node ID, wallet address or flag name
+	Reason    string            `json:"reason,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+	Timestamp int64             `json:"timestamp"`
+	PrevHash  string            `json:"prev_hash"`
+	Hash      string            `json:"hash"`
+}
+
+// sha256 of the entry's JSON with Hash left empty
+func (e Entry) ComputeHash() string {
+	e.Hash = ""
+	body, _ := json.Marshal(e) // Map keys are sorted, so this is stable
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Append-only log of admin actions. Every entry is also sent to the
+// notifier, so actions show up somewhere outside this server as they
+// happen.
+type Log struct {
+	entries  []Entry
+	notifier notify.Notifier
+	mu       sync.RWMutex
+}
+
+func New() *Log {
+	return &Log{notifier: notify.Nop{}}
+}
+
+// Where each new entry is sent
+func (l *Log) SetNotifier(n notify.Notifier) {
+	l.mu.Lock()
+	l.notifier = n
+	l.mu.Unlock()
+}
+
+func (l *Log) Append(action, admin, target, reason string, details map[string]string, now int64) Entry {
+	l.mu.Lock()
+	entry := Entry{
+		Seq:       uint64(len(l.entries)) + 1,
+		Action:    action,
+		Admin:     admin,
+		Target:    target,
+		Reason:    reason,
+		Details:   details,
+		Timestamp: now,
+		PrevHash:  l.head(),
+	}
+	entry.Hash = entry.ComputeHash()
+	l.entries = append(l.entries, entry)
+	notifier := l.notifier
+	l.mu.Unlock()
+
+	notifier.Notify(notify.Event{
+		Type:      notify.EventAdminAction,
+		Message:   fmt.Sprintf("%s %s by %s", action, target, admin),
+		Action:    action,
+		Admin:     admin,
+		Target:    target,
+		LogHash:   entry.Hash,
+		Timestamp: now,
+	})
+	return entry
+}
+
+// Hash of the latest entry (GenesisHash when empty). Signing this signs
+// the whole log up to it.
+func (l *Log) Head() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.head()
+}
+
+// Caller must hold l.mu
+func (l *Log) head() string {
+	if len(l.entries) == 0 {
+		return GenesisHash
+	}
+	return l.entries[len(l.entries)-1].Hash
+}
+
+// Number of entries
+func (l *Log) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.entries)
+}
+
+// Entries after seq, oldest first
+func (l *Log) Since(seq uint64) []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if seq >= uint64(len(l.entries)) {
+		return []Entry{}
+	}
+	return append([]Entry(nil), l.entries[seq:]...)
+}
+
+// Replace the log with entries saved from an earlier one, e.g. at
+// startup. They have to link up from the genesis hash, so a tampered
+// copy isn't taken in. Nothing is sent to the notifier.
+func (l *Log) Restore(entries []Entry) error {
+	if err := Verify(entries, GenesisHash); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	l.entries = append([]Entry(nil), entries...)
+	l.mu.Unlock()
+	return nil
+}
+
+// Check a run of entries links up, starting from prevHash (GenesisHash
+// for a full log). Anyone holding an export can run this.
+func Verify(entries []Entry, prevHash string) error {
+	for _, entry := range entries {
+		if entry.PrevHash != prevHash {
+			return fmt.Errorf("entry %d: prev_hash doesn't match the entry before it", entry.Seq)
+		}
+		if entry.ComputeHash() != entry.Hash {
+			return fmt.Errorf("entry %d: hash doesn't match its contents", entry.Seq)
+		}
+		prevHash = entry.Hash
+	}
+	return nil
+}
+
+// The text the server signs for an export
+func ExportMessage(count uint64, head string) string {
+	return fmt.Sprintf("DePIN Moderation Log\nEntries: %d\nHead: %s", count, head)
+}
@@ -0,0 +1,96 @@
+package modlog
+
+import (
+	"testing"
+
+	"github.com/depinonbnb/depin/internal/notify"
+)
+
+type recordingNotifier struct {
+	events []notify.Event
+}
+
+func (r *recordingNotifier) Notify(event notify.Event) {
+	r.events = append(r.events, event)
+}
+
+func TestAppendChains(t *testing.T) {
+	l := New()
+	if l.Head() != GenesisHash {
+		t.Errorf("expected an empty log to have the genesis head")
+	}
+
+	first := l.Append(ActionWarn, "admin-1", "node-1", "slow answers", nil, 1000)
+	second := l.Append(ActionSetFlag, "admin-2", "anticheat.honeypot", "", map[string]string{"percent": "50"}, 2000)
+
+	if first.Seq != 1 || first.PrevHash != GenesisHash {
+		t.Errorf("unexpected first entry: %+v", first)
+	}
+	if second.PrevHash != first.Hash || l.Head() != second.Hash {
+		t.Error("second entry should point at the first, and be the head")
+	}
+	if err := Verify(l.Since(0), GenesisHash); err != nil {
+		t.Errorf("untouched log should verify: %v", err)
+	}
+	if err := Verify(l.Since(1), first.Hash); err != nil {
+		t.Errorf("tail should verify from the hash before it: %v", err)
+	}
+}
+
+func TestVerifyCatchesTampering(t *testing.T) {
+	l := New()
+	l.Append(ActionBanNode, "admin-1", "node-1", "proxying", nil, 1000)
+	l.Append(ActionClear, "admin-1", "node-2", "", nil, 2000)
+	l.Append(ActionBanWallet, "admin-2", "0xabc", "farm", nil, 3000)
+
+	edited := l.Since(0)
+	edited[0].Reason = "nothing to see"
+	if err := Verify(edited, GenesisHash); err == nil {
+		t.Error("expected an edited entry to fail")
+	}
+
+	// Rehashing the edited entry doesn't help - the next one still points at the old hash
+	edited[0].Hash = edited[0].ComputeHash()
+	if err := Verify(edited, GenesisHash); err == nil {
+		t.Error("expected a rehashed entry to break the chain")
+	}
+
+	dropped := l.Since(0)
+	dropped = append(dropped[:1], dropped[2:]...)
+	if err := Verify(dropped, GenesisHash); err == nil {
+		t.Error("expected a removed entry to break the chain")
+	}
+}
+
+func TestAppendNotifies(t *testing.T) {
+	l := New()
+	n := &recordingNotifier{}
+	l.SetNotifier(n)
+
+	entry := l.Append(ActionBanNode, "admin-1", "node-1", "proxying", nil, 1000)
+	if len(n.events) != 1 || n.events[0].Type != notify.EventAdminAction || n.events[0].LogHash != entry.Hash {
+		t.Errorf("expected one admin-action event for the entry, got %+v", n.events)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	old := New()
+	old.Append(ActionBanNode, "admin-1", "node-1", "proxying", nil, 1000)
+	old.Append(ActionClear, "admin-1", "node-2", "", nil, 2000)
+
+	notifier := &recordingNotifier{}
+	l := New()
+	l.SetNotifier(notifier)
+	if err := l.Restore(old.Since(0)); err != nil || l.Head() != old.Head() || len(notifier.events) != 0 {
+		t.Fatalf("expected the log restored quietly, got %v", err)
+	}
+	if next := l.Append(ActionWarn, "admin-2", "node-3", "", nil, 3000); next.Seq != 3 || next.PrevHash != old.Head() {
+		t.Errorf("expected appends to carry on from the restored head, got %+v", next)
+	}
+
+	edited := old.Since(0)
+	edited[1].Admin = "admin-2"
+	if err := New().Restore(edited); err == nil {
+		t.Error("expected a tampered log to be refused")
+	}
+}
@@ -0,0 +1,229 @@
+// Package names shows wallets by the names their owners gave them: a
+// SpaceID .bnb name (or an ENS name on any ENS-style registry) set as the
+// wallet's reverse record. Lookups go over RPC in the background and are
+// cached, so reads never wait on them; a wallet without a name just shows
+// its address.
+package names
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The part of rpc.Client lookups need
+type Caller interface {
+	Call(to, data string) (string, uint64, error)
+}
+
+const (
+	refreshAfter = 24 * time.Hour // A wallet's name is looked up again daily
+	retryAfter   = time.Hour      // After a lookup failed
+	maxNameBytes = 255
+)
+
+// Function selectors of the registry and resolver calls
+const (
+	selectorResolver = "0178b8bf" // resolver(bytes32)
+	selectorName     = "691f3431" // name(bytes32)
+	selectorAddr     = "3b3b57de" // addr(bytes32)
+)
+
+type entry struct {
+	name      string
+	checkedAt int64
+	failed    bool
+}
+
+type Resolver struct {
+	caller   Caller
+	registry string
+
+	mu    sync.RWMutex
+	cache map[string]*entry // By lowercase address
+}
+
+// Look names up in the registry contract at registry through caller
+func NewResolver(caller Caller, registry string) *Resolver {
+	return &Resolver{caller: caller, registry: strings.ToLower(registry), cache: make(map[string]*entry)}
+}
+
+// A wallet's name, "" if it has none or hasn't been looked up yet
+func (r *Resolver) Name(address string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if e, ok := r.cache[strings.ToLower(address)]; ok {
+		return e.name
+	}
+	return ""
+}
+
+// Look up every wallet in addresses whose name hasn't been looked up yet
+// or is due again. Returns how many names changed and how many lookups
+// failed; a failed lookup keeps the name it had.
+func (r *Resolver) Refresh(addresses []string, now int64) (changed, failed int) {
+	for _, address := range addresses {
+		address = strings.ToLower(address)
+		r.mu.RLock()
+		e := r.cache[address]
+		r.mu.RUnlock()
+		if e != nil && !due(e, now) {
+			continue
+		}
+
+		name, err := r.lookup(address)
+
+		r.mu.Lock()
+		if e == nil {
+			e = &entry{}
+			r.cache[address] = e
+		}
+		e.checkedAt = now
+		e.failed = err != nil
+		if err == nil && name != e.name {
+			e.name = name
+			changed++
+		}
+		r.mu.Unlock()
+		if err != nil {
+			failed++
+		}
+	}
+	return changed, failed
+}
+
+func due(e *entry, now int64) bool {
+	after := refreshAfter
+	if e.failed {
+		after = retryAfter
+	}
+	return now-e.checkedAt >= after.Milliseconds()
+}
+
+// The wallet's reverse record, if the name points back at the wallet.
+// Anyone can set any name as their reverse record, so one that doesn't
+// resolve forward to the same address isn't shown.
+func (r *Resolver) lookup(address string) (string, error) {
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
+		return "", nil // Test or malformed wallets have no name
+	}
+
+	reverse := namehash(address[2:] + ".addr.reverse")
+	resolver, err := r.resolver(reverse)
+	if err != nil || resolver == "" {
+		return "", err
+	}
+	result, _, err := r.caller.Call(resolver, "0x"+selectorName+hex.EncodeToString(reverse))
+	if err != nil {
+		return "", err
+	}
+	name, err := decodeString(result)
+	if err != nil || !displayable(name) {
+		return "", err
+	}
+	name = strings.ToLower(name)
+
+	forward := namehash(name)
+	resolver, err = r.resolver(forward)
+	if err != nil || resolver == "" {
+		return "", err
+	}
+	result, _, err = r.caller.Call(resolver, "0x"+selectorAddr+hex.EncodeToString(forward))
+	if err != nil {
+		return "", err
+	}
+	owner, err := decodeAddress(result)
+	if err != nil || owner != address {
+		return "", err
+	}
+	return name, nil
+}
+
+// The resolver the registry has for node, "" if none is set
+func (r *Resolver) resolver(node []byte) (string, error) {
+	result, _, err := r.caller.Call(r.registry, "0x"+selectorResolver+hex.EncodeToString(node))
+	if err != nil {
+		return "", err
+	}
+	resolver, err := decodeAddress(result)
+	if err != nil || resolver == zeroAddress {
+		return "", err
+	}
+	return resolver, nil
+}
+
+// EIP-137 namehash
+func namehash(name string) []byte {
+	node := make([]byte, 32)
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = crypto.Keccak256(node, crypto.Keccak256([]byte(labels[i])))
+	}
+	return node
+}
+
+// Short enough and free of anything that could mess up a page showing it
+func displayable(name string) bool {
+	if name == "" || len(name) > maxNameBytes || !utf8.ValidString(name) {
+		return false
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) || unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+const zeroAddress = "0x0000000000000000000000000000000000000000"
+
+var errShortResult = errors.New("contract call returned too little data")
+
+func decodeAddress(result string) (string, error) {
+	data, err := hex.DecodeString(strings.TrimPrefix(result, "0x"))
+	if err != nil {
+		return "", err
+	}
+	if len(data) < 32 {
+		if len(data) == 0 {
+			return zeroAddress, nil // No contract there
+		}
+		return "", errShortResult
+	}
+	return "0x" + hex.EncodeToString(data[12:32]), nil
+}
+
+// An ABI-encoded string return value
+func decodeString(result string) (string, error) {
+	data, err := hex.DecodeString(strings.TrimPrefix(result, "0x"))
+	if err != nil {
+		return "", err
+	}
+	if len(data) == 0 {
+		return "", nil
+	}
+	if len(data) < 64 {
+		return "", errShortResult
+	}
+	offset := new(big.Int).SetBytes(data[:32])
+	if !offset.IsInt64() || offset.Int64() > int64(len(data)-32) {
+		return "", fmt.Errorf("string offset %s out of range", offset)
+	}
+	start := offset.Int64() + 32
+	length := new(big.Int).SetBytes(data[start-32 : start])
+	if !length.IsInt64() || length.Int64() > int64(len(data))-start {
+		return "", fmt.Errorf("string length %s out of range", length)
+	}
+	return string(data[start : start+length.Int64()]), nil
+}
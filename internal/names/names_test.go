@@ -0,0 +1,126 @@
+package names
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	registry = "0x00000000000000000000000000000000000000aa"
+	resolver = "0x00000000000000000000000000000000000000bb"
+	alice    = "0x1111111111111111111111111111111111111111"
+	mallory  = "0x2222222222222222222222222222222222222222"
+)
+
+// A registry and resolver holding reverse records and forward addresses
+type fakeChain struct {
+	reverse map[string]string // Address -> name
+	forward map[string]string // Name -> address
+	down    bool
+	calls   int
+}
+
+func (f *fakeChain) Call(to, data string) (string, uint64, error) {
+	f.calls++
+	if f.down {
+		return "", 0, errors.New("rpc down")
+	}
+	selector, node := data[2:10], data[10:]
+	switch {
+	case to == registry && selector == selectorResolver:
+		return word(resolver), 0, nil
+	case to == resolver && selector == selectorName:
+		for address, name := range f.reverse {
+			if hex.EncodeToString(namehash(address[2:]+".addr.reverse")) == node {
+				return encodeString(name), 0, nil
+			}
+		}
+		return encodeString(""), 0, nil
+	case to == resolver && selector == selectorAddr:
+		for name, address := range f.forward {
+			if hex.EncodeToString(namehash(name)) == node {
+				return word(address), 0, nil
+			}
+		}
+		return word(zeroAddress), 0, nil
+	}
+	return "", 0, fmt.Errorf("unexpected call to %s: %s", to, data)
+}
+
+func word(address string) string {
+	return "0x" + strings.Repeat("0", 24) + address[2:]
+}
+
+func encodeString(s string) string {
+	padded := make([]byte, (len(s)+31)/32*32)
+	copy(padded, s)
+	return fmt.Sprintf("0x%064x%064x%s", 32, len(s), hex.EncodeToString(padded))
+}
+
+func TestNamehash(t *testing.T) {
+	if got := hex.EncodeToString(namehash("eth")); got != "93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae" {
+		t.Errorf("namehash(eth) = %s", got)
+	}
+	if got := hex.EncodeToString(namehash("")); got != strings.Repeat("0", 64) {
+		t.Errorf("namehash of the root should be zero, got %s", got)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	chain := &fakeChain{
+		reverse: map[string]string{alice: "Alice.bnb", mallory: "alice.bnb"},
+		forward: map[string]string{"alice.bnb": alice},
+	}
+	r := NewResolver(chain, registry)
+	now := time.Now().UnixMilli()
+
+	changed, failed := r.Refresh([]string{strings.ToUpper(alice[:3]) + alice[3:], mallory, "0xnotawallet"}, now)
+	if changed != 1 || failed != 0 {
+		t.Fatalf("expected one name found, got %d changed, %d failed", changed, failed)
+	}
+	if name := r.Name(alice); name != "alice.bnb" {
+		t.Errorf("expected alice's name, got %q", name)
+	}
+	// Claiming someone else's name in a reverse record doesn't work
+	if name := r.Name(mallory); name != "" {
+		t.Errorf("expected a name that doesn't point back to be ignored, got %q", name)
+	}
+
+	// Cached until it's due again
+	calls := chain.calls
+	r.Refresh([]string{alice}, now+time.Hour.Milliseconds())
+	if chain.calls != calls {
+		t.Error("expected a fresh name not to be looked up again")
+	}
+
+	// A failed lookup keeps the name and is retried sooner
+	chain.down = true
+	later := now + refreshAfter.Milliseconds()
+	if _, failed := r.Refresh([]string{alice}, later); failed != 1 || r.Name(alice) != "alice.bnb" {
+		t.Errorf("expected the failure counted and the name kept, got %d failed, %q", failed, r.Name(alice))
+	}
+	chain.down = false
+	delete(chain.reverse, alice)
+	if changed, _ := r.Refresh([]string{alice}, later+retryAfter.Milliseconds()); changed != 1 || r.Name(alice) != "" {
+		t.Errorf("expected a removed reverse record to clear the name, got %q", r.Name(alice))
+	}
+}
+
+func TestDisplayable(t *testing.T) {
+	for name, want := range map[string]bool{
+		"alice.bnb":              true,
+		"":                       false,
+		"bad\nname.bnb":          false,
+		"two words.bnb":          false,
+		strings.Repeat("a", 300): false,
+		"\xff.bnb":               false,
+	} {
+		if got := displayable(name); got != want {
+			t.Errorf("displayable(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Package normalize puts challenge answers in one canonical form, so that
+// clients formatting the same data differently (hex case, leading zeros,
+// null fields, key order) give byte-identical answers. The prover applies
+// it before signing and the verifier before comparing.
+package normalize
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// Canonical form of an answer to a challenge of the given type. Anything
+// that doesn't parse as expected is just trimmed and lowercased.
+func Answer(challengeType types.ChallengeType, raw string) string {
+	answer := strings.ToLower(strings.TrimSpace(raw))
+	if answer == "" {
+		return ""
+	}
+
+	switch challengeType {
+	case types.BlockHash, types.ObjectChecksum:
+		return hexString(answer)
+
+	case types.StateBalance:
+		return hexNumber(answer)
+
+	case types.BlockData, types.SyncStatus, types.ObjectExists:
+		return jsonObject(answer)
+
+	default:
+		return answer
+	}
+}
+
+// Like Answer, but composites are normalized part by part. Parts the node
+// couldn't answer stay "".
+func ChallengeAnswer(ch *types.Challenge, raw string) string {
+	if ch.ChallengeType != types.Composite {
+		return Answer(ch.ChallengeType, raw)
+	}
+
+	var parts []string
+	if json.Unmarshal([]byte(raw), &parts) != nil || len(parts) != len(ch.Params.Parts) {
+		return strings.TrimSpace(raw)
+	}
+	for i, part := range ch.Params.Parts {
+		parts[i] = Answer(part.ChallengeType, parts[i])
+	}
+	out, _ := json.Marshal(parts)
+	return string(out)
+}
+
+// Hashes and checksums: 0x-prefixed lowercase hex
+func hexString(s string) string {
+	if !strings.HasPrefix(s, "0x") {
+		s = "0x" + s
+	}
+	return s
+}
+
+// Quantities: 0x-prefixed hex without leading zeros, "0x0" for zero (which
+// some clients send as a bare "0x")
+func hexNumber(s string) string {
+	digits := strings.TrimPrefix(s, "0x")
+	if digits == "" {
+		return "0x0"
+	}
+	n, ok := new(big.Int).SetString(digits, 16)
+	if !ok || n.Sign() < 0 {
+		return s
+	}
+	return "0x" + n.Text(16)
+}
+
+// JSON objects: null fields dropped, keys sorted
+func jsonObject(s string) string {
+	var obj map[string]interface{}
+	if json.Unmarshal([]byte(s), &obj) != nil {
+		return s
+	}
+	for key, value := range obj {
+		if value == nil {
+			delete(obj, key)
+		}
+	}
+	out, _ := json.Marshal(obj)
+	return string(out)
+}
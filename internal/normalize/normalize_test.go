@@ -0,0 +1,68 @@
+package normalize
+
+import (
+	"testing"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+func TestAnswer(t *testing.T) {
+	tests := []struct {
+		name          string
+		challengeType types.ChallengeType
+		a, b          string
+	}{
+		{"hash case", types.BlockHash, "0xABCdef", " 0xabcdef\n"},
+		{"hash prefix", types.ObjectChecksum, "abcdef", "0xabcdef"},
+		{"balance leading zeros", types.StateBalance, "0x00010", "0x10"},
+		{"balance case", types.StateBalance, "0xFF", "0xff"},
+		{"zero balance", types.StateBalance, "0x", "0x0"},
+		{"field order", types.BlockData, `{"stateRoot":"0x1","hash":"0x2"}`, `{"hash":"0x2","stateRoot":"0x1"}`},
+		{"hex case in fields", types.BlockData, `{"hash":"0xAB"}`, `{"hash":"0xab"}`},
+		{"null fields", types.BlockData, `{"hash":"0x2","l1InfoTx":null}`, `{"hash":"0x2"}`},
+		{"whitespace", types.SyncStatus, `{ "synced": true }`, `{"synced":true}`},
+	}
+
+	for _, tt := range tests {
+		if a, b := Answer(tt.challengeType, tt.a), Answer(tt.challengeType, tt.b); a != b {
+			t.Errorf("%s: %q and %q should normalize the same, got %q and %q", tt.name, tt.a, tt.b, a, b)
+		}
+	}
+
+	// Different data still differs
+	if Answer(types.StateBalance, "0x10") == Answer(types.StateBalance, "0x11") {
+		t.Error("different balances shouldn't normalize the same")
+	}
+	if Answer(types.BlockData, `{"hash":"0x2"}`) == Answer(types.BlockData, `{"hash":"0x2","l1infotx":"0x3"}`) {
+		t.Error("a present field shouldn't be dropped")
+	}
+	if got := Answer(types.StateBalance, "not a number"); got != "not a number" {
+		t.Errorf("unparseable answers should pass through, got %q", got)
+	}
+}
+
+func TestChallengeAnswer(t *testing.T) {
+	ch := &types.Challenge{
+		ChallengeType: types.Composite,
+		Params: types.ChallengeParams{Parts: []types.ChallengePart{
+			{ChallengeType: types.BlockHash},
+			{ChallengeType: types.StateBalance},
+			{ChallengeType: types.SyncStatus},
+		}},
+	}
+
+	got := ChallengeAnswer(ch, `["0xABC","0x0010",""]`)
+	if want := `["0xabc","0x10",""]`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Wrong shape is left for the verifier to reject
+	if got := ChallengeAnswer(ch, `["0xabc"]`); got != `["0xabc"]` {
+		t.Errorf("malformed composite should pass through, got %q", got)
+	}
+
+	single := &types.Challenge{ChallengeType: types.BlockHash}
+	if got := ChallengeAnswer(single, "0xABC"); got != "0xabc" {
+		t.Errorf("got %q for a single challenge", got)
+	}
+}
@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Mails each event as plain text through an SMTP server. Like Webhook,
+// delivery is best effort and never blocks the caller.
+type Email struct {
+	addr string // host:port
+	from string
+	to   []string
+	auth smtp.Auth // Nil = the server doesn't want a login
+}
+
+// Log in with username and password if a username is given
+func NewEmail(addr, from string, to []string, username, password string) *Email {
+	e := &Email{addr: addr, from: from, to: to}
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		e.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return e
+}
+
+func (e *Email) Notify(event Event) {
+	go func() {
+		if err := smtp.SendMail(e.addr, e.auth, e.from, e.to, e.message(event)); err != nil {
+			log.Printf("email %s to %s failed: %v", event.Type, strings.Join(e.to, ","), err)
+		}
+	}()
+}
+
+func (e *Email) message(event Event) []byte {
+	subject := "[DePIN] " + event.Type
+	if event.Rule != "" {
+		subject += ": " + event.Rule
+	}
+	if event.NodeID != "" {
+		subject += " (node " + event.NodeID + ")"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.UnixMilli(event.Timestamp).UTC().Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(event.Message)
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
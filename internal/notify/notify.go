@@ -0,0 +1,147 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/signing"
+)
+
+// Headers a signed webhook carries. The signature is over
+// signing.PushMessage(event type, node ID, body, timestamp).
+const (
+	TimestampHeader = "X-DePIN-Timestamp"
+	KeyIDHeader     = "X-DePIN-Key-Id"
+	SignatureHeader = "X-DePIN-Signature"
+)
+
+// Event types
+const (
+	// One more suspicious event and the node gets flagged
+	EventFlagImminent = "flag-imminent"
+
+	// An admin reviewed, banned or changed something
+	EventAdminAction = "admin-action"
+
+	// The node's client is older than the minimum release for its chain
+	EventClientOutdated = "client-outdated"
+
+	// Probes say the node is a different type than it registered as
+	EventReclassification = "reclassification-proposed"
+
+	// The node's 7-day uptime fell below its type's minimum
+	EventUptimeLow = "uptime-below-target"
+
+	// Something is off across the whole network, and when it no longer is
+	EventAnomaly         = "network-anomaly"
+	EventAnomalyResolved = "network-anomaly-resolved"
+)
+
+// Something an operator should hear about
+type Event struct {
+	Type          string `json:"type"`
+	NodeID        string `json:"node_id"`
+	WalletAddress string `json:"wallet_address"`
+	Message       string `json:"message"`
+	WarningCount  uint8  `json:"warning_count"`
+	FlagThreshold uint8  `json:"flag_threshold"`
+	Timestamp     int64  `json:"timestamp"`
+
+	// Admin actions only
+	Action  string `json:"action,omitempty"`
+	Admin   string `json:"admin,omitempty"`
+	Target  string `json:"target,omitempty"`
+	LogHash string `json:"log_hash,omitempty"` // Moderation log entry
+
+	// Network anomalies only
+	Rule string `json:"rule,omitempty"`
+}
+
+type Notifier interface {
+	Notify(event Event)
+}
+
+// Drops everything - used when no webhook is configured
+type Nop struct{}
+
+func (Nop) Notify(Event) {}
+
+// Sends each event to all of them
+type Multi []Notifier
+
+func (m Multi) Notify(event Event) {
+	for _, n := range m {
+		n.Notify(event)
+	}
+}
+
+// POSTs each event as JSON to a URL. Delivery is best effort and never
+// blocks the caller.
+type Webhook struct {
+	url    string
+	client *http.Client
+	keys   *signing.Keyring // Nil = unsigned
+}
+
+func NewWebhook(url string) *Webhook {
+	return &Webhook{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Sign every event with the server's active key, so receivers can tell a
+// webhook really came from us. Events go unsigned while signing is off.
+func (w *Webhook) SignWith(keys *signing.Keyring) *Webhook {
+	w.keys = keys
+	return w
+}
+
+func (w *Webhook) Notify(event Event) {
+	go func() {
+		if err := w.send(event); err != nil {
+			log.Printf("webhook %s for node %s failed: %v", event.Type, event.NodeID, err)
+		}
+	}()
+}
+
+func (w *Webhook) send(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if w.keys != nil {
+		push, err := w.keys.SignPush(event.Type, event.NodeID, body, time.Now().UnixMilli())
+		if err != nil {
+			return err
+		}
+		if push.Signature != "" {
+			req.Header.Set(TimestampHeader, strconv.FormatInt(push.Timestamp, 10))
+			req.Header.Set(KeyIDHeader, push.KeyID)
+			req.Header.Set(SignatureHeader, push.Signature)
+		}
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/signing"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestWebhookPostsEvent(t *testing.T) {
+	received := make(chan Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+		json.NewDecoder(r.Body).Decode(&event)
+		received <- event
+	}))
+	defer server.Close()
+
+	NewWebhook(server.URL).Notify(Event{Type: EventFlagImminent, NodeID: "n1"})
+
+	select {
+	case event := <-received:
+		if event.Type != EventFlagImminent || event.NodeID != "n1" {
+			t.Errorf("unexpected event: %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was never called")
+	}
+}
+
+func TestWebhookReportsBadStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if err := NewWebhook(server.URL).send(Event{}); err == nil {
+		t.Error("expected error for 500 response")
+	}
+}
+
+func TestWebhookSigned(t *testing.T) {
+	type delivery struct {
+		header http.Header
+		body   string
+	}
+	received := make(chan delivery, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- delivery{r.Header, string(body)}
+	}))
+	defer server.Close()
+
+	key, _ := crypto.GenerateKey()
+	signer, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	keys := signing.NewKeyring(time.Hour)
+	keys.Rotate(signer)
+
+	if err := NewWebhook(server.URL).SignWith(keys).send(Event{Type: EventFlagImminent, NodeID: "n1"}); err != nil {
+		t.Fatal(err)
+	}
+	got := <-received
+
+	timestamp, _ := strconv.ParseInt(got.header.Get(TimestampHeader), 10, 64)
+	push := signing.Push{
+		Event:     EventFlagImminent,
+		NodeID:    "n1",
+		Payload:   got.body,
+		Timestamp: timestamp,
+		KeyID:     got.header.Get(KeyIDHeader),
+		Signature: got.header.Get(SignatureHeader),
+	}
+	if !push.Verify([]string{signer.Address()}) {
+		t.Errorf("webhook signature should verify against the server key, headers %v", got.header)
+	}
+
+	keys.Disable()
+	NewWebhook(server.URL).SignWith(keys).send(Event{Type: EventFlagImminent, NodeID: "n1"})
+	if got := <-received; got.header.Get(SignatureHeader) != "" {
+		t.Error("webhook should go unsigned with signing off")
+	}
+}
+
+func TestEmailMessage(t *testing.T) {
+	e := NewEmail("smtp.example.com:587", "alerts@example.com", []string{"a@example.com", "b@example.com"}, "", "")
+	msg := string(e.message(Event{Type: EventAnomaly, Rule: "pass-rate-drop", Message: "pass rate fell", Timestamp: 1700000000000}))
+
+	for _, want := range []string{
+		"To: a@example.com, b@example.com\r\n",
+		"Subject: [DePIN] network-anomaly: pass-rate-drop\r\n",
+		"\r\n\r\npass rate fell\r\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected %q in:\n%s", want, msg)
+		}
+	}
+	if e.auth != nil {
+		t.Error("no username should mean no login")
+	}
+}
@@ -0,0 +1,69 @@
+package proverbuild
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// A build is the sha256 of the prover binary, "0x" and 64 lowercase hex
+var buildPattern = regexp.MustCompile(`^0x[0-9a-f]{64}$`)
+
+func Valid(build string) bool {
+	return buildPattern.MatchString(build)
+}
+
+// The build of the binary at path
+func Hash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return "0x" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// The build of the running binary
+func Self() (string, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return Hash(path)
+}
+
+// Builds of the official prover releases. A prover reports its own build,
+// so a modified one can claim an official hash; this shows who runs a
+// build of their own, not who cheats.
+type Allowlist map[string]bool
+
+// Parse a comma separated list of builds. Empty means no allowlist, and
+// no build counts as unofficial.
+func ParseAllowlist(spec string) (Allowlist, error) {
+	list := Allowlist{}
+	for _, build := range strings.Split(spec, ",") {
+		build = strings.ToLower(strings.TrimSpace(build))
+		if build == "" {
+			continue
+		}
+		if !Valid(build) {
+			return nil, fmt.Errorf("%q is not a build hash (0x and 64 hex digits)", build)
+		}
+		list[build] = true
+	}
+	return list, nil
+}
+
+// Whether build is known and not one of the official releases
+func (a Allowlist) Unofficial(build string) bool {
+	return len(a) > 0 && build != "" && !a[build]
+}
@@ -0,0 +1,56 @@
+package proverbuild
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prover")
+	if err := os.WriteFile(path, []byte("abc"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	build, err := Hash(path)
+	if err != nil {
+		t.Fatalf("hash failed: %v", err)
+	}
+	// sha256("abc")
+	if build != "0xba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Errorf("wrong build hash %s", build)
+	}
+	if !Valid(build) {
+		t.Error("a hash should be a valid build")
+	}
+
+	if self, err := Self(); err != nil || !Valid(self) {
+		t.Errorf("expected the test binary's build, got %q (%v)", self, err)
+	}
+}
+
+func TestAllowlist(t *testing.T) {
+	official := "0x" + strings.Repeat("ab", 32)
+	other := "0x" + strings.Repeat("cd", 32)
+
+	// Release notes may print hashes in uppercase
+	list, err := ParseAllowlist(" 0x" + strings.ToUpper(official[2:]) + ", ")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if list.Unofficial(official) || !list.Unofficial(other) {
+		t.Error("only builds missing from the list should be unofficial")
+	}
+	if list.Unofficial("") {
+		t.Error("a node that never reported a build isn't unofficial")
+	}
+
+	empty, _ := ParseAllowlist("")
+	if empty.Unofficial(other) {
+		t.Error("without an allowlist no build is unofficial")
+	}
+
+	if _, err := ParseAllowlist("0xabc"); err == nil {
+		t.Error("expected a short hash to be refused")
+	}
+}
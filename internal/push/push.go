@@ -0,0 +1,67 @@
+package push
+
+import (
+	"sync"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// Hub delivers challenges to provers holding an open stream, so the server
+// can send one whenever it likes instead of waiting for the next poll.
+type Hub struct {
+	subscribers map[string]map[chan *types.Challenge]struct{} // nodeID -> streams
+	mu          sync.Mutex
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[string]map[chan *types.Challenge]struct{}),
+	}
+}
+
+// Start receiving challenges for a node. Call the returned func when the
+// stream closes.
+func (h *Hub) Subscribe(nodeID string) (<-chan *types.Challenge, func()) {
+	ch := make(chan *types.Challenge, 1)
+
+	h.mu.Lock()
+	if h.subscribers[nodeID] == nil {
+		h.subscribers[nodeID] = make(map[chan *types.Challenge]struct{})
+	}
+	h.subscribers[nodeID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subscribers[nodeID], ch)
+		if len(h.subscribers[nodeID]) == 0 {
+			delete(h.subscribers, nodeID)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// Does the node have an open stream
+func (h *Hub) Connected(nodeID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers[nodeID]) > 0
+}
+
+// Send a challenge to every stream the node has open. Never blocks - a
+// stream that hasn't taken its last challenge yet misses this one.
+// Returns how many streams it reached.
+func (h *Hub) Publish(challenge *types.Challenge) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sent := 0
+	for ch := range h.subscribers[challenge.NodeID] {
+		select {
+		case ch <- challenge:
+			sent++
+		default:
+		}
+	}
+	return sent
+}
@@ -0,0 +1,51 @@
+package push
+
+import (
+	"testing"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+func TestPublish(t *testing.T) {
+	h := NewHub()
+
+	if h.Publish(&types.Challenge{ID: "c0", NodeID: "node-a"}) != 0 {
+		t.Error("nothing is subscribed yet")
+	}
+
+	a, unsubscribe := h.Subscribe("node-a")
+	b, _ := h.Subscribe("node-b")
+	if !h.Connected("node-a") {
+		t.Error("node-a should be connected")
+	}
+
+	if sent := h.Publish(&types.Challenge{ID: "c1", NodeID: "node-a"}); sent != 1 {
+		t.Errorf("expected 1 stream reached, got %d", sent)
+	}
+	if ch := <-a; ch.ID != "c1" {
+		t.Errorf("unexpected challenge %s", ch.ID)
+	}
+	select {
+	case ch := <-b:
+		t.Errorf("node-b got node-a's challenge %s", ch.ID)
+	default:
+	}
+
+	unsubscribe()
+	if h.Connected("node-a") {
+		t.Error("node-a should be gone after unsubscribing")
+	}
+}
+
+func TestPublishNeverBlocks(t *testing.T) {
+	h := NewHub()
+	h.Subscribe("node-a")
+
+	// Nobody is reading - the second one is dropped rather than blocking
+	if sent := h.Publish(&types.Challenge{ID: "c1", NodeID: "node-a"}); sent != 1 {
+		t.Errorf("expected first publish to land, got %d", sent)
+	}
+	if sent := h.Publish(&types.Challenge{ID: "c2", NodeID: "node-a"}); sent != 0 {
+		t.Errorf("expected second publish to be dropped, got %d", sent)
+	}
+}
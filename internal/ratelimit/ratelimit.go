@@ -0,0 +1,168 @@
+// Package ratelimit meters and limits reads of the public API. Explorers
+// and analytics sites read a lot; with a registered API key they get their
+// plan's allowance, everyone else shares the anonymous plan's allowance
+// per client IP.
+package ratelimit
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	Anonymous   = "anonymous" // Plan for requests without a key, limited per IP
+	DefaultPlan = "basic"     // Plan new keys start on
+)
+
+// Requests per minute by plan name, 0 = unlimited
+type Plans map[string]uint64
+
+// Parse "anonymous=60,basic=600,partner=0". Both anonymous and basic have
+// to be there.
+func ParsePlans(spec string) (Plans, error) {
+	plans := make(Plans)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		limit, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if !ok || err != nil || name == "" {
+			return nil, fmt.Errorf("want plan=requests-per-minute, got %q", entry)
+		}
+		plans[name] = limit
+	}
+	for _, required := range []string{Anonymous, DefaultPlan} {
+		if _, ok := plans[required]; !ok {
+			return nil, fmt.Errorf("missing the %s plan", required)
+		}
+	}
+	return plans, nil
+}
+
+// Whether a request may go ahead, with what the X-RateLimit headers say
+type Decision struct {
+	Allowed   bool
+	Limit     uint64 // 0 = unlimited
+	Remaining uint64
+	Reset     int64 // Unix ms the current minute ends
+}
+
+// One client's use of the API since the server started
+type Usage struct {
+	Client   string `json:"client"` // Key ID, or "anonymous" for everyone without a key
+	Plan     string `json:"plan"`
+	Requests uint64 `json:"requests"`
+	Limited  uint64 `json:"limited"` // Turned away over the limit
+	LastSeen int64  `json:"last_seen"`
+}
+
+type window struct {
+	start int64
+	count uint64
+}
+
+type Limiter struct {
+	mu      sync.Mutex
+	plans   Plans
+	windows map[string]*window // By client, this minute's only
+	minute  int64              // Start of the minute windows were last swept in
+	usage   map[string]*Usage  // By key ID, plus Anonymous
+}
+
+func New(plans Plans) *Limiter {
+	return &Limiter{plans: plans, windows: make(map[string]*window), usage: make(map[string]*Usage)}
+}
+
+func (l *Limiter) SetPlans(plans Plans) {
+	l.mu.Lock()
+	l.plans = plans
+	l.mu.Unlock()
+}
+
+// Whether a plan by this name is configured
+func (l *Limiter) HasPlan(name string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.plans[name]
+	return ok
+}
+
+// Count a request and decide whether it goes ahead. client is a key ID
+// for keyed requests, or the client IP on the anonymous plan. A key on a
+// plan that's no longer configured gets the default plan's allowance.
+func (l *Limiter) Allow(client, plan string, now int64) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	minute := now - now%60000
+	if minute != l.minute {
+		for id, w := range l.windows {
+			if w.start < minute {
+				delete(l.windows, id)
+			}
+		}
+		l.minute = minute
+	}
+
+	limit, ok := l.plans[plan]
+	if !ok {
+		limit = l.plans[DefaultPlan]
+	}
+	w := l.windows[client]
+	if w == nil {
+		w = &window{start: minute}
+		l.windows[client] = w
+	}
+
+	metered := client
+	if plan == Anonymous {
+		metered = Anonymous
+	}
+	u := l.usage[metered]
+	if u == nil {
+		u = &Usage{Client: metered}
+		l.usage[metered] = u
+	}
+	u.Plan = plan
+	u.LastSeen = now
+
+	decision := Decision{Allowed: true, Limit: limit, Reset: minute + 60000}
+	if limit > 0 && w.count >= limit {
+		u.Limited++
+		decision.Allowed = false
+		return decision
+	}
+	w.count++
+	u.Requests++
+	if limit > 0 {
+		decision.Remaining = limit - w.count
+	}
+	return decision
+}
+
+// The n heaviest clients by requests served, all of them if n is 0
+func (l *Limiter) Top(n int) []Usage {
+	l.mu.Lock()
+	top := make([]Usage, 0, len(l.usage))
+	for _, u := range l.usage {
+		top = append(top, *u)
+	}
+	l.mu.Unlock()
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Requests != top[j].Requests {
+			return top[i].Requests > top[j].Requests
+		}
+		return top[i].Client < top[j].Client
+	})
+	if n > 0 && len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import "testing"
+
+func TestParsePlans(t *testing.T) {
+	plans, err := ParsePlans("anonymous=60, Basic=600,partner=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plans[Anonymous] != 60 || plans[DefaultPlan] != 600 || plans["partner"] != 0 {
+		t.Errorf("unexpected plans: %v", plans)
+	}
+
+	for _, spec := range []string{"", "basic=600", "anonymous=60,basic", "anonymous=60,basic=lots", "=5,anonymous=60,basic=600"} {
+		if _, err := ParsePlans(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
+
+func TestAllow(t *testing.T) {
+	l := New(Plans{Anonymous: 2, DefaultPlan: 3, "partner": 0})
+	const minute = 60000
+	now := int64(10 * minute)
+
+	// Anonymous clients are limited per IP
+	for i := 0; i < 2; i++ {
+		if d := l.Allow("10.0.0.1", Anonymous, now); !d.Allowed || d.Remaining != uint64(1-i) {
+			t.Fatalf("request %d: unexpected decision %+v", i, d)
+		}
+	}
+	if d := l.Allow("10.0.0.1", Anonymous, now); d.Allowed || d.Reset != now+minute {
+		t.Fatalf("expected the third anonymous request turned away, got %+v", d)
+	}
+	if d := l.Allow("10.0.0.2", Anonymous, now); !d.Allowed {
+		t.Fatal("another IP has its own allowance")
+	}
+
+	// Keys get their plan's allowance; unlimited plans never run out
+	for i := 0; i < 3; i++ {
+		l.Allow("key1", DefaultPlan, now)
+	}
+	if d := l.Allow("key1", DefaultPlan, now); d.Allowed {
+		t.Fatal("expected the key over its plan's limit")
+	}
+	for i := 0; i < 100; i++ {
+		if d := l.Allow("key2", "partner", now); !d.Allowed || d.Limit != 0 {
+			t.Fatalf("unlimited plan turned a request away: %+v", d)
+		}
+	}
+
+	// A new minute, a new allowance
+	if d := l.Allow("key1", DefaultPlan, now+minute); !d.Allowed || d.Remaining != 2 {
+		t.Errorf("expected a fresh window, got %+v", d)
+	}
+
+	top := l.Top(2)
+	if len(top) != 2 || top[0].Client != "key2" || top[0].Requests != 100 || top[1].Client != "key1" || top[1].Limited != 1 {
+		t.Errorf("unexpected top consumers: %+v", top)
+	}
+	all := l.Top(0)
+	if len(all) != 3 || all[2].Client != Anonymous || all[2].Requests != 3 || all[2].Limited != 1 {
+		t.Errorf("expected anonymous traffic metered together, got %+v", all)
+	}
+}
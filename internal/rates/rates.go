@@ -0,0 +1,49 @@
+// Package rates lets the server change how many uptime points a node type
+// earns per hour without a release, e.g. to pay more for a type the network
+// is short of. Types that aren't overridden keep their rate from the types
+// package.
+package rates
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// Points per hour of uptime by node type, before the network multiplier
+type Rates map[types.NodeType]uint64
+
+// Parse "bsc-archive=12,opbnb-fast=2". Empty means no overrides. A rate
+// of 0 is allowed and stops a type earning uptime points.
+func Parse(spec string) (Rates, error) {
+	rates := make(Rates)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(entry, "=")
+		nodeType := types.NodeType(strings.ToLower(strings.TrimSpace(key)))
+		rate, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("want type=points, got %q", entry)
+		}
+		if nodeType.PointsPerHour() == 0 {
+			return nil, fmt.Errorf("unknown node type %q in %q", key, entry)
+		}
+		rates[nodeType] = rate
+	}
+	return rates, nil
+}
+
+// What a node type earns per hour: the override if there is one, the
+// built-in rate otherwise
+func (r Rates) PointsPerHour(nodeType types.NodeType) uint64 {
+	if rate, ok := r[nodeType]; ok {
+		return rate
+	}
+	return nodeType.PointsPerHour()
+}
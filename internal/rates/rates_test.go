@@ -0,0 +1,37 @@
+package rates
+
+import (
+	"testing"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+func TestParse(t *testing.T) {
+	rates, err := Parse(" bsc-archive=12, OpBNB-Fast=0 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rates.PointsPerHour(types.BscArchive) != 12 || rates.PointsPerHour(types.OpbnbFast) != 0 {
+		t.Errorf("unexpected rates: %v", rates)
+	}
+	if got := rates.PointsPerHour(types.BscFull); got != types.BscFull.PointsPerHour() {
+		t.Errorf("a type without an override should keep its rate, got %d", got)
+	}
+
+	if rates, err := Parse(""); err != nil || len(rates) != 0 {
+		t.Errorf("empty spec should mean no overrides, got %v, %v", rates, err)
+	}
+
+	for _, spec := range []string{"bsc-full", "bsc-full=-1", "bsc-full=lots", "solana=10"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
+
+func TestNilRates(t *testing.T) {
+	var rates Rates
+	if got := rates.PointsPerHour(types.BscArchive); got != types.BscArchive.PointsPerHour() {
+		t.Errorf("no overrides should mean built-in rates, got %d", got)
+	}
+}
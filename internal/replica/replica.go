@@ -0,0 +1,93 @@
+// Package replica keeps a read-only server's store in step with the
+// writer's. The store lives in the writer's memory, so instead of a shared
+// database each replica pulls the writer's snapshot every few seconds and
+// serves reads (leaderboard, stats, node queries) from its own copy.
+package replica
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/store"
+)
+
+// Where replicas fetch the writer's state (an admin endpoint)
+const SnapshotPath = "/api/admin/store/snapshot"
+
+// How far behind the writer a replica is
+type Status struct {
+	Writer   string `json:"writer"`
+	SyncedAt int64  `json:"synced_at,omitempty"` // Last successful pull
+	TakenAt  int64  `json:"taken_at,omitempty"`  // When the writer took what's being served
+	Error    string `json:"error,omitempty"`     // Why the last pull failed, if it did
+}
+
+type Follower struct {
+	writer string
+	apiKey string
+	store  store.Store
+	client *http.Client
+
+	mu     sync.Mutex
+	status Status
+}
+
+// Follow the writer at writer (a base URL), authenticating with one of
+// its admin keys
+func New(writer, apiKey string, s store.Store) *Follower {
+	writer = strings.TrimSuffix(writer, "/")
+	return &Follower{
+		writer: writer,
+		apiKey: apiKey,
+		store:  s,
+		client: &http.Client{Timeout: 30 * time.Second},
+		status: Status{Writer: writer},
+	}
+}
+
+func (f *Follower) Writer() string {
+	return f.writer
+}
+
+// Pull the writer's snapshot and serve from it. On failure the store keeps
+// what it had.
+func (f *Follower) Sync(now int64) error {
+	takenAt, err := f.pull()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err != nil {
+		f.status.Error = err.Error()
+		return err
+	}
+	f.status.SyncedAt = now
+	f.status.TakenAt = takenAt
+	f.status.Error = ""
+	return nil
+}
+
+func (f *Follower) pull() (int64, error) {
+	req, err := http.NewRequest(http.MethodGet, f.writer+SnapshotPath, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+f.apiKey)
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("writer answered %s", resp.Status)
+	}
+	return f.store.ReadSnapshot(resp.Body)
+}
+
+func (f *Follower) Status() Status {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.status
+}
@@ -0,0 +1,51 @@
+package replica
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/depinonbnb/depin/internal/store"
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+func TestSyncKeepsCopyOnFailure(t *testing.T) {
+	writerStore := store.NewStore()
+	node := writerStore.RegisterNode("0xwriter", types.BscFull, types.LocalProver, "", "")
+	up := true
+	writer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != SnapshotPath || r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, "no", http.StatusUnauthorized)
+			return
+		}
+		if !up {
+			http.Error(w, "down", http.StatusBadGateway)
+			return
+		}
+		writerStore.WriteSnapshot(w)
+	}))
+	defer writer.Close()
+
+	s := store.NewStore()
+	f := New(writer.URL+"/", "key", s)
+	if err := f.Sync(1000); err != nil {
+		t.Fatal(err)
+	}
+	if s.GetNode(node.ID) == nil || len(s.GetNodesByWallet("0xwriter")) != 1 {
+		t.Fatal("expected the writer's node after a sync")
+	}
+	if status := f.Status(); status.SyncedAt != 1000 || status.TakenAt == 0 || status.Writer != writer.URL {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	up = false
+	if err := f.Sync(2000); err == nil {
+		t.Fatal("expected the failed pull to be reported")
+	}
+	if s.GetNode(node.ID) == nil {
+		t.Error("a failed pull should leave the last copy in place")
+	}
+	if status := f.Status(); status.SyncedAt != 1000 || status.Error == "" {
+		t.Errorf("expected the last good sync and the error, got %+v", status)
+	}
+}
@@ -0,0 +1,93 @@
+package rewards
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Merkle trees over reward claims, built the way OpenZeppelin's
+// MerkleProof checks them, so a minting contract only has to store each
+// epoch's root. A leaf is
+//
+//	keccak256(abi.encodePacked(address account, uint256 amount, uint256 epoch))
+//
+// and each pair is hashed smallest first, so a proof needs no left/right
+// flags. A level with an odd count moves its last hash up unpaired.
+
+// One wallet's amount for an epoch
+type Claim struct {
+	Account string
+	Amount  uint64
+}
+
+func Leaf(account string, amount, epoch uint64) common.Hash {
+	var packed [20 + 32 + 32]byte
+	copy(packed[:20], common.HexToAddress(account).Bytes())
+	binary.BigEndian.PutUint64(packed[20+24:52], amount)
+	binary.BigEndian.PutUint64(packed[52+24:], epoch)
+	return crypto.Keccak256Hash(packed[:])
+}
+
+func hashPair(a, b common.Hash) common.Hash {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return crypto.Keccak256Hash(a[:], b[:])
+}
+
+// The root over claims, and each claim's proof (0x hashes, leaf to root)
+// in the order given. Nothing to claim has the zero root.
+func Tree(claims []Claim, epoch uint64) (string, [][]string) {
+	proofs := make([][]string, len(claims))
+	if len(claims) == 0 {
+		return common.Hash{}.Hex(), proofs
+	}
+
+	level := make([]common.Hash, len(claims))
+	// Where each claim's hash is on the current level
+	at := make([]int, len(claims))
+	for i, claim := range claims {
+		level[i] = Leaf(claim.Account, claim.Amount, epoch)
+		at[i] = i
+	}
+	for len(level) > 1 {
+		for i := range claims {
+			if sibling := at[i] ^ 1; sibling < len(level) {
+				proofs[i] = append(proofs[i], level[sibling].Hex())
+			}
+			at[i] /= 2
+		}
+		next := make([]common.Hash, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+			} else {
+				next = append(next, hashPair(level[i], level[i+1]))
+			}
+		}
+		level = next
+	}
+	for i := range proofs {
+		if proofs[i] == nil {
+			proofs[i] = []string{}
+		}
+	}
+	return level[0].Hex(), proofs
+}
+
+// Whether proof takes a claim to root, as the contract would check it
+func Verify(root string, proof []string, account string, amount, epoch uint64) bool {
+	hash := Leaf(account, amount, epoch)
+	for _, step := range proof {
+		sibling, err := hexutil.Decode(step)
+		if err != nil || len(sibling) != common.HashLength {
+			return false
+		}
+		hash = hashPair(hash, common.BytesToHash(sibling))
+	}
+	return hash.Hex() == root
+}
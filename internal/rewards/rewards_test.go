@@ -0,0 +1,49 @@
+package rewards
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestLeafMatchesEncodePacked(t *testing.T) {
+	account := "0x00000000000000000000000000000000000000aa"
+	packed := append(common.HexToAddress(account).Bytes(), common.LeftPadBytes(big.NewInt(1500).Bytes(), 32)...)
+	packed = append(packed, common.LeftPadBytes(big.NewInt(3).Bytes(), 32)...)
+	if want := crypto.Keccak256Hash(packed); Leaf(account, 1500, 3) != want {
+		t.Errorf("expected %s, got %s", want.Hex(), Leaf(account, 1500, 3).Hex())
+	}
+}
+
+func TestTree(t *testing.T) {
+	if root, proofs := Tree(nil, 1); root != (common.Hash{}).Hex() || len(proofs) != 0 {
+		t.Errorf("expected the zero root for no claims, got %s", root)
+	}
+
+	// Every size, odd levels included
+	for n := 1; n <= 9; n++ {
+		claims := make([]Claim, n)
+		for i := range claims {
+			claims[i] = Claim{Account: fmt.Sprintf("0x%040x", i+1), Amount: uint64(100 * (i + 1))}
+		}
+		root, proofs := Tree(claims, 7)
+		for i, claim := range claims {
+			if !Verify(root, proofs[i], claim.Account, claim.Amount, 7) {
+				t.Errorf("n=%d: claim %d doesn't verify", n, i)
+			}
+			if Verify(root, proofs[i], claim.Account, claim.Amount+1, 7) {
+				t.Errorf("n=%d: claim %d verifies with the wrong amount", n, i)
+			}
+			if Verify(root, proofs[i], claim.Account, claim.Amount, 8) {
+				t.Errorf("n=%d: claim %d verifies for another epoch", n, i)
+			}
+		}
+	}
+
+	if root, proofs := Tree([]Claim{{Account: "0x00000000000000000000000000000000000000aa", Amount: 5}}, 1); len(proofs[0]) != 0 || root != Leaf("0x00000000000000000000000000000000000000aa", 5, 1).Hex() {
+		t.Errorf("expected a lone claim to be the root with an empty proof, got %s %v", root, proofs[0])
+	}
+}
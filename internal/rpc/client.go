@@ -2,6 +2,7 @@ package rpc
 
 import (
 	"bytes"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -16,9 +17,16 @@ import (
 type Client struct {
 	endpoint  string
 	authToken string
+	chain     types.Chain
 	client    *http.Client
+	faults    *faultInjector
 }
 
+// op-geth answers eth_syncing from its own view, so it reports synced
+// even when op-node has stopped feeding it blocks. opBNB makes a block
+// every second; a head older than this means the node has stalled.
+const OpbnbMaxHeadAge = 60 * time.Second
+
 type RpcResponse struct {
 	Success   bool
 	Data      string
@@ -41,16 +49,17 @@ type jsonRpcResponse struct {
 }
 
 type BlockData struct {
-	Hash             string `json:"hash"`
-	Number           string `json:"number"`
-	Timestamp        string `json:"timestamp"`
-	ParentHash       string `json:"parentHash"`
-	StateRoot        string `json:"stateRoot"`
-	TransactionsRoot string `json:"transactionsRoot"`
-	ReceiptsRoot     string `json:"receiptsRoot"`
-	Miner            string `json:"miner"`
-	GasUsed          string `json:"gasUsed"`
-	GasLimit         string `json:"gasLimit"`
+	Hash             string   `json:"hash"`
+	Number           string   `json:"number"`
+	Timestamp        string   `json:"timestamp"`
+	ParentHash       string   `json:"parentHash"`
+	StateRoot        string   `json:"stateRoot"`
+	TransactionsRoot string   `json:"transactionsRoot"`
+	ReceiptsRoot     string   `json:"receiptsRoot"`
+	Miner            string   `json:"miner"`
+	GasUsed          string   `json:"gasUsed"`
+	GasLimit         string   `json:"gasLimit"`
+	Transactions     []string `json:"transactions"` // Hashes only, we never ask for full txs
 }
 
 func NewClient(endpoint string, authToken string) *Client {
@@ -60,13 +69,55 @@ func NewClient(endpoint string, authToken string) *Client {
 		client: &http.Client{
 			Timeout: 5 * time.Second,
 		},
+		faults: loadFaults("NODE"),
+		chain:  types.ChainBSC,
 	}
 }
 
+// Set which chain the node serves, for chain-specific answers and sync
+// checks. Returns the client so it can be chained onto the constructor.
+func (c *Client) WithChain(chain types.Chain) *Client {
+	c.chain = chain
+	return c
+}
+
+// Send requests through rt instead of straight to the endpoint, e.g. down
+// a tunnel to a node that can't take inbound connections. Returns the
+// client so it can be chained onto the constructor.
+func (c *Client) WithTransport(rt http.RoundTripper) *Client {
+	c.client.Transport = rt
+	return c
+}
+
+// Connect with this TLS config, e.g. to present a client certificate to
+// a node that requires mTLS. Connections aren't kept open, since clients
+// are made per check. Returns the client so it can be chained onto the
+// constructor.
+func (c *Client) WithTLS(config *tls.Config) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	transport.DisableKeepAlives = true
+	c.client.Transport = transport
+	return c
+}
+
+// Client for our own trusted RPC (the source of expected answers)
+func NewTrustedClient(endpoint string) *Client {
+	c := NewClient(endpoint, "")
+	c.faults = loadFaults("TRUSTED")
+	return c
+}
+
 // Make a JSON-RPC call to the node
 func (c *Client) call(method string, params []interface{}) (json.RawMessage, uint64, error) {
 	start := time.Now()
 
+	if c.faults != nil {
+		if err := c.faults.before(method); err != nil {
+			return nil, uint64(time.Since(start).Milliseconds()), err
+		}
+	}
+
 	reqBody := jsonRpcRequest{
 		Jsonrpc: "2.0",
 		ID:      1,
@@ -111,6 +162,10 @@ func (c *Client) call(method string, params []interface{}) (json.RawMessage, uin
 		return nil, latencyMs, fmt.Errorf(rpcResp.Error.Message)
 	}
 
+	if c.faults != nil {
+		return c.faults.after(rpcResp.Result), latencyMs, nil
+	}
+
 	return rpcResp.Result, latencyMs, nil
 }
 
@@ -134,6 +189,26 @@ func (c *Client) GetBlockNumber() (uint64, uint64, error) {
 	return blockNum, latency, nil
 }
 
+// Get the EIP-155 chain ID, to tell mainnet nodes from testnet ones
+func (c *Client) GetChainID() (uint64, uint64, error) {
+	result, latency, err := c.call("eth_chainId", []interface{}{})
+	if err != nil {
+		return 0, latency, err
+	}
+
+	var hexStr string
+	if err := json.Unmarshal(result, &hexStr); err != nil {
+		return 0, latency, err
+	}
+
+	chainID, err := strconv.ParseUint(strings.TrimPrefix(hexStr, "0x"), 16, 64)
+	if err != nil {
+		return 0, latency, err
+	}
+
+	return chainID, latency, nil
+}
+
 // Check if node is synced
 func (c *Client) GetSyncStatus() (bool, uint64, error) {
 	result, latency, err := c.call("eth_syncing", []interface{}{})
@@ -141,20 +216,38 @@ func (c *Client) GetSyncStatus() (bool, uint64, error) {
 		return false, latency, err
 	}
 
-	// If result is "false", node is synced
+	// If it's an object, node is still syncing
 	var syncing bool
-	if err := json.Unmarshal(result, &syncing); err == nil {
-		return !syncing, latency, nil
+	if err := json.Unmarshal(result, &syncing); err != nil || syncing {
+		return false, latency, nil
 	}
 
-	// If it's an object, node is still syncing
-	return false, latency, nil
+	if c.chain != types.ChainOpBNB {
+		return true, latency, nil
+	}
+
+	// opBNB: "false" only means op-geth isn't mid-sync, check the head
+	// is actually moving
+	block, headLatency, err := c.getBlock("latest")
+	latency += headLatency
+	if err != nil {
+		return false, latency, err
+	}
+	timestamp, err := strconv.ParseUint(strings.TrimPrefix(block.Timestamp, "0x"), 16, 64)
+	if err != nil {
+		return false, latency, err
+	}
+	age := time.Since(time.Unix(int64(timestamp), 0))
+	return age <= OpbnbMaxHeadAge, latency, nil
 }
 
 // Get block by number
 func (c *Client) GetBlockByNumber(blockNumber uint64) (*BlockData, uint64, error) {
-	blockHex := fmt.Sprintf("0x%x", blockNumber)
-	result, latency, err := c.call("eth_getBlockByNumber", []interface{}{blockHex, false})
+	return c.getBlock(fmt.Sprintf("0x%x", blockNumber))
+}
+
+func (c *Client) getBlock(blockTag string) (*BlockData, uint64, error) {
+	result, latency, err := c.call("eth_getBlockByNumber", []interface{}{blockTag, false})
 	if err != nil {
 		return nil, latency, err
 	}
@@ -198,6 +291,21 @@ func (c *Client) GetBalance(address string, blockNumber *uint64) (string, uint64
 	return balance, latency, nil
 }
 
+// Raw database stats from the debug namespace (most nodes don't expose it)
+func (c *Client) GetChaindbProperty(property string) (string, uint64, error) {
+	result, latency, err := c.call("debug_chaindbProperty", []interface{}{property})
+	if err != nil {
+		return "", latency, err
+	}
+
+	var stats string
+	if err := json.Unmarshal(result, &stats); err != nil {
+		return "", latency, err
+	}
+
+	return stats, latency, nil
+}
+
 // Get peer count
 func (c *Client) GetPeerCount() (uint64, uint64, error) {
 	result, latency, err := c.call("net_peerCount", []interface{}{})
@@ -218,6 +326,35 @@ func (c *Client) GetPeerCount() (uint64, uint64, error) {
 	return count, latency, nil
 }
 
+// Client name and release, as web3_clientVersion reports it
+func (c *Client) GetClientVersion() (string, uint64, error) {
+	result, latency, err := c.call("web3_clientVersion", []interface{}{})
+	if err != nil {
+		return "", latency, err
+	}
+
+	var version string
+	if err := json.Unmarshal(result, &version); err != nil {
+		return "", latency, err
+	}
+	return version, latency, nil
+}
+
+// Read-only contract call at the latest block, returning the hex result
+func (c *Client) Call(to, data string) (string, uint64, error) {
+	call := map[string]string{"to": to, "data": data}
+	result, latency, err := c.call("eth_call", []interface{}{call, "latest"})
+	if err != nil {
+		return "", latency, err
+	}
+
+	var out string
+	if err := json.Unmarshal(result, &out); err != nil {
+		return "", latency, err
+	}
+	return out, latency, nil
+}
+
 // Execute a challenge and return the answer
 func (c *Client) ExecuteChallenge(challenge *types.Challenge) RpcResponse {
 	switch challenge.ChallengeType {
@@ -247,6 +384,11 @@ func (c *Client) ExecuteChallenge(challenge *types.Challenge) RpcResponse {
 			"parentHash": block.ParentHash,
 			"stateRoot":  block.StateRoot,
 		}
+		// Every opBNB block starts with the L1 attributes deposit, which
+		// ties it to the BSC block it was derived from
+		if c.chain == types.ChainOpBNB && len(block.Transactions) > 0 {
+			data["l1InfoTx"] = block.Transactions[0]
+		}
 		jsonData, _ := json.Marshal(data)
 		return RpcResponse{Success: true, Data: string(jsonData), LatencyMs: latency}
 
@@ -266,7 +408,37 @@ func (c *Client) ExecuteChallenge(challenge *types.Challenge) RpcResponse {
 		jsonData, _ := json.Marshal(data)
 		return RpcResponse{Success: true, Data: string(jsonData), LatencyMs: latency}
 
+	case types.Composite:
+		return c.executeComposite(challenge)
+
 	default:
 		return RpcResponse{Success: false, Error: "unknown challenge type", LatencyMs: 0}
 	}
 }
+
+// Run each part of a composite challenge. The answer is a JSON array with
+// one entry per part, "" where a part failed, so the parts that did work
+// still count. Success means at least one part was answered; Error lists
+// the parts that weren't.
+func (c *Client) executeComposite(challenge *types.Challenge) RpcResponse {
+	answers := make([]string, len(challenge.Params.Parts))
+	var failures []string
+	var latency uint64
+	for i, part := range challenge.Params.Parts {
+		response := c.ExecuteChallenge(&types.Challenge{ChallengeType: part.ChallengeType, Params: part.Params})
+		latency += response.LatencyMs
+		if !response.Success {
+			failures = append(failures, fmt.Sprintf("part %d (%s): %s", i+1, part.ChallengeType, response.Error))
+			continue
+		}
+		answers[i] = response.Data
+	}
+
+	jsonData, _ := json.Marshal(answers)
+	return RpcResponse{
+		Success:   len(failures) < len(answers),
+		Data:      string(jsonData),
+		Error:     strings.Join(failures, "; "),
+		LatencyMs: latency,
+	}
+}
@@ -0,0 +1,138 @@
+package rpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/mockchain"
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+func TestOpbnbBlockData(t *testing.T) {
+	server := httptest.NewServer(mockchain.New(1000))
+	defer server.Close()
+
+	block := uint64(500)
+	ch := &types.Challenge{
+		ChallengeType: types.BlockData,
+		Params:        types.ChallengeParams{BlockNumber: &block},
+	}
+
+	tests := []struct {
+		chain    types.Chain
+		l1InfoTx string
+	}{
+		{types.ChainBSC, ""},
+		{types.ChainOpBNB, mockchain.TxHash(block, 0)},
+	}
+
+	for _, tt := range tests {
+		response := NewClient(server.URL, "").WithChain(tt.chain).ExecuteChallenge(ch)
+		if !response.Success {
+			t.Fatalf("%s: block-data failed: %s", tt.chain, response.Error)
+		}
+
+		var data map[string]string
+		if err := json.Unmarshal([]byte(response.Data), &data); err != nil {
+			t.Fatalf("%s: answer isn't JSON: %v", tt.chain, err)
+		}
+		if data["hash"] != mockchain.BlockHash(block) {
+			t.Errorf("%s: hash = %s, want %s", tt.chain, data["hash"], mockchain.BlockHash(block))
+		}
+		if data["l1InfoTx"] != tt.l1InfoTx {
+			t.Errorf("%s: l1InfoTx = %q, want %q", tt.chain, data["l1InfoTx"], tt.l1InfoTx)
+		}
+	}
+}
+
+func TestOpbnbSyncStatus(t *testing.T) {
+	chain := mockchain.New(1000)
+	server := httptest.NewServer(chain)
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		chain    types.Chain
+		headTime time.Time
+		syncing  bool
+		synced   bool
+	}{
+		{"bsc ignores head age", types.ChainBSC, time.Now().Add(-time.Hour), false, true},
+		{"opbnb with a fresh head", types.ChainOpBNB, time.Now(), false, true},
+		{"opbnb with a stalled head", types.ChainOpBNB, time.Now().Add(-2 * OpbnbMaxHeadAge), false, false},
+		{"opbnb still syncing", types.ChainOpBNB, time.Now(), true, false},
+	}
+
+	for _, tt := range tests {
+		chain.SetHeadTime(tt.headTime)
+		chain.SetSyncing(tt.syncing)
+
+		synced, _, err := NewClient(server.URL, "").WithChain(tt.chain).GetSyncStatus()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if synced != tt.synced {
+			t.Errorf("%s: synced = %v, want %v", tt.name, synced, tt.synced)
+		}
+	}
+}
+
+func TestCompositeChallenge(t *testing.T) {
+	server := httptest.NewServer(mockchain.New(1000))
+	defer server.Close()
+
+	block := uint64(500)
+	ch := &types.Challenge{
+		ChallengeType: types.Composite,
+		Params: types.ChallengeParams{Parts: []types.ChallengePart{
+			{ChallengeType: types.BlockHash, Params: types.ChallengeParams{BlockNumber: &block}},
+			{ChallengeType: "no-such-type"},
+			{ChallengeType: types.SyncStatus},
+		}},
+	}
+
+	response := NewClient(server.URL, "").ExecuteChallenge(ch)
+	if !response.Success || response.Error == "" {
+		t.Fatalf("one bad part should be a partial answer, got success=%v error=%q", response.Success, response.Error)
+	}
+
+	var answers []string
+	if err := json.Unmarshal([]byte(response.Data), &answers); err != nil || len(answers) != 3 {
+		t.Fatalf("answer should be a list of 3, got %s", response.Data)
+	}
+	if answers[0] != mockchain.BlockHash(block) || answers[1] != "" || answers[2] == "" {
+		t.Errorf("unexpected answers %q", answers)
+	}
+}
+
+func TestClientCertificate(t *testing.T) {
+	certPEM, keyPEM := mockchain.Certificate("node-a", time.Now().Add(time.Hour), x509.ExtKeyUsageClientAuth)
+	pair, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM([]byte(certPEM))
+
+	// A node that only talks to clients holding that cert
+	server := httptest.NewUnstartedServer(mockchain.New(1000))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	if _, _, err := NewClient(server.URL, "").WithTLS(&tls.Config{RootCAs: roots}).GetBlockNumber(); err == nil {
+		t.Error("expected the node to refuse a client without a certificate")
+	}
+
+	config := &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{pair}}
+	head, _, err := NewClient(server.URL, "").WithTLS(config).GetBlockNumber()
+	if err != nil || head != 1000 {
+		t.Errorf("expected head 1000 with the certificate, got %d, %v", head, err)
+	}
+}
@@ -0,0 +1,116 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fault injection for chaos testing.
+// Lets us check that anti-cheat and retry logic hold up when upstream RPCs
+// drop calls, get slow, or return garbage. Only loaded from env in builds
+// made with -tags chaos (see faults_chaos.go), so production binaries never
+// have it switched on.
+type FaultConfig struct {
+	DropPercent    int           // Fail this % of calls with an error
+	CorruptPercent int           // Mangle the result of this % of calls
+	Latency        time.Duration // Extra delay added to every call
+}
+
+func (f FaultConfig) active() bool {
+	return f.DropPercent > 0 || f.CorruptPercent > 0 || f.Latency > 0
+}
+
+type faultInjector struct {
+	config FaultConfig
+	rng    *rand.Rand
+	mu     sync.Mutex
+}
+
+func newFaultInjector(config FaultConfig) *faultInjector {
+	if !config.active() {
+		return nil
+	}
+	return &faultInjector{
+		config: config,
+		rng:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (f *faultInjector) roll(percent int) bool {
+	if percent <= 0 {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rng.Intn(100) < percent
+}
+
+// Called before the request goes out
+func (f *faultInjector) before(method string) error {
+	if f.config.Latency > 0 {
+		time.Sleep(f.config.Latency)
+	}
+	if f.roll(f.config.DropPercent) {
+		return fmt.Errorf("chaos: dropped %s call", method)
+	}
+	return nil
+}
+
+// Called with the raw result before it's returned to the caller
+func (f *faultInjector) after(result json.RawMessage) json.RawMessage {
+	if !f.roll(f.config.CorruptPercent) {
+		return result
+	}
+	return corruptResult(result)
+}
+
+// Change a result just enough that it won't match the real answer
+func corruptResult(result json.RawMessage) json.RawMessage {
+	var str string
+	if json.Unmarshal(result, &str) == nil {
+		corrupted, _ := json.Marshal(corruptHex(str))
+		return corrupted
+	}
+
+	var obj map[string]interface{}
+	if json.Unmarshal(result, &obj) == nil {
+		for _, field := range []string{"hash", "stateRoot", "parentHash"} {
+			if s, ok := obj[field].(string); ok {
+				obj[field] = corruptHex(s)
+				break
+			}
+		}
+		corrupted, _ := json.Marshal(obj)
+		return corrupted
+	}
+
+	var b bool
+	if json.Unmarshal(result, &b) == nil {
+		corrupted, _ := json.Marshal(!b)
+		return corrupted
+	}
+
+	return result
+}
+
+// Flip the last hex digit of a string
+func corruptHex(s string) string {
+	if s == "" || s == "0x" {
+		return "0x1"
+	}
+	last := s[len(s)-1]
+	replacement := "0"
+	if last == '0' {
+		replacement = "1"
+	}
+	return strings.TrimSuffix(s, string(last)) + replacement
+}
+
+// Turn on fault injection for a client by hand (tests, chaos builds)
+func (c *Client) SetFaults(config FaultConfig) {
+	c.faults = newFaultInjector(config)
+}
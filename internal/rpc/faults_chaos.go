@@ -0,0 +1,31 @@
+//go:build chaos
+
+package rpc
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// Chaos builds read fault settings from env. Scope is "TRUSTED" for our
+// trusted RPC and "NODE" for user nodes, e.g.
+//
+//	CHAOS_TRUSTED_DROP_PERCENT=10
+//	CHAOS_TRUSTED_CORRUPT_PERCENT=5
+//	CHAOS_NODE_LATENCY_MS=250
+func loadFaults(scope string) *faultInjector {
+	return newFaultInjector(FaultConfig{
+		DropPercent:    envInt("CHAOS_" + scope + "_DROP_PERCENT"),
+		CorruptPercent: envInt("CHAOS_" + scope + "_CORRUPT_PERCENT"),
+		Latency:        time.Duration(envInt("CHAOS_"+scope+"_LATENCY_MS")) * time.Millisecond,
+	})
+}
+
+func envInt(key string) int {
+	n, _ := strconv.Atoi(os.Getenv(key))
+	return n
+}
+
+// ChaosBuild reports whether fault injection can be enabled from env
+const ChaosBuild = true
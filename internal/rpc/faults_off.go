@@ -0,0 +1,11 @@
+//go:build !chaos
+
+package rpc
+
+// Normal builds never inject faults
+func loadFaults(scope string) *faultInjector {
+	return nil
+}
+
+// ChaosBuild reports whether fault injection can be enabled from env
+const ChaosBuild = false
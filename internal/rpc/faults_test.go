@@ -0,0 +1,83 @@
+package rpc
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/mockchain"
+)
+
+func TestFaultInjectorDisabledByDefault(t *testing.T) {
+	if newFaultInjector(FaultConfig{}) != nil {
+		t.Error("empty config should not create an injector")
+	}
+
+	c := NewClient("http://localhost", "")
+	if !ChaosBuild && c.faults != nil {
+		t.Error("normal builds should never load faults from env")
+	}
+}
+
+func TestFaultInjectorDropsAllCalls(t *testing.T) {
+	server := httptest.NewServer(mockchain.New(1000))
+	defer server.Close()
+
+	c := NewClient(server.URL, "")
+	c.SetFaults(FaultConfig{DropPercent: 100})
+
+	if _, _, err := c.GetBlockNumber(); err == nil {
+		t.Error("expected dropped call to return an error")
+	}
+}
+
+func TestFaultInjectorCorruptsAnswers(t *testing.T) {
+	server := httptest.NewServer(mockchain.New(1000))
+	defer server.Close()
+
+	c := NewClient(server.URL, "")
+	c.SetFaults(FaultConfig{CorruptPercent: 100})
+
+	hash, _, err := c.GetBlockHash(500)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hash == mockchain.BlockHash(500) {
+		t.Error("expected block hash to be corrupted")
+	}
+}
+
+func TestFaultInjectorAddsLatency(t *testing.T) {
+	server := httptest.NewServer(mockchain.New(1000))
+	defer server.Close()
+
+	c := NewClient(server.URL, "")
+	c.SetFaults(FaultConfig{Latency: 50 * time.Millisecond})
+
+	_, latency, err := c.GetBlockNumber()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if latency < 50 {
+		t.Errorf("expected at least 50ms latency, got %dms", latency)
+	}
+}
+
+func TestCorruptResult(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"hex string", `"0xabc"`},
+		{"block object", `{"hash":"0xabc","parentHash":"0x1"}`},
+		{"bool", `false`},
+	}
+
+	for _, tt := range tests {
+		got := corruptResult(json.RawMessage(tt.input))
+		if string(got) == tt.input {
+			t.Errorf("%s: result was not corrupted: %s", tt.name, got)
+		}
+	}
+}
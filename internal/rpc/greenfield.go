@@ -0,0 +1,163 @@
+package rpc
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// Client for a Greenfield storage provider's HTTP API. SPs don't speak
+// JSON-RPC - objects are looked up by bucket and name and metadata comes
+// back as XML.
+type GreenfieldClient struct {
+	endpoint  string
+	authToken string
+	client    *http.Client
+}
+
+// Object metadata as the SP reports it
+type ObjectMeta struct {
+	Bucket      string
+	Object      string
+	PayloadSize uint64
+	Checksums   []string // Hex, primary SP's integrity hash first
+}
+
+var ErrObjectNotFound = errors.New("object not found")
+
+type objectMetaResponse struct {
+	Object struct {
+		ObjectInfo struct {
+			BucketName  string   `xml:"BucketName"`
+			ObjectName  string   `xml:"ObjectName"`
+			PayloadSize uint64   `xml:"PayloadSize"`
+			Checksums   []string `xml:"Checksums"` // Base64
+		} `xml:"ObjectInfo"`
+		Removed bool `xml:"Removed"`
+	} `xml:"Object"`
+}
+
+func NewGreenfieldClient(endpoint string, authToken string) *GreenfieldClient {
+	return &GreenfieldClient{
+		endpoint:  strings.TrimSuffix(endpoint, "/"),
+		authToken: authToken,
+		client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+}
+
+func (c *GreenfieldClient) get(path string) ([]byte, int, uint64, error) {
+	start := time.Now()
+
+	req, err := http.NewRequest("GET", c.endpoint+path, nil)
+	if err != nil {
+		return nil, 0, uint64(time.Since(start).Milliseconds()), err
+	}
+	if c.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.authToken)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, 0, uint64(time.Since(start).Milliseconds()), err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	latencyMs := uint64(time.Since(start).Milliseconds())
+	if err != nil {
+		return nil, resp.StatusCode, latencyMs, err
+	}
+	return body, resp.StatusCode, latencyMs, nil
+}
+
+// Check the SP is up and serving its API
+func (c *GreenfieldClient) GetStatus() (uint64, error) {
+	_, status, latency, err := c.get("/status")
+	if err != nil {
+		return latency, err
+	}
+	if status != http.StatusOK {
+		return latency, fmt.Errorf("status endpoint returned %d", status)
+	}
+	return latency, nil
+}
+
+// Look up an object's metadata (path-style, so any SP endpoint works
+// without a per-bucket subdomain)
+func (c *GreenfieldClient) GetObjectMeta(bucket, object string) (*ObjectMeta, uint64, error) {
+	path := "/" + url.PathEscape(bucket) + "/" + url.PathEscape(object) + "?object-meta"
+	body, status, latency, err := c.get(path)
+	if err != nil {
+		return nil, latency, err
+	}
+	if status == http.StatusNotFound {
+		return nil, latency, ErrObjectNotFound
+	}
+	if status != http.StatusOK {
+		return nil, latency, fmt.Errorf("object-meta returned %d", status)
+	}
+
+	var resp objectMetaResponse
+	if err := xml.Unmarshal(body, &resp); err != nil {
+		return nil, latency, err
+	}
+	info := resp.Object.ObjectInfo
+	if info.ObjectName == "" || resp.Object.Removed {
+		return nil, latency, ErrObjectNotFound
+	}
+
+	meta := &ObjectMeta{
+		Bucket:      info.BucketName,
+		Object:      info.ObjectName,
+		PayloadSize: info.PayloadSize,
+		Checksums:   make([]string, 0, len(info.Checksums)),
+	}
+	// Hex so answers survive the case-insensitive comparison
+	for _, checksum := range info.Checksums {
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(checksum))
+		if err != nil {
+			return nil, latency, fmt.Errorf("bad checksum %q: %v", checksum, err)
+		}
+		meta.Checksums = append(meta.Checksums, "0x"+hex.EncodeToString(raw))
+	}
+	return meta, latency, nil
+}
+
+// Execute an object challenge and return the answer
+func (c *GreenfieldClient) ExecuteChallenge(challenge *types.Challenge) RpcResponse {
+	switch challenge.ChallengeType {
+	case types.ObjectExists:
+		_, latency, err := c.GetObjectMeta(challenge.Params.Bucket, challenge.Params.Object)
+		if err != nil && !errors.Is(err, ErrObjectNotFound) {
+			return RpcResponse{Success: false, Error: err.Error(), LatencyMs: latency}
+		}
+		data := map[string]bool{"exists": err == nil}
+		jsonData, _ := json.Marshal(data)
+		return RpcResponse{Success: true, Data: string(jsonData), LatencyMs: latency}
+
+	case types.ObjectChecksum:
+		meta, latency, err := c.GetObjectMeta(challenge.Params.Bucket, challenge.Params.Object)
+		if err != nil {
+			return RpcResponse{Success: false, Error: err.Error(), LatencyMs: latency}
+		}
+		if len(meta.Checksums) == 0 {
+			return RpcResponse{Success: false, Error: "object has no checksums", LatencyMs: latency}
+		}
+		return RpcResponse{Success: true, Data: meta.Checksums[0], LatencyMs: latency}
+
+	default:
+		return RpcResponse{Success: false, Error: "unknown challenge type", LatencyMs: 0}
+	}
+}
@@ -0,0 +1,73 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/depinonbnb/depin/internal/mockchain"
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+func TestGreenfieldObjectMeta(t *testing.T) {
+	sp := mockchain.NewSP()
+	sp.AddObject("depin-bucket", "data/file.bin")
+	server := httptest.NewServer(sp)
+	defer server.Close()
+
+	c := NewGreenfieldClient(server.URL, "")
+
+	meta, _, err := c.GetObjectMeta("depin-bucket", "data/file.bin")
+	if err != nil {
+		t.Fatalf("GetObjectMeta: %v", err)
+	}
+	want := "0x" + hex.EncodeToString(mockchain.ObjectChecksums("depin-bucket", "data/file.bin")[0])
+	if len(meta.Checksums) != 7 || meta.Checksums[0] != want {
+		t.Errorf("checksums = %v, want 7 starting with %s", meta.Checksums, want)
+	}
+
+	if _, _, err := c.GetObjectMeta("depin-bucket", "missing"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("missing object: err = %v, want ErrObjectNotFound", err)
+	}
+
+	if _, err := c.GetStatus(); err != nil {
+		t.Errorf("GetStatus: %v", err)
+	}
+}
+
+func TestGreenfieldChallenges(t *testing.T) {
+	sp := mockchain.NewSP()
+	sp.AddObject("depin-bucket", "file.bin")
+	server := httptest.NewServer(sp)
+	defer server.Close()
+
+	c := NewGreenfieldClient(server.URL, "")
+	checksum := "0x" + hex.EncodeToString(mockchain.ObjectChecksums("depin-bucket", "file.bin")[0])
+
+	tests := []struct {
+		name          string
+		challengeType types.ChallengeType
+		object        string
+		success       bool
+		data          string
+	}{
+		{"stored object exists", types.ObjectExists, "file.bin", true, `{"exists":true}`},
+		{"missing object doesn't", types.ObjectExists, "other.bin", true, `{"exists":false}`},
+		{"checksum of stored object", types.ObjectChecksum, "file.bin", true, checksum},
+		{"checksum of missing object", types.ObjectChecksum, "other.bin", false, ""},
+	}
+
+	for _, tt := range tests {
+		response := c.ExecuteChallenge(&types.Challenge{
+			ChallengeType: tt.challengeType,
+			Params:        types.ChallengeParams{Bucket: "depin-bucket", Object: tt.object},
+		})
+		if response.Success != tt.success {
+			t.Errorf("%s: success = %v, want %v (%s)", tt.name, response.Success, tt.success, response.Error)
+		}
+		if response.Data != tt.data {
+			t.Errorf("%s: data = %q, want %q", tt.name, response.Data, tt.data)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+// Package scheduler bounds how much challenge work (issuing challenges and
+// checking answers, each a trusted RPC round trip) runs at once. When every
+// slot is busy, waiting work is let in by priority rather than arrival, so
+// under load long-standing trusted nodes are served first and brand-new or
+// flagged ones wait.
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Coarse priority. Work in a higher class always goes first; within a
+// class, the higher score does, then whoever has waited longest.
+type Class int
+
+const (
+	Low Class = iota
+	Normal
+	High
+)
+
+func (c Class) String() string {
+	switch c {
+	case High:
+		return "high"
+	case Normal:
+		return "normal"
+	default:
+		return "low"
+	}
+}
+
+const sampleWindow = 1000 // Wait times kept per class
+
+// Wait times and counts for one class
+type ClassStats struct {
+	Class     string  `json:"class"`
+	Admitted  uint64  `json:"admitted"`
+	Abandoned uint64  `json:"abandoned"` // Gave up waiting, e.g. the client went away
+	Waiting   int     `json:"waiting"`
+	P50WaitMs float64 `json:"p50_wait_ms"` // Over the last 1000 admitted
+	P90WaitMs float64 `json:"p90_wait_ms"`
+	P99WaitMs float64 `json:"p99_wait_ms"`
+	MaxWaitMs float64 `json:"max_wait_ms"`
+}
+
+type Stats struct {
+	Slots   int          `json:"slots"` // 0 = unbounded
+	Running int          `json:"running"`
+	Classes []ClassStats `json:"classes"` // Highest first
+}
+
+type waiter struct {
+	class  Class
+	score  int
+	seq    uint64
+	queued time.Time
+	ready  chan struct{} // Closed once admitted
+	index  int           // In the heap, -1 once out of it
+}
+
+type waitHeap []*waiter
+
+func (h waitHeap) Len() int { return len(h) }
+func (h waitHeap) Less(i, j int) bool {
+	if h[i].class != h[j].class {
+		return h[i].class > h[j].class
+	}
+	if h[i].score != h[j].score {
+		return h[i].score > h[j].score
+	}
+	return h[i].seq < h[j].seq
+}
+func (h waitHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *waitHeap) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(*h)
+	*h = append(*h, w)
+}
+func (h *waitHeap) Pop() interface{} {
+	old := *h
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	w.index = -1
+	*h = old[:len(old)-1]
+	return w
+}
+
+type classStats struct {
+	admitted  uint64
+	abandoned uint64
+	waiting   int
+	samples   []time.Duration // Ring of the last sampleWindow waits
+	next      int
+}
+
+type Queue struct {
+	mu      sync.Mutex
+	slots   int
+	running int
+	waiting waitHeap
+	seq     uint64
+	classes map[Class]*classStats
+}
+
+// At most slots pieces of work run at once (0 = unbounded)
+func New(slots int) *Queue {
+	q := &Queue{classes: make(map[Class]*classStats)}
+	for _, c := range []Class{Low, Normal, High} {
+		q.classes[c] = &classStats{}
+	}
+	q.SetSlots(slots)
+	return q
+}
+
+// Change the number of slots. Growing lets waiting work in straight away;
+// shrinking takes effect as running work finishes.
+func (q *Queue) SetSlots(slots int) {
+	if slots < 0 {
+		slots = 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.slots = slots
+	q.admit()
+}
+
+// Wait for a slot and return the function that gives it back, which must
+// be called once the work is done. Gives up with ctx's error if ctx ends
+// first.
+func (q *Queue) Wait(ctx context.Context, class Class, score int) (func(), error) {
+	q.mu.Lock()
+	if q.free() && len(q.waiting) == 0 {
+		q.running++
+		q.observe(class, 0)
+		q.mu.Unlock()
+		return q.release, nil
+	}
+
+	q.seq++
+	w := &waiter{class: class, score: score, seq: q.seq, queued: time.Now(), ready: make(chan struct{})}
+	heap.Push(&q.waiting, w)
+	q.classes[class].waiting++
+	q.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return q.release, nil
+	case <-ctx.Done():
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if w.index < 0 {
+		// Admitted just as ctx ended; the slot is ours, so hand it on
+		q.running--
+		q.admit()
+	} else {
+		heap.Remove(&q.waiting, w.index)
+		q.classes[class].waiting--
+	}
+	q.classes[class].abandoned++
+	return nil, ctx.Err()
+}
+
+func (q *Queue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.running--
+	q.admit()
+}
+
+// Caller must hold q.mu
+func (q *Queue) free() bool {
+	return q.slots == 0 || q.running < q.slots
+}
+
+// Let waiting work in, best first, while there are free slots.
+// Caller must hold q.mu
+func (q *Queue) admit() {
+	for q.free() && len(q.waiting) > 0 {
+		w := heap.Pop(&q.waiting).(*waiter)
+		q.running++
+		q.classes[w.class].waiting--
+		q.observe(w.class, time.Since(w.queued))
+		close(w.ready)
+	}
+}
+
+// Caller must hold q.mu
+func (q *Queue) observe(class Class, waited time.Duration) {
+	cs := q.classes[class]
+	cs.admitted++
+	if len(cs.samples) < sampleWindow {
+		cs.samples = append(cs.samples, waited)
+	} else {
+		cs.samples[cs.next] = waited
+		cs.next = (cs.next + 1) % sampleWindow
+	}
+}
+
+func (q *Queue) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := Stats{Slots: q.slots, Running: q.running}
+	for _, c := range []Class{High, Normal, Low} {
+		cs := q.classes[c]
+		samples := append([]time.Duration(nil), cs.samples...)
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		stats.Classes = append(stats.Classes, ClassStats{
+			Class:     c.String(),
+			Admitted:  cs.admitted,
+			Abandoned: cs.abandoned,
+			Waiting:   cs.waiting,
+			P50WaitMs: ms(rank(samples, 50)),
+			P90WaitMs: ms(rank(samples, 90)),
+			P99WaitMs: ms(rank(samples, 99)),
+			MaxWaitMs: ms(rank(samples, 100)),
+		})
+	}
+	return stats
+}
+
+// Nearest-rank percentile of sorted samples
+func rank(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[(len(sorted)*p+99)/100-1]
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()/10) / 100
+}
@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPriorityOrder(t *testing.T) {
+	q := New(1)
+	release, err := q.Wait(context.Background(), Normal, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Queue up work in the wrong order and see it let in best first
+	order := make(chan string, 4)
+	queued := 0
+	queue := func(name string, class Class, score int) {
+		go func() {
+			done, err := q.Wait(context.Background(), class, score)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			order <- name
+			done()
+		}()
+		// Wait until it's queued so arrival order is fixed
+		queued++
+		for waiting(q) < queued {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	queue("low", Low, 90)
+	queue("normal", Normal, 10)
+	queue("high-50", High, 50)
+	queue("high-80", High, 80)
+	release()
+
+	want := []string{"high-80", "high-50", "normal", "low"}
+	for i, name := range want {
+		if got := <-order; got != name {
+			t.Fatalf("position %d: expected %s, got %s", i, name, got)
+		}
+	}
+
+	stats := q.Stats()
+	if stats.Running != 0 || stats.Classes[0].Class != "high" || stats.Classes[0].Admitted != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if stats.Classes[2].P99WaitMs == 0 {
+		t.Error("low priority work waited, so its wait time should show")
+	}
+}
+
+func waiting(q *Queue) int {
+	n := 0
+	for _, c := range q.Stats().Classes {
+		n += c.Waiting
+	}
+	return n
+}
+
+func TestWaitGivesUp(t *testing.T) {
+	q := New(1)
+	release, _ := q.Wait(context.Background(), High, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.Wait(ctx, Low, 0); err != context.DeadlineExceeded {
+		t.Fatalf("expected the wait to time out, got %v", err)
+	}
+	low := q.Stats().Classes[2]
+	if low.Waiting != 0 || low.Abandoned != 1 {
+		t.Errorf("abandoned work should leave the queue: %+v", low)
+	}
+
+	// The slot it never got is still there for the next one
+	release()
+	if _, err := q.Wait(context.Background(), Low, 0); err != nil {
+		t.Errorf("expected the free slot, got %v", err)
+	}
+}
+
+func TestSetSlots(t *testing.T) {
+	q := New(1)
+	q.Wait(context.Background(), Normal, 0)
+
+	admitted := make(chan struct{})
+	go func() {
+		q.Wait(context.Background(), Normal, 0)
+		close(admitted)
+	}()
+	for waiting(q) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	q.SetSlots(2)
+	select {
+	case <-admitted:
+	case <-time.After(time.Second):
+		t.Fatal("growing the queue should let waiting work in")
+	}
+
+	// Unbounded never queues
+	q.SetSlots(0)
+	for i := 0; i < 10; i++ {
+		if _, err := q.Wait(context.Background(), Low, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Package servicestatus tracks the verification service's own availability,
+// minute by minute, so operators can tell "my node failed" from "the
+// service was down" when uptime is disputed. A minute counts as down if
+// the server didn't get to mark itself alive in it (e.g. it was stalled),
+// or if every challenge it tried to issue failed (e.g. the trusted RPC was
+// unreachable), or if an admin had it in maintenance. Like the rest of the server's state it's kept in memory,
+// so time before the last start isn't covered.
+package servicestatus
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// How long minutes are kept, and the longest window reported
+const Retention = 30 * 24 * time.Hour
+
+// Windows the status is reported over
+var windows = []struct {
+	name string
+	span time.Duration
+}{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", Retention},
+}
+
+type minute struct {
+	alive       bool
+	maintenance bool
+	issued      uint64
+	failed      uint64
+}
+
+type Tracker struct {
+	started int64 // First whole minute tracked, unix minutes
+	settled int64 // Outages before this minute have been handed out by Settle
+	minutes map[int64]*minute
+	mu      sync.Mutex
+}
+
+// Start tracking from now. The minute the server starts in isn't counted,
+// since it was only up for part of it.
+func New(now int64) *Tracker {
+	return &Tracker{
+		started: unixMinute(now) + 1,
+		settled: unixMinute(now) + 1,
+		minutes: make(map[int64]*minute),
+	}
+}
+
+// Mark the server alive. Call it more often than once a minute, so no
+// minute is missed.
+func (t *Tracker) Alive(now int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.minute(now).alive = true
+
+	// Drop what's past retention
+	oldest := unixMinute(now) - int64(Retention/time.Minute)
+	for m := range t.minutes {
+		if m < oldest {
+			delete(t.minutes, m)
+		}
+	}
+}
+
+// Mark the service as in maintenance. Like Alive, call it more often
+// than once a minute for as long as maintenance lasts.
+func (t *Tracker) Maintenance(now int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	m := t.minute(now)
+	m.alive = true
+	m.maintenance = true
+}
+
+// Count a challenge the server tried to issue, and whether it could
+func (t *Tracker) RecordIssue(now int64, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	m := t.minute(now)
+	m.alive = true
+	if ok {
+		m.issued++
+	} else {
+		m.failed++
+	}
+}
+
+// Caller must hold t.mu
+func (t *Tracker) minute(now int64) *minute {
+	key := unixMinute(now)
+	m := t.minutes[key]
+	if m == nil {
+		m = &minute{}
+		t.minutes[key] = m
+	}
+	return m
+}
+
+// Availability and issuance over each window, and the outages in the
+// longest one. The minute in progress isn't counted yet.
+func (t *Tracker) Report(now int64) types.ServiceStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	end := unixMinute(now) // Exclusive
+	status := types.ServiceStatus{
+		TrackingSince: t.started * 60 * 1000,
+		GeneratedAt:   now,
+	}
+	for _, w := range windows {
+		start := t.windowStart(end, w.span)
+		window := types.ServiceWindow{Window: w.name}
+		var down int64
+		for m := start; m < end; m++ {
+			window.MinutesTracked++
+			if t.cause(m) != "" {
+				down++
+			}
+			if stats := t.minutes[m]; stats != nil {
+				window.ChallengesIssued += stats.issued
+				window.IssueFailures += stats.failed
+			}
+		}
+		if window.MinutesTracked > 0 {
+			window.UptimePercent = round(float64(window.MinutesTracked-down) / float64(window.MinutesTracked) * 100)
+		}
+		if attempts := window.ChallengesIssued + window.IssueFailures; attempts > 0 {
+			rate := round(float64(window.ChallengesIssued) / float64(attempts) * 100)
+			window.IssueSuccessRate = &rate
+		}
+		status.Windows = append(status.Windows, window)
+	}
+	status.Outages = t.outages(t.windowStart(end, Retention), end)
+	return status
+}
+
+// Outage windows between two times (unix ms), oldest first. One still
+// going is cut off at the current minute.
+func (t *Tracker) Outages(since, now int64) []types.Outage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	end := unixMinute(now)
+	start := unixMinute(since)
+	if oldest := t.windowStart(end, Retention); start < oldest {
+		start = oldest
+	}
+	return t.outages(start, end)
+}
+
+// Outages that have ended since the last call, so nodes can be made up
+// for them. Each outage is handed out once. One still going, or that
+// ended too recently to be sure it won't carry on, waits for a later call.
+func (t *Tracker) Settle(now int64) []types.Outage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	end := unixMinute(now)
+	start := t.settled
+	if oldest := t.windowStart(end, Retention); start < oldest {
+		start = oldest
+	}
+
+	var settled []types.Outage
+	for _, outage := range t.outages(start, end) {
+		// Reaching the minute in progress means it may not be over
+		if outage.End >= end*60*1000 {
+			break
+		}
+		settled = append(settled, outage)
+		t.settled = outage.End / (60 * 1000)
+	}
+	return settled
+}
+
+// Consecutive down minutes with the same cause make one outage.
+// Caller must hold t.mu.
+func (t *Tracker) outages(start, end int64) []types.Outage {
+	var outages []types.Outage
+	for m := start; m < end; m++ {
+		cause := t.cause(m)
+		if cause == "" {
+			continue
+		}
+		if n := len(outages); n > 0 && outages[n-1].Cause == cause && outages[n-1].End == m*60*1000 {
+			outages[n-1].End = (m + 1) * 60 * 1000
+			continue
+		}
+		outages = append(outages, types.Outage{Start: m * 60 * 1000, End: (m + 1) * 60 * 1000, Cause: cause})
+	}
+	return outages
+}
+
+// Why a minute counts as down, or "" if it doesn't. Caller must hold t.mu.
+func (t *Tracker) cause(m int64) types.OutageCause {
+	stats := t.minutes[m]
+	switch {
+	case stats == nil || !stats.alive:
+		return types.OutageUnresponsive
+	case stats.maintenance:
+		return types.OutageMaintenance
+	case stats.failed > 0 && stats.issued == 0:
+		return types.OutageIssuance
+	}
+	return ""
+}
+
+// Caller must hold t.mu
+func (t *Tracker) windowStart(end int64, span time.Duration) int64 {
+	start := end - int64(span/time.Minute)
+	if start < t.started {
+		return t.started
+	}
+	return start
+}
+
+func unixMinute(ms int64) int64 {
+	return ms / (60 * 1000)
+}
+
+func round(percent float64) float64 {
+	return math.Round(percent*100) / 100
+}
@@ -0,0 +1,124 @@
+package servicestatus
+
+import (
+	"testing"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+const minuteMs = 60 * 1000
+
+func TestReport(t *testing.T) {
+	start := int64(1000 * minuteMs)
+	tr := New(start + 30*1000) // Part way into minute 1000, so tracking starts at 1001
+
+	// Up for minutes 1001-1010, except 1005 when nothing could be issued
+	// and 1006-1007 when the server stalled
+	for m := int64(1001); m <= 1010; m++ {
+		now := m*minuteMs + 1000
+		switch m {
+		case 1005:
+			tr.RecordIssue(now, false)
+			tr.RecordIssue(now, false)
+		case 1006, 1007:
+		default:
+			tr.Alive(now)
+			tr.RecordIssue(now, true)
+		}
+	}
+	// A failure with successes in the same minute isn't an outage
+	tr.RecordIssue(1010*minuteMs+2000, false)
+
+	status := tr.Report(1011*minuteMs + 5000)
+	if status.TrackingSince != 1001*minuteMs {
+		t.Errorf("expected tracking from minute 1001, got %d", status.TrackingSince)
+	}
+	day := status.Windows[0]
+	if day.MinutesTracked != 10 || day.UptimePercent != 70 || day.ChallengesIssued != 7 || day.IssueFailures != 3 {
+		t.Errorf("unexpected 24h window: %+v", day)
+	}
+	if day.IssueSuccessRate == nil || *day.IssueSuccessRate != 70 {
+		t.Errorf("expected a 70%% issue success rate, got %v", day.IssueSuccessRate)
+	}
+
+	want := []types.Outage{
+		{Start: 1005 * minuteMs, End: 1006 * minuteMs, Cause: types.OutageIssuance},
+		{Start: 1006 * minuteMs, End: 1008 * minuteMs, Cause: types.OutageUnresponsive},
+	}
+	if len(status.Outages) != len(want) {
+		t.Fatalf("expected %d outages, got %+v", len(want), status.Outages)
+	}
+	for i := range want {
+		if status.Outages[i] != want[i] {
+			t.Errorf("outage %d: expected %+v, got %+v", i, want[i], status.Outages[i])
+		}
+	}
+
+	// Asking from before tracking started only gets what's tracked
+	if outages := tr.Outages(0, 1011*minuteMs); len(outages) != 2 {
+		t.Errorf("expected 2 outages, got %+v", outages)
+	}
+	if outages := tr.Outages(1006*minuteMs, 1011*minuteMs); len(outages) != 1 || outages[0].Cause != types.OutageUnresponsive {
+		t.Errorf("expected just the stall, got %+v", outages)
+	}
+}
+
+func TestReportNothingYet(t *testing.T) {
+	tr := New(0)
+	status := tr.Report(30 * 1000)
+	if len(status.Windows) != 3 || status.Windows[0].MinutesTracked != 0 || status.Windows[0].IssueSuccessRate != nil || len(status.Outages) != 0 {
+		t.Errorf("expected an empty report before the first whole minute, got %+v", status)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	tr := New(0)
+	tr.Alive(1 * minuteMs)
+	tr.Alive(31 * 24 * 60 * minuteMs)
+	if _, ok := tr.minutes[1]; ok {
+		t.Error("expected minutes past retention to be dropped")
+	}
+}
+
+func TestSettle(t *testing.T) {
+	tr := New(0)
+	for _, m := range []int64{1, 2, 5, 6} {
+		tr.Alive(m * minuteMs)
+	}
+
+	// Minutes 3-4 were down, and 7 (in progress) hasn't been marked yet
+	settled := tr.Settle(7*minuteMs + 1000)
+	if len(settled) != 1 || settled[0].Start != 3*minuteMs || settled[0].End != 5*minuteMs {
+		t.Fatalf("expected the 3-5 outage, got %+v", settled)
+	}
+	if again := tr.Settle(7*minuteMs + 2000); len(again) != 0 {
+		t.Errorf("an outage should only be settled once, got %+v", again)
+	}
+
+	// 7 and 8 were missed and the outage isn't over until a minute is up
+	if ongoing := tr.Settle(9*minuteMs + 1000); len(ongoing) != 0 {
+		t.Errorf("an outage reaching the current minute may carry on, got %+v", ongoing)
+	}
+	tr.Alive(9 * minuteMs)
+	settled = tr.Settle(10*minuteMs + 1000)
+	if len(settled) != 1 || settled[0].Start != 7*minuteMs || settled[0].End != 9*minuteMs {
+		t.Errorf("expected the 7-9 outage once it ended, got %+v", settled)
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	tr := New(0)
+	tr.Alive(1 * minuteMs)
+	// Still marked alive while in maintenance; maintenance wins for the minute
+	for _, m := range []int64{2, 3} {
+		tr.Alive(m*minuteMs + 1000)
+		tr.Maintenance(m*minuteMs + 2000)
+	}
+	tr.Alive(4 * minuteMs)
+
+	settled := tr.Settle(5*minuteMs + 1000)
+	want := types.Outage{Start: 2 * minuteMs, End: 4 * minuteMs, Cause: types.OutageMaintenance}
+	if len(settled) != 1 || settled[0] != want {
+		t.Errorf("expected %+v, got %+v", want, settled)
+	}
+}
@@ -0,0 +1,74 @@
+package sharedcache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// How long a cache call may take. Callers fall back to their own memory
+// when it fails, so a slow Redis mustn't hold requests up.
+const redisTimeout = 500 * time.Millisecond
+
+// Every key is put under this, so the server can share a Redis
+const redisPrefix = "depin:"
+
+// A Cache in Redis (6.2 or newer, for GETDEL)
+type Redis struct {
+	client *redis.Client
+}
+
+var _ Cache = (*Redis)(nil)
+
+// Connect to url (redis://[:password@]host:port/db, or rediss:// for TLS)
+// and check the server answers
+func NewRedis(url string) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &Redis{client: client}, nil
+}
+
+func (r *Redis) Get(key string) ([]byte, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return found(r.client.Get(ctx, redisPrefix+key).Bytes())
+}
+
+func (r *Redis) Set(key string, value []byte, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return r.client.Set(ctx, redisPrefix+key, value, ttl).Err()
+}
+
+func (r *Redis) Take(key string) ([]byte, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return found(r.client.GetDel(ctx, redisPrefix+key).Bytes())
+}
+
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
+
+// A missing key isn't an error
+func found(value []byte, err error) ([]byte, bool, error) {
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
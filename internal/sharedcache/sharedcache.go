@@ -0,0 +1,77 @@
+package sharedcache
+
+import (
+	"sync"
+	"time"
+)
+
+// Values every server process can see, so state that lived in one
+// process's memory can be picked up by another behind the same load
+// balancer. Implementations are safe for concurrent use.
+type Cache interface {
+	// ok is false if key isn't set or has expired
+	Get(key string) (value []byte, ok bool, err error)
+	// ttl 0 = until deleted
+	Set(key string, value []byte, ttl time.Duration) error
+	// Get and delete in one step, so when several processes race for a
+	// key, only one of them gets it
+	Take(key string) (value []byte, ok bool, err error)
+}
+
+// A Cache in this process's memory. For tests, and for a single server
+// that doesn't need Redis.
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time // Zero = never
+}
+
+var _ Cache = (*Memory)(nil)
+
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]memoryEntry)}
+}
+
+func (m *Memory) Get(key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.live(key)
+	return value, ok, nil
+}
+
+func (m *Memory) Set(key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := memoryEntry{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	m.entries[key] = entry
+	return nil
+}
+
+func (m *Memory) Take(key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.live(key)
+	delete(m.entries, key)
+	return value, ok, nil
+}
+
+// Caller must hold m.mu
+func (m *Memory) live(key string) ([]byte, bool) {
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		delete(m.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
@@ -0,0 +1,28 @@
+package sharedcache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemory(t *testing.T) {
+	m := NewMemory()
+	m.Set("a", []byte("1"), 0)
+	m.Set("b", []byte("2"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	if value, ok, _ := m.Get("a"); !ok || string(value) != "1" {
+		t.Errorf("expected a set, got %q %v", value, ok)
+	}
+	if _, ok, _ := m.Get("b"); ok {
+		t.Error("expected b expired")
+	}
+
+	// Only the first taker gets it
+	if value, ok, _ := m.Take("a"); !ok || string(value) != "1" {
+		t.Errorf("expected to take a, got %q %v", value, ok)
+	}
+	if _, ok, _ := m.Take("a"); ok {
+		t.Error("expected a gone once taken")
+	}
+}
@@ -0,0 +1,230 @@
+package signing
+
+import (
+	"encoding/base64"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// A server signing key. Key IDs are the key's address, so a signature's
+// key_id is also what you pass to ecrecover checks.
+type Key struct {
+	ID        string
+	Version   int
+	Signer    *Signer // Nil for retired keys we only know the address of
+	CreatedAt time.Time
+	RetiredAt time.Time // Zero while active
+}
+
+// Keyring holds the active signing key plus recently retired ones.
+// Retired keys stay published for a grace period so signatures issued
+// just before a rotation can still be checked.
+type Keyring struct {
+	keys    []*Key // Oldest first, active key last
+	grace   time.Duration
+	version int
+	mu      sync.RWMutex
+}
+
+func NewKeyring(grace time.Duration) *Keyring {
+	return &Keyring{grace: grace}
+}
+
+// Change how long retired keys stay published
+func (k *Keyring) SetGrace(grace time.Duration) {
+	k.mu.Lock()
+	k.grace = grace
+	k.mu.Unlock()
+}
+
+// Make next the active key. The previous active key is retired, not
+// removed. Rotating to the key that's already active does nothing.
+func (k *Keyring) Rotate(next *Signer) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	if active := k.active(); active != nil {
+		if strings.EqualFold(active.ID, next.Address()) {
+			return
+		}
+		active.RetiredAt = now
+	}
+
+	// Reactivating a retired key replaces its old entry
+	for i, key := range k.keys {
+		if strings.EqualFold(key.ID, next.Address()) {
+			k.keys = append(k.keys[:i], k.keys[i+1:]...)
+			break
+		}
+	}
+
+	k.version++
+	k.keys = append(k.keys, &Key{
+		ID:        next.Address(),
+		Version:   k.version,
+		Signer:    next,
+		CreatedAt: now,
+	})
+}
+
+// Stop signing with the active key (it stays published until the grace
+// period runs out)
+func (k *Keyring) Disable() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if active := k.active(); active != nil {
+		active.RetiredAt = time.Now()
+	}
+}
+
+// Publish keys we no longer hold, e.g. ones retired before a restart.
+// Replaces the previous list, so a key stops being published as soon as
+// it's dropped from config.
+func (k *Keyring) SetRetired(addresses []string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	held := make([]*Key, 0, len(k.keys))
+	for _, key := range k.keys {
+		if key.Signer != nil {
+			held = append(held, key)
+		}
+	}
+
+	// Address-only keys go first so the active key stays last
+	keys := make([]*Key, 0, len(addresses)+len(held))
+	for _, address := range addresses {
+		if !containsKey(held, address) && !containsKey(keys, address) {
+			keys = append(keys, &Key{ID: address})
+		}
+	}
+	k.keys = append(keys, held...)
+}
+
+func containsKey(keys []*Key, id string) bool {
+	for _, key := range keys {
+		if strings.EqualFold(key.ID, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// The key new signatures are made with, nil if signing is off
+func (k *Keyring) Active() *Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	active := k.active()
+	if active == nil {
+		return nil
+	}
+	copied := *active
+	return &copied
+}
+
+// Caller must hold k.mu
+func (k *Keyring) active() *Key {
+	if len(k.keys) == 0 {
+		return nil
+	}
+	last := k.keys[len(k.keys)-1]
+	if last.Signer == nil || !last.RetiredAt.IsZero() {
+		return nil
+	}
+	return last
+}
+
+// Keys anyone should accept signatures from right now: the active key,
+// anything retired within the grace period, and address-only keys.
+func (k *Keyring) Published() []Key {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	cutoff := time.Now().Add(-k.grace)
+	kept := k.keys[:0]
+	for _, key := range k.keys {
+		if key.Signer != nil && !key.RetiredAt.IsZero() && key.RetiredAt.Before(cutoff) {
+			continue
+		}
+		kept = append(kept, key)
+	}
+	k.keys = kept
+
+	published := make([]Key, len(kept))
+	for i, key := range kept {
+		published[i] = *key
+	}
+	return published
+}
+
+// Is this key currently published
+func (k *Keyring) Trusted(keyID string) bool {
+	for _, key := range k.Published() {
+		if strings.EqualFold(key.ID, keyID) {
+			return true
+		}
+	}
+	return false
+}
+
+// JWKS-style description of a key
+type JWK struct {
+	Kty       string `json:"kty"`
+	Crv       string `json:"crv"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+	Alg       string `json:"alg"`
+	Use       string `json:"use"`
+	Kid       string `json:"kid"`
+	Address   string `json:"address"`
+	Version   int    `json:"version,omitempty"`
+	Status    string `json:"status"` // "active" or "retired"
+	CreatedAt int64  `json:"created_at,omitempty"`
+	RetiredAt int64  `json:"retired_at,omitempty"`
+	ExpiresAt int64  `json:"expires_at,omitempty"` // When a retired key stops being published
+}
+
+func (k *Keyring) JWKS() []JWK {
+	k.mu.RLock()
+	grace := k.grace
+	k.mu.RUnlock()
+
+	published := k.Published()
+	jwks := make([]JWK, 0, len(published))
+	for _, key := range published {
+		jwk := JWK{
+			Kty:     "EC",
+			Crv:     "secp256k1",
+			Alg:     "ES256K",
+			Use:     "sig",
+			Kid:     key.ID,
+			Address: key.ID,
+			Version: key.Version,
+			Status:  "active",
+		}
+
+		if key.Signer != nil {
+			pub := crypto.FromECDSAPub(&key.Signer.privateKey.PublicKey) // 0x04 || X || Y
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub[1:33])
+			jwk.Y = base64.RawURLEncoding.EncodeToString(pub[33:65])
+			jwk.CreatedAt = key.CreatedAt.UnixMilli()
+		}
+
+		if key.Signer == nil || !key.RetiredAt.IsZero() {
+			jwk.Status = "retired"
+		}
+		if !key.RetiredAt.IsZero() {
+			jwk.RetiredAt = key.RetiredAt.UnixMilli()
+			jwk.ExpiresAt = key.RetiredAt.Add(grace).UnixMilli()
+		}
+
+		jwks = append(jwks, jwk)
+	}
+	return jwks
+}
@@ -0,0 +1,94 @@
+package signing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyringRotation(t *testing.T) {
+	k := NewKeyring(time.Hour)
+	if k.Active() != nil {
+		t.Fatal("new keyring should have no active key")
+	}
+
+	first := newTestSigner(t)
+	second := newTestSigner(t)
+
+	k.Rotate(first)
+	k.Rotate(first) // No-op
+	if k.Active().ID != first.Address() || k.Active().Version != 1 {
+		t.Fatalf("expected first key active at version 1, got %+v", k.Active())
+	}
+
+	k.Rotate(second)
+	if k.Active().ID != second.Address() || k.Active().Version != 2 {
+		t.Fatalf("expected second key active at version 2, got %+v", k.Active())
+	}
+
+	// Signatures made with the old key must stay checkable
+	if !k.Trusted(first.Address()) {
+		t.Error("retired key should stay published during the grace period")
+	}
+
+	jwks := k.JWKS()
+	if len(jwks) != 2 || jwks[0].Status != "retired" || jwks[1].Status != "active" {
+		t.Errorf("unexpected JWKS: %+v", jwks)
+	}
+	if jwks[0].ExpiresAt == 0 || jwks[1].X == "" {
+		t.Errorf("expected expiry on retired key and coordinates on active key: %+v", jwks)
+	}
+}
+
+func TestKeyringGraceExpiry(t *testing.T) {
+	k := NewKeyring(0)
+
+	first := newTestSigner(t)
+	k.Rotate(first)
+	k.Rotate(newTestSigner(t))
+
+	time.Sleep(time.Millisecond)
+	if k.Trusted(first.Address()) {
+		t.Error("retired key should be dropped once the grace period is over")
+	}
+}
+
+func TestKeyringDisable(t *testing.T) {
+	k := NewKeyring(time.Hour)
+	signer := newTestSigner(t)
+
+	k.Rotate(signer)
+	k.Disable()
+
+	if k.Active() != nil {
+		t.Error("disabled keyring should not sign")
+	}
+	if !k.Trusted(signer.Address()) {
+		t.Error("disabled key should stay published")
+	}
+
+	k.Rotate(signer)
+	if k.Active() == nil || len(k.Published()) != 1 {
+		t.Error("re-enabling the same key should not duplicate it")
+	}
+}
+
+func TestKeyringSetRetired(t *testing.T) {
+	k := NewKeyring(time.Hour)
+	signer := newTestSigner(t)
+	k.Rotate(signer)
+
+	old := "0x1111111111111111111111111111111111111111"
+	k.SetRetired([]string{old, old})
+
+	if !k.Trusted(old) || len(k.Published()) != 2 {
+		t.Errorf("expected old address published once, got %+v", k.Published())
+	}
+	if k.Active().ID != signer.Address() {
+		t.Error("retired addresses should not displace the active key")
+	}
+
+	k.SetRetired(nil)
+	if k.Trusted(old) {
+		t.Error("address dropped from config should stop being published")
+	}
+}
@@ -0,0 +1,50 @@
+package signing
+
+import "strings"
+
+// Something the server pushed to a prover, e.g. a streamed challenge.
+// The payload is kept as the exact JSON that was signed, so receivers
+// must check it before parsing.
+type Push struct {
+	Event     string `json:"event"`
+	NodeID    string `json:"node_id"`
+	Payload   string `json:"payload"`
+	Timestamp int64  `json:"timestamp"`
+	KeyID     string `json:"key_id,omitempty"`    // Empty when signing is off
+	Signature string `json:"signature,omitempty"` // Over PushMessage
+}
+
+// Wrap a payload for pushing to a node, signed with the active key.
+// Left unsigned when signing is off.
+func (k *Keyring) SignPush(event, nodeID string, payload []byte, timestamp int64) (Push, error) {
+	push := Push{Event: event, NodeID: nodeID, Payload: string(payload), Timestamp: timestamp}
+
+	key := k.Active()
+	if key == nil {
+		return push, nil
+	}
+	signature, err := key.Signer.Sign(PushMessage(event, nodeID, push.Payload, timestamp))
+	if err != nil {
+		return push, err
+	}
+	push.KeyID = key.ID
+	push.Signature = signature
+	return push, nil
+}
+
+// Whether one of the addresses signed the push
+func (p Push) Verify(addresses []string) bool {
+	if p.Signature == "" {
+		return false
+	}
+	message := PushMessage(p.Event, p.NodeID, p.Payload, p.Timestamp)
+	for _, address := range addresses {
+		if p.KeyID != "" && !strings.EqualFold(p.KeyID, address) {
+			continue
+		}
+		if Verify(message, p.Signature, address) {
+			return true
+		}
+	}
+	return false
+}
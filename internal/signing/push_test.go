@@ -0,0 +1,41 @@
+package signing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignPush(t *testing.T) {
+	k := NewKeyring(time.Hour)
+
+	unsigned, err := k.SignPush("challenge", "n1", []byte(`{"id":"c1"}`), 1000)
+	if err != nil || unsigned.Signature != "" || unsigned.Verify(nil) {
+		t.Fatalf("push should be unsigned with signing off: %+v", unsigned)
+	}
+
+	signer := newTestSigner(t)
+	k.Rotate(signer)
+	push, err := k.SignPush("challenge", "n1", []byte(`{"id":"c1"}`), 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !push.Verify([]string{newTestSigner(t).Address(), signer.Address()}) {
+		t.Error("push should verify against the signing key")
+	}
+	if push.Verify([]string{newTestSigner(t).Address()}) {
+		t.Error("push should not verify against another key")
+	}
+
+	// Every field is covered
+	for name, tampered := range map[string]Push{
+		"event":     {Event: "ping", NodeID: push.NodeID, Payload: push.Payload, Timestamp: push.Timestamp},
+		"node":      {Event: push.Event, NodeID: "n2", Payload: push.Payload, Timestamp: push.Timestamp},
+		"payload":   {Event: push.Event, NodeID: push.NodeID, Payload: `{"id":"c2"}`, Timestamp: push.Timestamp},
+		"timestamp": {Event: push.Event, NodeID: push.NodeID, Payload: push.Payload, Timestamp: 2000},
+	} {
+		tampered.KeyID, tampered.Signature = push.KeyID, push.Signature
+		if tampered.Verify([]string{signer.Address()}) {
+			t.Errorf("changing the %s should break the signature", name)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package signing
+
+import (
+	"strings"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// What the server decided about one submitted answer, signed so the
+// operator can keep it as evidence independent of the server's own
+// records (e.g. if its data is lost, or in a dispute over points).
+type Receipt struct {
+	ChallengeID   string              `json:"challenge_id"`
+	NodeID        string              `json:"node_id"`
+	ChallengeType types.ChallengeType `json:"challenge_type,omitempty"`
+	Passed        bool                `json:"passed"`
+	FailureKind   types.FailureKind   `json:"failure_kind,omitempty"`
+	PointsDelta   int64               `json:"points_delta"` // How much the node's points moved while the result was recorded
+	TotalPoints   uint64              `json:"total_points"`
+	Timestamp     int64               `json:"timestamp"`
+	KeyID         string              `json:"key_id,omitempty"`    // Empty when signing is off
+	Signature     string              `json:"signature,omitempty"` // Over ReceiptMessage
+}
+
+// Sign a receipt with the active key. Left unsigned when signing is off.
+func (k *Keyring) SignReceipt(receipt Receipt) (Receipt, error) {
+	key := k.Active()
+	if key == nil {
+		return receipt, nil
+	}
+	signature, err := key.Signer.Sign(ReceiptMessage(receipt))
+	if err != nil {
+		return receipt, err
+	}
+	receipt.KeyID = key.ID
+	receipt.Signature = signature
+	return receipt, nil
+}
+
+// Whether one of the addresses signed the receipt
+func (r Receipt) Verify(addresses []string) bool {
+	if r.Signature == "" {
+		return false
+	}
+	message := ReceiptMessage(r)
+	for _, address := range addresses {
+		if r.KeyID != "" && !strings.EqualFold(r.KeyID, address) {
+			continue
+		}
+		if Verify(message, r.Signature, address) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package signing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+func TestSignReceipt(t *testing.T) {
+	k := NewKeyring(time.Hour)
+	receipt := Receipt{ChallengeID: "c1", NodeID: "n1", ChallengeType: types.BlockHash, Passed: true, PointsDelta: 2, TotalPoints: 40, Timestamp: 1000}
+
+	unsigned, err := k.SignReceipt(receipt)
+	if err != nil || unsigned.Signature != "" || unsigned.Verify(nil) {
+		t.Fatalf("receipt should be unsigned with signing off: %+v", unsigned)
+	}
+
+	signer := newTestSigner(t)
+	k.Rotate(signer)
+	signed, err := k.SignReceipt(receipt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signed.Verify([]string{newTestSigner(t).Address(), signer.Address()}) {
+		t.Error("receipt should verify against the signing key")
+	}
+	if signed.Verify([]string{newTestSigner(t).Address()}) {
+		t.Error("receipt should not verify against another key")
+	}
+
+	// Every field is covered
+	for name, tamper := range map[string]func(*Receipt){
+		"challenge":    func(r *Receipt) { r.ChallengeID = "c2" },
+		"node":         func(r *Receipt) { r.NodeID = "n2" },
+		"type":         func(r *Receipt) { r.ChallengeType = types.StateBalance },
+		"result":       func(r *Receipt) { r.Passed = false },
+		"failure kind": func(r *Receipt) { r.FailureKind = types.FailureWrongAnswer },
+		"points":       func(r *Receipt) { r.PointsDelta = 200 },
+		"total":        func(r *Receipt) { r.TotalPoints = 4000 },
+		"timestamp":    func(r *Receipt) { r.Timestamp = 2000 },
+	} {
+		tampered := signed
+		tamper(&tampered)
+		if tampered.Verify([]string{signer.Address()}) {
+			t.Errorf("changing the %s should break the signature", name)
+		}
+	}
+}
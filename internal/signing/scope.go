@@ -0,0 +1,59 @@
+package signing
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Every message a prover signs, by first line, with the fields that must
+// follow in order. A prover refuses to sign anything else, so a
+// compromised or spoofed API can't get its wallet key to sign, say, a
+// token permit or a login for some other site.
+var proverMessages = map[string][]string{
+	"Register node":            {"Wallet", "Type", "Timestamp"},
+	"Hardware attestation":     {"Wallet", "Type", "CPUs", "Disk", "OS", "Timestamp"},
+	"Request challenge":        {"Node", "Timestamp"},
+	"Subscribe challenges":     {"Node", "Timestamp"},
+	"Open tunnel":              {"Node", "Timestamp"},
+	"Heartbeat":                {"Node", "Block", "Hash", "Timestamp"},
+	"Challenge Commit":         {"ID", "Commitment", "Timestamp"},
+	"DePIN Challenge Response": {"Version", "ID", "Node", "Type", "Answer", "Build", "Timestamp"},
+}
+
+// Fields whose values have a fixed shape
+var proverFields = map[string]*regexp.Regexp{
+	"Timestamp":  regexp.MustCompile(`^[0-9]+$`),
+	"Block":      regexp.MustCompile(`^[0-9]+$`),
+	"Hash":       regexp.MustCompile(`^0x[0-9a-f]{64}$`),
+	"Version":    regexp.MustCompile(`^3$`),
+	"Answer":     regexp.MustCompile(`^0x[0-9a-f]{64}$`), // keccak256 of the answer
+	"Commitment": regexp.MustCompile(`^0x[0-9a-f]{64}$`),
+	"Build":      regexp.MustCompile(`^0x[0-9a-f]{64}$`), // sha256 of the prover binary
+	"Wallet":     regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`),
+}
+
+// Check that a message is one a prover should sign: a known first line,
+// then exactly that message's fields, each on its own line. Returns why
+// not otherwise.
+func CheckProverMessage(message string) error {
+	lines := strings.Split(message, "\n")
+	fields, ok := proverMessages[lines[0]]
+	if !ok {
+		return fmt.Errorf("not a DePIN prover message: %q", lines[0])
+	}
+	if len(lines) != len(fields)+1 {
+		return fmt.Errorf("%s: expected %d fields, got %d", lines[0], len(fields), len(lines)-1)
+	}
+
+	for i, field := range fields {
+		value, ok := strings.CutPrefix(lines[i+1], field+": ")
+		if !ok || value == "" {
+			return fmt.Errorf("%s: line %d should be %s", lines[0], i+2, field)
+		}
+		if pattern := proverFields[field]; pattern != nil && !pattern.MatchString(value) {
+			return fmt.Errorf("%s: malformed %s %q", lines[0], field, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package signing
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+func TestCheckProverMessage(t *testing.T) {
+	build := "0x" + strings.Repeat("cd", 32)
+	answer, _ := AnswerMessage(AnswerV3, "c1", "n1", types.BlockHash, "0xabc", build, 1000)
+	v2, _ := AnswerMessage(AnswerV2, "c1", "n1", types.BlockHash, "0xabc", "", 1000)
+	commit := fmt.Sprintf("Challenge Commit\nID: c1\nCommitment: %s\nTimestamp: 1000", Commitment("0xabc", "nonce"))
+
+	valid := []string{
+		"Register node\nWallet: 0x00000000000000000000000000000000000000aB\nType: bsc-full\nTimestamp: 1000",
+		"Request challenge\nNode: n1\nTimestamp: 1000",
+		"Subscribe challenges\nNode: n1\nTimestamp: 1000",
+		HeartbeatMessage("n1", 46000000, "0x"+strings.Repeat("ab", 32), 1000),
+		answer,
+		commit,
+	}
+	for _, message := range valid {
+		if err := CheckProverMessage(message); err != nil {
+			t.Errorf("should sign %q: %v", message, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"Transfer 100 BNB to 0xabc",
+		"Request challenge\nNode: n1", // Missing field
+		"Request challenge\nNode: n1\nTimestamp: 1000\nAmount: 100",    // Extra field
+		"Request challenge\nTimestamp: 1000\nNode: n1",                 // Out of order
+		"Request challenge\nNode: n1\nTimestamp: soon",                 // Malformed value
+		"Challenge Response\nID: c1\nAnswer: 0xabc\nTimestamp: 1000",   // v1 is never signed any more
+		"Request challenge\r\nNode: n1\nTimestamp: 1000",               // Not quite our first line
+		"Challenge Commit\nID: c1\nCommitment: 0xabc\nTimestamp: 1000", // Not a hash
+		HeartbeatMessage("n1", 46000000, "0xabc", 1000),                // Not a block hash
+		v2, // Nor is v2; the prover signs its build
+	}
+	for _, message := range invalid {
+		if err := CheckProverMessage(message); err == nil {
+			t.Errorf("should refuse %q", message)
+		}
+	}
+}
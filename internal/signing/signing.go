@@ -0,0 +1,143 @@
+package signing
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/depinonbnb/depin/internal/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Server signing identity.
+// Uses the same Ethereum personal_sign scheme as wallets, so anyone can
+// check a server signature with standard tooling (ecrecover).
+type Signer struct {
+	privateKey *ecdsa.PrivateKey
+	address    string
+}
+
+func NewSigner(hexKey string) (*Signer, error) {
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(hexKey, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key: %v", err)
+	}
+
+	return &Signer{
+		privateKey: privateKey,
+		address:    crypto.PubkeyToAddress(privateKey.PublicKey).Hex(),
+	}, nil
+}
+
+// Address anyone can use to check our signatures
+func (s *Signer) Address() string {
+	return s.address
+}
+
+func (s *Signer) Sign(message string) (string, error) {
+	sig, err := crypto.Sign(hashMessage(message), s.privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	// Ethereum uses v = 27 or 28
+	sig[64] += 27
+
+	return "0x" + hex.EncodeToString(sig), nil
+}
+
+// Check a personal_sign style signature against an address
+func Verify(message, signature, expectedAddress string) bool {
+	sigBytes, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
+	if err != nil || len(sigBytes) != 65 {
+		return false
+	}
+
+	// Ethereum signatures have v = 27 or 28, but we need 0 or 1
+	if sigBytes[64] >= 27 {
+		sigBytes[64] -= 27
+	}
+
+	pubKey, err := crypto.SigToPub(hashMessage(message), sigBytes)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(crypto.PubkeyToAddress(*pubKey).Hex(), expectedAddress)
+}
+
+func hashMessage(message string) []byte {
+	prefixedMessage := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message)
+	return crypto.Keccak256Hash([]byte(prefixedMessage)).Bytes()
+}
+
+// The exact text the server signs for a challenge. Covers everything that
+// decides how hard the challenge is and when it was issued.
+func ChallengeMessage(ch *types.Challenge) string {
+	params, _ := json.Marshal(ch.Params)
+	return fmt.Sprintf("DePIN Challenge\nID: %s\nNode: %s\nType: %s\nParams: %s\nCreated: %d\nExpires: %d",
+		ch.ID, ch.NodeID, ch.ChallengeType, params, ch.CreatedAt, ch.ExpiresAt)
+}
+
+// The exact text the server signs for a signed API response. The payload is
+// the JSON body byte-for-byte, so verifiers must check it before parsing.
+func ResponseMessage(payload string, timestamp int64) string {
+	return fmt.Sprintf("DePIN Signed Response\nTimestamp: %d\nPayload: %s", timestamp, payload)
+}
+
+// The exact text the server signs for anything it sends without being
+// asked: a streamed challenge or a webhook. Names the event and the node
+// it's for, so a captured push can't be replayed to another node or as
+// another kind of event. The payload is the JSON body byte-for-byte.
+func PushMessage(event, nodeID, payload string, timestamp int64) string {
+	return fmt.Sprintf("DePIN Push\nEvent: %s\nNode: %s\nTimestamp: %d\nPayload: %s", event, nodeID, timestamp, payload)
+}
+
+// The exact text the server signs for a result receipt. Covers every
+// field but the signature itself.
+func ReceiptMessage(r Receipt) string {
+	return fmt.Sprintf("DePIN Result Receipt\nChallenge: %s\nNode: %s\nType: %s\nPassed: %t\nFailure: %s\nPoints: %d\nTotal: %d\nTimestamp: %d",
+		r.ChallengeID, r.NodeID, r.ChallengeType, r.Passed, r.FailureKind, r.PointsDelta, r.TotalPoints, r.Timestamp)
+}
+
+// The exact text a prover signs for a heartbeat: the newest block its node
+// has, so the server can check it against the chain
+func HeartbeatMessage(nodeID string, blockNumber uint64, blockHash string, timestamp int64) string {
+	return fmt.Sprintf("Heartbeat\nNode: %s\nBlock: %d\nHash: %s\nTimestamp: %d", nodeID, blockNumber, blockHash, timestamp)
+}
+
+// Versions of the message a prover signs when it submits an answer
+const (
+	AnswerV1 = 1 // Challenge ID, answer and timestamp. Being phased out.
+	AnswerV2 = 2 // Adds the node and challenge type, and hashes the answer
+	AnswerV3 = 3 // Adds the prover build that answered
+)
+
+// The exact text a prover signs to submit an answer, ok is false for an
+// unknown version. v2 names the node and challenge type, so a signature
+// can't be moved to another node's challenge, and signs keccak256 of the
+// answer so big answers still make a short message. v3 also signs the
+// prover's build hash (ProverBuild), which earlier versions ignore.
+func AnswerMessage(version int, challengeID, nodeID string, challengeType types.ChallengeType, answer, build string, timestamp int64) (string, bool) {
+	switch version {
+	case AnswerV1:
+		return fmt.Sprintf("Challenge Response\nID: %s\nAnswer: %s\nTimestamp: %d", challengeID, answer, timestamp), true
+	case AnswerV2:
+		return fmt.Sprintf("DePIN Challenge Response\nVersion: 2\nID: %s\nNode: %s\nType: %s\nAnswer: %s\nTimestamp: %d",
+			challengeID, nodeID, challengeType, crypto.Keccak256Hash([]byte(answer)).Hex(), timestamp), true
+	case AnswerV3:
+		return fmt.Sprintf("DePIN Challenge Response\nVersion: 3\nID: %s\nNode: %s\nType: %s\nAnswer: %s\nBuild: %s\nTimestamp: %d",
+			challengeID, nodeID, challengeType, crypto.Keccak256Hash([]byte(answer)).Hex(), build, timestamp), true
+	default:
+		return "", false
+	}
+}
+
+// Commitment to an answer for a commit-reveal challenge: keccak256 of the
+// answer followed by the nonce, hex encoded. The nonce keeps anyone from
+// guessing the answer out of the commitment before it's revealed.
+func Commitment(answer, nonce string) string {
+	return crypto.Keccak256Hash([]byte(answer), []byte(nonce)).Hex()
+}
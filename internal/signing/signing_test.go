@@ -0,0 +1,107 @@
+package signing
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/depinonbnb/depin/internal/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func newTestSigner(t *testing.T) *Signer {
+	t.Helper()
+
+	key, _ := crypto.GenerateKey()
+	signer, err := NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	return signer
+}
+
+func TestNewSignerInvalidKey(t *testing.T) {
+	if _, err := NewSigner("not-a-key"); err == nil {
+		t.Error("expected error for invalid key")
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	signer := newTestSigner(t)
+
+	sig, err := signer.Sign("hello")
+	if err != nil {
+		t.Fatalf("sign failed: %v", err)
+	}
+
+	if !Verify("hello", sig, signer.Address()) {
+		t.Error("signature should verify")
+	}
+	if Verify("hello!", sig, signer.Address()) {
+		t.Error("signature should not verify for a different message")
+	}
+	if Verify("hello", sig, "0x0000000000000000000000000000000000000000") {
+		t.Error("signature should not verify for a different address")
+	}
+	if Verify("hello", "0x1234", signer.Address()) {
+		t.Error("short signature should not verify")
+	}
+}
+
+func TestChallengeMessageCoversParams(t *testing.T) {
+	block := uint64(100)
+	ch := &types.Challenge{
+		ID:            "c1",
+		NodeID:        "n1",
+		ChallengeType: types.BlockHash,
+		CreatedAt:     1000,
+		ExpiresAt:     61000,
+		Params:        types.ChallengeParams{BlockNumber: &block},
+	}
+	original := ChallengeMessage(ch)
+
+	other := uint64(101)
+	ch.Params.BlockNumber = &other
+	if ChallengeMessage(ch) == original {
+		t.Error("changing params should change the signed message")
+	}
+
+	ch.Params.BlockNumber = &block
+	ch.CreatedAt = 2000
+	if ChallengeMessage(ch) == original {
+		t.Error("changing the timestamp should change the signed message")
+	}
+}
+
+func TestAnswerMessage(t *testing.T) {
+	v1, ok := AnswerMessage(AnswerV1, "c1", "n1", types.BlockHash, "0xabc", "", 1000)
+	if !ok || v1 != "Challenge Response\nID: c1\nAnswer: 0xabc\nTimestamp: 1000" {
+		t.Errorf("v1 message changed: %q", v1)
+	}
+
+	v2, ok := AnswerMessage(AnswerV2, "c1", "n1", types.BlockHash, "0xabc", "", 1000)
+	if !ok || !strings.Contains(v2, "Version: 2\n") || !strings.Contains(v2, "Node: n1\n") || !strings.Contains(v2, "Type: block-hash\n") {
+		t.Errorf("v2 message should name the version, node and type: %q", v2)
+	}
+	if strings.Contains(v2, "0xabc\n") {
+		t.Errorf("v2 should sign the answer's hash, not the answer: %q", v2)
+	}
+	if other, _ := AnswerMessage(AnswerV2, "c1", "n2", types.BlockHash, "0xabc", "", 1000); other == v2 {
+		t.Error("v2 message should differ per node")
+	}
+	if other, _ := AnswerMessage(AnswerV2, "c1", "n1", types.StateBalance, "0xabc", "", 1000); other == v2 {
+		t.Error("v2 message should differ per challenge type")
+	}
+
+	v3, ok := AnswerMessage(AnswerV3, "c1", "n1", types.BlockHash, "0xabc", "0xbuild", 1000)
+	if !ok || !strings.Contains(v3, "Version: 3\n") || !strings.Contains(v3, "Build: 0xbuild\n") {
+		t.Errorf("v3 message should name the version and build: %q", v3)
+	}
+	if v2, _ := AnswerMessage(AnswerV2, "c1", "n1", types.BlockHash, "0xabc", "0xbuild", 1000); strings.Contains(v2, "0xbuild") {
+		t.Errorf("v2 should ignore the build: %q", v2)
+	}
+
+	if _, ok := AnswerMessage(4, "c1", "n1", types.BlockHash, "0xabc", "", 1000); ok {
+		t.Error("unknown version should not produce a message")
+	}
+}
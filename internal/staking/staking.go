@@ -0,0 +1,148 @@
+// Package staking reads what wallets have staked on chain, so operators
+// who put stake behind their nodes earn more uptime points. The stake is
+// whatever balanceOf(wallet) returns on the configured contract: a staking
+// contract that exposes one, or the liquid staking token it hands out
+// (slisBNB, stkBNB, ...). Amounts are taken to have 18 decimals, as BNB
+// and nearly every BEP-20 token do.
+package staking
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// The part of rpc.Client reads need
+type Caller interface {
+	Call(to, data string) (string, uint64, error)
+}
+
+const selectorBalanceOf = "70a08231" // balanceOf(address)
+
+const (
+	// How often the server reads stakes
+	ReadInterval = time.Hour
+
+	// A stake that hasn't been read successfully for this long stops
+	// earning a bonus until it is again
+	StaleAfter = 3 * ReadInterval
+)
+
+// Wei per whole token
+var unit = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+// A bonus for wallets with at least Min whole tokens staked
+type Tier struct {
+	Min     uint64
+	Percent uint64
+}
+
+// Bonus tiers, smallest stake first
+type Tiers []Tier
+
+// Parse "1=5,10=10,100=20": whole tokens staked = percent more uptime
+// points. Empty means no bonus at any stake.
+func ParseTiers(spec string) (Tiers, error) {
+	var tiers Tiers
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(entry, "=")
+		min, minErr := strconv.ParseUint(strings.TrimSpace(key), 10, 64)
+		percent, percentErr := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if !ok || minErr != nil || percentErr != nil || min == 0 {
+			return nil, fmt.Errorf("want tokens=percent with tokens at least 1, got %q", entry)
+		}
+		if percent > 100 {
+			return nil, fmt.Errorf("bonus must be at most 100 percent, got %q", entry)
+		}
+		tiers = append(tiers, Tier{Min: min, Percent: percent})
+	}
+	sort.Slice(tiers, func(i, j int) bool {
+		return tiers[i].Min < tiers[j].Min
+	})
+	for i := 1; i < len(tiers); i++ {
+		if tiers[i].Min == tiers[i-1].Min {
+			return nil, fmt.Errorf("%d tokens listed twice", tiers[i].Min)
+		}
+	}
+	return tiers, nil
+}
+
+// The bonus for the highest tier tokens reaches, 0 below the lowest
+func (t Tiers) Bonus(tokens uint64) uint64 {
+	var percent uint64
+	for _, tier := range t {
+		if tokens >= tier.Min {
+			percent = tier.Percent
+		}
+	}
+	return percent
+}
+
+type Reader struct {
+	caller   Caller
+	contract string
+}
+
+// Read stakes from contract through caller
+func NewReader(caller Caller, contract string) *Reader {
+	return &Reader{caller: caller, contract: strings.ToLower(contract)}
+}
+
+// What each of wallets has staked. Wallets that aren't addresses are
+// skipped. A wallet whose read failed is left out, so the caller can keep
+// what it had; failed says how many.
+func (r *Reader) Read(wallets []string, now int64) (stakes map[string]types.WalletStake, failed int) {
+	stakes = make(map[string]types.WalletStake)
+	for _, wallet := range wallets {
+		wallet = strings.ToLower(wallet)
+		if _, done := stakes[wallet]; done || len(wallet) != 42 || !strings.HasPrefix(wallet, "0x") {
+			continue
+		}
+		wei, err := r.staked(wallet)
+		if err != nil {
+			failed++
+			continue
+		}
+		stakes[wallet] = types.WalletStake{Wei: wei.String(), Tokens: tokens(wei), CheckedAt: now}
+	}
+	return stakes, failed
+}
+
+func (r *Reader) staked(wallet string) (*big.Int, error) {
+	result, _, err := r.caller.Call(r.contract, "0x"+selectorBalanceOf+strings.Repeat("0", 24)+wallet[2:])
+	if err != nil {
+		return nil, err
+	}
+	data, err := hex.DecodeString(strings.TrimPrefix(result, "0x"))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 32 {
+		return nil, errShortResult
+	}
+	return new(big.Int).SetBytes(data[:32]), nil
+}
+
+var errShortResult = errors.New("balanceOf returned too little data")
+
+// Whole tokens in wei, rounded down
+func tokens(wei *big.Int) uint64 {
+	whole := new(big.Int).Quo(wei, unit)
+	if !whole.IsUint64() {
+		return math.MaxUint64
+	}
+	return whole.Uint64()
+}
@@ -0,0 +1,80 @@
+package staking
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+const contract = "0x00000000000000000000000000000000000000cc"
+
+// A contract holding balances, or an RPC that's down
+type fakeChain struct {
+	balances map[string]*big.Int // Lowercase address -> wei
+	down     bool
+}
+
+func (f *fakeChain) Call(to, data string) (string, uint64, error) {
+	if f.down {
+		return "", 0, errors.New("rpc down")
+	}
+	if to != contract || data[2:10] != selectorBalanceOf {
+		return "", 0, fmt.Errorf("unexpected call to %s: %s", to, data)
+	}
+	balance, ok := f.balances["0x"+data[len(data)-40:]]
+	if !ok {
+		balance = new(big.Int)
+	}
+	return fmt.Sprintf("0x%064x", balance), 0, nil
+}
+
+func TestParseTiers(t *testing.T) {
+	tiers, err := ParseTiers(" 100=20, 1=5,10=10 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tiers) != 3 || tiers[0] != (Tier{Min: 1, Percent: 5}) || tiers[2] != (Tier{Min: 100, Percent: 20}) {
+		t.Errorf("expected three tiers smallest first, got %+v", tiers)
+	}
+	for tokens, want := range map[uint64]uint64{0: 0, 1: 5, 9: 5, 10: 10, 99: 10, 100: 20, 1 << 40: 20} {
+		if got := tiers.Bonus(tokens); got != want {
+			t.Errorf("%d tokens: expected %d%%, got %d%%", tokens, want, got)
+		}
+	}
+
+	if tiers, err := ParseTiers(""); err != nil || len(tiers) != 0 || tiers.Bonus(1000) != 0 {
+		t.Errorf("expected no tiers and no bonus, got %+v %v", tiers, err)
+	}
+	for _, spec := range []string{"10", "0=5", "x=5", "10=y", "10=101", "10=5,10=6"} {
+		if _, err := ParseTiers(spec); err == nil {
+			t.Errorf("expected %q refused", spec)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	alice := "0x1111111111111111111111111111111111111111"
+	bob := "0x2222222222222222222222222222222222222222"
+	wei, _ := new(big.Int).SetString("12500000000000000000", 10) // 12.5 tokens
+	chain := &fakeChain{balances: map[string]*big.Int{alice: wei}}
+	reader := NewReader(chain, "0x"+strings.ToUpper(contract[2:]))
+
+	// Mixed case and repeats are read once, under the lowercase address
+	stakes, failed := reader.Read([]string{"0x" + strings.ToUpper(alice[2:]), alice, bob, "not-an-address"}, 1000)
+	if failed != 0 || len(stakes) != 2 {
+		t.Fatalf("expected alice and bob read, got %+v (%d failed)", stakes, failed)
+	}
+	if got := stakes[alice]; got.Wei != wei.String() || got.Tokens != 12 || got.CheckedAt != 1000 {
+		t.Errorf("expected 12 whole tokens for alice, got %+v", got)
+	}
+	if got := stakes[bob]; got.Wei != "0" || got.Tokens != 0 {
+		t.Errorf("expected nothing staked for bob, got %+v", got)
+	}
+
+	chain.down = true
+	if stakes, failed := reader.Read([]string{alice, bob}, 2000); failed != 2 || len(stakes) != 0 {
+		t.Errorf("expected both reads to fail, got %+v (%d failed)", stakes, failed)
+	}
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+)
+
+// A backup starts with this line, so restoring anything else (a replica
+// snapshot, an HTML error page saved by mistake) is refused before the
+// store is touched. The number goes up if the format changes.
+const backupHeader = "depin-backup/1\n"
+
+var ErrNotBackup = errors.New("not a store backup")
+
+// Write a backup of the whole store to w, for an operator to keep or to
+// load into another server with Import: the same state a durable backend
+// checkpoints, gzipped.
+func (s *MemoryStore) Export(w io.Writer) error {
+	if _, err := io.WriteString(w, backupHeader); err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(w)
+	if err := s.WriteCheckpoint(gz); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Replace the store's state with a backup read from r, returning when it
+// was taken. Anything registered or earned since is lost. On error the
+// store is left as it was.
+func (s *MemoryStore) Import(r io.Reader) (int64, error) {
+	br := bufio.NewReader(r)
+	header, err := br.ReadString('\n')
+	if err != nil || header != backupHeader {
+		return 0, ErrNotBackup
+	}
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		return 0, ErrNotBackup
+	}
+	defer gz.Close()
+	return s.ReadCheckpoint(gz)
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"encoding/gob"
+	"io"
+
+	"github.com/depinonbnb/depin/internal/modlog"
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// Everything a restart would otherwise lose: what replicas get, plus the
+// state only the writer keeps. Settings aren't in it; they come from the
+// config at startup as usual. A recompute in progress isn't either.
+type checkpoint struct {
+	Snapshot           snapshot
+	Replays            map[string]*types.ChallengeReplay
+	ReplayOrder        []string
+	Attempts           map[string][]types.SubmissionAttempt
+	AttemptOrder       []string
+	Fingerprints       map[string]checkpointCluster
+	NodeAddrs          map[string]map[string]int64
+	PendingBans        map[string]*types.PendingBan
+	Budgets            map[string]*types.ChallengeBudget
+	WalletTOTP         map[string]*types.WalletTOTP
+	NextAnnouncementID uint64
+	MaintenanceEndedAt int64
+	Moderation         []modlog.Entry
+}
+
+type checkpointCluster struct {
+	Info    types.FingerprintCluster
+	Flagged map[string]bool
+}
+
+// Write the store's whole state to w, for a backup. Holds the read lock
+// throughout, so what's written is consistent.
+func (s *MemoryStore) WriteCheckpoint(w io.Writer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return gob.NewEncoder(w).Encode(s.checkpoint())
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) checkpoint() *checkpoint {
+	clusters := make(map[string]checkpointCluster, len(s.fingerprints))
+	for fingerprint, cluster := range s.fingerprints {
+		clusters[fingerprint] = checkpointCluster{Info: cluster.info, Flagged: cluster.flagged}
+	}
+	return &checkpoint{
+		Snapshot:           *s.snapshot(),
+		Replays:            s.replays,
+		ReplayOrder:        s.replayOrder,
+		Attempts:           s.attempts,
+		AttemptOrder:       s.attemptOrder,
+		Fingerprints:       clusters,
+		NodeAddrs:          s.nodeAddrs,
+		PendingBans:        s.pendingBans,
+		Budgets:            s.budgets,
+		WalletTOTP:         s.walletTOTP,
+		NextAnnouncementID: s.nextAnnouncementID,
+		MaintenanceEndedAt: s.maintenanceEndedAt,
+		Moderation:         s.moderation.Since(0),
+	}
+}
+
+// Replace the store's state with a checkpoint read from r, returning when
+// it was taken. Meant for startup, before anything else uses the store, or
+// a restore from a backup. On error the store is left as it was.
+func (s *MemoryStore) ReadCheckpoint(r io.Reader) (int64, error) {
+	var cp checkpoint
+	if err := gob.NewDecoder(r).Decode(&cp); err != nil {
+		return 0, err
+	}
+	if err := s.moderation.Restore(cp.Moderation); err != nil {
+		return 0, err
+	}
+
+	clusters := make(map[string]*fingerprintCluster, len(cp.Fingerprints))
+	for fingerprint, cluster := range cp.Fingerprints {
+		clusters[fingerprint] = &fingerprintCluster{info: cluster.Info, flagged: orEmpty(cluster.Flagged)}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.restore(&cp.Snapshot)
+	s.replays = orEmpty(cp.Replays)
+	s.replayOrder = cp.ReplayOrder
+	s.attempts = orEmpty(cp.Attempts)
+	s.attemptOrder = cp.AttemptOrder
+	s.fingerprints = clusters
+	s.nodeAddrs = orEmpty(cp.NodeAddrs)
+	s.pendingBans = orEmpty(cp.PendingBans)
+	s.budgets = orEmpty(cp.Budgets)
+	s.walletTOTP = orEmpty(cp.WalletTOTP)
+	s.nextAnnouncementID = cp.NextAnnouncementID
+	s.maintenanceEndedAt = cp.MaintenanceEndedAt
+	return cp.Snapshot.TakenAt, nil
+}
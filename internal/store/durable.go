@@ -0,0 +1,595 @@
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// Backends the server can keep its store in
+const (
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+	BackendSQLite   = "sqlite"
+)
+
+func ValidBackend(backend string) bool {
+	return backend == BackendMemory || backend == BackendPostgres || backend == BackendSQLite
+}
+
+// A MemoryStore kept in a database. Everything is still served from
+// memory, loaded from the database when it's opened. Nodes, points
+// ledgers, verification history and heartbeats have a table each, and
+// Checkpoint, which the server calls on an interval and at shutdown, only
+// writes the rows that changed since the last one. The rest of the state
+// (bans, reports, epochs, the moderation log, ...) is one row in
+// depin_checkpoint, rewritten by every checkpoint. A crash loses what
+// changed since the last checkpoint.
+type DurableStore struct {
+	*MemoryStore
+	db      *sql.DB
+	dialect dialect
+
+	checkpointMu sync.Mutex // One checkpoint at a time
+	written      written
+}
+
+var _ Store = (*DurableStore)(nil)
+
+// How one database spells the tables and queries
+type dialect struct {
+	driver   string
+	schema   []string // Run in order on open; each must be safe to run again
+	numbered bool     // Placeholders are $1, $2, ... rather than ?
+	maxConns int      // 0 = as many as database/sql likes
+}
+
+// Queries are written with ?; numbered dialects get $n instead
+func (d dialect) rebind(query string) string {
+	if !d.numbered {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+const (
+	upsertCheckpoint = `INSERT INTO depin_checkpoint (id, taken_at, state) VALUES (1, ?, ?)
+	ON CONFLICT (id) DO UPDATE SET taken_at = excluded.taken_at, state = excluded.state`
+	upsertNode = `INSERT INTO depin_nodes (id, wallet_address, node_type, network, verification_method,
+		cheat_status, is_active, total_points, registered_at, last_verified_at, last_heartbeat_at, record)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (id) DO UPDATE SET wallet_address = excluded.wallet_address, node_type = excluded.node_type,
+		network = excluded.network, verification_method = excluded.verification_method,
+		cheat_status = excluded.cheat_status, is_active = excluded.is_active,
+		total_points = excluded.total_points, registered_at = excluded.registered_at,
+		last_verified_at = excluded.last_verified_at, last_heartbeat_at = excluded.last_heartbeat_at,
+		record = excluded.record`
+	insertPoints = `INSERT INTO depin_points (node_id, id, reason, amount, timestamp, reference, note,
+		stake_bonus_percent, stake_tokens)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	insertVerification = `INSERT INTO depin_verifications (node_id, seq, challenge_id, challenge_type, passed,
+		response_time_ms, failure_kind, failure_reason, suspicious, suspicious_note, surprise, block_age,
+		parts, timestamp)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	insertHeartbeat = `INSERT INTO depin_heartbeats (node_id, seq, timestamp, block_number, block_hash,
+		is_synced, latency_ms, peers_count, client_version)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+)
+
+// The tables with a row per entity, emptied when a restore replaces them
+var rowTables = []string{"depin_nodes", "depin_points", "depin_verifications", "depin_heartbeats"}
+
+// What's in the database, so a checkpoint only writes what changed
+type written struct {
+	generation uint64                       // The store's, when these were written
+	nodes      map[string][sha256.Size]byte // Node ID -> hash of the record written
+	ledger     map[string]uint64            // Node ID -> ID of the last entry written
+	history    map[string]cursor[types.VerificationResult]
+	heartbeats map[string]cursor[types.HeartbeatRecord]
+}
+
+// Where a node's capped history stands in the database. Rows are numbered
+// per node, and last is the entry in memory written as the newest row.
+type cursor[T any] struct {
+	seq  int64
+	last *T
+}
+
+func newWritten(generation uint64) written {
+	return written{
+		generation: generation,
+		nodes:      make(map[string][sha256.Size]byte),
+		ledger:     make(map[string]uint64),
+		history:    make(map[string]cursor[types.VerificationResult]),
+		heartbeats: make(map[string]cursor[types.HeartbeatRecord]),
+	}
+}
+
+func (w written) clone() written {
+	c := newWritten(w.generation)
+	for k, v := range w.nodes {
+		c.nodes[k] = v
+	}
+	for k, v := range w.ledger {
+		c.ledger[k] = v
+	}
+	for k, v := range w.history {
+		c.history[k] = v
+	}
+	for k, v := range w.heartbeats {
+		c.heartbeats[k] = v
+	}
+	return c
+}
+
+// Open the database, create the tables that are missing, and load what's
+// in them into s. Returns when the last checkpoint was taken, 0 if there
+// wasn't one yet.
+func openDurable(dsn string, dialect dialect, s *MemoryStore) (*DurableStore, int64, error) {
+	db, err := sql.Open(dialect.driver, dsn)
+	if err != nil {
+		return nil, 0, err
+	}
+	db.SetMaxOpenConns(dialect.maxConns)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, 0, err
+	}
+	for _, statement := range dialect.schema {
+		if _, err := db.Exec(statement); err != nil {
+			db.Close()
+			return nil, 0, fmt.Errorf("creating tables: %v", err)
+		}
+	}
+
+	d := &DurableStore{MemoryStore: s, db: db, dialect: dialect}
+	takenAt, err := d.load()
+	if err != nil {
+		db.Close()
+		return nil, 0, err
+	}
+	return d, takenAt, nil
+}
+
+// Load the checkpoint row, then the rows of every table over it. A
+// checkpoint from before the tables existed holds everything, and its
+// entities are written to the tables by the next checkpoint.
+func (d *DurableStore) load() (int64, error) {
+	var state []byte
+	var takenAt int64
+	err := d.db.QueryRow(`SELECT state FROM depin_checkpoint WHERE id = 1`).Scan(&state)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return 0, err
+	default:
+		if takenAt, err = d.ReadCheckpoint(bytes.NewReader(state)); err != nil {
+			return 0, fmt.Errorf("reading checkpoint: %v", err)
+		}
+	}
+
+	rows := &durableRows{
+		ledger:     make(map[string][]types.PointsEntry),
+		history:    make(map[string][]*types.VerificationResult),
+		heartbeats: make(map[string][]*types.HeartbeatRecord),
+	}
+	written := newWritten(0)
+	if err := d.loadNodes(rows, written); err != nil {
+		return 0, fmt.Errorf("loading nodes: %v", err)
+	}
+	if err := d.loadPoints(rows, written); err != nil {
+		return 0, fmt.Errorf("loading points: %v", err)
+	}
+	if err := d.loadVerifications(rows, written); err != nil {
+		return 0, fmt.Errorf("loading verifications: %v", err)
+	}
+	if err := d.loadHeartbeats(rows, written); err != nil {
+		return 0, fmt.Errorf("loading heartbeats: %v", err)
+	}
+	written.generation = d.installRows(rows)
+	d.written = written
+	return takenAt, nil
+}
+
+func (d *DurableStore) loadNodes(rows *durableRows, written written) error {
+	result, err := d.db.Query(`SELECT record FROM depin_nodes`)
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+	for result.Next() {
+		var record []byte
+		if err := result.Scan(&record); err != nil {
+			return err
+		}
+		node := &types.NodeRegistration{}
+		if err := gob.NewDecoder(bytes.NewReader(record)).Decode(node); err != nil {
+			return err
+		}
+		rows.nodes = append(rows.nodes, node)
+		written.nodes[node.ID] = sha256.Sum256(record)
+	}
+	return result.Err()
+}
+
+func (d *DurableStore) loadPoints(rows *durableRows, written written) error {
+	result, err := d.db.Query(`SELECT node_id, id, reason, amount, timestamp, reference, note,
+		stake_bonus_percent, stake_tokens
+	FROM depin_points ORDER BY node_id, id`)
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+	for result.Next() {
+		var nodeID string
+		var id, stakeBonus, stakeTokens int64
+		var entry types.PointsEntry
+		if err := result.Scan(&nodeID, &id, &entry.Reason, &entry.Amount, &entry.Timestamp, &entry.Reference, &entry.Note,
+			&stakeBonus, &stakeTokens); err != nil {
+			return err
+		}
+		entry.ID = uint64(id)
+		entry.StakeBonusPercent = uint64(stakeBonus)
+		entry.StakeTokens = uint64(stakeTokens)
+		rows.ledger[nodeID] = append(rows.ledger[nodeID], entry)
+		written.ledger[nodeID] = entry.ID
+	}
+	return result.Err()
+}
+
+func (d *DurableStore) loadVerifications(rows *durableRows, written written) error {
+	result, err := d.db.Query(`SELECT node_id, seq, challenge_id, challenge_type, passed, response_time_ms,
+		failure_kind, failure_reason, suspicious, suspicious_note, surprise, block_age, parts, timestamp
+	FROM depin_verifications ORDER BY node_id, seq`)
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+	for result.Next() {
+		var seq, responseTime int64
+		var blockAge sql.NullInt64
+		var parts string
+		v := &types.VerificationResult{}
+		if err := result.Scan(&v.NodeID, &seq, &v.ChallengeID, &v.ChallengeType, &v.Passed, &responseTime,
+			&v.FailureKind, &v.FailureReason, &v.Suspicious, &v.SuspiciousNote, &v.Surprise, &blockAge, &parts, &v.Timestamp); err != nil {
+			return err
+		}
+		v.ResponseTimeMs = uint64(responseTime)
+		if blockAge.Valid {
+			age := uint64(blockAge.Int64)
+			v.BlockAge = &age
+		}
+		if parts != "" {
+			if err := json.Unmarshal([]byte(parts), &v.Parts); err != nil {
+				return err
+			}
+		}
+		rows.history[v.NodeID] = append(rows.history[v.NodeID], v)
+		written.history[v.NodeID] = cursor[types.VerificationResult]{seq: seq, last: v}
+	}
+	return result.Err()
+}
+
+func (d *DurableStore) loadHeartbeats(rows *durableRows, written written) error {
+	result, err := d.db.Query(`SELECT node_id, seq, timestamp, block_number, block_hash, is_synced,
+		latency_ms, peers_count, client_version
+	FROM depin_heartbeats ORDER BY node_id, seq`)
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+	for result.Next() {
+		var seq, blockNumber, latency, peers int64
+		hb := &types.HeartbeatRecord{}
+		if err := result.Scan(&hb.NodeID, &seq, &hb.Timestamp, &blockNumber, &hb.BlockHash, &hb.IsSynced,
+			&latency, &peers, &hb.ClientVersion); err != nil {
+			return err
+		}
+		hb.BlockNumber, hb.LatencyMs, hb.PeersCount = uint64(blockNumber), uint64(latency), uint64(peers)
+		rows.heartbeats[hb.NodeID] = append(rows.heartbeats[hb.NodeID], hb)
+		written.heartbeats[hb.NodeID] = cursor[types.HeartbeatRecord]{seq: seq, last: hb}
+	}
+	return result.Err()
+}
+
+// Write what changed since the last checkpoint, in one transaction.
+// Returns how many rows were written.
+func (d *DurableStore) Checkpoint() (int, error) {
+	d.checkpointMu.Lock()
+	defer d.checkpointMu.Unlock()
+
+	state, err := d.durableState()
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	next := d.written.clone()
+	if state.generation != next.generation {
+		// Restored from a backup since the last checkpoint, so nothing in
+		// the tables can be trusted to still be there
+		for _, table := range rowTables {
+			if _, err := tx.Exec(`DELETE FROM ` + table); err != nil {
+				return 0, err
+			}
+		}
+		next = newWritten(state.generation)
+	}
+
+	rows := 0
+	for _, write := range []func(*sql.Tx, *durableState, written) (int, error){
+		d.writeNodes, d.writePoints, d.writeVerifications, d.writeHeartbeats,
+	} {
+		n, err := write(tx, state, next)
+		if err != nil {
+			return 0, err
+		}
+		rows += n
+	}
+	if _, err := tx.Exec(d.dialect.rebind(upsertCheckpoint), state.takenAt, state.rest); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	d.written = next
+	return rows + 1, nil
+}
+
+func (d *DurableStore) writeNodes(tx *sql.Tx, state *durableState, next written) (int, error) {
+	upsert, err := tx.Prepare(d.dialect.rebind(upsertNode))
+	if err != nil {
+		return 0, err
+	}
+	defer upsert.Close()
+
+	rows := 0
+	for i := range state.nodes {
+		node := &state.nodes[i]
+		var record bytes.Buffer
+		if err := gob.NewEncoder(&record).Encode(node); err != nil {
+			return 0, err
+		}
+		hash := sha256.Sum256(record.Bytes())
+		if next.nodes[node.ID] == hash {
+			continue
+		}
+		if _, err := upsert.Exec(node.ID, node.WalletAddress, string(node.NodeType), string(node.Network),
+			string(node.VerificationMethod), string(node.CheatStatus), node.IsActive, int64(node.TotalPoints),
+			node.RegisteredAt, node.LastVerifiedAt, node.LastHeartbeatAt, record.Bytes()); err != nil {
+			return 0, err
+		}
+		next.nodes[node.ID] = hash
+		rows++
+	}
+	return rows, nil
+}
+
+func (d *DurableStore) writePoints(tx *sql.Tx, state *durableState, next written) (int, error) {
+	insert, err := tx.Prepare(d.dialect.rebind(insertPoints))
+	if err != nil {
+		return 0, err
+	}
+	defer insert.Close()
+
+	rows := 0
+	for nodeID, entries := range state.ledger {
+		last := next.ledger[nodeID]
+		for _, entry := range entries {
+			if entry.ID <= last {
+				continue
+			}
+			if _, err := insert.Exec(nodeID, int64(entry.ID), string(entry.Reason), entry.Amount, entry.Timestamp, entry.Reference, entry.Note,
+				int64(entry.StakeBonusPercent), int64(entry.StakeTokens)); err != nil {
+				return 0, err
+			}
+			next.ledger[nodeID] = entry.ID
+			rows++
+		}
+	}
+	return rows, nil
+}
+
+func (d *DurableStore) writeVerifications(tx *sql.Tx, state *durableState, next written) (int, error) {
+	insert, err := tx.Prepare(d.dialect.rebind(insertVerification))
+	if err != nil {
+		return 0, err
+	}
+	defer insert.Close()
+
+	rows := 0
+	for nodeID, history := range state.history {
+		at := next.history[nodeID]
+		fresh := since(history, at.last)
+		for _, v := range fresh {
+			var blockAge sql.NullInt64
+			if v.BlockAge != nil {
+				blockAge = sql.NullInt64{Int64: int64(*v.BlockAge), Valid: true}
+			}
+			var parts string
+			if len(v.Parts) > 0 {
+				encoded, err := json.Marshal(v.Parts)
+				if err != nil {
+					return 0, err
+				}
+				parts = string(encoded)
+			}
+			at.seq++
+			if _, err := insert.Exec(nodeID, at.seq, v.ChallengeID, string(v.ChallengeType), v.Passed, int64(v.ResponseTimeMs),
+				string(v.FailureKind), v.FailureReason, v.Suspicious, v.SuspiciousNote, v.Surprise, blockAge, parts, v.Timestamp); err != nil {
+				return 0, err
+			}
+			at.last = v
+			rows++
+		}
+		if len(fresh) > 0 {
+			if err := d.trim(tx, "depin_verifications", nodeID, at.seq-maxVerificationHistory); err != nil {
+				return 0, err
+			}
+			next.history[nodeID] = at
+		}
+	}
+	return rows, nil
+}
+
+func (d *DurableStore) writeHeartbeats(tx *sql.Tx, state *durableState, next written) (int, error) {
+	insert, err := tx.Prepare(d.dialect.rebind(insertHeartbeat))
+	if err != nil {
+		return 0, err
+	}
+	defer insert.Close()
+
+	rows := 0
+	for nodeID, history := range state.heartbeats {
+		at := next.heartbeats[nodeID]
+		fresh := since(history, at.last)
+		for _, hb := range fresh {
+			at.seq++
+			if _, err := insert.Exec(nodeID, at.seq, hb.Timestamp, int64(hb.BlockNumber), hb.BlockHash, hb.IsSynced,
+				int64(hb.LatencyMs), int64(hb.PeersCount), hb.ClientVersion); err != nil {
+				return 0, err
+			}
+			at.last = hb
+			rows++
+		}
+		if len(fresh) > 0 {
+			if err := d.trim(tx, "depin_heartbeats", nodeID, at.seq-maxHeartbeats); err != nil {
+				return 0, err
+			}
+			next.heartbeats[nodeID] = at
+		}
+	}
+	return rows, nil
+}
+
+// Drop a node's rows the store no longer keeps in memory
+func (d *DurableStore) trim(tx *sql.Tx, table, nodeID string, upTo int64) error {
+	if upTo <= 0 {
+		return nil
+	}
+	_, err := tx.Exec(d.dialect.rebind(`DELETE FROM `+table+` WHERE node_id = ? AND seq <= ?`), nodeID, upTo)
+	return err
+}
+
+// The entries of a capped history after last, the newest already written.
+// If last has been trimmed away, or nothing was written yet, all of them.
+func since[T any](history []*T, last *T) []*T {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i] == last {
+			return history[i+1:]
+		}
+	}
+	return history
+}
+
+func (d *DurableStore) Close() error {
+	return d.db.Close()
+}
+
+// What a checkpoint writes. The entity tables are read from copies, so
+// they can be encoded and written after the store's lock is released.
+type durableState struct {
+	generation uint64
+	takenAt    int64
+	nodes      []types.NodeRegistration
+	ledger     map[string][]types.PointsEntry
+	history    map[string][]*types.VerificationResult
+	heartbeats map[string][]*types.HeartbeatRecord
+	rest       []byte // Everything else, gob encoded
+}
+
+// What's loaded from the entity tables
+type durableRows struct {
+	nodes      []*types.NodeRegistration
+	ledger     map[string][]types.PointsEntry
+	history    map[string][]*types.VerificationResult
+	heartbeats map[string][]*types.HeartbeatRecord
+}
+
+// Copy out what a checkpoint needs, holding the read lock only as long as
+// that takes. Nodes are copied by value (the reports they point to are
+// replaced, never changed). Ledgers and histories are only appended to or
+// trimmed from the front, and their entries never change, so copying the
+// slices is enough. The rest of the state is small and encoded here.
+func (s *MemoryStore) durableState() (*durableState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state := &durableState{
+		generation: s.generation,
+		takenAt:    s.clock.Now().UnixMilli(),
+		nodes:      make([]types.NodeRegistration, 0, len(s.nodes)),
+		ledger:     make(map[string][]types.PointsEntry, len(s.ledger)),
+		history:    make(map[string][]*types.VerificationResult, len(s.verificationHistory)),
+		heartbeats: make(map[string][]*types.HeartbeatRecord, len(s.heartbeats)),
+	}
+	for _, node := range s.nodes {
+		state.nodes = append(state.nodes, *node)
+	}
+	for id, entries := range s.ledger {
+		state.ledger[id] = entries
+	}
+	for id, history := range s.verificationHistory {
+		state.history[id] = history
+	}
+	for id, history := range s.heartbeats {
+		state.heartbeats[id] = history
+	}
+
+	cp := s.checkpoint()
+	cp.Snapshot.Nodes = nil
+	cp.Snapshot.Ledger = nil
+	cp.Snapshot.VerificationHistory = nil
+	cp.Snapshot.Heartbeats = nil
+	var rest bytes.Buffer
+	if err := gob.NewEncoder(&rest).Encode(cp); err != nil {
+		return nil, err
+	}
+	state.rest = rest.Bytes()
+	return state, nil
+}
+
+// Put rows loaded from the entity tables into the store, over what the
+// checkpoint row had. Returns the store's generation.
+func (s *MemoryStore) installRows(rows *durableRows) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, node := range rows.nodes {
+		s.nodes[node.ID] = node
+	}
+	for id, entries := range rows.ledger {
+		s.ledger[id] = entries
+	}
+	for id, history := range rows.history {
+		s.verificationHistory[id] = history
+	}
+	for id, history := range rows.heartbeats {
+		s.heartbeats[id] = history
+	}
+	return s.generation
+}
@@ -0,0 +1,187 @@
+package store
+
+import (
+	"io"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/budget"
+	"github.com/depinonbnb/depin/internal/clientversion"
+	"github.com/depinonbnb/depin/internal/clock"
+	"github.com/depinonbnb/depin/internal/hardfork"
+	"github.com/depinonbnb/depin/internal/modlog"
+	"github.com/depinonbnb/depin/internal/notify"
+	"github.com/depinonbnb/depin/internal/proverbuild"
+	"github.com/depinonbnb/depin/internal/rates"
+	"github.com/depinonbnb/depin/internal/staking"
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+//go:generate go run ./mockgen -out storemock/storemock.go
+
+// What the API and server need from a store. MemoryStore is the only
+// backend for now; handler tests can use storemock.Store instead.
+// Regenerate the mock with go generate after changing this.
+type Store interface {
+	// Nodes
+	RegisterNode(walletAddress string, nodeType types.NodeType, method types.VerificationMethod, rpcEndpoint, authToken string) *types.NodeRegistration
+	GetNode(nodeID string) *types.NodeRegistration
+	GetNodesByWallet(walletAddress string) []*types.NodeRegistration
+	SearchNodes(query string, limit int) []types.SearchResult
+	GetAllActiveNodes() []*types.NodeRegistration
+	UpdateNode(nodeID string, updates func(*types.NodeRegistration)) *types.NodeRegistration
+	PauseNode(nodeID string, now int64) (*types.NodeRegistration, error)
+	ResumeNode(nodeID string, now int64) (*types.NodeRegistration, error)
+	ChangeVerificationMethod(nodeID string, method types.VerificationMethod, rpcEndpoint, authToken string, now int64) (*types.NodeRegistration, error)
+	ExpireMaintenance(now int64) []string
+	InactivateSilentNodes(now int64) []string
+	EnforceUptime(now int64) []string
+	GetNodeStats(nodeID string) *types.NodeStats
+	GetSiblings(nodeID string) []types.SiblingNode
+	ProjectPoints(nodeID string, now int64) *types.PointsProjection
+	PointsLedger(nodeID string) []types.PointsEntry
+	ReversePoints(nodeID string, entryID uint64, note string, now int64) (types.PointsEntry, error)
+	AdjustPoints(nodeID string, amount int64, note string, now int64) (types.PointsEntry, error)
+	CompensateOutage(outage types.Outage, now int64) []string
+	RecordNodeCountry(nodeID, country string)
+	RecordClientVersion(nodeID, version string)
+	RecordProverBuild(nodeID, build string)
+	RecordStakes(stakes map[string]types.WalletStake)
+
+	// Verification
+	RecordVerificationResult(result *types.VerificationResult)
+	ClaimChallengeRequest(nodeID string, timestamp int64) bool
+	ChargeChallenge(nodeID string, challengeType types.ChallengeType, now int64) bool
+	HasChallengeBudget(nodeID string, now int64) bool
+	ChallengeBudget(nodeID string, now int64) types.ChallengeBudget
+	RecordPoll(nodeID string, now int64)
+	RecordSurpriseIssued(ch *types.Challenge)
+	ExpireSurprises(now int64)
+	GetChallengeReplay(challengeID string) *types.ChallengeReplay
+	RecordSubmission(challengeID string, attempt types.SubmissionAttempt) types.SubmissionAttempt
+	SubmissionAttempts(challengeID string) []types.SubmissionAttempt
+	GetLatencyPercentiles(nodeID string) types.LatencyPercentiles
+	GetNetworkFailureCounts() map[types.FailureKind]uint64
+	GetPassRatesByNodeType() map[types.NodeType]*types.PassRate
+	GetTrustScore(nodeID string) (types.TrustScore, bool)
+	GetHealth(nodeID string) (types.NodeHealth, bool)
+
+	// Heartbeats and uptime
+	RecordHeartbeat(heartbeat *types.HeartbeatRecord)
+	ClaimHeartbeat(nodeID string, timestamp int64) bool
+	RecordMissedHeartbeat(nodeID string, timestamp int64)
+	GetRecentUptimePercent(nodeID string, days int) float64
+	GetUptimeCalendar(nodeID string, year int, month time.Month) []types.UptimeDay
+
+	// Wallets
+	GetWalletStats(walletAddress string) *types.WalletStats
+	GetWalletStatsBatch(walletAddresses []string) map[string]*types.WalletStats
+
+	// Anti-cheat and moderation
+	AddSuspiciousEvent(nodeID string, reason string)
+	SetNodeCheatStatus(nodeID string, status types.CheatStatus, reason string) bool
+	CountByCheatStatus() map[types.CheatStatus]int
+	GetFlaggedNodes() []*types.NodeRegistration
+	RecordSubmissionFingerprint(nodeID, fingerprint string, conn types.ConnectionFingerprint, now int64)
+	GetFingerprintClusters() []types.FingerprintCluster
+	VerificationFeatures(since int64, salt string) []types.VerificationFeatures
+	FileReport(reporterWallet, nodeID, evidence string, now int64) (*types.CheatReport, error)
+	GetOpenReports(nodeID string) []types.CheatReport
+	GetReporterReputation(walletAddress string) types.ReporterReputation
+	RequestNodeBan(nodeID, reason, admin string, now int64) (*types.PendingBan, error)
+	RequestWalletBan(walletAddress, reason string, cooldownDays *uint64, admin string, now int64) (*types.PendingBan, *types.WalletBan, error)
+	GetPendingBans(now int64) []types.PendingBan
+	GetWalletBan(walletAddress string, now int64) *types.WalletBan
+	GetWalletBans() []types.WalletBan
+	LiftWalletBan(walletAddress string, now int64) error
+	ModerationLog() *modlog.Log
+
+	// Node-type reclassification
+	ProposeReclassification(nodeID string, to types.NodeType, reason string, now int64) (*types.Reclassification, bool, error)
+	GetReclassification(nodeID string) *types.Reclassification
+	GetOpenReclassifications() []types.Reclassification
+	ResolveReclassification(nodeID string, apply bool, want types.NodeType, by string, now int64) (*types.Reclassification, error)
+	ApplyDueReclassifications(now int64) []string
+
+	// Geographic diversity
+	RecalculateRegionWeights(now int64) types.RegionWeights
+	RegionWeights() types.RegionWeights
+
+	// Network upgrades
+	HardForkReadiness(now int64) []types.ForkReadiness
+
+	// Settings
+	Network() types.Network
+	SetNetwork(network types.Network)
+	SetNotifier(n notify.Notifier)
+	SetClock(c clock.Clock)
+	SetEscalationThresholds(warning, flag uint8)
+	SetFingerprintWalletThreshold(wallets int)
+	SetWalletBanCooldown(cooldown time.Duration)
+	SetBanApprovalWindow(window time.Duration)
+	SetTrustWeightedPoints(on bool)
+	SetSilentAfter(after time.Duration)
+	SetMinClientVersions(mins clientversion.Minimums)
+	SetOfficialBuilds(builds proverbuild.Allowlist)
+	SetHardForks(forks []hardfork.Fork)
+	SetChallengeCaps(caps budget.Caps)
+	SetLeaderboardRules(rules types.LeaderboardRules)
+	SetDiversityBonus(percent uint64)
+	SetStakingTiers(tiers staking.Tiers)
+	SetUptimePenalty(percent uint64)
+	SetPointsRates(r rates.Rates)
+	PointsPerHour(nodeType types.NodeType) uint64
+	LeaderboardRules() types.LeaderboardRules
+
+	// Diagnostics
+	NetworkActivity(since, until int64) types.NetworkActivity
+	Sizes() map[string]int
+	Usage() types.StoreUsage
+	Compact(now int64) types.Compaction
+
+	// Recomputing derived stats after rule changes
+	StartRecompute(now int64) (types.Recompute, bool)
+	RecomputeStep(batch int, now int64) types.Recompute
+	RecomputeStatus() (types.Recompute, bool)
+
+	// API keys for heavy readers
+	CreateAPIKey(walletAddress, label, plan string, now int64) (string, types.APIKey, error)
+	LookupAPIKey(secret string) (types.APIKey, bool)
+	GetAPIKey(id string) (types.APIKey, bool)
+	GetAPIKeys(walletAddress string) []types.APIKey
+	SetAPIKeyPlan(id, plan string) (types.APIKey, error)
+	RevokeAPIKey(id string, now int64) (types.APIKey, error)
+
+	// Two-factor for actions signed by a wallet
+	EnrollTOTP(walletAddress, secret string, now int64) error
+	ConfirmTOTP(walletAddress, code string, now int64) error
+	CheckTOTP(walletAddress, code string, now int64) error
+	DisableTOTP(walletAddress, code string, now int64) error
+	RequestTOTPReset(walletAddress, reason, admin string, now int64) (*types.PendingBan, error)
+	TOTPStatus(walletAddress string) (enabled bool, since int64)
+
+	// Points paid out as tokens, an epoch at a time
+	CloseEpoch(until, now int64) (*types.RewardEpoch, error)
+	GetEpochs() []types.RewardEpoch
+	GetEpoch(epoch uint64) *types.RewardEpoch
+	GetRewardClaims(walletAddress string) []types.RewardClaim
+
+	// Announcements for node operators
+	PostAnnouncement(kind types.AnnouncementKind, title, body string, expiresAt, now int64) types.Announcement
+	GetAnnouncements(now int64) []types.Announcement
+	RetractAnnouncement(id uint64) error
+
+	// Maintenance of the whole service
+	StartServiceMaintenance(reason string, until, now int64) types.ServiceMaintenance
+	EndServiceMaintenance(now int64) (types.ServiceMaintenance, error)
+	ServiceMaintenance() *types.ServiceMaintenance
+
+	// Read-only replicas
+	WriteSnapshot(w io.Writer) error
+	ReadSnapshot(r io.Reader) (int64, error)
+
+	// Backups of the whole store
+	Export(w io.Writer) error
+	Import(r io.Reader) (int64, error)
+}
+
+var _ Store = (*MemoryStore)(nil)
@@ -0,0 +1,182 @@
+// Generates storemock from the Store interface in interface.go. Each
+// method gets a func field; unset fields return zero values, so a test
+// only stubs what the handler under test calls. Run via go generate in
+// internal/store.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const storeImport = "github.com/depinonbnb/depin/internal/store"
+
+func main() {
+	in := flag.String("in", "interface.go", "file declaring the Store interface")
+	out := flag.String("out", "storemock/storemock.go", "where to write the mock")
+	flag.Parse()
+
+	src, err := generate(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(*out), 0o755); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func generate(path string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	iface := findInterface(file, "Store")
+	if iface == nil {
+		return nil, fmt.Errorf("%s: no Store interface", path)
+	}
+
+	expr := func(e ast.Expr) string {
+		var buf bytes.Buffer
+		printer.Fprint(&buf, fset, e)
+		return buf.String()
+	}
+
+	var fields, methods bytes.Buffer
+	for _, m := range iface.Methods.List {
+		fn, ok := m.Type.(*ast.FuncType)
+		if !ok || len(m.Names) == 0 {
+			return nil, fmt.Errorf("%s: only plain methods are supported", path)
+		}
+		name := m.Names[0].Name
+
+		var params, args, paramTypes []string
+		for _, p := range fieldList(fn.Params) {
+			arg := "p" + strconv.Itoa(len(args))
+			typ := expr(p)
+			if ell, ok := p.(*ast.Ellipsis); ok {
+				typ = "..." + expr(ell.Elt)
+				arg += "..."
+			}
+			params = append(params, strings.TrimSuffix(arg, "...")+" "+typ)
+			args = append(args, arg)
+			paramTypes = append(paramTypes, typ)
+		}
+		var results, resultTypes []string
+		for i, r := range fieldList(fn.Results) {
+			results = append(results, "r"+strconv.Itoa(i)+" "+expr(r))
+			resultTypes = append(resultTypes, expr(r))
+		}
+
+		sig := "(" + strings.Join(paramTypes, ", ") + ")"
+		if len(resultTypes) == 1 {
+			sig += " " + resultTypes[0]
+		} else if len(resultTypes) > 1 {
+			sig += " (" + strings.Join(resultTypes, ", ") + ")"
+		}
+		fmt.Fprintf(&fields, "\t%sFunc func%s\n", name, sig)
+
+		fmt.Fprintf(&methods, "\nfunc (m *Store) %s(%s)", name, strings.Join(params, ", "))
+		if len(results) > 0 {
+			fmt.Fprintf(&methods, " (%s)", strings.Join(results, ", "))
+		}
+		fmt.Fprintf(&methods, " {\n\tm.record(%q)\n\tif m.%sFunc != nil {\n", name, name)
+		call := fmt.Sprintf("m.%sFunc(%s)", name, strings.Join(args, ", "))
+		if len(results) > 0 {
+			fmt.Fprintf(&methods, "\t\treturn %s\n\t}\n\treturn\n}\n", call)
+		} else {
+			fmt.Fprintf(&methods, "\t\t%s\n\t}\n}\n", call)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by internal/store/mockgen. DO NOT EDIT.\n\n")
+	buf.WriteString("// Package storemock is a store.Store for tests.\npackage storemock\n\n")
+	writeImports(&buf, file)
+	buf.WriteString("// Stub a method by setting its Func field. Methods left unset return\n// zero values.\ntype Store struct {\n")
+	buf.Write(fields.Bytes())
+	buf.WriteString("\n\tmu    sync.Mutex\n\tcalls map[string]int\n}\n\n")
+	buf.WriteString("var _ store.Store = (*Store)(nil)\n\n")
+	buf.WriteString("// How many times a method was called\nfunc (m *Store) Calls(method string) int {\n\tm.mu.Lock()\n\tdefer m.mu.Unlock()\n\treturn m.calls[method]\n}\n\n")
+	buf.WriteString("func (m *Store) record(method string) {\n\tm.mu.Lock()\n\tdefer m.mu.Unlock()\n\tif m.calls == nil {\n\t\tm.calls = make(map[string]int)\n\t}\n\tm.calls[method]++\n}\n")
+	buf.Write(methods.Bytes())
+
+	return format.Source(buf.Bytes())
+}
+
+// The interface file's imports plus what the mock itself needs, standard
+// library first
+func writeImports(buf *bytes.Buffer, file *ast.File) {
+	std := []string{strconv.Quote("sync")}
+	other := []string{strconv.Quote(storeImport)}
+	for _, imp := range file.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			other = append(other, imp.Path.Value)
+		} else {
+			std = append(std, imp.Path.Value)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+
+	buf.WriteString("import (\n")
+	for _, path := range std {
+		buf.WriteString("\t" + path + "\n")
+	}
+	buf.WriteString("\n")
+	for _, path := range other {
+		buf.WriteString("\t" + path + "\n")
+	}
+	buf.WriteString(")\n\n")
+}
+
+func findInterface(file *ast.File, name string) *ast.InterfaceType {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if it, ok := ts.Type.(*ast.InterfaceType); ok && ts.Name.Name == name {
+				return it
+			}
+		}
+	}
+	return nil
+}
+
+// One type per parameter, expanding "a, b string"
+func fieldList(list *ast.FieldList) []ast.Expr {
+	if list == nil {
+		return nil
+	}
+	var types []ast.Expr
+	for _, f := range list.List {
+		n := len(f.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			types = append(types, f.Type)
+		}
+	}
+	return types
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	_ "github.com/lib/pq" // The postgres driver for database/sql
+)
+
+var postgresDialect = dialect{
+	driver:   "postgres",
+	numbered: true,
+	schema: []string{
+		`CREATE TABLE IF NOT EXISTS depin_checkpoint (
+	id       SMALLINT PRIMARY KEY CHECK (id = 1),
+	taken_at BIGINT NOT NULL,
+	state    BYTEA NOT NULL
+)`,
+		`CREATE TABLE IF NOT EXISTS depin_nodes (
+	id                  TEXT PRIMARY KEY,
+	wallet_address      TEXT NOT NULL,
+	node_type           TEXT NOT NULL,
+	network             TEXT NOT NULL,
+	verification_method TEXT NOT NULL,
+	cheat_status        TEXT NOT NULL,
+	is_active           BOOLEAN NOT NULL,
+	total_points        BIGINT NOT NULL,
+	registered_at       BIGINT NOT NULL,
+	last_verified_at    BIGINT NOT NULL,
+	last_heartbeat_at   BIGINT NOT NULL,
+	record              BYTEA NOT NULL
+)`,
+		`CREATE INDEX IF NOT EXISTS depin_nodes_wallet ON depin_nodes (wallet_address)`,
+		`CREATE TABLE IF NOT EXISTS depin_points (
+	node_id             TEXT NOT NULL,
+	id                  BIGINT NOT NULL,
+	reason              TEXT NOT NULL,
+	amount              BIGINT NOT NULL,
+	timestamp           BIGINT NOT NULL,
+	reference           TEXT NOT NULL,
+	note                TEXT NOT NULL,
+	stake_bonus_percent BIGINT NOT NULL DEFAULT 0,
+	stake_tokens        BIGINT NOT NULL DEFAULT 0,
+	PRIMARY KEY (node_id, id)
+)`,
+		`CREATE TABLE IF NOT EXISTS depin_verifications (
+	node_id          TEXT NOT NULL,
+	seq              BIGINT NOT NULL,
+	challenge_id     TEXT NOT NULL,
+	challenge_type   TEXT NOT NULL,
+	passed           BOOLEAN NOT NULL,
+	response_time_ms BIGINT NOT NULL,
+	failure_kind     TEXT NOT NULL,
+	failure_reason   TEXT NOT NULL,
+	suspicious       BOOLEAN NOT NULL,
+	suspicious_note  TEXT NOT NULL,
+	surprise         BOOLEAN NOT NULL,
+	block_age        BIGINT,
+	parts            TEXT NOT NULL,
+	timestamp        BIGINT NOT NULL,
+	PRIMARY KEY (node_id, seq)
+)`,
+		`CREATE TABLE IF NOT EXISTS depin_heartbeats (
+	node_id        TEXT NOT NULL,
+	seq            BIGINT NOT NULL,
+	timestamp      BIGINT NOT NULL,
+	block_number   BIGINT NOT NULL,
+	block_hash     TEXT NOT NULL,
+	is_synced      BOOLEAN NOT NULL,
+	latency_ms     BIGINT NOT NULL,
+	peers_count    BIGINT NOT NULL,
+	client_version TEXT NOT NULL,
+	PRIMARY KEY (node_id, seq)
+)`,
+	},
+}
+
+// Connect to url (postgres://...), create the tables that are missing, and
+// load what's in them into s. Returns when the last checkpoint was taken,
+// 0 if there wasn't one yet.
+func OpenPostgres(url string, s *MemoryStore) (*DurableStore, int64, error) {
+	return openDurable(url, postgresDialect, s)
+}
@@ -0,0 +1,141 @@
+package store
+
+import (
+	"encoding/gob"
+	"io"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// The state read-only replicas serve from: nodes, everything the public
+// node, leaderboard, stats and announcement queries read, the API keys
+// they're read with, and whether the service is in maintenance. Settings
+// aren't in it; a replica has its own config.
+type snapshot struct {
+	TakenAt             int64
+	Nodes               map[string]*types.NodeRegistration
+	NodesByWallet       map[string][]string
+	VerificationHistory map[string][]*types.VerificationResult
+	Heartbeats          map[string][]*types.HeartbeatRecord
+	Ledger              map[string][]types.PointsEntry
+	DailyUptime         map[string]map[string]snapshotDay
+	Failures            map[string]map[types.FailureKind]uint64
+	NetworkFailures     map[types.FailureKind]uint64
+	Reports             map[string]*types.CheatReport
+	ReportsByNode       map[string][]string
+	Reporters           map[string]*types.ReporterReputation
+	WalletBans          map[string]*types.WalletBan
+	APIKeys             map[string]*types.APIKey
+	Announcements       []*types.Announcement
+	Epochs              []*types.RewardEpoch
+	Maintenance         *types.ServiceMaintenance
+	ForkReady           map[string]map[string]int64
+	Reclassifications   map[string]*types.Reclassification
+	RegionWeights       map[string]types.RegionWeight
+	RegionWeightsAt     int64
+	Stakes              map[string]types.WalletStake
+}
+
+type snapshotDay struct {
+	Checks uint64
+	Up     uint64
+}
+
+// Write the replicated state to w. Holds the read lock throughout, so
+// what's written is consistent.
+func (s *MemoryStore) WriteSnapshot(w io.Writer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return gob.NewEncoder(w).Encode(s.snapshot())
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) snapshot() *snapshot {
+	days := make(map[string]map[string]snapshotDay, len(s.dailyUptime))
+	for nodeID, byDay := range s.dailyUptime {
+		days[nodeID] = make(map[string]snapshotDay, len(byDay))
+		for day, d := range byDay {
+			days[nodeID][day] = snapshotDay{Checks: d.checks, Up: d.up}
+		}
+	}
+	return &snapshot{
+		TakenAt:             s.clock.Now().UnixMilli(),
+		Nodes:               s.nodes,
+		NodesByWallet:       s.nodesByWallet,
+		VerificationHistory: s.verificationHistory,
+		Heartbeats:          s.heartbeats,
+		Ledger:              s.ledger,
+		DailyUptime:         days,
+		Failures:            s.failures,
+		NetworkFailures:     s.networkFailures,
+		Reports:             s.reports,
+		ReportsByNode:       s.reportsByNode,
+		Reporters:           s.reporters,
+		WalletBans:          s.walletBans,
+		APIKeys:             s.apiKeys,
+		Announcements:       s.announcements,
+		Epochs:              s.epochs,
+		Maintenance:         s.maintenance,
+		ForkReady:           s.forkReady,
+		Reclassifications:   s.reclassifications,
+		RegionWeights:       s.regionWeights,
+		RegionWeightsAt:     s.regionWeightsAt,
+		Stakes:              s.stakes,
+	}
+}
+
+// Replace the replicated state with a snapshot read from r, returning
+// when it was taken. On error the store is left as it was.
+func (s *MemoryStore) ReadSnapshot(r io.Reader) (int64, error) {
+	var snap snapshot
+	if err := gob.NewDecoder(r).Decode(&snap); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.restore(&snap)
+	return snap.TakenAt, nil
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) restore(snap *snapshot) {
+	days := make(map[string]map[string]*uptimeDay, len(snap.DailyUptime))
+	for nodeID, byDay := range snap.DailyUptime {
+		days[nodeID] = make(map[string]*uptimeDay, len(byDay))
+		for day, d := range byDay {
+			days[nodeID][day] = &uptimeDay{checks: d.Checks, up: d.Up}
+		}
+	}
+
+	// gob leaves empty maps nil
+	s.nodes = orEmpty(snap.Nodes)
+	s.nodesByWallet = orEmpty(snap.NodesByWallet)
+	s.verificationHistory = orEmpty(snap.VerificationHistory)
+	s.heartbeats = orEmpty(snap.Heartbeats)
+	s.ledger = orEmpty(snap.Ledger)
+	s.dailyUptime = days
+	s.failures = orEmpty(snap.Failures)
+	s.networkFailures = orEmpty(snap.NetworkFailures)
+	s.reports = orEmpty(snap.Reports)
+	s.reportsByNode = orEmpty(snap.ReportsByNode)
+	s.reporters = orEmpty(snap.Reporters)
+	s.walletBans = orEmpty(snap.WalletBans)
+	s.apiKeys = orEmpty(snap.APIKeys)
+	s.announcements = snap.Announcements
+	s.epochs = snap.Epochs
+	s.maintenance = snap.Maintenance
+	s.forkReady = orEmpty(snap.ForkReady)
+	s.reclassifications = orEmpty(snap.Reclassifications)
+	s.regionWeights = snap.RegionWeights
+	s.regionWeightsAt = snap.RegionWeightsAt
+	s.stakes = orEmpty(snap.Stakes)
+	s.generation++
+}
+
+func orEmpty[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return make(map[K]V)
+	}
+	return m
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	_ "modernc.org/sqlite" // Pure Go, so the server still builds without cgo
+)
+
+// One connection: SQLite takes one writer at a time anyway, and this way
+// a checkpoint never waits on the file's lock. The write-ahead log keeps a
+// checkpoint's transaction cheap enough to run every few seconds.
+var sqliteDialect = dialect{
+	driver:   "sqlite",
+	maxConns: 1,
+	schema: []string{
+		`PRAGMA journal_mode = WAL`,
+		`CREATE TABLE IF NOT EXISTS depin_checkpoint (
+	id       INTEGER PRIMARY KEY CHECK (id = 1),
+	taken_at INTEGER NOT NULL,
+	state    BLOB NOT NULL
+)`,
+		`CREATE TABLE IF NOT EXISTS depin_nodes (
+	id                  TEXT PRIMARY KEY,
+	wallet_address      TEXT NOT NULL,
+	node_type           TEXT NOT NULL,
+	network             TEXT NOT NULL,
+	verification_method TEXT NOT NULL,
+	cheat_status        TEXT NOT NULL,
+	is_active           INTEGER NOT NULL,
+	total_points        INTEGER NOT NULL,
+	registered_at       INTEGER NOT NULL,
+	last_verified_at    INTEGER NOT NULL,
+	last_heartbeat_at   INTEGER NOT NULL,
+	record              BLOB NOT NULL
+)`,
+		`CREATE INDEX IF NOT EXISTS depin_nodes_wallet ON depin_nodes (wallet_address)`,
+		`CREATE TABLE IF NOT EXISTS depin_points (
+	node_id             TEXT NOT NULL,
+	id                  INTEGER NOT NULL,
+	reason              TEXT NOT NULL,
+	amount              INTEGER NOT NULL,
+	timestamp           INTEGER NOT NULL,
+	reference           TEXT NOT NULL,
+	note                TEXT NOT NULL,
+	stake_bonus_percent INTEGER NOT NULL DEFAULT 0,
+	stake_tokens        INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (node_id, id)
+)`,
+		`CREATE TABLE IF NOT EXISTS depin_verifications (
+	node_id          TEXT NOT NULL,
+	seq              INTEGER NOT NULL,
+	challenge_id     TEXT NOT NULL,
+	challenge_type   TEXT NOT NULL,
+	passed           INTEGER NOT NULL,
+	response_time_ms INTEGER NOT NULL,
+	failure_kind     TEXT NOT NULL,
+	failure_reason   TEXT NOT NULL,
+	suspicious       INTEGER NOT NULL,
+	suspicious_note  TEXT NOT NULL,
+	surprise         INTEGER NOT NULL,
+	block_age        INTEGER,
+	parts            TEXT NOT NULL,
+	timestamp        INTEGER NOT NULL,
+	PRIMARY KEY (node_id, seq)
+)`,
+		`CREATE TABLE IF NOT EXISTS depin_heartbeats (
+	node_id        TEXT NOT NULL,
+	seq            INTEGER NOT NULL,
+	timestamp      INTEGER NOT NULL,
+	block_number   INTEGER NOT NULL,
+	block_hash     TEXT NOT NULL,
+	is_synced      INTEGER NOT NULL,
+	latency_ms     INTEGER NOT NULL,
+	peers_count    INTEGER NOT NULL,
+	client_version TEXT NOT NULL,
+	PRIMARY KEY (node_id, seq)
+)`,
+	},
+}
+
+// Open the SQLite database at path, creating the file and its tables on
+// first start, and load what's in them into s. Returns when the last
+// checkpoint was taken, 0 if there wasn't one yet. For operators who
+// don't want to run a database server; only one process may use the file.
+func OpenSQLite(path string, s *MemoryStore) (*DurableStore, int64, error) {
+	return openDurable(path, sqliteDialect, s)
+}
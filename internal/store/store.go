@@ -1,34 +1,186 @@
 package store
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
-
+	"unsafe"
+
+	"github.com/depinonbnb/depin/internal/budget"
+	"github.com/depinonbnb/depin/internal/clientversion"
+	"github.com/depinonbnb/depin/internal/clock"
+	"github.com/depinonbnb/depin/internal/geo"
+	"github.com/depinonbnb/depin/internal/hardfork"
+	"github.com/depinonbnb/depin/internal/health"
+	"github.com/depinonbnb/depin/internal/modlog"
+	"github.com/depinonbnb/depin/internal/notify"
+	"github.com/depinonbnb/depin/internal/proverbuild"
+	"github.com/depinonbnb/depin/internal/rates"
+	"github.com/depinonbnb/depin/internal/rewards"
+	"github.com/depinonbnb/depin/internal/staking"
+	"github.com/depinonbnb/depin/internal/totp"
 	"github.com/depinonbnb/depin/internal/types"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/google/uuid"
 )
 
 // In-memory store for nodes and verification data
 // Replace with a real database in production
-type Store struct {
+type MemoryStore struct {
 	nodes               map[string]*types.NodeRegistration
 	nodesByWallet       map[string][]string
 	verificationHistory map[string][]*types.VerificationResult
 	heartbeats          map[string][]*types.HeartbeatRecord
+	ledger              map[string][]types.PointsEntry   // nodeID -> every points change
+	dailyUptime         map[string]map[string]*uptimeDay // nodeID -> "2006-01-02" -> checks
+	failures            map[string]map[types.FailureKind]uint64
+	networkFailures     map[types.FailureKind]uint64
+	replays             map[string]*types.ChallengeReplay // challengeID -> failed challenge
+	replayOrder         []string                          // Oldest first, for trimming
+	attempts            map[string][]types.SubmissionAttempt
+	attemptOrder        []string
+	reports             map[string]*types.CheatReport
+	reportsByNode       map[string][]string // nodeID -> report IDs
+	reporters           map[string]*types.ReporterReputation
+	fingerprints        map[string]*fingerprintCluster
+	fingerprintWallets  int                         // Wallets sharing a fingerprint before its nodes are flagged
+	nodeAddrs           map[string]map[string]int64 // nodeID -> submitting address -> last seen
+	walletBans          map[string]*types.WalletBan
+	walletTOTP          map[string]*types.WalletTOTP
+	apiKeys             map[string]*types.APIKey // By ID
+	announcements       []*types.Announcement    // Oldest first
+	epochs              []*types.RewardEpoch     // Oldest first
+	nextAnnouncementID  uint64
+	maintenance         *types.ServiceMaintenance // Nil = not in maintenance
+	maintenanceEndedAt  int64
+	walletBanCooldown   time.Duration // Per offence, 0 = bans are permanent
+	pendingBans         map[string]*types.PendingBan
+	banApprovalWindow   time.Duration // 0 = one admin can ban alone
+	moderation          *modlog.Log
+	trustWeightedPoints bool
+	silentAfter         time.Duration // 0 = quiet nodes stay active
+	network             types.Network
+	minClientVersions   clientversion.Minimums
+	officialBuilds      proverbuild.Allowlist
+	forks               []hardfork.Fork
+	forkReady           map[string]map[string]int64        // nodeID -> fork ID -> first seen ready
+	reclassifications   map[string]*types.Reclassification // nodeID -> latest proposal
+	budgets             map[string]*types.ChallengeBudget  // nodeID -> today's challenges
+	challengeCaps       budget.Caps
+	lastCompaction      *types.Compaction
+	recompute           *recomputeJob
+	leaderboardRules    types.LeaderboardRules
+	diversityBonus      uint64 // Percent for the rarest country, 0 = off
+	uptimePenalty       uint64 // Percent off uptime points below the uptime target
+	pointsRates         rates.Rates
+	regionWeights       map[string]types.RegionWeight
+	regionWeightsAt     int64
+	stakes              map[string]types.WalletStake // By wallet, as last read from the staking contract
+	stakingTiers        staking.Tiers
+	warningThreshold    uint8
+	flagThreshold       uint8
+	notifier            notify.Notifier
+	clock               clock.Clock
+	generation          uint64 // Bumped by every restore, so a durable backend knows its rows are stale
 	mu                  sync.RWMutex
 }
 
-func NewStore() *Store {
-	return &Store{
+func NewStore() *MemoryStore {
+	return &MemoryStore{
 		nodes:               make(map[string]*types.NodeRegistration),
 		nodesByWallet:       make(map[string][]string),
 		verificationHistory: make(map[string][]*types.VerificationResult),
 		heartbeats:          make(map[string][]*types.HeartbeatRecord),
+		ledger:              make(map[string][]types.PointsEntry),
+		dailyUptime:         make(map[string]map[string]*uptimeDay),
+		failures:            make(map[string]map[types.FailureKind]uint64),
+		networkFailures:     make(map[types.FailureKind]uint64),
+		replays:             make(map[string]*types.ChallengeReplay),
+		attempts:            make(map[string][]types.SubmissionAttempt),
+		reports:             make(map[string]*types.CheatReport),
+		reportsByNode:       make(map[string][]string),
+		reporters:           make(map[string]*types.ReporterReputation),
+		fingerprints:        make(map[string]*fingerprintCluster),
+		fingerprintWallets:  5,
+		nodeAddrs:           make(map[string]map[string]int64),
+		walletBans:          make(map[string]*types.WalletBan),
+		walletTOTP:          make(map[string]*types.WalletTOTP),
+		stakes:              make(map[string]types.WalletStake),
+		apiKeys:             make(map[string]*types.APIKey),
+		walletBanCooldown:   30 * 24 * time.Hour,
+		pendingBans:         make(map[string]*types.PendingBan),
+		forkReady:           make(map[string]map[string]int64),
+		reclassifications:   make(map[string]*types.Reclassification),
+		budgets:             make(map[string]*types.ChallengeBudget),
+		moderation:          modlog.New(),
+		network:             types.Mainnet,
+		warningThreshold:    2,
+		flagThreshold:       5,
+		notifier:            notify.Nop{},
+		clock:               clock.System{},
 	}
 }
 
+// Where the store gets the time. Set before the store is in use.
+func (s *MemoryStore) SetClock(c clock.Clock) {
+	s.mu.Lock()
+	s.clock = c
+	s.mu.Unlock()
+}
+
+// Hash-chained record of every admin action
+func (s *MemoryStore) ModerationLog() *modlog.Log {
+	return s.moderation
+}
+
+// Where operator-facing events (e.g. an imminent flag) get sent
+func (s *MemoryStore) SetNotifier(n notify.Notifier) {
+	s.mu.Lock()
+	s.notifier = n
+	s.mu.Unlock()
+}
+
+// Change how many wallets can share a connection fingerprint before
+// their nodes are flagged
+func (s *MemoryStore) SetFingerprintWalletThreshold(wallets int) {
+	s.mu.Lock()
+	s.fingerprintWallets = wallets
+	s.mu.Unlock()
+}
+
+// Change what a node needs to show up on the leaderboard
+func (s *MemoryStore) SetLeaderboardRules(rules types.LeaderboardRules) {
+	s.mu.Lock()
+	s.leaderboardRules = rules
+	s.mu.Unlock()
+}
+
+func (s *MemoryStore) LeaderboardRules() types.LeaderboardRules {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.leaderboardRules
+}
+
+// Change how many suspicious events it takes to warn/flag a node
+func (s *MemoryStore) SetEscalationThresholds(warning, flag uint8) {
+	s.mu.Lock()
+	s.warningThreshold = warning
+	s.flagThreshold = flag
+	s.mu.Unlock()
+}
+
 // Register a new node - gives registration bonus points
-func (s *Store) RegisterNode(walletAddress string, nodeType types.NodeType, method types.VerificationMethod, rpcEndpoint, authToken string) *types.NodeRegistration {
+func (s *MemoryStore) RegisterNode(walletAddress string, nodeType types.NodeType, method types.VerificationMethod, rpcEndpoint, authToken string) *types.NodeRegistration {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -36,12 +188,12 @@ func (s *Store) RegisterNode(walletAddress string, nodeType types.NodeType, meth
 		ID:                 uuid.New().String(),
 		WalletAddress:      walletAddress,
 		NodeType:           nodeType,
+		Network:            s.network,
 		VerificationMethod: method,
 		RPCEndpoint:        rpcEndpoint,
 		AuthToken:          authToken,
-		RegisteredAt:       time.Now().UnixMilli(),
+		RegisteredAt:       s.clock.Now().UnixMilli(),
 		IsActive:           true,
-		TotalPoints:        nodeType.RegistrationBonus(), // Bonus for registering!
 		TotalUptimeMinutes: 0,
 		CheatStatus:        types.StatusClean,
 		WarningCount:       0,
@@ -49,6 +201,8 @@ func (s *Store) RegisterNode(walletAddress string, nodeType types.NodeType, meth
 	}
 
 	s.nodes[node.ID] = node
+	s.credit(node, types.PointsRegistration, nodeType.RegistrationBonus()*s.network.PointsMultiplier(), "", node.RegisteredAt) // Bonus for registering!
+	s.refreshTrust(node, node.RegisteredAt)
 
 	// Track by wallet
 	s.nodesByWallet[walletAddress] = append(s.nodesByWallet[walletAddress], node.ID)
@@ -56,13 +210,13 @@ func (s *Store) RegisterNode(walletAddress string, nodeType types.NodeType, meth
 	return node
 }
 
-func (s *Store) GetNode(nodeID string) *types.NodeRegistration {
+func (s *MemoryStore) GetNode(nodeID string) *types.NodeRegistration {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return s.nodes[nodeID]
 }
 
-func (s *Store) GetNodesByWallet(walletAddress string) []*types.NodeRegistration {
+func (s *MemoryStore) GetNodesByWallet(walletAddress string) []*types.NodeRegistration {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -78,7 +232,107 @@ func (s *Store) GetNodesByWallet(walletAddress string) []*types.NodeRegistration
 	return nodes
 }
 
-func (s *Store) GetAllActiveNodes() []*types.NodeRegistration {
+// Search nodes by ID, wallet address (or a prefix of either) and tags
+// such as the node type, chain, client or country. Every word in query
+// has to match; results come best match first, then by points.
+func (s *MemoryStore) SearchNodes(query string, limit int) []types.SearchResult {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	s.mu.RLock()
+	var results []types.SearchResult
+	for _, node := range s.nodes {
+		tags := nodeTags(node)
+		result := types.SearchResult{
+			NodeID:        node.ID,
+			WalletAddress: node.WalletAddress,
+			NodeType:      node.NodeType,
+			Network:       node.Network,
+			IsActive:      node.IsActive,
+			CheatStatus:   node.CheatStatus,
+			TotalPoints:   node.TotalPoints,
+			Tags:          tags,
+		}
+		for _, term := range terms {
+			score, matched := searchScore(node, tags, term)
+			if score == 0 {
+				result.Score = 0
+				break
+			}
+			result.Score += score
+			result.Matched = append(result.Matched, matched)
+		}
+		if result.Score > 0 {
+			results = append(results, result)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].TotalPoints != results[j].TotalPoints {
+			return results[i].TotalPoints > results[j].TotalPoints
+		}
+		return results[i].NodeID < results[j].NodeID
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// Prefixes shorter than this would match half the store
+const minSearchPrefix = 4
+
+// How well one search word matches a node, 0 = not at all. An exact ID or
+// wallet beats a prefix of one, a longer prefix beats a shorter one, and
+// any of those beats a tag.
+func searchScore(node *types.NodeRegistration, tags []string, term string) (int, string) {
+	id, wallet := strings.ToLower(node.ID), strings.ToLower(node.WalletAddress)
+	switch {
+	case term == id:
+		return 100, "id"
+	case term == wallet:
+		return 90, "wallet"
+	case len(term) >= minSearchPrefix && strings.HasPrefix(id, term):
+		return 40 + 40*len(term)/len(id), "id"
+	case len(term) >= minSearchPrefix && strings.HasPrefix(wallet, term):
+		return 30 + 40*len(term)/len(wallet), "wallet"
+	}
+	for _, tag := range tags {
+		if tag == term {
+			return 20, tag
+		}
+	}
+	return 0, ""
+}
+
+// The labels a node already carries that it can be searched by
+func nodeTags(node *types.NodeRegistration) []string {
+	tags := []string{
+		string(node.NodeType),
+		string(node.NodeType.Chain()),
+		string(node.VerificationMethod),
+		string(node.Network),
+		string(node.CheatStatus),
+	}
+	if node.Country != "" {
+		tags = append(tags, strings.ToLower(node.Country))
+	}
+	if version, ok := clientversion.Parse(node.ClientVersion); ok {
+		tags = append(tags, version.Client)
+	}
+	if node.Paused {
+		tags = append(tags, "paused")
+	}
+	return tags
+}
+
+func (s *MemoryStore) GetAllActiveNodes() []*types.NodeRegistration {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -91,7 +345,7 @@ func (s *Store) GetAllActiveNodes() []*types.NodeRegistration {
 	return nodes
 }
 
-func (s *Store) UpdateNode(nodeID string, updates func(*types.NodeRegistration)) *types.NodeRegistration {
+func (s *MemoryStore) UpdateNode(nodeID string, updates func(*types.NodeRegistration)) *types.NodeRegistration {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -104,8 +358,14 @@ func (s *Store) UpdateNode(nodeID string, updates func(*types.NodeRegistration))
 	return node
 }
 
+// How many verification results and heartbeats are kept per node
+const (
+	maxVerificationHistory = 1000
+	maxHeartbeats          = 300
+)
+
 // Record verification result and award points
-func (s *Store) RecordVerificationResult(result *types.VerificationResult) {
+func (s *MemoryStore) RecordVerificationResult(result *types.VerificationResult) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -113,20 +373,40 @@ func (s *Store) RecordVerificationResult(result *types.VerificationResult) {
 	history = append(history, result)
 
 	// Keep last 1000 results
-	if len(history) > 1000 {
+	if len(history) > maxVerificationHistory {
 		history = history[1:]
 	}
 
 	s.verificationHistory[result.NodeID] = history
 
+	if result.Passed && s.nodes[result.NodeID] != nil {
+		s.budgetFor(result.NodeID, result.Timestamp).Passed[result.ChallengeType]++
+	}
+
+	if !result.Passed {
+		s.countFailure(result.NodeID, result.FailureKind)
+		if result.Replay != nil {
+			s.saveReplay(result)
+		}
+	}
+
 	// Update node stats
 	if node, ok := s.nodes[result.NodeID]; ok {
-		if result.Passed {
-			node.TotalChallengesPassed++
-		} else {
-			node.TotalChallengesFailed++
+		if result.Surprise && node.Surprise.PendingID == result.ChallengeID {
+			s.settleSurprise(node, result.Passed)
+		}
+
+		// Composites count once per part, so partly right is partly credited
+		if result.Passed && node.Silent {
+			node.IsActive = true
+			node.Silent = false
 		}
+
+		passed, failed := result.Credit()
+		node.TotalChallengesPassed += passed
+		node.TotalChallengesFailed += failed
 		node.LastVerifiedAt = result.Timestamp
+		s.recordUptimeCheck(result.NodeID, result.Timestamp, passed > 0)
 
 		// Track suspicious activity
 		if result.Suspicious {
@@ -135,7 +415,7 @@ func (s *Store) RecordVerificationResult(result *types.VerificationResult) {
 				event = "Suspicious verification detected"
 			}
 			node.SuspiciousEvents = append(node.SuspiciousEvents,
-				time.Now().Format("2006-01-02 15:04")+": "+event)
+				s.clock.Now().Format("2006-01-02 15:04")+": "+event)
 
 			// Keep only last 20 events
 			if len(node.SuspiciousEvents) > 20 {
@@ -145,18 +425,21 @@ func (s *Store) RecordVerificationResult(result *types.VerificationResult) {
 			node.WarningCount++
 
 			// Escalate based on warning count
-			if node.WarningCount >= 5 {
+			if node.WarningCount >= s.flagThreshold {
 				node.CheatStatus = types.StatusFlagged
 				node.CheatReason = "Multiple suspicious activities - needs manual review"
-			} else if node.WarningCount >= 2 {
+			} else if node.WarningCount >= s.warningThreshold {
 				node.CheatStatus = types.StatusWarning
 				node.CheatReason = event
 			}
+			s.warnIfFlagImminent(node)
 		}
+
+		s.refreshTrust(node, result.Timestamp)
 	}
 }
 
-func (s *Store) GetVerificationHistory(nodeID string, limit int) []*types.VerificationResult {
+func (s *MemoryStore) GetVerificationHistory(nodeID string, limit int) []*types.VerificationResult {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -168,7 +451,7 @@ func (s *Store) GetVerificationHistory(nodeID string, limit int) []*types.Verifi
 }
 
 // Record heartbeat
-func (s *Store) RecordHeartbeat(heartbeat *types.HeartbeatRecord) {
+func (s *MemoryStore) RecordHeartbeat(heartbeat *types.HeartbeatRecord) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -176,14 +459,33 @@ func (s *Store) RecordHeartbeat(heartbeat *types.HeartbeatRecord) {
 	history = append(history, heartbeat)
 
 	// Keep last 300 (about 24 hours at 5 min intervals)
-	if len(history) > 300 {
+	if len(history) > maxHeartbeats {
 		history = history[1:]
 	}
 
 	s.heartbeats[heartbeat.NodeID] = history
+	s.recordUptimeCheck(heartbeat.NodeID, heartbeat.Timestamp, heartbeat.IsSynced)
+
+	node := s.nodes[heartbeat.NodeID]
+	if node == nil {
+		return
+	}
+	node.LastHeartbeatAt = heartbeat.Timestamp
+	if heartbeat.ClientVersion != "" {
+		node.ClientVersion = heartbeat.ClientVersion
+		s.checkClientVersion(node)
+	}
+}
+
+// Heartbeat that couldn't reach the node - counts as downtime
+func (s *MemoryStore) RecordMissedHeartbeat(nodeID string, timestamp int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recordUptimeCheck(nodeID, timestamp, false)
 }
 
-func (s *Store) GetHeartbeats(nodeID string, since int64) []*types.HeartbeatRecord {
+func (s *MemoryStore) GetHeartbeats(nodeID string, since int64) []*types.HeartbeatRecord {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -201,8 +503,36 @@ func (s *Store) GetHeartbeats(nodeID string, since int64) []*types.HeartbeatReco
 	return filtered
 }
 
+// Results and registrations across every node from since up to until
+func (s *MemoryStore) NetworkActivity(since, until int64) types.NetworkActivity {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	activity := types.NetworkActivity{ByType: make(map[types.ChallengeType]types.ChallengeCounts)}
+	for id, node := range s.nodes {
+		if node.RegisteredAt >= since && node.RegisteredAt < until {
+			activity.Registrations++
+		}
+		for _, v := range s.verificationHistory[id] {
+			if v.Timestamp >= since && v.Timestamp < until {
+				activity.Checks++
+				counts := activity.ByType[v.ChallengeType]
+				counts.Checks++
+				if v.Passed {
+					activity.Passed++
+					counts.Passed++
+				}
+				if v.ChallengeType != "" {
+					activity.ByType[v.ChallengeType] = counts
+				}
+			}
+		}
+	}
+	return activity
+}
+
 // Get node stats
-func (s *Store) GetNodeStats(nodeID string) *types.NodeStats {
+func (s *MemoryStore) GetNodeStats(nodeID string) *types.NodeStats {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -214,7 +544,7 @@ func (s *Store) GetNodeStats(nodeID string) *types.NodeStats {
 	verifications := s.verificationHistory[nodeID]
 
 	// Challenge pass rate
-	last24h := time.Now().UnixMilli() - 24*60*60*1000
+	last24h := s.clock.Now().UnixMilli() - 24*60*60*1000
 	recentVerifications := 0
 	recentPassed := 0
 	var totalLatency uint64
@@ -243,14 +573,178 @@ func (s *Store) GetNodeStats(nodeID string) *types.NodeStats {
 		AverageLatencyMs:   avgLatency,
 		CheatStatus:        node.CheatStatus,
 		WarningCount:       node.WarningCount,
+		FailuresByKind:     copyFailureCounts(s.failures[nodeID]),
+		LatencyPercentiles: s.latencyWindows(nodeID),
+		ChallengeBudget:    s.budgetToday(nodeID, s.clock.Now().UnixMilli()),
+		OutageCompensation: s.outageCompensation(nodeID),
+	}
+}
+
+// Totals of a node's outage-compensation entries, nil if it has none.
+// Caller must hold s.mu
+func (s *MemoryStore) outageCompensation(nodeID string) *types.OutageCompensation {
+	var comp *types.OutageCompensation
+	compensated := make(map[uint64]bool)
+	for _, entry := range s.ledger[nodeID] {
+		if entry.Reason != types.PointsOutage {
+			continue
+		}
+		if comp == nil {
+			comp = &types.OutageCompensation{}
+		}
+		compensated[entry.ID] = true
+		comp.Outages++
+		comp.Points += entry.Amount
+		var start, end int64
+		if _, err := fmt.Sscanf(entry.Reference, "%d-%d", &start, &end); err == nil {
+			comp.Minutes += uint64((end - start) / (60 * 1000))
+		}
+	}
+	// Reversed compensation doesn't count
+	for _, entry := range s.ledger[nodeID] {
+		if id, err := strconv.ParseUint(entry.Reference, 10, 64); err == nil && entry.Reason == types.PointsReversal && compensated[id] {
+			comp.Points += entry.Amount
+		}
+	}
+	return comp
+}
+
+// A node's health grade, from the last 24 hours of challenges and
+// heartbeats and the last 7 days of uptime
+func (s *MemoryStore) GetHealth(nodeID string) (types.NodeHealth, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.nodes[nodeID]; !ok {
+		return types.NodeHealth{}, false
+	}
+	return s.health(nodeID), true
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) health(nodeID string) types.NodeHealth {
+	since := s.clock.Now().Add(-24 * time.Hour).UnixMilli()
+	var in health.Inputs
+
+	passed := 0
+	for _, v := range s.verificationHistory[nodeID] {
+		if v.Timestamp >= since {
+			in.Challenges++
+			if v.Passed {
+				passed++
+			}
+		}
+	}
+	if in.Challenges > 0 {
+		in.PassRate = float64(passed) / float64(in.Challenges) * 100
+	}
+
+	latency := s.latencyWindows(nodeID)["24h"]
+	in.LatencyP90Ms, in.LatencySamples = latency.P90, latency.Samples
+
+	synced := 0
+	for _, hb := range s.heartbeats[nodeID] {
+		if hb.Timestamp >= since {
+			in.Heartbeats++
+			if hb.IsSynced {
+				synced++
+			}
+		}
+	}
+	if in.Heartbeats > 0 {
+		in.SyncedPercent = float64(synced) / float64(in.Heartbeats) * 100
+	}
+
+	in.UptimeChecked = s.hasUptimeChecks(nodeID, 7)
+	in.UptimePercent = s.recentUptimePercent(nodeID, 7)
+	return health.Grade(in)
+}
+
+// Rolling windows we report latency percentiles over
+var latencyWindows = []struct {
+	name string
+	span time.Duration
+}{
+	{"1h", time.Hour},
+	{"24h", 24 * time.Hour},
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) latencyWindows(nodeID string) map[string]types.LatencyPercentiles {
+	now := s.clock.Now()
+	verifications := s.verificationHistory[nodeID]
+
+	windows := make(map[string]types.LatencyPercentiles, len(latencyWindows))
+	for _, w := range latencyWindows {
+		since := now.Add(-w.span).UnixMilli()
+		samples := make([]uint64, 0, len(verifications))
+		for _, v := range verifications {
+			if v.Timestamp >= since {
+				samples = append(samples, v.ResponseTimeMs)
+			}
+		}
+		windows[w.name] = percentiles(samples)
+	}
+	return windows
+}
+
+// Nearest-rank percentiles. Sorts samples in place.
+func percentiles(samples []uint64) types.LatencyPercentiles {
+	if len(samples) == 0 {
+		return types.LatencyPercentiles{}
 	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	rank := func(p int) uint64 {
+		idx := (len(samples)*p + 99) / 100 // ceil(n * p / 100)
+		if idx < 1 {
+			idx = 1
+		}
+		return samples[idx-1]
+	}
+
+	return types.LatencyPercentiles{
+		P50:     rank(50),
+		P90:     rank(90),
+		P99:     rank(99),
+		Samples: len(samples),
+	}
+}
+
+// Latency over the last 24h, for admin views
+func (s *MemoryStore) GetLatencyPercentiles(nodeID string) types.LatencyPercentiles {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.latencyWindows(nodeID)["24h"]
 }
 
 // Get total points for a wallet (across all their nodes)
-func (s *Store) GetWalletStats(walletAddress string) *types.WalletStats {
+func (s *MemoryStore) GetWalletStats(walletAddress string) *types.WalletStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.walletStats(walletAddress)
+}
+
+// Stats for many wallets under a single lock. Wallets with no nodes are
+// left out of the result.
+func (s *MemoryStore) GetWalletStatsBatch(walletAddresses []string) map[string]*types.WalletStats {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	result := make(map[string]*types.WalletStats, len(walletAddresses))
+	for _, wallet := range walletAddresses {
+		if stats := s.walletStats(wallet); stats != nil {
+			result[wallet] = stats
+		}
+	}
+	return result
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) walletStats(walletAddress string) *types.WalletStats {
 	nodeIDs := s.nodesByWallet[walletAddress]
 	if len(nodeIDs) == 0 {
 		return nil
@@ -259,16 +753,28 @@ func (s *Store) GetWalletStats(walletAddress string) *types.WalletStats {
 	var totalPoints uint64
 	activeNodes := 0
 	flaggedNodes := 0
+	pendingFlags := make([]string, 0)
+	liveness := make([]types.NodeLiveness, 0, len(nodeIDs))
+	now := s.clock.Now().UnixMilli()
 
 	for _, nodeID := range nodeIDs {
 		if node, ok := s.nodes[nodeID]; ok {
 			totalPoints += node.TotalPoints
+			liveness = append(liveness, types.NodeLiveness{
+				NodeID:          node.ID,
+				LastVerifiedAt:  node.LastVerifiedAt,
+				LastHeartbeatAt: node.LastHeartbeatAt,
+				Liveness:        node.Liveness(now),
+			})
 			if node.IsActive {
 				activeNodes++
 			}
 			if node.CheatStatus == types.StatusFlagged || node.CheatStatus == types.StatusWarning {
 				flaggedNodes++
 			}
+			if s.flagImminent(node) {
+				pendingFlags = append(pendingFlags, node.ID)
+			}
 		}
 	}
 
@@ -278,12 +784,15 @@ func (s *Store) GetWalletStats(walletAddress string) *types.WalletStats {
 		TotalNodes:    len(nodeIDs),
 		ActiveNodes:   activeNodes,
 		FlaggedNodes:  flaggedNodes,
+		PendingFlags:  pendingFlags,
+		Nodes:         liveness,
+		Stake:         s.stake(walletAddress, s.clock.Now().UnixMilli()),
 	}
 }
 
 // Award points for uptime - call this periodically (every 5 minutes)
 // Also tracks uptime minutes
-func (s *Store) AwardUptimePoints(nodeID string, minutesOnline uint64) {
+func (s *MemoryStore) AwardUptimePoints(nodeID string, minutesOnline uint64) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -297,86 +806,2979 @@ func (s *Store) AwardUptimePoints(nodeID string, minutesOnline uint64) {
 		return
 	}
 
+	// Paused for maintenance - not running, so no uptime
+	if node.Paused {
+		return
+	}
+
+	now := s.clock.Now().UnixMilli()
 	node.TotalUptimeMinutes += minutesOnline
-	node.LastHeartbeatAt = time.Now().UnixMilli()
+	node.LastHeartbeatAt = now
 
-	// Award points based on uptime (per hour rate, divided by 12 for 5-min intervals)
-	// So if PointsPerHour is 6, they get 0.5 points per 5 minutes
-	pointsPerInterval := node.NodeType.PointsPerHour() / 12
-	if pointsPerInterval < 1 {
-		pointsPerInterval = 1
+	pointsPerInterval := s.uptimePointsPerInterval(node)
+	stakeBonus, stakeTokens := s.stakeBonus(node, now)
+	if !s.trustWeightedPoints && s.regionBonus(node) == 0 && stakeBonus == 0 && s.uptimePenaltyFor(node) == 0 {
+		s.credit(node, types.PointsUptime, pointsPerInterval, "", now)
+		return
+	}
+
+	// Carry the fraction so small awards still add up
+	hundredths := s.intervalHundredths(node, stakeBonus) + node.PointsCarry
+	node.PointsCarry = hundredths % 100
+	if hundredths < 100 {
+		return
 	}
-	node.TotalPoints += pointsPerInterval
+	s.appendPoints(node, types.PointsEntry{
+		Reason:            types.PointsUptime,
+		Amount:            int64(hundredths / 100),
+		Timestamp:         now,
+		StakeBonusPercent: stakeBonus,
+		StakeTokens:       stakeTokens,
+	})
 }
 
-// Add a suspicious event to a node
-func (s *Store) AddSuspiciousEvent(nodeID string, reason string) {
+// Hundredths of a point one uptime interval earns a node, scaled by trust
+// score, region and stake bonuses and uptime penalty.
+// Caller must hold s.mu
+func (s *MemoryStore) intervalHundredths(node *types.NodeRegistration, stakeBonus uint64) uint64 {
+	pointsPerInterval := s.uptimePointsPerInterval(node)
+	hundredths := pointsPerInterval * 100
+	if s.trustWeightedPoints {
+		hundredths = pointsPerInterval * uint64(node.Trust.Score)
+	}
+	hundredths = hundredths * (100 + s.regionBonus(node)) / 100 * (100 + stakeBonus) / 100
+	return hundredths * (100 - s.uptimePenaltyFor(node)) / 100
+}
+
+// Make up for an outage of the service itself: every node that was
+// earning uptime points when it started gets what it would have earned
+// for the length of it, recorded in its ledger with the outage as the
+// reference. The caller makes sure each outage is only passed in once.
+// Returns the nodes credited.
+func (s *MemoryStore) CompensateOutage(outage types.Outage, now int64) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	minutes := uint64((outage.End - outage.Start) / (60 * 1000))
+	reference := fmt.Sprintf("%d-%d", outage.Start, outage.End)
+	note := fmt.Sprintf("service outage (%s), %d minutes", outage.Cause, minutes)
+
+	var credited []string
+	for _, node := range s.nodes {
+		// Same reasons AwardUptimePoints skips a node, and nodes that
+		// weren't registered yet
+		if !node.IsActive || node.Paused || node.RegisteredAt >= outage.Start ||
+			node.CheatStatus == types.StatusFlagged || node.CheatStatus == types.StatusBanned {
+			continue
+		}
+
+		stakeBonus, stakeTokens := s.stakeBonus(node, now)
+		hundredths := s.intervalHundredths(node, stakeBonus)*minutes/uptimeIntervalMinutes + node.PointsCarry
+		node.PointsCarry = hundredths % 100
+		if hundredths < 100 {
+			continue
+		}
+		s.appendPoints(node, types.PointsEntry{
+			Reason:            types.PointsOutage,
+			Amount:            int64(hundredths / 100),
+			Timestamp:         now,
+			Reference:         reference,
+			Note:              note,
+			StakeBonusPercent: stakeBonus,
+			StakeTokens:       stakeTokens,
+		})
+		credited = append(credited, node.ID)
+	}
+	sort.Strings(credited)
+	return credited
+}
+
+// Add points to a node's ledger and total. Zero awards aren't recorded.
+// Caller must hold s.mu
+func (s *MemoryStore) credit(node *types.NodeRegistration, reason types.PointsReason, amount uint64, reference string, now int64) {
+	if amount == 0 {
+		return
+	}
+	s.appendPoints(node, types.PointsEntry{Reason: reason, Amount: int64(amount), Timestamp: now, Reference: reference})
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) appendPoints(node *types.NodeRegistration, entry types.PointsEntry) types.PointsEntry {
+	entry.ID = uint64(len(s.ledger[node.ID])) + 1
+	s.ledger[node.ID] = append(s.ledger[node.ID], entry)
+	node.TotalPoints = uint64(int64(node.TotalPoints) + entry.Amount)
+	return entry
+}
+
+// Every change to a node's points, oldest first. Nil if it has none.
+func (s *MemoryStore) PointsLedger(nodeID string) []types.PointsEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ledger := s.ledger[nodeID]
+	if len(ledger) == 0 {
+		return nil
+	}
+	return append([]types.PointsEntry(nil), ledger...)
+}
+
+var (
+	ErrEntryNotFound   = errors.New("ledger entry not found")
+	ErrReverseReversal = errors.New("a reversal can't be reversed")
+	ErrAlreadyReversed = errors.New("ledger entry was already reversed")
+	ErrPointsNegative  = errors.New("that would take the node below zero points")
+)
+
+// Undo a ledger entry by appending one for the opposite amount. An entry
+// can only be reversed once, and reversals can't be reversed.
+func (s *MemoryStore) ReversePoints(nodeID string, entryID uint64, note string, now int64) (types.PointsEntry, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	node, ok := s.nodes[nodeID]
 	if !ok {
-		return
+		return types.PointsEntry{}, ErrNodeNotFound
+	}
+	ledger := s.ledger[nodeID]
+	if entryID == 0 || entryID > uint64(len(ledger)) {
+		return types.PointsEntry{}, ErrEntryNotFound
+	}
+	original := ledger[entryID-1]
+	if original.Reason == types.PointsReversal {
+		return types.PointsEntry{}, ErrReverseReversal
 	}
 
-	// Add to suspicious events list
-	event := time.Now().Format("2006-01-02 15:04") + ": " + reason
-	node.SuspiciousEvents = append(node.SuspiciousEvents, event)
+	reference := strconv.FormatUint(entryID, 10)
+	for _, entry := range ledger {
+		if entry.Reason == types.PointsReversal && entry.Reference == reference {
+			return types.PointsEntry{}, ErrAlreadyReversed
+		}
+	}
+	if int64(node.TotalPoints) < original.Amount {
+		return types.PointsEntry{}, ErrPointsNegative
+	}
 
-	// Keep only last 20 events
-	if len(node.SuspiciousEvents) > 20 {
-		node.SuspiciousEvents = node.SuspiciousEvents[1:]
+	return s.appendPoints(node, types.PointsEntry{
+		Reason:    types.PointsReversal,
+		Amount:    -original.Amount,
+		Timestamp: now,
+		Reference: reference,
+		Note:      note,
+	}), nil
+}
+
+// Add or take away points by hand, e.g. for a bug that paid the wrong
+// amount over many entries. Recorded like every other change, with note
+// saying why.
+func (s *MemoryStore) AdjustPoints(nodeID string, amount int64, note string, now int64) (types.PointsEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[nodeID]
+	if !ok {
+		return types.PointsEntry{}, ErrNodeNotFound
 	}
+	if int64(node.TotalPoints)+amount < 0 {
+		return types.PointsEntry{}, ErrPointsNegative
+	}
+	return s.appendPoints(node, types.PointsEntry{
+		Reason:    types.PointsAdjustment,
+		Amount:    amount,
+		Timestamp: now,
+		Note:      note,
+	}), nil
+}
 
-	node.WarningCount++
+// Uptime is awarded every 5 minutes
+const (
+	uptimeIntervalMinutes = 5
+	uptimeIntervalsPerDay = 24 * 60 / uptimeIntervalMinutes
+)
 
-	// Escalate status based on warning count
-	if node.WarningCount >= 5 {
-		node.CheatStatus = types.StatusFlagged
-		node.CheatReason = "Multiple suspicious activities detected - needs manual review"
-	} else if node.WarningCount >= 2 {
-		node.CheatStatus = types.StatusWarning
-		node.CheatReason = reason
+// Award points based on uptime (per hour rate, divided by 12 for 5-min intervals)
+// So if PointsPerHour is 6, they get 0.5 points per 5 minutes
+func (s *MemoryStore) uptimePointsPerInterval(node *types.NodeRegistration) uint64 {
+	rate := s.pointsRates.PointsPerHour(node.NodeType)
+	if rate == 0 {
+		return 0 // Turned off for this type
+	}
+	pointsPerInterval := rate / 12
+	if pointsPerInterval < 1 {
+		pointsPerInterval = 1
 	}
+	return pointsPerInterval * s.network.PointsMultiplier()
 }
 
-// Get all nodes that need admin review
-func (s *Store) GetFlaggedNodes() []*types.NodeRegistration {
+// Estimate a node's uptime points per day and week at today's rates,
+// assuming it stays up as much as it has for the last week. Nil if the
+// node doesn't exist.
+func (s *MemoryStore) ProjectPoints(nodeID string, now int64) *types.PointsProjection {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	flagged := make([]*types.NodeRegistration, 0)
-	for _, node := range s.nodes {
-		if node.CheatStatus == types.StatusFlagged || node.CheatStatus == types.StatusWarning {
-			flagged = append(flagged, node)
+	node, ok := s.nodes[nodeID]
+	if !ok {
+		return nil
+	}
+
+	stakeBonus, _ := s.stakeBonus(node, now)
+	inputs := types.ProjectionInputs{
+		NodeType:           node.NodeType,
+		PointsPerHour:      s.pointsRates.PointsPerHour(node.NodeType),
+		PointsPerInterval:  s.uptimePointsPerInterval(node),
+		IntervalsPerDay:    uptimeIntervalsPerDay,
+		NetworkMultiplier:  s.network.PointsMultiplier(),
+		RegionBonusPercent: s.regionBonus(node),
+		StakeBonusPercent:  stakeBonus,
+		UptimePenalty:      s.uptimePenaltyFor(node),
+		TrustWeighted:      s.trustWeightedPoints,
+		Uptime7dPercent:    s.recentUptimePercent(nodeID, 7),
+	}
+
+	// Same window as the node's stats
+	var recent, passed int
+	for _, v := range s.verificationHistory[nodeID] {
+		if v.Timestamp >= now-24*60*60*1000 {
+			recent++
+			if v.Passed {
+				passed++
+			}
 		}
 	}
-	return flagged
+	if recent > 0 {
+		inputs.ChallengePassRate = float64(passed) / float64(recent) * 100
+	}
+
+	switch {
+	case s.hasUptimeChecks(nodeID, 7):
+		inputs.OnlinePercent, inputs.OnlineBasis = inputs.Uptime7dPercent, "uptime_7d"
+	case recent > 0:
+		inputs.OnlinePercent, inputs.OnlineBasis = inputs.ChallengePassRate, "pass_rate_24h"
+	default:
+		inputs.OnlinePercent, inputs.OnlineBasis = 100, "assumed"
+	}
+
+	// Same reasons AwardUptimePoints skips a node
+	switch {
+	case !node.IsActive:
+		inputs.NotEarning = "inactive"
+	case node.CheatStatus == types.StatusFlagged || node.CheatStatus == types.StatusBanned:
+		inputs.NotEarning = string(node.CheatStatus)
+	case node.Paused:
+		inputs.NotEarning = "paused"
+	}
+
+	projection := &types.PointsProjection{NodeID: nodeID, Inputs: inputs}
+	if inputs.NotEarning == "" {
+		perDay := float64(uptimeIntervalsPerDay*inputs.PointsPerInterval) * float64(100+inputs.RegionBonusPercent) / 100
+		perDay = perDay * float64(100+inputs.StakeBonusPercent) / 100
+		perDay = perDay * float64(100-inputs.UptimePenalty) / 100
+		projection.Daily = perDay * inputs.OnlinePercent / 100
+		projection.Weekly = projection.Daily * 7
+	}
+	return projection
 }
 
-// Admin action: clear warnings or ban a node
-func (s *Store) SetNodeCheatStatus(nodeID string, status types.CheatStatus, reason string) bool {
+// Checks a node needs in the last week before its uptime is judged, so
+// one missed heartbeat from a new node isn't 0%
+const minUptimeChecks = 12
+
+// Recompute each active node's 7-day uptime and compare it with its type's
+// minimum. Operators get a warning when a node first drops below; until it
+// recovers its uptime points are cut by the uptime penalty. Paused nodes
+// and nodes with too few checks are left as they are. Returns the nodes
+// that newly fell below.
+func (s *MemoryStore) EnforceUptime(now int64) []string {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	node, ok := s.nodes[nodeID]
-	if !ok {
-		return false
+	fell := make([]string, 0)
+	if s.maintenance != nil {
+		return fell
 	}
+	for id, node := range s.nodes {
+		if !node.IsActive || node.Paused {
+			continue
+		}
+		checks, up := s.recentUptimeChecks(id, 7)
+		if checks < minUptimeChecks {
+			continue
+		}
 
-	node.CheatStatus = status
-	node.CheatReason = reason
+		node.Uptime7dPercent = float64(up) / float64(checks) * 100
+		target := node.NodeType.MinUptimePercent()
+		below := node.Uptime7dPercent < float64(target)
+		if below && !node.BelowUptimeTarget {
+			s.notifier.Notify(notify.Event{
+				Type:          notify.EventUptimeLow,
+				NodeID:        id,
+				WalletAddress: node.WalletAddress,
+				Message:       fmt.Sprintf("7-day uptime is %.1f%%, %s nodes need %d%%; uptime points are cut by %d%% until it recovers", node.Uptime7dPercent, node.NodeType, target, s.uptimePenalty),
+				Timestamp:     now,
+			})
+			fell = append(fell, id)
+		}
+		node.BelowUptimeTarget = below
+	}
+	return fell
+}
 
-	// If cleared, reset warning count
-	if status == types.StatusClean {
-		node.WarningCount = 0
-		node.SuspiciousEvents = []string{}
+// Percent taken off uptime points for nodes below their uptime target
+func (s *MemoryStore) SetUptimePenalty(percent uint64) {
+	s.mu.Lock()
+	s.uptimePenalty = percent
+	s.mu.Unlock()
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) uptimePenaltyFor(node *types.NodeRegistration) uint64 {
+	if !node.BelowUptimeTarget {
+		return 0
 	}
+	return s.uptimePenalty
+}
 
-	// If banned, deactivate
-	if status == types.StatusBanned {
-		node.IsActive = false
+// Extra uptime points for a node in an underrepresented country, as a
+// percent. Caller must hold s.mu.
+func (s *MemoryStore) regionBonus(node *types.NodeRegistration) uint64 {
+	if s.diversityBonus == 0 || node.Country == "" {
+		return 0
 	}
+	return uint64(s.regionWeights[node.Country].Weight * float64(s.diversityBonus))
+}
 
-	return true
+// Override how many uptime points node types earn per hour (safe to call
+// while serving). Types without an override keep their built-in rate.
+func (s *MemoryStore) SetPointsRates(r rates.Rates) {
+	s.mu.Lock()
+	s.pointsRates = r
+	s.mu.Unlock()
+}
+
+// What a node type earns per hour of uptime right now
+func (s *MemoryStore) PointsPerHour(nodeType types.NodeType) uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.pointsRates.PointsPerHour(nodeType)
+}
+
+// Extra uptime points for a node whose wallet has stake behind it, as a
+// percent, and the tokens held that earn it. A stake that hasn't been read
+// for staking.StaleAfter earns nothing. Caller must hold s.mu.
+func (s *MemoryStore) stakeBonus(node *types.NodeRegistration, now int64) (percent, tokens uint64) {
+	stake := s.stakes[strings.ToLower(node.WalletAddress)]
+	if stakeStale(stake, now) || stake.BonusPercent == 0 {
+		return 0, 0
+	}
+	return stake.BonusPercent, stake.HeldTokens
+}
+
+func stakeStale(stake types.WalletStake, now int64) bool {
+	return now-stake.CheckedAt > staking.StaleAfter.Milliseconds()
+}
+
+// Change the stake bonus tiers (safe to call while serving). Every
+// wallet's bonus follows straight away, without reading stakes again.
+func (s *MemoryStore) SetStakingTiers(tiers staking.Tiers) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stakingTiers = tiers
+	for wallet, stake := range s.stakes {
+		stake.BonusPercent = tiers.Bonus(stake.HeldTokens)
+		s.stakes[wallet] = stake
+	}
+}
+
+// Keep stakes just read from the staking contract, keyed by lowercase
+// wallet. Wallets missing from stakes keep what they had, so a failed read
+// doesn't cost a bonus until the stake goes stale. A wallet is only
+// credited with tokens it also had at the read before, so staking for one
+// read and withdrawing straight after earns nothing.
+func (s *MemoryStore) RecordStakes(stakes map[string]types.WalletStake) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for wallet, stake := range stakes {
+		stake.HeldTokens = 0
+		if previous, ok := s.stakes[wallet]; ok && !stakeStale(previous, stake.CheckedAt) {
+			stake.HeldTokens = previous.Tokens
+			if stake.Tokens < stake.HeldTokens {
+				stake.HeldTokens = stake.Tokens
+			}
+		}
+		stake.BonusPercent = s.stakingTiers.Bonus(stake.HeldTokens)
+		s.stakes[wallet] = stake
+	}
+}
+
+// What a wallet has staked, nil if it hasn't been read. A stale stake
+// shows no bonus, since it isn't earning one.
+// Caller must hold s.mu
+func (s *MemoryStore) stake(walletAddress string, now int64) *types.WalletStake {
+	stake, ok := s.stakes[strings.ToLower(walletAddress)]
+	if !ok {
+		return nil
+	}
+	if stakeStale(stake, now) {
+		stake.BonusPercent = 0
+	}
+	return &stake
+}
+
+// Change the bonus for nodes in the rarest countries (percent, 0 = off)
+func (s *MemoryStore) SetDiversityBonus(percent uint64) {
+	s.mu.Lock()
+	s.diversityBonus = percent
+	s.mu.Unlock()
+}
+
+// Remember which country a node's requests come from. Empty is ignored,
+// so a request the proxy couldn't place doesn't erase what we knew.
+func (s *MemoryStore) RecordNodeCountry(nodeID, country string) {
+	if country == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if node := s.nodes[nodeID]; node != nil {
+		node.Country = country
+	}
+}
+
+// Count active nodes per country and reweigh them. Run weekly, so the
+// bonus doesn't swing every time a node comes or goes.
+func (s *MemoryStore) RecalculateRegionWeights(now int64) types.RegionWeights {
+	s.mu.Lock()
+	counts := make(map[string]int)
+	for _, node := range s.nodes {
+		if !node.IsActive || node.Country == "" || node.Network != s.network || node.CheatStatus == types.StatusBanned {
+			continue
+		}
+		counts[node.Country]++
+	}
+	s.regionWeights = geo.Weights(counts)
+	s.regionWeightsAt = now
+	s.mu.Unlock()
+
+	return s.RegionWeights()
+}
+
+// The current weights, with the bonus each country's nodes get
+func (s *MemoryStore) RegionWeights() types.RegionWeights {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	weights := types.RegionWeights{
+		UpdatedAt:       s.regionWeightsAt,
+		MaxBonusPercent: s.diversityBonus,
+		Regions:         make(map[string]types.RegionWeight, len(s.regionWeights)),
+	}
+	for country, w := range s.regionWeights {
+		w.BonusPercent = uint64(w.Weight * float64(s.diversityBonus))
+		weights.Regions[country] = w
+	}
+	return weights
+}
+
+// Add a suspicious event to a node
+func (s *MemoryStore) AddSuspiciousEvent(nodeID string, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[nodeID]
+	if !ok {
+		return
+	}
+	s.addSuspiciousEvent(node, reason)
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) addSuspiciousEvent(node *types.NodeRegistration, reason string) {
+	// Add to suspicious events list
+	event := s.clock.Now().Format("2006-01-02 15:04") + ": " + reason
+	node.SuspiciousEvents = append(node.SuspiciousEvents, event)
+
+	// Keep only last 20 events
+	if len(node.SuspiciousEvents) > 20 {
+		node.SuspiciousEvents = node.SuspiciousEvents[1:]
+	}
+
+	node.WarningCount++
+
+	// Escalate status based on warning count
+	if node.WarningCount >= s.flagThreshold {
+		node.CheatStatus = types.StatusFlagged
+		node.CheatReason = "Multiple suspicious activities detected - needs manual review"
+	} else if node.WarningCount >= s.warningThreshold {
+		node.CheatStatus = types.StatusWarning
+		node.CheatReason = reason
+	}
+	s.warnIfFlagImminent(node)
+}
+
+// Get all nodes that need admin review
+func (s *MemoryStore) GetFlaggedNodes() []*types.NodeRegistration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	flagged := make([]*types.NodeRegistration, 0)
+	for id, node := range s.nodes {
+		if node.CheatStatus == types.StatusFlagged || node.CheatStatus == types.StatusWarning {
+			flagged = append(flagged, node)
+		} else if node.CheatStatus != types.StatusBanned && s.hasOpenReports(id) {
+			// Reported by the community but not caught by anti-cheat yet
+			flagged = append(flagged, node)
+		}
+	}
+	return flagged
+}
+
+// Admin action: clear warnings or ban a node
+func (s *MemoryStore) SetNodeCheatStatus(nodeID string, status types.CheatStatus, reason string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[nodeID]
+	if !ok {
+		return false
+	}
+	s.setNodeCheatStatus(node, status, reason)
+	return true
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) setNodeCheatStatus(node *types.NodeRegistration, status types.CheatStatus, reason string) {
+	nodeID := node.ID
+	previous := node.CheatStatus
+	node.CheatStatus = status
+	node.CheatReason = reason
+
+	// If cleared, reset warning count
+	if status == types.StatusClean {
+		node.WarningCount = 0
+		node.SuspiciousEvents = []string{}
+		s.liftNodeWalletBan(node)
+	}
+
+	// If banned, deactivate, bar the wallet and send its siblings for review
+	if status == types.StatusBanned && previous != types.StatusBanned {
+		node.IsActive = false
+		s.banWallet(node.WalletAddress, reason, node.ID, s.clock.Now().UnixMilli())
+		s.flagSiblings(node)
+	}
+
+	// The review settles any community reports against the node
+	switch status {
+	case types.StatusClean:
+		s.resolveReports(nodeID, types.ReportDismissed)
+	case types.StatusWarning, types.StatusBanned:
+		s.resolveReports(nodeID, types.ReportUpheld)
+	}
+}
+
+// Lifetime challenge results grouped by node type
+func (s *MemoryStore) GetPassRatesByNodeType() map[types.NodeType]*types.PassRate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rates := make(map[types.NodeType]*types.PassRate)
+	for _, node := range s.nodes {
+		rate, ok := rates[node.NodeType]
+		if !ok {
+			rate = &types.PassRate{}
+			rates[node.NodeType] = rate
+		}
+		rate.Passed += node.TotalChallengesPassed
+		rate.Failed += node.TotalChallengesFailed
+	}
+
+	for _, rate := range rates {
+		if total := rate.Passed + rate.Failed; total > 0 {
+			rate.Rate = float64(rate.Passed) / float64(total) * 100
+		}
+	}
+	return rates
+}
+
+// How many nodes are in each anti-cheat status
+func (s *MemoryStore) CountByCheatStatus() map[types.CheatStatus]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := map[types.CheatStatus]int{
+		types.StatusClean:   0,
+		types.StatusWarning: 0,
+		types.StatusFlagged: 0,
+		types.StatusBanned:  0,
+	}
+	for _, node := range s.nodes {
+		counts[node.CheatStatus]++
+	}
+	return counts
+}
+
+// Checks that landed on one UTC day
+type uptimeDay struct {
+	checks uint64
+	up     uint64
+}
+
+// Keep a bit over a year of daily uptime for the calendar
+const uptimeRetentionDays = 400
+
+// Caller must hold s.mu
+func (s *MemoryStore) recordUptimeCheck(nodeID string, timestamp int64, up bool) {
+	days, ok := s.dailyUptime[nodeID]
+	if !ok {
+		days = make(map[string]*uptimeDay)
+		s.dailyUptime[nodeID] = days
+	}
+
+	date := time.UnixMilli(timestamp).UTC().Format("2006-01-02")
+	day, ok := days[date]
+	if !ok {
+		day = &uptimeDay{}
+		days[date] = day
+
+		// New day - drop anything past retention (ISO dates sort as strings)
+		cutoff := time.UnixMilli(timestamp).UTC().AddDate(0, 0, -uptimeRetentionDays).Format("2006-01-02")
+		for d := range days {
+			if d < cutoff {
+				delete(days, d)
+			}
+		}
+	}
+
+	day.checks++
+	if up {
+		day.up++
+	}
+}
+
+// Uptime percent over the last N days (UTC), 0 if there were no checks
+func (s *MemoryStore) GetRecentUptimePercent(nodeID string, days int) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.recentUptimePercent(nodeID, days)
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) recentUptimePercent(nodeID string, days int) float64 {
+	checks, up := s.recentUptimeChecks(nodeID, days)
+	if checks == 0 {
+		return 0
+	}
+	return float64(up) / float64(checks) * 100
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) hasUptimeChecks(nodeID string, days int) bool {
+	checks, _ := s.recentUptimeChecks(nodeID, days)
+	return checks > 0
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) recentUptimeChecks(nodeID string, days int) (checks, up uint64) {
+	since := s.clock.Now().UTC().AddDate(0, 0, -days+1).Format("2006-01-02")
+	for date, day := range s.dailyUptime[nodeID] {
+		if date >= since {
+			checks += day.checks
+			up += day.up
+		}
+	}
+	return checks, up
+}
+
+// Per-day uptime for one calendar month (UTC). Every day of the month is
+// included; days with no checks have Checks = 0.
+func (s *MemoryStore) GetUptimeCalendar(nodeID string, year int, month time.Month) []types.UptimeDay {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	days := s.dailyUptime[nodeID]
+
+	start := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	calendar := make([]types.UptimeDay, 0, 31)
+	for d := start; d.Month() == month; d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		entry := types.UptimeDay{Date: date}
+		if day, ok := days[date]; ok && day.checks > 0 {
+			entry.Checks = day.checks
+			entry.UptimePercent = float64(day.up) / float64(day.checks) * 100
+		}
+		calendar = append(calendar, entry)
+	}
+	return calendar
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) countFailure(nodeID string, kind types.FailureKind) {
+	if kind == "" {
+		kind = types.FailureOther
+	}
+
+	counts, ok := s.failures[nodeID]
+	if !ok {
+		counts = make(map[types.FailureKind]uint64)
+		s.failures[nodeID] = counts
+	}
+	counts[kind]++
+	s.networkFailures[kind]++
+}
+
+// Failed challenges across every node, by kind
+func (s *MemoryStore) GetNetworkFailureCounts() map[types.FailureKind]uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return copyFailureCounts(s.networkFailures)
+}
+
+func copyFailureCounts(counts map[types.FailureKind]uint64) map[types.FailureKind]uint64 {
+	out := make(map[types.FailureKind]uint64, len(counts))
+	for kind, n := range counts {
+		out[kind] = n
+	}
+	return out
+}
+
+// Keep the last 10000 failed challenges for admin inspection
+const maxReplays = 10000
+
+// Caller must hold s.mu
+func (s *MemoryStore) saveReplay(result *types.VerificationResult) {
+	replay := *result.Replay
+	replay.FailureReason = result.FailureReason
+	replay.FailureKind = result.FailureKind
+	replay.ResponseTimeMs = result.ResponseTimeMs
+	replay.VerifiedAt = result.Timestamp
+
+	if _, exists := s.replays[result.ChallengeID]; !exists {
+		s.replayOrder = append(s.replayOrder, result.ChallengeID)
+	}
+	s.replays[result.ChallengeID] = &replay
+
+	if len(s.replayOrder) > maxReplays {
+		delete(s.replays, s.replayOrder[0])
+		s.replayOrder = s.replayOrder[1:]
+	}
+}
+
+// Submissions kept per challenge. A node sending more than this to one
+// challenge is up to something, and the first ones show what.
+const maxAttemptsPerChallenge = 20
+
+// Keep a submission to a challenge, whatever became of it. One with a
+// different answer than the node sent to the same challenge before is
+// marked conflicting: an honest prover resends the same answer, while a
+// node trying several is guessing or running more than one backend. The
+// first conflict on a challenge is a suspicious event for the node.
+// Submissions are kept for the last 10000 challenges, like replays.
+func (s *MemoryStore) RecordSubmission(challengeID string, attempt types.SubmissionAttempt) types.SubmissionAttempt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	earlier, exists := s.attempts[challengeID]
+	conflicted := false
+	for _, e := range earlier {
+		if e.NodeID != attempt.NodeID {
+			continue
+		}
+		if e.AnswerHash != attempt.AnswerHash {
+			attempt.Conflicting = true
+		}
+		conflicted = conflicted || e.Conflicting
+	}
+	if attempt.Conflicting && !conflicted {
+		if node := s.nodes[attempt.NodeID]; node != nil {
+			s.addSuspiciousEvent(node, fmt.Sprintf("Sent conflicting answers to challenge %s", challengeID))
+		}
+	}
+
+	if len(earlier) < maxAttemptsPerChallenge {
+		s.attempts[challengeID] = append(earlier, attempt)
+	}
+	if !exists {
+		s.attemptOrder = append(s.attemptOrder, challengeID)
+		if len(s.attemptOrder) > maxReplays {
+			delete(s.attempts, s.attemptOrder[0])
+			s.attemptOrder = s.attemptOrder[1:]
+		}
+	}
+	return attempt
+}
+
+// Every submission kept for a challenge, oldest first
+func (s *MemoryStore) SubmissionAttempts(challengeID string) []types.SubmissionAttempt {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]types.SubmissionAttempt(nil), s.attempts[challengeID]...)
+}
+
+// Details of a failed challenge, nil if we don't have it
+func (s *MemoryStore) GetChallengeReplay(challengeID string) *types.ChallengeReplay {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.replays[challengeID]
+}
+
+var (
+	ErrNodeNotFound         = errors.New("node not found")
+	ErrAlreadyPaused        = errors.New("node is already paused")
+	ErrNotPaused            = errors.New("node is not paused")
+	ErrMaintenanceExhausted = errors.New("maintenance allowance used up for this month")
+)
+
+// Operator pauses their node for maintenance. Paused nodes aren't
+// challenged, and the time counts against the monthly allowance.
+func (s *MemoryStore) PauseNode(nodeID string, now int64) (*types.NodeRegistration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[nodeID]
+	if !ok {
+		return nil, ErrNodeNotFound
+	}
+	if node.Paused {
+		return nil, ErrAlreadyPaused
+	}
+
+	resetMaintenanceMonth(node, now)
+	if node.MaintenanceUsedMinutes >= types.MaintenanceAllowanceMinutes {
+		return nil, ErrMaintenanceExhausted
+	}
+
+	node.Paused = true
+	node.PausedAt = now
+	return node, nil
+}
+
+func (s *MemoryStore) ResumeNode(nodeID string, now int64) (*types.NodeRegistration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[nodeID]
+	if !ok {
+		return nil, ErrNodeNotFound
+	}
+	if !node.Paused {
+		return nil, ErrNotPaused
+	}
+
+	resume(node, now)
+	return node, nil
+}
+
+var (
+	ErrSameMethod = errors.New("node already uses this verification method")
+	ErrNodeBanned = errors.New("node is banned")
+)
+
+// Switch a node between exposed-rpc and local-prover, keeping its points
+// and history. What only made sense for the old method goes: the endpoint,
+// auth token and client certificate when moving to the prover, the
+// prover's polling stats when moving to an endpoint, and the storage
+// report and client version either way, since they describe the old
+// endpoint.
+func (s *MemoryStore) ChangeVerificationMethod(nodeID string, method types.VerificationMethod, rpcEndpoint, authToken string, now int64) (*types.NodeRegistration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[nodeID]
+	if !ok {
+		return nil, ErrNodeNotFound
+	}
+	if node.CheatStatus == types.StatusBanned {
+		return nil, ErrNodeBanned
+	}
+	if node.VerificationMethod == method {
+		return nil, ErrSameMethod
+	}
+
+	node.VerificationMethod = method
+	node.RPCEndpoint = ""
+	node.AuthToken = ""
+	node.ClientCert = nil
+	if method == types.ExposedRPC {
+		node.RPCEndpoint = rpcEndpoint
+		node.AuthToken = authToken
+		node.LastPolledAt = 0
+		node.PollIntervalMs = 0
+	}
+	node.Storage = nil
+	node.ClientVersion = ""
+	node.ClientOutdated = false
+	node.ProverBuild = ""
+	node.UnofficialBuild = false
+	node.MethodChangedAt = now
+	return node, nil
+}
+
+// Resume every node that has run out of maintenance allowance.
+// Call this periodically. Returns the IDs of resumed nodes.
+func (s *MemoryStore) ExpireMaintenance(now int64) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resumed := make([]string, 0)
+	for id, node := range s.nodes {
+		if !node.Paused {
+			continue
+		}
+		resetMaintenanceMonth(node, now)
+		if node.MaintenanceUsedMinutes+pausedMinutes(node, now) >= types.MaintenanceAllowanceMinutes {
+			resume(node, now)
+			resumed = append(resumed, id)
+		}
+	}
+	return resumed
+}
+
+// Mark nodes inactive that haven't passed a proof or sent a heartbeat
+// within the silent period, so they drop out of active stats and the
+// leaderboard. Paused nodes are left alone. Returns the nodes it marked.
+func (s *MemoryStore) InactivateSilentNodes(now int64) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	silenced := make([]string, 0)
+	if s.silentAfter == 0 || s.maintenance != nil {
+		return silenced
+	}
+	cutoff := now - s.silentAfter.Milliseconds()
+	for id, node := range s.nodes {
+		if !node.IsActive || node.Paused {
+			continue
+		}
+		// Nobody could get through during maintenance, so the silence
+		// only counts from when it ended
+		lastSeen := max(node.RegisteredAt, node.LastVerifiedAt, node.LastHeartbeatAt, s.maintenanceEndedAt)
+		if lastSeen < cutoff {
+			node.IsActive = false
+			node.Silent = true
+			silenced = append(silenced, id)
+		}
+	}
+	return silenced
+}
+
+// How long a node can go without a proof or heartbeat before it's made
+// inactive (0 = never)
+func (s *MemoryStore) SetSilentAfter(after time.Duration) {
+	s.mu.Lock()
+	s.silentAfter = after
+	s.mu.Unlock()
+}
+
+// Minutes of maintenance left this month
+func RemainingMaintenanceMinutes(node *types.NodeRegistration) uint64 {
+	if node.MaintenanceUsedMinutes >= types.MaintenanceAllowanceMinutes {
+		return 0
+	}
+	return types.MaintenanceAllowanceMinutes - node.MaintenanceUsedMinutes
+}
+
+func resume(node *types.NodeRegistration, now int64) {
+	resetMaintenanceMonth(node, now)
+
+	used := node.MaintenanceUsedMinutes + pausedMinutes(node, now)
+	if used > types.MaintenanceAllowanceMinutes {
+		used = types.MaintenanceAllowanceMinutes
+	}
+
+	node.MaintenanceUsedMinutes = used
+	node.Paused = false
+	node.PausedAt = 0
+}
+
+// Whole minutes paused so far, rounded up
+func pausedMinutes(node *types.NodeRegistration, now int64) uint64 {
+	if now <= node.PausedAt {
+		return 0
+	}
+	return uint64((now - node.PausedAt + 59999) / 60000)
+}
+
+// Allowance resets at the start of each UTC month
+func resetMaintenanceMonth(node *types.NodeRegistration, now int64) {
+	month := time.UnixMilli(now).UTC().Format("2006-01")
+	if node.MaintenanceMonth != month {
+		node.MaintenanceMonth = month
+		node.MaintenanceUsedMinutes = 0
+	}
+}
+
+// One suspicious event away from being flagged
+func (s *MemoryStore) flagImminent(node *types.NodeRegistration) bool {
+	return node.CheatStatus == types.StatusWarning && node.WarningCount+1 >= s.flagThreshold
+}
+
+// Give honest operators a chance to fix things before they're flagged.
+// Fires once, on the event that puts the node one step from the threshold.
+// Caller must hold s.mu
+func (s *MemoryStore) warnIfFlagImminent(node *types.NodeRegistration) {
+	if node.CheatStatus != types.StatusWarning || node.WarningCount+1 != s.flagThreshold {
+		return
+	}
+
+	s.notifier.Notify(notify.Event{
+		Type:          notify.EventFlagImminent,
+		NodeID:        node.ID,
+		WalletAddress: node.WalletAddress,
+		Message:       fmt.Sprintf("Node will be flagged for review after one more suspicious event (last: %s)", node.CheatReason),
+		WarningCount:  node.WarningCount,
+		FlagThreshold: s.flagThreshold,
+		Timestamp:     s.clock.Now().UnixMilli(),
+	})
+}
+
+var (
+	ErrDuplicateReport    = errors.New("you already have an open report against this node")
+	ErrTooManyOpenReports = errors.New("too many open reports - wait for some to be reviewed")
+	ErrReporterBlocked    = errors.New("too many of your reports have been dismissed")
+)
+
+const (
+	maxOpenReportsPerWallet = 5
+	minResolvedForBlock     = 3    // Don't judge a reporter on one or two calls
+	minReporterScore        = 0.25 // Below this (after minResolvedForBlock) reports are refused
+)
+
+// File a community report against a node. Reports sit in the admin
+// review queue until the node is reviewed.
+func (s *MemoryStore) FileReport(reporterWallet, nodeID, evidence string, now int64) (*types.CheatReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.nodes[nodeID]; !ok {
+		return nil, ErrNodeNotFound
+	}
+
+	rep := s.reporter(reporterWallet)
+	if rep.Upheld+rep.Dismissed >= minResolvedForBlock && rep.Score < minReporterScore {
+		return nil, ErrReporterBlocked
+	}
+
+	open := 0
+	for _, report := range s.reports {
+		if report.ReporterWallet != reporterWallet || report.Status != types.ReportOpen {
+			continue
+		}
+		if report.NodeID == nodeID {
+			return nil, ErrDuplicateReport
+		}
+		open++
+	}
+	if open >= maxOpenReportsPerWallet {
+		return nil, ErrTooManyOpenReports
+	}
+
+	report := &types.CheatReport{
+		ID:             uuid.New().String(),
+		NodeID:         nodeID,
+		ReporterWallet: reporterWallet,
+		Evidence:       evidence,
+		Status:         types.ReportOpen,
+		CreatedAt:      now,
+	}
+	s.reports[report.ID] = report
+	s.reportsByNode[nodeID] = append(s.reportsByNode[nodeID], report.ID)
+	rep.Filed++
+
+	copied := *report
+	return &copied, nil
+}
+
+// Open reports against a node, oldest first
+func (s *MemoryStore) GetOpenReports(nodeID string) []types.CheatReport {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	open := make([]types.CheatReport, 0)
+	for _, id := range s.reportsByNode[nodeID] {
+		if report := s.reports[id]; report.Status == types.ReportOpen {
+			open = append(open, *report)
+		}
+	}
+	return open
+}
+
+// Track record of a reporter (zero history if they've never reported)
+func (s *MemoryStore) GetReporterReputation(walletAddress string) types.ReporterReputation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if rep, ok := s.reporters[walletAddress]; ok {
+		return *rep
+	}
+	return types.ReporterReputation{WalletAddress: walletAddress, Score: reporterScore(0, 0)}
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) reporter(walletAddress string) *types.ReporterReputation {
+	rep, ok := s.reporters[walletAddress]
+	if !ok {
+		rep = &types.ReporterReputation{WalletAddress: walletAddress, Score: reporterScore(0, 0)}
+		s.reporters[walletAddress] = rep
+	}
+	return rep
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) hasOpenReports(nodeID string) bool {
+	for _, id := range s.reportsByNode[nodeID] {
+		if s.reports[id].Status == types.ReportOpen {
+			return true
+		}
+	}
+	return false
+}
+
+// Close every open report against a node and credit or debit the reporters.
+// Caller must hold s.mu
+func (s *MemoryStore) resolveReports(nodeID string, status types.ReportStatus) {
+	now := s.clock.Now().UnixMilli()
+	for _, id := range s.reportsByNode[nodeID] {
+		report := s.reports[id]
+		if report.Status != types.ReportOpen {
+			continue
+		}
+		report.Status = status
+		report.ResolvedAt = now
+
+		rep := s.reporter(report.ReporterWallet)
+		if status == types.ReportUpheld {
+			rep.Upheld++
+		} else {
+			rep.Dismissed++
+		}
+		rep.Score = reporterScore(rep.Upheld, rep.Dismissed)
+	}
+}
+
+// Share of reports upheld, smoothed so a single call doesn't swing it to 0 or 1
+func reporterScore(upheld, dismissed uint64) float64 {
+	return float64(upheld+1) / float64(upheld+dismissed+2)
+}
+
+type fingerprintCluster struct {
+	info    types.FingerprintCluster
+	flagged map[string]bool // Nodes already given a suspicious event for this cluster
+}
+
+// Remember which node submitted from which connection. Once enough
+// different wallets share a fingerprint, each of their nodes gets a
+// suspicious event - one operator running many wallets from one box.
+func (s *MemoryStore) RecordSubmissionFingerprint(nodeID, fingerprint string, conn types.ConnectionFingerprint, now int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[nodeID]
+	if !ok {
+		return
+	}
+
+	cluster, ok := s.fingerprints[fingerprint]
+	if !ok {
+		cluster = &fingerprintCluster{
+			info:    types.FingerprintCluster{Fingerprint: fingerprint, Connection: conn},
+			flagged: make(map[string]bool),
+		}
+		s.fingerprints[fingerprint] = cluster
+	}
+	cluster.info.LastSeen = now
+	cluster.info.Wallets = appendUnique(cluster.info.Wallets, node.WalletAddress)
+	cluster.info.NodeIDs = appendUnique(cluster.info.NodeIDs, nodeID)
+
+	if s.nodeAddrs[nodeID] == nil {
+		s.nodeAddrs[nodeID] = make(map[string]int64)
+	}
+	s.nodeAddrs[nodeID][conn.RemoteAddr] = now
+
+	// A new wallet in the cluster changes every member's cluster factor
+	for _, id := range cluster.info.NodeIDs {
+		if member, ok := s.nodes[id]; ok {
+			s.refreshTrust(member, now)
+		}
+	}
+
+	if len(cluster.info.Wallets) < s.fingerprintWallets {
+		return
+	}
+
+	reason := fmt.Sprintf("Submits from the same connection fingerprint as %d wallets - possible Sybil farm", len(cluster.info.Wallets))
+	for _, id := range cluster.info.NodeIDs {
+		if member, ok := s.nodes[id]; ok && !cluster.flagged[id] {
+			cluster.flagged[id] = true
+			s.addSuspiciousEvent(member, reason)
+		}
+	}
+}
+
+// Fingerprints shared by more than one wallet, most wallets first
+func (s *MemoryStore) GetFingerprintClusters() []types.FingerprintCluster {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	clusters := make([]types.FingerprintCluster, 0)
+	for _, cluster := range s.fingerprints {
+		if len(cluster.info.Wallets) > 1 {
+			info := cluster.info
+			info.Wallets = append([]string(nil), info.Wallets...)
+			info.NodeIDs = append([]string(nil), info.NodeIDs...)
+			clusters = append(clusters, info)
+		}
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		return len(clusters[i].Wallets) > len(clusters[j].Wallets)
+	})
+	return clusters
+}
+
+// Every verification since the given time as a row of anti-cheat
+// features, oldest first. Node IDs and fingerprints are replaced by
+// hashes salted with salt, so rows from one export can be grouped by node
+// and cluster but not traced back to either.
+func (s *MemoryStore) VerificationFeatures(since int64, salt string) []types.VerificationFeatures {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pseudonym := func(id string) string {
+		sum := sha256.Sum256([]byte(salt + "\x00" + id))
+		return hex.EncodeToString(sum[:8])
+	}
+
+	// Each node's largest cluster, the same one its trust score counts
+	clusters := make(map[string]*fingerprintCluster)
+	for _, cluster := range s.fingerprints {
+		for _, nodeID := range cluster.info.NodeIDs {
+			if c := clusters[nodeID]; c == nil || len(cluster.info.Wallets) > len(c.info.Wallets) {
+				clusters[nodeID] = cluster
+			}
+		}
+	}
+
+	type row struct {
+		at       int64
+		features types.VerificationFeatures
+	}
+	var found []row
+	for nodeID, history := range s.verificationHistory {
+		node := pseudonym(nodeID)
+		var cluster string
+		var wallets int
+		if c := clusters[nodeID]; c != nil {
+			cluster, wallets = pseudonym(c.info.Fingerprint), len(c.info.Wallets)
+		}
+		for _, v := range history {
+			if v.Timestamp < since {
+				continue
+			}
+			at := time.UnixMilli(v.Timestamp).UTC()
+			found = append(found, row{v.Timestamp, types.VerificationFeatures{
+				Node:           node,
+				ChallengeType:  v.ChallengeType,
+				ResponseTimeMs: v.ResponseTimeMs,
+				HourOfDay:      at.Hour(),
+				Day:            at.Format("2006-01-02"),
+				BlockAge:       v.BlockAge,
+				Cluster:        cluster,
+				ClusterWallets: wallets,
+				Surprise:       v.Surprise,
+				Suspicious:     v.Suspicious,
+				Passed:         v.Passed,
+				FailureKind:    v.FailureKind,
+			}})
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { return found[i].at < found[j].at })
+	rows := make([]types.VerificationFeatures, len(found))
+	for i, r := range found {
+		rows[i] = r.features
+	}
+	return rows
+}
+
+func appendUnique(items []string, item string) []string {
+	for _, existing := range items {
+		if existing == item {
+			return items
+		}
+	}
+	return append(items, item)
+}
+
+// Local prover asked for a challenge. Tracks how often it polls so
+// surprise challenges can land between polls.
+func (s *MemoryStore) RecordPoll(nodeID string, now int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[nodeID]
+	if !ok {
+		return
+	}
+
+	if node.LastPolledAt > 0 && now > node.LastPolledAt {
+		interval := uint64(now - node.LastPolledAt)
+		if node.PollIntervalMs == 0 {
+			node.PollIntervalMs = interval
+		} else {
+			node.PollIntervalMs = (node.PollIntervalMs*4 + interval) / 5
+		}
+	}
+	node.LastPolledAt = now
+}
+
+// A surprise challenge was pushed to the node
+func (s *MemoryStore) RecordSurpriseIssued(ch *types.Challenge) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if node, ok := s.nodes[ch.NodeID]; ok {
+		node.Surprise.Issued++
+		node.Surprise.PendingID = ch.ID
+		node.Surprise.PendingExpiresAt = ch.ExpiresAt
+	}
+}
+
+// Count unanswered surprise challenges past their expiry as missed.
+// Call this periodically.
+func (s *MemoryStore) ExpireSurprises(now int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Answers are held during maintenance and settle these once it's over
+	if s.maintenance != nil {
+		return
+	}
+	for _, node := range s.nodes {
+		if node.Surprise.PendingID != "" && now > node.Surprise.PendingExpiresAt {
+			s.settleSurprise(node, false)
+		}
+	}
+}
+
+// Misses in a row (while still passing scheduled challenges) before a
+// suspicious event
+const surpriseMissLimit = 3
+
+// Caller must hold s.mu
+func (s *MemoryStore) settleSurprise(node *types.NodeRegistration, passed bool) {
+	node.Surprise.PendingID = ""
+	node.Surprise.PendingExpiresAt = 0
+
+	if passed {
+		node.Surprise.Passed++
+		node.Surprise.ConsecutiveMissed = 0
+		return
+	}
+
+	node.Surprise.Missed++
+	node.Surprise.ConsecutiveMissed++
+	if node.Surprise.ConsecutiveMissed >= surpriseMissLimit && node.TotalChallengesPassed > 0 {
+		node.Surprise.ConsecutiveMissed = 0
+		s.addSuspiciousEvent(node, fmt.Sprintf("Passes scheduled challenges but missed %d surprise challenges in a row", surpriseMissLimit))
+	}
+}
+
+// How much each factor counts towards the trust score
+const (
+	trustWeightPassRate    = 0.25
+	trustWeightLatency     = 0.25
+	trustWeightIPStability = 0.15
+	trustWeightCluster     = 0.20
+	trustWeightDiversity   = 0.15
+
+	// Addresses seen within this window count against IP stability
+	trustAddressWindow = 7 * 24 * time.Hour
+)
+
+// Scale uptime points by each node's trust score (100 = full points)
+func (s *MemoryStore) SetTrustWeightedPoints(on bool) {
+	s.mu.Lock()
+	s.trustWeightedPoints = on
+	s.mu.Unlock()
+}
+
+// A node's trust score and what went into it
+func (s *MemoryStore) GetTrustScore(nodeID string) (types.TrustScore, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	node, ok := s.nodes[nodeID]
+	if !ok {
+		return types.TrustScore{}, false
+	}
+	return node.Trust, true
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) refreshTrust(node *types.NodeRegistration, now int64) {
+	node.Trust = s.trustScore(node.ID, now)
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) trustScore(nodeID string, now int64) types.TrustScore {
+	var passed, clean int
+	issued := make(map[types.ChallengeType]bool)
+	passedTypes := make(map[types.ChallengeType]bool)
+	history := s.verificationHistory[nodeID]
+	for _, result := range history {
+		if result.ChallengeType != "" {
+			issued[result.ChallengeType] = true
+		}
+		if !result.Passed {
+			continue
+		}
+		passed++
+		if !result.Suspicious {
+			clean++
+		}
+		if result.ChallengeType != "" {
+			passedTypes[result.ChallengeType] = true
+		}
+	}
+
+	// Rates start at 0.5 and move with evidence, so one early result
+	// doesn't swing a new node to either end
+	trust := types.TrustScore{
+		PassRate:    float64(passed+1) / float64(len(history)+2),
+		Latency:     float64(clean+1) / float64(passed+2),
+		IPStability: 1,
+		Cluster:     1,
+		Diversity:   1,
+		UpdatedAt:   now,
+	}
+
+	// Only answering the cheap challenge types is what a cache or a
+	// pruned proxy looks like
+	if len(issued) > 0 {
+		trust.Diversity = float64(len(passedTypes)) / float64(len(issued))
+	}
+
+	// One address is normal, a home connection may change now and then,
+	// many at once means the node isn't where it says it is
+	recent := 0
+	cutoff := now - trustAddressWindow.Milliseconds()
+	for _, seen := range s.nodeAddrs[nodeID] {
+		if seen >= cutoff {
+			recent++
+		}
+	}
+	if recent > 1 {
+		trust.IPStability = 2 / float64(recent+1)
+	}
+
+	// Falls to 0 as the wallets sharing its connection reach the threshold
+	wallets := 1
+	for _, cluster := range s.fingerprints {
+		if len(cluster.info.Wallets) > wallets && containsString(cluster.info.NodeIDs, nodeID) {
+			wallets = len(cluster.info.Wallets)
+		}
+	}
+	if wallets > 1 && s.fingerprintWallets > 1 {
+		trust.Cluster = math.Max(0, 1-float64(wallets-1)/float64(s.fingerprintWallets-1))
+	}
+
+	score := trust.PassRate*trustWeightPassRate +
+		trust.Latency*trustWeightLatency +
+		trust.IPStability*trustWeightIPStability +
+		trust.Cluster*trustWeightCluster +
+		trust.Diversity*trustWeightDiversity
+	trust.Score = uint8(math.Round(score * 100))
+	return trust
+}
+
+func containsString(items []string, item string) bool {
+	for _, existing := range items {
+		if existing == item {
+			return true
+		}
+	}
+	return false
+}
+
+// Nodes that look like they're run by the same operator as this one: same
+// wallet, same submitting address, or an RPC endpoint on the same host
+func (s *MemoryStore) GetSiblings(nodeID string) []types.SiblingNode {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	node, ok := s.nodes[nodeID]
+	if !ok {
+		return nil
+	}
+	return s.siblings(node)
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) siblings(node *types.NodeRegistration) []types.SiblingNode {
+	links := make(map[string][]types.SiblingLink)
+	link := func(id string, kind types.SiblingLink) {
+		if id != node.ID {
+			links[id] = append(links[id], kind)
+		}
+	}
+
+	for _, id := range s.nodesByWallet[node.WalletAddress] {
+		link(id, types.LinkWallet)
+	}
+
+	if addrs := s.nodeAddrs[node.ID]; len(addrs) > 0 {
+		for id, other := range s.nodeAddrs {
+			for addr := range other {
+				if _, shared := addrs[addr]; shared {
+					link(id, types.LinkIP)
+					break
+				}
+			}
+		}
+	}
+
+	if host := endpointHost(node.RPCEndpoint); host != "" {
+		for id, other := range s.nodes {
+			if endpointHost(other.RPCEndpoint) == host {
+				link(id, types.LinkEndpoint)
+			}
+		}
+	}
+
+	siblings := make([]types.SiblingNode, 0, len(links))
+	for id, kinds := range links {
+		other, ok := s.nodes[id]
+		if !ok {
+			continue
+		}
+		siblings = append(siblings, types.SiblingNode{
+			NodeID:        id,
+			WalletAddress: other.WalletAddress,
+			CheatStatus:   other.CheatStatus,
+			Links:         kinds,
+		})
+	}
+
+	sort.Slice(siblings, func(i, j int) bool {
+		return siblings[i].NodeID < siblings[j].NodeID
+	})
+	return siblings
+}
+
+// A banned node's siblings go straight to flagged, so an admin looks at
+// them too instead of having to go and find them
+//
+// Caller must hold s.mu
+func (s *MemoryStore) flagSiblings(banned *types.NodeRegistration) {
+	for _, sibling := range s.siblings(banned) {
+		node := s.nodes[sibling.NodeID]
+		if node.CheatStatus == types.StatusBanned || node.CheatStatus == types.StatusFlagged {
+			continue
+		}
+
+		kinds := make([]string, len(sibling.Links))
+		for i, kind := range sibling.Links {
+			kinds[i] = string(kind)
+		}
+		reason := fmt.Sprintf("Shares %s with banned node %s - needs review", strings.Join(kinds, ", "), banned.ID)
+
+		node.CheatStatus = types.StatusFlagged
+		node.CheatReason = reason
+		node.SuspiciousEvents = append(node.SuspiciousEvents, s.clock.Now().Format("2006-01-02 15:04")+": "+reason)
+		if len(node.SuspiciousEvents) > 20 {
+			node.SuspiciousEvents = node.SuspiciousEvents[1:]
+		}
+	}
+}
+
+// Host of an RPC endpoint, "" if there isn't one
+func endpointHost(endpoint string) string {
+	if endpoint == "" {
+		return ""
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+var ErrWalletNotBanned = errors.New("wallet is not banned")
+
+// How long a wallet stays banned per offence (0 = forever)
+func (s *MemoryStore) SetWalletBanCooldown(cooldown time.Duration) {
+	s.mu.Lock()
+	s.walletBanCooldown = cooldown
+	s.mu.Unlock()
+}
+
+// Ban a wallet by hand. A nil cooldown uses the configured one per
+// offence, 0 is permanent.
+func (s *MemoryStore) BanWallet(walletAddress, reason string, cooldown *time.Duration, now int64) *types.WalletBan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *s.manualWalletBan(walletAddress, reason, cooldown, now)
+	return &copied
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) manualWalletBan(walletAddress, reason string, cooldown *time.Duration, now int64) *types.WalletBan {
+	ban := s.banWallet(walletAddress, reason, "", now)
+	if cooldown != nil {
+		ban.ExpiresAt = 0
+		if *cooldown > 0 {
+			ban.ExpiresAt = now + cooldown.Milliseconds()
+		}
+	}
+	return ban
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) banWallet(walletAddress, reason, nodeID string, now int64) *types.WalletBan {
+	ban, ok := s.walletBans[walletAddress]
+	if !ok {
+		ban = &types.WalletBan{WalletAddress: walletAddress}
+		s.walletBans[walletAddress] = ban
+	}
+
+	// Every banned node on the wallet counts, so a wallet that was banned
+	// and came back to cheat again waits longer the next time
+	offences := ban.Offences + 1
+	if nodeID != "" {
+		banned := uint64(0)
+		for _, id := range s.nodesByWallet[walletAddress] {
+			if node, ok := s.nodes[id]; ok && node.CheatStatus == types.StatusBanned {
+				banned++
+			}
+		}
+		if banned > offences {
+			offences = banned
+		}
+	}
+
+	ban.Reason = reason
+	ban.NodeID = nodeID
+	ban.Offences = offences
+	ban.BannedAt = now
+	ban.ExpiresAt = 0
+	if s.walletBanCooldown > 0 {
+		ban.ExpiresAt = now + s.walletBanCooldown.Milliseconds()*int64(offences)
+	}
+	return ban
+}
+
+// Clearing the node a wallet ban came from means the ban was a mistake
+//
+// Caller must hold s.mu
+func (s *MemoryStore) liftNodeWalletBan(node *types.NodeRegistration) {
+	now := s.clock.Now().UnixMilli()
+	ban, ok := s.walletBans[node.WalletAddress]
+	if !ok || ban.NodeID != node.ID || !ban.Active(now) {
+		return
+	}
+	ban.ExpiresAt = now
+	if ban.Offences > 0 {
+		ban.Offences--
+	}
+}
+
+// End a wallet's ban now. Its offence count is kept.
+func (s *MemoryStore) LiftWalletBan(walletAddress string, now int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ban, ok := s.walletBans[walletAddress]
+	if !ok || !ban.Active(now) {
+		return ErrWalletNotBanned
+	}
+	ban.ExpiresAt = now
+	return nil
+}
+
+// The wallet's ban if it's still in force, nil otherwise
+func (s *MemoryStore) GetWalletBan(walletAddress string, now int64) *types.WalletBan {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ban, ok := s.walletBans[walletAddress]
+	if !ok || !ban.Active(now) {
+		return nil
+	}
+	copied := *ban
+	return &copied
+}
+
+// Every wallet ban ever made, newest first
+func (s *MemoryStore) GetWalletBans() []types.WalletBan {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bans := make([]types.WalletBan, 0, len(s.walletBans))
+	for _, ban := range s.walletBans {
+		bans = append(bans, *ban)
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].BannedAt > bans[j].BannedAt
+	})
+	return bans
+}
+
+var ErrSameAdmin = errors.New("this needs a second, different admin to confirm it")
+
+// Require a second admin to confirm a ban within this window (0 = off)
+func (s *MemoryStore) SetBanApprovalWindow(window time.Duration) {
+	s.mu.Lock()
+	s.banApprovalWindow = window
+	s.mu.Unlock()
+}
+
+// Ban a node, or with approval on, ask for it to be banned. Returns the
+// pending ban if it's waiting for another admin, nil if it took effect.
+func (s *MemoryStore) RequestNodeBan(nodeID, reason, admin string, now int64) (*types.PendingBan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.nodes[nodeID]; !ok {
+		return nil, ErrNodeNotFound
+	}
+
+	request := types.PendingBan{Kind: types.BanNode, Target: nodeID, Reason: reason, RequestedBy: admin}
+	apply, waiting, err := s.confirmBan(request, now)
+	if err != nil || waiting != nil {
+		return waiting, err
+	}
+
+	// The node may have been removed while the ban waited
+	if node, ok := s.nodes[apply.Target]; ok {
+		s.setNodeCheatStatus(node, types.StatusBanned, apply.Reason)
+	}
+	return nil, nil
+}
+
+// Ban a wallet directly, or with approval on, ask for it. Returns either
+// the pending ban or the ban that took effect.
+func (s *MemoryStore) RequestWalletBan(walletAddress, reason string, cooldownDays *uint64, admin string, now int64) (*types.PendingBan, *types.WalletBan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	request := types.PendingBan{Kind: types.BanWallet, Target: walletAddress, Reason: reason, CooldownDays: cooldownDays, RequestedBy: admin}
+	apply, waiting, err := s.confirmBan(request, now)
+	if err != nil || waiting != nil {
+		return waiting, nil, err
+	}
+
+	var cooldown *time.Duration
+	if apply.CooldownDays != nil {
+		d := time.Duration(*apply.CooldownDays) * 24 * time.Hour
+		cooldown = &d
+	}
+	copied := *s.manualWalletBan(apply.Target, apply.Reason, cooldown, now)
+	return nil, &copied, nil
+}
+
+// First request for a ban parks it; a different admin asking for the same
+// ban before it expires confirms it. Returns the request to carry out now,
+// or the pending ban that's still waiting.
+//
+// Caller must hold s.mu
+func (s *MemoryStore) confirmBan(request types.PendingBan, now int64) (apply, waiting *types.PendingBan, err error) {
+	if s.banApprovalWindow == 0 {
+		return &request, nil, nil
+	}
+
+	request.ID = string(request.Kind) + ":" + request.Target
+	if pending, ok := s.pendingBans[request.ID]; ok && now < pending.ExpiresAt {
+		if pending.RequestedBy == request.RequestedBy {
+			return nil, nil, ErrSameAdmin
+		}
+		delete(s.pendingBans, request.ID)
+		pending.ApprovedBy = request.RequestedBy
+		return pending, nil, nil
+	}
+
+	request.RequestedAt = now
+	request.ExpiresAt = now + s.banApprovalWindow.Milliseconds()
+	s.pendingBans[request.ID] = &request
+
+	copied := request
+	return nil, &copied, nil
+}
+
+// Bans waiting for a second admin, oldest first. Expired ones are dropped.
+func (s *MemoryStore) GetPendingBans(now int64) []types.PendingBan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := make([]types.PendingBan, 0, len(s.pendingBans))
+	for id, ban := range s.pendingBans {
+		if now >= ban.ExpiresAt {
+			delete(s.pendingBans, id)
+			continue
+		}
+		pending = append(pending, *ban)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].RequestedAt < pending[j].RequestedAt
+	})
+	return pending
+}
+
+// Which network this server runs. Nodes are stamped with it when they
+// register, and only nodes from the same network are ranked. Call before
+// serving.
+func (s *MemoryStore) SetNetwork(network types.Network) {
+	s.mu.Lock()
+	s.network = network
+	s.mu.Unlock()
+}
+
+func (s *MemoryStore) Network() types.Network {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.network
+}
+
+// Lowest client release nodes on each chain should run. Nodes that fall
+// below it (now, or when it's raised) get a client-outdated notification.
+func (s *MemoryStore) SetMinClientVersions(mins clientversion.Minimums) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.minClientVersions = mins
+	for _, node := range s.nodes {
+		s.checkClientVersion(node)
+	}
+}
+
+// What a node reports it runs (web3_clientVersion)
+func (s *MemoryStore) RecordClientVersion(nodeID, version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node := s.nodes[nodeID]
+	if node == nil || version == "" {
+		return
+	}
+	node.ClientVersion = version
+	s.checkClientVersion(node)
+}
+
+// Builds of the official prover releases. Nodes already on a build
+// that's no longer official (or now is) are marked straight away.
+func (s *MemoryStore) SetOfficialBuilds(builds proverbuild.Allowlist) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.officialBuilds = builds
+	for _, node := range s.nodes {
+		node.UnofficialBuild = builds.Unofficial(node.ProverBuild)
+	}
+}
+
+// The prover build a node signed its last answer with
+func (s *MemoryStore) RecordProverBuild(nodeID, build string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node := s.nodes[nodeID]
+	if node == nil || build == "" {
+		return
+	}
+	node.ProverBuild = build
+	node.UnofficialBuild = s.officialBuilds.Unofficial(build)
+}
+
+// Warn the operator once when their client drops below the minimum.
+// Caller must hold s.mu
+func (s *MemoryStore) checkClientVersion(node *types.NodeRegistration) {
+	min, outdated := s.minClientVersions.Outdated(node.NodeType.Chain(), node.ClientVersion)
+	if outdated && !node.ClientOutdated {
+		s.notifier.Notify(notify.Event{
+			Type:          notify.EventClientOutdated,
+			NodeID:        node.ID,
+			WalletAddress: node.WalletAddress,
+			Message:       fmt.Sprintf("Node runs %s, %s nodes need %s or newer", node.ClientVersion, node.NodeType.Chain(), min),
+			Timestamp:     s.clock.Now().UnixMilli(),
+		})
+	}
+	node.ClientOutdated = outdated
+	s.checkForkReadiness(node, s.clock.Now().UnixMilli())
+}
+
+// Scheduled hard forks. Nodes already running a ready release are picked
+// up straight away.
+func (s *MemoryStore) SetHardForks(forks []hardfork.Fork) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.forks = forks
+	now := s.clock.Now().UnixMilli()
+	for _, node := range s.nodes {
+		s.checkForkReadiness(node, now)
+	}
+}
+
+// Note when a node first runs a release ready for each upcoming fork on
+// its chain, and pay the early-upgrade bonus.
+// Caller must hold s.mu
+func (s *MemoryStore) checkForkReadiness(node *types.NodeRegistration, now int64) {
+	for _, fork := range s.forks {
+		if fork.Chain != node.NodeType.Chain() || !fork.Upcoming(now) {
+			continue
+		}
+		if _, seen := s.forkReady[node.ID][fork.ID()]; seen {
+			continue
+		}
+		if ready, _ := fork.Ready(node.ClientVersion); !ready {
+			continue
+		}
+
+		if s.forkReady[node.ID] == nil {
+			s.forkReady[node.ID] = make(map[string]int64)
+		}
+		s.forkReady[node.ID][fork.ID()] = now
+
+		if node.IsActive && node.CheatStatus != types.StatusFlagged && node.CheatStatus != types.StatusBanned {
+			s.credit(node, types.PointsForkBonus, fork.EarlyBonus(now)*s.network.PointsMultiplier(), fork.ID(), now)
+		}
+	}
+}
+
+// When a node was first seen ready for a fork, 0 if it hasn't been
+func (s *MemoryStore) ForkReadyAt(nodeID, forkID string) int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.forkReady[nodeID][forkID]
+}
+
+// Readiness of this network's active nodes for every scheduled fork
+func (s *MemoryStore) HardForkReadiness(now int64) []types.ForkReadiness {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	readiness := make([]types.ForkReadiness, 0, len(s.forks))
+	for _, fork := range s.forks {
+		r := types.ForkReadiness{
+			Fork:        fork.Name,
+			Chain:       fork.Chain,
+			ActivatesAt: fork.ActivatesAt,
+			Activated:   !fork.Upcoming(now),
+			Releases:    make(map[string]string, len(fork.Releases)),
+		}
+		for client, v := range fork.Releases {
+			r.Releases[client] = fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+		}
+
+		for _, node := range s.nodes {
+			if !node.IsActive || node.Network != s.network || node.NodeType.Chain() != fork.Chain {
+				continue
+			}
+			r.Nodes++
+			switch ready, known := fork.Ready(node.ClientVersion); {
+			case !known:
+				r.Unknown++
+			case ready:
+				r.Ready++
+			default:
+				r.NotReady++
+			}
+		}
+		if r.Nodes > 0 {
+			r.ReadyPercent = float64(r.Ready) / float64(r.Nodes) * 100
+		}
+		readiness = append(readiness, r)
+	}
+	return readiness
+}
+
+// How long an operator has to look at a proposed reclassification before
+// it's applied anyway
+const ReclassificationGrace = 72 * time.Hour
+
+var (
+	ErrNoReclassification = errors.New("no reclassification proposed for this node")
+	ErrReclassifyMismatch = errors.New("proposed type has changed")
+)
+
+// Propose moving a node to the type its probes say it is. A proposal
+// already open for the same type is left alone (created is false); one for
+// a different type is replaced. The operator is notified either way a new
+// proposal is made.
+func (s *MemoryStore) ProposeReclassification(nodeID string, to types.NodeType, reason string, now int64) (*types.Reclassification, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[nodeID]
+	if !ok {
+		return nil, false, ErrNodeNotFound
+	}
+
+	if open := s.reclassifications[nodeID]; open != nil && open.Status == types.ReclassificationProposed && open.To == to {
+		copied := *open
+		return &copied, false, nil
+	}
+
+	proposal := &types.Reclassification{
+		NodeID:        nodeID,
+		WalletAddress: node.WalletAddress,
+		From:          node.NodeType,
+		To:            to,
+		Reason:        reason,
+		Status:        types.ReclassificationProposed,
+		ProposedAt:    now,
+		DueAt:         now + ReclassificationGrace.Milliseconds(),
+	}
+	s.reclassifications[nodeID] = proposal
+
+	s.notifier.Notify(notify.Event{
+		Type:          notify.EventReclassification,
+		NodeID:        nodeID,
+		WalletAddress: node.WalletAddress,
+		Message:       fmt.Sprintf("Node looks like %s, not %s (%s). It will be reclassified in %d hours unless reviewed", to, node.NodeType, reason, int(ReclassificationGrace.Hours())),
+		Timestamp:     now,
+	})
+
+	copied := *proposal
+	return &copied, true, nil
+}
+
+// Latest reclassification for a node, nil if there's never been one
+func (s *MemoryStore) GetReclassification(nodeID string) *types.Reclassification {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r := s.reclassifications[nodeID]
+	if r == nil {
+		return nil
+	}
+	copied := *r
+	return &copied
+}
+
+// Reclassifications waiting to be applied, oldest first
+func (s *MemoryStore) GetOpenReclassifications() []types.Reclassification {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	open := make([]types.Reclassification, 0)
+	for _, r := range s.reclassifications {
+		if r.Status == types.ReclassificationProposed {
+			open = append(open, *r)
+		}
+	}
+	sort.Slice(open, func(i, j int) bool {
+		return open[i].ProposedAt < open[j].ProposedAt
+	})
+	return open
+}
+
+// Apply or dismiss a node's open proposal. If want is set it has to match
+// the proposed type, so an operator can't accept a proposal that changed
+// under them.
+func (s *MemoryStore) ResolveReclassification(nodeID string, apply bool, want types.NodeType, by string, now int64) (*types.Reclassification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := s.reclassifications[nodeID]
+	if r == nil || r.Status != types.ReclassificationProposed {
+		return nil, ErrNoReclassification
+	}
+	if want != "" && want != r.To {
+		return nil, ErrReclassifyMismatch
+	}
+
+	s.resolveReclassification(r, apply, by, now)
+	copied := *r
+	return &copied, nil
+}
+
+// Apply every proposal whose grace period is over. Returns the node IDs.
+func (s *MemoryStore) ApplyDueReclassifications(now int64) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var applied []string
+	for nodeID, r := range s.reclassifications {
+		if r.Status == types.ReclassificationProposed && now >= r.DueAt {
+			s.resolveReclassification(r, true, "auto", now)
+			applied = append(applied, nodeID)
+		}
+	}
+	return applied
+}
+
+// Points already earned stay; uptime points from here on use the new
+// type's rate.
+// Caller must hold s.mu
+func (s *MemoryStore) resolveReclassification(r *types.Reclassification, apply bool, by string, now int64) {
+	r.ResolvedAt = now
+	r.ResolvedBy = by
+	r.Status = types.ReclassificationDismissed
+	if !apply {
+		return
+	}
+
+	r.Status = types.ReclassificationApplied
+	if node := s.nodes[r.NodeID]; node != nil {
+		node.NodeType = r.To
+	}
+}
+
+// Take a signed challenge request's timestamp if it's newer than the
+// node's last one. A request seen in a log or proxy can't be replayed to
+// use up the node's challenges.
+func (s *MemoryStore) ClaimChallengeRequest(nodeID string, timestamp int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[nodeID]
+	if !ok || timestamp <= node.LastRequestTimestamp {
+		return false
+	}
+	node.LastRequestTimestamp = timestamp
+	return true
+}
+
+// Take a signed prover heartbeat's timestamp if it's newer than the node's
+// last one, so a captured heartbeat can't be replayed to keep it online
+func (s *MemoryStore) ClaimHeartbeat(nodeID string, timestamp int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[nodeID]
+	if !ok || timestamp <= node.LastHeartbeatTimestamp {
+		return false
+	}
+	node.LastHeartbeatTimestamp = timestamp
+	return true
+}
+
+// Cap how many challenges each node can be issued per UTC day
+func (s *MemoryStore) SetChallengeCaps(caps budget.Caps) {
+	s.mu.Lock()
+	s.challengeCaps = caps
+	s.mu.Unlock()
+}
+
+// Count a challenge a node asked for against today's caps. Counts nothing
+// and returns false if the cap for its type, or for the day, is used up.
+func (s *MemoryStore) ChargeChallenge(nodeID string, challengeType types.ChallengeType, now int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.nodes[nodeID] == nil {
+		return false
+	}
+	b := s.budgetFor(nodeID, now)
+	if !s.challengeCaps.Allows(b.Issued, challengeType) {
+		return false
+	}
+	b.Issued[challengeType]++
+	return true
+}
+
+// Whether the node's cap for the day still has room for any challenge
+func (s *MemoryStore) HasChallengeBudget(nodeID string, now int64) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.challengeCaps.TotalLeft(s.budgetToday(nodeID, now).Issued)
+}
+
+// The node's challenges for the day now falls on, with the caps
+func (s *MemoryStore) ChallengeBudget(nodeID string, now int64) types.ChallengeBudget {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.budgetToday(nodeID, now)
+}
+
+// The node's counts for the day now falls on, starting a new day if the
+// last one is over. Caller must hold s.mu for writing.
+func (s *MemoryStore) budgetFor(nodeID string, now int64) *types.ChallengeBudget {
+	day := budget.Day(now)
+	b := s.budgets[nodeID]
+	if b == nil || b.Date != day {
+		b = &types.ChallengeBudget{
+			Date:   day,
+			Issued: make(map[types.ChallengeType]uint64),
+			Passed: make(map[types.ChallengeType]uint64),
+		}
+		s.budgets[nodeID] = b
+	}
+	return b
+}
+
+// A copy of the node's counts for the day now falls on, with the caps.
+// Caller must hold s.mu.
+func (s *MemoryStore) budgetToday(nodeID string, now int64) types.ChallengeBudget {
+	today := types.ChallengeBudget{
+		Date:     budget.Day(now),
+		Issued:   make(map[types.ChallengeType]uint64),
+		Passed:   make(map[types.ChallengeType]uint64),
+		Caps:     s.challengeCaps.PerType,
+		TotalCap: s.challengeCaps.Total,
+	}
+	if b := s.budgets[nodeID]; b != nil && b.Date == today.Date {
+		for t, n := range b.Issued {
+			today.Issued[t] = n
+		}
+		for t, n := range b.Passed {
+			today.Passed[t] = n
+		}
+	}
+	return today
+}
+
+// How many entries each map holds, for watching memory growth. Per-node
+// histories count their records, not their nodes.
+func (s *MemoryStore) Sizes() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.sizes()
+}
+
+// Caller must hold s.mu
+func (s *MemoryStore) sizes() map[string]int {
+	records := func(m map[string][]*types.VerificationResult) int {
+		n := 0
+		for _, list := range m {
+			n += len(list)
+		}
+		return n
+	}
+	heartbeats := 0
+	for _, list := range s.heartbeats {
+		heartbeats += len(list)
+	}
+	ledger := 0
+	for _, entries := range s.ledger {
+		ledger += len(entries)
+	}
+	uptimeDays := 0
+	for _, days := range s.dailyUptime {
+		uptimeDays += len(days)
+	}
+	nodeAddrs := 0
+	for _, addrs := range s.nodeAddrs {
+		nodeAddrs += len(addrs)
+	}
+
+	return map[string]int{
+		"nodes":                len(s.nodes),
+		"verification_records": records(s.verificationHistory),
+		"heartbeats":           heartbeats,
+		"points_ledger":        ledger,
+		"uptime_days":          uptimeDays,
+		"replays":              len(s.replays),
+		"submission_attempts":  len(s.attempts),
+		"reports":              len(s.reports),
+		"fingerprints":         len(s.fingerprints),
+		"node_addresses":       nodeAddrs,
+		"wallet_bans":          len(s.walletBans),
+		"pending_bans":         len(s.pendingBans),
+		"reclassifications":    len(s.reclassifications),
+		"challenge_budgets":    len(s.budgets),
+		"moderation_log":       s.moderation.Len(),
+	}
+}
+
+// Rough per-entry sizes for the usage estimate. Shallow: strings and
+// nested slices inside a record aren't followed.
+const (
+	pointerBytes   = int64(unsafe.Sizeof(uintptr(0)))
+	stringBytes    = int64(unsafe.Sizeof(""))
+	nodeBytes      = int64(unsafe.Sizeof(types.NodeRegistration{}))
+	resultBytes    = int64(unsafe.Sizeof(types.VerificationResult{}))
+	heartbeatBytes = int64(unsafe.Sizeof(types.HeartbeatRecord{}))
+	replayBytes    = int64(unsafe.Sizeof(types.ChallengeReplay{}))
+	attemptBytes   = int64(unsafe.Sizeof(types.SubmissionAttempt{}))
+	pointsBytes    = int64(unsafe.Sizeof(types.PointsEntry{}))
+	uptimeDayBytes = int64(unsafe.Sizeof(uptimeDay{})) + stringBytes + int64(len("2006-01-02"))
+)
+
+// Entry counts plus an estimate of the memory the biggest collections
+// hold, counting slice capacity that compaction could give back
+func (s *MemoryStore) Usage() types.StoreUsage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	usage := types.StoreUsage{
+		Nodes:          len(s.nodes),
+		Entries:        s.sizes(),
+		EstimatedBytes: make(map[string]int64),
+		LastCompaction: s.lastCompaction,
+	}
+
+	nodes := int64(len(s.nodes)) * (nodeBytes + pointerBytes)
+	for _, node := range s.nodes {
+		nodes += int64(cap(node.SuspiciousEvents)) * stringBytes
+		for _, event := range node.SuspiciousEvents {
+			nodes += int64(len(event))
+		}
+	}
+	usage.EstimatedBytes["nodes"] = nodes
+
+	var results int64
+	for _, history := range s.verificationHistory {
+		results += int64(cap(history))*pointerBytes + int64(len(history))*resultBytes
+	}
+	usage.EstimatedBytes["verification_records"] = results
+
+	var heartbeats int64
+	for _, history := range s.heartbeats {
+		heartbeats += int64(cap(history))*pointerBytes + int64(len(history))*heartbeatBytes
+	}
+	usage.EstimatedBytes["heartbeats"] = heartbeats
+
+	var ledger int64
+	for _, entries := range s.ledger {
+		ledger += int64(cap(entries)) * pointsBytes
+	}
+	usage.EstimatedBytes["points_ledger"] = ledger
+
+	var uptime int64
+	for _, days := range s.dailyUptime {
+		uptime += int64(len(days)) * uptimeDayBytes
+	}
+	usage.EstimatedBytes["uptime_days"] = uptime
+
+	usage.EstimatedBytes["replays"] = int64(len(s.replays))*(replayBytes+pointerBytes) + int64(cap(s.replayOrder))*stringBytes
+
+	var attempts int64
+	for _, list := range s.attempts {
+		attempts += stringBytes + int64(cap(list))*attemptBytes
+	}
+	usage.EstimatedBytes["submission_attempts"] = attempts + int64(cap(s.attemptOrder))*stringBytes
+
+	for _, n := range usage.EstimatedBytes {
+		usage.TotalBytes += n
+	}
+	return usage
+}
+
+// Copy every per-node slice that has spare capacity into one that fits.
+// Histories are trimmed from the front, which leaves the old backing
+// arrays behind until the next append outgrows them.
+func (s *MemoryStore) Compact(now int64) types.Compaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := types.Compaction{At: now}
+	count := func(length, capacity int, slot int64) {
+		result.Slices++
+		result.SlotsFreed += capacity - length
+		result.BytesFreed += int64(capacity-length) * slot
+	}
+
+	for id, history := range s.verificationHistory {
+		if cap(history) > len(history) {
+			count(len(history), cap(history), pointerBytes)
+			s.verificationHistory[id] = append(make([]*types.VerificationResult, 0, len(history)), history...)
+		}
+	}
+	for id, history := range s.heartbeats {
+		if cap(history) > len(history) {
+			count(len(history), cap(history), pointerBytes)
+			s.heartbeats[id] = append(make([]*types.HeartbeatRecord, 0, len(history)), history...)
+		}
+	}
+	for _, node := range s.nodes {
+		if cap(node.SuspiciousEvents) > len(node.SuspiciousEvents) {
+			count(len(node.SuspiciousEvents), cap(node.SuspiciousEvents), stringBytes)
+			node.SuspiciousEvents = append(make([]string, 0, len(node.SuspiciousEvents)), node.SuspiciousEvents...)
+		}
+	}
+	for wallet, ids := range s.nodesByWallet {
+		if cap(ids) > len(ids) {
+			count(len(ids), cap(ids), stringBytes)
+			s.nodesByWallet[wallet] = append(make([]string, 0, len(ids)), ids...)
+		}
+	}
+	if cap(s.replayOrder) > len(s.replayOrder) {
+		count(len(s.replayOrder), cap(s.replayOrder), stringBytes)
+		s.replayOrder = append(make([]string, 0, len(s.replayOrder)), s.replayOrder...)
+	}
+
+	s.lastCompaction = &result
+	return result
+}
+
+type recomputeJob struct {
+	progress types.Recompute
+	pending  []string // Node IDs not done yet
+}
+
+// Start recomputing every node's derived stats. The caller drives it with
+// RecomputeStep, so the store lock is only held a batch at a time. False,
+// with the running job, if one is already going.
+func (s *MemoryStore) StartRecompute(now int64) (types.Recompute, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.recompute != nil && s.recompute.progress.FinishedAt == 0 {
+		return s.recompute.copy(), false
+	}
+	pending := make([]string, 0, len(s.nodes))
+	for id := range s.nodes {
+		pending = append(pending, id)
+	}
+	sort.Strings(pending)
+	s.recompute = &recomputeJob{
+		progress: types.Recompute{StartedAt: now, Total: len(pending), Grades: make(map[types.HealthGrade]int)},
+		pending:  pending,
+	}
+	if len(pending) == 0 {
+		s.recompute.progress.FinishedAt = now
+	}
+	return s.recompute.copy(), true
+}
+
+// Recompute up to batch more nodes of the running job. Nodes deleted since
+// it started are skipped.
+func (s *MemoryStore) RecomputeStep(batch int, now int64) types.Recompute {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := s.recompute
+	if job == nil {
+		return types.Recompute{}
+	}
+	for ; batch > 0 && len(job.pending) > 0; batch-- {
+		id := job.pending[0]
+		job.pending = job.pending[1:]
+		job.progress.Done++
+
+		node, ok := s.nodes[id]
+		if !ok {
+			continue
+		}
+		before := node.Trust.Score
+		s.refreshTrust(node, now)
+		if node.Trust.Score != before {
+			job.progress.TrustChanged++
+		}
+
+		health := s.health(id)
+		job.progress.Grades[health.Grade]++
+		if node.Network == s.network && s.leaderboardRules.Eligible(node, health.PassRate24h) {
+			job.progress.Eligible++
+		}
+	}
+	if len(job.pending) == 0 && job.progress.FinishedAt == 0 {
+		job.progress.FinishedAt = now
+	}
+	return job.copy()
+}
+
+// The running or last finished recompute, false if there hasn't been one
+func (s *MemoryStore) RecomputeStatus() (types.Recompute, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.recompute == nil {
+		return types.Recompute{}, false
+	}
+	return s.recompute.copy(), true
+}
+
+func (j *recomputeJob) copy() types.Recompute {
+	progress := j.progress
+	progress.Grades = make(map[types.HealthGrade]int, len(j.progress.Grades))
+	for grade, n := range j.progress.Grades {
+		progress.Grades[grade] = n
+	}
+	return progress
+}
+
+// Keys a wallet can hold at once, so creating keys isn't a way around
+// the anonymous limit
+const maxAPIKeysPerWallet = 5
+
+var (
+	ErrTooManyAPIKeys = errors.New("wallet already has the most API keys allowed - revoke one first")
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrAPIKeyRevoked  = errors.New("api key was already revoked")
+)
+
+// Issue a wallet a new API key on plan. Returns the key itself, which is
+// only kept hashed and can't be shown again.
+func (s *MemoryStore) CreateAPIKey(walletAddress, label, plan string, now int64) (string, types.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	active := 0
+	for _, key := range s.apiKeys {
+		if key.WalletAddress == walletAddress && key.RevokedAt == 0 {
+			active++
+		}
+	}
+	if active >= maxAPIKeysPerWallet {
+		return "", types.APIKey{}, ErrTooManyAPIKeys
+	}
+
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", types.APIKey{}, err
+	}
+	secret := "dpk_" + hex.EncodeToString(raw)
+	hash := hashAPIKey(secret)
+	key := &types.APIKey{
+		ID:            hash[:16],
+		WalletAddress: walletAddress,
+		Label:         label,
+		Plan:          plan,
+		CreatedAt:     now,
+		SecretHash:    hash,
+	}
+	s.apiKeys[key.ID] = key
+	return secret, *key, nil
+}
+
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// The unrevoked key a request presented, if there is one
+func (s *MemoryStore) LookupAPIKey(secret string) (types.APIKey, bool) {
+	hash := hashAPIKey(secret)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.apiKeys[hash[:16]]
+	if !ok || key.RevokedAt != 0 || subtle.ConstantTimeCompare([]byte(key.SecretHash), []byte(hash)) != 1 {
+		return types.APIKey{}, false
+	}
+	return *key, true
+}
+
+func (s *MemoryStore) GetAPIKey(id string) (types.APIKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.apiKeys[id]
+	if !ok {
+		return types.APIKey{}, false
+	}
+	return *key, true
+}
+
+// A wallet's keys, revoked ones included, oldest first
+func (s *MemoryStore) GetAPIKeys(walletAddress string) []types.APIKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []types.APIKey
+	for _, key := range s.apiKeys {
+		if key.WalletAddress == walletAddress {
+			keys = append(keys, *key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt < keys[j].CreatedAt })
+	return keys
+}
+
+func (s *MemoryStore) SetAPIKeyPlan(id, plan string) (types.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[id]
+	if !ok {
+		return types.APIKey{}, ErrAPIKeyNotFound
+	}
+	key.Plan = plan
+	return *key, nil
+}
+
+func (s *MemoryStore) RevokeAPIKey(id string, now int64) (types.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[id]
+	if !ok {
+		return types.APIKey{}, ErrAPIKeyNotFound
+	}
+	if key.RevokedAt != 0 {
+		return *key, ErrAPIKeyRevoked
+	}
+	key.RevokedAt = now
+	return *key, nil
+}
+
+var ErrAnnouncementNotFound = errors.New("announcement not found")
+
+// Post news for node operators. expiresAt 0 keeps it up until it's
+// retracted.
+func (s *MemoryStore) PostAnnouncement(kind types.AnnouncementKind, title, body string, expiresAt, now int64) types.Announcement {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextAnnouncementID++
+	a := &types.Announcement{
+		ID:        s.nextAnnouncementID,
+		Kind:      kind,
+		Title:     title,
+		Body:      body,
+		PostedAt:  now,
+		ExpiresAt: expiresAt,
+	}
+	s.announcements = append(s.announcements, a)
+	return *a
+}
+
+// Announcements that haven't expired, newest first
+func (s *MemoryStore) GetAnnouncements(now int64) []types.Announcement {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	current := make([]types.Announcement, 0, len(s.announcements))
+	for i := len(s.announcements) - 1; i >= 0; i-- {
+		if a := s.announcements[i]; a.ExpiresAt == 0 || a.ExpiresAt > now {
+			current = append(current, *a)
+		}
+	}
+	return current
+}
+
+func (s *MemoryStore) RetractAnnouncement(id uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, a := range s.announcements {
+		if a.ID == id {
+			s.announcements = append(s.announcements[:i], s.announcements[i+1:]...)
+			return nil
+		}
+	}
+	return ErrAnnouncementNotFound
+}
+
+var ErrNotInMaintenance = errors.New("service is not in maintenance")
+
+// Put the whole service in maintenance, or change the reason or expected
+// end of the one under way. Until it's ended, nodes aren't made inactive
+// for going quiet, uptime targets aren't enforced and unanswered surprise
+// challenges aren't counted as missed: nodes can't reach the service, so
+// none of that is their doing.
+func (s *MemoryStore) StartServiceMaintenance(reason string, until, now int64) types.ServiceMaintenance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maintenance == nil {
+		s.maintenance = &types.ServiceMaintenance{StartedAt: now}
+	}
+	s.maintenance.Reason = reason
+	s.maintenance.Until = until
+	return *s.maintenance
+}
+
+// End maintenance, returning what it was
+func (s *MemoryStore) EndServiceMaintenance(now int64) (types.ServiceMaintenance, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maintenance == nil {
+		return types.ServiceMaintenance{}, ErrNotInMaintenance
+	}
+	ended := *s.maintenance
+	s.maintenance = nil
+	s.maintenanceEndedAt = now
+	return ended, nil
+}
+
+// The maintenance under way, nil if there isn't one
+func (s *MemoryStore) ServiceMaintenance() *types.ServiceMaintenance {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.maintenance == nil {
+		return nil
+	}
+	m := *s.maintenance
+	return &m
+}
+
+var (
+	ErrTOTPEnabled     = errors.New("two-factor is already on for this wallet - turn it off first")
+	ErrTOTPNotEnrolled = errors.New("no two-factor enrollment to confirm")
+	ErrTOTPNotEnabled  = errors.New("two-factor isn't on for this wallet")
+	ErrTOTPRequired    = errors.New("this wallet has two-factor on - send totp_code")
+	ErrTOTPInvalid     = errors.New("wrong or already used two-factor code")
+	ErrTOTPLocked      = errors.New("too many wrong two-factor codes - try again later")
+)
+
+// Whoever steals a wallet key can sign as many requests as they like, so
+// wrong codes are what's limited: after totpMaxFailures in a row no code is
+// taken for totpLockout, doubling with each wrong code after that.
+const (
+	totpMaxFailures = 5
+	totpLockout     = 15 * time.Minute
+	totpMaxLockout  = 24 * time.Hour
+)
+
+// Start enrolling a wallet in two-factor with an authenticator secret.
+// Nothing is asked for until it's confirmed with a code, so a secret that
+// never made it into the app can't lock the wallet out. Enrolling again
+// before confirming replaces the secret.
+func (s *MemoryStore) EnrollTOTP(walletAddress, secret string, now int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current := s.walletTOTP[walletAddress]; current != nil && current.ConfirmedAt != 0 {
+		return ErrTOTPEnabled
+	}
+	s.walletTOTP[walletAddress] = &types.WalletTOTP{Secret: secret, CreatedAt: now}
+	return nil
+}
+
+// Turn two-factor on with the first code from the authenticator
+func (s *MemoryStore) ConfirmTOTP(walletAddress, code string, now int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.walletTOTP[walletAddress]
+	if current == nil || current.ConfirmedAt != 0 {
+		return ErrTOTPNotEnrolled
+	}
+	if err := useTOTPCode(current, code, now); err != nil {
+		return err
+	}
+	current.ConfirmedAt = now
+	return nil
+}
+
+// Check the second factor for an action the wallet signed. Nil if the
+// wallet has no two-factor on. Each code works once.
+func (s *MemoryStore) CheckTOTP(walletAddress, code string, now int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.walletTOTP[walletAddress]
+	if current == nil || current.ConfirmedAt == 0 {
+		return nil
+	}
+	if code == "" {
+		return ErrTOTPRequired
+	}
+	return useTOTPCode(current, code, now)
+}
+
+// Turn two-factor off, with a code to show it's the owner
+func (s *MemoryStore) DisableTOTP(walletAddress, code string, now int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.walletTOTP[walletAddress]
+	if current == nil || current.ConfirmedAt == 0 {
+		return ErrTOTPNotEnabled
+	}
+	if err := useTOTPCode(current, code, now); err != nil {
+		return err
+	}
+	delete(s.walletTOTP, walletAddress)
+	return nil
+}
+
+// Remove a wallet's two-factor without a code, for an admin helping an
+// operator who lost their authenticator. With ban approval on it waits for
+// a second admin like a ban does, and the pending request is returned; nil
+// if it took effect.
+func (s *MemoryStore) RequestTOTPReset(walletAddress, reason, admin string, now int64) (*types.PendingBan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.walletTOTP[walletAddress]; !ok {
+		return nil, ErrTOTPNotEnabled
+	}
+
+	request := types.PendingBan{Kind: types.BanTOTPReset, Target: walletAddress, Reason: reason, RequestedBy: admin}
+	apply, waiting, err := s.confirmBan(request, now)
+	if err != nil || waiting != nil {
+		return waiting, err
+	}
+	delete(s.walletTOTP, apply.Target)
+	return nil, nil
+}
+
+// Whether the wallet has two-factor on, and since when
+func (s *MemoryStore) TOTPStatus(walletAddress string) (enabled bool, since int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	current := s.walletTOTP[walletAddress]
+	if current == nil || current.ConfirmedAt == 0 {
+		return false, 0
+	}
+	return true, current.ConfirmedAt
+}
+
+// Accept code if it's current and newer than the last one used, and the
+// wallet isn't locked out. Wrong codes count towards a lockout; a right one
+// clears the count.
+// Caller must hold s.mu
+func useTOTPCode(current *types.WalletTOTP, code string, now int64) error {
+	if now < current.LockedUntil {
+		return ErrTOTPLocked
+	}
+	step, ok := totp.Match(current.Secret, code, time.UnixMilli(now))
+	if !ok || step <= current.LastStep {
+		current.Failures++
+		if extra := current.Failures - totpMaxFailures; extra >= 0 {
+			lockout := totpMaxLockout
+			if extra < 7 && totpLockout<<extra < totpMaxLockout { // 7 doublings is past the cap
+				lockout = totpLockout << extra
+			}
+			current.LockedUntil = now + lockout.Milliseconds()
+		}
+		return ErrTOTPInvalid
+	}
+	current.LastStep = step
+	current.Failures = 0
+	return nil
+}
+
+var (
+	ErrEpochNotAfter = errors.New("an epoch has to end after the last one")
+	ErrEpochFuture   = errors.New("an epoch can't end in the future")
+	ErrEpochEmpty    = errors.New("no new points to pay out")
+)
+
+// Close the next reward epoch at until: each wallet gets its points from
+// ledger entries up to then, less what it was paid in earlier epochs.
+// Banned nodes' points are left out, and wallets that aren't plain
+// addresses can't be minted to. A wallet whose points went down since it
+// was last paid gets nothing until they're back above what it was paid.
+func (s *MemoryStore) CloseEpoch(until, now int64) (*types.RewardEpoch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if until > now {
+		return nil, ErrEpochFuture
+	}
+	if n := len(s.epochs); n > 0 && until <= s.epochs[n-1].Until {
+		return nil, ErrEpochNotAfter
+	}
+
+	var since int64
+	if n := len(s.epochs); n > 0 {
+		since = s.epochs[n-1].Until
+	}
+
+	// What the stake bonus paid comes from the entries this epoch adds,
+	// summed per bonus percent before working out its share
+	type staked struct {
+		wallet  string
+		percent uint64
+	}
+	earned := make(map[string]int64)
+	stakedPoints := make(map[staked]uint64)
+	for _, node := range s.nodes {
+		if node.CheatStatus == types.StatusBanned || !common.IsHexAddress(node.WalletAddress) {
+			continue
+		}
+		for _, entry := range s.ledger[node.ID] {
+			if entry.Timestamp > until {
+				continue
+			}
+			earned[node.WalletAddress] += entry.Amount
+			if entry.Timestamp > since && entry.StakeBonusPercent > 0 && entry.Amount > 0 {
+				stakedPoints[staked{node.WalletAddress, entry.StakeBonusPercent}] += uint64(entry.Amount)
+			}
+		}
+	}
+	stakeBonus := make(map[string]uint64)
+	for key, points := range stakedPoints {
+		stakeBonus[key.wallet] += points * key.percent / (100 + key.percent)
+	}
+	for _, epoch := range s.epochs {
+		for _, claim := range epoch.Claims {
+			earned[claim.WalletAddress] -= int64(claim.Amount)
+		}
+	}
+
+	var claims []rewards.Claim
+	var total uint64
+	for wallet, amount := range earned {
+		if amount > 0 {
+			claims = append(claims, rewards.Claim{Account: wallet, Amount: uint64(amount)})
+			total += uint64(amount)
+		}
+	}
+	if len(claims) == 0 {
+		return nil, ErrEpochEmpty
+	}
+	sort.Slice(claims, func(i, j int) bool {
+		return claims[i].Account < claims[j].Account
+	})
+
+	epoch := &types.RewardEpoch{
+		Epoch:    uint64(len(s.epochs)) + 1,
+		Until:    until,
+		ClosedAt: now,
+		Total:    total,
+		Claims:   make([]types.RewardClaim, len(claims)),
+	}
+	root, proofs := rewards.Tree(claims, epoch.Epoch)
+	epoch.MerkleRoot = root
+	for i, claim := range claims {
+		bonus := stakeBonus[claim.Account]
+		if bonus > claim.Amount {
+			bonus = claim.Amount
+		}
+		epoch.Claims[i] = types.RewardClaim{WalletAddress: claim.Account, Amount: claim.Amount, Epoch: epoch.Epoch, Proof: proofs[i], StakeBonus: bonus}
+	}
+	s.epochs = append(s.epochs, epoch)
+
+	e := *epoch
+	return &e, nil
+}
+
+// Every closed epoch, oldest first, without its claims
+func (s *MemoryStore) GetEpochs() []types.RewardEpoch {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	epochs := make([]types.RewardEpoch, len(s.epochs))
+	for i, epoch := range s.epochs {
+		epochs[i] = *epoch
+		epochs[i].Claims = nil
+	}
+	return epochs
+}
+
+// A closed epoch with every claim in it, nil if there's no such epoch
+func (s *MemoryStore) GetEpoch(epoch uint64) *types.RewardEpoch {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if epoch == 0 || epoch > uint64(len(s.epochs)) {
+		return nil
+	}
+	e := *s.epochs[epoch-1]
+	return &e
+}
+
+// A wallet's claim in every epoch it has one, oldest first
+func (s *MemoryStore) GetRewardClaims(walletAddress string) []types.RewardClaim {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	claims := []types.RewardClaim{}
+	for _, epoch := range s.epochs {
+		i := sort.Search(len(epoch.Claims), func(i int) bool {
+			return epoch.Claims[i].WalletAddress >= walletAddress
+		})
+		if i < len(epoch.Claims) && epoch.Claims[i].WalletAddress == walletAddress {
+			claims = append(claims, epoch.Claims[i])
+		}
+	}
+	return claims
 }
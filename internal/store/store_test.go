@@ -1,8 +1,25 @@
 package store
 
 import (
+	"bytes"
+	"fmt"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
-
+	"time"
+
+	"github.com/depinonbnb/depin/internal/budget"
+	"github.com/depinonbnb/depin/internal/clientversion"
+	"github.com/depinonbnb/depin/internal/clock"
+	"github.com/depinonbnb/depin/internal/hardfork"
+	"github.com/depinonbnb/depin/internal/modlog"
+	"github.com/depinonbnb/depin/internal/notify"
+	"github.com/depinonbnb/depin/internal/rates"
+	"github.com/depinonbnb/depin/internal/rewards"
+	"github.com/depinonbnb/depin/internal/staking"
+	"github.com/depinonbnb/depin/internal/totp"
 	"github.com/depinonbnb/depin/internal/types"
 )
 
@@ -168,6 +185,20 @@ func TestRecordVerificationResult(t *testing.T) {
 	if updated.TotalChallengesFailed != 1 {
 		t.Errorf("expected 1 failed challenge, got %d", updated.TotalChallengesFailed)
 	}
+
+	// A composite with two of three parts right is credited per part
+	s.RecordVerificationResult(&types.VerificationResult{
+		ChallengeID: "challenge3",
+		NodeID:      node.ID,
+		Passed:      false,
+		Parts:       []types.PartResult{{Passed: true}, {Passed: false}, {Passed: true}},
+		Timestamp:   3000,
+	})
+
+	updated = s.GetNode(node.ID)
+	if updated.TotalChallengesPassed != 3 || updated.TotalChallengesFailed != 2 {
+		t.Errorf("expected 3 passed and 2 failed, got %d and %d", updated.TotalChallengesPassed, updated.TotalChallengesFailed)
+	}
 }
 
 func TestSuspiciousActivityTracking(t *testing.T) {
@@ -281,6 +312,93 @@ func TestAwardUptimePoints(t *testing.T) {
 	}
 }
 
+func TestPointsRates(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xtest", types.BscArchive, types.LocalProver, "", "")
+	before := s.GetNode(node.ID).TotalPoints
+
+	s.SetPointsRates(rates.Rates{types.BscArchive: 120, types.BscFull: 0})
+	if got := s.PointsPerHour(types.BscArchive); got != 120 {
+		t.Errorf("expected the override, got %d", got)
+	}
+	if got := s.PointsPerHour(types.OpbnbFast); got != types.OpbnbFast.PointsPerHour() {
+		t.Errorf("expected the built-in rate without an override, got %d", got)
+	}
+
+	s.AwardUptimePoints(node.ID, 5)
+	if got := s.GetNode(node.ID).TotalPoints - before; got != 10 {
+		t.Errorf("expected 120/h = 10 per interval, got %d", got)
+	}
+
+	full := s.RegisterNode("0xother", types.BscFull, types.LocalProver, "", "")
+	fullBefore := s.GetNode(full.ID).TotalPoints
+	s.AwardUptimePoints(full.ID, 5)
+	if got := s.GetNode(full.ID).TotalPoints; got != fullBefore {
+		t.Errorf("a rate of 0 should earn nothing, got %d more", got-fullBefore)
+	}
+}
+
+func TestTestnetPoints(t *testing.T) {
+	s := NewStore()
+	s.SetNetwork(types.Testnet)
+
+	node := s.RegisterNode("0xtest", types.BscFull, types.LocalProver, "", "")
+	if node.Network != types.Testnet {
+		t.Errorf("expected node stamped with testnet, got %q", node.Network)
+	}
+	if node.TotalPoints != 500 {
+		t.Errorf("expected 10x registration bonus (500), got %d", node.TotalPoints)
+	}
+
+	s.AwardUptimePoints(node.ID, 5)
+	if got := s.GetNode(node.ID).TotalPoints; got != 510 {
+		t.Errorf("expected 10x uptime points (510), got %d", got)
+	}
+}
+
+func TestCompensateOutage(t *testing.T) {
+	s := NewStore()
+	s.SetPointsRates(rates.Rates{types.BscArchive: 120})
+
+	node := s.RegisterNode("0xtest", types.BscArchive, types.LocalProver, "", "")
+	flagged := s.RegisterNode("0xflagged", types.BscArchive, types.LocalProver, "", "")
+	s.SetNodeCheatStatus(flagged.ID, types.StatusFlagged, "test")
+
+	start := time.Now().Add(time.Minute).UnixMilli()
+	outage := types.Outage{Start: start, End: start + 30*60*1000, Cause: types.OutageUnresponsive}
+
+	before := s.GetNode(node.ID).TotalPoints
+	credited := s.CompensateOutage(outage, outage.End)
+	if len(credited) != 1 || credited[0] != node.ID {
+		t.Fatalf("expected only the clean node credited, got %v", credited)
+	}
+	// 120/h over 30 minutes
+	if got := s.GetNode(node.ID).TotalPoints - before; got != 60 {
+		t.Errorf("expected 60 points, got %d", got)
+	}
+
+	comp := s.GetNodeStats(node.ID).OutageCompensation
+	if comp == nil || comp.Outages != 1 || comp.Minutes != 30 || comp.Points != 60 {
+		t.Errorf("unexpected compensation in stats: %+v", comp)
+	}
+	if s.GetNodeStats(flagged.ID).OutageCompensation != nil {
+		t.Error("flagged node shouldn't show compensation")
+	}
+
+	// Nodes registered after it started weren't losing anything
+	if credited := s.CompensateOutage(types.Outage{Start: start - 10*60*1000, End: start}, start); len(credited) != 0 {
+		t.Errorf("expected nodes registered mid-outage skipped, got %v", credited)
+	}
+
+	ledger := s.PointsLedger(node.ID)
+	if _, err := s.ReversePoints(node.ID, ledger[len(ledger)-1].ID, "test", start); err != nil {
+		t.Fatal(err)
+	}
+	if comp := s.GetNodeStats(node.ID).OutageCompensation; comp.Points != 0 {
+		t.Errorf("expected the reversal netted out, got %+v", comp)
+	}
+}
+
 func TestAwardUptimePointsNotForFlagged(t *testing.T) {
 	s := NewStore()
 
@@ -374,3 +492,2104 @@ func TestGetNodeStats(t *testing.T) {
 		t.Errorf("expected clean status, got %s", stats.CheatStatus)
 	}
 }
+
+func TestCustomEscalationThresholds(t *testing.T) {
+	s := NewStore()
+	s.SetEscalationThresholds(1, 3)
+
+	node := s.RegisterNode("0xtest", types.BscFull, types.LocalProver, "", "")
+
+	s.AddSuspiciousEvent(node.ID, "first")
+	if s.GetNode(node.ID).CheatStatus != types.StatusWarning {
+		t.Errorf("expected warning after 1 event with threshold 1, got %s", s.GetNode(node.ID).CheatStatus)
+	}
+
+	s.AddSuspiciousEvent(node.ID, "second")
+	s.AddSuspiciousEvent(node.ID, "third")
+	if s.GetNode(node.ID).CheatStatus != types.StatusFlagged {
+		t.Errorf("expected flagged after 3 events with threshold 3, got %s", s.GetNode(node.ID).CheatStatus)
+	}
+}
+
+func TestGetPassRatesByNodeType(t *testing.T) {
+	s := NewStore()
+
+	full := s.RegisterNode("0x1", types.BscFull, types.LocalProver, "", "")
+	s.RegisterNode("0x2", types.BscArchive, types.LocalProver, "", "")
+
+	for _, passed := range []bool{true, true, true, false} {
+		s.RecordVerificationResult(&types.VerificationResult{NodeID: full.ID, Passed: passed})
+	}
+
+	rates := s.GetPassRatesByNodeType()
+
+	if rates[types.BscFull].Passed != 3 || rates[types.BscFull].Failed != 1 {
+		t.Errorf("unexpected bsc-full counts: %+v", rates[types.BscFull])
+	}
+	if rates[types.BscFull].Rate != 75 {
+		t.Errorf("expected 75%% pass rate, got %f", rates[types.BscFull].Rate)
+	}
+	if rates[types.BscArchive].Rate != 0 {
+		t.Errorf("node type with no challenges should have 0 rate, got %f", rates[types.BscArchive].Rate)
+	}
+}
+
+func TestCountByCheatStatus(t *testing.T) {
+	s := NewStore()
+
+	s.RegisterNode("0x1", types.BscFull, types.LocalProver, "", "")
+	banned := s.RegisterNode("0x2", types.BscFull, types.LocalProver, "", "")
+	s.SetNodeCheatStatus(banned.ID, types.StatusBanned, "cheating")
+
+	counts := s.CountByCheatStatus()
+
+	if counts[types.StatusClean] != 1 || counts[types.StatusBanned] != 1 {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+	if _, ok := counts[types.StatusFlagged]; !ok {
+		t.Error("every status should be present, even at zero")
+	}
+}
+
+func TestGetWalletStatsBatch(t *testing.T) {
+	s := NewStore()
+
+	s.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+	s.RegisterNode("0xa", types.BscFast, types.LocalProver, "", "")
+	s.RegisterNode("0xb", types.BscArchive, types.LocalProver, "", "")
+
+	stats := s.GetWalletStatsBatch([]string{"0xa", "0xb", "0xmissing"})
+
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 wallets, got %d", len(stats))
+	}
+	if stats["0xa"].TotalNodes != 2 {
+		t.Errorf("expected 2 nodes for 0xa, got %d", stats["0xa"].TotalNodes)
+	}
+	if stats["0xb"].TotalPoints != 100 {
+		t.Errorf("expected 100 points for 0xb, got %d", stats["0xb"].TotalPoints)
+	}
+	if _, ok := stats["0xmissing"]; ok {
+		t.Error("unknown wallet should not be in the result")
+	}
+}
+
+func TestGetUptimeCalendar(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xtest", types.BscFull, types.ExposedRPC, "http://test", "")
+
+	day := func(d, hour int) int64 {
+		return time.Date(2024, time.February, d, hour, 0, 0, 0, time.UTC).UnixMilli()
+	}
+
+	// Feb 3: 3 of 4 checks up
+	s.RecordHeartbeat(&types.HeartbeatRecord{NodeID: node.ID, Timestamp: day(3, 1), IsSynced: true})
+	s.RecordHeartbeat(&types.HeartbeatRecord{NodeID: node.ID, Timestamp: day(3, 2), IsSynced: true})
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: node.ID, Timestamp: day(3, 3), Passed: true})
+	s.RecordMissedHeartbeat(node.ID, day(3, 4))
+
+	// Next month - shouldn't show up
+	s.RecordHeartbeat(&types.HeartbeatRecord{NodeID: node.ID, Timestamp: day(30, 1), IsSynced: true})
+
+	calendar := s.GetUptimeCalendar(node.ID, 2024, time.February)
+
+	if len(calendar) != 29 {
+		t.Fatalf("expected 29 days in Feb 2024, got %d", len(calendar))
+	}
+	if calendar[2].Date != "2024-02-03" || calendar[2].Checks != 4 || calendar[2].UptimePercent != 75 {
+		t.Errorf("unexpected Feb 3 entry: %+v", calendar[2])
+	}
+	if calendar[0].Checks != 0 {
+		t.Errorf("Feb 1 had no checks, got %+v", calendar[0])
+	}
+}
+
+func TestUptimeCalendarRetention(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xtest", types.BscFull, types.ExposedRPC, "http://test", "")
+
+	old := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	s.RecordHeartbeat(&types.HeartbeatRecord{NodeID: node.ID, Timestamp: old.UnixMilli(), IsSynced: true})
+	s.RecordHeartbeat(&types.HeartbeatRecord{NodeID: node.ID, Timestamp: old.AddDate(2, 0, 0).UnixMilli(), IsSynced: true})
+
+	if s.GetUptimeCalendar(node.ID, 2022, time.January)[0].Checks != 0 {
+		t.Error("days past retention should be dropped")
+	}
+}
+
+func TestFailureCounts(t *testing.T) {
+	s := NewStore()
+	a := s.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+	b := s.RegisterNode("0xb", types.BscFull, types.LocalProver, "", "")
+
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: a.ID, FailureKind: types.FailureExpired})
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: a.ID, FailureKind: types.FailureExpired})
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: a.ID, Passed: true})
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: b.ID, FailureKind: types.FailureWrongAnswer})
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: b.ID}) // No kind given
+
+	stats := s.GetNodeStats(a.ID)
+	if stats.FailuresByKind[types.FailureExpired] != 2 || len(stats.FailuresByKind) != 1 {
+		t.Errorf("unexpected failures for node a: %v", stats.FailuresByKind)
+	}
+
+	network := s.GetNetworkFailureCounts()
+	if network[types.FailureExpired] != 2 || network[types.FailureWrongAnswer] != 1 || network[types.FailureOther] != 1 {
+		t.Errorf("unexpected network failures: %v", network)
+	}
+}
+
+func TestLatencyPercentiles(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xtest", types.BscFull, types.LocalProver, "", "")
+
+	now := time.Now().UnixMilli()
+
+	// Bimodal: 90 fast answers, 10 slow ones - the average hides this
+	for i := 0; i < 90; i++ {
+		s.RecordVerificationResult(&types.VerificationResult{NodeID: node.ID, Passed: true, ResponseTimeMs: 40, Timestamp: now})
+	}
+	for i := 0; i < 10; i++ {
+		s.RecordVerificationResult(&types.VerificationResult{NodeID: node.ID, Passed: true, ResponseTimeMs: 900, Timestamp: now})
+	}
+
+	// Outside the 1h window but inside 24h
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: node.ID, Passed: true, ResponseTimeMs: 5000, Timestamp: now - 2*60*60*1000})
+
+	stats := s.GetNodeStats(node.ID)
+
+	hour := stats.LatencyPercentiles["1h"]
+	if hour.Samples != 100 || hour.P50 != 40 || hour.P90 != 40 || hour.P99 != 900 {
+		t.Errorf("unexpected 1h percentiles: %+v", hour)
+	}
+
+	day := stats.LatencyPercentiles["24h"]
+	if day.Samples != 101 || day.P99 != 900 {
+		t.Errorf("unexpected 24h percentiles: %+v", day)
+	}
+
+	if s.GetLatencyPercentiles(node.ID) != day {
+		t.Error("admin latency should match the 24h window")
+	}
+}
+
+func TestPercentilesEmpty(t *testing.T) {
+	if p := percentiles(nil); p.Samples != 0 || p.P99 != 0 {
+		t.Errorf("expected zero percentiles, got %+v", p)
+	}
+}
+
+func TestChallengeReplay(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xtest", types.BscFull, types.LocalProver, "", "")
+
+	s.RecordVerificationResult(&types.VerificationResult{
+		ChallengeID:    "c1",
+		NodeID:         node.ID,
+		FailureReason:  "incorrect answer",
+		FailureKind:    types.FailureWrongAnswer,
+		ResponseTimeMs: 42,
+		Timestamp:      1000,
+		Replay: &types.ChallengeReplay{
+			Challenge:       types.Challenge{ID: "c1"},
+			ExpectedAnswer:  "0xa",
+			SubmittedAnswer: "0xb",
+		},
+	})
+
+	// Passed results are never kept
+	s.RecordVerificationResult(&types.VerificationResult{
+		ChallengeID: "c2",
+		NodeID:      node.ID,
+		Passed:      true,
+		Replay:      &types.ChallengeReplay{},
+	})
+
+	replay := s.GetChallengeReplay("c1")
+	if replay == nil {
+		t.Fatal("expected replay for c1")
+	}
+	if replay.FailureKind != types.FailureWrongAnswer || replay.ResponseTimeMs != 42 || replay.VerifiedAt != 1000 {
+		t.Errorf("result details should be copied into the replay: %+v", replay)
+	}
+	if s.GetChallengeReplay("c2") != nil {
+		t.Error("passed challenges should not be kept")
+	}
+}
+
+func TestPauseAndResume(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xtest", types.BscFull, types.LocalProver, "", "")
+
+	start := time.Date(2024, time.May, 10, 12, 0, 0, 0, time.UTC).UnixMilli()
+
+	if _, err := s.PauseNode(node.ID, start); err != nil {
+		t.Fatalf("pause failed: %v", err)
+	}
+	if _, err := s.PauseNode(node.ID, start); err != ErrAlreadyPaused {
+		t.Errorf("expected ErrAlreadyPaused, got %v", err)
+	}
+
+	// 90 minutes and a bit - rounds up to 91
+	resumed, err := s.ResumeNode(node.ID, start+90*60*1000+1)
+	if err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+	if resumed.Paused || resumed.MaintenanceUsedMinutes != 91 {
+		t.Errorf("unexpected node after resume: paused=%v used=%d", resumed.Paused, resumed.MaintenanceUsedMinutes)
+	}
+	if RemainingMaintenanceMinutes(resumed) != types.MaintenanceAllowanceMinutes-91 {
+		t.Errorf("unexpected remaining allowance: %d", RemainingMaintenanceMinutes(resumed))
+	}
+
+	if _, err := s.ResumeNode(node.ID, start); err != ErrNotPaused {
+		t.Errorf("expected ErrNotPaused, got %v", err)
+	}
+	if _, err := s.PauseNode("missing", start); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+}
+
+func TestChangeVerificationMethod(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xtest", types.BscFull, types.LocalProver, "", "")
+	s.AwardUptimePoints(node.ID, 5)
+	s.UpdateNode(node.ID, func(n *types.NodeRegistration) {
+		n.LastPolledAt = 1000
+		n.PollIntervalMs = 60000
+	})
+	before := *s.GetNode(node.ID)
+
+	updated, err := s.ChangeVerificationMethod(node.ID, types.ExposedRPC, "http://10.0.0.5:8545", "secret", 5000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.RPCEndpoint != "http://10.0.0.5:8545" || updated.AuthToken != "secret" || updated.MethodChangedAt != 5000 {
+		t.Errorf("unexpected node after switching to exposed-rpc: %+v", updated)
+	}
+	if updated.LastPolledAt != 0 || updated.PollIntervalMs != 0 {
+		t.Error("prover polling stats should be cleared")
+	}
+	if updated.TotalPoints != before.TotalPoints || updated.TotalUptimeMinutes != before.TotalUptimeMinutes || len(s.PointsLedger(node.ID)) != 2 {
+		t.Error("points and history should be kept")
+	}
+
+	s.RecordClientVersion(node.ID, "Geth/v1.4.15")
+	s.UpdateNode(node.ID, func(n *types.NodeRegistration) { n.ClientCert = &types.ClientCert{Fingerprint: "ab"} })
+	updated, _ = s.ChangeVerificationMethod(node.ID, types.LocalProver, "http://ignored:8545", "ignored", 6000)
+	if updated.RPCEndpoint != "" || updated.AuthToken != "" || updated.ClientVersion != "" || updated.ClientCert != nil {
+		t.Errorf("the old endpoint and what it reported should be cleared, got %+v", updated)
+	}
+
+	if _, err := s.ChangeVerificationMethod(node.ID, types.LocalProver, "", "", 7000); err != ErrSameMethod {
+		t.Errorf("expected ErrSameMethod, got %v", err)
+	}
+	s.UpdateNode(node.ID, func(n *types.NodeRegistration) { n.CheatStatus = types.StatusBanned })
+	if _, err := s.ChangeVerificationMethod(node.ID, types.ExposedRPC, "http://10.0.0.5:8545", "", 7000); err != ErrNodeBanned {
+		t.Errorf("expected ErrNodeBanned, got %v", err)
+	}
+	if _, err := s.ChangeVerificationMethod("missing", types.ExposedRPC, "", "", 7000); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+}
+
+func TestMaintenanceAllowance(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xtest", types.BscFull, types.LocalProver, "", "")
+
+	start := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	allowance := time.Duration(types.MaintenanceAllowanceMinutes) * time.Minute
+
+	s.PauseNode(node.ID, start.UnixMilli())
+
+	if resumed := s.ExpireMaintenance(start.Add(time.Hour).UnixMilli()); len(resumed) != 0 {
+		t.Errorf("node still has allowance, should stay paused: %v", resumed)
+	}
+
+	resumed := s.ExpireMaintenance(start.Add(allowance).UnixMilli())
+	if len(resumed) != 1 || s.GetNode(node.ID).Paused {
+		t.Fatalf("node should be resumed once the allowance is used up")
+	}
+
+	if _, err := s.PauseNode(node.ID, start.Add(allowance+time.Hour).UnixMilli()); err != ErrMaintenanceExhausted {
+		t.Errorf("expected ErrMaintenanceExhausted, got %v", err)
+	}
+
+	// New month, fresh allowance
+	if _, err := s.PauseNode(node.ID, start.AddDate(0, 1, 0).UnixMilli()); err != nil {
+		t.Errorf("allowance should reset next month: %v", err)
+	}
+}
+
+func TestNoUptimePointsWhilePaused(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xtest", types.BscFull, types.LocalProver, "", "")
+	before := node.TotalPoints
+
+	s.PauseNode(node.ID, time.Now().UnixMilli())
+	s.AwardUptimePoints(node.ID, 5)
+
+	if s.GetNode(node.ID).TotalPoints != before {
+		t.Error("paused nodes should not earn uptime points")
+	}
+}
+
+type recordingNotifier struct {
+	events []notify.Event
+}
+
+func (r *recordingNotifier) Notify(event notify.Event) {
+	r.events = append(r.events, event)
+}
+
+func TestFlagImminentNotification(t *testing.T) {
+	s := NewStore() // warning at 2, flagged at 5
+	notifier := &recordingNotifier{}
+	s.SetNotifier(notifier)
+
+	node := s.RegisterNode("0xtest", types.BscFull, types.LocalProver, "", "")
+
+	for i := 0; i < 3; i++ {
+		s.AddSuspiciousEvent(node.ID, "slow")
+	}
+	if len(notifier.events) != 0 {
+		t.Fatalf("no notification expected yet, got %d", len(notifier.events))
+	}
+	if len(s.GetWalletStats("0xtest").PendingFlags) != 0 {
+		t.Error("node is not one step from flagged yet")
+	}
+
+	// 4th event - one more will flag it
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: node.ID, Passed: true, Suspicious: true, SuspiciousNote: "slow"})
+
+	if len(notifier.events) != 1 || notifier.events[0].Type != notify.EventFlagImminent {
+		t.Fatalf("expected one flag-imminent event, got %+v", notifier.events)
+	}
+	if notifier.events[0].WalletAddress != "0xtest" || notifier.events[0].FlagThreshold != 5 {
+		t.Errorf("unexpected event: %+v", notifier.events[0])
+	}
+
+	pending := s.GetWalletStats("0xtest").PendingFlags
+	if len(pending) != 1 || pending[0] != node.ID {
+		t.Errorf("expected node in pending_flags, got %v", pending)
+	}
+
+	// Flagged now - no longer pending, no repeat notification
+	s.AddSuspiciousEvent(node.ID, "slow")
+	if len(notifier.events) != 1 {
+		t.Errorf("should only notify once, got %d events", len(notifier.events))
+	}
+	if len(s.GetWalletStats("0xtest").PendingFlags) != 0 {
+		t.Error("flagged node should not be pending")
+	}
+}
+
+func TestClientOutdatedNotification(t *testing.T) {
+	s := NewStore()
+	notifier := &recordingNotifier{}
+	s.SetNotifier(notifier)
+
+	node := s.RegisterNode("0xtest", types.BscFull, types.ExposedRPC, "http://node", "")
+	s.RecordHeartbeat(&types.HeartbeatRecord{NodeID: node.ID, Timestamp: time.Now().UnixMilli(), ClientVersion: "Geth/v1.4.10-abc/linux-amd64/go1.21"})
+	if s.GetNode(node.ID).ClientVersion != "Geth/v1.4.10-abc/linux-amd64/go1.21" {
+		t.Fatal("heartbeat should record the client version")
+	}
+	if len(notifier.events) != 0 {
+		t.Fatalf("no minimum set - nothing to warn about, got %+v", notifier.events)
+	}
+
+	// Raising the minimum catches nodes already running an old release
+	mins, _ := clientversion.ParseMinimums("bsc/geth=1.4.15")
+	s.SetMinClientVersions(mins)
+	if len(notifier.events) != 1 || notifier.events[0].Type != notify.EventClientOutdated {
+		t.Fatalf("expected one client-outdated event, got %+v", notifier.events)
+	}
+	if !s.GetNode(node.ID).ClientOutdated {
+		t.Error("node should be marked outdated")
+	}
+
+	// Still outdated - no repeat
+	s.RecordClientVersion(node.ID, "Geth/v1.4.11-abc/linux-amd64/go1.21")
+	if len(notifier.events) != 1 {
+		t.Errorf("should only notify once, got %d events", len(notifier.events))
+	}
+
+	s.RecordClientVersion(node.ID, "Geth/v1.4.15-abc/linux-amd64/go1.21")
+	if s.GetNode(node.ID).ClientOutdated {
+		t.Error("upgraded node should no longer be outdated")
+	}
+}
+
+func TestHardForkReadiness(t *testing.T) {
+	s := NewStore()
+	now := time.Now()
+	spec := fmt.Sprintf("bsc/pascal@%d:geth=1.5.7", now.Add(10*24*time.Hour).Unix())
+	forks, err := hardfork.Parse(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ready := s.RegisterNode("0xready", types.BscFull, types.ExposedRPC, "http://a", "")
+	old := s.RegisterNode("0xold", types.BscFull, types.ExposedRPC, "http://b", "")
+	s.RegisterNode("0xsilent", types.BscFull, types.ExposedRPC, "http://c", "")
+	s.RegisterNode("0xopbnb", types.OpbnbFull, types.ExposedRPC, "http://d", "")
+	s.RecordClientVersion(ready.ID, "Geth/v1.5.7-abc/linux-amd64/go1.23")
+	s.RecordClientVersion(old.ID, "Geth/v1.5.6-abc/linux-amd64/go1.23")
+
+	before := s.GetNode(ready.ID).TotalPoints
+	s.SetHardForks(forks)
+
+	if got := s.GetNode(ready.ID).TotalPoints - before; got != forks[0].EarlyBonus(now.UnixMilli()) {
+		t.Errorf("ready node should get the early bonus, got %d", got)
+	}
+	if s.ForkReadyAt(old.ID, "bsc/pascal") != 0 {
+		t.Error("old client isn't ready")
+	}
+
+	readiness := s.HardForkReadiness(now.UnixMilli())
+	if len(readiness) != 1 {
+		t.Fatalf("expected 1 fork, got %d", len(readiness))
+	}
+	r := readiness[0]
+	if r.Nodes != 3 || r.Ready != 1 || r.NotReady != 1 || r.Unknown != 1 {
+		t.Errorf("unexpected counts: %+v", r)
+	}
+	if math.Abs(r.ReadyPercent-100.0/3) > 0.01 || r.Releases["geth"] != "1.5.7" || r.Activated {
+		t.Errorf("unexpected readiness: %+v", r)
+	}
+
+	// Upgrading earns the bonus once
+	oldBefore := s.GetNode(old.ID).TotalPoints
+	s.RecordClientVersion(old.ID, "Geth/v1.5.7-abc/linux-amd64/go1.23")
+	s.RecordClientVersion(old.ID, "Geth/v1.5.8-abc/linux-amd64/go1.23")
+	if got := s.GetNode(old.ID).TotalPoints - oldBefore; got == 0 || got > 1000 {
+		t.Errorf("upgraded node should get one bonus, got %d points", got)
+	}
+	if s.ForkReadyAt(old.ID, "bsc/pascal") == 0 {
+		t.Error("upgraded node should be marked ready")
+	}
+}
+
+func TestReclassification(t *testing.T) {
+	s := NewStore()
+	notifier := &recordingNotifier{}
+	s.SetNotifier(notifier)
+	node := s.RegisterNode("0xtest", types.BscFast, types.ExposedRPC, "http://node", "")
+	now := time.Now().UnixMilli()
+
+	if _, _, err := s.ProposeReclassification("missing", types.BscFull, "big", now); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+
+	proposal, created, err := s.ProposeReclassification(node.ID, types.BscFull, "database is 1500GB", now)
+	if err != nil || !created {
+		t.Fatalf("expected a new proposal, got %v, %v", created, err)
+	}
+	if proposal.From != types.BscFast || proposal.DueAt != now+ReclassificationGrace.Milliseconds() {
+		t.Errorf("unexpected proposal: %+v", proposal)
+	}
+	if len(notifier.events) != 1 || notifier.events[0].Type != notify.EventReclassification {
+		t.Fatalf("expected a reclassification event, got %+v", notifier.events)
+	}
+
+	// Same finding again - no new proposal, no repeat notification
+	if _, created, _ := s.ProposeReclassification(node.ID, types.BscFull, "database is 1500GB", now+1000); created {
+		t.Error("an open proposal for the same type shouldn't be replaced")
+	}
+	if len(notifier.events) != 1 {
+		t.Errorf("should only notify once, got %d events", len(notifier.events))
+	}
+
+	if _, err := s.ResolveReclassification(node.ID, true, types.BscArchive, "operator", now); err != ErrReclassifyMismatch {
+		t.Errorf("accepting a different type: expected ErrReclassifyMismatch, got %v", err)
+	}
+	if got := s.ApplyDueReclassifications(now + 1000); len(got) != 0 {
+		t.Errorf("nothing is due yet, applied %v", got)
+	}
+	if got := s.ApplyDueReclassifications(proposal.DueAt); len(got) != 1 || got[0] != node.ID {
+		t.Fatalf("expected the proposal to be applied when due, got %v", got)
+	}
+
+	if s.GetNode(node.ID).NodeType != types.BscFull {
+		t.Error("node type should be changed")
+	}
+	if r := s.GetReclassification(node.ID); r.Status != types.ReclassificationApplied || r.ResolvedBy != "auto" {
+		t.Errorf("unexpected resolution: %+v", r)
+	}
+	if _, err := s.ResolveReclassification(node.ID, false, "", "admin", now); err != ErrNoReclassification {
+		t.Errorf("nothing open: expected ErrNoReclassification, got %v", err)
+	}
+
+	// Dismissed proposals leave the type alone
+	s.ProposeReclassification(node.ID, types.BscArchive, "serves deep historical state", now)
+	if _, err := s.ResolveReclassification(node.ID, false, "", "admin", now); err != nil {
+		t.Fatal(err)
+	}
+	if s.GetNode(node.ID).NodeType != types.BscFull || len(s.GetOpenReclassifications()) != 0 {
+		t.Error("dismissing shouldn't change the type")
+	}
+}
+
+func TestFileReport(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xoperator", types.BscFull, types.LocalProver, "", "")
+	now := time.Now().UnixMilli()
+
+	if _, err := s.FileReport("0xreporter", "missing", "proxying", now); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+
+	report, err := s.FileReport("0xreporter", node.ID, "answers in 400ms every time", now)
+	if err != nil {
+		t.Fatalf("report failed: %v", err)
+	}
+	if report.Status != types.ReportOpen {
+		t.Errorf("expected open report, got %s", report.Status)
+	}
+	if _, err := s.FileReport("0xreporter", node.ID, "again", now); err != ErrDuplicateReport {
+		t.Errorf("expected ErrDuplicateReport, got %v", err)
+	}
+
+	// Reported nodes show up for review even while clean
+	if flagged := s.GetFlaggedNodes(); len(flagged) != 1 || flagged[0].ID != node.ID {
+		t.Errorf("expected reported node in flagged list, got %d nodes", len(flagged))
+	}
+
+	// Banning upholds the report
+	s.SetNodeCheatStatus(node.ID, types.StatusBanned, "confirmed proxy")
+	if open := s.GetOpenReports(node.ID); len(open) != 0 {
+		t.Errorf("expected no open reports after review, got %d", len(open))
+	}
+	rep := s.GetReporterReputation("0xreporter")
+	if rep.Filed != 1 || rep.Upheld != 1 || rep.Score <= 0.5 {
+		t.Errorf("unexpected reputation after upheld report: %+v", rep)
+	}
+}
+
+func TestReportLimits(t *testing.T) {
+	s := NewStore()
+	now := time.Now().UnixMilli()
+
+	nodes := make([]*types.NodeRegistration, maxOpenReportsPerWallet+1)
+	for i := range nodes {
+		nodes[i] = s.RegisterNode(fmt.Sprintf("0xoperator%d", i), types.BscFull, types.LocalProver, "", "")
+	}
+
+	for _, node := range nodes[:maxOpenReportsPerWallet] {
+		if _, err := s.FileReport("0xspammer", node.ID, "cheater", now); err != nil {
+			t.Fatalf("report failed: %v", err)
+		}
+	}
+	if _, err := s.FileReport("0xspammer", nodes[maxOpenReportsPerWallet].ID, "cheater", now); err != ErrTooManyOpenReports {
+		t.Errorf("expected ErrTooManyOpenReports, got %v", err)
+	}
+
+	// Every report dismissed - the wallet can't report any more
+	for _, node := range nodes[:maxOpenReportsPerWallet] {
+		s.SetNodeCheatStatus(node.ID, types.StatusClean, "")
+	}
+	rep := s.GetReporterReputation("0xspammer")
+	if rep.Dismissed != uint64(maxOpenReportsPerWallet) || rep.Score >= minReporterScore {
+		t.Errorf("unexpected reputation after dismissed reports: %+v", rep)
+	}
+	if _, err := s.FileReport("0xspammer", nodes[maxOpenReportsPerWallet].ID, "cheater", now); err != ErrReporterBlocked {
+		t.Errorf("expected ErrReporterBlocked, got %v", err)
+	}
+}
+
+func TestFingerprintClusters(t *testing.T) {
+	s := NewStore()
+	s.SetFingerprintWalletThreshold(3)
+	conn := types.ConnectionFingerprint{ClientSignature: "abc", RemoteAddr: "203.0.113.7"}
+	now := time.Now().UnixMilli()
+
+	// One wallet with two nodes is not a cluster
+	a1 := s.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+	a2 := s.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+	s.RecordSubmissionFingerprint(a1.ID, "fp1", conn, now)
+	s.RecordSubmissionFingerprint(a2.ID, "fp1", conn, now)
+	if clusters := s.GetFingerprintClusters(); len(clusters) != 0 {
+		t.Errorf("expected no clusters for a single wallet, got %d", len(clusters))
+	}
+
+	b := s.RegisterNode("0xb", types.BscFull, types.LocalProver, "", "")
+	s.RecordSubmissionFingerprint(b.ID, "fp1", conn, now)
+	if s.GetNode(b.ID).WarningCount != 0 {
+		t.Error("two wallets is under the threshold - no suspicious event yet")
+	}
+
+	// Third wallet crosses the threshold - every node in the cluster is marked once
+	c := s.RegisterNode("0xc", types.BscFull, types.LocalProver, "", "")
+	s.RecordSubmissionFingerprint(c.ID, "fp1", conn, now)
+	s.RecordSubmissionFingerprint(c.ID, "fp1", conn, now)
+	for _, id := range []string{a1.ID, a2.ID, b.ID, c.ID} {
+		if n := s.GetNode(id).WarningCount; n != 1 {
+			t.Errorf("node %s: expected 1 warning, got %d", id, n)
+		}
+	}
+
+	clusters := s.GetFingerprintClusters()
+	if len(clusters) != 1 || len(clusters[0].Wallets) != 3 || len(clusters[0].NodeIDs) != 4 {
+		t.Errorf("unexpected clusters: %+v", clusters)
+	}
+}
+
+func TestRecordPoll(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xtest", types.BscFull, types.LocalProver, "", "")
+	start := time.Now().UnixMilli()
+
+	s.RecordPoll(node.ID, start)
+	if s.GetNode(node.ID).PollIntervalMs != 0 {
+		t.Error("no interval after a single poll")
+	}
+
+	s.RecordPoll(node.ID, start+300000)
+	if got := s.GetNode(node.ID).PollIntervalMs; got != 300000 {
+		t.Errorf("expected first interval 300000, got %d", got)
+	}
+
+	// One odd gap only nudges the average
+	s.RecordPoll(node.ID, start+300000+800000)
+	if got := s.GetNode(node.ID).PollIntervalMs; got != 400000 {
+		t.Errorf("expected smoothed interval 400000, got %d", got)
+	}
+}
+
+func TestSurpriseMisses(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xtest", types.BscFull, types.LocalProver, "", "")
+	now := time.Now().UnixMilli()
+
+	// Passes its scheduled challenges
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: node.ID, ChallengeID: "scheduled", Passed: true, Timestamp: now})
+
+	miss := func(id string) {
+		s.RecordSurpriseIssued(&types.Challenge{ID: id, NodeID: node.ID, ExpiresAt: now + 15000})
+		s.ExpireSurprises(now + 16000)
+	}
+
+	miss("s1")
+	miss("s2")
+	if s.GetNode(node.ID).WarningCount != 0 {
+		t.Error("two misses should not be suspicious yet")
+	}
+
+	// Answering one resets the run
+	s.RecordSurpriseIssued(&types.Challenge{ID: "s3", NodeID: node.ID, ExpiresAt: now + 15000})
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: node.ID, ChallengeID: "s3", Passed: true, Surprise: true, Timestamp: now})
+	miss("s4")
+	miss("s5")
+	if s.GetNode(node.ID).WarningCount != 0 {
+		t.Error("a passed surprise should reset the miss count")
+	}
+
+	miss("s6")
+	got := s.GetNode(node.ID)
+	if got.WarningCount != 1 {
+		t.Errorf("expected a suspicious event after 3 misses in a row, got %d", got.WarningCount)
+	}
+	if got.Surprise.Issued != 6 || got.Surprise.Passed != 1 || got.Surprise.Missed != 5 || got.Surprise.PendingID != "" {
+		t.Errorf("unexpected surprise stats: %+v", got.Surprise)
+	}
+}
+
+func TestSurpriseMissesWithoutScheduledPasses(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xtest", types.BscFull, types.LocalProver, "", "")
+	now := time.Now().UnixMilli()
+
+	// A node that's just down fails everything - no need to pile on
+	for i := 0; i < 3; i++ {
+		s.RecordSurpriseIssued(&types.Challenge{ID: fmt.Sprintf("s%d", i), NodeID: node.ID, ExpiresAt: now})
+		s.ExpireSurprises(now + 1)
+	}
+	if s.GetNode(node.ID).WarningCount != 0 {
+		t.Error("node that never passed anything should not get surprise suspicion")
+	}
+}
+
+func TestTrustScore(t *testing.T) {
+	s := NewStore()
+	honest := s.RegisterNode("0xhonest", types.BscFull, types.LocalProver, "", "")
+	cache := s.RegisterNode("0xcache", types.BscFull, types.LocalProver, "", "")
+	now := time.Now().UnixMilli()
+
+	if got := s.GetNode(honest.ID).Trust.Score; got != 75 {
+		t.Errorf("expected a new node to start at 75, got %d", got)
+	}
+
+	kinds := []types.ChallengeType{types.BlockHash, types.BlockData, types.StateBalance}
+	for i := 0; i < 30; i++ {
+		kind := kinds[i%len(kinds)]
+		s.RecordVerificationResult(&types.VerificationResult{NodeID: honest.ID, ChallengeType: kind, Passed: true, Timestamp: now})
+
+		// Only ever gets the cheap type right, slowly
+		s.RecordVerificationResult(&types.VerificationResult{NodeID: cache.ID, ChallengeType: kind, Passed: kind == types.BlockHash, Suspicious: kind == types.BlockHash, Timestamp: now})
+	}
+
+	h, _ := s.GetTrustScore(honest.ID)
+	c, _ := s.GetTrustScore(cache.ID)
+	if h.Score < 90 {
+		t.Errorf("expected an honest node to score 90+, got %+v", h)
+	}
+	// Its connection looks fine, so the answer factors alone pull it down
+	if c.Score > 55 || c.Diversity > 0.34 {
+		t.Errorf("expected a cache-like node to score low, got %+v", c)
+	}
+	if _, ok := s.GetTrustScore("missing"); ok {
+		t.Error("expected no score for an unknown node")
+	}
+}
+
+func TestGetHealth(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xhealth", types.BscFull, types.ExposedRPC, "http://node:8545", "")
+	now := time.Now().UnixMilli()
+
+	if h, _ := s.GetHealth(node.ID); h.Grade != types.GradeNone {
+		t.Errorf("expected a new node to be ungraded, got %+v", h)
+	}
+
+	for i := 0; i < 10; i++ {
+		s.RecordVerificationResult(&types.VerificationResult{NodeID: node.ID, Passed: i > 0, ResponseTimeMs: 40, Timestamp: now})
+		s.RecordHeartbeat(&types.HeartbeatRecord{NodeID: node.ID, Timestamp: now, IsSynced: true})
+	}
+	h, ok := s.GetHealth(node.ID)
+	if !ok || h.Grade != types.GradeA || h.PassRate24h != 90 || h.LatencyP90Ms != 40 || h.SyncedPercent != 100 {
+		t.Errorf("expected an A from a mostly passing, synced node, got %+v", h)
+	}
+
+	// Old results don't count
+	stale := s.RegisterNode("0xstale", types.BscFull, types.LocalProver, "", "")
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: stale.ID, Passed: false, Timestamp: now - 2*24*60*60*1000})
+	if h, _ := s.GetHealth(stale.ID); h.PassRate24h != 0 || h.LatencyP90Ms != 0 {
+		t.Errorf("expected results older than 24 hours to be left out, got %+v", h)
+	}
+
+	if _, ok := s.GetHealth("missing"); ok {
+		t.Error("expected no health for an unknown node")
+	}
+}
+
+func TestStoreOnFakeClock(t *testing.T) {
+	s := NewStore()
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	s.SetClock(fake)
+
+	node := s.RegisterNode("0xclock", types.BscFull, types.LocalProver, "", "")
+	if node.RegisteredAt != start.UnixMilli() {
+		t.Errorf("expected registration at the fake time, got %d", node.RegisteredAt)
+	}
+
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: node.ID, Passed: true, Timestamp: start.UnixMilli()})
+	if rate := s.GetNodeStats(node.ID).ChallengePassRate; rate != 100 {
+		t.Fatalf("expected a 100%% pass rate, got %f", rate)
+	}
+
+	// A day later the result falls out of the 24-hour window
+	fake.Advance(24*time.Hour + time.Millisecond)
+	if rate := s.GetNodeStats(node.ID).ChallengePassRate; rate != 0 {
+		t.Errorf("expected the result to have aged out, got %f", rate)
+	}
+	if h, _ := s.GetHealth(node.ID); h.PassRate24h != 0 {
+		t.Errorf("expected health to age the result out too, got %+v", h)
+	}
+
+	s.AddSuspiciousEvent(node.ID, "odd")
+	if events := s.GetNode(node.ID).SuspiciousEvents; len(events) != 1 || !strings.HasPrefix(events[0], "2025-03-02 12:00: ") {
+		t.Errorf("expected the event stamped with the fake time, got %v", events)
+	}
+}
+
+func TestRecompute(t *testing.T) {
+	s := NewStore()
+	now := time.Now().UnixMilli()
+	if _, ok := s.RecomputeStatus(); ok {
+		t.Error("expected no recompute before one is started")
+	}
+
+	// Two wallets on one connection cost both some trust
+	a := s.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+	b := s.RegisterNode("0xb", types.BscFull, types.LocalProver, "", "")
+	s.RegisterNode("0xc", types.BscFull, types.LocalProver, "", "")
+	conn := types.ConnectionFingerprint{RemoteAddr: "203.0.113.1"}
+	s.RecordSubmissionFingerprint(a.ID, "shared", conn, now)
+	s.RecordSubmissionFingerprint(b.ID, "shared", conn, now)
+	before := s.GetNode(a.ID).Trust.Score
+
+	// A looser threshold only shows once the scores are recomputed
+	s.SetFingerprintWalletThreshold(20)
+	if s.GetNode(a.ID).Trust.Score != before {
+		t.Fatal("trust scores shouldn't move until recomputed")
+	}
+
+	job, started := s.StartRecompute(now)
+	if !started || job.Total != 3 || job.Done != 0 {
+		t.Fatalf("unexpected job: %+v", job)
+	}
+	if _, started := s.StartRecompute(now); started {
+		t.Error("expected a second recompute to be refused while one runs")
+	}
+
+	job = s.RecomputeStep(2, now)
+	if job.Done != 2 || job.FinishedAt != 0 {
+		t.Errorf("expected 2 of 3 done, got %+v", job)
+	}
+	job = s.RecomputeStep(2, now+1)
+	if job.Done != 3 || job.FinishedAt != now+1 {
+		t.Errorf("expected the job finished, got %+v", job)
+	}
+	if job.TrustChanged != 2 || job.Grades[types.GradeNone] != 3 || job.Eligible != 3 {
+		t.Errorf("unexpected tallies: %+v", job)
+	}
+	if s.GetNode(a.ID).Trust.Score <= before {
+		t.Errorf("expected a's trust to recover, still %d", s.GetNode(a.ID).Trust.Score)
+	}
+
+	if status, ok := s.RecomputeStatus(); !ok || status.FinishedAt != now+1 {
+		t.Errorf("expected the finished job's status, got %+v", status)
+	}
+	if _, started := s.StartRecompute(now + 2); !started {
+		t.Error("expected a new recompute once the last one finished")
+	}
+}
+
+func TestTrustScoreConnections(t *testing.T) {
+	s := NewStore()
+	s.SetFingerprintWalletThreshold(3)
+	node := s.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+	now := time.Now().UnixMilli()
+
+	for i := 0; i < 3; i++ {
+		conn := types.ConnectionFingerprint{RemoteAddr: fmt.Sprintf("203.0.113.%d", i)}
+		s.RecordSubmissionFingerprint(node.ID, fmt.Sprintf("fp%d", i), conn, now)
+	}
+	if got := s.GetNode(node.ID).Trust.IPStability; got != 0.5 {
+		t.Errorf("expected IP stability 0.5 for 3 recent addresses, got %f", got)
+	}
+
+	// A second wallet on the same connection is halfway to the threshold
+	other := s.RegisterNode("0xb", types.BscFull, types.LocalProver, "", "")
+	s.RecordSubmissionFingerprint(other.ID, "fp0", types.ConnectionFingerprint{RemoteAddr: "203.0.113.0"}, now)
+	if got := s.GetNode(node.ID).Trust.Cluster; got != 0.5 {
+		t.Errorf("expected cluster factor 0.5, got %f", got)
+	}
+
+	// Addresses from over a week ago stop counting
+	s.RecordSubmissionFingerprint(node.ID, "fp0", types.ConnectionFingerprint{RemoteAddr: "203.0.113.0"}, now+8*24*60*60*1000)
+	if got := s.GetNode(node.ID).Trust.IPStability; got != 1 {
+		t.Errorf("expected IP stability back to 1, got %f", got)
+	}
+}
+
+func TestTrustWeightedPoints(t *testing.T) {
+	s := NewStore()
+	s.SetTrustWeightedPoints(true)
+	node := s.RegisterNode("0xtest", types.BscFull, types.LocalProver, "", "")
+	s.UpdateNode(node.ID, func(n *types.NodeRegistration) { n.Trust.Score = 50 })
+	start := s.GetNode(node.ID).TotalPoints
+
+	// 1 point per interval at half trust - one point every other interval
+	for i := 0; i < 4; i++ {
+		s.AwardUptimePoints(node.ID, 5)
+	}
+	if got := s.GetNode(node.ID).TotalPoints - start; got != 2 {
+		t.Errorf("expected 2 points over 4 intervals, got %d", got)
+	}
+}
+
+func TestBanFlagsSiblings(t *testing.T) {
+	s := NewStore()
+	now := time.Now().UnixMilli()
+
+	banned := s.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+	sameWallet := s.RegisterNode("0xa", types.BscFast, types.LocalProver, "", "")
+	sameIP := s.RegisterNode("0xb", types.BscFull, types.LocalProver, "", "")
+	alreadyBanned := s.RegisterNode("0xc", types.BscFull, types.LocalProver, "", "")
+	unrelated := s.RegisterNode("0xd", types.BscFull, types.LocalProver, "", "")
+
+	s.RecordSubmissionFingerprint(banned.ID, "fp1", types.ConnectionFingerprint{RemoteAddr: "203.0.113.7"}, now)
+	s.RecordSubmissionFingerprint(sameIP.ID, "fp2", types.ConnectionFingerprint{RemoteAddr: "203.0.113.7"}, now)
+	s.RecordSubmissionFingerprint(alreadyBanned.ID, "fp3", types.ConnectionFingerprint{RemoteAddr: "203.0.113.7"}, now)
+	s.RecordSubmissionFingerprint(unrelated.ID, "fp4", types.ConnectionFingerprint{RemoteAddr: "198.51.100.1"}, now)
+	s.SetNodeCheatStatus(alreadyBanned.ID, types.StatusBanned, "earlier ban")
+
+	// Banning alreadyBanned flagged these two already - clear them first
+	s.SetNodeCheatStatus(banned.ID, types.StatusClean, "")
+	s.SetNodeCheatStatus(sameIP.ID, types.StatusClean, "")
+
+	s.SetNodeCheatStatus(banned.ID, types.StatusBanned, "proxying")
+
+	for _, id := range []string{sameWallet.ID, sameIP.ID} {
+		if got := s.GetNode(id); got.CheatStatus != types.StatusFlagged || !strings.Contains(got.CheatReason, banned.ID) {
+			t.Errorf("node %s: expected flagged because of %s, got %s (%s)", id, banned.ID, got.CheatStatus, got.CheatReason)
+		}
+	}
+	if got := s.GetNode(alreadyBanned.ID).CheatStatus; got != types.StatusBanned {
+		t.Errorf("an already banned sibling should stay banned, got %s", got)
+	}
+	if got := s.GetNode(unrelated.ID).CheatStatus; got != types.StatusClean {
+		t.Errorf("unrelated node should be untouched, got %s", got)
+	}
+
+	siblings := s.GetSiblings(banned.ID)
+	if len(siblings) != 3 {
+		t.Fatalf("expected 3 siblings, got %+v", siblings)
+	}
+	for _, sibling := range siblings {
+		if sibling.NodeID == sameWallet.ID && (len(sibling.Links) != 1 || sibling.Links[0] != types.LinkWallet) {
+			t.Errorf("expected a wallet link, got %v", sibling.Links)
+		}
+	}
+}
+
+func TestWalletBanCooldown(t *testing.T) {
+	s := NewStore()
+	s.SetWalletBanCooldown(24 * time.Hour)
+	day := int64(24 * 60 * 60 * 1000)
+
+	first := s.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+	s.SetNodeCheatStatus(first.ID, types.StatusBanned, "proxying")
+
+	now := time.Now().UnixMilli()
+	ban := s.GetWalletBan("0xa", now)
+	if ban == nil || ban.Offences != 1 || ban.NodeID != first.ID {
+		t.Fatalf("expected a first-offence ban from %s, got %+v", first.ID, ban)
+	}
+	if s.GetWalletBan("0xa", now+day+1000) != nil {
+		t.Error("first ban should be over after a day")
+	}
+
+	// Came back and cheated again - twice as long
+	second := s.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+	s.SetNodeCheatStatus(second.ID, types.StatusBanned, "proxying again")
+	ban = s.GetWalletBan("0xa", now)
+	if ban == nil || ban.Offences != 2 || ban.ExpiresAt-ban.BannedAt != 2*day {
+		t.Errorf("expected a two-day second offence, got %+v", ban)
+	}
+
+	// Clearing the node behind the ban lifts it
+	s.SetNodeCheatStatus(second.ID, types.StatusClean, "")
+	if ban := s.GetWalletBan("0xa", time.Now().UnixMilli()); ban != nil {
+		t.Errorf("expected the ban lifted after clearing its node, got %+v", ban)
+	}
+	if err := s.LiftWalletBan("0xa", time.Now().UnixMilli()); err != ErrWalletNotBanned {
+		t.Errorf("expected ErrWalletNotBanned, got %v", err)
+	}
+}
+
+func TestManualWalletBan(t *testing.T) {
+	s := NewStore()
+	now := time.Now().UnixMilli()
+
+	permanent := time.Duration(0)
+	s.BanWallet("0xa", "known farm", &permanent, now)
+	if ban := s.GetWalletBan("0xa", now+365*24*60*60*1000); ban == nil || ban.ExpiresAt != 0 {
+		t.Errorf("expected a permanent ban, got %+v", ban)
+	}
+
+	s.BanWallet("0xb", "suspected farm", nil, now)
+	if ban := s.GetWalletBan("0xb", now); ban == nil || ban.ExpiresAt != now+(30*24*time.Hour).Milliseconds() {
+		t.Errorf("expected the default 30 day cooldown, got %+v", ban)
+	}
+
+	if bans := s.GetWalletBans(); len(bans) != 2 {
+		t.Errorf("expected 2 bans, got %d", len(bans))
+	}
+}
+
+func TestPendingBanExpires(t *testing.T) {
+	s := NewStore()
+	s.SetBanApprovalWindow(10 * time.Minute)
+	now := time.Now().UnixMilli()
+
+	pending, ban, err := s.RequestWalletBan("0xa", "farm", nil, "admin-1", now)
+	if err != nil || pending == nil || ban != nil {
+		t.Fatalf("expected a pending wallet ban, got %+v %+v %v", pending, ban, err)
+	}
+
+	// Second admin is too late - their request starts a new pending ban
+	later := now + 11*60*1000
+	if pending, _, _ := s.RequestWalletBan("0xa", "farm", nil, "admin-2", later); pending == nil || pending.RequestedBy != "admin-2" {
+		t.Errorf("expected a fresh pending ban from admin-2, got %+v", pending)
+	}
+	if s.GetWalletBan("0xa", later) != nil {
+		t.Error("an expired request must not count as approval")
+	}
+
+	_, ban, err = s.RequestWalletBan("0xa", "farm", nil, "admin-1", later+1000)
+	if err != nil || ban == nil {
+		t.Fatalf("expected the wallet banned on confirmation, got %+v %v", ban, err)
+	}
+	if len(s.GetPendingBans(later+1000)) != 0 {
+		t.Error("confirmed ban should leave the pending list")
+	}
+}
+
+func TestBanWithoutApproval(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+
+	if pending, err := s.RequestNodeBan(node.ID, "proxying", "admin-1", time.Now().UnixMilli()); err != nil || pending != nil {
+		t.Fatalf("expected an immediate ban, got %+v %v", pending, err)
+	}
+	if s.GetNode(node.ID).CheatStatus != types.StatusBanned {
+		t.Error("expected the node banned")
+	}
+	if _, err := s.RequestNodeBan("missing", "", "admin-1", time.Now().UnixMilli()); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+}
+
+func TestSizes(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+	s.RegisterNode("0xb", types.BscFull, types.LocalProver, "", "")
+	for i := 0; i < 3; i++ {
+		s.RecordVerificationResult(&types.VerificationResult{NodeID: node.ID, Passed: true, Timestamp: time.Now().UnixMilli()})
+	}
+
+	sizes := s.Sizes()
+	if sizes["nodes"] != 2 || sizes["verification_records"] != 3 {
+		t.Errorf("unexpected sizes: %v", sizes)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+	for i := 0; i < 1001; i++ {
+		s.RecordVerificationResult(&types.VerificationResult{NodeID: node.ID, Passed: true, Timestamp: int64(i)})
+	}
+
+	before := s.Usage()
+	if before.Nodes != 1 || before.EstimatedBytes["verification_records"] == 0 || before.LastCompaction != nil {
+		t.Fatalf("unexpected usage: %+v", before)
+	}
+
+	compaction := s.Compact(5000)
+	if compaction.Slices == 0 || compaction.SlotsFreed == 0 || compaction.BytesFreed == 0 {
+		t.Fatalf("expected spare capacity to be released, got %+v", compaction)
+	}
+	history := s.verificationHistory[node.ID]
+	if len(history) != 1000 || cap(history) != 1000 || history[0].Timestamp != 1 {
+		t.Errorf("history should keep its last 1000 records in a slice that fits, got len %d cap %d", len(history), cap(history))
+	}
+
+	after := s.Usage()
+	if after.TotalBytes >= before.TotalBytes {
+		t.Errorf("estimate should drop after compaction: %d -> %d", before.TotalBytes, after.TotalBytes)
+	}
+	if after.LastCompaction == nil || after.LastCompaction.At != 5000 {
+		t.Errorf("usage should report the last compaction, got %+v", after.LastCompaction)
+	}
+
+	// Nothing left to give back
+	if again := s.Compact(6000); again.Slices != 0 {
+		t.Errorf("second pass should be a no-op, got %+v", again)
+	}
+}
+
+func TestDiversityBonus(t *testing.T) {
+	s := NewStore()
+	for i := 0; i < 4; i++ {
+		node := s.RegisterNode(fmt.Sprintf("0x%d", i), types.BscFull, types.LocalProver, "", "")
+		s.RecordNodeCountry(node.ID, "US")
+	}
+	rare := s.RegisterNode("0xrare", types.BscFull, types.LocalProver, "", "")
+	s.RecordNodeCountry(rare.ID, "NG")
+	s.RecordNodeCountry(rare.ID, "") // Unplaced request keeps the country
+
+	s.SetDiversityBonus(50)
+	weights := s.RecalculateRegionWeights(1000)
+	if weights.UpdatedAt != 1000 || weights.MaxBonusPercent != 50 {
+		t.Fatalf("unexpected weights: %+v", weights)
+	}
+	// 5 nodes over 2 countries: NG has 1 of a fair 2.5
+	if ng := weights.Regions["NG"]; ng.Nodes != 1 || ng.BonusPercent != 30 {
+		t.Errorf("expected NG nodes to get 30%%, got %+v", ng)
+	}
+	if us := weights.Regions["US"]; us.BonusPercent != 0 {
+		t.Errorf("US is overrepresented, got %+v", us)
+	}
+
+	// bsc-full earns 6/12 -> 1 point per interval, so 1.3 with the bonus
+	before := s.GetNode(rare.ID).TotalPoints
+	for i := 0; i < 10; i++ {
+		s.AwardUptimePoints(rare.ID, 5)
+	}
+	if got := s.GetNode(rare.ID).TotalPoints - before; got != 13 {
+		t.Errorf("expected 13 points with the bonus, got %d", got)
+	}
+
+	// Off again: the weights stay published but pay nothing
+	s.SetDiversityBonus(0)
+	if s.RegionWeights().Regions["NG"].BonusPercent != 0 {
+		t.Error("bonus should be 0 when switched off")
+	}
+}
+
+func TestStakeBonus(t *testing.T) {
+	s := NewStore()
+	staker := s.RegisterNode("0x00000000000000000000000000000000000000A1", types.BscFull, types.LocalProver, "", "")
+	other := s.RegisterNode("0x00000000000000000000000000000000000000b2", types.BscFull, types.LocalProver, "", "")
+	now := time.Now().UnixMilli()
+	hour := staking.ReadInterval.Milliseconds()
+
+	tiers, _ := staking.ParseTiers("1=5,10=20")
+	s.SetStakingTiers(tiers)
+
+	// One read isn't enough: the tokens have to be there at two in a row
+	s.RecordStakes(map[string]types.WalletStake{
+		"0x00000000000000000000000000000000000000a1": {Wei: "12000000000000000000", Tokens: 12, CheckedAt: now - hour},
+	})
+	if stake := s.GetWalletStats(staker.WalletAddress).Stake; stake == nil || stake.Tokens != 12 || stake.BonusPercent != 0 {
+		t.Fatalf("expected 12 tokens staked for no bonus yet, got %+v", stake)
+	}
+	s.RecordStakes(map[string]types.WalletStake{
+		"0x00000000000000000000000000000000000000a1": {Wei: "12000000000000000000", Tokens: 12, CheckedAt: now},
+	})
+	if stake := s.GetWalletStats(staker.WalletAddress).Stake; stake == nil || stake.HeldTokens != 12 || stake.BonusPercent != 20 {
+		t.Fatalf("expected 12 tokens held for 20%%, got %+v", stake)
+	}
+	if s.GetWalletStats(other.WalletAddress).Stake != nil {
+		t.Error("a wallet never read should have no stake")
+	}
+
+	// bsc-full earns 1 point per interval, so 1.2 with the bonus
+	before := s.GetNode(staker.ID).TotalPoints
+	for i := 0; i < 10; i++ {
+		s.AwardUptimePoints(staker.ID, 5)
+	}
+	if got := s.GetNode(staker.ID).TotalPoints - before; got != 12 {
+		t.Errorf("expected 12 points with the bonus, got %d", got)
+	}
+	ledger := s.PointsLedger(staker.ID)
+	if last := ledger[len(ledger)-1]; last.StakeBonusPercent != 20 || last.StakeTokens != 12 {
+		t.Errorf("expected the uptime entry to record the stake it was paid with, got %+v", last)
+	}
+	if p := s.ProjectPoints(staker.ID, now); p.Inputs.StakeBonusPercent != 20 || p.Daily != 288*1.2 {
+		t.Errorf("expected the projection to include the bonus, got %+v", p)
+	}
+
+	// New tiers apply without reading stakes again
+	tiers, _ = staking.ParseTiers("1=5,100=20")
+	s.SetStakingTiers(tiers)
+	if p := s.ProjectPoints(staker.ID, now); p.Inputs.StakeBonusPercent != 5 {
+		t.Errorf("expected 5%% under the new tiers, got %d", p.Inputs.StakeBonusPercent)
+	}
+
+	// A wallet left out of a read keeps what it had, until it goes stale
+	s.RecordStakes(map[string]types.WalletStake{})
+	if stake := s.GetWalletStats(staker.WalletAddress).Stake; stake == nil || stake.Tokens != 12 {
+		t.Errorf("expected the stake kept after a failed read, got %+v", stake)
+	}
+	if p := s.ProjectPoints(staker.ID, now+staking.StaleAfter.Milliseconds()+1); p.Inputs.StakeBonusPercent != 0 {
+		t.Errorf("expected no bonus once the stake went stale, got %d", p.Inputs.StakeBonusPercent)
+	}
+
+	// Staking for a single read and withdrawing earns nothing
+	s.RecordStakes(map[string]types.WalletStake{
+		"0x00000000000000000000000000000000000000b2": {Wei: "500000000000000000000", Tokens: 500, CheckedAt: now - hour},
+	})
+	s.RecordStakes(map[string]types.WalletStake{
+		"0x00000000000000000000000000000000000000b2": {Wei: "0", Tokens: 0, CheckedAt: now},
+	})
+	if p := s.ProjectPoints(other.ID, now); p.Inputs.StakeBonusPercent != 0 {
+		t.Errorf("expected no bonus for a stake withdrawn after one read, got %d", p.Inputs.StakeBonusPercent)
+	}
+
+	// The claim says what the bonus paid: 12 points at 20% is 2 of them
+	end := time.Now().UnixMilli()
+	epoch, err := s.CloseEpoch(end, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, claim := range epoch.Claims {
+		want := uint64(0)
+		if strings.EqualFold(claim.WalletAddress, staker.WalletAddress) {
+			want = 2
+		}
+		if claim.StakeBonus != want {
+			t.Errorf("expected the claim for %s to record %d bonus points, got %d", claim.WalletAddress, want, claim.StakeBonus)
+		}
+	}
+}
+
+func TestProjectPoints(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xa", types.BscArchive, types.LocalProver, "", "")
+	now := time.Now().UnixMilli()
+
+	if s.ProjectPoints("missing", now) != nil {
+		t.Error("expected nil for an unknown node")
+	}
+
+	// No history yet: assumed up all day, 288 awards of 1 point
+	p := s.ProjectPoints(node.ID, now)
+	if p.Inputs.OnlineBasis != "assumed" || p.Daily != 288 || p.Weekly != 288*7 {
+		t.Errorf("unexpected projection for a new node: %+v", p)
+	}
+
+	// Up for 3 of 4 checks this week
+	for i, passed := range []bool{true, true, false, true} {
+		s.RecordVerificationResult(&types.VerificationResult{NodeID: node.ID, Passed: passed, Timestamp: now - int64(i)})
+	}
+	p = s.ProjectPoints(node.ID, now)
+	if p.Inputs.OnlineBasis != "uptime_7d" || p.Inputs.OnlinePercent != 75 || p.Inputs.ChallengePassRate != 75 || p.Daily != 216 {
+		t.Errorf("unexpected projection: %+v", p)
+	}
+
+	// Testnet points count 10x
+	s.SetNetwork(types.Testnet)
+	if p = s.ProjectPoints(node.ID, now); p.Inputs.PointsPerInterval != 10 || p.Daily != 2160 {
+		t.Errorf("expected the testnet multiplier, got %+v", p)
+	}
+
+	s.PauseNode(node.ID, now)
+	if p = s.ProjectPoints(node.ID, now); p.Daily != 0 || p.Inputs.NotEarning != "paused" {
+		t.Errorf("a paused node earns nothing, got %+v", p)
+	}
+}
+
+func TestChallengeBudget(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+	caps, _ := budget.ParseCaps("block-hash=2,*=3")
+	s.SetChallengeCaps(caps)
+
+	now := time.Now().UnixMilli()
+	if !s.ChargeChallenge(node.ID, types.BlockHash, now) || !s.ChargeChallenge(node.ID, types.BlockHash, now) {
+		t.Fatal("first two block-hash challenges should fit the budget")
+	}
+	if s.ChargeChallenge(node.ID, types.BlockHash, now) {
+		t.Error("third block-hash challenge is over its cap")
+	}
+	if !s.HasChallengeBudget(node.ID, now) || !s.ChargeChallenge(node.ID, types.StateBalance, now) {
+		t.Error("other types should still fit until the total is used up")
+	}
+	if s.HasChallengeBudget(node.ID, now) || s.ChargeChallenge(node.ID, types.SyncStatus, now) {
+		t.Error("total cap is used up")
+	}
+	if s.ChargeChallenge("missing", types.BlockHash, now) {
+		t.Error("unknown node should not be charged")
+	}
+
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: node.ID, ChallengeType: types.BlockHash, Passed: true, Timestamp: now})
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: node.ID, ChallengeType: types.StateBalance, Passed: false, Timestamp: now})
+
+	b := s.GetNodeStats(node.ID).ChallengeBudget
+	if b.Issued[types.BlockHash] != 2 || b.Issued[types.StateBalance] != 1 || b.Passed[types.BlockHash] != 1 || b.Passed[types.StateBalance] != 0 {
+		t.Errorf("unexpected budget: %+v", b)
+	}
+	if b.Caps[types.BlockHash] != 2 || b.TotalCap != 3 {
+		t.Errorf("stats should show the caps, got %+v", b)
+	}
+
+	// A new day starts from nothing
+	tomorrow := now + 24*60*60*1000
+	if !s.HasChallengeBudget(node.ID, tomorrow) || !s.ChargeChallenge(node.ID, types.BlockHash, tomorrow) {
+		t.Error("budget should reset the next day")
+	}
+}
+
+func TestInactivateSilentNodes(t *testing.T) {
+	s := NewStore()
+	quiet := s.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+	proving := s.RegisterNode("0xb", types.BscFull, types.LocalProver, "", "")
+	beating := s.RegisterNode("0xc", types.BscFull, types.ExposedRPC, "http://localhost:8545", "")
+	paused := s.RegisterNode("0xd", types.BscFull, types.LocalProver, "", "")
+
+	now := time.Now().UnixMilli()
+	later := now + 25*60*60*1000
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: proving.ID, Passed: true, Timestamp: later - 1000})
+	s.RecordHeartbeat(&types.HeartbeatRecord{NodeID: beating.ID, IsSynced: true, Timestamp: later - 1000})
+	s.PauseNode(paused.ID, now)
+
+	if silenced := s.InactivateSilentNodes(later); len(silenced) != 0 {
+		t.Fatalf("nothing should be silenced while the setting is off, got %v", silenced)
+	}
+
+	s.SetSilentAfter(24 * time.Hour)
+	silenced := s.InactivateSilentNodes(later)
+	if len(silenced) != 1 || silenced[0] != quiet.ID {
+		t.Fatalf("expected only the quiet node to be silenced, got %v", silenced)
+	}
+	if node := s.GetNode(quiet.ID); node.IsActive || !node.Silent {
+		t.Errorf("quiet node should be inactive and silent, got %+v", node)
+	}
+	if len(s.GetAllActiveNodes()) != 3 {
+		t.Error("silent node should drop out of the active nodes")
+	}
+
+	// A failed proof isn't enough to come back
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: quiet.ID, Passed: false, Timestamp: later})
+	if s.GetNode(quiet.ID).IsActive {
+		t.Error("failed proof should not reactivate")
+	}
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: quiet.ID, Passed: true, Timestamp: later})
+	if node := s.GetNode(quiet.ID); !node.IsActive || node.Silent {
+		t.Errorf("passed proof should reactivate, got %+v", node)
+	}
+
+	// Banned nodes are inactive for good, not silent
+	s.SetNodeCheatStatus(proving.ID, types.StatusBanned, "cheating")
+	s.RecordVerificationResult(&types.VerificationResult{NodeID: proving.ID, Passed: true, Timestamp: later})
+	if s.GetNode(proving.ID).IsActive {
+		t.Error("passed proof should not reactivate a banned node")
+	}
+}
+
+func TestEnforceUptime(t *testing.T) {
+	s := NewStore()
+	notifier := &recordingNotifier{}
+	s.SetNotifier(notifier)
+	s.SetUptimePenalty(50)
+
+	good := s.RegisterNode("0xa", types.BscFull, types.ExposedRPC, "http://localhost:8545", "")
+	poor := s.RegisterNode("0xb", types.BscFull, types.ExposedRPC, "http://localhost:8546", "")
+	fresh := s.RegisterNode("0xc", types.BscFull, types.ExposedRPC, "http://localhost:8547", "")
+
+	// bsc-full needs 95%
+	now := time.Now().UnixMilli()
+	for i := 0; i < 20; i++ {
+		s.RecordHeartbeat(&types.HeartbeatRecord{NodeID: good.ID, IsSynced: true, Timestamp: now})
+		s.RecordHeartbeat(&types.HeartbeatRecord{NodeID: poor.ID, IsSynced: i >= 2, Timestamp: now})
+	}
+	s.RecordMissedHeartbeat(fresh.ID, now)
+
+	fell := s.EnforceUptime(now)
+	if len(fell) != 1 || fell[0] != poor.ID {
+		t.Fatalf("expected only the poor node to fall below, got %v", fell)
+	}
+	if node := s.GetNode(poor.ID); !node.BelowUptimeTarget || node.Uptime7dPercent != 90 {
+		t.Errorf("poor node should be at 90%% and below target, got %+v", node)
+	}
+	if node := s.GetNode(good.ID); node.BelowUptimeTarget || node.Uptime7dPercent != 100 {
+		t.Errorf("good node should be at 100%%, got %+v", node)
+	}
+	if s.GetNode(fresh.ID).BelowUptimeTarget {
+		t.Error("a node with too few checks shouldn't be judged")
+	}
+	if len(notifier.events) != 1 || notifier.events[0].Type != notify.EventUptimeLow || notifier.events[0].NodeID != poor.ID {
+		t.Fatalf("expected one uptime-below-target event, got %+v", notifier.events)
+	}
+
+	// Still below: no repeat warning
+	if fell := s.EnforceUptime(now); len(fell) != 0 || len(notifier.events) != 1 {
+		t.Errorf("should only warn once, got %v and %d events", fell, len(notifier.events))
+	}
+
+	// Half the points while below
+	goodBefore, poorBefore := s.GetNode(good.ID).TotalPoints, s.GetNode(poor.ID).TotalPoints
+	for i := 0; i < 4; i++ {
+		s.AwardUptimePoints(good.ID, 5)
+		s.AwardUptimePoints(poor.ID, 5)
+	}
+	goodPoints := s.GetNode(good.ID).TotalPoints - goodBefore
+	poorPoints := s.GetNode(poor.ID).TotalPoints - poorBefore
+	if goodPoints == 0 || poorPoints*2 != goodPoints {
+		t.Errorf("expected half the uptime points below target, got %d vs %d", poorPoints, goodPoints)
+	}
+	if p := s.ProjectPoints(poor.ID, now); p.Inputs.UptimePenalty != 50 {
+		t.Errorf("projection should show the penalty, got %+v", p.Inputs)
+	}
+
+	// Back above target
+	for i := 0; i < 40; i++ {
+		s.RecordHeartbeat(&types.HeartbeatRecord{NodeID: poor.ID, IsSynced: true, Timestamp: now})
+	}
+	s.EnforceUptime(now)
+	if s.GetNode(poor.ID).BelowUptimeTarget {
+		t.Error("node should recover once its uptime is back above target")
+	}
+}
+
+func TestPointsLedger(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xa", types.BscFull, types.ExposedRPC, "http://localhost:8545", "")
+	s.AwardUptimePoints(node.ID, 5)
+	s.AwardUptimePoints(node.ID, 5)
+
+	ledger := s.PointsLedger(node.ID)
+	if len(ledger) != 3 || ledger[0].Reason != types.PointsRegistration || ledger[1].Reason != types.PointsUptime {
+		t.Fatalf("expected a registration and two uptime entries, got %+v", ledger)
+	}
+	sum := func() (total int64) {
+		for _, entry := range s.PointsLedger(node.ID) {
+			total += entry.Amount
+		}
+		return total
+	}
+	if uint64(sum()) != s.GetNode(node.ID).TotalPoints {
+		t.Errorf("total %d should be the ledger sum %d", s.GetNode(node.ID).TotalPoints, sum())
+	}
+
+	reversal, err := s.ReversePoints(node.ID, 2, "double award", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reversal.ID != 4 || reversal.Amount != -ledger[1].Amount || reversal.Reference != "2" {
+		t.Errorf("unexpected reversal: %+v", reversal)
+	}
+	if uint64(sum()) != s.GetNode(node.ID).TotalPoints {
+		t.Error("total should follow the reversal")
+	}
+
+	if _, err := s.ReversePoints(node.ID, 2, "again", 1000); err != ErrAlreadyReversed {
+		t.Errorf("expected ErrAlreadyReversed, got %v", err)
+	}
+	if _, err := s.ReversePoints(node.ID, 4, "undo", 1000); err != ErrReverseReversal {
+		t.Errorf("expected ErrReverseReversal, got %v", err)
+	}
+	if _, err := s.ReversePoints(node.ID, 9, "missing", 1000); err != ErrEntryNotFound {
+		t.Errorf("expected ErrEntryNotFound, got %v", err)
+	}
+	if _, err := s.ReversePoints("nope", 1, "missing", 1000); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+
+	// A hand adjustment can't leave the node owing points, and nor can
+	// undoing one
+	total := int64(s.GetNode(node.ID).TotalPoints)
+	if _, err := s.AdjustPoints(node.ID, -total-1, "too much", 2000); err != ErrPointsNegative {
+		t.Errorf("expected ErrPointsNegative, got %v", err)
+	}
+	bonus, err := s.AdjustPoints(node.ID, 10, "missed payout", 2000)
+	if err != nil || bonus.Reason != types.PointsAdjustment {
+		t.Fatalf("expected an adjustment entry, got %+v %v", bonus, err)
+	}
+	if _, err := s.AdjustPoints(node.ID, -total-10, "clawback", 3000); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ReversePoints(node.ID, bonus.ID, "wasn't owed", 4000); err != ErrPointsNegative {
+		t.Errorf("expected reversing into negative refused, got %v", err)
+	}
+	if uint64(sum()) != s.GetNode(node.ID).TotalPoints {
+		t.Error("total should follow the adjustments")
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	s := NewStore()
+	secret, key, err := s.CreateAPIKey("0xreader", "explorer", "basic", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found, ok := s.LookupAPIKey(secret); !ok || found.ID != key.ID || found.SecretHash == secret {
+		t.Fatalf("expected the key found by its secret and only its hash kept, got %+v", found)
+	}
+	if _, ok := s.LookupAPIKey(secret + "0"); ok {
+		t.Error("expected a wrong secret not to match")
+	}
+
+	for i := 1; i < maxAPIKeysPerWallet; i++ {
+		s.CreateAPIKey("0xreader", "", "basic", 1000+int64(i))
+	}
+	if _, _, err := s.CreateAPIKey("0xreader", "", "basic", 2000); err != ErrTooManyAPIKeys {
+		t.Fatalf("expected the wallet capped at %d keys, got %v", maxAPIKeysPerWallet, err)
+	}
+
+	// Revoking one makes room, and the revoked one stops working
+	if _, err := s.RevokeAPIKey(key.ID, 3000); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.LookupAPIKey(secret); ok {
+		t.Error("expected a revoked key not to be found")
+	}
+	if _, _, err := s.CreateAPIKey("0xreader", "", "basic", 4000); err != nil {
+		t.Errorf("expected room for a new key after revoking one, got %v", err)
+	}
+	if keys := s.GetAPIKeys("0xreader"); len(keys) != maxAPIKeysPerWallet+1 || keys[0].RevokedAt != 3000 {
+		t.Errorf("expected every key listed oldest first, got %+v", keys)
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	s := NewStore()
+	secret, _ := totp.NewSecret()
+	now := time.Now().UnixMilli()
+	code := func(at int64) string {
+		c, _ := totp.Code(secret, totp.StepAt(time.UnixMilli(at)))
+		return c
+	}
+
+	// Enrolled but not confirmed asks for nothing yet
+	if err := s.EnrollTOTP("0xa", secret, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CheckTOTP("0xa", "", now); err != nil {
+		t.Errorf("expected no code needed before confirming, got %v", err)
+	}
+	if err := s.ConfirmTOTP("0xa", code(now-10*int64(totp.Step/time.Millisecond)), now); err != ErrTOTPInvalid {
+		t.Errorf("expected a stale code refused, got %v", err)
+	}
+	if err := s.ConfirmTOTP("0xa", code(now), now); err != nil {
+		t.Fatalf("confirm failed: %v", err)
+	}
+	if enabled, since := s.TOTPStatus("0xa"); !enabled || since != now {
+		t.Errorf("expected two-factor on since %d, got %v %d", now, enabled, since)
+	}
+	if err := s.EnrollTOTP("0xa", secret, now); err != ErrTOTPEnabled {
+		t.Errorf("expected enrolling again refused while on, got %v", err)
+	}
+
+	// Now every check needs a fresh code, each used once
+	later := now + int64(totp.Step/time.Millisecond)
+	if err := s.CheckTOTP("0xa", "", later); err != ErrTOTPRequired {
+		t.Errorf("expected a code required, got %v", err)
+	}
+	if err := s.CheckTOTP("0xa", code(now), later); err != ErrTOTPInvalid {
+		t.Errorf("expected the confirming code not to work again, got %v", err)
+	}
+	if err := s.CheckTOTP("0xa", code(later), later); err != nil {
+		t.Errorf("expected the next code accepted, got %v", err)
+	}
+	if err := s.CheckTOTP("0xb", "", later); err != nil {
+		t.Errorf("expected a wallet without two-factor let through, got %v", err)
+	}
+
+	last := later + int64(totp.Step/time.Millisecond)
+	if err := s.DisableTOTP("0xa", code(last), last); err != nil {
+		t.Fatalf("disable failed: %v", err)
+	}
+	if err := s.DisableTOTP("0xa", code(last), last); err != ErrTOTPNotEnabled {
+		t.Errorf("expected nothing to disable, got %v", err)
+	}
+
+	// An admin reset waits for a second admin when bans do
+	s.EnrollTOTP("0xa", secret, last)
+	s.SetBanApprovalWindow(time.Hour)
+	if pending, err := s.RequestTOTPReset("0xa", "lost phone", "admin-1", last); err != nil || pending == nil || pending.Kind != types.BanTOTPReset {
+		t.Fatalf("expected the reset to wait for a second admin, got %+v %v", pending, err)
+	}
+	if _, err := s.RequestTOTPReset("0xa", "lost phone", "admin-1", last); err != ErrSameAdmin {
+		t.Errorf("expected the same admin refused, got %v", err)
+	}
+	if pending, err := s.RequestTOTPReset("0xa", "lost phone", "admin-2", last); err != nil || pending != nil {
+		t.Fatalf("expected a second admin to confirm the reset, got %+v %v", pending, err)
+	}
+	if _, err := s.RequestTOTPReset("0xa", "lost phone", "admin-1", last); err != ErrTOTPNotEnabled {
+		t.Errorf("expected nothing left to reset, got %v", err)
+	}
+}
+
+func TestTOTPLockout(t *testing.T) {
+	s := NewStore()
+	secret, _ := totp.NewSecret()
+	now := time.Now().UnixMilli()
+	step := int64(totp.Step / time.Millisecond)
+	code := func(at int64) string {
+		c, _ := totp.Code(secret, totp.StepAt(time.UnixMilli(at)))
+		return c
+	}
+	wrong := func(at int64) string {
+		c, _ := totp.Code(secret, totp.StepAt(time.UnixMilli(at))+100)
+		return c
+	}
+	s.EnrollTOTP("0xa", secret, now)
+	s.ConfirmTOTP("0xa", code(now), now)
+
+	// A right code clears the count, so four wrong ones and a right one
+	// leave the wallet usable
+	at := now + step
+	for i := 0; i < totpMaxFailures-1; i++ {
+		s.CheckTOTP("0xa", wrong(at), at)
+	}
+	if err := s.CheckTOTP("0xa", code(at), at); err != nil {
+		t.Fatalf("expected the right code accepted before the lockout, got %v", err)
+	}
+
+	at += step
+	for i := 0; i < totpMaxFailures; i++ {
+		if err := s.CheckTOTP("0xa", wrong(at), at); err != ErrTOTPInvalid {
+			t.Errorf("wrong code %d: expected it refused as wrong, got %v", i+1, err)
+		}
+	}
+	if err := s.CheckTOTP("0xa", code(at), at); err != ErrTOTPLocked {
+		t.Errorf("expected even the right code refused while locked, got %v", err)
+	}
+	if err := s.DisableTOTP("0xa", code(at), at); err != ErrTOTPLocked {
+		t.Errorf("expected disabling refused while locked, got %v", err)
+	}
+
+	// Each wrong code after the lockout ends doubles it
+	at += totpLockout.Milliseconds()
+	if err := s.CheckTOTP("0xa", wrong(at), at); err != ErrTOTPInvalid {
+		t.Errorf("expected a guess taken once the lockout ended, got %v", err)
+	}
+	if err := s.CheckTOTP("0xa", code(at+totpLockout.Milliseconds()), at+totpLockout.Milliseconds()); err != ErrTOTPLocked {
+		t.Errorf("expected the second lockout to last longer, got %v", err)
+	}
+	at += 2 * totpLockout.Milliseconds()
+	if err := s.CheckTOTP("0xa", code(at), at); err != nil {
+		t.Errorf("expected the right code accepted after the lockout, got %v", err)
+	}
+
+	// The count survives a restart
+	at += step
+	s.CheckTOTP("0xa", wrong(at), at)
+	var buf bytes.Buffer
+	if err := s.WriteCheckpoint(&buf); err != nil {
+		t.Fatal(err)
+	}
+	restored := NewStore()
+	if _, err := restored.ReadCheckpoint(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < totpMaxFailures-1; i++ {
+		restored.CheckTOTP("0xa", wrong(at), at)
+	}
+	if err := restored.CheckTOTP("0xa", code(at), at); err != ErrTOTPLocked {
+		t.Errorf("expected the failures kept across a checkpoint, got %v", err)
+	}
+}
+
+func TestRewardEpochs(t *testing.T) {
+	s := NewStore()
+	alice := s.RegisterNode("0x00000000000000000000000000000000000000a1", types.BscFull, types.LocalProver, "", "")
+	bob := s.RegisterNode("0x00000000000000000000000000000000000000b2", types.BscFast, types.LocalProver, "", "")
+	s.RegisterNode("not-an-address", types.BscFast, types.LocalProver, "", "")
+	now := time.Now().UnixMilli()
+
+	if _, err := s.CloseEpoch(now+1, now); err != ErrEpochFuture {
+		t.Errorf("expected an epoch ending in the future refused, got %v", err)
+	}
+	first, err := s.CloseEpoch(now, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Epoch != 1 || len(first.Claims) != 2 || first.Total != alice.TotalPoints+bob.TotalPoints {
+		t.Fatalf("expected both wallets paid their registration points, got %+v", first)
+	}
+	for _, claim := range first.Claims {
+		if !rewards.Verify(first.MerkleRoot, claim.Proof, claim.WalletAddress, claim.Amount, 1) {
+			t.Errorf("expected %s's proof to check out", claim.WalletAddress)
+		}
+	}
+	if _, err := s.CloseEpoch(now, now); err != ErrEpochNotAfter {
+		t.Errorf("expected a second epoch at the same cutoff refused, got %v", err)
+	}
+	if _, err := s.CloseEpoch(now+1, now+1); err != ErrEpochEmpty {
+		t.Errorf("expected nothing new to pay, got %v", err)
+	}
+
+	// Taking back points already paid holds the wallet at nothing until it
+	// has earned them again
+	s.ReversePoints(alice.ID, 1, "duplicate registration", now+2)
+	s.AwardUptimePoints(bob.ID, 5)
+	second, err := s.CloseEpoch(time.Now().UnixMilli()+3, time.Now().UnixMilli()+3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second.Claims) != 1 || second.Claims[0].WalletAddress != bob.WalletAddress {
+		t.Errorf("expected only bob paid in the second epoch, got %+v", second.Claims)
+	}
+
+	if claims := s.GetRewardClaims(bob.WalletAddress); len(claims) != 2 || claims[1].Epoch != 2 {
+		t.Errorf("expected bob's claims from both epochs, got %+v", claims)
+	}
+	if epochs := s.GetEpochs(); len(epochs) != 2 || epochs[0].Claims != nil {
+		t.Errorf("expected both epochs listed without claims, got %+v", epochs)
+	}
+	if s.GetEpoch(3) != nil || len(s.GetEpoch(1).Claims) != 2 {
+		t.Error("expected epochs fetched by number with their claims")
+	}
+}
+
+func TestSearchNodes(t *testing.T) {
+	s := NewStore()
+	full := s.RegisterNode("0xaaaa1111", types.BscFull, types.LocalProver, "", "")
+	archive := s.RegisterNode("0xaaaa2222", types.BscArchive, types.ExposedRPC, "http://archive:8545", "")
+	opbnb := s.RegisterNode("0xbbbb3333", types.OpbnbFull, types.LocalProver, "", "")
+	s.RecordClientVersion(archive.ID, "erigon/2.60.1/linux-amd64/go1.22.3")
+
+	ids := func(results []types.SearchResult) []string {
+		var out []string
+		for _, r := range results {
+			out = append(out, r.NodeID)
+		}
+		return out
+	}
+
+	// An exact ID beats everything
+	if results := s.SearchNodes(strings.ToUpper(full.ID), 0); len(results) != 1 || results[0].Matched[0] != "id" {
+		t.Errorf("expected the node by its ID, got %+v", results)
+	}
+	// A wallet prefix finds both of its nodes; too short a prefix finds nothing
+	if got := ids(s.SearchNodes("0xaaaa", 0)); len(got) != 2 {
+		t.Errorf("expected both 0xaaaa nodes, got %v", got)
+	}
+	if got := s.SearchNodes("0xa", 0); len(got) != 0 {
+		t.Errorf("expected a 3-character prefix to match nothing, got %v", ids(got))
+	}
+	// A full wallet ranks above a prefix of another
+	if results := s.SearchNodes("0xbbbb3333", 0); len(results) != 1 || results[0].NodeID != opbnb.ID || results[0].Score != 90 {
+		t.Errorf("expected the opbnb node by wallet, got %+v", results)
+	}
+
+	// Tags, and every word has to match
+	if got := ids(s.SearchNodes("bsc", 0)); len(got) != 2 {
+		t.Errorf("expected both bsc nodes, got %v", got)
+	}
+	if got := ids(s.SearchNodes("bsc erigon", 0)); len(got) != 1 || got[0] != archive.ID {
+		t.Errorf("expected only the erigon archive node, got %v", got)
+	}
+	if got := s.SearchNodes("bsc opbnb", 0); len(got) != 0 {
+		t.Errorf("expected no node on both chains, got %v", ids(got))
+	}
+	if got := s.SearchNodes("local-prover", 1); len(got) != 1 {
+		t.Errorf("expected the limit applied, got %v", ids(got))
+	}
+}
+
+func TestVerificationFeatures(t *testing.T) {
+	s := NewStore()
+	a := s.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+	b := s.RegisterNode("0xb", types.BscFull, types.LocalProver, "", "")
+	conn := types.ConnectionFingerprint{RemoteAddr: "203.0.113.7"}
+	s.RecordSubmissionFingerprint(a.ID, "shared", conn, 0)
+	s.RecordSubmissionFingerprint(b.ID, "shared", conn, 0)
+
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	age := uint64(1234)
+	s.RecordVerificationResult(&types.VerificationResult{ChallengeID: "c2", NodeID: b.ID, Passed: false, FailureKind: types.FailureWrongAnswer, ChallengeType: types.BlockHash, ResponseTimeMs: 900, Timestamp: day.Add(15 * time.Hour).UnixMilli()})
+	s.RecordVerificationResult(&types.VerificationResult{ChallengeID: "c1", NodeID: a.ID, Passed: true, ChallengeType: types.BlockHash, ResponseTimeMs: 120, BlockAge: &age, Timestamp: day.Add(3 * time.Hour).UnixMilli()})
+	s.RecordVerificationResult(&types.VerificationResult{ChallengeID: "c0", NodeID: a.ID, Passed: true, Timestamp: day.Add(-time.Hour).UnixMilli()})
+
+	rows := s.VerificationFeatures(day.UnixMilli(), "salt")
+	if len(rows) != 2 {
+		t.Fatalf("expected the two verifications since the cutoff, got %+v", rows)
+	}
+	first, second := rows[0], rows[1]
+	if first.HourOfDay != 3 || first.Day != "2026-03-01" || first.BlockAge == nil || *first.BlockAge != age || !first.Passed {
+		t.Errorf("unexpected first row: %+v", first)
+	}
+	if second.HourOfDay != 15 || second.Passed || second.FailureKind != types.FailureWrongAnswer || second.BlockAge != nil {
+		t.Errorf("unexpected second row: %+v", second)
+	}
+
+	// Pseudonyms group rows but don't give the node away, and change with the salt
+	if first.Node == second.Node || strings.Contains(first.Node, a.ID) {
+		t.Errorf("expected distinct, opaque node pseudonyms, got %q and %q", first.Node, second.Node)
+	}
+	if first.Cluster == "" || first.Cluster != second.Cluster || first.ClusterWallets != 2 || first.Cluster == "shared" {
+		t.Errorf("expected both nodes in one pseudonymous cluster, got %+v", rows)
+	}
+	if other := s.VerificationFeatures(day.UnixMilli(), "pepper"); other[0].Node == first.Node {
+		t.Error("expected a different salt to give different pseudonyms")
+	}
+}
+
+func TestAnnouncementsExpire(t *testing.T) {
+	s := NewStore()
+	now := time.Now().UnixMilli()
+	s.PostAnnouncement(types.AnnounceRewards, "Double points weekend", "", now+1000, now)
+	kept := s.PostAnnouncement(types.AnnounceChallenges, "New challenge type", "", 0, now)
+
+	if got := s.GetAnnouncements(now); len(got) != 2 {
+		t.Fatalf("expected both announcements up, got %+v", got)
+	}
+	if got := s.GetAnnouncements(now + 1000); len(got) != 1 || got[0].ID != kept.ID {
+		t.Errorf("expected the expired one gone, got %+v", got)
+	}
+	if err := s.RetractAnnouncement(kept.ID); err != nil || len(s.GetAnnouncements(now)) != 1 {
+		t.Errorf("expected only the expiring one left, got %v", err)
+	}
+	if err := s.RetractAnnouncement(kept.ID); err != ErrAnnouncementNotFound {
+		t.Errorf("expected retracting twice to fail, got %v", err)
+	}
+}
+
+func TestServiceMaintenance(t *testing.T) {
+	s := NewStore()
+	s.SetSilentAfter(24 * time.Hour)
+	node := s.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+
+	now := time.Now().UnixMilli()
+	started := s.StartServiceMaintenance("database upgrade", now+60*60*1000, now)
+	if started.StartedAt != now || s.ServiceMaintenance() == nil {
+		t.Fatalf("expected maintenance under way, got %+v", started)
+	}
+	// Changing it keeps when it started
+	if updated := s.StartServiceMaintenance("database upgrade, running late", 0, now+1000); updated.StartedAt != now || updated.Until != 0 {
+		t.Errorf("expected the same maintenance updated, got %+v", updated)
+	}
+
+	// The node can't get through, so it isn't silenced
+	later := now + 25*60*60*1000
+	if silenced := s.InactivateSilentNodes(later); len(silenced) != 0 {
+		t.Errorf("nothing should be silenced during maintenance, got %v", silenced)
+	}
+
+	if _, err := s.EndServiceMaintenance(later); err != nil || s.ServiceMaintenance() != nil {
+		t.Fatalf("expected maintenance over, got %v", err)
+	}
+	if _, err := s.EndServiceMaintenance(later); err != ErrNotInMaintenance {
+		t.Errorf("expected ending twice to fail, got %v", err)
+	}
+
+	// Its silence counts from the end of maintenance
+	if silenced := s.InactivateSilentNodes(later + 60*60*1000); len(silenced) != 0 {
+		t.Errorf("expected a grace period after maintenance, got %v", silenced)
+	}
+	if silenced := s.InactivateSilentNodes(later + 25*60*60*1000); len(silenced) != 1 || silenced[0] != node.ID {
+		t.Errorf("expected the node silenced a day after maintenance, got %v", silenced)
+	}
+}
+
+func TestRecordSubmission(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+	other := s.RegisterNode("0xb", types.BscFull, types.LocalProver, "", "")
+
+	first := s.RecordSubmission("c1", types.SubmissionAttempt{NodeID: node.ID, AnswerHash: "aa", Passed: true})
+	repeat := s.RecordSubmission("c1", types.SubmissionAttempt{NodeID: node.ID, AnswerHash: "aa", Passed: true, Repeat: true})
+	elsewhere := s.RecordSubmission("c1", types.SubmissionAttempt{NodeID: other.ID, AnswerHash: "bb"})
+	if first.Conflicting || repeat.Conflicting || elsewhere.Conflicting {
+		t.Fatal("the same answer again, or another node's, isn't a conflict")
+	}
+	if len(s.GetNode(node.ID).SuspiciousEvents) != 0 {
+		t.Fatal("nothing suspicious yet")
+	}
+
+	// A different answer from the same node is; only the first one raises an event
+	if changed := s.RecordSubmission("c1", types.SubmissionAttempt{NodeID: node.ID, AnswerHash: "cc"}); !changed.Conflicting {
+		t.Error("expected a different answer to conflict")
+	}
+	s.RecordSubmission("c1", types.SubmissionAttempt{NodeID: node.ID, AnswerHash: "dd"})
+	if got := s.GetNode(node.ID); len(got.SuspiciousEvents) != 1 || got.WarningCount != 1 {
+		t.Errorf("expected one suspicious event for the challenge, got %+v", got.SuspiciousEvents)
+	}
+
+	attempts := s.SubmissionAttempts("c1")
+	if len(attempts) != 5 || !attempts[1].Repeat || !attempts[4].Conflicting {
+		t.Errorf("expected every submission kept in order, got %+v", attempts)
+	}
+	for i := 0; i < maxAttemptsPerChallenge; i++ {
+		s.RecordSubmission("c1", types.SubmissionAttempt{NodeID: node.ID, AnswerHash: "dd"})
+	}
+	if n := len(s.SubmissionAttempts("c1")); n != maxAttemptsPerChallenge {
+		t.Errorf("expected submissions capped at %d, got %d", maxAttemptsPerChallenge, n)
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	s := NewStore()
+	s.SetFingerprintWalletThreshold(2)
+	now := time.Now().UnixMilli()
+	a := s.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+	b := s.RegisterNode("0xb", types.BscFull, types.LocalProver, "", "")
+	conn := types.ConnectionFingerprint{ClientSignature: "abc", RemoteAddr: "203.0.113.7"}
+	s.RecordSubmissionFingerprint(a.ID, "fp1", conn, now)
+	s.RecordSubmissionFingerprint(b.ID, "fp1", conn, now)
+	s.RecordVerificationResult(&types.VerificationResult{ChallengeID: "c1", NodeID: a.ID, Passed: true, Timestamp: now})
+	s.RecordSubmission("c1", types.SubmissionAttempt{NodeID: a.ID, AnswerHash: "aa", Passed: true})
+	s.ChargeChallenge(a.ID, types.BlockHash, now)
+	s.PostAnnouncement(types.AnnounceGeneral, "Hello", "", 0, now)
+	s.ModerationLog().Append(modlog.ActionWarn, "admin-1", a.ID, "", nil, now)
+
+	var buf bytes.Buffer
+	if err := s.WriteCheckpoint(&buf); err != nil {
+		t.Fatalf("checkpoint failed: %v", err)
+	}
+	restored := NewStore()
+	restored.SetFingerprintWalletThreshold(2)
+	if _, err := restored.ReadCheckpoint(&buf); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+
+	if node := restored.GetNode(a.ID); node == nil || node.TotalChallengesPassed != 1 || node.WarningCount != 1 {
+		t.Errorf("expected the node restored with its record, got %+v", node)
+	}
+	if clusters := restored.GetFingerprintClusters(); len(clusters) != 1 || len(clusters[0].Wallets) != 2 {
+		t.Errorf("expected the fingerprint cluster restored, got %+v", clusters)
+	}
+	if len(restored.SubmissionAttempts("c1")) != 1 || restored.ChallengeBudget(a.ID, now).Issued[types.BlockHash] != 1 {
+		t.Error("expected submissions and budgets restored")
+	}
+	if restored.ModerationLog().Head() != s.ModerationLog().Head() {
+		t.Error("expected the moderation log restored")
+	}
+	// IDs carry on rather than starting over
+	if next := restored.PostAnnouncement(types.AnnounceGeneral, "Again", "", 0, now); next.ID != 2 {
+		t.Errorf("expected announcement IDs to carry on, got %d", next.ID)
+	}
+
+	// A cluster already flagged isn't flagged again
+	restored.RecordSubmissionFingerprint(a.ID, "fp1", conn, now+1)
+	if restored.GetNode(a.ID).WarningCount != 1 {
+		t.Error("expected the cluster's flagged nodes restored too")
+	}
+}
+
+func TestSQLiteStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "depin.db")
+	durable, takenAt, err := OpenSQLite(path, NewStore())
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	if takenAt != 0 {
+		t.Errorf("expected no checkpoint in a new file, got one from %d", takenAt)
+	}
+	now := time.Now().UnixMilli()
+	age := uint64(3)
+	node := durable.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+	durable.RecordVerificationResult(&types.VerificationResult{ChallengeID: "c1", NodeID: node.ID, Passed: true, BlockAge: &age, Timestamp: now})
+	for i := 0; i < maxHeartbeats+5; i++ {
+		durable.RecordHeartbeat(&types.HeartbeatRecord{NodeID: node.ID, Timestamp: now + int64(i), BlockNumber: uint64(i), IsSynced: true})
+	}
+	tiers, _ := staking.ParseTiers("1=50")
+	durable.SetStakingTiers(tiers)
+	for _, checkedAt := range []int64{now - staking.ReadInterval.Milliseconds(), now} {
+		durable.RecordStakes(map[string]types.WalletStake{"0xa": {Wei: "2000000000000000000", Tokens: 2, CheckedAt: checkedAt}})
+	}
+	durable.AwardUptimePoints(node.ID, 5)
+	if _, err := durable.Checkpoint(); err != nil {
+		t.Fatalf("checkpoint failed: %v", err)
+	}
+
+	// Nothing changed: only the checkpoint row is written again
+	if rows, err := durable.Checkpoint(); err != nil || rows != 1 {
+		t.Fatalf("expected only the checkpoint row rewritten, got %d rows (%v)", rows, err)
+	}
+	// One new verification is one node row (its counts changed), one
+	// verification and its points
+	durable.RecordVerificationResult(&types.VerificationResult{ChallengeID: "c2", NodeID: node.ID, Passed: false, Timestamp: now + 1})
+	durable.AdjustPoints(node.ID, 5, "goodwill", now+1)
+	if rows, err := durable.Checkpoint(); err != nil || rows != 4 {
+		t.Fatalf("expected the node, a verification, a points entry and the checkpoint row, got %d rows (%v)", rows, err)
+	}
+
+	// The tables can be queried on their own
+	count := func(query string) (n int) {
+		if err := durable.db.QueryRow(query).Scan(&n); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+		return n
+	}
+	if n := count(`SELECT COUNT(*) FROM depin_heartbeats`); n != maxHeartbeats {
+		t.Errorf("expected heartbeats trimmed to %d, got %d", maxHeartbeats, n)
+	}
+	if n := count(`SELECT COUNT(*) FROM depin_verifications WHERE passed = 1`); n != 1 {
+		t.Errorf("expected one passed verification, got %d", n)
+	}
+	if n := count(`SELECT SUM(amount) FROM depin_points`); uint64(n) != durable.GetNode(node.ID).TotalPoints {
+		t.Errorf("expected the points table to add up to the node's %d, got %d", durable.GetNode(node.ID).TotalPoints, n)
+	}
+	durable.Close()
+
+	reopened, takenAt, err := OpenSQLite(path, NewStore())
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	if takenAt == 0 {
+		t.Error("expected the checkpoint's time")
+	}
+	if got := reopened.GetNode(node.ID); got == nil || got.TotalChallengesPassed != 1 || got.TotalChallengesFailed != 1 {
+		t.Errorf("expected the node back from the file, got %+v", got)
+	}
+	if got := reopened.GetNodesByWallet("0xa"); len(got) != 1 {
+		t.Errorf("expected the node found by wallet, got %d", len(got))
+	}
+	history := reopened.GetVerificationHistory(node.ID, 10)
+	if len(history) != 2 || history[0].BlockAge == nil || *history[0].BlockAge != age {
+		t.Errorf("expected both verifications back with the block age, got %+v", history)
+	}
+	if ledger := reopened.PointsLedger(node.ID); !reflect.DeepEqual(ledger, durable.PointsLedger(node.ID)) || ledger[1].StakeBonusPercent != 50 || ledger[1].StakeTokens != 2 {
+		t.Errorf("expected the whole ledger back with the stake the uptime was paid with, got %+v", ledger)
+	}
+	// Loading doesn't count as a change
+	if rows, err := reopened.Checkpoint(); err != nil || rows != 1 {
+		t.Errorf("expected nothing to write after a reload, got %d rows (%v)", rows, err)
+	}
+
+	// A restore replaces everything, so the tables are rewritten
+	fresh := NewStore()
+	other := fresh.RegisterNode("0xb", types.BscFast, types.LocalProver, "", "")
+	var backup bytes.Buffer
+	fresh.Export(&backup)
+	if _, err := reopened.Import(&backup); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reopened.Checkpoint(); err != nil {
+		t.Fatalf("checkpoint after restore failed: %v", err)
+	}
+	var ids []string
+	result, _ := reopened.db.Query(`SELECT id FROM depin_nodes`)
+	for result.Next() {
+		var id string
+		result.Scan(&id)
+		ids = append(ids, id)
+	}
+	result.Close()
+	if len(ids) != 1 || ids[0] != other.ID {
+		t.Errorf("expected only the restored node left, got %v", ids)
+	}
+	reopened.Close()
+}
+
+// A database written before the tables existed holds everything in the
+// checkpoint row. It still loads, and the next checkpoint fills the tables.
+func TestSQLiteCheckpointOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "depin.db")
+	old := NewStore()
+	node := old.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+	var state bytes.Buffer
+	if err := old.WriteCheckpoint(&state); err != nil {
+		t.Fatal(err)
+	}
+	durable, _, err := OpenSQLite(path, NewStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := durable.db.Exec(`INSERT INTO depin_checkpoint (id, taken_at, state) VALUES (1, 1000, ?)`, state.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	durable.Close()
+
+	durable, takenAt, err := OpenSQLite(path, NewStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer durable.Close()
+	if takenAt == 0 || durable.GetNode(node.ID) == nil {
+		t.Fatalf("expected the node from the old checkpoint, got %+v", durable.GetNode(node.ID))
+	}
+	if rows, err := durable.Checkpoint(); err != nil || rows < 3 {
+		t.Errorf("expected the node and its points moved into the tables, got %d rows (%v)", rows, err)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	s := NewStore()
+	node := s.RegisterNode("0xa", types.BscFull, types.LocalProver, "", "")
+	s.ModerationLog().Append(modlog.ActionWarn, "admin-1", node.ID, "", nil, 1000)
+
+	var backup bytes.Buffer
+	if err := s.Export(&backup); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	restored := NewStore()
+	if _, err := restored.Import(strings.NewReader("not a backup")); err != ErrNotBackup {
+		t.Errorf("expected anything but a backup refused, got %v", err)
+	}
+	var snapshot bytes.Buffer
+	s.WriteSnapshot(&snapshot)
+	if _, err := restored.Import(&snapshot); err != ErrNotBackup {
+		t.Errorf("expected a replica snapshot refused, got %v", err)
+	}
+
+	if _, err := restored.Import(&backup); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if got := restored.GetNode(node.ID); got == nil || got.TotalPoints != node.TotalPoints {
+		t.Errorf("expected the node and its points back, got %+v", got)
+	}
+	if len(restored.PointsLedger(node.ID)) != 1 || restored.ModerationLog().Len() != 1 {
+		t.Error("expected the ledger and moderation log restored too")
+	}
+}
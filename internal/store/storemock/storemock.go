@@ -0,0 +1,1143 @@
+// Code generated by internal/store/mockgen. DO NOT EDIT.
+
+// Package storemock is a store.Store for tests.
+package storemock
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/budget"
+	"github.com/depinonbnb/depin/internal/clientversion"
+	"github.com/depinonbnb/depin/internal/clock"
+	"github.com/depinonbnb/depin/internal/hardfork"
+	"github.com/depinonbnb/depin/internal/modlog"
+	"github.com/depinonbnb/depin/internal/notify"
+	"github.com/depinonbnb/depin/internal/proverbuild"
+	"github.com/depinonbnb/depin/internal/rates"
+	"github.com/depinonbnb/depin/internal/staking"
+	"github.com/depinonbnb/depin/internal/store"
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// Stub a method by setting its Func field. Methods left unset return
+// zero values.
+type Store struct {
+	RegisterNodeFunc                  func(string, types.NodeType, types.VerificationMethod, string, string) *types.NodeRegistration
+	GetNodeFunc                       func(string) *types.NodeRegistration
+	GetNodesByWalletFunc              func(string) []*types.NodeRegistration
+	SearchNodesFunc                   func(string, int) []types.SearchResult
+	GetAllActiveNodesFunc             func() []*types.NodeRegistration
+	UpdateNodeFunc                    func(string, func(*types.NodeRegistration)) *types.NodeRegistration
+	PauseNodeFunc                     func(string, int64) (*types.NodeRegistration, error)
+	ResumeNodeFunc                    func(string, int64) (*types.NodeRegistration, error)
+	ChangeVerificationMethodFunc      func(string, types.VerificationMethod, string, string, int64) (*types.NodeRegistration, error)
+	ExpireMaintenanceFunc             func(int64) []string
+	InactivateSilentNodesFunc         func(int64) []string
+	EnforceUptimeFunc                 func(int64) []string
+	GetNodeStatsFunc                  func(string) *types.NodeStats
+	GetSiblingsFunc                   func(string) []types.SiblingNode
+	ProjectPointsFunc                 func(string, int64) *types.PointsProjection
+	PointsLedgerFunc                  func(string) []types.PointsEntry
+	ReversePointsFunc                 func(string, uint64, string, int64) (types.PointsEntry, error)
+	AdjustPointsFunc                  func(string, int64, string, int64) (types.PointsEntry, error)
+	CompensateOutageFunc              func(types.Outage, int64) []string
+	RecordNodeCountryFunc             func(string, string)
+	RecordClientVersionFunc           func(string, string)
+	RecordProverBuildFunc             func(string, string)
+	RecordStakesFunc                  func(map[string]types.WalletStake)
+	RecordVerificationResultFunc      func(*types.VerificationResult)
+	ClaimChallengeRequestFunc         func(string, int64) bool
+	ChargeChallengeFunc               func(string, types.ChallengeType, int64) bool
+	HasChallengeBudgetFunc            func(string, int64) bool
+	ChallengeBudgetFunc               func(string, int64) types.ChallengeBudget
+	RecordPollFunc                    func(string, int64)
+	RecordSurpriseIssuedFunc          func(*types.Challenge)
+	ExpireSurprisesFunc               func(int64)
+	GetChallengeReplayFunc            func(string) *types.ChallengeReplay
+	RecordSubmissionFunc              func(string, types.SubmissionAttempt) types.SubmissionAttempt
+	SubmissionAttemptsFunc            func(string) []types.SubmissionAttempt
+	GetLatencyPercentilesFunc         func(string) types.LatencyPercentiles
+	GetNetworkFailureCountsFunc       func() map[types.FailureKind]uint64
+	GetPassRatesByNodeTypeFunc        func() map[types.NodeType]*types.PassRate
+	GetTrustScoreFunc                 func(string) (types.TrustScore, bool)
+	GetHealthFunc                     func(string) (types.NodeHealth, bool)
+	RecordHeartbeatFunc               func(*types.HeartbeatRecord)
+	ClaimHeartbeatFunc                func(string, int64) bool
+	RecordMissedHeartbeatFunc         func(string, int64)
+	GetRecentUptimePercentFunc        func(string, int) float64
+	GetUptimeCalendarFunc             func(string, int, time.Month) []types.UptimeDay
+	GetWalletStatsFunc                func(string) *types.WalletStats
+	GetWalletStatsBatchFunc           func([]string) map[string]*types.WalletStats
+	AddSuspiciousEventFunc            func(string, string)
+	SetNodeCheatStatusFunc            func(string, types.CheatStatus, string) bool
+	CountByCheatStatusFunc            func() map[types.CheatStatus]int
+	GetFlaggedNodesFunc               func() []*types.NodeRegistration
+	RecordSubmissionFingerprintFunc   func(string, string, types.ConnectionFingerprint, int64)
+	GetFingerprintClustersFunc        func() []types.FingerprintCluster
+	VerificationFeaturesFunc          func(int64, string) []types.VerificationFeatures
+	FileReportFunc                    func(string, string, string, int64) (*types.CheatReport, error)
+	GetOpenReportsFunc                func(string) []types.CheatReport
+	GetReporterReputationFunc         func(string) types.ReporterReputation
+	RequestNodeBanFunc                func(string, string, string, int64) (*types.PendingBan, error)
+	RequestWalletBanFunc              func(string, string, *uint64, string, int64) (*types.PendingBan, *types.WalletBan, error)
+	GetPendingBansFunc                func(int64) []types.PendingBan
+	GetWalletBanFunc                  func(string, int64) *types.WalletBan
+	GetWalletBansFunc                 func() []types.WalletBan
+	LiftWalletBanFunc                 func(string, int64) error
+	ModerationLogFunc                 func() *modlog.Log
+	ProposeReclassificationFunc       func(string, types.NodeType, string, int64) (*types.Reclassification, bool, error)
+	GetReclassificationFunc           func(string) *types.Reclassification
+	GetOpenReclassificationsFunc      func() []types.Reclassification
+	ResolveReclassificationFunc       func(string, bool, types.NodeType, string, int64) (*types.Reclassification, error)
+	ApplyDueReclassificationsFunc     func(int64) []string
+	RecalculateRegionWeightsFunc      func(int64) types.RegionWeights
+	RegionWeightsFunc                 func() types.RegionWeights
+	HardForkReadinessFunc             func(int64) []types.ForkReadiness
+	NetworkFunc                       func() types.Network
+	SetNetworkFunc                    func(types.Network)
+	SetNotifierFunc                   func(notify.Notifier)
+	SetClockFunc                      func(clock.Clock)
+	SetEscalationThresholdsFunc       func(uint8, uint8)
+	SetFingerprintWalletThresholdFunc func(int)
+	SetWalletBanCooldownFunc          func(time.Duration)
+	SetBanApprovalWindowFunc          func(time.Duration)
+	SetTrustWeightedPointsFunc        func(bool)
+	SetSilentAfterFunc                func(time.Duration)
+	SetMinClientVersionsFunc          func(clientversion.Minimums)
+	SetOfficialBuildsFunc             func(proverbuild.Allowlist)
+	SetHardForksFunc                  func([]hardfork.Fork)
+	SetChallengeCapsFunc              func(budget.Caps)
+	SetLeaderboardRulesFunc           func(types.LeaderboardRules)
+	SetDiversityBonusFunc             func(uint64)
+	SetStakingTiersFunc               func(staking.Tiers)
+	SetUptimePenaltyFunc              func(uint64)
+	SetPointsRatesFunc                func(rates.Rates)
+	PointsPerHourFunc                 func(types.NodeType) uint64
+	LeaderboardRulesFunc              func() types.LeaderboardRules
+	NetworkActivityFunc               func(int64, int64) types.NetworkActivity
+	SizesFunc                         func() map[string]int
+	UsageFunc                         func() types.StoreUsage
+	CompactFunc                       func(int64) types.Compaction
+	StartRecomputeFunc                func(int64) (types.Recompute, bool)
+	RecomputeStepFunc                 func(int, int64) types.Recompute
+	RecomputeStatusFunc               func() (types.Recompute, bool)
+	CreateAPIKeyFunc                  func(string, string, string, int64) (string, types.APIKey, error)
+	LookupAPIKeyFunc                  func(string) (types.APIKey, bool)
+	GetAPIKeyFunc                     func(string) (types.APIKey, bool)
+	GetAPIKeysFunc                    func(string) []types.APIKey
+	SetAPIKeyPlanFunc                 func(string, string) (types.APIKey, error)
+	RevokeAPIKeyFunc                  func(string, int64) (types.APIKey, error)
+	EnrollTOTPFunc                    func(string, string, int64) error
+	ConfirmTOTPFunc                   func(string, string, int64) error
+	CheckTOTPFunc                     func(string, string, int64) error
+	DisableTOTPFunc                   func(string, string, int64) error
+	RequestTOTPResetFunc              func(string, string, string, int64) (*types.PendingBan, error)
+	TOTPStatusFunc                    func(string) (bool, int64)
+	CloseEpochFunc                    func(int64, int64) (*types.RewardEpoch, error)
+	GetEpochsFunc                     func() []types.RewardEpoch
+	GetEpochFunc                      func(uint64) *types.RewardEpoch
+	GetRewardClaimsFunc               func(string) []types.RewardClaim
+	PostAnnouncementFunc              func(types.AnnouncementKind, string, string, int64, int64) types.Announcement
+	GetAnnouncementsFunc              func(int64) []types.Announcement
+	RetractAnnouncementFunc           func(uint64) error
+	StartServiceMaintenanceFunc       func(string, int64, int64) types.ServiceMaintenance
+	EndServiceMaintenanceFunc         func(int64) (types.ServiceMaintenance, error)
+	ServiceMaintenanceFunc            func() *types.ServiceMaintenance
+	WriteSnapshotFunc                 func(io.Writer) error
+	ReadSnapshotFunc                  func(io.Reader) (int64, error)
+	ExportFunc                        func(io.Writer) error
+	ImportFunc                        func(io.Reader) (int64, error)
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+var _ store.Store = (*Store)(nil)
+
+// How many times a method was called
+func (m *Store) Calls(method string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls[method]
+}
+
+func (m *Store) record(method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.calls == nil {
+		m.calls = make(map[string]int)
+	}
+	m.calls[method]++
+}
+
+func (m *Store) RegisterNode(p0 string, p1 types.NodeType, p2 types.VerificationMethod, p3 string, p4 string) (r0 *types.NodeRegistration) {
+	m.record("RegisterNode")
+	if m.RegisterNodeFunc != nil {
+		return m.RegisterNodeFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) GetNode(p0 string) (r0 *types.NodeRegistration) {
+	m.record("GetNode")
+	if m.GetNodeFunc != nil {
+		return m.GetNodeFunc(p0)
+	}
+	return
+}
+
+func (m *Store) GetNodesByWallet(p0 string) (r0 []*types.NodeRegistration) {
+	m.record("GetNodesByWallet")
+	if m.GetNodesByWalletFunc != nil {
+		return m.GetNodesByWalletFunc(p0)
+	}
+	return
+}
+
+func (m *Store) SearchNodes(p0 string, p1 int) (r0 []types.SearchResult) {
+	m.record("SearchNodes")
+	if m.SearchNodesFunc != nil {
+		return m.SearchNodesFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetAllActiveNodes() (r0 []*types.NodeRegistration) {
+	m.record("GetAllActiveNodes")
+	if m.GetAllActiveNodesFunc != nil {
+		return m.GetAllActiveNodesFunc()
+	}
+	return
+}
+
+func (m *Store) UpdateNode(p0 string, p1 func(*types.NodeRegistration)) (r0 *types.NodeRegistration) {
+	m.record("UpdateNode")
+	if m.UpdateNodeFunc != nil {
+		return m.UpdateNodeFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) PauseNode(p0 string, p1 int64) (r0 *types.NodeRegistration, r1 error) {
+	m.record("PauseNode")
+	if m.PauseNodeFunc != nil {
+		return m.PauseNodeFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ResumeNode(p0 string, p1 int64) (r0 *types.NodeRegistration, r1 error) {
+	m.record("ResumeNode")
+	if m.ResumeNodeFunc != nil {
+		return m.ResumeNodeFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ChangeVerificationMethod(p0 string, p1 types.VerificationMethod, p2 string, p3 string, p4 int64) (r0 *types.NodeRegistration, r1 error) {
+	m.record("ChangeVerificationMethod")
+	if m.ChangeVerificationMethodFunc != nil {
+		return m.ChangeVerificationMethodFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) ExpireMaintenance(p0 int64) (r0 []string) {
+	m.record("ExpireMaintenance")
+	if m.ExpireMaintenanceFunc != nil {
+		return m.ExpireMaintenanceFunc(p0)
+	}
+	return
+}
+
+func (m *Store) InactivateSilentNodes(p0 int64) (r0 []string) {
+	m.record("InactivateSilentNodes")
+	if m.InactivateSilentNodesFunc != nil {
+		return m.InactivateSilentNodesFunc(p0)
+	}
+	return
+}
+
+func (m *Store) EnforceUptime(p0 int64) (r0 []string) {
+	m.record("EnforceUptime")
+	if m.EnforceUptimeFunc != nil {
+		return m.EnforceUptimeFunc(p0)
+	}
+	return
+}
+
+func (m *Store) GetNodeStats(p0 string) (r0 *types.NodeStats) {
+	m.record("GetNodeStats")
+	if m.GetNodeStatsFunc != nil {
+		return m.GetNodeStatsFunc(p0)
+	}
+	return
+}
+
+func (m *Store) GetSiblings(p0 string) (r0 []types.SiblingNode) {
+	m.record("GetSiblings")
+	if m.GetSiblingsFunc != nil {
+		return m.GetSiblingsFunc(p0)
+	}
+	return
+}
+
+func (m *Store) ProjectPoints(p0 string, p1 int64) (r0 *types.PointsProjection) {
+	m.record("ProjectPoints")
+	if m.ProjectPointsFunc != nil {
+		return m.ProjectPointsFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) PointsLedger(p0 string) (r0 []types.PointsEntry) {
+	m.record("PointsLedger")
+	if m.PointsLedgerFunc != nil {
+		return m.PointsLedgerFunc(p0)
+	}
+	return
+}
+
+func (m *Store) ReversePoints(p0 string, p1 uint64, p2 string, p3 int64) (r0 types.PointsEntry, r1 error) {
+	m.record("ReversePoints")
+	if m.ReversePointsFunc != nil {
+		return m.ReversePointsFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) AdjustPoints(p0 string, p1 int64, p2 string, p3 int64) (r0 types.PointsEntry, r1 error) {
+	m.record("AdjustPoints")
+	if m.AdjustPointsFunc != nil {
+		return m.AdjustPointsFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) CompensateOutage(p0 types.Outage, p1 int64) (r0 []string) {
+	m.record("CompensateOutage")
+	if m.CompensateOutageFunc != nil {
+		return m.CompensateOutageFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) RecordNodeCountry(p0 string, p1 string) {
+	m.record("RecordNodeCountry")
+	if m.RecordNodeCountryFunc != nil {
+		m.RecordNodeCountryFunc(p0, p1)
+	}
+}
+
+func (m *Store) RecordClientVersion(p0 string, p1 string) {
+	m.record("RecordClientVersion")
+	if m.RecordClientVersionFunc != nil {
+		m.RecordClientVersionFunc(p0, p1)
+	}
+}
+
+func (m *Store) RecordProverBuild(p0 string, p1 string) {
+	m.record("RecordProverBuild")
+	if m.RecordProverBuildFunc != nil {
+		m.RecordProverBuildFunc(p0, p1)
+	}
+}
+
+func (m *Store) RecordStakes(p0 map[string]types.WalletStake) {
+	m.record("RecordStakes")
+	if m.RecordStakesFunc != nil {
+		m.RecordStakesFunc(p0)
+	}
+}
+
+func (m *Store) RecordVerificationResult(p0 *types.VerificationResult) {
+	m.record("RecordVerificationResult")
+	if m.RecordVerificationResultFunc != nil {
+		m.RecordVerificationResultFunc(p0)
+	}
+}
+
+func (m *Store) ClaimChallengeRequest(p0 string, p1 int64) (r0 bool) {
+	m.record("ClaimChallengeRequest")
+	if m.ClaimChallengeRequestFunc != nil {
+		return m.ClaimChallengeRequestFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ChargeChallenge(p0 string, p1 types.ChallengeType, p2 int64) (r0 bool) {
+	m.record("ChargeChallenge")
+	if m.ChargeChallengeFunc != nil {
+		return m.ChargeChallengeFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) HasChallengeBudget(p0 string, p1 int64) (r0 bool) {
+	m.record("HasChallengeBudget")
+	if m.HasChallengeBudgetFunc != nil {
+		return m.HasChallengeBudgetFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ChallengeBudget(p0 string, p1 int64) (r0 types.ChallengeBudget) {
+	m.record("ChallengeBudget")
+	if m.ChallengeBudgetFunc != nil {
+		return m.ChallengeBudgetFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) RecordPoll(p0 string, p1 int64) {
+	m.record("RecordPoll")
+	if m.RecordPollFunc != nil {
+		m.RecordPollFunc(p0, p1)
+	}
+}
+
+func (m *Store) RecordSurpriseIssued(p0 *types.Challenge) {
+	m.record("RecordSurpriseIssued")
+	if m.RecordSurpriseIssuedFunc != nil {
+		m.RecordSurpriseIssuedFunc(p0)
+	}
+}
+
+func (m *Store) ExpireSurprises(p0 int64) {
+	m.record("ExpireSurprises")
+	if m.ExpireSurprisesFunc != nil {
+		m.ExpireSurprisesFunc(p0)
+	}
+}
+
+func (m *Store) GetChallengeReplay(p0 string) (r0 *types.ChallengeReplay) {
+	m.record("GetChallengeReplay")
+	if m.GetChallengeReplayFunc != nil {
+		return m.GetChallengeReplayFunc(p0)
+	}
+	return
+}
+
+func (m *Store) RecordSubmission(p0 string, p1 types.SubmissionAttempt) (r0 types.SubmissionAttempt) {
+	m.record("RecordSubmission")
+	if m.RecordSubmissionFunc != nil {
+		return m.RecordSubmissionFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) SubmissionAttempts(p0 string) (r0 []types.SubmissionAttempt) {
+	m.record("SubmissionAttempts")
+	if m.SubmissionAttemptsFunc != nil {
+		return m.SubmissionAttemptsFunc(p0)
+	}
+	return
+}
+
+func (m *Store) GetLatencyPercentiles(p0 string) (r0 types.LatencyPercentiles) {
+	m.record("GetLatencyPercentiles")
+	if m.GetLatencyPercentilesFunc != nil {
+		return m.GetLatencyPercentilesFunc(p0)
+	}
+	return
+}
+
+func (m *Store) GetNetworkFailureCounts() (r0 map[types.FailureKind]uint64) {
+	m.record("GetNetworkFailureCounts")
+	if m.GetNetworkFailureCountsFunc != nil {
+		return m.GetNetworkFailureCountsFunc()
+	}
+	return
+}
+
+func (m *Store) GetPassRatesByNodeType() (r0 map[types.NodeType]*types.PassRate) {
+	m.record("GetPassRatesByNodeType")
+	if m.GetPassRatesByNodeTypeFunc != nil {
+		return m.GetPassRatesByNodeTypeFunc()
+	}
+	return
+}
+
+func (m *Store) GetTrustScore(p0 string) (r0 types.TrustScore, r1 bool) {
+	m.record("GetTrustScore")
+	if m.GetTrustScoreFunc != nil {
+		return m.GetTrustScoreFunc(p0)
+	}
+	return
+}
+
+func (m *Store) GetHealth(p0 string) (r0 types.NodeHealth, r1 bool) {
+	m.record("GetHealth")
+	if m.GetHealthFunc != nil {
+		return m.GetHealthFunc(p0)
+	}
+	return
+}
+
+func (m *Store) RecordHeartbeat(p0 *types.HeartbeatRecord) {
+	m.record("RecordHeartbeat")
+	if m.RecordHeartbeatFunc != nil {
+		m.RecordHeartbeatFunc(p0)
+	}
+}
+
+func (m *Store) ClaimHeartbeat(p0 string, p1 int64) (r0 bool) {
+	m.record("ClaimHeartbeat")
+	if m.ClaimHeartbeatFunc != nil {
+		return m.ClaimHeartbeatFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) RecordMissedHeartbeat(p0 string, p1 int64) {
+	m.record("RecordMissedHeartbeat")
+	if m.RecordMissedHeartbeatFunc != nil {
+		m.RecordMissedHeartbeatFunc(p0, p1)
+	}
+}
+
+func (m *Store) GetRecentUptimePercent(p0 string, p1 int) (r0 float64) {
+	m.record("GetRecentUptimePercent")
+	if m.GetRecentUptimePercentFunc != nil {
+		return m.GetRecentUptimePercentFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetUptimeCalendar(p0 string, p1 int, p2 time.Month) (r0 []types.UptimeDay) {
+	m.record("GetUptimeCalendar")
+	if m.GetUptimeCalendarFunc != nil {
+		return m.GetUptimeCalendarFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) GetWalletStats(p0 string) (r0 *types.WalletStats) {
+	m.record("GetWalletStats")
+	if m.GetWalletStatsFunc != nil {
+		return m.GetWalletStatsFunc(p0)
+	}
+	return
+}
+
+func (m *Store) GetWalletStatsBatch(p0 []string) (r0 map[string]*types.WalletStats) {
+	m.record("GetWalletStatsBatch")
+	if m.GetWalletStatsBatchFunc != nil {
+		return m.GetWalletStatsBatchFunc(p0)
+	}
+	return
+}
+
+func (m *Store) AddSuspiciousEvent(p0 string, p1 string) {
+	m.record("AddSuspiciousEvent")
+	if m.AddSuspiciousEventFunc != nil {
+		m.AddSuspiciousEventFunc(p0, p1)
+	}
+}
+
+func (m *Store) SetNodeCheatStatus(p0 string, p1 types.CheatStatus, p2 string) (r0 bool) {
+	m.record("SetNodeCheatStatus")
+	if m.SetNodeCheatStatusFunc != nil {
+		return m.SetNodeCheatStatusFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) CountByCheatStatus() (r0 map[types.CheatStatus]int) {
+	m.record("CountByCheatStatus")
+	if m.CountByCheatStatusFunc != nil {
+		return m.CountByCheatStatusFunc()
+	}
+	return
+}
+
+func (m *Store) GetFlaggedNodes() (r0 []*types.NodeRegistration) {
+	m.record("GetFlaggedNodes")
+	if m.GetFlaggedNodesFunc != nil {
+		return m.GetFlaggedNodesFunc()
+	}
+	return
+}
+
+func (m *Store) RecordSubmissionFingerprint(p0 string, p1 string, p2 types.ConnectionFingerprint, p3 int64) {
+	m.record("RecordSubmissionFingerprint")
+	if m.RecordSubmissionFingerprintFunc != nil {
+		m.RecordSubmissionFingerprintFunc(p0, p1, p2, p3)
+	}
+}
+
+func (m *Store) GetFingerprintClusters() (r0 []types.FingerprintCluster) {
+	m.record("GetFingerprintClusters")
+	if m.GetFingerprintClustersFunc != nil {
+		return m.GetFingerprintClustersFunc()
+	}
+	return
+}
+
+func (m *Store) VerificationFeatures(p0 int64, p1 string) (r0 []types.VerificationFeatures) {
+	m.record("VerificationFeatures")
+	if m.VerificationFeaturesFunc != nil {
+		return m.VerificationFeaturesFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) FileReport(p0 string, p1 string, p2 string, p3 int64) (r0 *types.CheatReport, r1 error) {
+	m.record("FileReport")
+	if m.FileReportFunc != nil {
+		return m.FileReportFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) GetOpenReports(p0 string) (r0 []types.CheatReport) {
+	m.record("GetOpenReports")
+	if m.GetOpenReportsFunc != nil {
+		return m.GetOpenReportsFunc(p0)
+	}
+	return
+}
+
+func (m *Store) GetReporterReputation(p0 string) (r0 types.ReporterReputation) {
+	m.record("GetReporterReputation")
+	if m.GetReporterReputationFunc != nil {
+		return m.GetReporterReputationFunc(p0)
+	}
+	return
+}
+
+func (m *Store) RequestNodeBan(p0 string, p1 string, p2 string, p3 int64) (r0 *types.PendingBan, r1 error) {
+	m.record("RequestNodeBan")
+	if m.RequestNodeBanFunc != nil {
+		return m.RequestNodeBanFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) RequestWalletBan(p0 string, p1 string, p2 *uint64, p3 string, p4 int64) (r0 *types.PendingBan, r1 *types.WalletBan, r2 error) {
+	m.record("RequestWalletBan")
+	if m.RequestWalletBanFunc != nil {
+		return m.RequestWalletBanFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) GetPendingBans(p0 int64) (r0 []types.PendingBan) {
+	m.record("GetPendingBans")
+	if m.GetPendingBansFunc != nil {
+		return m.GetPendingBansFunc(p0)
+	}
+	return
+}
+
+func (m *Store) GetWalletBan(p0 string, p1 int64) (r0 *types.WalletBan) {
+	m.record("GetWalletBan")
+	if m.GetWalletBanFunc != nil {
+		return m.GetWalletBanFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetWalletBans() (r0 []types.WalletBan) {
+	m.record("GetWalletBans")
+	if m.GetWalletBansFunc != nil {
+		return m.GetWalletBansFunc()
+	}
+	return
+}
+
+func (m *Store) LiftWalletBan(p0 string, p1 int64) (r0 error) {
+	m.record("LiftWalletBan")
+	if m.LiftWalletBanFunc != nil {
+		return m.LiftWalletBanFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ModerationLog() (r0 *modlog.Log) {
+	m.record("ModerationLog")
+	if m.ModerationLogFunc != nil {
+		return m.ModerationLogFunc()
+	}
+	return
+}
+
+func (m *Store) ProposeReclassification(p0 string, p1 types.NodeType, p2 string, p3 int64) (r0 *types.Reclassification, r1 bool, r2 error) {
+	m.record("ProposeReclassification")
+	if m.ProposeReclassificationFunc != nil {
+		return m.ProposeReclassificationFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) GetReclassification(p0 string) (r0 *types.Reclassification) {
+	m.record("GetReclassification")
+	if m.GetReclassificationFunc != nil {
+		return m.GetReclassificationFunc(p0)
+	}
+	return
+}
+
+func (m *Store) GetOpenReclassifications() (r0 []types.Reclassification) {
+	m.record("GetOpenReclassifications")
+	if m.GetOpenReclassificationsFunc != nil {
+		return m.GetOpenReclassificationsFunc()
+	}
+	return
+}
+
+func (m *Store) ResolveReclassification(p0 string, p1 bool, p2 types.NodeType, p3 string, p4 int64) (r0 *types.Reclassification, r1 error) {
+	m.record("ResolveReclassification")
+	if m.ResolveReclassificationFunc != nil {
+		return m.ResolveReclassificationFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) ApplyDueReclassifications(p0 int64) (r0 []string) {
+	m.record("ApplyDueReclassifications")
+	if m.ApplyDueReclassificationsFunc != nil {
+		return m.ApplyDueReclassificationsFunc(p0)
+	}
+	return
+}
+
+func (m *Store) RecalculateRegionWeights(p0 int64) (r0 types.RegionWeights) {
+	m.record("RecalculateRegionWeights")
+	if m.RecalculateRegionWeightsFunc != nil {
+		return m.RecalculateRegionWeightsFunc(p0)
+	}
+	return
+}
+
+func (m *Store) RegionWeights() (r0 types.RegionWeights) {
+	m.record("RegionWeights")
+	if m.RegionWeightsFunc != nil {
+		return m.RegionWeightsFunc()
+	}
+	return
+}
+
+func (m *Store) HardForkReadiness(p0 int64) (r0 []types.ForkReadiness) {
+	m.record("HardForkReadiness")
+	if m.HardForkReadinessFunc != nil {
+		return m.HardForkReadinessFunc(p0)
+	}
+	return
+}
+
+func (m *Store) Network() (r0 types.Network) {
+	m.record("Network")
+	if m.NetworkFunc != nil {
+		return m.NetworkFunc()
+	}
+	return
+}
+
+func (m *Store) SetNetwork(p0 types.Network) {
+	m.record("SetNetwork")
+	if m.SetNetworkFunc != nil {
+		m.SetNetworkFunc(p0)
+	}
+}
+
+func (m *Store) SetNotifier(p0 notify.Notifier) {
+	m.record("SetNotifier")
+	if m.SetNotifierFunc != nil {
+		m.SetNotifierFunc(p0)
+	}
+}
+
+func (m *Store) SetClock(p0 clock.Clock) {
+	m.record("SetClock")
+	if m.SetClockFunc != nil {
+		m.SetClockFunc(p0)
+	}
+}
+
+func (m *Store) SetEscalationThresholds(p0 uint8, p1 uint8) {
+	m.record("SetEscalationThresholds")
+	if m.SetEscalationThresholdsFunc != nil {
+		m.SetEscalationThresholdsFunc(p0, p1)
+	}
+}
+
+func (m *Store) SetFingerprintWalletThreshold(p0 int) {
+	m.record("SetFingerprintWalletThreshold")
+	if m.SetFingerprintWalletThresholdFunc != nil {
+		m.SetFingerprintWalletThresholdFunc(p0)
+	}
+}
+
+func (m *Store) SetWalletBanCooldown(p0 time.Duration) {
+	m.record("SetWalletBanCooldown")
+	if m.SetWalletBanCooldownFunc != nil {
+		m.SetWalletBanCooldownFunc(p0)
+	}
+}
+
+func (m *Store) SetBanApprovalWindow(p0 time.Duration) {
+	m.record("SetBanApprovalWindow")
+	if m.SetBanApprovalWindowFunc != nil {
+		m.SetBanApprovalWindowFunc(p0)
+	}
+}
+
+func (m *Store) SetTrustWeightedPoints(p0 bool) {
+	m.record("SetTrustWeightedPoints")
+	if m.SetTrustWeightedPointsFunc != nil {
+		m.SetTrustWeightedPointsFunc(p0)
+	}
+}
+
+func (m *Store) SetSilentAfter(p0 time.Duration) {
+	m.record("SetSilentAfter")
+	if m.SetSilentAfterFunc != nil {
+		m.SetSilentAfterFunc(p0)
+	}
+}
+
+func (m *Store) SetMinClientVersions(p0 clientversion.Minimums) {
+	m.record("SetMinClientVersions")
+	if m.SetMinClientVersionsFunc != nil {
+		m.SetMinClientVersionsFunc(p0)
+	}
+}
+
+func (m *Store) SetOfficialBuilds(p0 proverbuild.Allowlist) {
+	m.record("SetOfficialBuilds")
+	if m.SetOfficialBuildsFunc != nil {
+		m.SetOfficialBuildsFunc(p0)
+	}
+}
+
+func (m *Store) SetHardForks(p0 []hardfork.Fork) {
+	m.record("SetHardForks")
+	if m.SetHardForksFunc != nil {
+		m.SetHardForksFunc(p0)
+	}
+}
+
+func (m *Store) SetChallengeCaps(p0 budget.Caps) {
+	m.record("SetChallengeCaps")
+	if m.SetChallengeCapsFunc != nil {
+		m.SetChallengeCapsFunc(p0)
+	}
+}
+
+func (m *Store) SetLeaderboardRules(p0 types.LeaderboardRules) {
+	m.record("SetLeaderboardRules")
+	if m.SetLeaderboardRulesFunc != nil {
+		m.SetLeaderboardRulesFunc(p0)
+	}
+}
+
+func (m *Store) SetDiversityBonus(p0 uint64) {
+	m.record("SetDiversityBonus")
+	if m.SetDiversityBonusFunc != nil {
+		m.SetDiversityBonusFunc(p0)
+	}
+}
+
+func (m *Store) SetStakingTiers(p0 staking.Tiers) {
+	m.record("SetStakingTiers")
+	if m.SetStakingTiersFunc != nil {
+		m.SetStakingTiersFunc(p0)
+	}
+}
+
+func (m *Store) SetUptimePenalty(p0 uint64) {
+	m.record("SetUptimePenalty")
+	if m.SetUptimePenaltyFunc != nil {
+		m.SetUptimePenaltyFunc(p0)
+	}
+}
+
+func (m *Store) SetPointsRates(p0 rates.Rates) {
+	m.record("SetPointsRates")
+	if m.SetPointsRatesFunc != nil {
+		m.SetPointsRatesFunc(p0)
+	}
+}
+
+func (m *Store) PointsPerHour(p0 types.NodeType) (r0 uint64) {
+	m.record("PointsPerHour")
+	if m.PointsPerHourFunc != nil {
+		return m.PointsPerHourFunc(p0)
+	}
+	return
+}
+
+func (m *Store) LeaderboardRules() (r0 types.LeaderboardRules) {
+	m.record("LeaderboardRules")
+	if m.LeaderboardRulesFunc != nil {
+		return m.LeaderboardRulesFunc()
+	}
+	return
+}
+
+func (m *Store) NetworkActivity(p0 int64, p1 int64) (r0 types.NetworkActivity) {
+	m.record("NetworkActivity")
+	if m.NetworkActivityFunc != nil {
+		return m.NetworkActivityFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) Sizes() (r0 map[string]int) {
+	m.record("Sizes")
+	if m.SizesFunc != nil {
+		return m.SizesFunc()
+	}
+	return
+}
+
+func (m *Store) Usage() (r0 types.StoreUsage) {
+	m.record("Usage")
+	if m.UsageFunc != nil {
+		return m.UsageFunc()
+	}
+	return
+}
+
+func (m *Store) Compact(p0 int64) (r0 types.Compaction) {
+	m.record("Compact")
+	if m.CompactFunc != nil {
+		return m.CompactFunc(p0)
+	}
+	return
+}
+
+func (m *Store) StartRecompute(p0 int64) (r0 types.Recompute, r1 bool) {
+	m.record("StartRecompute")
+	if m.StartRecomputeFunc != nil {
+		return m.StartRecomputeFunc(p0)
+	}
+	return
+}
+
+func (m *Store) RecomputeStep(p0 int, p1 int64) (r0 types.Recompute) {
+	m.record("RecomputeStep")
+	if m.RecomputeStepFunc != nil {
+		return m.RecomputeStepFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) RecomputeStatus() (r0 types.Recompute, r1 bool) {
+	m.record("RecomputeStatus")
+	if m.RecomputeStatusFunc != nil {
+		return m.RecomputeStatusFunc()
+	}
+	return
+}
+
+func (m *Store) CreateAPIKey(p0 string, p1 string, p2 string, p3 int64) (r0 string, r1 types.APIKey, r2 error) {
+	m.record("CreateAPIKey")
+	if m.CreateAPIKeyFunc != nil {
+		return m.CreateAPIKeyFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) LookupAPIKey(p0 string) (r0 types.APIKey, r1 bool) {
+	m.record("LookupAPIKey")
+	if m.LookupAPIKeyFunc != nil {
+		return m.LookupAPIKeyFunc(p0)
+	}
+	return
+}
+
+func (m *Store) GetAPIKey(p0 string) (r0 types.APIKey, r1 bool) {
+	m.record("GetAPIKey")
+	if m.GetAPIKeyFunc != nil {
+		return m.GetAPIKeyFunc(p0)
+	}
+	return
+}
+
+func (m *Store) GetAPIKeys(p0 string) (r0 []types.APIKey) {
+	m.record("GetAPIKeys")
+	if m.GetAPIKeysFunc != nil {
+		return m.GetAPIKeysFunc(p0)
+	}
+	return
+}
+
+func (m *Store) SetAPIKeyPlan(p0 string, p1 string) (r0 types.APIKey, r1 error) {
+	m.record("SetAPIKeyPlan")
+	if m.SetAPIKeyPlanFunc != nil {
+		return m.SetAPIKeyPlanFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) RevokeAPIKey(p0 string, p1 int64) (r0 types.APIKey, r1 error) {
+	m.record("RevokeAPIKey")
+	if m.RevokeAPIKeyFunc != nil {
+		return m.RevokeAPIKeyFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) EnrollTOTP(p0 string, p1 string, p2 int64) (r0 error) {
+	m.record("EnrollTOTP")
+	if m.EnrollTOTPFunc != nil {
+		return m.EnrollTOTPFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) ConfirmTOTP(p0 string, p1 string, p2 int64) (r0 error) {
+	m.record("ConfirmTOTP")
+	if m.ConfirmTOTPFunc != nil {
+		return m.ConfirmTOTPFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) CheckTOTP(p0 string, p1 string, p2 int64) (r0 error) {
+	m.record("CheckTOTP")
+	if m.CheckTOTPFunc != nil {
+		return m.CheckTOTPFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) DisableTOTP(p0 string, p1 string, p2 int64) (r0 error) {
+	m.record("DisableTOTP")
+	if m.DisableTOTPFunc != nil {
+		return m.DisableTOTPFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) RequestTOTPReset(p0 string, p1 string, p2 string, p3 int64) (r0 *types.PendingBan, r1 error) {
+	m.record("RequestTOTPReset")
+	if m.RequestTOTPResetFunc != nil {
+		return m.RequestTOTPResetFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) TOTPStatus(p0 string) (r0 bool, r1 int64) {
+	m.record("TOTPStatus")
+	if m.TOTPStatusFunc != nil {
+		return m.TOTPStatusFunc(p0)
+	}
+	return
+}
+
+func (m *Store) CloseEpoch(p0 int64, p1 int64) (r0 *types.RewardEpoch, r1 error) {
+	m.record("CloseEpoch")
+	if m.CloseEpochFunc != nil {
+		return m.CloseEpochFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetEpochs() (r0 []types.RewardEpoch) {
+	m.record("GetEpochs")
+	if m.GetEpochsFunc != nil {
+		return m.GetEpochsFunc()
+	}
+	return
+}
+
+func (m *Store) GetEpoch(p0 uint64) (r0 *types.RewardEpoch) {
+	m.record("GetEpoch")
+	if m.GetEpochFunc != nil {
+		return m.GetEpochFunc(p0)
+	}
+	return
+}
+
+func (m *Store) GetRewardClaims(p0 string) (r0 []types.RewardClaim) {
+	m.record("GetRewardClaims")
+	if m.GetRewardClaimsFunc != nil {
+		return m.GetRewardClaimsFunc(p0)
+	}
+	return
+}
+
+func (m *Store) PostAnnouncement(p0 types.AnnouncementKind, p1 string, p2 string, p3 int64, p4 int64) (r0 types.Announcement) {
+	m.record("PostAnnouncement")
+	if m.PostAnnouncementFunc != nil {
+		return m.PostAnnouncementFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) GetAnnouncements(p0 int64) (r0 []types.Announcement) {
+	m.record("GetAnnouncements")
+	if m.GetAnnouncementsFunc != nil {
+		return m.GetAnnouncementsFunc(p0)
+	}
+	return
+}
+
+func (m *Store) RetractAnnouncement(p0 uint64) (r0 error) {
+	m.record("RetractAnnouncement")
+	if m.RetractAnnouncementFunc != nil {
+		return m.RetractAnnouncementFunc(p0)
+	}
+	return
+}
+
+func (m *Store) StartServiceMaintenance(p0 string, p1 int64, p2 int64) (r0 types.ServiceMaintenance) {
+	m.record("StartServiceMaintenance")
+	if m.StartServiceMaintenanceFunc != nil {
+		return m.StartServiceMaintenanceFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) EndServiceMaintenance(p0 int64) (r0 types.ServiceMaintenance, r1 error) {
+	m.record("EndServiceMaintenance")
+	if m.EndServiceMaintenanceFunc != nil {
+		return m.EndServiceMaintenanceFunc(p0)
+	}
+	return
+}
+
+func (m *Store) ServiceMaintenance() (r0 *types.ServiceMaintenance) {
+	m.record("ServiceMaintenance")
+	if m.ServiceMaintenanceFunc != nil {
+		return m.ServiceMaintenanceFunc()
+	}
+	return
+}
+
+func (m *Store) WriteSnapshot(p0 io.Writer) (r0 error) {
+	m.record("WriteSnapshot")
+	if m.WriteSnapshotFunc != nil {
+		return m.WriteSnapshotFunc(p0)
+	}
+	return
+}
+
+func (m *Store) ReadSnapshot(p0 io.Reader) (r0 int64, r1 error) {
+	m.record("ReadSnapshot")
+	if m.ReadSnapshotFunc != nil {
+		return m.ReadSnapshotFunc(p0)
+	}
+	return
+}
+
+func (m *Store) Export(p0 io.Writer) (r0 error) {
+	m.record("Export")
+	if m.ExportFunc != nil {
+		return m.ExportFunc(p0)
+	}
+	return
+}
+
+func (m *Store) Import(p0 io.Reader) (r0 int64, r1 error) {
+	m.record("Import")
+	if m.ImportFunc != nil {
+		return m.ImportFunc(p0)
+	}
+	return
+}
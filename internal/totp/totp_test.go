@@ -0,0 +1,61 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The RFC 6238 test key, "12345678901234567890", as base32
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// RFC 6238 appendix B, SHA1, last 6 digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		code, err := Code(rfcSecret, StepAt(time.Unix(tt.unix, 0)))
+		if err != nil || code != tt.want {
+			t.Errorf("at %d: expected %s, got %s (%v)", tt.unix, tt.want, code, err)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, _ := Code(rfcSecret, StepAt(now))
+
+	if step, ok := Match(rfcSecret, code, now); !ok || step != StepAt(now) {
+		t.Error("expected the current code to match its step")
+	}
+	if _, ok := Match(rfcSecret, code, now.Add(Step)); !ok {
+		t.Error("expected a code from the last step to still match")
+	}
+	if _, ok := Match(rfcSecret, code, now.Add(3*Step)); ok {
+		t.Error("expected an old code to be refused")
+	}
+	if _, ok := Match(rfcSecret, "12345", now); ok {
+		t.Error("expected a short code to be refused")
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Code(secret, 1); err != nil {
+		t.Errorf("a new secret should make codes: %v", err)
+	}
+	if uri := URI("DePIN BNB", "0xabc", secret); !strings.HasPrefix(uri, "otpauth://totp/DePIN%20BNB:0xabc?") || !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("unexpected URI %s", uri)
+	}
+}
@@ -0,0 +1,228 @@
+// Package tunnel lets exposed-rpc verification reach nodes that can't
+// accept inbound connections. The prover holds a stream open to the
+// server; JSON-RPC calls meant for the node are sent down it, and the
+// prover posts the node's replies back. To the rest of the server a
+// tunnelled node is just an endpoint, "tunnel://<node ID>", with the
+// broker as its transport.
+package tunnel
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// What operators register as their rpc_endpoint to ask for a tunnel. The
+// server swaps it for the node's own endpoint once it has an ID.
+const Placeholder = "tunnel://"
+
+// The endpoint a node's tunnel is reached at
+func EndpointFor(nodeID string) string {
+	return Placeholder + nodeID
+}
+
+// Is this a tunnel endpoint (or the placeholder)
+func IsEndpoint(endpoint string) bool {
+	return strings.HasPrefix(endpoint, Placeholder)
+}
+
+// The node a tunnel endpoint leads to, "" for the placeholder
+func NodeID(endpoint string) string {
+	return strings.TrimPrefix(endpoint, Placeholder)
+}
+
+// How many calls can wait for the prover to pick them up
+const queueSize = 16
+
+var (
+	ErrNotConnected = errors.New("node has no tunnel open")
+	ErrStaleOpen    = errors.New("tunnel open is older than the current one")
+	ErrBusy         = errors.New("tunnel has too many calls waiting")
+	ErrClosed       = errors.New("tunnel closed before the node answered")
+	ErrUnknownCall  = errors.New("no call waiting with that ID")
+)
+
+// A call for the prover to make against its node. ID is random and only
+// ever sent down the node's tunnel, so knowing it is what lets a reply in.
+type Request struct {
+	ID   string `json:"id"`
+	Body []byte `json:"body"` // JSON-RPC request
+}
+
+// The node's answer to a Request, or why the prover couldn't get one
+type Reply struct {
+	ID     string `json:"id"`
+	Status int    `json:"status"`
+	Body   []byte `json:"body"`
+	Error  string `json:"error,omitempty"`
+}
+
+// One open stream from a prover
+type Tunnel struct {
+	nodeID   string
+	requests chan *Request
+	done     chan struct{}
+	once     sync.Once
+}
+
+// Calls to send down the stream
+func (t *Tunnel) Requests() <-chan *Request {
+	return t.requests
+}
+
+// Closed once the tunnel is closed or replaced by a newer one
+func (t *Tunnel) Done() <-chan struct{} {
+	return t.done
+}
+
+func (t *Tunnel) close() {
+	t.once.Do(func() { close(t.done) })
+}
+
+type call struct {
+	nodeID string
+	reply  chan *Reply
+}
+
+// Broker matches calls to the tunnels provers hold open. It's an
+// http.RoundTripper, so RPC clients pointed at a tunnel endpoint go
+// through it unchanged.
+type Broker struct {
+	tunnels map[string]*Tunnel // nodeID -> newest open
+	calls   map[string]*call   // Request ID -> caller waiting for the reply
+	opens   map[string]int64   // nodeID -> timestamp of the newest open
+	mu      sync.Mutex
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		tunnels: make(map[string]*Tunnel),
+		calls:   make(map[string]*call),
+		opens:   make(map[string]int64),
+	}
+}
+
+// Open a tunnel for a node, replacing any it already has (a prover that
+// reconnects shouldn't have to wait for the old stream to time out).
+// timestamp is the signed open's; each has to be newer than the last, so
+// a replayed open can't take over a tunnel. Close the tunnel when the
+// stream ends.
+func (b *Broker) Open(nodeID string, timestamp int64) (*Tunnel, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if timestamp <= b.opens[nodeID] {
+		return nil, ErrStaleOpen
+	}
+	b.opens[nodeID] = timestamp
+
+	if old := b.tunnels[nodeID]; old != nil {
+		old.close()
+	}
+	t := &Tunnel{
+		nodeID:   nodeID,
+		requests: make(chan *Request, queueSize),
+		done:     make(chan struct{}),
+	}
+	b.tunnels[nodeID] = t
+	return t, nil
+}
+
+// Close a tunnel. Calls waiting on it fail straight away.
+func (b *Broker) Close(t *Tunnel) {
+	b.mu.Lock()
+	if b.tunnels[t.nodeID] == t {
+		delete(b.tunnels, t.nodeID)
+	}
+	b.mu.Unlock()
+	t.close()
+}
+
+// Does the node have a tunnel open
+func (b *Broker) Connected(nodeID string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tunnels[nodeID] != nil
+}
+
+// Hand the caller a reply the node's prover sent back
+func (b *Broker) Deliver(nodeID string, reply *Reply) error {
+	b.mu.Lock()
+	c := b.calls[reply.ID]
+	if c == nil || c.nodeID != nodeID {
+		b.mu.Unlock()
+		return ErrUnknownCall
+	}
+	delete(b.calls, reply.ID)
+	b.mu.Unlock()
+
+	c.reply <- reply // Buffered, and each call is delivered once
+	return nil
+}
+
+// Send a request to a tunnel endpoint's node and wait for its reply, or
+// for the request's context to end
+func (b *Broker) RoundTrip(req *http.Request) (*http.Response, error) {
+	nodeID := req.URL.Host
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	request := &Request{ID: uuid.New().String(), Body: body}
+	c := &call{nodeID: nodeID, reply: make(chan *Reply, 1)}
+
+	b.mu.Lock()
+	t := b.tunnels[nodeID]
+	if t == nil {
+		b.mu.Unlock()
+		return nil, ErrNotConnected
+	}
+	select {
+	case t.requests <- request:
+	default:
+		b.mu.Unlock()
+		return nil, ErrBusy
+	}
+	b.calls[request.ID] = c
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(b.calls, request.ID)
+		b.mu.Unlock()
+	}()
+
+	select {
+	case reply := <-c.reply:
+		if reply.Error != "" {
+			return nil, errors.New(reply.Error)
+		}
+		return &http.Response{
+			Status:        http.StatusText(reply.Status),
+			StatusCode:    reply.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {"application/json"}},
+			Body:          io.NopCloser(bytes.NewReader(reply.Body)),
+			ContentLength: int64(len(reply.Body)),
+			Request:       req,
+		}, nil
+	case <-t.Done():
+		return nil, ErrClosed
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+}
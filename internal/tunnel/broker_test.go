@@ -0,0 +1,164 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Answer every call on a tunnel, the way a prover relays its node's
+// replies
+func serve(b *Broker, nodeID string, t *Tunnel, answer func(*Request) *Reply) {
+	go func() {
+		for {
+			select {
+			case req := <-t.Requests():
+				reply := answer(req)
+				reply.ID = req.ID
+				b.Deliver(nodeID, reply)
+			case <-t.Done():
+				return
+			}
+		}
+	}()
+}
+
+func post(b *Broker, endpoint, body string) (*http.Response, error) {
+	client := &http.Client{Transport: b, Timeout: time.Second}
+	return client.Post(endpoint, "application/json", strings.NewReader(body))
+}
+
+func TestRoundTrip(t *testing.T) {
+	b := NewBroker()
+	if _, err := post(b, EndpointFor("node-a"), "{}"); !errors.Is(err, ErrNotConnected) {
+		t.Fatalf("expected ErrNotConnected before the tunnel opens, got %v", err)
+	}
+
+	tun, err := b.Open("node-a", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close(tun)
+	serve(b, "node-a", tun, func(req *Request) *Reply {
+		return &Reply{Status: 200, Body: []byte(`{"result":` + string(req.Body) + `}`)}
+	})
+
+	resp, err := post(b, EndpointFor("node-a"), `"0x1"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 || string(body) != `{"result":"0x1"}` {
+		t.Errorf("unexpected reply %d %s", resp.StatusCode, body)
+	}
+	if !b.Connected("node-a") || b.Connected("node-b") {
+		t.Error("only node-a should be connected")
+	}
+}
+
+func TestReplyError(t *testing.T) {
+	b := NewBroker()
+	tun, _ := b.Open("node-a", 1)
+	defer b.Close(tun)
+	serve(b, "node-a", tun, func(*Request) *Reply {
+		return &Reply{Error: "connection refused"}
+	})
+
+	if _, err := post(b, EndpointFor("node-a"), "{}"); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("expected the prover's error, got %v", err)
+	}
+}
+
+func TestDeliverChecksNode(t *testing.T) {
+	b := NewBroker()
+	tun, _ := b.Open("node-a", 1)
+	defer b.Close(tun)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := post(b, EndpointFor("node-a"), "{}")
+		done <- err
+	}()
+	req := <-tun.Requests()
+
+	// Another node can't answer for this one, and an unknown ID goes nowhere
+	if err := b.Deliver("node-b", &Reply{ID: req.ID, Status: 200}); !errors.Is(err, ErrUnknownCall) {
+		t.Errorf("expected ErrUnknownCall for the wrong node, got %v", err)
+	}
+	if err := b.Deliver("node-a", &Reply{ID: "made-up", Status: 200}); !errors.Is(err, ErrUnknownCall) {
+		t.Errorf("expected ErrUnknownCall for an unknown ID, got %v", err)
+	}
+
+	if err := b.Deliver("node-a", &Reply{ID: req.ID, Status: 200, Body: []byte("{}")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("call should have succeeded, got %v", err)
+	}
+	if err := b.Deliver("node-a", &Reply{ID: req.ID, Status: 200}); !errors.Is(err, ErrUnknownCall) {
+		t.Errorf("a call should only take one reply, got %v", err)
+	}
+}
+
+func TestOpenReplaces(t *testing.T) {
+	b := NewBroker()
+	first, _ := b.Open("node-a", 100)
+
+	if _, err := b.Open("node-a", 100); !errors.Is(err, ErrStaleOpen) {
+		t.Errorf("a replayed open should be refused, got %v", err)
+	}
+
+	second, err := b.Open("node-a", 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-first.Done():
+	default:
+		t.Error("the first tunnel should close when replaced")
+	}
+
+	// Closing the replaced tunnel mustn't take down its replacement
+	b.Close(first)
+	if !b.Connected("node-a") {
+		t.Error("the newer tunnel should still be open")
+	}
+	b.Close(second)
+	if b.Connected("node-a") {
+		t.Error("node-a should be disconnected")
+	}
+}
+
+func TestCloseFailsWaitingCalls(t *testing.T) {
+	b := NewBroker()
+	tun, _ := b.Open("node-a", 1)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := post(b, EndpointFor("node-a"), "{}")
+		done <- err
+	}()
+	<-tun.Requests()
+	b.Close(tun)
+
+	if err := <-done; !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestCallTimesOut(t *testing.T) {
+	b := NewBroker()
+	tun, _ := b.Open("node-a", 1)
+	defer b.Close(tun)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "POST", EndpointFor("node-a"), strings.NewReader("{}"))
+	if _, err := b.RoundTrip(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the call to time out, got %v", err)
+	}
+}
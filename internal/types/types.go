@@ -4,13 +4,79 @@ package types
 type NodeType string
 
 const (
-	BscFull     NodeType = "bsc-full"
-	BscFast     NodeType = "bsc-fast"
-	BscArchive  NodeType = "bsc-archive"
-	OpbnbFull   NodeType = "opbnb-full"
-	OpbnbFast   NodeType = "opbnb-fast"
+	BscFull      NodeType = "bsc-full"
+	BscFast      NodeType = "bsc-fast"
+	BscArchive   NodeType = "bsc-archive"
+	OpbnbFull    NodeType = "opbnb-full"
+	OpbnbFast    NodeType = "opbnb-fast"
+	GreenfieldSP NodeType = "greenfield-sp" // Greenfield storage provider
 )
 
+// Every supported node type, in the order they're listed to operators
+var NodeTypes = []NodeType{BscArchive, BscFull, BscFast, OpbnbFull, OpbnbFast, GreenfieldSP}
+
+// Which chain a node serves
+type Chain string
+
+const (
+	ChainBSC        Chain = "bsc"
+	ChainOpBNB      Chain = "opbnb"
+	ChainGreenfield Chain = "greenfield"
+)
+
+// Which network the server runs on. One server runs one network, so
+// testnet points never mix with mainnet ones.
+type Network string
+
+const (
+	Mainnet Network = "mainnet"
+	Testnet Network = "testnet"
+)
+
+// Testnet points are only for trying the prover out, so they come
+// faster to show operators their setup works
+func (n Network) PointsMultiplier() uint64 {
+	if n == Testnet {
+		return 10
+	}
+	return 1
+}
+
+// EIP-155 chain ID a node on this chain reports, 0 if it has none
+func (c Chain) ChainID(network Network) uint64 {
+	testnet := network == Testnet
+	switch c {
+	case ChainBSC:
+		if testnet {
+			return 97
+		}
+		return 56
+	case ChainOpBNB:
+		if testnet {
+			return 5611
+		}
+		return 204
+	case ChainGreenfield:
+		if testnet {
+			return 5600
+		}
+		return 1017
+	default:
+		return 0
+	}
+}
+
+func (n NodeType) Chain() Chain {
+	switch n {
+	case OpbnbFull, OpbnbFast:
+		return ChainOpBNB
+	case GreenfieldSP:
+		return ChainGreenfield
+	default:
+		return ChainBSC
+	}
+}
+
 // Points users get just for registering a synced node
 func (n NodeType) RegistrationBonus() uint64 {
 	switch n {
@@ -24,6 +90,8 @@ func (n NodeType) RegistrationBonus() uint64 {
 		return 40
 	case OpbnbFast:
 		return 30
+	case GreenfieldSP:
+		return 80 // Serving other people's data takes real hardware and bandwidth
 	default:
 		return 0
 	}
@@ -42,6 +110,8 @@ func (n NodeType) PointsPerHour() uint64 {
 		return 4
 	case OpbnbFast:
 		return 3
+	case GreenfieldSP:
+		return 8
 	default:
 		return 0
 	}
@@ -49,7 +119,7 @@ func (n NodeType) PointsPerHour() uint64 {
 
 func (n NodeType) MinUptimePercent() uint8 {
 	switch n {
-	case BscArchive, BscFull:
+	case BscArchive, BscFull, GreenfieldSP:
 		return 95
 	case BscFast, OpbnbFull:
 		return 90
@@ -60,6 +130,29 @@ func (n NodeType) MinUptimePercent() uint8 {
 	}
 }
 
+// Smallest disk that can hold this node's data
+func (n NodeType) MinDiskGB() uint64 {
+	switch n {
+	case BscArchive, GreenfieldSP:
+		return 4000
+	case BscFull:
+		return 1000
+	case BscFast, OpbnbFull:
+		return 500
+	default:
+		return 250
+	}
+}
+
+func (n NodeType) MinCPUs() int {
+	switch n {
+	case BscArchive, BscFull, GreenfieldSP:
+		return 8
+	default:
+		return 4
+	}
+}
+
 func (n NodeType) ChallengeFrequencyMinutes() uint64 {
 	switch n {
 	case BscArchive, BscFull:
@@ -69,6 +162,25 @@ func (n NodeType) ChallengeFrequencyMinutes() uint64 {
 	}
 }
 
+// Challenges this type of node can answer. Composites are built from
+// these when their flag is on.
+func (n NodeType) ChallengeTypes() []ChallengeType {
+	switch n {
+	case GreenfieldSP:
+		// Storage providers hold objects, not chain state
+		return []ChallengeType{ObjectExists, ObjectChecksum}
+	case BscArchive:
+		// Archive nodes keep all historical state
+		return []ChallengeType{BlockHash, BlockData, StateBalance, SyncStatus}
+	case BscFull, OpbnbFull:
+		// Full nodes have block data but limited historical state
+		return []ChallengeType{BlockHash, BlockData, SyncStatus}
+	default:
+		// Fast nodes only keep recent stuff
+		return []ChallengeType{BlockHash, SyncStatus}
+	}
+}
+
 // How we verify the node
 type VerificationMethod string
 
@@ -86,6 +198,14 @@ const (
 	StateBalance ChallengeType = "state-balance"
 	TxReceipt    ChallengeType = "tx-receipt"
 	SyncStatus   ChallengeType = "sync-status"
+
+	// Greenfield storage providers are asked about objects, not blocks
+	ObjectExists   ChallengeType = "object-exists"
+	ObjectChecksum ChallengeType = "object-checksum"
+
+	// Several of the above in one challenge, answered as a JSON array with
+	// one entry per part and credited part by part
+	Composite ChallengeType = "composite"
 )
 
 // Anti-cheat status
@@ -98,28 +218,211 @@ const (
 	StatusBanned   CheatStatus = "banned"    // Confirmed cheating
 )
 
+// Why a challenge failed, grouped so operators can see patterns
+type FailureKind string
+
+const (
+	FailureExpired     FailureKind = "expired"      // Answered after the deadline (or not at all)
+	FailureWrongAnswer FailureKind = "wrong-answer" // Answer didn't match our trusted node
+	FailureTooSlow     FailureKind = "too-slow"     // Right answer, but over the latency limit
+	FailureUnreachable FailureKind = "unreachable"  // Couldn't get an answer from the node
+	FailureServerError FailureKind = "server-error" // Our side failed - not the node's fault
+	FailureOther       FailureKind = "other"
+)
+
 // A registered node
 type NodeRegistration struct {
 	ID                    string             `json:"id"`
 	WalletAddress         string             `json:"wallet_address"`
 	NodeType              NodeType           `json:"node_type"`
+	Network               Network            `json:"network"`
 	VerificationMethod    VerificationMethod `json:"verification_method"`
 	RPCEndpoint           string             `json:"rpc_endpoint,omitempty"`
 	AuthToken             string             `json:"auth_token,omitempty"`
+	ClientCert            *ClientCert        `json:"client_cert,omitempty"`       // Presented to RPC endpoints that require mTLS
+	MethodChangedAt       int64              `json:"method_changed_at,omitempty"` // Last switch between exposed-rpc and local-prover
 	RegisteredAt          int64              `json:"registered_at"`
 	LastVerifiedAt        int64              `json:"last_verified_at"`
 	LastHeartbeatAt       int64              `json:"last_heartbeat_at"`
 	TotalChallengesPassed uint64             `json:"total_challenges_passed"`
 	TotalChallengesFailed uint64             `json:"total_challenges_failed"`
 	TotalUptimeMinutes    uint64             `json:"total_uptime_minutes"`
-	TotalPoints           uint64             `json:"total_points"`
+	TotalPoints           uint64             `json:"total_points"` // Sum of the node's points ledger
 	IsActive              bool               `json:"is_active"`
+	Silent                bool               `json:"silent,omitempty"`  // Made inactive for going quiet; the next passed proof reactivates it
+	Country               string             `json:"country,omitempty"` // Where its submissions come from, per the proxy in front of us
+
+	// Operator maintenance (no challenges while paused)
+	Paused                 bool   `json:"paused"`
+	PausedAt               int64  `json:"paused_at,omitempty"`
+	MaintenanceUsedMinutes uint64 `json:"maintenance_used_minutes"` // This month (UTC)
+	MaintenanceMonth       string `json:"maintenance_month,omitempty"`
+
+	Hardware *HardwareReport `json:"hardware,omitempty"` // Optional prover attestation
+	Storage  *StorageReport  `json:"storage,omitempty"`  // Last storage check (exposed-rpc)
+
+	// Rolling 7-day uptime against the type's MinUptimePercent. Below it,
+	// uptime points are paid at a reduced rate.
+	Uptime7dPercent   float64 `json:"uptime_7d_percent"`
+	BelowUptimeTarget bool    `json:"below_uptime_target,omitempty"`
+
+	// What the node runs, from web3_clientVersion
+	ClientVersion  string `json:"client_version,omitempty"`
+	ClientOutdated bool   `json:"client_outdated,omitempty"` // Below the minimum release for its chain
+
+	// The prover build that last answered (sha256 of its binary), signed
+	// with the answer. Unofficial builds aren't in PROVER_BUILD_HASHES; they
+	// still earn points, admins just get to see them.
+	ProverBuild     string `json:"prover_build,omitempty"`
+	UnofficialBuild bool   `json:"unofficial_build,omitempty"`
+
+	// Local-prover polling, for timing surprise challenges
+	LastPolledAt   int64         `json:"last_polled_at,omitempty"`
+	PollIntervalMs uint64        `json:"poll_interval_ms,omitempty"` // Moving average
+	Surprise       SurpriseStats `json:"surprise_challenges"`
+
+	// Signed timestamps of the last challenge request and prover
+	// heartbeat, so neither is replayed
+	LastRequestTimestamp   int64 `json:"-"`
+	LastHeartbeatTimestamp int64 `json:"-"`
 
 	// Anti-cheat
 	CheatStatus      CheatStatus `json:"cheat_status"`
 	WarningCount     uint8       `json:"warning_count"`
 	CheatReason      string      `json:"cheat_reason,omitempty"`
 	SuspiciousEvents []string    `json:"suspicious_events,omitempty"`
+	Trust            TrustScore  `json:"-"` // Admin only
+
+	PointsCarry uint64 `json:"-"` // Hundredths of a point left over from trust weighting
+}
+
+// A client certificate the verifier presents to an exposed-rpc node that
+// only accepts mTLS. The PEM cert and key are kept sealed with the
+// server's CLIENT_CERT_KEY; the rest is shown so operators can tell which
+// cert is on file and when it runs out.
+type ClientCert struct {
+	Sealed      string `json:"sealed,omitempty"` // Never shown
+	Subject     string `json:"subject"`
+	Fingerprint string `json:"fingerprint"` // SHA-256 of the leaf certificate, hex
+	NotAfter    int64  `json:"not_after"`
+}
+
+// Whether a node is up, for status badges
+type Liveness string
+
+const (
+	Online   Liveness = "online"   // Seen within one challenge interval
+	Degraded Liveness = "degraded" // Seen within three
+	Offline  Liveness = "offline"  // Quiet for longer, or inactive or paused
+)
+
+// Last challenge answer or heartbeat, whichever is later. 0 if neither yet.
+func (n *NodeRegistration) LastSeenAt() int64 {
+	return max(n.LastVerifiedAt, n.LastHeartbeatAt)
+}
+
+// Liveness as of a unix ms time, judged against how often the node's
+// type is challenged
+func (n *NodeRegistration) Liveness(now int64) Liveness {
+	lastSeen := n.LastSeenAt()
+	if !n.IsActive || n.Paused || lastSeen == 0 {
+		return Offline
+	}
+	interval := int64(n.NodeType.ChallengeFrequencyMinutes()) * 60 * 1000
+	switch since := now - lastSeen; {
+	case since <= interval:
+		return Online
+	case since <= 3*interval:
+		return Degraded
+	default:
+		return Offline
+	}
+}
+
+// Last seen times and liveness for one node, as shown in wallet stats
+type NodeLiveness struct {
+	NodeID          string   `json:"node_id"`
+	LastVerifiedAt  int64    `json:"last_verified_at"`
+	LastHeartbeatAt int64    `json:"last_heartbeat_at"`
+	Liveness        Liveness `json:"liveness"`
+}
+
+// Every anti-cheat signal for a node rolled into one 0-100 number. Each
+// factor is 0-1, 1 meaning nothing looks wrong.
+type TrustScore struct {
+	Score       uint8   `json:"score"`
+	PassRate    float64 `json:"pass_rate"`
+	Latency     float64 `json:"latency"`      // Passing answers not marked suspicious
+	IPStability float64 `json:"ip_stability"` // Few addresses submitting for it lately
+	Cluster     float64 `json:"cluster"`      // Not sharing a connection with other wallets
+	Diversity   float64 `json:"diversity"`    // Passes every kind of challenge it gets, not just some
+	UpdatedAt   int64   `json:"updated_at"`
+}
+
+// A node's quality at a glance, A (best) to F, from its recent pass rate,
+// latency, sync and uptime. The inputs are shown alongside so a grade can
+// be explained.
+type HealthGrade string
+
+const (
+	GradeA    HealthGrade = "A"
+	GradeB    HealthGrade = "B"
+	GradeC    HealthGrade = "C"
+	GradeD    HealthGrade = "D"
+	GradeF    HealthGrade = "F"
+	GradeNone HealthGrade = "none" // Nothing to go on yet
+)
+
+type NodeHealth struct {
+	Grade           HealthGrade `json:"grade"`
+	Score           float64     `json:"score"` // 0-100
+	PassRate24h     float64     `json:"pass_rate_24h"`
+	LatencyP90Ms    uint64      `json:"latency_p90_ms"`
+	SyncedPercent   float64     `json:"synced_percent"` // Heartbeats in the last 24 hours that found the node synced
+	Uptime7dPercent float64     `json:"uptime_7d_percent"`
+}
+
+// The verification service's own availability, for telling a node's
+// failures apart from the service's
+type ServiceStatus struct {
+	TrackingSince int64           `json:"tracking_since"` // Server start; nothing before it is known
+	Windows       []ServiceWindow `json:"windows"`
+	Outages       []Outage        `json:"outages"` // Last 30 days, oldest first
+	GeneratedAt   int64           `json:"generated_at"`
+}
+
+// Planned maintenance of the whole service: no challenges are issued,
+// answers to ones already out are held until it's over, and everything
+// else answers 503
+type ServiceMaintenance struct {
+	Reason    string `json:"reason,omitempty"`
+	StartedAt int64  `json:"started_at"`
+	Until     int64  `json:"until,omitempty"` // Expected end, unix ms; 0 = not given
+}
+
+type ServiceWindow struct {
+	Window           string   `json:"window"` // 24h, 7d or 30d
+	MinutesTracked   int64    `json:"minutes_tracked"`
+	UptimePercent    float64  `json:"uptime_percent"`
+	ChallengesIssued uint64   `json:"challenges_issued"`
+	IssueFailures    uint64   `json:"issue_failures"`
+	IssueSuccessRate *float64 `json:"issue_success_rate,omitempty"` // Percent; unset if nothing was asked for
+}
+
+// Why the service counted as down
+type OutageCause string
+
+const (
+	OutageUnresponsive OutageCause = "unresponsive"     // The server didn't run in that time
+	OutageIssuance     OutageCause = "issuance-failing" // Every challenge it tried to issue failed
+	OutageMaintenance  OutageCause = "maintenance"      // An admin put the whole service in maintenance
+)
+
+// A stretch of whole minutes the service was down, unix ms, end exclusive
+type Outage struct {
+	Start int64       `json:"start"`
+	End   int64       `json:"end"`
+	Cause OutageCause `json:"cause"`
 }
 
 // Challenge we send to nodes
@@ -130,12 +433,25 @@ type Challenge struct {
 	CreatedAt     int64           `json:"created_at"`
 	ExpiresAt     int64           `json:"expires_at"`
 	Params        ChallengeParams `json:"params"`
+	Signature     string          `json:"signature,omitempty"` // Server signature (if signing is enabled)
+	KeyID         string          `json:"key_id,omitempty"`    // Server key that made the signature
+	CommitBy      int64           `json:"commit_by,omitempty"` // Commit-reveal deadline, 0 = answer directly
 }
 
 type ChallengeParams struct {
 	BlockNumber *uint64 `json:"block_number,omitempty"`
 	Address     string  `json:"address,omitempty"`
 	TxHash      string  `json:"tx_hash,omitempty"`
+	Bucket      string  `json:"bucket,omitempty"` // Greenfield bucket
+	Object      string  `json:"object,omitempty"` // Object name within the bucket
+
+	Parts []ChallengePart `json:"parts,omitempty"` // Composite challenges only
+}
+
+// One query within a composite challenge
+type ChallengePart struct {
+	ChallengeType ChallengeType   `json:"challenge_type"`
+	Params        ChallengeParams `json:"params"`
 }
 
 // Response from user's prover
@@ -146,28 +462,103 @@ type ChallengeResponse struct {
 	Signature      string `json:"signature"`
 	ResponseTimeMs uint64 `json:"response_time_ms"`
 	Timestamp      int64  `json:"timestamp"`
+	Nonce          string `json:"nonce,omitempty"`  // Reveals the commitment, for commit-reveal challenges
+	Wallet         string `json:"wallet,omitempty"` // Wallet that signed the answer
+
+	ChallengeType ChallengeType `json:"challenge_type,omitempty"` // Type the answer was signed for, if the message covered it
 }
 
 // Result of verification
 type VerificationResult struct {
-	ChallengeID    string `json:"challenge_id"`
-	NodeID         string `json:"node_id"`
-	Passed         bool   `json:"passed"`
-	ResponseTimeMs uint64 `json:"response_time_ms"`
-	FailureReason  string `json:"failure_reason,omitempty"`
-	Suspicious     bool   `json:"suspicious"`
-	SuspiciousNote string `json:"suspicious_note,omitempty"`
-	Timestamp      int64  `json:"timestamp"`
+	ChallengeID    string        `json:"challenge_id"`
+	NodeID         string        `json:"node_id"`
+	Passed         bool          `json:"passed"`
+	ResponseTimeMs uint64        `json:"response_time_ms"`
+	FailureReason  string        `json:"failure_reason,omitempty"`
+	FailureKind    FailureKind   `json:"failure_kind,omitempty"`
+	Suspicious     bool          `json:"suspicious"`
+	SuspiciousNote string        `json:"suspicious_note,omitempty"`
+	ChallengeType  ChallengeType `json:"challenge_type,omitempty"`
+	Surprise       bool          `json:"surprise,omitempty"` // Pushed between polls with a short expiry
+	Parts          []PartResult  `json:"parts,omitempty"`    // Composite challenges, one per part
+	BlockAge       *uint64       `json:"-"`                  // Blocks behind the trusted head when issued, for feature exports
+	Timestamp      int64         `json:"timestamp"`
+
+	Replay *ChallengeReplay `json:"-"` // Set on failures, kept for admin inspection
+	Retry  bool             `json:"-"` // Repeat of an answer already verified, not to be recorded again
+}
+
+// How one part of a composite challenge went
+type PartResult struct {
+	ChallengeType ChallengeType `json:"challenge_type"`
+	Passed        bool          `json:"passed"`
+	FailureReason string        `json:"failure_reason,omitempty"`
+}
+
+// How many challenges a result counts as: one for a plain challenge, one
+// per part for a composite, so getting half the parts right is half credit
+func (r *VerificationResult) Credit() (passed, failed uint64) {
+	if len(r.Parts) == 0 {
+		if r.Passed {
+			return 1, 0
+		}
+		return 0, 1
+	}
+	for _, part := range r.Parts {
+		if part.Passed {
+			passed++
+		} else {
+			failed++
+		}
+	}
+	return passed, failed
+}
+
+// How a node does on challenges pushed between its scheduled polls
+type SurpriseStats struct {
+	Issued            uint64 `json:"issued"`
+	Passed            uint64 `json:"passed"`
+	Missed            uint64 `json:"missed"` // Failed or never answered
+	ConsecutiveMissed uint64 `json:"consecutive_missed"`
+
+	PendingID        string `json:"-"`
+	PendingExpiresAt int64  `json:"-"`
+}
+
+// Everything about a failed challenge, for disputes
+type ChallengeReplay struct {
+	Challenge       Challenge   `json:"challenge"`
+	ExpectedAnswer  string      `json:"expected_answer"`  // Large answers are stored as "sha256:<hex> (<n> bytes)"
+	SubmittedAnswer string      `json:"submitted_answer"` // Same
+	FailureReason   string      `json:"failure_reason"`
+	FailureKind     FailureKind `json:"failure_kind"`
+	ResponseTimeMs  uint64      `json:"response_time_ms"`
+	VerifiedAt      int64       `json:"verified_at"`
+}
+
+// One submission of an answer to a challenge. Every one is kept, repeats
+// and late ones too, not just the one whose verdict counted.
+type SubmissionAttempt struct {
+	NodeID      string      `json:"node_id"`
+	AnswerHash  string      `json:"answer_hash"` // sha256 of the answer, hex
+	ReceivedAt  int64       `json:"received_at"`
+	Passed      bool        `json:"passed"`
+	FailureKind FailureKind `json:"failure_kind,omitempty"`
+	Repeat      bool        `json:"repeat,omitempty"`      // Got back the verdict already given
+	Held        bool        `json:"held,omitempty"`        // Arrived during maintenance, checked after
+	Conflicting bool        `json:"conflicting,omitempty"` // Not the answer the node sent before
 }
 
 // Heartbeat for uptime tracking
 type HeartbeatRecord struct {
-	NodeID      string `json:"node_id"`
-	Timestamp   int64  `json:"timestamp"`
-	BlockNumber uint64 `json:"block_number"`
-	IsSynced    bool   `json:"is_synced"`
-	LatencyMs   uint64 `json:"latency_ms"`
-	PeersCount  uint64 `json:"peers_count"`
+	NodeID        string `json:"node_id"`
+	Timestamp     int64  `json:"timestamp"`
+	BlockNumber   uint64 `json:"block_number"`
+	BlockHash     string `json:"block_hash,omitempty"` // Sent by local provers
+	IsSynced      bool   `json:"is_synced"`
+	LatencyMs     uint64 `json:"latency_ms"`
+	PeersCount    uint64 `json:"peers_count"`
+	ClientVersion string `json:"client_version,omitempty"`
 }
 
 // Stats for a node
@@ -180,17 +571,525 @@ type NodeStats struct {
 	AverageLatencyMs   float64     `json:"average_latency_ms"`
 	CheatStatus        CheatStatus `json:"cheat_status"`
 	WarningCount       uint8       `json:"warning_count"`
+
+	FailuresByKind     map[FailureKind]uint64        `json:"failures_by_kind"`
+	LatencyPercentiles map[string]LatencyPercentiles `json:"latency_percentiles"` // By window ("1h", "24h")
+	ChallengeBudget    ChallengeBudget               `json:"challenge_budget"`    // Today's
+
+	OutageCompensation *OutageCompensation `json:"outage_compensation,omitempty"` // Unset if the node was never compensated
+}
+
+// Points a node was credited for the service's own outages, totalled from
+// its ledger's outage-compensation entries
+type OutageCompensation struct {
+	Outages int    `json:"outages"`
+	Minutes uint64 `json:"minutes"`
+	Points  int64  `json:"points"` // Net of any reversals
+}
+
+// Challenges a node asked for and passed on one UTC day, against the
+// daily caps. A type missing from Caps has no cap of its own.
+type ChallengeBudget struct {
+	Date     string                   `json:"date"` // YYYY-MM-DD (UTC)
+	Issued   map[ChallengeType]uint64 `json:"issued"`
+	Passed   map[ChallengeType]uint64 `json:"passed"`
+	Caps     map[ChallengeType]uint64 `json:"caps,omitempty"`
+	TotalCap uint64                   `json:"total_cap,omitempty"` // All types together, 0 = no cap
+}
+
+// Response time distribution. Averages hide proxies that are fast most of
+// the time and slow on cache misses; the tail shows it.
+type LatencyPercentiles struct {
+	P50     uint64 `json:"p50_ms"`
+	P90     uint64 `json:"p90_ms"`
+	P99     uint64 `json:"p99_ms"`
+	Samples int    `json:"samples"`
+}
+
+// One day on the uptime calendar
+type UptimeDay struct {
+	Date          string  `json:"date"` // YYYY-MM-DD (UTC)
+	UptimePercent float64 `json:"uptime_percent"`
+	Checks        uint64  `json:"checks"` // Heartbeats + verifications that day
+}
+
+// Why a node's points changed
+type PointsReason string
+
+const (
+	PointsRegistration PointsReason = "registration"
+	PointsUptime       PointsReason = "uptime"
+	PointsForkBonus    PointsReason = "fork-early-upgrade"
+	PointsReversal     PointsReason = "reversal"
+	PointsOutage       PointsReason = "outage-compensation" // Uptime the service's own outage kept a node from earning
+	PointsAdjustment   PointsReason = "adjustment"          // An admin's correction, with their reason as the note
+)
+
+// One change to a node's points. The ledger is append-only: a mistake is
+// undone with a reversal entry, never by editing the original.
+type PointsEntry struct {
+	ID        uint64       `json:"id"` // Per node, from 1
+	Reason    PointsReason `json:"reason"`
+	Amount    int64        `json:"amount"`
+	Timestamp int64        `json:"timestamp"`
+	Reference string       `json:"reference,omitempty"` // Fork ID, the outage's start-end, or for a reversal the entry it undoes
+	Note      string       `json:"note,omitempty"`
+
+	// The stake bonus an uptime or outage entry was paid with, and the
+	// tokens held that earned it
+	StakeBonusPercent uint64 `json:"stake_bonus_percent,omitempty"`
+	StakeTokens       uint64 `json:"stake_tokens,omitempty"`
+}
+
+// Points finalized for minting as tokens: every wallet's points up to a
+// cutoff, less what earlier epochs already paid out. A closed epoch never
+// changes. Points a reversal takes back after it closed come off the
+// wallet's next epoch instead.
+type RewardEpoch struct {
+	Epoch      uint64        `json:"epoch"` // From 1
+	Until      int64         `json:"until"` // Ledger entries up to here (inclusive)
+	ClosedAt   int64         `json:"closed_at"`
+	MerkleRoot string        `json:"merkle_root"`
+	Total      uint64        `json:"total"`
+	Claims     []RewardClaim `json:"claims,omitempty"` // By address
+}
+
+// What a wallet can mint for an epoch, and the proof the minting contract
+// checks against the epoch's merkle root
+type RewardClaim struct {
+	WalletAddress string   `json:"address"`
+	Amount        uint64   `json:"amount"`
+	Epoch         uint64   `json:"epoch"`
+	Proof         []string `json:"proof"` // 0x hashes, leaf to root
+
+	// Points of Amount the stake bonus paid, from the ledger entries the
+	// epoch covers, so the bonus can be audited. Not part of the leaf.
+	StakeBonus uint64 `json:"stake_bonus,omitempty"`
+}
+
+// What a wallet had staked when it was last read from the staking
+// contract, and the uptime points bonus that earns its nodes. The bonus
+// goes by the tokens held across the last two reads, so stake that's only
+// there for one read earns nothing.
+type WalletStake struct {
+	Wei          string `json:"wei"`         // Exact, as a decimal string
+	Tokens       uint64 `json:"tokens"`      // Whole tokens
+	HeldTokens   uint64 `json:"held_tokens"` // The fewer of Tokens and the read before's, what bonus tiers go by
+	BonusPercent uint64 `json:"bonus_percent"`
+	CheckedAt    int64  `json:"checked_at"`
 }
 
 // Wallet-level stats (user can have multiple nodes)
 type WalletStats struct {
 	WalletAddress string `json:"wallet_address"`
+	Name          string `json:"name,omitempty"` // The wallet's .bnb or ENS name, if it set one
 	TotalPoints   uint64 `json:"total_points"`
 	TotalNodes    int    `json:"total_nodes"`
 	ActiveNodes   int    `json:"active_nodes"`
 	FlaggedNodes  int    `json:"flagged_nodes"`
+
+	// Nodes one suspicious event away from being flagged
+	PendingFlags []string `json:"pending_flags"`
+
+	// What the wallet has staked, if there's a staking contract and it's
+	// been read
+	Stake *WalletStake `json:"stake,omitempty"`
+
+	Nodes []NodeLiveness `json:"nodes"`
+}
+
+// Estimated uptime points for a node, and everything the estimate was
+// made from
+type PointsProjection struct {
+	NodeID string           `json:"node_id"`
+	Daily  float64          `json:"daily"`
+	Weekly float64          `json:"weekly"`
+	Inputs ProjectionInputs `json:"inputs"`
+}
+
+type ProjectionInputs struct {
+	NodeType           NodeType `json:"node_type"`
+	PointsPerHour      uint64   `json:"points_per_hour"`     // Base rate for the type
+	PointsPerInterval  uint64   `json:"points_per_interval"` // Per 5-minute award, after rounding and the network multiplier
+	IntervalsPerDay    uint64   `json:"intervals_per_day"`
+	NetworkMultiplier  uint64   `json:"network_multiplier"`
+	RegionBonusPercent uint64   `json:"region_bonus_percent"`
+	StakeBonusPercent  uint64   `json:"stake_bonus_percent"`
+	UptimePenalty      uint64   `json:"uptime_penalty_percent"` // Taken off while below the type's uptime target
+	TrustWeighted      bool     `json:"trust_weighted"`         // Awards are also scaled by the trust score, which isn't public, so it's left out here
+	Uptime7dPercent    float64  `json:"uptime_7d_percent"`
+	ChallengePassRate  float64  `json:"challenge_pass_rate"`   // Last 24 hours
+	OnlinePercent      float64  `json:"online_percent"`        // Share of awards the node is expected to be up for
+	OnlineBasis        string   `json:"online_basis"`          // uptime_7d, pass_rate_24h, or assumed for a node with no history
+	NotEarning         string   `json:"not_earning,omitempty"` // Why the projection is 0
+}
+
+// How underrepresented a country is among active nodes
+type RegionWeight struct {
+	Nodes        int     `json:"nodes"`
+	SharePercent float64 `json:"share_percent"`
+	Weight       float64 `json:"weight"`        // 0 = at or above its fair share, towards 1 = rare
+	BonusPercent uint64  `json:"bonus_percent"` // Extra uptime points for nodes there
+}
+
+// Region weights as of the last weekly recalculation
+type RegionWeights struct {
+	UpdatedAt       int64                   `json:"updated_at"`
+	MaxBonusPercent uint64                  `json:"max_bonus_percent"` // 0 = no bonus
+	Regions         map[string]RegionWeight `json:"regions"`           // ISO country code -> weight
+}
+
+// What a node needs to show up on the leaderboard. Banned nodes and
+// nodes from the other network never do.
+type LeaderboardRules struct {
+	MinUptimeHours uint64 `json:"min_uptime_hours"`
+	MinPassRate    uint8  `json:"min_pass_rate"` // Percent, over the last 24 hours
+	CleanOnly      bool   `json:"clean_only"`    // Leave out warning and flagged nodes too
+}
+
+// Whether a node with this pass rate (percent) meets the rules
+func (r LeaderboardRules) Eligible(node *NodeRegistration, passRate float64) bool {
+	if node.CheatStatus == StatusBanned {
+		return false
+	}
+	if r.CleanOnly && node.CheatStatus != StatusClean {
+		return false
+	}
+	if node.TotalUptimeMinutes < r.MinUptimeHours*60 {
+		return false
+	}
+	return passRate >= float64(r.MinPassRate)
+}
+
+// Rough memory footprint of the store, for watching a long-running server
+type StoreUsage struct {
+	Nodes          int              `json:"nodes"`
+	Entries        map[string]int   `json:"entries"`         // Same counts as the diagnostics sizes
+	EstimatedBytes map[string]int64 `json:"estimated_bytes"` // The biggest collections only
+	TotalBytes     int64            `json:"total_bytes"`
+	LastCompaction *Compaction      `json:"last_compaction,omitempty"`
+}
+
+// An admin-started pass that recomputes every node's derived stats under
+// the current rules, e.g. after a threshold change. Trust scores are
+// stored, so they're rewritten; health grades and leaderboard eligibility
+// are worked out on read, so they're only tallied.
+type Recompute struct {
+	StartedAt    int64               `json:"started_at"`
+	FinishedAt   int64               `json:"finished_at,omitempty"` // Unset while running
+	Total        int                 `json:"total"`
+	Done         int                 `json:"done"`
+	TrustChanged int                 `json:"trust_changed"` // Nodes whose trust score moved
+	Grades       map[HealthGrade]int `json:"grades"`        // Nodes done so far, by health grade
+	Eligible     int                 `json:"eligible"`      // Nodes done so far that make the leaderboard
+}
+
+// What happened across the whole network in some window
+type NetworkActivity struct {
+	Checks        uint64                            `json:"checks"` // Challenge results recorded
+	Passed        uint64                            `json:"passed"`
+	Registrations uint64                            `json:"registrations"`
+	ByType        map[ChallengeType]ChallengeCounts `json:"by_type"` // Checks and passes of each challenge type
+}
+
+// Results of one challenge type
+type ChallengeCounts struct {
+	Checks uint64 `json:"checks"`
+	Passed uint64 `json:"passed"`
+}
+
+// Percent of checks passed, 0 with none
+func (c ChallengeCounts) PassRate() float64 {
+	if c.Checks == 0 {
+		return 0
+	}
+	return float64(c.Passed) / float64(c.Checks) * 100
+}
+
+// Percent of checks passed, 0 with none
+func (a NetworkActivity) PassRate() float64 {
+	if a.Checks == 0 {
+		return 0
+	}
+	return float64(a.Passed) / float64(a.Checks) * 100
+}
+
+// What a compaction pass gave back
+type Compaction struct {
+	At         int64 `json:"at"`
+	Slices     int   `json:"slices"`      // Copied into ones that fit
+	SlotsFreed int   `json:"slots_freed"` // Unused capacity released
+	BytesFreed int64 `json:"bytes_freed"` // Estimated
+}
+
+// Challenge results for a group of nodes
+type PassRate struct {
+	Passed uint64  `json:"passed"`
+	Failed uint64  `json:"failed"`
+	Rate   float64 `json:"rate"` // Percent passed
+}
+
+// Community report against a node suspected of cheating
+type CheatReport struct {
+	ID             string       `json:"id"`
+	NodeID         string       `json:"node_id"`
+	ReporterWallet string       `json:"reporter_wallet"`
+	Evidence       string       `json:"evidence"`
+	Status         ReportStatus `json:"status"`
+	CreatedAt      int64        `json:"created_at"`
+	ResolvedAt     int64        `json:"resolved_at,omitempty"`
+}
+
+type ReportStatus string
+
+const (
+	ReportOpen      ReportStatus = "open"
+	ReportUpheld    ReportStatus = "upheld"    // Admin warned or banned the node
+	ReportDismissed ReportStatus = "dismissed" // Admin cleared the node
+)
+
+// How often a wallet's reports turn out to be right
+type ReporterReputation struct {
+	WalletAddress string  `json:"wallet_address"`
+	Filed         uint64  `json:"filed"`
+	Upheld        uint64  `json:"upheld"`
+	Dismissed     uint64  `json:"dismissed"`
+	Score         float64 `json:"score"` // 0-1, starts at 0.5 with no history
+}
+
+// What a storage check concluded
+type StorageVerdict string
+
+const (
+	StorageOK          StorageVerdict = "ok"
+	StorageTooSmall    StorageVerdict = "too-small"     // Database smaller than the node type needs
+	StorageNoDeepState StorageVerdict = "no-deep-state" // Couldn't serve old state an archive keeps
+	StorageUnknown     StorageVerdict = "unknown"       // Node didn't give us anything to go on
+)
+
+// Evidence of how much chain data an exposed-rpc node really stores
+type StorageReport struct {
+	NodeID      string         `json:"node_id"`
+	ChainDataGB *float64       `json:"chain_data_gb,omitempty"`  // From debug_chaindbProperty, if exposed
+	DeepState   *bool          `json:"deep_state,omitempty"`     // Answered an old-state query correctly
+	Verdict     StorageVerdict `json:"verdict"`
+	Note        string         `json:"note,omitempty"`
+	CheckedAt   int64          `json:"checked_at"`
+}
+
+// Coarse facts about the machine a prover runs on. Vague on purpose:
+// enough to catch an impossible claim, not enough to identify anyone.
+type HardwareReport struct {
+	CPUCount  int    `json:"cpu_count"`
+	DiskClass string `json:"disk_class"` // Size bucket of the disk holding the node's data, e.g. "2tb-4tb"
+	OS        string `json:"os"`         // e.g. "linux/amd64"
+}
+
+// A hardware report signed by the registering wallet
+type HardwareAttestation struct {
+	Report    HardwareReport `json:"report"`
+	Signature string         `json:"signature"`
+}
+
+// Connection metadata from a challenge submission. Everyone running the
+// stock prover shares a client signature, so the source address is part
+// of what makes two submissions "the same machine".
+type ConnectionFingerprint struct {
+	JA3             string `json:"ja3,omitempty"`    // Forwarded by the TLS terminator, if it does
+	ClientSignature string `json:"client_signature"` // Hash of the HTTP client's header layout
+	UserAgent       string `json:"user_agent"`
+	RemoteAddr      string `json:"remote_addr"`
 }
 
+// Nodes from different wallets submitting from the same fingerprint
+type FingerprintCluster struct {
+	Fingerprint string                `json:"fingerprint"`
+	Connection  ConnectionFingerprint `json:"connection"`
+	Wallets     []string              `json:"wallets"`
+	NodeIDs     []string              `json:"node_ids"`
+	LastSeen    int64                 `json:"last_seen"`
+}
+
+// A wallet that can't register nodes until the ban runs out
+type WalletBan struct {
+	WalletAddress string `json:"wallet_address"`
+	Reason        string `json:"reason"`
+	NodeID        string `json:"node_id,omitempty"` // Node whose ban caused it, empty if banned by an admin directly
+	Offences      uint64 `json:"offences"`
+	BannedAt      int64  `json:"banned_at"`
+	ExpiresAt     int64  `json:"expires_at,omitempty"` // 0 = permanent
+}
+
+func (b *WalletBan) Active(now int64) bool {
+	return b.ExpiresAt == 0 || now < b.ExpiresAt
+}
+
+// A key heavy readers (explorers, analytics sites) send as X-API-Key to
+// read the public API on their plan's rate limit instead of the anonymous
+// one. The key itself is only shown once, when it's created.
+type APIKey struct {
+	ID            string `json:"id"` // Safe to show and log
+	WalletAddress string `json:"wallet_address"`
+	Label         string `json:"label,omitempty"`
+	Plan          string `json:"plan"`
+	CreatedAt     int64  `json:"created_at"`
+	RevokedAt     int64  `json:"revoked_at,omitempty"`
+	SecretHash    string `json:"-"` // SHA-256 of the key, hex
+}
+
+// A wallet's second factor: an authenticator app, asked for alongside the
+// wallet's signature on actions a stolen hot key shouldn't be enough for
+type WalletTOTP struct {
+	Secret      string // Base32, never shown after enrollment
+	CreatedAt   int64
+	ConfirmedAt int64 // 0 = enrolled, not yet proven with a code
+	LastStep    int64 // Time step of the last code used, so none works twice
+	Failures    int   // Wrong codes in a row
+	LockedUntil int64 // No code is taken before this, after too many wrong ones
+}
+
+// What an announcement is about
+type AnnouncementKind string
+
+const (
+	AnnounceGeneral       AnnouncementKind = "general"
+	AnnounceChallenges    AnnouncementKind = "challenges"     // New or retired challenge types
+	AnnounceProverVersion AnnouncementKind = "prover-version" // A prover release operators need
+	AnnounceRewards       AnnouncementKind = "rewards"        // Point rate changes
+)
+
+func (k AnnouncementKind) Valid() bool {
+	switch k {
+	case AnnounceGeneral, AnnounceChallenges, AnnounceProverVersion, AnnounceRewards:
+		return true
+	}
+	return false
+}
+
+// News for node operators, posted by admins. Provers print the current
+// ones at startup.
+type Announcement struct {
+	ID        uint64           `json:"id"`
+	Kind      AnnouncementKind `json:"kind"`
+	Title     string           `json:"title"`
+	Body      string           `json:"body,omitempty"`
+	PostedAt  int64            `json:"posted_at"`
+	ExpiresAt int64            `json:"expires_at,omitempty"` // 0 = until retracted
+}
+
+// What a ban is against. A two-factor reset waits for a second admin the
+// same way, so it's one too.
+type BanKind string
+
+const (
+	BanNode      BanKind = "node"
+	BanWallet    BanKind = "wallet"
+	BanTOTPReset BanKind = "totp-reset"
+)
+
+// A ban (or two-factor reset) one admin asked for, waiting for a different
+// admin to confirm it
+type PendingBan struct {
+	ID           string  `json:"id"` // "<kind>:<target>"
+	Kind         BanKind `json:"kind"`
+	Target       string  `json:"target"` // Node ID or wallet address
+	Reason       string  `json:"reason"`
+	CooldownDays *uint64 `json:"cooldown_days,omitempty"` // Wallet bans only, unset = configured cooldown
+	RequestedBy  string  `json:"requested_by"`
+	RequestedAt  int64   `json:"requested_at"`
+	ExpiresAt    int64   `json:"expires_at"`
+	ApprovedBy   string  `json:"approved_by,omitempty"`
+}
+
+// Why two nodes are thought to be run by the same operator
+type SiblingLink string
+
+const (
+	LinkWallet   SiblingLink = "wallet"   // Registered by the same wallet
+	LinkIP       SiblingLink = "ip"       // Submitted challenges from the same address
+	LinkEndpoint SiblingLink = "endpoint" // RPC endpoint on the same host
+)
+
+// A node sharing infrastructure with another one
+type SiblingNode struct {
+	NodeID        string        `json:"node_id"`
+	WalletAddress string        `json:"wallet_address"`
+	CheatStatus   CheatStatus   `json:"cheat_status"`
+	Links         []SiblingLink `json:"links"`
+}
+
+type ReclassificationStatus string
+
+const (
+	ReclassificationProposed  ReclassificationStatus = "proposed"
+	ReclassificationApplied   ReclassificationStatus = "applied"
+	ReclassificationDismissed ReclassificationStatus = "dismissed"
+)
+
+// A node whose probes say it runs a different type than it registered as.
+// Applied when the operator accepts, an admin applies it, or DueAt passes.
+type Reclassification struct {
+	NodeID        string                 `json:"node_id"`
+	WalletAddress string                 `json:"wallet_address"`
+	From          NodeType               `json:"from"`
+	To            NodeType               `json:"to"`
+	Reason        string                 `json:"reason"`
+	Status        ReclassificationStatus `json:"status"`
+	ProposedAt    int64                  `json:"proposed_at"`
+	DueAt         int64                  `json:"due_at"`
+	ResolvedAt    int64                  `json:"resolved_at,omitempty"`
+	ResolvedBy    string                 `json:"resolved_by,omitempty"` // "operator", "auto" or the admin
+}
+
+// How many nodes are ready for a scheduled hard fork
+type ForkReadiness struct {
+	Fork         string            `json:"fork"`
+	Chain        Chain             `json:"chain"`
+	ActivatesAt  int64             `json:"activates_at"`
+	Activated    bool              `json:"activated"`
+	Releases     map[string]string `json:"releases"` // Client -> first ready release
+	Nodes        int               `json:"nodes"`
+	Ready        int               `json:"ready"`
+	NotReady     int               `json:"not_ready"`
+	Unknown      int               `json:"unknown"` // No client version, or a client the fork lists no release for
+	ReadyPercent float64           `json:"ready_percent"`
+}
+
+// A node matching a search, for the explorer's search box
+type SearchResult struct {
+	NodeID        string      `json:"node_id"`
+	WalletAddress string      `json:"wallet_address"`
+	NodeType      NodeType    `json:"node_type"`
+	Network       Network     `json:"network"`
+	IsActive      bool        `json:"is_active"`
+	CheatStatus   CheatStatus `json:"cheat_status"`
+	TotalPoints   uint64      `json:"total_points"`
+	Tags          []string    `json:"tags"`    // What the node can be found by besides its ID and wallet
+	Matched       []string    `json:"matched"` // Why it's here: "id", "wallet" or a tag
+	Score         int         `json:"score"`   // Higher is a better match
+}
+
+// One verification as a row of anti-cheat features, for training models
+// offline. Nothing in it names the node or wallet: nodes and connection
+// clusters are pseudonyms that only hold within one export.
+type VerificationFeatures struct {
+	Node           string        `json:"node"`
+	ChallengeType  ChallengeType `json:"challenge_type"`
+	ResponseTimeMs uint64        `json:"response_time_ms"`
+	HourOfDay      int           `json:"hour_of_day"` // UTC
+	Day            string        `json:"day"`         // UTC, for splitting by time
+	BlockAge       *uint64       `json:"block_age"`   // Nil if not about a block, or recorded before block ages were
+	Cluster        string        `json:"cluster"`     // The node's largest connection fingerprint cluster, "" if none
+	ClusterWallets int           `json:"cluster_wallets"`
+	Surprise       bool          `json:"surprise"`
+	Suspicious     bool          `json:"suspicious"`
+	Passed         bool          `json:"passed"`
+	FailureKind    FailureKind   `json:"failure_kind"`
+}
+
+// How long an operator can pause their node each month (UTC)
+const MaintenanceAllowanceMinutes uint64 = 48 * 60
+
 // Latency limits for anti-cheat
 const (
 	LatencyLocalNode      uint64 = 100   // Local nodes respond in under 100ms
@@ -12,6 +12,7 @@ func TestNodeTypeRegistrationBonus(t *testing.T) {
 		{BscFast, 40},
 		{OpbnbFull, 40},
 		{OpbnbFast, 30},
+		{GreenfieldSP, 80},
 		{NodeType("unknown"), 0},
 	}
 
@@ -33,6 +34,7 @@ func TestNodeTypePointsPerHour(t *testing.T) {
 		{BscFast, 4},
 		{OpbnbFull, 4},
 		{OpbnbFast, 3},
+		{GreenfieldSP, 8},
 		{NodeType("unknown"), 0},
 	}
 
@@ -54,6 +56,7 @@ func TestNodeTypeMinUptimePercent(t *testing.T) {
 		{BscFast, 90},
 		{OpbnbFull, 90},
 		{OpbnbFast, 85},
+		{GreenfieldSP, 95},
 		{NodeType("unknown"), 90}, // Default is 90
 	}
 
@@ -132,3 +135,90 @@ func TestLatencyConstants(t *testing.T) {
 		t.Error("LatencyPublicRPC should be less than LatencyMaxAllowed")
 	}
 }
+
+func TestVerificationResultCredit(t *testing.T) {
+	tests := []struct {
+		result         VerificationResult
+		passed, failed uint64
+	}{
+		{VerificationResult{Passed: true}, 1, 0},
+		{VerificationResult{Passed: false}, 0, 1},
+		{VerificationResult{Passed: false, Parts: []PartResult{{Passed: true}, {Passed: false}, {Passed: true}}}, 2, 1},
+		{VerificationResult{Passed: true, Parts: []PartResult{{Passed: true}, {Passed: true}}}, 2, 0},
+	}
+
+	for i, tt := range tests {
+		if passed, failed := tt.result.Credit(); passed != tt.passed || failed != tt.failed {
+			t.Errorf("case %d: credit = %d/%d, want %d/%d", i, passed, failed, tt.passed, tt.failed)
+		}
+	}
+}
+
+func TestNodeLiveness(t *testing.T) {
+	const minute = int64(60 * 1000)
+	now := 1000 * minute
+
+	tests := []struct {
+		name string
+		node NodeRegistration
+		want Liveness
+	}{
+		{"never seen", NodeRegistration{NodeType: BscFull, IsActive: true}, Offline},
+		{"fresh heartbeat", NodeRegistration{NodeType: BscFull, IsActive: true, LastHeartbeatAt: now - 5*minute}, Online},
+		{"late proof", NodeRegistration{NodeType: BscFull, IsActive: true, LastVerifiedAt: now - 45*minute}, Degraded},
+		{"later of the two", NodeRegistration{NodeType: BscFull, IsActive: true, LastVerifiedAt: now - 45*minute, LastHeartbeatAt: now - minute}, Online},
+		{"slower type", NodeRegistration{NodeType: OpbnbFast, IsActive: true, LastVerifiedAt: now - 45*minute}, Online},
+		{"quiet", NodeRegistration{NodeType: BscFull, IsActive: true, LastVerifiedAt: now - 100*minute}, Offline},
+		{"inactive", NodeRegistration{NodeType: BscFull, LastHeartbeatAt: now}, Offline},
+		{"paused", NodeRegistration{NodeType: BscFull, IsActive: true, Paused: true, LastHeartbeatAt: now}, Offline},
+	}
+
+	for _, tt := range tests {
+		if got := tt.node.Liveness(now); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLeaderboardEligible(t *testing.T) {
+	rules := LeaderboardRules{MinUptimeHours: 24, MinPassRate: 90, CleanOnly: true}
+
+	tests := []struct {
+		name     string
+		rules    LeaderboardRules
+		node     NodeRegistration
+		passRate float64
+		want     bool
+	}{
+		{"no rules", LeaderboardRules{}, NodeRegistration{CheatStatus: StatusWarning}, 0, true},
+		{"banned", LeaderboardRules{}, NodeRegistration{CheatStatus: StatusBanned}, 100, false},
+		{"meets all", rules, NodeRegistration{CheatStatus: StatusClean, TotalUptimeMinutes: 24 * 60}, 90, true},
+		{"too new", rules, NodeRegistration{CheatStatus: StatusClean, TotalUptimeMinutes: 24*60 - 1}, 100, false},
+		{"failing", rules, NodeRegistration{CheatStatus: StatusClean, TotalUptimeMinutes: 48 * 60}, 89.9, false},
+		{"warned", rules, NodeRegistration{CheatStatus: StatusWarning, TotalUptimeMinutes: 48 * 60}, 100, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.rules.Eligible(&tt.node, tt.passRate); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNodeTypesComplete(t *testing.T) {
+	for _, nodeType := range NodeTypes {
+		if nodeType.RegistrationBonus() == 0 || nodeType.PointsPerHour() == 0 {
+			t.Errorf("%s has no points configured", nodeType)
+		}
+		if len(nodeType.ChallengeTypes()) == 0 {
+			t.Errorf("%s can't be challenged", nodeType)
+		}
+	}
+
+	// Storage providers never get chain challenges
+	for _, ct := range GreenfieldSP.ChallengeTypes() {
+		if ct != ObjectExists && ct != ObjectChecksum {
+			t.Errorf("greenfield-sp should only get object challenges, got %s", ct)
+		}
+	}
+}
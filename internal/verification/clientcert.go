@@ -0,0 +1,22 @@
+package verification
+
+import (
+	"github.com/depinonbnb/depin/internal/clientcert"
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// Key node client certificates are sealed with. Without one (the
+// default), nodes can't give us a certificate.
+func (v *Verifier) SetClientCertSealer(sealer *clientcert.Sealer) {
+	v.mu.Lock()
+	v.certs = sealer
+	v.mu.Unlock()
+}
+
+// Check a node's PEM client cert and key and seal them for storing
+func (v *Verifier) SealClientCert(certPEM, keyPEM string) (*types.ClientCert, error) {
+	v.mu.RLock()
+	sealer := v.certs
+	v.mu.RUnlock()
+	return sealer.Seal(certPEM, keyPEM, v.clock.Now())
+}
@@ -0,0 +1,81 @@
+package verification
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/signing"
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// A proxy that forwards our challenge to a public RPC can still answer
+// well inside the latency limit, because the prover reports its own
+// query time. With commit-reveal the prover first commits to
+// H(answer‖nonce) and reveals later; the latency that counts is when the
+// commitment reached us, which we measure ourselves.
+const CommitWindow = 2 * time.Second
+
+var (
+	ErrChallengeNotFound = errors.New("challenge not found or expired")
+	ErrCommitNotRequired = errors.New("challenge doesn't take a commitment")
+	ErrAlreadyCommitted  = errors.New("answer already committed")
+	ErrCommitTooLate     = errors.New("commit deadline passed")
+)
+
+// Give a challenge a commit deadline if the node is in the rollout. Only
+// local provers - exposed nodes are timed by us already.
+func (v *Verifier) requireCommit(ch *types.Challenge, node *types.NodeRegistration) {
+	if node.VerificationMethod != types.LocalProver || !v.flags.EnabledFor(FlagCommitReveal, node.ID) {
+		return
+	}
+	ch.CommitBy = ch.CreatedAt + CommitWindow.Milliseconds()
+}
+
+// Record a node's commitment to its answer. The commit time is what the
+// answer's latency is judged on.
+func (v *Verifier) CommitAnswer(challengeID, nodeID, commitment string) error {
+	v.adoptPending(challengeID)
+	if err := v.commitAnswer(challengeID, nodeID, commitment); err != nil {
+		return err
+	}
+	// The reveal may go to another server
+	v.sharePending(challengeID)
+	return nil
+}
+
+func (v *Verifier) commitAnswer(challengeID, nodeID, commitment string) error {
+	now := v.clock.Now().UnixMilli()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	pending, exists := v.pendingChallenges[challengeID]
+	if !exists || pending.Challenge.NodeID != nodeID {
+		return ErrChallengeNotFound
+	}
+	if pending.Challenge.CommitBy == 0 {
+		return ErrCommitNotRequired
+	}
+	if pending.Commitment != "" {
+		return ErrAlreadyCommitted
+	}
+	if now > pending.Challenge.CommitBy {
+		return ErrCommitTooLate
+	}
+
+	pending.Commitment = strings.ToLower(commitment)
+	pending.CommittedAt = now
+	return nil
+}
+
+// Why a reveal doesn't stand, and how to count the failure ("" if it does)
+func checkReveal(commitment string, response *types.ChallengeResponse) (string, types.FailureKind) {
+	if commitment == "" {
+		return "answer wasn't committed before the deadline", types.FailureTooSlow
+	}
+	if signing.Commitment(response.Answer, response.Nonce) != commitment {
+		return "answer doesn't match commitment", types.FailureWrongAnswer
+	}
+	return "", ""
+}
@@ -0,0 +1,52 @@
+package verification
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// Grade a composite answer part by part. Both answers are JSON arrays with
+// one entry per part; "" is a part the node couldn't answer.
+func (v *Verifier) gradeParts(ch *types.Challenge, submitted, expected string) []types.PartResult {
+	parts := make([]types.PartResult, len(ch.Params.Parts))
+	for i, part := range ch.Params.Parts {
+		parts[i].ChallengeType = part.ChallengeType
+	}
+
+	var got, want []string
+	if json.Unmarshal([]byte(submitted), &got) != nil || len(got) != len(parts) {
+		for i := range parts {
+			parts[i].FailureReason = fmt.Sprintf("answer isn't a list of %d answers", len(parts))
+		}
+		return parts
+	}
+	json.Unmarshal([]byte(expected), &want)
+
+	for i := range parts {
+		switch {
+		case got[i] == "":
+			parts[i].FailureReason = "not answered"
+		case i >= len(want) || !v.compareAnswers(got[i], want[i], parts[i].ChallengeType):
+			parts[i].FailureReason = "incorrect answer"
+		default:
+			parts[i].Passed = true
+		}
+	}
+	return parts
+}
+
+// Why a composite failed, "" if every part passed
+func partsFailure(parts []types.PartResult) string {
+	passed := 0
+	for _, part := range parts {
+		if part.Passed {
+			passed++
+		}
+	}
+	if passed == len(parts) {
+		return ""
+	}
+	return fmt.Sprintf("%d of %d parts incorrect", len(parts)-passed, len(parts))
+}
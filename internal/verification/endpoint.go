@@ -0,0 +1,75 @@
+package verification
+
+import (
+	"fmt"
+
+	"github.com/depinonbnb/depin/internal/rpc"
+	"github.com/depinonbnb/depin/internal/tunnel"
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// What trying an exposed-rpc endpoint before accepting it found. Problem
+// means it can't pass a single challenge as it is; warnings are things a
+// node can grow out of, like still syncing.
+type EndpointCheck struct {
+	Reachable bool     `json:"reachable"`
+	ChainID   uint64   `json:"chain_id,omitempty"`
+	Head      uint64   `json:"head,omitempty"`
+	Synced    bool     `json:"synced"`
+	LatencyMs uint64   `json:"latency_ms,omitempty"`
+	Problem   string   `json:"problem,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+// Probe an endpoint the way scheduled verification will use it: it has to
+// answer, be on this network's chain, and be synced near our head.
+func (v *Verifier) CheckEndpoint(nodeType types.NodeType, endpoint, authToken string, cert *types.ClientCert) *EndpointCheck {
+	check := &EndpointCheck{}
+
+	if nodeType.Chain() == types.ChainGreenfield {
+		latency, err := rpc.NewGreenfieldClient(endpoint, authToken).GetStatus()
+		if err != nil {
+			check.Problem = fmt.Sprintf("endpoint unreachable: %v", err)
+			return check
+		}
+		check.Reachable, check.Synced, check.LatencyMs = true, true, latency
+		return check
+	}
+
+	// A tunnel is opened by the prover after registering, so there may be
+	// nothing to try yet
+	if tunnel.IsEndpoint(endpoint) && !v.tunnels.Connected(tunnel.NodeID(endpoint)) {
+		check.Warnings = append(check.Warnings, "tunnel isn't open yet, start the prover with --tunnel")
+		return check
+	}
+
+	nodeRPC := v.rpcClient(endpoint, authToken, nodeType.Chain(), cert)
+	head, latency, err := nodeRPC.GetBlockNumber()
+	if err != nil {
+		check.Problem = fmt.Sprintf("endpoint unreachable: %v", err)
+		return check
+	}
+	check.Reachable, check.Head, check.LatencyMs = true, head, latency
+
+	v.mu.RLock()
+	network := v.network
+	v.mu.RUnlock()
+	want := nodeType.Chain().ChainID(network)
+	if chainID, _, err := nodeRPC.GetChainID(); err != nil {
+		check.Warnings = append(check.Warnings, "couldn't read the chain ID")
+	} else if check.ChainID = chainID; chainID != want {
+		check.Problem = fmt.Sprintf("node is on chain %d, %s %s is chain %d", chainID, network, nodeType.Chain(), want)
+		return check
+	}
+
+	check.Synced, _, _ = nodeRPC.GetSyncStatus()
+	if !check.Synced {
+		check.Warnings = append(check.Warnings, "node is still syncing, so challenges will fail until it catches up")
+	}
+	// The trusted RPC being down isn't the node's fault
+	if trustedHead, _, err := v.trustedFor(nodeType).GetBlockNumber(); err == nil && head+HeartbeatMaxLag < trustedHead {
+		check.Synced = false
+		check.Warnings = append(check.Warnings, fmt.Sprintf("node's head #%d is %d blocks behind the chain", head, trustedHead-head))
+	}
+	return check
+}
@@ -0,0 +1,106 @@
+package verification
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+
+	"github.com/depinonbnb/depin/internal/rpc"
+	"github.com/depinonbnb/depin/internal/tunnel"
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// Anything we can put a challenge to: a JSON-RPC node or a Greenfield SP
+type challengeTarget interface {
+	ExecuteChallenge(challenge *types.Challenge) rpc.RpcResponse
+}
+
+// Client for talking to the node itself, in whatever protocol it speaks
+func (v *Verifier) nodeClient(node *types.NodeRegistration) challengeTarget {
+	if node.NodeType.Chain() == types.ChainGreenfield {
+		return rpc.NewGreenfieldClient(node.RPCEndpoint, node.AuthToken)
+	}
+	return v.rpcFor(node)
+}
+
+// JSON-RPC client for a node, through its tunnel if it registered one, and
+// presenting its client certificate if it gave us one
+func (v *Verifier) rpcClient(endpoint, authToken string, chain types.Chain, cert *types.ClientCert) *rpc.Client {
+	client := rpc.NewClient(endpoint, authToken).WithChain(chain)
+	if tunnel.IsEndpoint(endpoint) {
+		return client.WithTransport(v.tunnels)
+	}
+	if cert != nil {
+		v.mu.RLock()
+		sealer := v.certs
+		v.mu.RUnlock()
+		pair, err := sealer.Open(cert)
+		if err != nil {
+			// The node will turn us away, which is what it'll be judged on
+			log.Printf("can't present client certificate %s: %v", cert.Fingerprint, err)
+			return client
+		}
+		client.WithTLS(&tls.Config{Certificates: []tls.Certificate{pair}})
+	}
+	return client
+}
+
+func (v *Verifier) rpcFor(node *types.NodeRegistration) *rpc.Client {
+	return v.rpcClient(node.RPCEndpoint, node.AuthToken, node.NodeType.Chain(), node.ClientCert)
+}
+
+// Storage provider whose answers object challenges are checked against
+func (v *Verifier) SetTrustedGreenfieldSP(endpoint string) {
+	client := rpc.NewGreenfieldClient(endpoint, "")
+	v.mu.Lock()
+	v.trustedGreenfield = client
+	v.mu.Unlock()
+}
+
+// Public objects storage providers get asked about, as "bucket/object".
+// Call before serving.
+func (v *Verifier) SetGreenfieldObjects(objects []string) {
+	v.generator.SetObjects(objects)
+}
+
+// Object challenges for storage providers. Never a honeypot - there's no
+// deep history to probe, and missing-object probes already catch SPs that
+// answer without looking.
+func (v *Verifier) nextObjectChallenge(node *types.NodeRegistration) (*types.Challenge, string, bool, error) {
+	ch := v.generator.GenerateChallenge(node.ID, node.NodeType)
+	if ch == nil {
+		return nil, "", false, ErrNoChallengeTypes
+	}
+	if ch.Params.Bucket == "" {
+		return ch, "", false, fmt.Errorf("no Greenfield objects configured")
+	}
+
+	v.mu.RLock()
+	trusted := v.trustedGreenfield
+	v.mu.RUnlock()
+	if trusted == nil {
+		return ch, "", false, fmt.Errorf("no trusted Greenfield SP configured")
+	}
+
+	response := trusted.ExecuteChallenge(ch)
+	if !response.Success {
+		return ch, "", false, fmt.Errorf("%s", response.Error)
+	}
+	return ch, response.Data, false, nil
+}
+
+// Storage providers have no block height - a heartbeat just checks the
+// SP API is up
+func (v *Verifier) checkSPHeartbeat(node *types.NodeRegistration) *types.HeartbeatRecord {
+	latency, err := rpc.NewGreenfieldClient(node.RPCEndpoint, node.AuthToken).GetStatus()
+	if err != nil {
+		return nil
+	}
+
+	return &types.HeartbeatRecord{
+		NodeID:    node.ID,
+		Timestamp: v.clock.Now().UnixMilli(),
+		IsSynced:  true,
+		LatencyMs: latency,
+	}
+}
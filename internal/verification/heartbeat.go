@@ -0,0 +1,69 @@
+package verification
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// How far a prover's head may trail ours and still count as synced, and
+// how far it may lead, since the trusted RPC can be a few blocks behind
+const (
+	HeartbeatMaxLag  = 20
+	heartbeatMaxLead = 5
+)
+
+// What a prover's heartbeat told us about its node
+type HeartbeatCheck struct {
+	TrustedHead uint64
+	Synced      bool   // Head within HeartbeatMaxLag of ours
+	Suspicious  string // Why the reported block can't be real, "" if it can
+}
+
+// Check the newest block a prover says its node has against the trusted
+// chain. A hash that doesn't match, or a block past the head, means the
+// prover made it up. Errors only when the trusted RPC can't be reached.
+func (v *Verifier) CheckProverHeartbeat(node *types.NodeRegistration, blockNumber uint64, blockHash string) (HeartbeatCheck, error) {
+	trusted := v.trustedFor(node.NodeType)
+	head, _, err := trusted.GetBlockNumber()
+	if err != nil {
+		return HeartbeatCheck{}, err
+	}
+	check := HeartbeatCheck{TrustedHead: head}
+
+	if blockNumber > head+heartbeatMaxLead {
+		check.Suspicious = fmt.Sprintf("Heartbeat reported block %d, past the chain head %d", blockNumber, head)
+		return check, nil
+	}
+	check.Synced = blockNumber+HeartbeatMaxLag >= head
+
+	expected, ok := v.heartbeatHash(node.NodeType, blockNumber)
+	if !ok {
+		// Too new for the trusted RPC to have yet; the number alone will do
+		return check, nil
+	}
+	if !strings.EqualFold(expected, blockHash) {
+		check.Synced = false
+		check.Suspicious = fmt.Sprintf("Heartbeat block %d hash %s doesn't match the chain", blockNumber, blockHash)
+	}
+	return check, nil
+}
+
+// The real hash of a block, from the header chain when it has it
+func (v *Verifier) heartbeatHash(nodeType types.NodeType, number uint64) (string, bool) {
+	v.mu.RLock()
+	headers := v.headers
+	v.mu.RUnlock()
+
+	if headers != nil && nodeType.Chain() == types.ChainBSC {
+		if hash, ok := headers.Hash(number); ok {
+			return hash, true
+		}
+	}
+	hash, _, err := v.trustedFor(nodeType).GetBlockHash(number)
+	if err != nil || hash == "" {
+		return "", false
+	}
+	return hash, true
+}
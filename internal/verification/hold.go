@@ -0,0 +1,77 @@
+package verification
+
+import (
+	"sort"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// An answer that arrived while the service was in maintenance
+type heldAnswer struct {
+	response   *types.ChallengeResponse
+	receivedAt int64
+}
+
+// Keep an answer to check once maintenance is over, instead of checking
+// it now against a service that may be half down. Only answers to a
+// challenge still waiting on the node it was for are held; anything else
+// would fail anyway and can be checked straight away. A second answer to
+// the same challenge is dropped, as it would be once the first was
+// checked. With a shared cache the challenge is claimed when its answer is
+// held, since maintenance may outlast its time in the cache. Returns
+// whether the answer was held.
+func (v *Verifier) HoldAnswer(response *types.ChallengeResponse, receivedAt int64) bool {
+	v.adoptPending(response.ChallengeID)
+	v.mu.RLock()
+	pending := v.pendingChallenges[response.ChallengeID]
+	_, held := v.held[response.ChallengeID]
+	v.mu.RUnlock()
+	if pending == nil || pending.Challenge.NodeID != response.NodeID {
+		return false
+	}
+	if held {
+		return true
+	}
+	if !v.claimPending(response.ChallengeID) {
+		v.deleteChallenge(response.ChallengeID) // Verified elsewhere
+		return false
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.held[response.ChallengeID]; !ok {
+		v.held[response.ChallengeID] = &heldAnswer{response: response, receivedAt: receivedAt}
+	}
+	return true
+}
+
+// How many answers are waiting on the end of maintenance
+func (v *Verifier) HeldAnswers() int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return len(v.held)
+}
+
+// Check every held answer, oldest first, as of when it arrived: the wait
+// doesn't make it late. The caller records the results.
+func (v *Verifier) VerifyHeld() []*types.VerificationResult {
+	v.mu.RLock()
+	held := make([]*heldAnswer, 0, len(v.held))
+	for _, h := range v.held {
+		held = append(held, h)
+	}
+	v.mu.RUnlock()
+
+	sort.Slice(held, func(i, j int) bool {
+		return held[i].receivedAt < held[j].receivedAt
+	})
+	results := make([]*types.VerificationResult, 0, len(held))
+	for _, h := range held {
+		results = append(results, v.verifyAt(h.response, h.receivedAt))
+		// Only let go of it now, so cleanup can't expire its challenge first
+		v.mu.Lock()
+		delete(v.held, h.response.ChallengeID)
+		v.mu.Unlock()
+	}
+	return results
+}
@@ -0,0 +1,92 @@
+package verification
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// When the honeypot flag is on for a node, roughly one challenge in this
+// many is a honeypot
+const honeypotOneIn = 10
+
+// Only archive nodes keep the deep state honeypots ask about
+func holdsDeepState(nodeType types.NodeType) bool {
+	return nodeType == types.BscArchive
+}
+
+// Pick the next challenge for a node, sometimes a honeypot. Falls back to
+// a normal challenge if our trusted node can't answer the honeypot.
+func (v *Verifier) nextChallenge(node *types.NodeRegistration) (*types.Challenge, string, bool, error) {
+	if node.NodeType.Chain() == types.ChainGreenfield {
+		return v.nextObjectChallenge(node)
+	}
+
+	trusted := v.trustedFor(node.NodeType)
+	if v.flags.EnabledFor(FlagHoneypot, node.ID) && rand.Intn(honeypotOneIn) == 0 {
+		ch := v.generator.GenerateHoneypot(node.ID, node.NodeType)
+		if response := trusted.ExecuteChallenge(ch); response.Success {
+			return ch, response.Data, true, nil
+		}
+	}
+
+	ch := v.generator.GenerateChallenge(node.ID, node.NodeType)
+	if ch == nil {
+		return nil, "", false, ErrNoChallengeTypes
+	}
+	if expected, ok := v.headerChainAnswer(ch, node.NodeType); ok {
+		return ch, expected, false, nil
+	}
+
+	// A composite needs our answer to every part, not just some
+	response := trusted.ExecuteChallenge(ch)
+	if !response.Success || response.Error != "" {
+		return ch, "", false, fmt.Errorf("%s", response.Error)
+	}
+	return ch, response.Data, false, nil
+}
+
+// Point a BSC block-hash challenge at a block from the header chain, if
+// we're keeping one, and return its hash as the expected answer
+func (v *Verifier) headerChainAnswer(ch *types.Challenge, nodeType types.NodeType) (string, bool) {
+	v.mu.RLock()
+	headers := v.headers
+	v.mu.RUnlock()
+
+	if headers == nil || ch.ChallengeType != types.BlockHash || nodeType.Chain() != types.ChainBSC {
+		return "", false
+	}
+	number, hash, ok := headers.Random()
+	if !ok {
+		return "", false
+	}
+	ch.Params.BlockNumber = &number
+	return hash, true
+}
+
+// Honeypots are graded on whether the node could answer at all, not just
+// on whether it got it right. answered is false when the node's RPC
+// returned an error instead of an answer.
+func gradeHoneypot(result *types.VerificationResult, nodeType types.NodeType, answered bool) {
+	if holdsDeepState(nodeType) {
+		// A claimed archive node fronting a pruned public RPC misses these
+		if !result.Passed && !result.Suspicious {
+			result.Suspicious = true
+			result.SuspiciousNote = "Archive node couldn't serve deep historical state - possibly proxying to a pruned RPC"
+		}
+		return
+	}
+
+	switch {
+	case result.Passed:
+		result.Suspicious = true
+		result.SuspiciousNote = fmt.Sprintf("Answered archive-only state a %s node doesn't keep - likely proxying to an archive RPC", nodeType)
+	case !answered:
+		// What an honest node does - it doesn't have the state
+		result.Passed = true
+		result.FailureReason = ""
+		result.FailureKind = ""
+		result.Replay = nil
+	}
+}
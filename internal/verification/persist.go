@@ -0,0 +1,86 @@
+package verification
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// What SavePending writes. It holds expected answers, so it's only
+// readable by the server's user.
+type pendingFile struct {
+	SavedAt    int64               `json:"saved_at"`
+	Challenges []*pendingChallenge `json:"challenges"`
+}
+
+// Write every unexpired pending challenge to path, so the next server can
+// take them over and provers mid-proof don't fail across a deploy. Call it
+// once requests have stopped. Returns how many were saved.
+func (v *Verifier) SavePending(path string) (int, error) {
+	now := v.clock.Now().UnixMilli()
+	file := pendingFile{SavedAt: now, Challenges: []*pendingChallenge{}}
+
+	v.mu.RLock()
+	for _, pending := range v.pendingChallenges {
+		if now <= pending.Challenge.ExpiresAt {
+			file.Challenges = append(file.Challenges, pending)
+		}
+	}
+	data, err := json.Marshal(file)
+	v.mu.RUnlock()
+	if err != nil {
+		return 0, err
+	}
+
+	// Never leave a half-written file for the next start to trip over
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+	return len(file.Challenges), nil
+}
+
+// Take over the challenges SavePending wrote to path, leaving out any that
+// expired in between. The file is removed so they're only restored once.
+// A missing file isn't an error.
+func (v *Verifier) LoadPending(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var file pendingFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return 0, err
+	}
+
+	now := v.clock.Now().UnixMilli()
+	restored := 0
+	v.mu.Lock()
+	for _, pending := range file.Challenges {
+		if pending.Challenge == nil || now > pending.Challenge.ExpiresAt {
+			continue
+		}
+		if _, exists := v.pendingChallenges[pending.Challenge.ID]; !exists {
+			v.pendingChallenges[pending.Challenge.ID] = pending
+			restored++
+		}
+	}
+	v.mu.Unlock()
+
+	return restored, os.Remove(path)
+}
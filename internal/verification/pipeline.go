@@ -0,0 +1,208 @@
+package verification
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// A submitted answer on its way through verification, and the verdict so far
+type answer struct {
+	response            *types.ChallengeResponse
+	pending             *pendingChallenge // Nil unless checkExists found it for this node
+	commitment          string
+	committedAt         int64
+	latencySuspiciousMs uint64
+	latencyMaxMs        uint64
+	result              *types.VerificationResult
+	now                 int64
+}
+
+// Something an answer has to get past. Returning false ends verification
+// with the failure the check recorded.
+type answerCheck func(v *Verifier, a *answer) bool
+
+// Runs on every verdict, pass or fail. Scorers can mark a result
+// suspicious or regrade it but never stop verification.
+type answerScorer func(v *Verifier, a *answer)
+
+// In order. New anti-cheat rules go in here rather than in VerifyResponse.
+var (
+	answerChecks  = []answerCheck{checkExists, checkExpiry, checkBinding, checkClaim, checkAnswer, checkLatency}
+	answerScorers = []answerScorer{scoreAnomaly, scoreHoneypot, scoreProviderLatency}
+)
+
+// Start an answer off as passing; checks fail it
+func (v *Verifier) newAnswer(response *types.ChallengeResponse, now int64) *answer {
+	v.mu.RLock()
+	pending := v.pendingChallenges[response.ChallengeID]
+	a := &answer{
+		response:            response,
+		latencySuspiciousMs: v.latencySuspiciousMs,
+		latencyMaxMs:        v.latencyMaxMs,
+		now:                 now,
+	}
+	if pending != nil {
+		a.commitment, a.committedAt = pending.Commitment, pending.CommittedAt
+	}
+	v.mu.RUnlock()
+
+	a.result = &types.VerificationResult{
+		ChallengeID:    response.ChallengeID,
+		NodeID:         response.NodeID,
+		Passed:         true,
+		ResponseTimeMs: response.ResponseTimeMs,
+		Timestamp:      now,
+	}
+	return a
+}
+
+// Record a failure and stop
+func (a *answer) fail(reason string, kind types.FailureKind) bool {
+	a.result.Passed = false
+	a.result.FailureReason = reason
+	a.result.FailureKind = kind
+	if a.pending != nil {
+		a.result.Replay = newReplay(a.pending.Challenge, a.pending.ExpectedAnswer, a.response.Answer)
+	}
+	return false
+}
+
+// The challenge has to be one we issued and haven't had answered yet
+func checkExists(v *Verifier, a *answer) bool {
+	v.mu.RLock()
+	a.pending = v.pendingChallenges[a.response.ChallengeID]
+	v.mu.RUnlock()
+
+	if a.pending == nil {
+		return a.fail("challenge not found or expired", types.FailureExpired)
+	}
+	return true
+}
+
+// Challenges expire after a minute (surprises sooner)
+func checkExpiry(v *Verifier, a *answer) bool {
+	if a.now > a.pending.Challenge.ExpiresAt {
+		return a.fail("challenge expired", types.FailureExpired)
+	}
+	return true
+}
+
+// The answer has to be bound to the challenge: from the node the (signed)
+// challenge names, signed by the wallet that requested it, and with
+// commit-reveal, the answer committed to. How fast the commit arrived is
+// then the latency that counts.
+func checkBinding(v *Verifier, a *answer) bool {
+	requester := a.pending.RequestedBy
+	if a.pending.Challenge.NodeID != a.response.NodeID ||
+		(requester != "" && !strings.EqualFold(requester, a.response.Wallet)) {
+		// Someone else's challenge. Forget we found it, so it stays
+		// pending for the node it was issued to.
+		a.pending = nil
+		return a.fail("challenge not found or expired", types.FailureExpired)
+	}
+
+	if t := a.response.ChallengeType; t != "" && t != a.pending.Challenge.ChallengeType {
+		return a.fail(fmt.Sprintf("answer was signed for a %s challenge, not %s", t, a.pending.Challenge.ChallengeType), types.FailureWrongAnswer)
+	}
+
+	if a.pending.Challenge.CommitBy == 0 {
+		return true
+	}
+	if reason, kind := checkReveal(a.commitment, a.response); reason != "" {
+		return a.fail(reason, kind)
+	}
+	revealed := *a.response
+	revealed.ResponseTimeMs = uint64(a.committedAt - a.pending.Challenge.CreatedAt)
+	a.response = &revealed
+	a.result.ResponseTimeMs = revealed.ResponseTimeMs
+	return true
+}
+
+// Does their answer match ours? Composites are graded part by part, and
+// fail if any part is wrong; the parts they got right still count.
+func checkAnswer(v *Verifier, a *answer) bool {
+	if ch := a.pending.Challenge; ch.ChallengeType == types.Composite {
+		a.result.Parts = v.gradeParts(ch, a.response.Answer, a.pending.ExpectedAnswer)
+		if reason := partsFailure(a.result.Parts); reason != "" {
+			return a.fail(reason, types.FailureWrongAnswer)
+		}
+		return true
+	}
+	if !v.compareAnswers(a.response.Answer, a.pending.ExpectedAnswer, a.pending.Challenge.ChallengeType) {
+		return a.fail("incorrect answer", types.FailureWrongAnswer)
+	}
+	return true
+}
+
+// Right answers over the latency limit still fail
+func checkLatency(v *Verifier, a *answer) bool {
+	if a.response.ResponseTimeMs > a.latencyMaxMs {
+		a.fail("response too slow", types.FailureTooSlow)
+		a.result.Suspicious = true
+		a.result.SuspiciousNote = "Response took too long - possible proxy or offline node"
+		return false
+	}
+	return true
+}
+
+// Ask the anomaly scorer about the answer. A suspicious verdict doesn't
+// fail it. The external scorer, where one is set and rolled out to the
+// node, replaces the rules; if it fails, the rules score the answer.
+func scoreAnomaly(v *Verifier, a *answer) {
+	nodeID := a.response.NodeID
+	input := AnomalyInput{
+		NodeID:         nodeID,
+		ChallengeType:  a.result.ChallengeType,
+		ResponseTimeMs: a.result.ResponseTimeMs,
+		HourOfDay:      time.UnixMilli(a.now).UTC().Hour(),
+		BlockAge:       a.result.BlockAge,
+		Surprise:       a.result.Surprise,
+		Passed:         a.result.Passed,
+		FailureKind:    a.result.FailureKind,
+	}
+
+	v.mu.RLock()
+	scorer := v.scorer
+	v.mu.RUnlock()
+	if scorer != nil && v.flags.EnabledFor(FlagExternalScorer, nodeID) {
+		verdict, err := scorer.Score(input)
+		if err == nil {
+			markAnomaly(a, verdict)
+			return
+		}
+		log.Printf("anomaly scorer failed for node %s, using the rules: %v", nodeID, err)
+	}
+
+	if !v.flags.EnabledFor(FlagLatencyRule, nodeID) {
+		return
+	}
+	verdict, _ := RuleScorer{SuspiciousMs: a.latencySuspiciousMs}.Score(input)
+	markAnomaly(a, verdict)
+}
+
+func markAnomaly(a *answer, verdict AnomalyVerdict) {
+	if !verdict.Suspicious {
+		return
+	}
+	if !a.result.Suspicious {
+		a.result.Suspicious = true
+		a.result.SuspiciousNote = verdict.Note
+	}
+	log.Printf("suspicious answer from node %s (score %.2f): %s", a.response.NodeID, verdict.Score, verdict.Note)
+}
+
+// Honeypots are graded on whether the node should have been able to answer
+func scoreHoneypot(v *Verifier, a *answer) {
+	if a.pending == nil || !a.pending.Honeypot || a.result.FailureKind == types.FailureExpired {
+		return
+	}
+	gradeHoneypot(a.result, a.pending.NodeType, true)
+}
+
+func scoreProviderLatency(v *Verifier, a *answer) {
+	v.checkProviderLatency(a.result)
+}
@@ -0,0 +1,326 @@
+package verification
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/signing"
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// An answer from test-node to a pending block-hash challenge, ready for a
+// single check to run on
+func testAnswer(v *Verifier, answer string, responseTimeMs uint64) *answer {
+	now := time.Now().UnixMilli()
+	pending := &pendingChallenge{
+		Challenge: &types.Challenge{
+			ID:            "test-challenge",
+			NodeID:        "test-node",
+			ChallengeType: types.BlockHash,
+			CreatedAt:     now - 1000,
+			ExpiresAt:     now + 60000,
+		},
+		ExpectedAnswer: "0xabc",
+		NodeType:       types.BscFull,
+	}
+	v.mu.Lock()
+	v.pendingChallenges[pending.Challenge.ID] = pending
+	v.mu.Unlock()
+
+	a := v.newAnswer(&types.ChallengeResponse{
+		ChallengeID:    pending.Challenge.ID,
+		NodeID:         "test-node",
+		Answer:         answer,
+		ResponseTimeMs: responseTimeMs,
+	}, now)
+	a.pending = pending
+	return a
+}
+
+func TestCheckExists(t *testing.T) {
+	v := NewVerifier("https://bsc-dataseed1.binance.org")
+
+	a := testAnswer(v, "0xabc", 50)
+	if !checkExists(v, a) {
+		t.Errorf("pending challenge should be found: %s", a.result.FailureReason)
+	}
+
+	a.response.ChallengeID = "nonexistent"
+	if checkExists(v, a) || a.result.FailureKind != types.FailureExpired || a.result.Replay != nil {
+		t.Errorf("unknown challenge should fail as expired without a replay, got %+v", a.result)
+	}
+}
+
+func TestCheckExpiry(t *testing.T) {
+	v := NewVerifier("https://bsc-dataseed1.binance.org")
+
+	a := testAnswer(v, "0xabc", 50)
+	if !checkExpiry(v, a) {
+		t.Error("live challenge shouldn't count as expired")
+	}
+
+	a.now = a.pending.Challenge.ExpiresAt + 1
+	if checkExpiry(v, a) || a.result.FailureReason != "challenge expired" || a.result.Replay == nil {
+		t.Errorf("late answer should fail with a replay, got %+v", a.result)
+	}
+}
+
+func TestCheckBinding(t *testing.T) {
+	v := NewVerifier("https://bsc-dataseed1.binance.org")
+
+	a := testAnswer(v, "0xabc", 50)
+	if !checkBinding(v, a) {
+		t.Errorf("answer from the challenged node should pass: %s", a.result.FailureReason)
+	}
+
+	// Another node answering it is treated as not found, and the
+	// challenge isn't used up
+	a = testAnswer(v, "0xabc", 50)
+	a.response.NodeID = "other-node"
+	if checkBinding(v, a) || a.pending != nil || a.result.FailureKind != types.FailureExpired {
+		t.Errorf("answer from another node should fail as not found, got %+v", a.result)
+	}
+
+	// A requested challenge has to be answered by the wallet that asked
+	a = testAnswer(v, "0xabc", 50)
+	a.pending.RequestedBy = "0xwallet"
+	a.response.Wallet = "0xWALLET"
+	if !checkBinding(v, a) {
+		t.Errorf("requester's own answer should pass: %s", a.result.FailureReason)
+	}
+	a = testAnswer(v, "0xabc", 50)
+	a.pending.RequestedBy = "0xwallet"
+	a.response.Wallet = "0xother"
+	if checkBinding(v, a) || a.pending != nil {
+		t.Errorf("answer signed by another wallet should fail as not found, got %+v", a.result)
+	}
+
+	// A v2 signature names the challenge type it was for
+	a = testAnswer(v, "0xabc", 50)
+	a.response.ChallengeType = types.BlockHash
+	if !checkBinding(v, a) {
+		t.Errorf("answer signed for the right type should pass: %s", a.result.FailureReason)
+	}
+	a = testAnswer(v, "0xabc", 50)
+	a.response.ChallengeType = types.StateBalance
+	if checkBinding(v, a) || a.result.FailureKind != types.FailureWrongAnswer {
+		t.Errorf("answer signed for another type should fail, got %+v", a.result)
+	}
+
+	// Commit-reveal: the reveal has to match, and the commit time is the latency
+	tests := []struct {
+		name       string
+		commitment string
+		pass       bool
+		kind       types.FailureKind
+	}{
+		{"matching reveal", signing.Commitment("0xabc", "n1"), true, ""},
+		{"never committed", "", false, types.FailureTooSlow},
+		{"different answer committed", signing.Commitment("0xdef", "n1"), false, types.FailureWrongAnswer},
+	}
+	for _, tt := range tests {
+		a := testAnswer(v, "0xabc", 3000)
+		a.response.Nonce = "n1"
+		a.pending.Challenge.CommitBy = a.pending.Challenge.CreatedAt + CommitWindow.Milliseconds()
+		a.commitment = tt.commitment
+		a.committedAt = a.pending.Challenge.CreatedAt + 120
+
+		if pass := checkBinding(v, a); pass != tt.pass || a.result.FailureKind != tt.kind {
+			t.Errorf("%s: pass = %v (%s), want %v (%s)", tt.name, pass, a.result.FailureKind, tt.pass, tt.kind)
+		}
+		if tt.pass && (a.response.ResponseTimeMs != 120 || a.result.ResponseTimeMs != 120) {
+			t.Errorf("%s: latency should be the commit time, got %dms", tt.name, a.response.ResponseTimeMs)
+		}
+	}
+}
+
+func TestCheckAnswer(t *testing.T) {
+	v := NewVerifier("https://bsc-dataseed1.binance.org")
+
+	if a := testAnswer(v, " 0xABC ", 50); !checkAnswer(v, a) {
+		t.Error("answers should compare case- and space-insensitively")
+	}
+	a := testAnswer(v, "0xdef", 50)
+	if checkAnswer(v, a) || a.result.FailureKind != types.FailureWrongAnswer || a.result.Replay.SubmittedAnswer != "0xdef" {
+		t.Errorf("wrong answer should fail with a replay, got %+v", a.result)
+	}
+}
+
+func TestCheckLatency(t *testing.T) {
+	v := NewVerifier("https://bsc-dataseed1.binance.org")
+
+	if a := testAnswer(v, "0xabc", types.LatencyMaxAllowed); !checkLatency(v, a) {
+		t.Error("answer right at the limit should pass")
+	}
+	a := testAnswer(v, "0xabc", types.LatencyMaxAllowed+1)
+	if checkLatency(v, a) || a.result.FailureKind != types.FailureTooSlow || !a.result.Suspicious {
+		t.Errorf("answer over the limit should fail as suspicious, got %+v", a.result)
+	}
+}
+
+func TestScoreAnomaly(t *testing.T) {
+	v := NewVerifier("https://bsc-dataseed1.binance.org")
+
+	a := testAnswer(v, "0xabc", types.LatencySuspiciousMin+1)
+	scoreAnomaly(v, a)
+	if !a.result.Passed || !a.result.Suspicious {
+		t.Errorf("slow pass should stay passed but be suspicious, got %+v", a.result)
+	}
+
+	a = testAnswer(v, "0xabc", types.LatencySuspiciousMin+1)
+	a.fail("incorrect answer", types.FailureWrongAnswer)
+	scoreAnomaly(v, a)
+	if a.result.Suspicious {
+		t.Error("failed answers aren't scored on latency")
+	}
+
+	v.Flags().Set(FlagLatencyRule, 0)
+	a = testAnswer(v, "0xabc", types.LatencySuspiciousMin+1)
+	scoreAnomaly(v, a)
+	if a.result.Suspicious {
+		t.Error("latency rule should be off with its flag")
+	}
+}
+
+func TestExternalAnomalyScorer(t *testing.T) {
+	score, calls := 0.95, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var input AnomalyInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.NodeID != "test-node" {
+			t.Errorf("unexpected input %+v: %v", input, err)
+		}
+		if score < 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"score": score})
+	}))
+	defer server.Close()
+
+	v := NewVerifier("https://bsc-dataseed1.binance.org")
+	scorer := NewHTTPScorer(server.URL, time.Second, 0.9)
+	v.SetAnomalyScorer(scorer)
+
+	// Not rolled out yet, so the rules score it
+	a := testAnswer(v, "0xabc", 50)
+	scoreAnomaly(v, a)
+	if calls != 0 || a.result.Suspicious {
+		t.Fatalf("expected the rules alone behind the flag, got %d calls", calls)
+	}
+
+	v.Flags().Set(FlagExternalScorer, 100)
+	a = testAnswer(v, "0xabc", 50)
+	scoreAnomaly(v, a)
+	if calls != 1 || !a.result.Passed || !a.result.Suspicious || a.result.SuspiciousNote == "" {
+		t.Errorf("expected the model's high score to mark a fast pass suspicious, got %+v", a.result)
+	}
+
+	// The model overrules the latency rule
+	score = 0.1
+	a = testAnswer(v, "0xabc", types.LatencySuspiciousMin+1)
+	scoreAnomaly(v, a)
+	if a.result.Suspicious {
+		t.Error("expected a low score to leave a slow answer alone")
+	}
+
+	// The service failing falls back to the rules, then isn't called
+	// again until the backoff is up
+	score = -1
+	a = testAnswer(v, "0xabc", types.LatencySuspiciousMin+1)
+	scoreAnomaly(v, a)
+	if !a.result.Suspicious {
+		t.Error("expected the rules to score the answer while the service is down")
+	}
+	calls = 0
+	if _, err := scorer.Score(AnomalyInput{NodeID: "test-node"}); err != ErrScorerUnavailable || calls != 0 {
+		t.Errorf("expected the service left alone while backing off, got %v after %d calls", err, calls)
+	}
+}
+
+func TestScoreHoneypot(t *testing.T) {
+	v := NewVerifier("https://bsc-dataseed1.binance.org")
+
+	// A full node answering archive-only state is proxying
+	a := testAnswer(v, "0xabc", 50)
+	a.pending.Honeypot = true
+	scoreHoneypot(v, a)
+	if !a.result.Suspicious {
+		t.Error("full node answering a honeypot should be suspicious")
+	}
+
+	// Expired answers aren't graded
+	a = testAnswer(v, "0xabc", 50)
+	a.pending.Honeypot = true
+	a.fail("challenge expired", types.FailureExpired)
+	scoreHoneypot(v, a)
+	if a.result.Suspicious {
+		t.Error("expired honeypot answers shouldn't be graded")
+	}
+
+	// Normal challenges are left alone
+	a = testAnswer(v, "0xabc", 50)
+	scoreHoneypot(v, a)
+	if a.result.Suspicious {
+		t.Error("normal challenge shouldn't be graded as a honeypot")
+	}
+}
+
+func TestVerifyResponseKeepsOtherNodesChallenge(t *testing.T) {
+	v := NewVerifier("https://bsc-dataseed1.binance.org")
+	testAnswer(v, "0xabc", 50)
+
+	result := v.VerifyResponse(&types.ChallengeResponse{ChallengeID: "test-challenge", NodeID: "other-node", Answer: "0xabc"})
+	if result.Passed || result.Replay != nil {
+		t.Errorf("answer from another node should fail without a replay, got %+v", result)
+	}
+
+	result = v.VerifyResponse(&types.ChallengeResponse{ChallengeID: "test-challenge", NodeID: "test-node", Answer: "0xabc", ResponseTimeMs: 50})
+	if !result.Passed {
+		t.Errorf("challenged node should still be able to answer, got %s", result.FailureReason)
+	}
+}
+
+func TestCheckAnswerComposite(t *testing.T) {
+	v := NewVerifier("https://bsc-dataseed1.binance.org")
+
+	tests := []struct {
+		name    string
+		answer  string
+		passed  []bool
+		failure string
+	}{
+		{"unanswered part", `["0xabc","0x10",""]`, []bool{true, true, false}, "1 of 3 parts incorrect"},
+		{"every part", `["0xabc","0x10","{\"synced\":true}"]`, []bool{true, true, true}, ""},
+		{"one wrong", `["0xdef","0x010","{\"synced\":true}"]`, []bool{false, true, true}, "1 of 3 parts incorrect"},
+		{"malformed", `0xabc`, []bool{false, false, false}, "3 of 3 parts incorrect"},
+		{"too few", `["0xabc"]`, []bool{false, false, false}, "3 of 3 parts incorrect"},
+	}
+
+	for _, tt := range tests {
+		a := testAnswer(v, tt.answer, 50)
+		a.pending.Challenge.ChallengeType = types.Composite
+		a.pending.Challenge.Params.Parts = []types.ChallengePart{
+			{ChallengeType: types.BlockHash},
+			{ChallengeType: types.StateBalance},
+			{ChallengeType: types.SyncStatus},
+		}
+		a.pending.ExpectedAnswer = `["0xabc","0x10","{\"synced\":true}"]`
+
+		pass := checkAnswer(v, a)
+		if pass != (tt.failure == "") || a.result.FailureReason != tt.failure {
+			t.Errorf("%s: pass = %v (%q), want failure %q", tt.name, pass, a.result.FailureReason, tt.failure)
+		}
+		if len(a.result.Parts) != 3 {
+			t.Fatalf("%s: expected 3 part results, got %d", tt.name, len(a.result.Parts))
+		}
+		for i, part := range a.result.Parts {
+			if part.Passed != tt.passed[i] {
+				t.Errorf("%s: part %d passed = %v, want %v", tt.name, i+1, part.Passed, tt.passed[i])
+			}
+		}
+	}
+}
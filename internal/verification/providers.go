@@ -0,0 +1,219 @@
+package verification
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/rpc"
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// A node that forwards challenges to a public RPC inherits that provider's
+// jitter: when the provider is slow, so is the node. We probe the big
+// public BSC RPCs ourselves and look for nodes whose answer latency moves
+// in step with one of them.
+const (
+	providerWindow     = 6 * time.Hour
+	providerMaxGapMs   = 30000 // Pair a node answer with a probe at most this far away
+	providerMinPairs   = 20    // Don't judge on fewer paired samples than this
+	providerCheckEvery = 20    // Re-check a node after this many new answers
+	providerMinCorr    = 0.8   // Correlation that counts as tracking a provider
+)
+
+type latencySample struct {
+	at        int64
+	latencyMs float64
+}
+
+type providerTracker struct {
+	clients map[string]*rpc.Client
+	probes  map[string][]latencySample // provider -> probes, oldest first
+	nodes   map[string][]latencySample // nodeID -> answer latencies, oldest first
+	unseen  map[string]int             // nodeID -> answers since last check
+	mu      sync.Mutex
+}
+
+func newProviderTracker() *providerTracker {
+	return &providerTracker{
+		clients: make(map[string]*rpc.Client),
+		probes:  make(map[string][]latencySample),
+		nodes:   make(map[string][]latencySample),
+		unseen:  make(map[string]int),
+	}
+}
+
+// A node whose latency tracks a public provider
+type ProviderMatch struct {
+	Provider    string  `json:"provider"`
+	Correlation float64 `json:"correlation"`
+	Samples     int     `json:"samples"`
+}
+
+// Public RPCs to compare node latency against. Providers are named by host.
+func (v *Verifier) SetPublicProviders(endpoints []string) {
+	p := v.providers
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.clients = make(map[string]*rpc.Client, len(endpoints))
+	for _, endpoint := range endpoints {
+		p.clients[providerName(endpoint)] = rpc.NewClient(endpoint, "")
+	}
+	for name := range p.probes {
+		if _, ok := p.clients[name]; !ok {
+			delete(p.probes, name)
+		}
+	}
+}
+
+func providerName(endpoint string) string {
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return endpoint
+}
+
+// Measure every public provider once. Call this periodically.
+func (v *Verifier) ProbeProviders() {
+	p := v.providers
+
+	p.mu.Lock()
+	clients := make(map[string]*rpc.Client, len(p.clients))
+	for name, client := range p.clients {
+		clients[name] = client
+	}
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for name, client := range clients {
+		wg.Add(1)
+		go func(name string, client *rpc.Client) {
+			defer wg.Done()
+			if _, latency, err := client.GetBlockNumber(); err == nil {
+				p.recordProbe(name, v.clock.Now().UnixMilli(), latency)
+			}
+		}(name, client)
+	}
+	wg.Wait()
+}
+
+func (p *providerTracker) recordProbe(name string, at int64, latencyMs uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.probes[name] = trimSamples(append(p.probes[name], latencySample{at, float64(latencyMs)}), at)
+}
+
+// Add a node's answer latency. Every providerCheckEvery answers, returns
+// the provider the node tracks most closely, if any.
+func (p *providerTracker) recordNode(nodeID string, at int64, latencyMs uint64) (ProviderMatch, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.nodes[nodeID] = trimSamples(append(p.nodes[nodeID], latencySample{at, float64(latencyMs)}), at)
+	p.unseen[nodeID]++
+	if p.unseen[nodeID] < providerCheckEvery {
+		return ProviderMatch{}, false
+	}
+	p.unseen[nodeID] = 0
+
+	var best ProviderMatch
+	for name, probes := range p.probes {
+		node, provider := pairSamples(p.nodes[nodeID], probes)
+		if len(node) < providerMinPairs {
+			continue
+		}
+		if r := correlation(node, provider); r >= providerMinCorr && r > best.Correlation {
+			best = ProviderMatch{Provider: name, Correlation: r, Samples: len(node)}
+		}
+	}
+	return best, best.Provider != ""
+}
+
+// Drop samples older than the window
+func trimSamples(samples []latencySample, now int64) []latencySample {
+	cutoff := now - providerWindow.Milliseconds()
+	i := sort.Search(len(samples), func(i int) bool { return samples[i].at >= cutoff })
+	return samples[i:]
+}
+
+// Line each node sample up with the provider probe closest in time
+func pairSamples(node, probes []latencySample) ([]float64, []float64) {
+	var xs, ys []float64
+	for _, s := range node {
+		i := sort.Search(len(probes), func(i int) bool { return probes[i].at >= s.at })
+
+		nearest := -1
+		for _, j := range []int{i - 1, i} {
+			if j < 0 || j >= len(probes) {
+				continue
+			}
+			if nearest == -1 || abs64(probes[j].at-s.at) < abs64(probes[nearest].at-s.at) {
+				nearest = j
+			}
+		}
+		if nearest == -1 || abs64(probes[nearest].at-s.at) > providerMaxGapMs {
+			continue
+		}
+
+		xs = append(xs, s.latencyMs)
+		ys = append(ys, probes[nearest].latencyMs)
+	}
+	return xs, ys
+}
+
+// Pearson correlation. 0 if either series is flat - no jitter, nothing to track.
+func correlation(xs, ys []float64) float64 {
+	n := float64(len(xs))
+	if n == 0 {
+		return 0
+	}
+
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= n
+	meanY /= n
+
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varX*varY)
+}
+
+func abs64(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// Mark a passing answer suspicious if the node's latency has been
+// tracking a public provider
+func (v *Verifier) checkProviderLatency(result *types.VerificationResult) {
+	if !result.Passed || !v.flags.EnabledFor(FlagProviderLatency, result.NodeID) {
+		return
+	}
+
+	match, ok := v.providers.recordNode(result.NodeID, result.Timestamp, result.ResponseTimeMs)
+	if !ok || result.Suspicious {
+		return
+	}
+	result.Suspicious = true
+	result.SuspiciousNote = fmt.Sprintf("Latency tracks public RPC %s (r=%.2f over %d answers) - likely proxying",
+		match.Provider, match.Correlation, match.Samples)
+}
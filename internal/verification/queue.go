@@ -0,0 +1,36 @@
+package verification
+
+import (
+	"time"
+
+	"github.com/depinonbnb/depin/internal/scheduler"
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// Who goes first when challenge work has to queue
+const (
+	trustedMinScore = 70                  // Trust score for the high class...
+	trustedMinAge   = 30 * 24 * time.Hour // ...held by a node at least this old
+	newNodeAge      = 7 * 24 * time.Hour  // Younger nodes go in the low class
+)
+
+// Issuing challenges and checking answers share this queue. Unbounded
+// until SetSlots is called on it.
+func (v *Verifier) Queue() *scheduler.Queue {
+	return v.queue
+}
+
+// Where a node's challenge work goes in the queue: flagged and brand-new
+// nodes last, long-standing trusted nodes first, and within a class by
+// trust score
+func WorkPriority(node *types.NodeRegistration, now int64) (scheduler.Class, int) {
+	age := time.Duration(now-node.RegisteredAt) * time.Millisecond
+	class := scheduler.Normal
+	switch {
+	case node.CheatStatus == types.StatusFlagged || node.CheatStatus == types.StatusBanned || age < newNodeAge:
+		class = scheduler.Low
+	case node.Trust.Score >= trustedMinScore && age >= trustedMinAge:
+		class = scheduler.High
+	}
+	return class, int(node.Trust.Score)
+}
@@ -0,0 +1,39 @@
+package verification
+
+import (
+	"time"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// A prover whose submit timed out on the way back will send it again, but
+// the challenge is gone by then. For this long after a verdict, a repeat
+// from the same node gets the same verdict instead of "not found".
+const RetryWindow = 2 * time.Minute
+
+type answeredChallenge struct {
+	result *types.VerificationResult
+	at     int64
+}
+
+// Keep a verdict around for retries
+func (v *Verifier) rememberAnswer(result *types.VerificationResult) {
+	v.mu.Lock()
+	v.answered[result.ChallengeID] = &answeredChallenge{result: result, at: result.Timestamp}
+	v.mu.Unlock()
+}
+
+// The verdict already given for this answer, marked as a retry, or nil if
+// there isn't one. Only the node the challenge was for gets it back.
+func (v *Verifier) retriedAnswer(response *types.ChallengeResponse, now int64) *types.VerificationResult {
+	v.mu.RLock()
+	answered, ok := v.answered[response.ChallengeID]
+	v.mu.RUnlock()
+
+	if !ok || answered.result.NodeID != response.NodeID || now-answered.at > RetryWindow.Milliseconds() {
+		return nil
+	}
+	result := *answered.result
+	result.Retry = true
+	return &result
+}
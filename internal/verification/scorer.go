@@ -0,0 +1,132 @@
+package verification
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// What an anomaly scorer sees of a verified answer: the same features the
+// admin feature export has, so a model trained on the export can score
+// answers as they come in.
+type AnomalyInput struct {
+	NodeID         string              `json:"node_id"`
+	ChallengeType  types.ChallengeType `json:"challenge_type"`
+	ResponseTimeMs uint64              `json:"response_time_ms"`
+	HourOfDay      int                 `json:"hour_of_day"` // UTC
+	BlockAge       *uint64             `json:"block_age"`   // Nil if not about a block
+	Surprise       bool                `json:"surprise"`
+	Passed         bool                `json:"passed"`
+	FailureKind    types.FailureKind   `json:"failure_kind"`
+}
+
+// How unusual an answer looks. Suspicious answers count towards the
+// node's warning and flag thresholds like any other suspicious result.
+type AnomalyVerdict struct {
+	Score      float64 // 0 = normal, 1 = certainly anomalous
+	Suspicious bool
+	Note       string
+}
+
+// Scores answers for anomalies. The verifier uses RuleScorer unless given
+// another with SetAnomalyScorer; if that one fails, RuleScorer scores the
+// answer instead, so a scorer going down never stops verification.
+type AnomalyScorer interface {
+	Score(input AnomalyInput) (AnomalyVerdict, error)
+}
+
+// The built-in rules: a passing answer slower than SuspiciousMs looks
+// like a node proxying to a public RPC
+type RuleScorer struct {
+	SuspiciousMs uint64
+}
+
+func (r RuleScorer) Score(input AnomalyInput) (AnomalyVerdict, error) {
+	if !input.Passed || input.ResponseTimeMs <= r.SuspiciousMs {
+		return AnomalyVerdict{}, nil
+	}
+	return AnomalyVerdict{
+		Score:      1,
+		Suspicious: true,
+		Note:       fmt.Sprintf("High latency %dms - might be proxying to public RPC", input.ResponseTimeMs),
+	}, nil
+}
+
+// After a failed call, how long HTTPScorer leaves the service alone. Every
+// answer would otherwise wait out the timeout while it's down.
+const scorerBackoff = 30 * time.Second
+
+// The scoring service is backing off after a failure
+var ErrScorerUnavailable = errors.New("anomaly scoring service unavailable")
+
+// Scores answers with an external service, e.g. a model trained on the
+// feature export. Each answer is POSTed as JSON (AnomalyInput) and the
+// service replies {"score": 0.97, "note": "..."}; answers scoring at or
+// above the threshold are suspicious.
+type HTTPScorer struct {
+	url       string
+	threshold float64
+	client    *http.Client
+
+	mu        sync.Mutex
+	downUntil time.Time
+}
+
+func NewHTTPScorer(url string, timeout time.Duration, threshold float64) *HTTPScorer {
+	return &HTTPScorer{url: url, threshold: threshold, client: &http.Client{Timeout: timeout}}
+}
+
+func (h *HTTPScorer) Score(input AnomalyInput) (AnomalyVerdict, error) {
+	h.mu.Lock()
+	down := time.Now().Before(h.downUntil)
+	h.mu.Unlock()
+	if down {
+		return AnomalyVerdict{}, ErrScorerUnavailable
+	}
+
+	verdict, err := h.call(input)
+	if err != nil {
+		h.mu.Lock()
+		h.downUntil = time.Now().Add(scorerBackoff)
+		h.mu.Unlock()
+	}
+	return verdict, err
+}
+
+func (h *HTTPScorer) call(input AnomalyInput) (AnomalyVerdict, error) {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return AnomalyVerdict{}, err
+	}
+	resp, err := h.client.Post(h.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return AnomalyVerdict{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return AnomalyVerdict{}, fmt.Errorf("anomaly scoring service returned %s", resp.Status)
+	}
+
+	var reply struct {
+		Score *float64 `json:"score"`
+		Note  string   `json:"note"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return AnomalyVerdict{}, fmt.Errorf("bad anomaly score: %v", err)
+	}
+	if reply.Score == nil || *reply.Score < 0 || *reply.Score > 1 {
+		return AnomalyVerdict{}, errors.New("anomaly score missing or outside 0-1")
+	}
+
+	verdict := AnomalyVerdict{Score: *reply.Score, Suspicious: *reply.Score >= h.threshold, Note: reply.Note}
+	if verdict.Suspicious && verdict.Note == "" {
+		verdict.Note = fmt.Sprintf("Anomaly score %.2f from the scoring model", verdict.Score)
+	}
+	return verdict, nil
+}
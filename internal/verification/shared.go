@@ -0,0 +1,111 @@
+package verification
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/sharedcache"
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// Pending challenges can also be kept in a cache every server process
+// shares (SetSharedCache), so an answer can reach any of them, not just the
+// one that issued its challenge. Each process still keeps the challenges
+// it knows in memory. The cache is where the others find them, and where
+// whichever process verifies an answer claims its challenge, so no two
+// verify the same one. When the cache fails, a process carries on with
+// what it has in memory.
+
+const pendingKeyPrefix = "pending:"
+
+// Kept in the cache a little past expiry, so a late answer still finds the
+// challenge and fails as expired rather than as unknown
+const sharedPendingGrace = time.Minute
+
+// Share pending challenges through cache. Set before the verifier is in
+// use.
+func (v *Verifier) SetSharedCache(cache sharedcache.Cache) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.shared = cache
+}
+
+// Publish a pending challenge, or its latest state, for the other
+// processes
+func (v *Verifier) sharePending(id string) {
+	v.mu.RLock()
+	cache, pending := v.shared, v.pendingChallenges[id]
+	if cache == nil || pending == nil {
+		v.mu.RUnlock()
+		return
+	}
+	data, err := json.Marshal(pending)
+	ttl := time.Duration(pending.Challenge.ExpiresAt-v.clock.Now().UnixMilli())*time.Millisecond + sharedPendingGrace
+	v.mu.RUnlock()
+
+	if err == nil {
+		err = cache.Set(pendingKeyPrefix+id, data, ttl)
+	}
+	if err != nil {
+		log.Printf("sharing challenge %s failed, only this server can verify it: %v", id, err)
+	}
+}
+
+// Fetch a challenge another process issued, if this one doesn't have it
+func (v *Verifier) adoptPending(id string) {
+	v.mu.RLock()
+	cache := v.shared
+	_, known := v.pendingChallenges[id]
+	v.mu.RUnlock()
+	if cache == nil || known {
+		return
+	}
+
+	data, ok, err := cache.Get(pendingKeyPrefix + id)
+	if err != nil {
+		log.Printf("looking up challenge %s in the shared cache failed: %v", id, err)
+		return
+	}
+	var pending pendingChallenge
+	if !ok || json.Unmarshal(data, &pending) != nil || pending.Challenge == nil || pending.Challenge.ID != id {
+		return
+	}
+	v.mu.Lock()
+	if _, exists := v.pendingChallenges[id]; !exists {
+		v.pendingChallenges[id] = &pending
+	}
+	v.mu.Unlock()
+}
+
+// Claim a challenge for verifying. False if another process already did.
+// A cache that fails can't settle it, so the claim goes through.
+func (v *Verifier) claimPending(id string) bool {
+	v.mu.RLock()
+	cache := v.shared
+	v.mu.RUnlock()
+	if cache == nil {
+		return true
+	}
+
+	_, ok, err := cache.Take(pendingKeyPrefix + id)
+	if err != nil {
+		log.Printf("claiming challenge %s in the shared cache failed, verifying it here: %v", id, err)
+		return true
+	}
+	return ok
+}
+
+// Another process verified the challenge first. Forget it here too. A
+// held answer's challenge was claimed when it was held.
+func checkClaim(v *Verifier, a *answer) bool {
+	v.mu.RLock()
+	_, held := v.held[a.response.ChallengeID]
+	v.mu.RUnlock()
+	if held || v.claimPending(a.response.ChallengeID) {
+		return true
+	}
+	v.deleteChallenge(a.response.ChallengeID)
+	a.pending = nil
+	return a.fail("challenge not found or expired", types.FailureExpired)
+}
@@ -0,0 +1,177 @@
+package verification
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// Check that an exposed-rpc node stores as much data as its type needs.
+// Only archive claims are judged - pruned node sizes vary too much - but
+// every node gets the indicators reported.
+func (v *Verifier) CheckStorage(node *types.NodeRegistration) *types.StorageReport {
+	nodeRPC := v.rpcFor(node)
+	report := &types.StorageReport{
+		NodeID:    node.ID,
+		CheckedAt: v.clock.Now().UnixMilli(),
+	}
+
+	// Storage providers prove what they hold with object challenges
+	if node.NodeType.Chain() == types.ChainGreenfield {
+		report.Verdict = types.StorageOK
+		report.Note = "storage providers are checked with object challenges"
+		return report
+	}
+
+	// Database size, if the node exposes the debug namespace. LevelDB
+	// nodes answer "leveldb.stats", Pebble nodes answer "".
+	for _, property := range []string{"leveldb.stats", ""} {
+		if stats, _, err := nodeRPC.GetChaindbProperty(property); err == nil {
+			if gb, ok := parseChaindbSize(stats); ok {
+				report.ChainDataGB = &gb
+				break
+			}
+		}
+	}
+
+	// Old state only an archive node keeps - the same query honeypots use
+	ch := v.generator.GenerateHoneypot(node.ID, node.NodeType)
+	if expected := v.trustedFor(node.NodeType).ExecuteChallenge(ch); expected.Success {
+		answer := nodeRPC.ExecuteChallenge(ch)
+		deep := answer.Success && v.compareAnswers(answer.Data, expected.Data, ch.ChallengeType)
+		report.DeepState = &deep
+	}
+
+	report.Verdict, report.Note = storageVerdict(node.NodeType, report)
+	return report
+}
+
+func storageVerdict(nodeType types.NodeType, r *types.StorageReport) (types.StorageVerdict, string) {
+	if nodeType != types.BscArchive {
+		return types.StorageOK, ""
+	}
+
+	if r.ChainDataGB != nil && *r.ChainDataGB < float64(nodeType.MinDiskGB()) {
+		return types.StorageTooSmall, fmt.Sprintf("Archive node database is %.0fGB, archive data needs over %dGB", *r.ChainDataGB, nodeType.MinDiskGB())
+	}
+	if r.DeepState != nil && !*r.DeepState {
+		return types.StorageNoDeepState, "Archive node couldn't serve old state"
+	}
+	if r.ChainDataGB == nil && r.DeepState == nil {
+		return types.StorageUnknown, "Node doesn't expose database stats and old state couldn't be checked"
+	}
+	return types.StorageOK, ""
+}
+
+// Types on each chain from smallest to largest. Archive is only proposed
+// when a node serves deep state, never from database size alone.
+var typeLadder = map[types.Chain][]types.NodeType{
+	types.ChainBSC:   {types.BscFast, types.BscFull, types.BscArchive},
+	types.ChainOpBNB: {types.OpbnbFast, types.OpbnbFull},
+}
+
+// The type a storage report says a node really is, if that's not the type
+// it registered as. A pruned node serving deep state is an archive node; a
+// database too big or too small for the claimed type puts it on the rung
+// whose minimum disk it fits.
+func ProposeNodeType(current types.NodeType, r *types.StorageReport) (types.NodeType, string, bool) {
+	ladder := typeLadder[current.Chain()]
+	if len(ladder) == 0 {
+		return "", "", false
+	}
+
+	if r.DeepState != nil && *r.DeepState && holdsDeepState(ladder[len(ladder)-1]) && !holdsDeepState(current) {
+		return ladder[len(ladder)-1], "serves deep historical state", true
+	}
+	if r.ChainDataGB == nil {
+		return "", "", false
+	}
+	if holdsDeepState(current) && (r.DeepState == nil || *r.DeepState) {
+		return "", "", false // Still serves archive state, whatever the size
+	}
+
+	fits := ladder[0]
+	for _, nodeType := range ladder {
+		if !holdsDeepState(nodeType) && *r.ChainDataGB >= float64(nodeType.MinDiskGB()) {
+			fits = nodeType
+		}
+	}
+	if fits == current {
+		return "", "", false
+	}
+	return fits, fmt.Sprintf("database is %.0fGB", *r.ChainDataGB), true
+}
+
+var sizeWithUnit = regexp.MustCompile(`^([0-9.]+)\s*([KMGTP]?i?B)$`)
+
+// Pull the total database size (in GB) out of debug_chaindbProperty
+// output. Handles LevelDB's compaction table (a Size(MB) column per level)
+// and Pebble's metrics (a "total" row with a size like "1.2TB").
+func parseChaindbSize(stats string) (float64, bool) {
+	lines := strings.Split(stats, "\n")
+
+	// LevelDB: sum the Size(MB) column
+	sizeCol := -1
+	var totalMB float64
+	found := false
+	for _, line := range lines {
+		cols := strings.Split(line, "|")
+		if sizeCol == -1 {
+			for i, col := range cols {
+				if strings.TrimSpace(col) == "Size(MB)" {
+					sizeCol = i
+				}
+			}
+			continue
+		}
+		if len(cols) <= sizeCol {
+			continue
+		}
+		if mb, err := strconv.ParseFloat(strings.TrimSpace(cols[sizeCol]), 64); err == nil {
+			totalMB += mb
+			found = true
+		}
+	}
+	if found {
+		return totalMB / 1000, true
+	}
+
+	// Pebble: first size on the "total" row
+	for _, line := range lines {
+		cols := strings.Split(line, "|")
+		if len(cols) < 2 || strings.TrimSpace(cols[0]) != "total" {
+			continue
+		}
+		for _, field := range strings.Fields(cols[1]) {
+			if bytes, ok := parseSize(field); ok {
+				return bytes / 1e9, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+func parseSize(s string) (float64, bool) {
+	m := sizeWithUnit.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, false
+	}
+
+	units := map[string]float64{
+		"B": 1, "KB": 1e3, "MB": 1e6, "GB": 1e9, "TB": 1e12, "PB": 1e15,
+		"KiB": 1 << 10, "MiB": 1 << 20, "GiB": 1 << 30, "TiB": 1 << 40, "PiB": 1 << 50,
+	}
+	unit, ok := units[m[2]]
+	if !ok {
+		return 0, false
+	}
+	return n * unit, true
+}
@@ -0,0 +1,65 @@
+package verification
+
+import (
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/push"
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// A prover that only wakes up on its own schedule (say, one that spins up
+// a borrowed node just before each poll) can't answer a challenge pushed
+// at a random moment in between.
+const (
+	SurpriseExpiry = 15 * time.Second
+
+	// Each round, an eligible node gets a surprise with 1-in-this chance
+	surpriseOneIn = 30
+)
+
+// Open challenge streams to local provers
+func (v *Verifier) Push() *push.Hub {
+	return v.push
+}
+
+// Push a surprise challenge to some of these nodes. Only local-prover
+// nodes with the flag on, an established poll cadence, no surprise
+// already outstanding, and that are roughly halfway between polls are
+// eligible. Call this periodically; returns what was sent.
+func (v *Verifier) IssueSurprises(nodes []*types.NodeRegistration, now int64) []*types.Challenge {
+	var issued []*types.Challenge
+	for _, node := range nodes {
+		if !surpriseDue(node, now) || !v.flags.EnabledFor(FlagSurprise, node.ID) {
+			continue
+		}
+		if rand.Intn(surpriseOneIn) != 0 {
+			continue
+		}
+
+		ch, err := v.createChallenge(node, true, "")
+		if err != nil {
+			log.Printf("surprise challenge for %s: %v", node.ID, err)
+			continue
+		}
+
+		// Not connected counts too - the node has to be reachable between polls
+		v.push.Publish(ch)
+		issued = append(issued, ch)
+	}
+	return issued
+}
+
+func surpriseDue(node *types.NodeRegistration, now int64) bool {
+	if node.VerificationMethod != types.LocalProver || !node.IsActive || node.Paused {
+		return false
+	}
+	if node.PollIntervalMs == 0 || node.Surprise.PendingID != "" {
+		return false
+	}
+
+	// Well clear of both the last poll and the next one
+	since := uint64(now - node.LastPolledAt)
+	return since >= node.PollIntervalMs/4 && since <= node.PollIntervalMs*3/4
+}
@@ -0,0 +1,140 @@
+package verification
+
+import (
+	"sync"
+	"time"
+
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+// How far back challenge blocks were, in blocks behind the trusted head
+var blockAgeBuckets = []struct {
+	label string
+	max   uint64
+}{
+	{"0-1k", 1000},
+	{"1k-100k", 100000},
+	{"100k-1m", 1000000},
+	{"1m-10m", 10000000},
+	{"10m+", ^uint64(0)},
+}
+
+const (
+	blockAgeUnknown = "unknown"
+	headCacheTTL    = 30 * time.Second
+)
+
+// Running totals of every challenge we've issued, for the public
+// transparency report. Only counts - nothing about individual nodes.
+type issuedStats struct {
+	total    uint64
+	byType   map[types.ChallengeType]uint64
+	blockAge map[string]uint64
+
+	heads map[types.Chain]cachedHead // Each chain has its own block numbers
+	mu    sync.Mutex
+}
+
+// The last head read for a chain. attemptedAt counts failed reads too,
+// so a trusted RPC that's down is asked once per headCacheTTL.
+type cachedHead struct {
+	number      uint64
+	attemptedAt time.Time
+}
+
+func newIssuedStats() *issuedStats {
+	return &issuedStats{
+		byType:   make(map[types.ChallengeType]uint64),
+		blockAge: make(map[string]uint64),
+		heads:    make(map[types.Chain]cachedHead),
+	}
+}
+
+// Snapshot of the issued challenge distribution
+type IssuedChallengeStats struct {
+	Total         uint64                         `json:"total"`
+	ByType        map[types.ChallengeType]uint64 `json:"by_type"`
+	BlockAgeRange map[string]uint64              `json:"block_age_range"`
+}
+
+// Count an issued challenge. Returns how many blocks behind the trusted
+// head its block was, nil if it isn't about a block or the head is unknown.
+func (v *Verifier) recordIssued(ch *types.Challenge, nodeType types.NodeType) *uint64 {
+	s := v.issued
+
+	s.mu.Lock()
+	s.total++
+	s.byType[ch.ChallengeType]++
+	if ch.Params.BlockNumber == nil {
+		s.mu.Unlock()
+		return nil
+	}
+
+	// Don't hit the trusted RPC for every challenge - the head only
+	// needs to be roughly right for bucketing. One caller reads it, marked
+	// before the lock is let go, and the rest use the head they have.
+	chain := nodeType.Chain()
+	head := s.heads[chain]
+	now := v.clock.Now()
+	refresh := now.Sub(head.attemptedAt) > headCacheTTL
+	if refresh {
+		head.attemptedAt = now
+		s.heads[chain] = head
+	}
+	s.mu.Unlock()
+
+	if refresh {
+		if number, _, err := v.trustedFor(nodeType).GetBlockNumber(); err == nil {
+			head.number = number
+		}
+	}
+
+	block := *ch.Params.BlockNumber
+	s.mu.Lock()
+	if refresh && s.heads[chain].attemptedAt.Equal(head.attemptedAt) {
+		s.heads[chain] = head // Unless a later read already replaced it
+	}
+	s.blockAge[blockAgeBucket(head.number, block)]++
+	s.mu.Unlock()
+
+	if head.number == 0 || block > head.number {
+		return nil
+	}
+	age := head.number - block
+	return &age
+}
+
+func blockAgeBucket(head, block uint64) string {
+	if head == 0 || block > head {
+		return blockAgeUnknown
+	}
+
+	age := head - block
+	for _, b := range blockAgeBuckets {
+		if age < b.max {
+			return b.label
+		}
+	}
+	return blockAgeBuckets[len(blockAgeBuckets)-1].label
+}
+
+// Distribution of every challenge issued since startup
+func (v *Verifier) IssuedStats() IssuedChallengeStats {
+	s := v.issued
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := IssuedChallengeStats{
+		Total:         s.total,
+		ByType:        make(map[types.ChallengeType]uint64, len(s.byType)),
+		BlockAgeRange: make(map[string]uint64, len(s.blockAge)),
+	}
+	for k, n := range s.byType {
+		stats.ByType[k] = n
+	}
+	for k, n := range s.blockAge {
+		stats.BlockAgeRange[k] = n
+	}
+	return stats
+}
@@ -0,0 +1,11 @@
+package verification
+
+import (
+	"github.com/depinonbnb/depin/internal/tunnel"
+)
+
+// Tunnels provers hold open for exposed-rpc nodes that can't take inbound
+// connections
+func (v *Verifier) Tunnels() *tunnel.Broker {
+	return v.tunnels
+}
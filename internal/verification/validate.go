@@ -0,0 +1,199 @@
+package verification
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/depinonbnb/depin/internal/normalize"
+	"github.com/depinonbnb/depin/internal/types"
+)
+
+var ErrAlreadyValidated = errors.New("challenge already validated")
+
+// How one field of a candidate answer compares to the expected one
+type DiffStatus string
+
+const (
+	DiffMatch      DiffStatus = "match"      // Identical
+	DiffFormat     DiffStatus = "format"     // Same once normalized, so it passes
+	DiffMismatch   DiffStatus = "mismatch"   // Different value
+	DiffMissing    DiffStatus = "missing"    // Expected but not in the answer
+	DiffUnexpected DiffStatus = "unexpected" // In the answer but not expected
+)
+
+type FieldDiff struct {
+	Field     string     `json:"field"` // JSON key, "part N" in a composite, "" for a plain answer
+	Status    DiffStatus `json:"status"`
+	Submitted string     `json:"submitted,omitempty"`
+	Expected  string     `json:"expected,omitempty"` // Only shown to admins
+	Note      string     `json:"note,omitempty"`
+}
+
+// A dry run of an answer against a pending challenge
+type AnswerDiff struct {
+	ChallengeID   string              `json:"challenge_id"`
+	NodeID        string              `json:"node_id"`
+	ChallengeType types.ChallengeType `json:"challenge_type"`
+	Passed        bool                `json:"passed"`     // Whether submitting it now would pass
+	Normalized    string              `json:"normalized"` // The answer as it's compared
+	Fields        []FieldDiff         `json:"fields"`
+}
+
+// Compare a candidate answer to what a pending challenge expects, without
+// using the challenge up. An operator (nodeID set) can only validate their
+// own node's challenges, once each, and isn't shown the expected values:
+// otherwise they could dry-run their way to the right answer. Admins pass
+// nodeID "" and see everything.
+func (v *Verifier) ValidateAnswer(challengeID, nodeID, answer string) (*AnswerDiff, error) {
+	v.adoptPending(challengeID)
+	v.mu.Lock()
+	pending, exists := v.pendingChallenges[challengeID]
+	if !exists || (nodeID != "" && pending.Challenge.NodeID != nodeID) {
+		v.mu.Unlock()
+		return nil, ErrChallengeNotFound
+	}
+	if nodeID != "" {
+		if pending.Validated {
+			v.mu.Unlock()
+			return nil, ErrAlreadyValidated
+		}
+		pending.Validated = true
+	}
+	ch, expected := pending.Challenge, pending.ExpectedAnswer
+	v.mu.Unlock()
+	if nodeID != "" {
+		v.sharePending(challengeID) // So the dry run is used up on every server
+	}
+
+	diff := &AnswerDiff{
+		ChallengeID:   ch.ID,
+		NodeID:        ch.NodeID,
+		ChallengeType: ch.ChallengeType,
+		Normalized:    normalize.ChallengeAnswer(ch, answer),
+	}
+	if ch.ChallengeType == types.Composite {
+		diff.Passed = partsFailure(v.gradeParts(ch, answer, expected)) == ""
+		diff.Fields = diffParts(ch, answer, expected)
+	} else {
+		diff.Passed = v.compareAnswers(answer, expected, ch.ChallengeType)
+		diff.Fields = diffAnswer(ch.ChallengeType, answer, expected)
+	}
+
+	if nodeID != "" {
+		for i := range diff.Fields {
+			diff.Fields[i].Expected = ""
+		}
+	}
+	return diff, nil
+}
+
+// Composites are diffed part by part
+func diffParts(ch *types.Challenge, submitted, expected string) []FieldDiff {
+	var got, want []string
+	json.Unmarshal([]byte(expected), &want)
+	if json.Unmarshal([]byte(submitted), &got) != nil {
+		return []FieldDiff{{Status: DiffMismatch, Submitted: submitted, Note: "answer isn't a JSON list of strings"}}
+	}
+
+	var fields []FieldDiff
+	for i, part := range ch.Params.Parts {
+		name := fmt.Sprintf("part %d", i+1)
+		if i >= len(got) {
+			fields = append(fields, FieldDiff{Field: name, Status: DiffMissing})
+			continue
+		}
+		for _, f := range diffAnswer(part.ChallengeType, got[i], at(want, i)) {
+			f.Field = strings.TrimSpace(name + " " + f.Field)
+			fields = append(fields, f)
+		}
+	}
+	for i := len(ch.Params.Parts); i < len(got); i++ {
+		fields = append(fields, FieldDiff{Field: fmt.Sprintf("part %d", i+1), Status: DiffUnexpected, Submitted: got[i]})
+	}
+	return fields
+}
+
+// JSON answers are diffed key by key, anything else as a whole
+func diffAnswer(challengeType types.ChallengeType, submitted, expected string) []FieldDiff {
+	switch challengeType {
+	case types.BlockData, types.SyncStatus, types.ObjectExists:
+	default:
+		return []FieldDiff{diffValue("", challengeType, submitted, expected)}
+	}
+
+	got, ok := jsonFields(submitted)
+	if !ok {
+		return []FieldDiff{{Status: DiffMismatch, Submitted: submitted, Expected: expected, Note: "answer isn't a JSON object"}}
+	}
+	want, _ := jsonFields(expected)
+
+	keys := make(map[string]bool)
+	for key := range got {
+		keys[key] = true
+	}
+	for key := range want {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	var fields []FieldDiff
+	for _, key := range sorted {
+		g, inGot := got[key]
+		w, inWant := want[key]
+		switch {
+		case !inGot:
+			fields = append(fields, FieldDiff{Field: key, Status: DiffMissing, Expected: w})
+		case !inWant:
+			fields = append(fields, FieldDiff{Field: key, Status: DiffUnexpected, Submitted: g})
+		default:
+			fields = append(fields, diffValue(key, "", g, w))
+		}
+	}
+	return fields
+}
+
+// One value. Inside JSON there's no challenge type to normalize by, so
+// values only match case- and space-insensitively.
+func diffValue(field string, challengeType types.ChallengeType, submitted, expected string) FieldDiff {
+	f := FieldDiff{Field: field, Submitted: submitted, Expected: expected}
+	switch {
+	case submitted == expected:
+		f.Status = DiffMatch
+	case normalize.Answer(challengeType, submitted) == normalize.Answer(challengeType, expected):
+		f.Status = DiffFormat
+	default:
+		f.Status = DiffMismatch
+	}
+	return f
+}
+
+// Top-level fields of a JSON object as raw JSON, keyed lowercase like the
+// normalized answer. Null fields count as absent.
+func jsonFields(s string) (map[string]string, bool) {
+	var obj map[string]json.RawMessage
+	if json.Unmarshal([]byte(strings.TrimSpace(s)), &obj) != nil {
+		return nil, false
+	}
+	fields := make(map[string]string, len(obj))
+	for key, value := range obj {
+		if string(value) == "null" {
+			continue
+		}
+		fields[strings.ToLower(key)] = string(value)
+	}
+	return fields, true
+}
+
+func at(list []string, i int) string {
+	if i < len(list) {
+		return list[i]
+	}
+	return ""
+}
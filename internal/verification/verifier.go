@@ -1,141 +1,394 @@
 package verification
 
 import (
-	"encoding/json"
+	"crypto/sha256"
+	"errors"
 	"fmt"
-	"log"
-	"math/big"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/depinonbnb/depin/internal/challenge"
+	"github.com/depinonbnb/depin/internal/clientcert"
+	"github.com/depinonbnb/depin/internal/clock"
+	"github.com/depinonbnb/depin/internal/flags"
+	"github.com/depinonbnb/depin/internal/headerchain"
+	"github.com/depinonbnb/depin/internal/normalize"
+	"github.com/depinonbnb/depin/internal/push"
 	"github.com/depinonbnb/depin/internal/rpc"
+	"github.com/depinonbnb/depin/internal/scheduler"
+	"github.com/depinonbnb/depin/internal/servicestatus"
+	"github.com/depinonbnb/depin/internal/sharedcache"
+	"github.com/depinonbnb/depin/internal/signing"
+	"github.com/depinonbnb/depin/internal/tunnel"
 	"github.com/depinonbnb/depin/internal/types"
 )
 
+// Tagged for SavePending's file
 type pendingChallenge struct {
-	Challenge      *types.Challenge
-	ExpectedAnswer string
+	Challenge      *types.Challenge `json:"challenge"`
+	ExpectedAnswer string           `json:"expected_answer"`
+	NodeType       types.NodeType   `json:"node_type"`
+	Honeypot       bool             `json:"honeypot"` // Never revealed to the node
+	Surprise       bool             `json:"surprise"`
+	Commitment     string           `json:"commitment,omitempty"` // Commit-reveal: H(answer‖nonce), once committed
+	CommittedAt    int64            `json:"committed_at,omitempty"`
+	RequestedBy    string           `json:"requested_by,omitempty"` // Wallet that signed the request, "" for pushed challenges
+	Validated      bool             `json:"validated"`              // The operator has had their one dry run
+	BlockAge       *uint64          `json:"block_age,omitempty"`    // Blocks behind the trusted head when issued
 }
 
 type Verifier struct {
-	trustedRPC        *rpc.Client
-	generator         *challenge.Generator
-	pendingChallenges map[string]*pendingChallenge
-	mu                sync.RWMutex
+	trustedRPC          *rpc.Client
+	trustedOpbnb        *rpc.Client // Nil = opBNB challenges go to trustedRPC too
+	trustedGreenfield   *rpc.GreenfieldClient
+	headers             *headerchain.Chain // Nil = block hashes come from trustedRPC
+	network             types.Network
+	generator           *challenge.Generator
+	pendingChallenges   map[string]*pendingChallenge
+	answered            map[string]*answeredChallenge // Recent verdicts, for retried submits
+	held                map[string]*heldAnswer        // Answers waiting out maintenance
+	latencySuspiciousMs uint64
+	latencyMaxMs        uint64
+	flags               *flags.Flags
+	keys                *signing.Keyring
+	issued              *issuedStats
+	providers           *providerTracker
+	push                *push.Hub
+	tunnels             *tunnel.Broker
+	status              *servicestatus.Tracker
+	queue               *scheduler.Queue
+	certs               *clientcert.Sealer
+	clock               clock.Clock
+	charge              func(nodeID string, challengeType types.ChallengeType, now int64) bool // Nil = no budget
+	scorer              AnomalyScorer                                                          // Nil = RuleScorer only
+	shared              sharedcache.Cache                                                      // Nil = pending challenges stay in this process
+	mu                  sync.RWMutex
 }
 
+// A node asked for a challenge its daily budget has no room for
+var ErrBudgetExhausted = errors.New("daily challenge budget used up")
+
+// Flags have switched off every challenge type the node could get
+var ErrNoChallengeTypes = errors.New("no challenge types are enabled for this node")
+
+// Anti-cheat rules that can be rolled out behind flags
+const (
+	FlagLatencyRule     = "anticheat.latency-suspicious"
+	FlagHoneypot        = "anticheat.honeypot"
+	FlagProviderLatency = "anticheat.provider-latency"
+	FlagSurprise        = "anticheat.surprise-challenges"
+	FlagCommitReveal    = "anticheat.commit-reveal"
+	FlagAnswerV2        = "anticheat.answer-message-v2"
+	FlagExternalScorer  = "anticheat.external-scorer"
+)
+
 func NewVerifier(trustedRPCEndpoint string) *Verifier {
-	return &Verifier{
-		trustedRPC:        rpc.NewClient(trustedRPCEndpoint, ""),
-		generator:         challenge.NewGenerator(),
-		pendingChallenges: make(map[string]*pendingChallenge),
+	v := &Verifier{
+		trustedRPC:          rpc.NewTrustedClient(trustedRPCEndpoint),
+		generator:           challenge.NewGenerator(),
+		pendingChallenges:   make(map[string]*pendingChallenge),
+		answered:            make(map[string]*answeredChallenge),
+		held:                make(map[string]*heldAnswer),
+		latencySuspiciousMs: types.LatencySuspiciousMin,
+		latencyMaxMs:        types.LatencyMaxAllowed,
+		flags:               flags.New(),
+		issued:              newIssuedStats(),
+		providers:           newProviderTracker(),
+		keys:                signing.NewKeyring(DefaultKeyGrace),
+		push:                push.NewHub(),
+		tunnels:             tunnel.NewBroker(),
+		status:              servicestatus.New(time.Now().UnixMilli()),
+		queue:               scheduler.New(0),
+		network:             types.Mainnet,
+		clock:               clock.System{},
 	}
+
+	defineFlags(v.flags)
+	v.generator.SetFlags(v.flags)
+
+	return v
+}
+
+// Default rollout for every flag the verifier knows about
+func defineFlags(f *flags.Flags) {
+	f.Define(challenge.FlagName(types.BlockHash), "Issue block-hash challenges", 100)
+	f.Define(challenge.FlagName(types.BlockData), "Issue block-data challenges", 100)
+	f.Define(challenge.FlagName(types.StateBalance), "Issue state-balance challenges", 100)
+	f.Define(challenge.FlagName(types.SyncStatus), "Issue sync-status challenges", 100)
+	f.Define(challenge.FlagName(types.ObjectExists), "Issue object-exists challenges to Greenfield storage providers", 100)
+	f.Define(challenge.FlagName(types.ObjectChecksum), "Issue object-checksum challenges to Greenfield storage providers", 100)
+	f.Define(challenge.FlagName(types.Composite), "Sometimes bundle several queries into one composite challenge, credited per part", 0)
+	f.Define(FlagLatencyRule, "Mark passing answers over the suspicious latency threshold as suspicious", 100)
+	f.Define(FlagHoneypot, "Occasionally send deep-state challenges only archive nodes can answer, to catch proxies", 0)
+	f.Define(FlagProviderLatency, "Mark nodes whose answer latency tracks a public RPC provider's jitter as suspicious", 0)
+	f.Define(FlagSurprise, "Push short-lived challenges to local provers between their scheduled polls", 0)
+	f.Define(FlagCommitReveal, "Make local provers commit to a hash of their answer within seconds, and time the commit", 0)
+	f.Define(FlagAnswerV2, "Only accept answers signed with the v2 message, which names the node and challenge type", 0)
+	f.Define(FlagExternalScorer, "Score answers with the external anomaly scorer (ANOMALY_SCORER_URL) instead of the built-in rules", 0)
+}
+
+// Where the verifier and its challenge generator get the time. Set before
+// the verifier is in use.
+func (v *Verifier) SetClock(c clock.Clock) {
+	v.clock = c
+	v.generator.SetClock(c)
+}
+
+// Feature flags controlling challenge types and anti-cheat rules
+func (v *Verifier) Flags() *flags.Flags {
+	return v.flags
+}
+
+// How long a retired signing key stays published by default
+const DefaultKeyGrace = 7 * 24 * time.Hour
+
+// Server signing keys. While one is active, every challenge we issue is
+// signed so provers can prove what they were sent.
+func (v *Verifier) Keys() *signing.Keyring {
+	return v.keys
+}
+
+// The service's own availability and challenge issuance, minute by minute
+func (v *Verifier) ServiceStatus() *servicestatus.Tracker {
+	return v.status
+}
+
+// Count every challenge a node asks for against its daily budget (see
+// store.ChargeChallenge). A challenge charge turns down isn't issued.
+func (v *Verifier) SetChallengeBudget(charge func(nodeID string, challengeType types.ChallengeType, now int64) bool) {
+	v.mu.Lock()
+	v.charge = charge
+	v.mu.Unlock()
+}
+
+// Score answers with scorer where the anticheat.external-scorer flag is
+// on, nil to go back to the built-in rules (safe to call while serving)
+func (v *Verifier) SetAnomalyScorer(scorer AnomalyScorer) {
+	v.mu.Lock()
+	v.scorer = scorer
+	v.mu.Unlock()
+}
+
+// Change latency limits (safe to call while serving)
+func (v *Verifier) SetLatencyThresholds(suspiciousMs, maxMs uint64) {
+	v.mu.Lock()
+	v.latencySuspiciousMs = suspiciousMs
+	v.latencyMaxMs = maxMs
+	v.mu.Unlock()
+}
+
+// Switch the trusted BSC RPC (safe to call while serving). Challenges
+// already issued keep the answers the old one gave.
+func (v *Verifier) SetTrustedRPC(endpoint string) {
+	client := rpc.NewTrustedClient(endpoint)
+	v.mu.Lock()
+	v.trustedRPC = client
+	v.mu.Unlock()
+}
+
+// Answer opBNB challenges from a dedicated opBNB node. BSC and opBNB
+// are separate chains, so one trusted RPC can't serve both correctly.
+func (v *Verifier) SetTrustedOpbnbRPC(endpoint string) {
+	client := rpc.NewTrustedClient(endpoint).WithChain(types.ChainOpBNB)
+	v.mu.Lock()
+	v.trustedOpbnb = client
+	v.mu.Unlock()
+}
+
+// Which network nodes have to be on. Call before serving.
+func (v *Verifier) SetNetwork(network types.Network) {
+	v.mu.Lock()
+	v.network = network
+	v.mu.Unlock()
+	v.generator.SetNetwork(network)
+}
+
+// How challenge blocks are picked (see challenge.Sampling). Call before
+// serving.
+func (v *Verifier) SetBlockSampling(sampling challenge.Sampling) {
+	v.generator.SetSampling(sampling)
+}
+
+// Why an exposed node is on the wrong chain, "" if it isn't. A mainnet
+// node can't answer testnet challenges and a testnet node mustn't earn
+// mainnet points. Nodes that don't report a chain ID go on to the
+// challenge, which they'll fail anyway if they're on the wrong chain.
+func (v *Verifier) wrongChain(node *types.NodeRegistration) string {
+	v.mu.RLock()
+	network := v.network
+	v.mu.RUnlock()
+
+	want := node.NodeType.Chain().ChainID(network)
+	if node.NodeType.Chain() == types.ChainGreenfield {
+		return "" // SPs don't speak eth_chainId
+	}
+
+	got, _, err := v.rpcFor(node).GetChainID()
+	if err != nil || got == want {
+		return ""
+	}
+	return fmt.Sprintf("node is on chain %d, %s %s is chain %d", got, network, node.NodeType.Chain(), want)
+}
+
+// Answer BSC block-hash challenges from a header chain synced from
+// several RPCs, instead of asking the trusted RPC. Challenges then ask
+// about blocks in the header chain's window. Until it has synced, the
+// trusted RPC is still used. nil goes back to the trusted RPC.
+func (v *Verifier) SetHeaderChain(headers *headerchain.Chain) {
+	v.mu.Lock()
+	v.headers = headers
+	v.mu.Unlock()
+}
+
+// The header chain block-hash answers come from, nil if there isn't one
+func (v *Verifier) HeaderChain() *headerchain.Chain {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.headers
+}
+
+// The trusted RPC that knows the right answers for this node type
+func (v *Verifier) trustedFor(nodeType types.NodeType) *rpc.Client {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if nodeType.Chain() == types.ChainOpBNB && v.trustedOpbnb != nil {
+		return v.trustedOpbnb
+	}
+	return v.trustedRPC
 }
 
 // Create a challenge for a node
 // We query our trusted node first so we know the right answer
 func (v *Verifier) CreateChallenge(node *types.NodeRegistration) (*types.Challenge, error) {
-	ch := v.generator.GenerateChallenge(node.ID, node.NodeType)
+	return v.createChallenge(node, false, "")
+}
 
+// Create a challenge a wallet signed a request for. Only an answer signed
+// by the same wallet is accepted.
+func (v *Verifier) CreateChallengeFor(node *types.NodeRegistration, requester string) (*types.Challenge, error) {
+	return v.createChallenge(node, false, strings.ToLower(requester))
+}
+
+func (v *Verifier) createChallenge(node *types.NodeRegistration, surprise bool, requester string) (*types.Challenge, error) {
 	// Get the answer from our trusted node
-	response := v.trustedRPC.ExecuteChallenge(ch)
-	if !response.Success {
-		return nil, fmt.Errorf("failed to get expected answer: %s", response.Error)
+	ch, expected, honeypot, err := v.nextChallenge(node)
+	if err == ErrNoChallengeTypes {
+		return nil, err // Not the trusted node's fault
+	}
+	if err != nil {
+		v.status.RecordIssue(v.clock.Now().UnixMilli(), false)
+		return nil, fmt.Errorf("failed to get expected answer: %v", err)
+	}
+	if surprise {
+		ch.ExpiresAt = ch.CreatedAt + SurpriseExpiry.Milliseconds()
 	}
 
+	// Only challenges a node asked for come out of its budget
+	v.mu.RLock()
+	charge := v.charge
+	v.mu.RUnlock()
+	if requester != "" && charge != nil && !charge(node.ID, ch.ChallengeType, ch.CreatedAt) {
+		return nil, ErrBudgetExhausted
+	}
+	v.requireCommit(ch, node)
+
+	if key := v.keys.Active(); key != nil {
+		sig, err := key.Signer.Sign(signing.ChallengeMessage(ch))
+		if err != nil {
+			v.status.RecordIssue(ch.CreatedAt, false)
+			return nil, fmt.Errorf("failed to sign challenge: %v", err)
+		}
+		ch.Signature = sig
+		ch.KeyID = key.ID
+	}
+
+	blockAge := v.recordIssued(ch, node.NodeType)
+
 	// Store the challenge with its answer
 	v.mu.Lock()
 	v.pendingChallenges[ch.ID] = &pendingChallenge{
 		Challenge:      ch,
-		ExpectedAnswer: response.Data,
+		ExpectedAnswer: expected,
+		NodeType:       node.NodeType,
+		Honeypot:       honeypot,
+		Surprise:       surprise,
+		RequestedBy:    requester,
+		BlockAge:       blockAge,
 	}
 	v.mu.Unlock()
+	v.sharePending(ch.ID)
+
+	v.status.RecordIssue(ch.CreatedAt, true)
 
 	return ch, nil
 }
 
-// Check if a submitted answer is correct
+// Check if a submitted answer is correct. The answer goes through
+// answerChecks until one fails, then every answerScorer sees the verdict.
 func (v *Verifier) VerifyResponse(response *types.ChallengeResponse) *types.VerificationResult {
-	v.mu.RLock()
-	pending, exists := v.pendingChallenges[response.ChallengeID]
-	v.mu.RUnlock()
-
-	now := time.Now().UnixMilli()
+	return v.verifyAt(response, v.clock.Now().UnixMilli())
+}
 
-	// Challenge not found or already used
-	if !exists {
-		return &types.VerificationResult{
-			ChallengeID:    response.ChallengeID,
-			NodeID:         response.NodeID,
-			Passed:         false,
-			ResponseTimeMs: response.ResponseTimeMs,
-			FailureReason:  "challenge not found or expired",
-			Timestamp:      now,
-		}
+// Check an answer as if it arrived at now
+func (v *Verifier) verifyAt(response *types.ChallengeResponse, now int64) *types.VerificationResult {
+	if result := v.retriedAnswer(response, now); result != nil {
+		return result
 	}
+	v.adoptPending(response.ChallengeID)
 
-	// Too slow - challenges expire after 1 minute
-	if now > pending.Challenge.ExpiresAt {
-		v.deleteChallenge(response.ChallengeID)
-		return &types.VerificationResult{
-			ChallengeID:    response.ChallengeID,
-			NodeID:         response.NodeID,
-			Passed:         false,
-			ResponseTimeMs: response.ResponseTimeMs,
-			FailureReason:  "challenge expired",
-			Timestamp:      now,
+	a := v.newAnswer(response, now)
+	for _, check := range answerChecks {
+		if !check(v, a) {
+			break
 		}
 	}
 
-	// Does their answer match ours?
-	if !v.compareAnswers(response.Answer, pending.ExpectedAnswer, pending.Challenge.ChallengeType) {
+	if a.pending != nil {
 		v.deleteChallenge(response.ChallengeID)
-		return &types.VerificationResult{
-			ChallengeID:    response.ChallengeID,
-			NodeID:         response.NodeID,
-			Passed:         false,
-			ResponseTimeMs: response.ResponseTimeMs,
-			FailureReason:  "incorrect answer",
-			Timestamp:      now,
-		}
+		a.result.ChallengeType = a.pending.Challenge.ChallengeType
+		a.result.Surprise = a.pending.Surprise
+		a.result.BlockAge = a.pending.BlockAge
 	}
 
-	// Check if response time looks suspicious
-	if response.ResponseTimeMs > types.LatencyMaxAllowed {
-		v.deleteChallenge(response.ChallengeID)
-		return &types.VerificationResult{
-			ChallengeID:    response.ChallengeID,
-			NodeID:         response.NodeID,
-			Passed:         false,
-			ResponseTimeMs: response.ResponseTimeMs,
-			FailureReason:  "response too slow",
-			Suspicious:     true,
-			SuspiciousNote: "Response took too long - possible proxy or offline node",
-			Timestamp:      now,
-		}
+	for _, score := range answerScorers {
+		score(v, a)
+	}
+	if a.pending != nil {
+		v.rememberAnswer(a.result)
 	}
+	return a.result
+}
 
-	// They passed!
-	v.deleteChallenge(response.ChallengeID)
+// Answers bigger than this are stored as a hash in replays
+const maxReplayAnswerBytes = 1024
 
-	// Flag slow responses but still pass them (suspicious but not failed)
-	suspicious := response.ResponseTimeMs > types.LatencySuspiciousMin
-	suspiciousNote := ""
-	if suspicious {
-		suspiciousNote = fmt.Sprintf("High latency %dms - might be proxying to public RPC", response.ResponseTimeMs)
-		log.Printf("suspicious latency for node %s: %dms", response.NodeID, response.ResponseTimeMs)
+// What an admin needs to see why a challenge failed
+func newReplay(ch *types.Challenge, expected, submitted string) *types.ChallengeReplay {
+	return &types.ChallengeReplay{
+		Challenge:       *ch,
+		ExpectedAnswer:  replayAnswer(expected),
+		SubmittedAnswer: replayAnswer(submitted),
 	}
+}
 
-	return &types.VerificationResult{
-		ChallengeID:    response.ChallengeID,
-		NodeID:         response.NodeID,
-		Passed:         true,
-		ResponseTimeMs: response.ResponseTimeMs,
-		Suspicious:     suspicious,
-		SuspiciousNote: suspiciousNote,
-		Timestamp:      now,
+// Full answer if small, otherwise a hash and size so replays stay cheap
+func replayAnswer(answer string) string {
+	if len(answer) <= maxReplayAnswerBytes {
+		return answer
+	}
+	sum := sha256.Sum256([]byte(answer))
+	return fmt.Sprintf("sha256:%x (%d bytes)", sum, len(answer))
+}
+
+// How many entries the verifier's maps hold, for watching memory growth
+func (v *Verifier) Sizes() map[string]int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return map[string]int{
+		"pending_challenges": len(v.pendingChallenges),
+		"answered":           len(v.answered),
+		"held_answers":       len(v.held),
 	}
 }
 
@@ -145,44 +398,15 @@ func (v *Verifier) deleteChallenge(id string) {
 	v.mu.Unlock()
 }
 
-// Compare answers - different challenge types need different comparison
+// Compare answers in normalized form, so formatting differences between
+// clients (hex case, leading zeros, field order) don't count as wrong
 func (v *Verifier) compareAnswers(submitted, expected string, challengeType types.ChallengeType) bool {
-	submitted = strings.ToLower(strings.TrimSpace(submitted))
-	expected = strings.ToLower(strings.TrimSpace(expected))
-
-	switch challengeType {
-	case types.BlockHash:
-		// Block hashes should match exactly
-		return submitted == expected
-
-	case types.StateBalance:
-		// Balances can have different formatting so compare as numbers
-		subBig, ok1 := new(big.Int).SetString(strings.TrimPrefix(submitted, "0x"), 16)
-		expBig, ok2 := new(big.Int).SetString(strings.TrimPrefix(expected, "0x"), 16)
-		if ok1 && ok2 {
-			return subBig.Cmp(expBig) == 0
-		}
-		return submitted == expected
-
-	case types.BlockData, types.SyncStatus:
-		// JSON responses need to be parsed and compared
-		var subObj, expObj map[string]interface{}
-		if json.Unmarshal([]byte(submitted), &subObj) == nil &&
-			json.Unmarshal([]byte(expected), &expObj) == nil {
-			subJSON, _ := json.Marshal(subObj)
-			expJSON, _ := json.Marshal(expObj)
-			return string(subJSON) == string(expJSON)
-		}
-		return submitted == expected
-
-	default:
-		return submitted == expected
-	}
+	return normalize.Answer(challengeType, submitted) == normalize.Answer(challengeType, expected)
 }
 
 // For nodes that expose their RPC, we query them directly
 func (v *Verifier) VerifyExposedRPC(node *types.NodeRegistration) *types.VerificationResult {
-	now := time.Now().UnixMilli()
+	now := v.clock.Now().UnixMilli()
 
 	if node.RPCEndpoint == "" {
 		return &types.VerificationResult{
@@ -190,59 +414,86 @@ func (v *Verifier) VerifyExposedRPC(node *types.NodeRegistration) *types.Verific
 			NodeID:        node.ID,
 			Passed:        false,
 			FailureReason: "no RPC endpoint configured",
+			FailureKind:   types.FailureUnreachable,
 			Timestamp:     now,
 		}
 	}
 
-	nodeRPC := rpc.NewClient(node.RPCEndpoint, node.AuthToken)
-
-	// Generate a challenge
-	ch := v.generator.GenerateChallenge(node.ID, node.NodeType)
-
-	// Get the right answer from our trusted node
-	expectedResponse := v.trustedRPC.ExecuteChallenge(ch)
-	if !expectedResponse.Success {
+	if reason := v.wrongChain(node); reason != "" {
 		return &types.VerificationResult{
-			ChallengeID:   ch.ID,
+			ChallengeID:   fmt.Sprintf("direct-%d", now),
 			NodeID:        node.ID,
 			Passed:        false,
-			FailureReason: fmt.Sprintf("trusted node error: %s", expectedResponse.Error),
+			FailureReason: reason,
+			FailureKind:   types.FailureWrongAnswer,
 			Timestamp:     now,
 		}
 	}
 
-	// Now ask their node the same question
-	userResponse := nodeRPC.ExecuteChallenge(ch)
-	if !userResponse.Success {
+	nodeRPC := v.nodeClient(node)
+
+	// Generate a challenge and get the right answer from our trusted node
+	ch, expected, honeypot, err := v.nextChallenge(node)
+	if err == ErrNoChallengeTypes {
 		return &types.VerificationResult{
-			ChallengeID:    ch.ID,
-			NodeID:         node.ID,
-			Passed:         false,
-			ResponseTimeMs: userResponse.LatencyMs,
-			FailureReason:  userResponse.Error,
-			Timestamp:      now,
+			ChallengeID:   fmt.Sprintf("direct-%d", now),
+			NodeID:        node.ID,
+			Passed:        false,
+			FailureReason: err.Error(),
+			FailureKind:   types.FailureServerError,
+			Timestamp:     now,
 		}
 	}
-
-	// Do the answers match?
-	if !v.compareAnswers(userResponse.Data, expectedResponse.Data, ch.ChallengeType) {
+	if err != nil {
 		return &types.VerificationResult{
-			ChallengeID:    ch.ID,
-			NodeID:         node.ID,
-			Passed:         false,
-			ResponseTimeMs: userResponse.LatencyMs,
-			FailureReason:  "incorrect answer",
-			Timestamp:      now,
+			ChallengeID:   ch.ID,
+			NodeID:        node.ID,
+			Passed:        false,
+			FailureReason: fmt.Sprintf("trusted node error: %v", err),
+			FailureKind:   types.FailureServerError,
+			Timestamp:     now,
 		}
 	}
 
-	return &types.VerificationResult{
+	blockAge := v.recordIssued(ch, node.NodeType)
+
+	// Now ask their node the same question
+	userResponse := nodeRPC.ExecuteChallenge(ch)
+	result := &types.VerificationResult{
 		ChallengeID:    ch.ID,
 		NodeID:         node.ID,
 		Passed:         true,
 		ResponseTimeMs: userResponse.LatencyMs,
+		BlockAge:       blockAge,
 		Timestamp:      now,
 	}
+
+	if !userResponse.Success {
+		result.Passed = false
+		result.FailureReason = userResponse.Error
+		result.FailureKind = types.FailureUnreachable
+		result.Replay = newReplay(ch, expected, "")
+	} else if ch.ChallengeType == types.Composite {
+		result.Parts = v.gradeParts(ch, userResponse.Data, expected)
+		if reason := partsFailure(result.Parts); reason != "" {
+			result.Passed = false
+			result.FailureReason = reason
+			result.FailureKind = types.FailureWrongAnswer
+			result.Replay = newReplay(ch, expected, userResponse.Data)
+		}
+	} else if !v.compareAnswers(userResponse.Data, expected, ch.ChallengeType) {
+		// Do the answers match?
+		result.Passed = false
+		result.FailureReason = "incorrect answer"
+		result.FailureKind = types.FailureWrongAnswer
+		result.Replay = newReplay(ch, expected, userResponse.Data)
+	}
+
+	if honeypot {
+		gradeHoneypot(result, node.NodeType, userResponse.Success)
+	}
+	v.checkProviderLatency(result)
+	return result
 }
 
 // Quick check to see if a node is online and synced
@@ -250,8 +501,11 @@ func (v *Verifier) CheckHeartbeat(node *types.NodeRegistration) *types.Heartbeat
 	if node.RPCEndpoint == "" {
 		return nil
 	}
+	if node.NodeType.Chain() == types.ChainGreenfield {
+		return v.checkSPHeartbeat(node)
+	}
 
-	nodeRPC := rpc.NewClient(node.RPCEndpoint, node.AuthToken)
+	nodeRPC := v.rpcFor(node)
 
 	blockNum, latency, err := nodeRPC.GetBlockNumber()
 	if err != nil {
@@ -260,31 +514,54 @@ func (v *Verifier) CheckHeartbeat(node *types.NodeRegistration) *types.Heartbeat
 
 	synced, _, _ := nodeRPC.GetSyncStatus()
 	peerCount, _, _ := nodeRPC.GetPeerCount()
+	clientVersion, _, _ := nodeRPC.GetClientVersion()
 
 	return &types.HeartbeatRecord{
-		NodeID:      node.ID,
-		Timestamp:   time.Now().UnixMilli(),
-		BlockNumber: blockNum,
-		IsSynced:    synced,
-		LatencyMs:   latency,
-		PeersCount:  peerCount,
+		NodeID:        node.ID,
+		Timestamp:     v.clock.Now().UnixMilli(),
+		BlockNumber:   blockNum,
+		IsSynced:      synced,
+		LatencyMs:     latency,
+		PeersCount:    peerCount,
+		ClientVersion: clientVersion,
 	}
 }
 
+// Ask an exposed node what client it runs ("" if it won't say). Used to
+// check readiness ahead of hard forks between heartbeats.
+func (v *Verifier) CheckClientVersion(node *types.NodeRegistration) string {
+	if node.RPCEndpoint == "" || node.NodeType.Chain() == types.ChainGreenfield {
+		return ""
+	}
+	version, _, err := v.rpcFor(node).GetClientVersion()
+	if err != nil {
+		return ""
+	}
+	return version
+}
+
 // Remove old challenges that nobody answered
 func (v *Verifier) CleanupExpiredChallenges() int {
-	now := time.Now().UnixMilli()
+	now := v.clock.Now().UnixMilli()
 	cleaned := 0
 
 	v.mu.Lock()
 	defer v.mu.Unlock()
 
 	for id, pending := range v.pendingChallenges {
+		if _, held := v.held[id]; held {
+			continue
+		}
 		if now > pending.Challenge.ExpiresAt {
 			delete(v.pendingChallenges, id)
 			cleaned++
 		}
 	}
+	for id, answered := range v.answered {
+		if now-answered.at > RetryWindow.Milliseconds() {
+			delete(v.answered, id)
+		}
+	}
 
 	return cleaned
 }
@@ -1,10 +1,27 @@
 package verification
 
 import (
+	"encoding/hex"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/depinonbnb/depin/internal/challenge"
+	"github.com/depinonbnb/depin/internal/clock"
+	"github.com/depinonbnb/depin/internal/headerchain"
+	"github.com/depinonbnb/depin/internal/mockchain"
+	"github.com/depinonbnb/depin/internal/rpc"
+	"github.com/depinonbnb/depin/internal/scheduler"
+	"github.com/depinonbnb/depin/internal/sharedcache"
+	"github.com/depinonbnb/depin/internal/signing"
 	"github.com/depinonbnb/depin/internal/types"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 func TestNewVerifier(t *testing.T) {
@@ -290,9 +307,1147 @@ func TestVerifyResponseChallengeDeleted(t *testing.T) {
 		t.Error("first response should pass")
 	}
 
-	// Second response should fail (challenge deleted)
+	if _, pending := v.pendingChallenges["test-challenge"]; pending {
+		t.Error("challenge should be deleted after use")
+	}
+
+	// A retry gets the same verdict back, marked so it isn't recorded twice
 	result2 := v.VerifyResponse(response)
-	if result2.Passed {
-		t.Error("second response should fail - challenge should be deleted after use")
+	if !result2.Passed || !result2.Retry || result2.Timestamp != result.Timestamp {
+		t.Errorf("retry should repeat the first verdict, got %+v", result2)
+	}
+
+	// Only for the node the challenge was for
+	other := *response
+	other.NodeID = "other-node"
+	if result3 := v.VerifyResponse(&other); result3.Passed || result3.Retry {
+		t.Error("another node shouldn't get the verdict back")
+	}
+
+	// And only for a while
+	v.mu.Lock()
+	v.answered["test-challenge"].at -= RetryWindow.Milliseconds() + 1
+	v.mu.Unlock()
+	if result4 := v.VerifyResponse(response); result4.Passed {
+		t.Error("a retry after the window should fail - challenge was used")
+	}
+	v.CleanupExpiredChallenges()
+	if len(v.answered) != 0 {
+		t.Error("cleanup should drop verdicts past the retry window")
+	}
+}
+
+func TestCreateChallengeTrustedRPCDown(t *testing.T) {
+	server := httptest.NewServer(mockchain.New(46000000))
+	defer server.Close()
+
+	v := NewVerifier(server.URL)
+	v.trustedRPC.SetFaults(rpc.FaultConfig{DropPercent: 100})
+
+	node := &types.NodeRegistration{ID: "test-node", NodeType: types.BscFull}
+	if _, err := v.CreateChallenge(node); err == nil {
+		t.Error("expected error when trusted RPC drops calls")
+	}
+
+	if len(v.pendingChallenges) != 0 {
+		t.Error("no challenge should be pending when the expected answer is unknown")
+	}
+}
+
+func TestVerifyExposedRPCCorruptNode(t *testing.T) {
+	server := httptest.NewServer(mockchain.New(46000000))
+	defer server.Close()
+
+	v := NewVerifier(server.URL)
+	node := &types.NodeRegistration{
+		ID:          "test-node",
+		NodeType:    types.BscFast,
+		RPCEndpoint: server.URL,
+	}
+
+	// Same chain on both sides - should pass
+	result := v.VerifyExposedRPC(node)
+	if !result.Passed {
+		t.Fatalf("expected pass against matching chain, got: %s", result.FailureReason)
+	}
+
+	// Corrupt every trusted answer - the honest node should now fail
+	v.trustedRPC.SetFaults(rpc.FaultConfig{CorruptPercent: 100})
+	result = v.VerifyExposedRPC(node)
+	if result.Passed {
+		t.Error("expected failure when trusted answers are corrupted")
+	}
+}
+
+func TestOpbnbTrustedRPC(t *testing.T) {
+	bsc := httptest.NewServer(mockchain.New(46000000))
+	defer bsc.Close()
+
+	opbnb := mockchain.New(46000000)
+	opbnb.SetChainID(204)
+	opbnb.SetHeadTime(time.Now())
+	opbnbServer := httptest.NewServer(opbnb)
+	defer opbnbServer.Close()
+
+	v := NewVerifier(bsc.URL)
+	v.SetTrustedOpbnbRPC(opbnbServer.URL)
+	v.trustedRPC.SetFaults(rpc.FaultConfig{DropPercent: 100})
+
+	// Sync status is the check that differs between the chains
+	for _, ct := range []types.ChallengeType{types.BlockHash, types.BlockData, types.StateBalance} {
+		v.Flags().Set(challenge.FlagName(ct), 0)
+	}
+
+	node := &types.NodeRegistration{
+		ID:          "test-node",
+		NodeType:    types.OpbnbFull,
+		RPCEndpoint: opbnbServer.URL,
+	}
+	if result := v.VerifyExposedRPC(node); !result.Passed {
+		t.Fatalf("opBNB node should be checked against the opBNB RPC, got: %s", result.FailureReason)
+	}
+
+	// op-geth still says it isn't syncing, but the head stopped moving
+	stalled := mockchain.New(46000000)
+	stalled.SetChainID(204)
+	stalled.SetHeadTime(time.Now().Add(-2 * rpc.OpbnbMaxHeadAge))
+	stalledServer := httptest.NewServer(stalled)
+	defer stalledServer.Close()
+
+	node.RPCEndpoint = stalledServer.URL
+	if result := v.VerifyExposedRPC(node); result.Passed {
+		t.Error("stalled opBNB node should fail its sync check")
+	}
+}
+
+func TestHeaderChainAnswers(t *testing.T) {
+	chain := mockchain.New(46000000)
+	server := httptest.NewServer(chain)
+	defer server.Close()
+
+	headers := headerchain.FromEndpoints([]string{server.URL, server.URL}, 2)
+	for headers.Sync() > 0 {
+	}
+
+	v := NewVerifier(server.URL)
+	v.SetHeaderChain(headers)
+	v.trustedRPC.SetFaults(rpc.FaultConfig{DropPercent: 100})
+	for _, ct := range []types.ChallengeType{types.BlockData, types.StateBalance, types.SyncStatus} {
+		v.Flags().Set(challenge.FlagName(ct), 0)
+	}
+
+	node := &types.NodeRegistration{ID: "test-node", NodeType: types.BscFull}
+	ch, err := v.CreateChallenge(node)
+	if err != nil {
+		t.Fatalf("block-hash challenges shouldn't need the trusted RPC: %v", err)
+	}
+	from, to, _ := headers.Range()
+	if number := *ch.Params.BlockNumber; number < from || number > to {
+		t.Errorf("challenge block %d is outside the header chain window %d-%d", number, from, to)
+	}
+
+	result := v.VerifyResponse(&types.ChallengeResponse{
+		ChallengeID: ch.ID,
+		NodeID:      node.ID,
+		Answer:      mockchain.BlockHash(*ch.Params.BlockNumber),
+	})
+	if !result.Passed {
+		t.Errorf("right hash should pass against the header chain, got: %s", result.FailureReason)
+	}
+}
+
+// With every type a node can get rolled back to 0%, nothing is issued
+// rather than a type the admin switched off
+func TestAllChallengeTypesFlaggedOff(t *testing.T) {
+	server := httptest.NewServer(mockchain.New(46000000))
+	defer server.Close()
+
+	v := NewVerifier(server.URL)
+	for _, ct := range types.BscFull.ChallengeTypes() {
+		v.Flags().Set(challenge.FlagName(ct), 0)
+	}
+
+	node := &types.NodeRegistration{ID: "test-node", NodeType: types.BscFull, RPCEndpoint: server.URL}
+	if ch := v.generator.GenerateChallenge(node.ID, node.NodeType); ch != nil {
+		t.Errorf("expected no challenge, got a %s one", ch.ChallengeType)
+	}
+	if _, err := v.CreateChallenge(node); err != ErrNoChallengeTypes {
+		t.Errorf("expected ErrNoChallengeTypes, got %v", err)
+	}
+	if result := v.VerifyExposedRPC(node); result.Passed || result.FailureKind != types.FailureServerError {
+		t.Errorf("expected a server-side failure, not one held against the node, got %+v", result)
+	}
+	if v.IssuedStats().Total != 0 {
+		t.Error("nothing should count as issued")
+	}
+
+	// Archive nodes still have state-balance left on
+	if ch := v.generator.GenerateChallenge("archive", types.BscArchive); ch == nil || ch.ChallengeType != types.StateBalance {
+		t.Errorf("expected a state-balance challenge for an archive node, got %+v", ch)
+	}
+}
+
+func TestVerifyExposedRPCWrongNetwork(t *testing.T) {
+	server := httptest.NewServer(mockchain.New(46000000)) // Mainnet chain ID
+	defer server.Close()
+
+	v := NewVerifier(server.URL)
+	v.SetNetwork(types.Testnet)
+	node := &types.NodeRegistration{
+		ID:          "test-node",
+		NodeType:    types.BscFull,
+		RPCEndpoint: server.URL,
+	}
+
+	result := v.VerifyExposedRPC(node)
+	if result.Passed || !strings.Contains(result.FailureReason, "chain 56") {
+		t.Errorf("mainnet node should fail on a testnet server, got passed=%v: %s", result.Passed, result.FailureReason)
+	}
+}
+
+func TestGreenfieldStorageProvider(t *testing.T) {
+	trusted := mockchain.NewSP()
+	trusted.AddObject("depin-bucket", "file.bin")
+	trustedServer := httptest.NewServer(trusted)
+	defer trustedServer.Close()
+
+	honest := mockchain.NewSP()
+	honest.AddObject("depin-bucket", "file.bin")
+	honestServer := httptest.NewServer(honest)
+	defer honestServer.Close()
+
+	v := NewVerifier("http://127.0.0.1:0")
+	v.SetTrustedGreenfieldSP(trustedServer.URL)
+	node := &types.NodeRegistration{
+		ID:          "test-sp",
+		NodeType:    types.GreenfieldSP,
+		RPCEndpoint: honestServer.URL,
+	}
+
+	if result := v.VerifyExposedRPC(node); result.Passed {
+		t.Error("SP shouldn't pass with no objects configured to ask about")
+	}
+
+	v.SetGreenfieldObjects([]string{"depin-bucket/file.bin"})
+	for i := 0; i < 20; i++ {
+		if result := v.VerifyExposedRPC(node); !result.Passed {
+			t.Fatalf("honest SP failed: %s", result.FailureReason)
+		}
+	}
+
+	if heartbeat := v.CheckHeartbeat(node); heartbeat == nil || !heartbeat.IsSynced {
+		t.Errorf("expected a synced heartbeat from a reachable SP, got %+v", heartbeat)
+	}
+
+	// An SP that dropped the data can't produce its checksum
+	honest.RemoveObject("depin-bucket", "file.bin")
+	v.Flags().Set(challenge.FlagName(types.ObjectExists), 0)
+	if result := v.VerifyExposedRPC(node); result.Passed {
+		t.Error("SP that lost the object should fail a checksum challenge")
+	}
+}
+
+func TestLatencyRuleBehindFlag(t *testing.T) {
+	v := NewVerifier("https://bsc-dataseed1.binance.org")
+	v.Flags().Set(FlagLatencyRule, 0)
+
+	v.mu.Lock()
+	v.pendingChallenges["test-challenge"] = &pendingChallenge{
+		Challenge: &types.Challenge{
+			ID:        "test-challenge",
+			NodeID:    "test-node",
+			ExpiresAt: time.Now().UnixMilli() + 60000,
+		},
+		ExpectedAnswer: "correct-answer",
+	}
+	v.mu.Unlock()
+
+	result := v.VerifyResponse(&types.ChallengeResponse{
+		ChallengeID:    "test-challenge",
+		NodeID:         "test-node",
+		Answer:         "correct-answer",
+		ResponseTimeMs: 200,
+		Timestamp:      time.Now().UnixMilli(),
+	})
+
+	if !result.Passed {
+		t.Error("should pass")
+	}
+	if result.Suspicious {
+		t.Error("latency rule is off - should not be marked suspicious")
+	}
+}
+
+func TestChallengeTypeRolledBack(t *testing.T) {
+	v := NewVerifier("https://bsc-dataseed1.binance.org")
+	v.Flags().Set("challenge.state-balance", 0)
+	v.Flags().Set("challenge.block-data", 0)
+
+	for i := 0; i < 50; i++ {
+		ch := v.generator.GenerateChallenge("test-node", types.BscArchive)
+		if ch.ChallengeType == types.StateBalance || ch.ChallengeType == types.BlockData {
+			t.Fatalf("generated disabled challenge type %s", ch.ChallengeType)
+		}
+	}
+}
+
+func TestCreateChallengeSigned(t *testing.T) {
+	server := httptest.NewServer(mockchain.New(46000000))
+	defer server.Close()
+
+	key, _ := crypto.GenerateKey()
+	signer, _ := signing.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+
+	v := NewVerifier(server.URL)
+	v.Keys().Rotate(signer)
+
+	node := &types.NodeRegistration{ID: "test-node", NodeType: types.BscFull}
+	ch, err := v.CreateChallenge(node)
+	if err != nil {
+		t.Fatalf("create challenge failed: %v", err)
+	}
+
+	if !signing.Verify(signing.ChallengeMessage(ch), ch.Signature, signer.Address()) {
+		t.Error("challenge signature should verify against the server key")
+	}
+}
+
+func TestBlockAgeBucket(t *testing.T) {
+	tests := []struct {
+		head, block uint64
+		want        string
+	}{
+		{0, 100, blockAgeUnknown},
+		{100, 200, blockAgeUnknown},
+		{46000000, 45999500, "0-1k"},
+		{46000000, 45950000, "1k-100k"},
+		{46000000, 45500000, "100k-1m"},
+		{46000000, 40000000, "1m-10m"},
+		{46000000, 1000000, "10m+"},
+	}
+
+	for _, tt := range tests {
+		if got := blockAgeBucket(tt.head, tt.block); got != tt.want {
+			t.Errorf("blockAgeBucket(%d, %d) = %s, want %s", tt.head, tt.block, got, tt.want)
+		}
+	}
+}
+
+func TestIssuedStats(t *testing.T) {
+	server := httptest.NewServer(mockchain.New(46000000))
+	defer server.Close()
+
+	v := NewVerifier(server.URL)
+	node := &types.NodeRegistration{ID: "test-node", NodeType: types.BscFull}
+
+	for i := 0; i < 10; i++ {
+		if _, err := v.CreateChallenge(node); err != nil {
+			t.Fatalf("create challenge failed: %v", err)
+		}
+	}
+
+	stats := v.IssuedStats()
+	if stats.Total != 10 {
+		t.Errorf("expected 10 issued, got %d", stats.Total)
+	}
+
+	var byType, byAge uint64
+	for _, n := range stats.ByType {
+		byType += n
+	}
+	for _, n := range stats.BlockAgeRange {
+		byAge += n
+	}
+	if byType != 10 {
+		t.Errorf("type counts should add up to 10, got %d", byType)
+	}
+	if byAge != 10-stats.ByType[types.SyncStatus] {
+		t.Errorf("every block challenge should be bucketed, got %d", byAge)
+	}
+	if stats.BlockAgeRange[blockAgeUnknown] != 0 {
+		t.Error("head is known - no challenge should be unknown age")
+	}
+
+	// Each challenge remembers its own age, for the feature export
+	for _, pending := range v.pendingChallenges {
+		if (pending.Challenge.Params.BlockNumber != nil) != (pending.BlockAge != nil) {
+			t.Errorf("%s challenge: block age %v for block %v", pending.Challenge.ChallengeType, pending.BlockAge, pending.Challenge.Params.BlockNumber)
+		}
+	}
+}
+
+// A trusted RPC that's down is asked for the head once per headCacheTTL,
+// not once per challenge
+func TestIssuedHeadFailure(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	v := NewVerifier(server.URL)
+	fake := clock.NewFake(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	v.SetClock(fake)
+
+	block := uint64(100)
+	ch := &types.Challenge{ChallengeType: types.BlockHash, Params: types.ChallengeParams{BlockNumber: &block}}
+	for i := 0; i < 5; i++ {
+		if age := v.recordIssued(ch, types.BscFull); age != nil {
+			t.Fatalf("expected no age without a head, got %d", *age)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected one head read for 5 challenges, got %d", n)
+	}
+
+	fake.Advance(headCacheTTL + time.Second)
+	v.recordIssued(ch, types.BscFull)
+	if n := calls.Load(); n != 2 {
+		t.Errorf("expected another try once the TTL passed, got %d reads", n)
+	}
+	if stats := v.IssuedStats(); stats.BlockAgeRange[blockAgeUnknown] != 6 {
+		t.Errorf("expected every challenge counted as unknown age, got %+v", stats.BlockAgeRange)
+	}
+}
+
+func TestVerifyResponseFailureKinds(t *testing.T) {
+	v := NewVerifier("https://bsc-dataseed1.binance.org")
+
+	addPending := func(id string, expiresAt int64) {
+		v.mu.Lock()
+		v.pendingChallenges[id] = &pendingChallenge{
+			Challenge:      &types.Challenge{ID: id, NodeID: "test-node", ExpiresAt: expiresAt},
+			ExpectedAnswer: "correct-answer",
+		}
+		v.mu.Unlock()
+	}
+
+	future := time.Now().UnixMilli() + 60000
+	addPending("expired", time.Now().UnixMilli()-1000)
+	addPending("wrong", future)
+	addPending("slow", future)
+
+	tests := []struct {
+		challengeID string
+		answer      string
+		latency     uint64
+		want        types.FailureKind
+	}{
+		{"missing", "correct-answer", 50, types.FailureExpired},
+		{"expired", "correct-answer", 50, types.FailureExpired},
+		{"wrong", "wrong-answer", 50, types.FailureWrongAnswer},
+		{"slow", "correct-answer", 6000, types.FailureTooSlow},
+	}
+
+	for _, tt := range tests {
+		result := v.VerifyResponse(&types.ChallengeResponse{
+			ChallengeID:    tt.challengeID,
+			NodeID:         "test-node",
+			Answer:         tt.answer,
+			ResponseTimeMs: tt.latency,
+		})
+		if result.FailureKind != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.challengeID, tt.want, result.FailureKind)
+		}
+	}
+}
+
+func TestWrongAnswerKeepsReplay(t *testing.T) {
+	v := NewVerifier("https://bsc-dataseed1.binance.org")
+
+	block := uint64(12345)
+	v.mu.Lock()
+	v.pendingChallenges["test-challenge"] = &pendingChallenge{
+		Challenge: &types.Challenge{
+			ID:            "test-challenge",
+			NodeID:        "test-node",
+			ChallengeType: types.BlockHash,
+			ExpiresAt:     time.Now().UnixMilli() + 60000,
+			Params:        types.ChallengeParams{BlockNumber: &block},
+		},
+		ExpectedAnswer: "0xexpected",
+	}
+	v.mu.Unlock()
+
+	result := v.VerifyResponse(&types.ChallengeResponse{
+		ChallengeID: "test-challenge",
+		NodeID:      "test-node",
+		Answer:      "0xsubmitted",
+	})
+
+	if result.Replay == nil {
+		t.Fatal("failed verification should carry a replay")
+	}
+	if result.Replay.ExpectedAnswer != "0xexpected" || result.Replay.SubmittedAnswer != "0xsubmitted" {
+		t.Errorf("unexpected answers in replay: %+v", result.Replay)
+	}
+	if *result.Replay.Challenge.Params.BlockNumber != block {
+		t.Error("replay should keep the challenge params")
+	}
+}
+
+func TestReplayAnswerHashesLargePayloads(t *testing.T) {
+	if got := replayAnswer("0xsmall"); got != "0xsmall" {
+		t.Errorf("small answers should be kept as is, got %s", got)
+	}
+
+	large := strings.Repeat("a", maxReplayAnswerBytes+1)
+	got := replayAnswer(large)
+	if !strings.HasPrefix(got, "sha256:") || !strings.HasSuffix(got, "(1025 bytes)") {
+		t.Errorf("large answers should be hashed, got %s", got)
+	}
+}
+
+func TestHoneypotGrading(t *testing.T) {
+	trusted := httptest.NewServer(mockchain.New(46000000))
+	defer trusted.Close()
+
+	pruned := mockchain.New(46000000)
+	pruned.SetStateHistory(128)
+	prunedServer := httptest.NewServer(pruned)
+	defer prunedServer.Close()
+
+	archiveServer := httptest.NewServer(mockchain.New(46000000))
+	defer archiveServer.Close()
+
+	tests := []struct {
+		name           string
+		nodeType       types.NodeType
+		endpoint       string
+		wantPassed     bool
+		wantSuspicious bool
+	}{
+		{"honest full node can't answer", types.BscFull, prunedServer.URL, true, false},
+		{"full node proxying to an archive", types.BscFull, archiveServer.URL, true, true},
+		{"honest archive node", types.BscArchive, archiveServer.URL, true, false},
+		{"archive node proxying to a pruned RPC", types.BscArchive, prunedServer.URL, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVerifier(trusted.URL)
+			node := &types.NodeRegistration{ID: "test-node", NodeType: tt.nodeType, RPCEndpoint: tt.endpoint}
+
+			ch := v.generator.GenerateHoneypot(node.ID, node.NodeType)
+			expected := v.trustedRPC.ExecuteChallenge(ch)
+			answer := rpc.NewClient(tt.endpoint, "").ExecuteChallenge(ch)
+
+			result := &types.VerificationResult{Passed: answer.Success && answer.Data == expected.Data}
+			if !answer.Success {
+				result.FailureKind = types.FailureUnreachable
+			}
+			gradeHoneypot(result, node.NodeType, answer.Success)
+
+			if result.Passed != tt.wantPassed || result.Suspicious != tt.wantSuspicious {
+				t.Errorf("got passed=%v suspicious=%v (%s), want passed=%v suspicious=%v",
+					result.Passed, result.Suspicious, result.SuspiciousNote, tt.wantPassed, tt.wantSuspicious)
+			}
+		})
+	}
+}
+
+func TestHoneypotsDontFailHonestNodes(t *testing.T) {
+	trusted := httptest.NewServer(mockchain.New(46000000))
+	defer trusted.Close()
+
+	pruned := mockchain.New(46000000)
+	pruned.SetStateHistory(128)
+	prunedServer := httptest.NewServer(pruned)
+	defer prunedServer.Close()
+
+	v := NewVerifier(trusted.URL)
+	v.Flags().Set(FlagHoneypot, 100)
+	node := &types.NodeRegistration{ID: "test-node", NodeType: types.BscFull, RPCEndpoint: prunedServer.URL}
+
+	// Enough rounds that some of them are honeypots
+	for i := 0; i < 50; i++ {
+		if result := v.VerifyExposedRPC(node); !result.Passed || result.Suspicious {
+			t.Fatalf("honest full node penalised: %s %s", result.FailureReason, result.SuspiciousNote)
+		}
+	}
+}
+
+func TestHoneypotsOffByDefault(t *testing.T) {
+	server := httptest.NewServer(mockchain.New(46000000))
+	defer server.Close()
+
+	v := NewVerifier(server.URL)
+	node := &types.NodeRegistration{ID: "test-node", NodeType: types.BscFast}
+
+	for i := 0; i < 50; i++ {
+		if _, _, honeypot, _ := v.nextChallenge(node); honeypot {
+			t.Fatal("honeypot issued while the flag is off")
+		}
+	}
+}
+
+func TestProviderLatencyTracking(t *testing.T) {
+	v := NewVerifier("https://bsc-dataseed1.binance.org")
+	v.Flags().Set(FlagProviderLatency, 100)
+
+	// Two providers with different jitter, probed every 30s
+	start := time.Now().UnixMilli()
+	jitter := func(i int, period int) uint64 { return uint64(200 + (i%period)*40) }
+	for i := 0; i < 40; i++ {
+		at := start + int64(i)*30000
+		v.providers.recordProbe("rpc.proxied.example", at, jitter(i, 7))
+		v.providers.recordProbe("rpc.other.example", at, jitter(i, 3))
+	}
+
+	var proxy, honest *types.VerificationResult
+	for i := 0; i < providerCheckEvery*2; i++ {
+		at := start + int64(i)*30000 + 2000
+
+		// Provider latency plus a steady hop to the node
+		proxy = &types.VerificationResult{NodeID: "proxy", Passed: true, ResponseTimeMs: jitter(i, 7) + 15, Timestamp: at}
+		v.checkProviderLatency(proxy)
+
+		// Local node, steady and unrelated
+		honest = &types.VerificationResult{NodeID: "honest", Passed: true, ResponseTimeMs: uint64(20 + i%2), Timestamp: at}
+		v.checkProviderLatency(honest)
+	}
+
+	if !proxy.Suspicious || !strings.Contains(proxy.SuspiciousNote, "rpc.proxied.example") {
+		t.Errorf("expected proxy to match rpc.proxied.example, got %q", proxy.SuspiciousNote)
+	}
+	if honest.Suspicious {
+		t.Errorf("honest node marked suspicious: %s", honest.SuspiciousNote)
+	}
+}
+
+func TestCorrelation(t *testing.T) {
+	tests := []struct {
+		xs, ys []float64
+		want   float64
+	}{
+		{[]float64{1, 2, 3}, []float64{10, 20, 30}, 1},
+		{[]float64{1, 2, 3}, []float64{30, 20, 10}, -1},
+		{[]float64{1, 2, 3}, []float64{5, 5, 5}, 0}, // Flat series has no jitter to track
+		{nil, nil, 0},
+	}
+
+	for _, tt := range tests {
+		if got := correlation(tt.xs, tt.ys); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("correlation(%v, %v) = %f, want %f", tt.xs, tt.ys, got, tt.want)
+		}
+	}
+}
+
+func TestParseChaindbSize(t *testing.T) {
+	leveldb := `Compactions
+ Level |   Tables   |    Size(MB)   |    Time(sec)  |    Read(MB)   |   Write(MB)
+-------+------------+---------------+---------------+---------------+---------------
+   0   |          2 |     150.00000 |       1.20000 |       0.00000 |     150.00000
+   1   |         50 |    2850.00000 |      10.00000 |     500.00000 |     500.00000
+   2   |        900 | 4997000.00000 |     100.00000 |       0.00000 |       0.00000
+`
+	pebble := `      |                             |       |       |   ingested   |     moved    |    written   |       |    amp
+level | tables  size val-bl vtables | score |   in  | tables  size | tables  size | tables  size |  read |   r   w
+------+-----------------------------+-------+-------+--------------+--------------+--------------+-------+---------
+    0 |     0     0B     0B       0 |  0.00 |   0B  |     0     0B |     0     0B |     0     0B |    0B |   0  0.0
+    6 | 12000  1.8TB     0B       0 |     - | 10GB  |     0     0B |     0     0B |  9000 1.8TB |    0B |   1  1.0
+total | 12000  1.8TB     0B       0 |     - | 10GB  |     0     0B |     0     0B |  9000 1.8TB |    0B |   1  1.0
+`
+
+	tests := []struct {
+		name   string
+		stats  string
+		wantGB float64
+		wantOK bool
+	}{
+		{"leveldb", leveldb, 5000, true},
+		{"pebble", pebble, 1800, true},
+		{"garbage", "no stats here", 0, false},
+	}
+
+	for _, tt := range tests {
+		gb, ok := parseChaindbSize(tt.stats)
+		if ok != tt.wantOK || math.Abs(gb-tt.wantGB) > 1 {
+			t.Errorf("%s: got %.1fGB ok=%v, want %.1fGB ok=%v", tt.name, gb, ok, tt.wantGB, tt.wantOK)
+		}
+	}
+}
+
+func TestCheckStorage(t *testing.T) {
+	trusted := httptest.NewServer(mockchain.New(46000000))
+	defer trusted.Close()
+
+	archive := mockchain.New(46000000)
+	archive.SetDatabaseSize(9e12)
+	archiveServer := httptest.NewServer(archive)
+	defer archiveServer.Close()
+
+	small := mockchain.New(46000000)
+	small.SetDatabaseSize(300e9)
+	smallServer := httptest.NewServer(small)
+	defer smallServer.Close()
+
+	pruned := mockchain.New(46000000)
+	pruned.SetStateHistory(128)
+	prunedServer := httptest.NewServer(pruned)
+	defer prunedServer.Close()
+
+	tests := []struct {
+		name     string
+		nodeType types.NodeType
+		endpoint string
+		want     types.StorageVerdict
+	}{
+		{"real archive", types.BscArchive, archiveServer.URL, types.StorageOK},
+		{"archive claim on a small database", types.BscArchive, smallServer.URL, types.StorageTooSmall},
+		{"archive claim without old state", types.BscArchive, prunedServer.URL, types.StorageNoDeepState},
+		{"full node isn't judged", types.BscFull, prunedServer.URL, types.StorageOK},
+	}
+
+	v := NewVerifier(trusted.URL)
+	for _, tt := range tests {
+		report := v.CheckStorage(&types.NodeRegistration{ID: "test-node", NodeType: tt.nodeType, RPCEndpoint: tt.endpoint})
+		if report.Verdict != tt.want {
+			t.Errorf("%s: got %s (%s), want %s", tt.name, report.Verdict, report.Note, tt.want)
+		}
+	}
+}
+
+func TestProposeNodeType(t *testing.T) {
+	gb := func(n float64) *float64 { return &n }
+	yes, no := true, false
+
+	tests := []struct {
+		name    string
+		current types.NodeType
+		report  types.StorageReport
+		want    types.NodeType // "" = no proposal
+	}{
+		{"fast node serving deep state", types.BscFast, types.StorageReport{DeepState: &yes}, types.BscArchive},
+		{"full node serving deep state", types.BscFull, types.StorageReport{DeepState: &yes, ChainDataGB: gb(1500)}, types.BscArchive},
+		{"fast node with a full-sized database", types.BscFast, types.StorageReport{DeepState: &no, ChainDataGB: gb(1500)}, types.BscFull},
+		{"full node with a fast-sized database", types.BscFull, types.StorageReport{DeepState: &no, ChainDataGB: gb(300)}, types.BscFast},
+		{"archive without deep state", types.BscArchive, types.StorageReport{DeepState: &no, ChainDataGB: gb(1500)}, types.BscFull},
+		{"archive that couldn't be probed", types.BscArchive, types.StorageReport{ChainDataGB: gb(1500)}, ""},
+		{"full node as expected", types.BscFull, types.StorageReport{DeepState: &no, ChainDataGB: gb(1500)}, ""},
+		{"nothing to go on", types.BscFast, types.StorageReport{}, ""},
+		{"opbnb fast with a full-sized database", types.OpbnbFast, types.StorageReport{ChainDataGB: gb(800)}, types.OpbnbFull},
+		{"opbnb never proposed archive", types.OpbnbFull, types.StorageReport{DeepState: &yes, ChainDataGB: gb(800)}, ""},
+		{"storage providers aren't reclassified", types.GreenfieldSP, types.StorageReport{ChainDataGB: gb(10)}, ""},
+	}
+
+	for _, tt := range tests {
+		got, reason, ok := ProposeNodeType(tt.current, &tt.report)
+		if ok != (tt.want != "") || got != tt.want {
+			t.Errorf("%s: got %q (%s), want %q", tt.name, got, reason, tt.want)
+		}
+	}
+}
+
+func TestSurpriseDue(t *testing.T) {
+	base := types.NodeRegistration{
+		VerificationMethod: types.LocalProver,
+		IsActive:           true,
+		LastPolledAt:       1000000,
+		PollIntervalMs:     300000,
+	}
+
+	tests := []struct {
+		name   string
+		modify func(n *types.NodeRegistration)
+		now    int64
+		want   bool
+	}{
+		{"halfway between polls", func(n *types.NodeRegistration) {}, 1150000, true},
+		{"just polled", func(n *types.NodeRegistration) {}, 1010000, false},
+		{"about to poll", func(n *types.NodeRegistration) {}, 1290000, false},
+		{"no cadence yet", func(n *types.NodeRegistration) { n.PollIntervalMs = 0 }, 1150000, false},
+		{"surprise outstanding", func(n *types.NodeRegistration) { n.Surprise.PendingID = "x" }, 1150000, false},
+		{"exposed rpc", func(n *types.NodeRegistration) { n.VerificationMethod = types.ExposedRPC }, 1150000, false},
+		{"paused", func(n *types.NodeRegistration) { n.Paused = true }, 1150000, false},
+	}
+
+	for _, tt := range tests {
+		node := base
+		tt.modify(&node)
+		if got := surpriseDue(&node, tt.now); got != tt.want {
+			t.Errorf("%s: surpriseDue = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSurpriseChallenges(t *testing.T) {
+	server := httptest.NewServer(mockchain.New(46000000))
+	defer server.Close()
+
+	v := NewVerifier(server.URL)
+	now := time.Now().UnixMilli()
+	node := &types.NodeRegistration{
+		ID:                 "test-node",
+		NodeType:           types.BscFull,
+		VerificationMethod: types.LocalProver,
+		IsActive:           true,
+		LastPolledAt:       now - 150000,
+		PollIntervalMs:     300000,
+	}
+
+	nodes := make([]*types.NodeRegistration, 200)
+	for i := range nodes {
+		nodes[i] = node
+	}
+
+	if issued := v.IssueSurprises(nodes, now); len(issued) != 0 {
+		t.Fatal("surprise issued while the flag is off")
+	}
+
+	v.Flags().Set(FlagSurprise, 100)
+	stream, unsubscribe := v.Push().Subscribe(node.ID)
+	defer unsubscribe()
+
+	issued := v.IssueSurprises(nodes, now)
+	if len(issued) == 0 {
+		t.Fatal("expected some surprises across 200 eligible rounds")
+	}
+
+	ch := <-stream
+	if ch.ExpiresAt-ch.CreatedAt != SurpriseExpiry.Milliseconds() {
+		t.Errorf("expected a %v expiry, got %dms", SurpriseExpiry, ch.ExpiresAt-ch.CreatedAt)
+	}
+
+	result := v.VerifyResponse(&types.ChallengeResponse{ChallengeID: ch.ID, NodeID: node.ID, Answer: "wrong", Timestamp: now})
+	if !result.Surprise {
+		t.Error("result for a pushed challenge should be marked as a surprise")
+	}
+}
+
+func TestCommitReveal(t *testing.T) {
+	v := NewVerifier("https://bsc-dataseed1.binance.org")
+
+	addPending := func(id string, commitBy int64) {
+		now := time.Now().UnixMilli()
+		v.mu.Lock()
+		v.pendingChallenges[id] = &pendingChallenge{
+			Challenge: &types.Challenge{
+				ID:            id,
+				NodeID:        "test-node",
+				ChallengeType: types.BlockHash,
+				CreatedAt:     now - 100,
+				ExpiresAt:     now + 60000,
+				CommitBy:      commitBy,
+			},
+			ExpectedAnswer: "0xcorrect",
+		}
+		v.mu.Unlock()
+	}
+
+	future := time.Now().UnixMilli() + 60000
+	addPending("honest", future)
+	addPending("swapped", future)
+	addPending("uncommitted", future)
+	addPending("late", time.Now().UnixMilli()-1)
+	addPending("direct", 0)
+
+	if err := v.CommitAnswer("honest", "other-node", signing.Commitment("0xcorrect", "n1")); err != ErrChallengeNotFound {
+		t.Errorf("another node's commit: expected ErrChallengeNotFound, got %v", err)
+	}
+	if err := v.CommitAnswer("honest", "test-node", signing.Commitment("0xcorrect", "n1")); err != nil {
+		t.Fatalf("commit failed: %v", err)
+	}
+	if err := v.CommitAnswer("honest", "test-node", signing.Commitment("0xother", "n1")); err != ErrAlreadyCommitted {
+		t.Errorf("second commit: expected ErrAlreadyCommitted, got %v", err)
+	}
+	if err := v.CommitAnswer("swapped", "test-node", signing.Commitment("0xwrong", "n2")); err != nil {
+		t.Fatalf("commit failed: %v", err)
+	}
+	if err := v.CommitAnswer("late", "test-node", signing.Commitment("0xcorrect", "n3")); err != ErrCommitTooLate {
+		t.Errorf("late commit: expected ErrCommitTooLate, got %v", err)
+	}
+	if err := v.CommitAnswer("direct", "test-node", signing.Commitment("0xcorrect", "n4")); err != ErrCommitNotRequired {
+		t.Errorf("direct challenge: expected ErrCommitNotRequired, got %v", err)
+	}
+
+	tests := []struct {
+		challengeID string
+		answer      string
+		nonce       string
+		passed      bool
+		kind        types.FailureKind
+	}{
+		{"honest", "0xcorrect", "n1", true, ""},
+		{"swapped", "0xcorrect", "n2", false, types.FailureWrongAnswer},
+		{"uncommitted", "0xcorrect", "", false, types.FailureTooSlow},
+		{"late", "0xcorrect", "n3", false, types.FailureTooSlow},
+		{"direct", "0xcorrect", "", true, ""},
+	}
+
+	for _, tt := range tests {
+		result := v.VerifyResponse(&types.ChallengeResponse{
+			ChallengeID:    tt.challengeID,
+			NodeID:         "test-node",
+			Answer:         tt.answer,
+			Nonce:          tt.nonce,
+			ResponseTimeMs: 1, // Self-reported, ignored for commit-reveal
+		})
+		if result.Passed != tt.passed || result.FailureKind != tt.kind {
+			t.Errorf("%s: passed=%v kind=%q, want passed=%v kind=%q", tt.challengeID, result.Passed, result.FailureKind, tt.passed, tt.kind)
+		}
+		if tt.challengeID == "honest" && result.ResponseTimeMs < 100 {
+			t.Errorf("commit latency should be measured from challenge creation, got %dms", result.ResponseTimeMs)
+		}
+	}
+}
+
+func TestCommitRevealRollout(t *testing.T) {
+	trusted := httptest.NewServer(mockchain.New(46000000))
+	defer trusted.Close()
+
+	v := NewVerifier(trusted.URL)
+	prover := &types.NodeRegistration{ID: "prover", NodeType: types.BscFull, VerificationMethod: types.LocalProver}
+
+	ch, err := v.CreateChallenge(prover)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ch.CommitBy != 0 {
+		t.Error("commit-reveal should be off by default")
+	}
+
+	v.Flags().Set(FlagCommitReveal, 100)
+	ch, err = v.CreateChallenge(prover)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ch.CommitBy != ch.CreatedAt+CommitWindow.Milliseconds() {
+		t.Errorf("expected a commit deadline %s after creation, got %d", CommitWindow, ch.CommitBy-ch.CreatedAt)
+	}
+
+	exposed := &types.NodeRegistration{ID: "exposed", NodeType: types.BscFull, VerificationMethod: types.ExposedRPC}
+	ch, err = v.CreateChallenge(exposed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ch.CommitBy != 0 {
+		t.Error("exposed nodes are timed by the server and shouldn't commit")
+	}
+}
+
+func TestValidateAnswer(t *testing.T) {
+	v := NewVerifier("https://bsc-dataseed1.binance.org")
+	a := testAnswer(v, "", 0)
+	a.pending.Challenge.ChallengeType = types.BlockData
+	a.pending.ExpectedAnswer = `{"hash":"0xab","parentHash":"0xcd","stateRoot":"0xef"}`
+
+	// Admins see the expected values and can try as often as they like
+	diff, err := v.ValidateAnswer("test-challenge", "", `{"hash":"0xAB","parentHash":"0x00","l1InfoTx":"0x1"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]DiffStatus{
+		"hash":       DiffFormat,
+		"l1infotx":   DiffUnexpected,
+		"parenthash": DiffMismatch,
+		"stateroot":  DiffMissing,
+	}
+	if diff.Passed || len(diff.Fields) != len(want) {
+		t.Fatalf("unexpected diff: %+v", diff)
+	}
+	for _, f := range diff.Fields {
+		if f.Status != want[f.Field] {
+			t.Errorf("%s: status %s, want %s", f.Field, f.Status, want[f.Field])
+		}
+	}
+	if diff.Fields[2].Expected != `"0xcd"` {
+		t.Errorf("admins should see the expected value, got %q", diff.Fields[2].Expected)
+	}
+
+	diff, _ = v.ValidateAnswer("test-challenge", "", `{"stateRoot":"0xEF","hash":"0xab","parentHash":"0xcd"}`)
+	if !diff.Passed {
+		t.Errorf("answer that differs only in format should pass: %+v", diff.Fields)
+	}
+
+	// Operators get one try at their own node's challenge, blind
+	if _, err := v.ValidateAnswer("test-challenge", "other-node", "{}"); err != ErrChallengeNotFound {
+		t.Errorf("another node's challenge should be not found, got %v", err)
+	}
+	diff, err = v.ValidateAnswer("test-challenge", "test-node", `{"hash":"0xab"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range diff.Fields {
+		if f.Expected != "" {
+			t.Errorf("operators shouldn't see expected values, got %q for %s", f.Expected, f.Field)
+		}
+	}
+	if _, err := v.ValidateAnswer("test-challenge", "test-node", `{"hash":"0xab"}`); err != ErrAlreadyValidated {
+		t.Errorf("expected a second try to be refused, got %v", err)
+	}
+
+	// And the challenge is still there to answer
+	if _, ok := v.pendingChallenges["test-challenge"]; !ok {
+		t.Error("validating shouldn't use the challenge up")
+	}
+}
+
+func TestWorkPriority(t *testing.T) {
+	now := time.Now().UnixMilli()
+	day := int64(24 * 60 * 60 * 1000)
+	tests := []struct {
+		name  string
+		node  types.NodeRegistration
+		class scheduler.Class
+	}{
+		{"trusted veteran", types.NodeRegistration{RegisteredAt: now - 60*day, Trust: types.TrustScore{Score: 90}}, scheduler.High},
+		{"trusted but young", types.NodeRegistration{RegisteredAt: now - 10*day, Trust: types.TrustScore{Score: 90}}, scheduler.Normal},
+		{"old, low trust", types.NodeRegistration{RegisteredAt: now - 60*day, Trust: types.TrustScore{Score: 40}}, scheduler.Normal},
+		{"brand new", types.NodeRegistration{RegisteredAt: now - day, Trust: types.TrustScore{Score: 90}}, scheduler.Low},
+		{"flagged veteran", types.NodeRegistration{RegisteredAt: now - 60*day, Trust: types.TrustScore{Score: 90}, CheatStatus: types.StatusFlagged}, scheduler.Low},
+	}
+	for _, tt := range tests {
+		class, score := WorkPriority(&tt.node, now)
+		if class != tt.class || score != int(tt.node.Trust.Score) {
+			t.Errorf("%s: got %s/%d, want %s", tt.name, class, score, tt.class)
+		}
+	}
+}
+
+func TestChallengeExpiryOnFakeClock(t *testing.T) {
+	server := httptest.NewServer(mockchain.New(46000000))
+	defer server.Close()
+
+	v := NewVerifier(server.URL)
+	fake := clock.NewFake(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	v.SetClock(fake)
+
+	node := &types.NodeRegistration{ID: "test-node", NodeType: types.BscFull}
+	ch, err := v.CreateChallenge(node)
+	if err != nil {
+		t.Fatalf("create challenge failed: %v", err)
+	}
+	if ch.CreatedAt != fake.Now().UnixMilli() || ch.ExpiresAt != ch.CreatedAt+60000 {
+		t.Fatalf("expected the challenge timed by the fake clock, got %d-%d", ch.CreatedAt, ch.ExpiresAt)
+	}
+
+	// A minute on the dot is still in time
+	fake.Advance(time.Minute)
+	if cleaned := v.CleanupExpiredChallenges(); cleaned != 0 {
+		t.Fatalf("expected nothing expired yet, cleaned %d", cleaned)
+	}
+
+	fake.Advance(time.Millisecond)
+	result := v.VerifyResponse(&types.ChallengeResponse{ChallengeID: ch.ID, NodeID: node.ID, Answer: "x", ResponseTimeMs: 50})
+	if result.Passed || result.FailureKind != types.FailureExpired || result.Timestamp != fake.Now().UnixMilli() {
+		t.Errorf("expected an expired failure at the fake time, got %+v", result)
+	}
+}
+
+func TestPendingSurvivesRestart(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	now := fake.Now().UnixMilli()
+	path := filepath.Join(t.TempDir(), "pending.json")
+
+	old := NewVerifier("https://bsc-dataseed1.binance.org")
+	old.SetClock(fake)
+	old.pendingChallenges["live"] = &pendingChallenge{
+		Challenge:      &types.Challenge{ID: "live", NodeID: "test-node", ExpiresAt: now + 30000},
+		ExpectedAnswer: "correct-answer",
+		RequestedBy:    "0xabc",
+	}
+	old.pendingChallenges["soon"] = &pendingChallenge{Challenge: &types.Challenge{ID: "soon", ExpiresAt: now + 1000}}
+	old.pendingChallenges["gone"] = &pendingChallenge{Challenge: &types.Challenge{ID: "gone", ExpiresAt: now - 1}}
+
+	if saved, err := old.SavePending(path); err != nil || saved != 2 {
+		t.Fatalf("expected 2 saved, got %d (%v)", saved, err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("the file holds answers, expected 0600, got %v", info.Mode().Perm())
+	}
+
+	// The deploy takes a few seconds
+	fake.Advance(5 * time.Second)
+	next := NewVerifier("https://bsc-dataseed1.binance.org")
+	next.SetClock(fake)
+	if restored, err := next.LoadPending(path); err != nil || restored != 1 {
+		t.Fatalf("expected only the unexpired challenge restored, got %d (%v)", restored, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected the file removed once loaded")
+	}
+
+	result := next.VerifyResponse(&types.ChallengeResponse{ChallengeID: "live", NodeID: "test-node", Answer: "correct-answer", ResponseTimeMs: 50, Wallet: "0xabc"})
+	if !result.Passed {
+		t.Errorf("expected the restored challenge to be answerable, got %s", result.FailureReason)
+	}
+
+	if restored, err := next.LoadPending(path); err != nil || restored != 0 {
+		t.Errorf("a missing file should restore nothing without error, got %d (%v)", restored, err)
+	}
+}
+
+func TestHeldAnswers(t *testing.T) {
+	v := NewVerifier("https://bsc-dataseed1.binance.org")
+	fake := clock.NewFake(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	v.SetClock(fake)
+
+	now := fake.Now().UnixMilli()
+	v.mu.Lock()
+	v.pendingChallenges["test-challenge"] = &pendingChallenge{
+		Challenge:      &types.Challenge{ID: "test-challenge", NodeID: "test-node", ExpiresAt: now + 60000},
+		ExpectedAnswer: "correct-answer",
+	}
+	v.mu.Unlock()
+
+	answer := &types.ChallengeResponse{ChallengeID: "test-challenge", NodeID: "test-node", Answer: "correct-answer", ResponseTimeMs: 50}
+	if v.HoldAnswer(&types.ChallengeResponse{ChallengeID: "test-challenge", NodeID: "other-node"}, now) {
+		t.Error("an answer from another node shouldn't be held")
+	}
+	if v.HoldAnswer(&types.ChallengeResponse{ChallengeID: "unknown", NodeID: "test-node"}, now) {
+		t.Error("an answer to an unknown challenge shouldn't be held")
+	}
+	if !v.HoldAnswer(answer, now+1000) || v.HeldAnswers() != 1 {
+		t.Fatal("expected the answer held")
+	}
+
+	// Maintenance runs well past the challenge's expiry
+	fake.Advance(time.Hour)
+	if cleaned := v.CleanupExpiredChallenges(); cleaned != 0 {
+		t.Fatalf("a challenge with a held answer shouldn't be cleaned up, cleaned %d", cleaned)
+	}
+
+	results := v.VerifyHeld()
+	if len(results) != 1 || !results[0].Passed || results[0].Timestamp != now+1000 {
+		t.Fatalf("expected the held answer to pass as of when it arrived, got %+v", results)
+	}
+	if v.HeldAnswers() != 0 || len(v.VerifyHeld()) != 0 {
+		t.Error("held answers should only be checked once")
+	}
+}
+
+func TestSharedPendingChallenges(t *testing.T) {
+	cache := sharedcache.NewMemory()
+	issuer := NewVerifier("https://bsc-dataseed1.binance.org")
+	other := NewVerifier("https://bsc-dataseed1.binance.org")
+	issuer.SetSharedCache(cache)
+	other.SetSharedCache(cache)
+
+	issuer.mu.Lock()
+	issuer.pendingChallenges["test-challenge"] = &pendingChallenge{
+		Challenge:      &types.Challenge{ID: "test-challenge", NodeID: "test-node", ExpiresAt: time.Now().UnixMilli() + 60000},
+		ExpectedAnswer: "correct-answer",
+	}
+	issuer.mu.Unlock()
+	issuer.sharePending("test-challenge")
+
+	// Someone else's answer doesn't use it up
+	if result := other.VerifyResponse(&types.ChallengeResponse{ChallengeID: "test-challenge", NodeID: "other-node", Answer: "correct-answer"}); result.Passed {
+		t.Fatal("another node's answer shouldn't pass")
+	}
+
+	answer := &types.ChallengeResponse{ChallengeID: "test-challenge", NodeID: "test-node", Answer: "correct-answer", ResponseTimeMs: 50}
+	if result := other.VerifyResponse(answer); !result.Passed {
+		t.Fatalf("expected the answer to pass on a server that didn't issue the challenge: %s", result.FailureReason)
+	}
+
+	// The issuer still has it in memory, but it's been claimed
+	if result := issuer.VerifyResponse(answer); result.Passed || result.FailureKind != types.FailureExpired {
+		t.Errorf("expected the challenge used up everywhere, got %+v", result)
+	}
+	if issuer.Sizes()["pending_challenges"] != 0 {
+		t.Error("expected the issuer to forget the claimed challenge")
 	}
 }